@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// announceWebhookEnvVar overrides announce.webhook, so the URL can stay out
+// of config files
+const announceWebhookEnvVar = "SNAP_ANNOUNCE_WEBHOOK"
+
+// maxHighlights caps the commits listed in an announcement
+const maxHighlights = 5
+
+// announceTimeout bounds the webhook request
+const announceTimeout = 10 * time.Second
+
+// Announcement is the summary of a release posted to a chat webhook
+type Announcement struct {
+	Repo       string
+	Version    string
+	Previous   string // Previous tag, empty for the first release
+	Commits    int
+	Highlights []string // Breaking changes, features and fixes, at most maxHighlights
+	More       int      // Highlights left out
+	CompareURL string
+	TagURL     string
+}
+
+// announceWebhook returns the webhook to announce releases to, or "" when
+// announcements are off
+func announceWebhook() string {
+	if webhook := os.Getenv(announceWebhookEnvVar); webhook != "" {
+		return webhook
+	}
+	return config.Announce.Webhook
+}
+
+// announceFormat returns the configured message format, or guesses it from
+// the webhook's host
+func announceFormat(webhook string) string {
+	if config.Announce.Format != "" {
+		return config.Announce.Format
+	}
+	switch {
+	case strings.Contains(webhook, "discord.com/") || strings.Contains(webhook, "discordapp.com/"):
+		return "discord"
+	case strings.Contains(webhook, ".office.com/") || strings.Contains(webhook, ".logic.azure.com"):
+		return "teams"
+	default:
+		return "slack"
+	}
+}
+
+// BuildAnnouncement summarizes a pushed release. Links are added when the
+// remote is on a known forge.
+func BuildAnnouncement(tagName, previousTag string, commits []CommitWithStats) Announcement {
+	a := Announcement{Version: tagName, Previous: previousTag, Commits: len(commits)}
+
+	// Breaking changes first, then features and fixes
+	var breaking, features, fixes []string
+	for _, c := range commits {
+		conventional, ok := ParseConventionalCommit(c.Message, "")
+		switch {
+		case !ok:
+		case conventional.Breaking:
+			breaking = append(breaking, "⚠️ "+conventional.Description)
+		case conventional.Type == "feat":
+			features = append(features, conventional.Description)
+		case conventional.Type == "fix":
+			fixes = append(fixes, conventional.Description)
+		}
+	}
+	highlights := append(append(breaking, features...), fixes...)
+	if len(highlights) > maxHighlights {
+		a.More = len(highlights) - maxHighlights
+		highlights = highlights[:maxHighlights]
+	}
+	a.Highlights = highlights
+
+	if forge, err := GetForge(); err == nil {
+		a.Repo = forge.BaseURL[strings.LastIndex(forge.BaseURL, "/")+1:]
+		a.TagURL = forge.TagURL(tagName)
+		if previousTag != "" {
+			a.CompareURL = forge.CompareURL(previousTag, tagName)
+		}
+	} else if root, err := GetRepoRoot(); err == nil {
+		a.Repo = filepath.Base(root)
+	}
+	return a
+}
+
+// Render formats the announcement as chat markdown. Slack uses its own
+// mrkdwn syntax for bold text and links.
+func (a Announcement) Render(format string) string {
+	bold := func(s string) string { return "**" + s + "**" }
+	link := func(label, url string) string { return fmt.Sprintf("[%s](%s)", label, url) }
+	if format == "slack" {
+		bold = func(s string) string { return "*" + s + "*" }
+		link = func(label, url string) string { return fmt.Sprintf("<%s|%s>", url, label) }
+	}
+
+	var b strings.Builder
+	title := a.Version
+	if a.Repo != "" {
+		title = a.Repo + " " + a.Version
+	}
+	if a.TagURL != "" {
+		title = link(title, a.TagURL)
+	}
+	b.WriteString("🚀 " + bold(title) + " released")
+	switch {
+	case a.Previous != "":
+		fmt.Fprintf(&b, " - %d commits since %s", a.Commits, a.Previous)
+	case a.Commits > 0:
+		fmt.Fprintf(&b, " - %d commits", a.Commits)
+	}
+	b.WriteString("\n")
+
+	for _, highlight := range a.Highlights {
+		b.WriteString("• " + highlight + "\n")
+	}
+	if a.More > 0 {
+		fmt.Fprintf(&b, "• ... and %d more\n", a.More)
+	}
+	if a.CompareURL != "" {
+		b.WriteString(link(fmt.Sprintf("Compare %s...%s", a.Previous, a.Version), a.CompareURL) + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// PostAnnouncement sends the announcement to the webhook in the given format
+func PostAnnouncement(webhook, format string, a Announcement) error {
+	text := a.Render(format)
+	var payload any
+	switch format {
+	case "discord":
+		payload = map[string]string{"content": text}
+	case "teams":
+		// Teams renders markdown, but needs blank lines between paragraphs
+		payload = map[string]string{"text": strings.ReplaceAll(text, "\n", "\n\n")}
+	default:
+		payload = map[string]any{"text": text, "unfurl_links": false}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: announceTimeout}
+	resp, err := client.Post(webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestBuildAnnouncement(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	exec.Command("git", "remote", "add", "origin", "git@github.com:acme/rocket.git").Run()
+
+	commits := []CommitWithStats{
+		{Message: "fix: crash on empty config"},
+		{Message: "docs: typo"},
+		{Message: "feat(api)!: drop v1 endpoints"},
+		{Message: "feat: dark mode"},
+		{Message: "fix: slow startup"},
+		{Message: "feat: export to CSV"},
+		{Message: "fix: wrong totals"},
+	}
+	a := BuildAnnouncement("v1.2.0", "v1.1.0", commits)
+	want := []string{"⚠️ drop v1 endpoints", "dark mode", "export to CSV", "crash on empty config", "slow startup"}
+	if strings.Join(a.Highlights, "|") != strings.Join(want, "|") || a.More != 1 {
+		t.Errorf("Unexpected highlights %q (+%d)", a.Highlights, a.More)
+	}
+	if a.Repo != "rocket" || a.CompareURL != "https://github.com/acme/rocket/compare/v1.1.0...v1.2.0" {
+		t.Errorf("Unexpected repo %q or compare URL %q", a.Repo, a.CompareURL)
+	}
+
+	slack := a.Render("slack")
+	if !strings.HasPrefix(slack, "🚀 *<https://github.com/acme/rocket/releases/tag/v1.2.0|rocket v1.2.0>* released - 7 commits since v1.1.0\n") {
+		t.Errorf("Unexpected Slack title in %q", slack)
+	}
+	if !strings.Contains(slack, "• ... and 1 more\n<https://github.com/acme/rocket/compare/v1.1.0...v1.2.0|Compare v1.1.0...v1.2.0>") {
+		t.Errorf("Expected the compare link at the end, got %q", slack)
+	}
+	if discord := a.Render("discord"); !strings.Contains(discord, "[Compare v1.1.0...v1.2.0](https://github.com/acme/rocket/compare/v1.1.0...v1.2.0)") {
+		t.Errorf("Expected a markdown compare link, got %q", discord)
+	}
+
+	// The first release has nothing to compare with
+	if first := BuildAnnouncement("v0.1.0", "", commits[:1]); first.CompareURL != "" || strings.Contains(first.Render("slack"), "since") {
+		t.Errorf("Unexpected first release announcement %q", first.Render("slack"))
+	}
+}
+
+func TestPostAnnouncement(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	a := Announcement{Version: "v1.0.0", Commits: 2, Highlights: []string{"dark mode"}}
+	if err := PostAnnouncement(server.URL, "discord", a); err != nil {
+		t.Fatalf("PostAnnouncement failed: %v", err)
+	}
+	if got["content"] != a.Render("discord") {
+		t.Errorf("Expected the rendered text as Discord content, got %v", got)
+	}
+	if err := PostAnnouncement(server.URL, "slack", a); err != nil {
+		t.Fatalf("PostAnnouncement failed: %v", err)
+	}
+	if got["text"] != a.Render("slack") {
+		t.Errorf("Expected the rendered text as Slack text, got %v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+	if err := PostAnnouncement(failing.URL, "slack", a); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected the webhook's status in the error, got %v", err)
+	}
+}
+
+func TestAnnounceFormat(t *testing.T) {
+	defer applyConfig(config)
+	applyConfig(defaultConfig())
+
+	cases := map[string]string{
+		"https://hooks.slack.com/services/T0/B0/x":         "slack",
+		"https://discord.com/api/webhooks/1/x":             "discord",
+		"https://acme.webhook.office.com/webhookb2/x":      "teams",
+		"https://prod-01.westus.logic.azure.com/workflows": "teams",
+	}
+	for webhook, want := range cases {
+		if got := announceFormat(webhook); got != want {
+			t.Errorf("announceFormat(%q) = %q, want %q", webhook, got, want)
+		}
+	}
+
+	cfg := defaultConfig()
+	cfg.Announce.Format = "discord"
+	applyConfig(cfg)
+	if got := announceFormat("https://hooks.slack.com/services/T0/B0/x"); got != "discord" {
+		t.Errorf("Expected announce.format to win, got %q", got)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// browserCommand returns the command that opens url in the default browser
+func browserCommand(goos, url string) (*exec.Cmd, error) {
+	switch goos {
+	case "darwin":
+		return exec.Command("open", url), nil
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url), nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return exec.Command("xdg-open", url), nil
+	default:
+		return nil, fmt.Errorf("opening a browser is not supported on %s", goos)
+	}
+}
+
+// OpenBrowser opens url in the default browser without waiting for it to exit
+func OpenBrowser(url string) error {
+	cmd, err := browserCommand(runtime.GOOS, url)
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open browser: %w", err)
+	}
+	// Reap the opener process in the background
+	go cmd.Wait()
+	return nil
+}
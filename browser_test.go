@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBrowserCommand(t *testing.T) {
+	tests := []struct {
+		goos     string
+		expected string
+		wantErr  bool
+	}{
+		{goos: "darwin", expected: "open https://example.com"},
+		{goos: "linux", expected: "xdg-open https://example.com"},
+		{goos: "windows", expected: "rundll32 url.dll,FileProtocolHandler https://example.com"},
+		{goos: "plan9", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			cmd, err := browserCommand(tt.goos, "https://example.com")
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error for unsupported platform")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("browserCommand failed: %v", err)
+			}
+			if result := strings.Join(cmd.Args, " "); result != tt.expected {
+				t.Errorf("browserCommand(%q) = %q, want %q", tt.goos, result, tt.expected)
+			}
+		})
+	}
+}
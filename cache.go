@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// aiCacheTTL is how long cached AI results are kept
+const aiCacheTTL = 7 * 24 * time.Hour
+
+// aiCacheDir returns the directory of cached AI results, honoring
+// $XDG_CACHE_HOME
+func aiCacheDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "snap", "ai"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".cache", "snap", "ai"), nil
+}
+
+// aiCacheKey hashes what an AI result depends on: its kind, the provider and
+// model, and the input (the diff or prompt and the seed). Generation is seeded,
+// so the same key would produce the same result again.
+func aiCacheKey(kind string, parts ...string) string {
+	hash := sha256.New()
+	for _, part := range append([]string{kind, config.AI.Provider, config.Model()}, parts...) {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return kind + "-" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// loadCached reads a cached result into v. It reports false when ai.cache is
+// off or there is no fresh entry.
+func loadCached(key string, v any) bool {
+	dir, err := aiCacheDir()
+	if err != nil || !config.AI.Cache {
+		return false
+	}
+	path := filepath.Join(dir, key)
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > aiCacheTTL {
+		return false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(content, v) == nil
+}
+
+// saveCached stores a result and drops expired entries. Failures are ignored;
+// the cache only saves time.
+func saveCached(key string, v any) {
+	dir, err := aiCacheDir()
+	if err != nil || !config.AI.Cache {
+		return
+	}
+	content, err := json.Marshal(v)
+	if err != nil || os.MkdirAll(dir, 0700) != nil {
+		return
+	}
+	os.WriteFile(filepath.Join(dir, key), content, 0600)
+	pruneAICache(dir)
+}
+
+// pruneAICache removes entries older than aiCacheTTL
+func pruneAICache(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > aiCacheTTL {
+			os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAICache(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		fmt.Fprint(w, `{"response": "feat: add caching", "done": true}`)
+	}))
+	defer server.Close()
+
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+	defer applyConfig(config)
+	cfg := defaultConfig()
+	cfg.Ollama.URL = server.URL
+	applyConfig(cfg)
+
+	generate := func(diff string, seed int) (string, string) {
+		var streamed strings.Builder
+		candidates, _, err := GenerateCommitMessageCandidates(context.Background(), diff, seed, 1, func(token string) {
+			streamed.WriteString(token)
+		}, nil)
+		if err != nil {
+			t.Fatalf("GenerateCommitMessageCandidates failed: %v", err)
+		}
+		return candidates[0], streamed.String()
+	}
+	largeDiff := "diff --git a/a.go b/a.go\n+" + strings.Repeat("x", 2500) + "\n"
+
+	generate(largeDiff, 42)
+	if requests.Load() != 2 {
+		t.Fatalf("Expected a summary and a message request, got %d", requests.Load())
+	}
+
+	// Same diff and seed: summary and message come from the cache
+	message, streamed := generate(largeDiff, 42)
+	if requests.Load() != 2 {
+		t.Errorf("Expected no requests for a cached diff, got %d", requests.Load()-2)
+	}
+	if message != "feat(a): add caching" || streamed != "feat: add caching" {
+		t.Errorf("Expected the cached message to be shown, got %q (streamed %q)", message, streamed)
+	}
+
+	// Summaries are seeded too, so another seed asks for both again
+	generate(largeDiff, 7)
+	if requests.Load() != 4 {
+		t.Errorf("Expected new requests for another seed, got %d", requests.Load()-2)
+	}
+
+	// Expired entries are ignored and removed
+	entries, _ := os.ReadDir(filepath.Join(cacheHome, "snap", "ai"))
+	old := time.Now().Add(-aiCacheTTL - time.Hour)
+	for _, entry := range entries {
+		os.Chtimes(filepath.Join(cacheHome, "snap", "ai", entry.Name()), old, old)
+	}
+	generate(largeDiff, 42)
+	if requests.Load() != 6 {
+		t.Errorf("Expected expired entries to be regenerated, got %d requests", requests.Load()-4)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(cacheHome, "snap", "ai")); len(entries) != 2 {
+		t.Errorf("Expected expired entries to be pruned, got %d entries", len(entries))
+	}
+
+	cfg.AI.Cache = false
+	applyConfig(cfg)
+	generate(largeDiff, 42)
+	if requests.Load() != 8 {
+		t.Errorf("Expected no caching with ai.cache off, got %d requests", requests.Load()-6)
+	}
+}
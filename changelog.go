@@ -0,0 +1,406 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// ConventionalCommit is a commit subject parsed as type(scope)!: description
+type ConventionalCommit struct {
+	Type        string
+	Scope       string
+	Description string
+	Breaking    bool
+	BreakingMsg string // Text of a BREAKING CHANGE footer, if any
+}
+
+var conventionalSubjectRe = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// gitmojiPrefixRe matches a leading gitmoji, as an emoji or a :shortcode:
+var gitmojiPrefixRe = regexp.MustCompile(`^(?:[\p{So}\x{FE0F}\x{200D}]+|:[a-z0-9_+-]+:)\s*`)
+
+// gitmojis maps conventional types to the emoji the gitmoji style puts in front
+var gitmojis = map[string]string{
+	"feat":     "✨",
+	"fix":      "🐛",
+	"perf":     "⚡️",
+	"revert":   "⏪️",
+	"refactor": "♻️",
+	"docs":     "📝",
+	"test":     "✅",
+	"build":    "📦️",
+	"ci":       "👷",
+	"style":    "🎨",
+	"chore":    "🔧",
+}
+var breakingFooterRe = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:\s*(.+)$`)
+
+// issueRefRe matches "#123" issue references, but not "owner/repo#123" or "&#123;"
+var issueRefRe = regexp.MustCompile(`(^|[^\w/&\[])#(\d+)\b`)
+
+// ParseConventionalCommit parses a commit subject and body. ok is false for
+// subjects that don't follow the conventional commit format. A leading
+// gitmoji ("✨ feat: ...") is ignored.
+func ParseConventionalCommit(subject, body string) (ConventionalCommit, bool) {
+	subject = gitmojiPrefixRe.ReplaceAllString(strings.TrimSpace(subject), "")
+	match := conventionalSubjectRe.FindStringSubmatch(subject)
+	if match == nil {
+		return ConventionalCommit{}, false
+	}
+
+	c := ConventionalCommit{
+		Type:        strings.ToLower(match[1]),
+		Scope:       match[2],
+		Description: match[4],
+		Breaking:    match[3] == "!",
+	}
+	if footer := breakingFooterRe.FindStringSubmatch(body); footer != nil {
+		c.Breaking = true
+		c.BreakingMsg = strings.TrimSpace(footer[1])
+	}
+	return c, true
+}
+
+// changelogSections lists the changelog headings in display order
+var changelogSections = []struct {
+	Type  string
+	Title string
+}{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance"},
+	{"revert", "Reverts"},
+	{"refactor", "Refactoring"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build"},
+	{"ci", "CI"},
+	{"style", "Styles"},
+	{"chore", "Chores"},
+}
+
+// otherCommitType groups subjects that aren't conventional or use an unlisted type
+const otherCommitType = "other"
+
+// CommitType returns the conventional type of a commit subject, or "other" for
+// subjects that don't follow the format or use a type without a changelog section
+func CommitType(subject string) string {
+	c, ok := ParseConventionalCommit(subject, "")
+	if !ok {
+		return otherCommitType
+	}
+	for _, section := range changelogSections {
+		if section.Type == c.Type {
+			return c.Type
+		}
+	}
+	return otherCommitType
+}
+
+// ApplyGitmoji puts the gitmoji for a conventional subject's type in front of
+// it, replacing any emoji already there. Other subjects are returned unchanged.
+func ApplyGitmoji(subject string) string {
+	c, ok := ParseConventionalCommit(subject, "")
+	if !ok {
+		return subject
+	}
+	emoji, ok := gitmojis[c.Type]
+	if !ok {
+		return subject
+	}
+	return emoji + " " + gitmojiPrefixRe.ReplaceAllString(strings.TrimSpace(subject), "")
+}
+
+// ApplyScope adds scope to a conventional subject that has none, e.g.
+// "feat: add x" becomes "feat(tui): add x". Subjects that already have a scope
+// or aren't conventional are returned unchanged.
+func ApplyScope(subject, scope string) string {
+	m := conventionalSubjectRe.FindStringSubmatch(strings.TrimSpace(subject))
+	if scope == "" || m == nil || m[2] != "" {
+		return subject
+	}
+	return fmt.Sprintf("%s(%s)%s: %s", m[1], scope, m[3], m[4])
+}
+
+// CheckConvention reports how a subject breaks the commit convention, or
+// returns nil if it follows it. A leading gitmoji doesn't count towards the
+// subject length.
+func CheckConvention(subject string, convention ConventionConfig) error {
+	c, ok := ParseConventionalCommit(subject, "")
+	if !ok {
+		return fmt.Errorf("expected %s", convention.format())
+	}
+	if !slices.Contains(convention.Types, c.Type) {
+		return fmt.Errorf("type '%s' is not one of %s", c.Type, strings.Join(convention.Types, ", "))
+	}
+	if convention.RequireScope && c.Scope == "" {
+		return fmt.Errorf("a scope is required, e.g. %s(api): %s", c.Type, c.Description)
+	}
+	length := utf8.RuneCountInString(gitmojiPrefixRe.ReplaceAllString(strings.TrimSpace(subject), ""))
+	if length > convention.MaxSubject {
+		return fmt.Errorf("subject is %d characters, the limit is %d", length, convention.MaxSubject)
+	}
+	return nil
+}
+
+// format describes the subject format of the convention for prompts and errors
+func (c ConventionConfig) format() string {
+	if c.RequireScope {
+		return "<type>(<scope>): <description>"
+	}
+	return "<type>: <description>"
+}
+
+// defaultChangelogExclude lists commit types left out of changelogs unless configured
+var defaultChangelogExclude = []string{"chore"}
+
+// LoadChangelogExclude returns the commit types to omit from changelogs, from
+// changelog.exclude in the config. Without a [changelog] section, the older
+// snap.changelogExclude git config key (repeatable, comma-separated) still
+// applies; an empty value includes all types.
+func LoadChangelogExclude() []string {
+	if config.changelogSet {
+		return config.Changelog.Exclude
+	}
+	cmd := exec.Command("git", "config", "--get-all", "snap.changelogExclude")
+	output, err := cmd.Output()
+	if err != nil {
+		return config.Changelog.Exclude
+	}
+
+	exclude := []string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		for _, t := range strings.Split(line, ",") {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+				exclude = append(exclude, t)
+			}
+		}
+	}
+	return exclude
+}
+
+// changelogEntry is a commit or pull request prepared for the changelog
+type changelogEntry struct {
+	commit       CommitWithStats
+	conventional ConventionalCommit
+	ok           bool   // Subject follows the conventional commit format
+	ref          string // Replaces the commit hash reference, e.g. a pull request link
+}
+
+// line formats the entry as a markdown bullet ending in the short hash.
+// With a forge, the hash and issue references become links.
+func (e changelogEntry) line(forge *Forge) string {
+	text := e.commit.Message
+	if e.ok {
+		text = e.conventional.Description
+		if e.conventional.Scope != "" {
+			text = e.conventional.Scope + ": " + text
+		}
+	}
+	line := "- " + linkIssues(text, forge)
+	ref := e.ref
+	if ref == "" {
+		ref = commitRef(e.commit, forge)
+	}
+	if ref != "" {
+		line += " " + ref
+	}
+	return line
+}
+
+// commitRef formats the short hash of a commit, linked to the forge when known
+func commitRef(commit CommitWithStats, forge *Forge) string {
+	if commit.ShortHash == "" {
+		return ""
+	}
+	if forge == nil || commit.Hash == "" {
+		return "(" + commit.ShortHash + ")"
+	}
+	return fmt.Sprintf("([%s](%s))", commit.ShortHash, forge.CommitURL(commit.Hash))
+}
+
+// linkIssues turns "#123" references into markdown links to the forge's issues
+func linkIssues(text string, forge *Forge) string {
+	if forge == nil {
+		return text
+	}
+	return issueRefRe.ReplaceAllStringFunc(text, func(match string) string {
+		parts := issueRefRe.FindStringSubmatch(match)
+		return fmt.Sprintf("%s[#%s](%s)", parts[1], parts[2], forge.IssueURL(parts[2]))
+	})
+}
+
+// formatChangelog groups commits by conventional type. Breaking changes are listed
+// first, commits that aren't conventional go to "Other Changes". If nothing is
+// conventional, the plain list of subjects is returned. forge may be nil for
+// output without links.
+func formatChangelog(commits []CommitWithStats, bodies map[string]string, exclude []string, forge *Forge) string {
+	entries := make([]changelogEntry, len(commits))
+	for i, commit := range commits {
+		conventional, ok := ParseConventionalCommit(commit.Message, bodies[commit.Hash])
+		entries[i] = changelogEntry{commit: commit, conventional: conventional, ok: ok}
+	}
+	return formatChangelogEntries(entries, exclude, forge)
+}
+
+// formatChangelogEntries groups prepared entries the way formatChangelog describes
+func formatChangelogEntries(entries []changelogEntry, exclude []string, forge *Forge) string {
+	excluded := make(map[string]bool, len(exclude))
+	for _, t := range exclude {
+		excluded[t] = true
+	}
+
+	anyConventional := false
+	for _, e := range entries {
+		anyConventional = anyConventional || e.ok
+	}
+
+	if !anyConventional {
+		var sb strings.Builder
+		for _, e := range entries {
+			sb.WriteString(e.line(forge) + "\n")
+		}
+		return sb.String()
+	}
+
+	var sections []string
+	addSection := func(title string, lines []string) {
+		if len(lines) > 0 {
+			sections = append(sections, "### "+title+"\n\n"+strings.Join(lines, "\n")+"\n")
+		}
+	}
+
+	// Breaking changes are never hidden, even for excluded types
+	var breaking []string
+	for _, e := range entries {
+		if e.ok && e.conventional.Breaking {
+			line := e.line(forge)
+			if e.conventional.BreakingMsg != "" {
+				line += "\n  " + linkIssues(e.conventional.BreakingMsg, forge)
+			}
+			breaking = append(breaking, line)
+		}
+	}
+	addSection("Breaking Changes", breaking)
+
+	known := map[string]bool{}
+	for _, section := range changelogSections {
+		known[section.Type] = true
+		if excluded[section.Type] {
+			continue
+		}
+		var lines []string
+		for _, e := range entries {
+			if e.ok && !e.conventional.Breaking && e.conventional.Type == section.Type {
+				lines = append(lines, e.line(forge))
+			}
+		}
+		addSection(section.Title, lines)
+	}
+
+	// Unknown types and non-conventional subjects
+	var other []string
+	for _, e := range entries {
+		switch {
+		case e.ok && (e.conventional.Breaking || known[e.conventional.Type] || excluded[e.conventional.Type]):
+			continue
+		case !e.ok:
+			other = append(other, e.line(forge))
+		default:
+			// Unknown type - keep the full subject so the type stays visible
+			other = append(other, changelogEntry{commit: e.commit, ref: e.ref}.line(forge))
+		}
+	}
+	addSection("Other Changes", other)
+
+	if len(sections) == 0 {
+		// Only excluded types - fall back to the plain list rather than an empty message
+		var sb strings.Builder
+		for _, e := range entries {
+			sb.WriteString(changelogEntry{commit: e.commit, ref: e.ref}.line(forge) + "\n")
+		}
+		return sb.String()
+	}
+
+	return strings.Join(sections, "\n")
+}
+
+// GetCommitBodies returns the message bodies of the given commits keyed by full hash
+func GetCommitBodies(hashes []string) (map[string]string, error) {
+	bodies := make(map[string]string, len(hashes))
+	if len(hashes) == 0 {
+		return bodies, nil
+	}
+
+	args := append([]string{"show", "-s", "--format=%H%x00%b%x1e"}, hashes...)
+	cmd := exec.Command("git", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range strings.Split(string(output), "\x1e") {
+		hash, body, ok := strings.Cut(strings.TrimLeft(record, "\n"), "\x00")
+		if ok {
+			bodies[hash] = strings.TrimSpace(body)
+		}
+	}
+	return bodies, nil
+}
+
+// GenerateChangelog builds the grouped changelog for the given commits,
+// reading commit bodies for breaking-change footers and the excluded types from git config.
+// With a forge, commits and issue references are linked for pasting into a release page.
+func GenerateChangelog(commits []CommitWithStats, forge *Forge) string {
+	hashes := make([]string, len(commits))
+	for i, c := range commits {
+		hashes[i] = c.Hash
+	}
+
+	// Without bodies only "!" marks breaking changes
+	bodies, err := GetCommitBodies(hashes)
+	if err != nil {
+		bodies = map[string]string{}
+	}
+
+	return formatChangelog(commits, bodies, LoadChangelogExclude(), forge)
+}
+
+// GeneratePullRequestChangelog builds the grouped changelog from merged pull request
+// titles, followed by commits that were pushed without a pull request. Pull request
+// bodies are read for breaking-change footers. With a forge, references are linked.
+func GeneratePullRequestChangelog(prs []PullRequest, commits []CommitWithStats, forge *Forge) string {
+	entries := make([]changelogEntry, 0, len(prs)+len(commits))
+	for _, pr := range prs {
+		conventional, ok := ParseConventionalCommit(pr.Title, pr.Body)
+		ref := "(" + pr.Label + ")"
+		if forge != nil && pr.URL != "" {
+			ref = fmt.Sprintf("([%s](%s))", pr.Label, pr.URL)
+		}
+		entries = append(entries, changelogEntry{
+			commit:       CommitWithStats{Message: pr.Title},
+			conventional: conventional,
+			ok:           ok,
+			ref:          ref,
+		})
+	}
+
+	hashes := make([]string, len(commits))
+	for i, c := range commits {
+		hashes[i] = c.Hash
+	}
+	bodies, err := GetCommitBodies(hashes)
+	if err != nil {
+		bodies = map[string]string{}
+	}
+	for _, commit := range commits {
+		conventional, ok := ParseConventionalCommit(commit.Message, bodies[commit.Hash])
+		entries = append(entries, changelogEntry{commit: commit, conventional: conventional, ok: ok})
+	}
+
+	return formatChangelogEntries(entries, LoadChangelogExclude(), forge)
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	tests := []struct {
+		subject  string
+		body     string
+		expected ConventionalCommit
+		ok       bool
+	}{
+		{subject: "feat: add login", expected: ConventionalCommit{Type: "feat", Description: "add login"}, ok: true},
+		{subject: "fix(api): handle nil", expected: ConventionalCommit{Type: "fix", Scope: "api", Description: "handle nil"}, ok: true},
+		{subject: "feat(api)!: drop v1", expected: ConventionalCommit{Type: "feat", Scope: "api", Description: "drop v1", Breaking: true}, ok: true},
+		{
+			subject:  "refactor: rename config",
+			body:     "Details here.\n\nBREAKING CHANGE: config file moved to ~/.config",
+			expected: ConventionalCommit{Type: "refactor", Description: "rename config", Breaking: true, BreakingMsg: "config file moved to ~/.config"},
+			ok:       true,
+		},
+		{subject: "✨ feat(ui): add login flow", expected: ConventionalCommit{Type: "feat", Scope: "ui", Description: "add login flow"}, ok: true},
+		{subject: "♻️ refactor: split config", expected: ConventionalCommit{Type: "refactor", Description: "split config"}, ok: true},
+		{subject: ":bug: fix: handle nil", expected: ConventionalCommit{Type: "fix", Description: "handle nil"}, ok: true},
+		{subject: "Update README", ok: false},
+		{subject: "Merge branch 'main'", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			result, ok := ParseConventionalCommit(tt.subject, tt.body)
+			if ok != tt.ok {
+				t.Fatalf("ParseConventionalCommit(%q) ok = %v, want %v", tt.subject, ok, tt.ok)
+			}
+			if ok && result != tt.expected {
+				t.Errorf("ParseConventionalCommit(%q) = %+v, want %+v", tt.subject, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatChangelog(t *testing.T) {
+	commits := []CommitWithStats{
+		{Hash: "a1", ShortHash: "a1", Message: "feat(ui): add dark mode"},
+		{Hash: "a2", ShortHash: "a2", Message: "fix: crash on empty repo"},
+		{Hash: "a3", ShortHash: "a3", Message: "chore: bump deps"},
+		{Hash: "a4", ShortHash: "a4", Message: "perf: cache status"},
+		{Hash: "a5", ShortHash: "a5", Message: "feat!: remove legacy flags"},
+		{Hash: "a6", ShortHash: "a6", Message: "Update README"},
+		{Hash: "a7", ShortHash: "a7", Message: "refactor: split config"},
+	}
+	bodies := map[string]string{"a7": "BREAKING CHANGE: config keys renamed"}
+
+	expected := `### Breaking Changes
+
+- remove legacy flags (a5)
+- split config (a7)
+  config keys renamed
+
+### Features
+
+- ui: add dark mode (a1)
+
+### Bug Fixes
+
+- crash on empty repo (a2)
+
+### Performance
+
+- cache status (a4)
+
+### Other Changes
+
+- Update README (a6)
+`
+	if result := formatChangelog(commits, bodies, []string{"chore"}, nil); result != expected {
+		t.Errorf("formatChangelog() =\n%s\nwant\n%s", result, expected)
+	}
+
+	// Including chores adds their section
+	if result := formatChangelog(commits, bodies, nil, nil); !strings.Contains(result, "### Chores\n\n- bump deps (a3)\n") {
+		t.Errorf("Expected Chores section when nothing is excluded, got:\n%s", result)
+	}
+}
+
+func TestFormatChangelogFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		commits  []CommitWithStats
+		expected string
+	}{
+		{
+			name:     "No conventional commits",
+			commits:  []CommitWithStats{{Message: "Fix typo"}, {Message: "Add feature"}},
+			expected: "- Fix typo\n- Add feature\n",
+		},
+		{
+			name:     "Only excluded types",
+			commits:  []CommitWithStats{{Message: "chore: bump deps"}},
+			expected: "- chore: bump deps\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := formatChangelog(tt.commits, nil, []string{"chore"}, nil); result != tt.expected {
+				t.Errorf("formatChangelog() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatChangelogLinks(t *testing.T) {
+	commits := []CommitWithStats{
+		{Hash: "abc1234def", ShortHash: "abc1234", Message: "fix(api): handle timeouts (#42)"},
+		{Hash: "fff0000aaa", ShortHash: "fff0000", Message: "Merge owner/repo#7 and escape &#38;"},
+	}
+
+	tests := []struct {
+		name     string
+		forge    Forge
+		expected string
+	}{
+		{
+			name:  "GitHub",
+			forge: newForge("https://github.com/owner/repo"),
+			expected: "### Bug Fixes\n\n" +
+				"- api: handle timeouts ([#42](https://github.com/owner/repo/issues/42)) " +
+				"([abc1234](https://github.com/owner/repo/commit/abc1234def))\n\n" +
+				"### Other Changes\n\n" +
+				"- Merge owner/repo#7 and escape &#38; ([fff0000](https://github.com/owner/repo/commit/fff0000aaa))\n",
+		},
+		{
+			name:  "GitLab",
+			forge: newForge("https://gitlab.com/group/repo"),
+			expected: "### Bug Fixes\n\n" +
+				"- api: handle timeouts ([#42](https://gitlab.com/group/repo/-/issues/42)) " +
+				"([abc1234](https://gitlab.com/group/repo/-/commit/abc1234def))\n\n" +
+				"### Other Changes\n\n" +
+				"- Merge owner/repo#7 and escape &#38; ([fff0000](https://gitlab.com/group/repo/-/commit/fff0000aaa))\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := formatChangelog(commits, nil, nil, &tt.forge); result != tt.expected {
+				t.Errorf("formatChangelog() =\n%s\nwant\n%s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGeneratePullRequestChangelog(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	prs := []PullRequest{
+		{Label: "#12", Title: "feat(api): add notes", URL: "https://github.com/owner/repo/pull/12"},
+		{Label: "#11", Title: "fix: timeouts", Body: "BREAKING CHANGE: retries removed", URL: "https://github.com/owner/repo/pull/11"},
+	}
+	commits := []CommitWithStats{
+		{Hash: "abc1234def", ShortHash: "abc1234", Message: "docs: hotfix readme"},
+	}
+
+	forge := newForge("https://github.com/owner/repo")
+	expected := "### Breaking Changes\n\n" +
+		"- timeouts ([#11](https://github.com/owner/repo/pull/11))\n  retries removed\n\n" +
+		"### Features\n\n" +
+		"- api: add notes ([#12](https://github.com/owner/repo/pull/12))\n\n" +
+		"### Documentation\n\n" +
+		"- hotfix readme ([abc1234](https://github.com/owner/repo/commit/abc1234def))\n"
+	if result := GeneratePullRequestChangelog(prs, commits, &forge); result != expected {
+		t.Errorf("GeneratePullRequestChangelog() =\n%s\nwant\n%s", result, expected)
+	}
+
+	expected = "### Breaking Changes\n\n" +
+		"- timeouts (#11)\n  retries removed\n\n" +
+		"### Features\n\n" +
+		"- api: add notes (#12)\n"
+	if result := GeneratePullRequestChangelog(prs, nil, nil); result != expected {
+		t.Errorf("GeneratePullRequestChangelog() without links =\n%s\nwant\n%s", result, expected)
+	}
+}
+
+func TestCommitType(t *testing.T) {
+	tests := map[string]string{
+		"feat(api): add notes":   "feat",
+		"Fix!: drop old flag":    "fix",
+		"docs: update readme":    "docs",
+		"wip: half done":         "other",
+		"Update README.md":       "other",
+		"Merge branch 'feature'": "other",
+	}
+
+	for subject, expected := range tests {
+		if result := CommitType(subject); result != expected {
+			t.Errorf("CommitType(%q) = %q, want %q", subject, result, expected)
+		}
+	}
+}
+
+func TestApplyGitmoji(t *testing.T) {
+	tests := map[string]string{
+		"feat: add login flow":    "✨ feat: add login flow",
+		"fix(api)!: drop v1":      "🐛 fix(api)!: drop v1",
+		"🎨 refactor: split files": "♻️ refactor: split files",
+		":memo: docs: update":     "📝 docs: update",
+		"wip: half done":          "wip: half done",
+		"Update README":           "Update README",
+	}
+
+	for subject, expected := range tests {
+		if result := ApplyGitmoji(subject); result != expected {
+			t.Errorf("ApplyGitmoji(%q) = %q, want %q", subject, result, expected)
+		}
+	}
+}
+
+func TestApplyScope(t *testing.T) {
+	tests := map[string]string{
+		"feat: add login flow": "feat(tui): add login flow",
+		"fix!: drop v1":        "fix(tui)!: drop v1",
+		"fix(api): keep scope": "fix(api): keep scope",
+		"Update README":        "Update README",
+	}
+
+	for subject, expected := range tests {
+		if result := ApplyScope(subject, "tui"); result != expected {
+			t.Errorf("ApplyScope(%q) = %q, want %q", subject, result, expected)
+		}
+	}
+	if result := ApplyScope("feat: add x", ""); result != "feat: add x" {
+		t.Errorf("Expected no scope to leave the subject alone, got %q", result)
+	}
+}
+
+func TestCheckConvention(t *testing.T) {
+	convention := ConventionConfig{Types: []string{"feat", "fix"}, MaxSubject: 30}
+	scoped := ConventionConfig{Types: []string{"feat"}, MaxSubject: 72, RequireScope: true}
+
+	tests := []struct {
+		name       string
+		subject    string
+		convention ConventionConfig
+		wantErr    string
+	}{
+		{name: "Valid", subject: "feat: add login flow", convention: convention},
+		{name: "Gitmoji not counted", subject: "✨ feat: add a login flow today", convention: convention},
+		{name: "Not conventional", subject: "Add login flow", convention: convention, wantErr: "expected <type>: <description>"},
+		{name: "Unknown type", subject: "docs: update readme", convention: convention, wantErr: "type 'docs' is not one of feat, fix"},
+		{name: "Too long", subject: "fix: handle the very long edge case", convention: convention, wantErr: "35 characters, the limit is 30"},
+		{name: "Missing scope", subject: "feat: add login", convention: scoped, wantErr: "scope is required"},
+		{name: "Scoped", subject: "feat(auth): add login", convention: scoped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckConvention(tt.subject, tt.convention)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadChangelogExclude(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	if exclude := LoadChangelogExclude(); len(exclude) != 1 || exclude[0] != "chore" {
+		t.Errorf("Expected default [chore], got %v", exclude)
+	}
+
+	exec.Command("git", "config", "--add", "snap.changelogExclude", "chore,ci").Run()
+	exec.Command("git", "config", "--add", "snap.changelogExclude", "Test").Run()
+	exclude := LoadChangelogExclude()
+	if strings.Join(exclude, " ") != "chore ci test" {
+		t.Errorf("Expected [chore ci test], got %v", exclude)
+	}
+
+	// [changelog] in the config replaces the git config key
+	defer applyConfig(config)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	os.WriteFile(repoConfigFile, []byte("[changelog]\nexclude = [\"docs\"]\n"), 0644)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	applyConfig(cfg)
+	if exclude := LoadChangelogExclude(); strings.Join(exclude, " ") != "docs" {
+		t.Errorf("Expected [docs] from the config, got %v", exclude)
+	}
+}
+
+func TestGetCommitBodies(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	exec.Command("git", "commit", "--allow-empty", "-m", "feat: new api", "-m", "BREAKING CHANGE: old api removed").Run()
+	hash, _ := exec.Command("git", "rev-parse", "HEAD").Output()
+	parent, _ := exec.Command("git", "rev-parse", "HEAD~1").Output()
+
+	h := strings.TrimSpace(string(hash))
+	p := strings.TrimSpace(string(parent))
+	bodies, err := GetCommitBodies([]string{h, p})
+	if err != nil {
+		t.Fatalf("GetCommitBodies failed: %v", err)
+	}
+	if bodies[h] != "BREAKING CHANGE: old api removed" {
+		t.Errorf("Unexpected body %q", bodies[h])
+	}
+	if body, ok := bodies[p]; !ok || body != "" {
+		t.Errorf("Expected empty body for initial commit, got %q (present %v)", body, ok)
+	}
+}
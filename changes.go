@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type changesState int
+
+const (
+	changesStateLoading changesState = iota
+	changesStateList
+	changesStateError
+)
+
+type changesModel struct {
+	state           changesState
+	spinner         spinner.Model
+	viewport        viewport.Model
+	status          string
+	ignored         string
+	expandUntracked bool
+	showIgnored     bool
+	err             error
+	showHelp        bool
+	width           int
+	height          int
+	ready           bool
+}
+
+type getChangesMsg struct {
+	status  string
+	ignored string
+	err     error
+}
+
+func initialChangesModel(expandUntracked bool, showIgnored bool) changesModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
+
+	return changesModel{
+		state:           changesStateLoading,
+		spinner:         s,
+		expandUntracked: expandUntracked,
+		showIgnored:     showIgnored,
+		showHelp:        true,
+		width:           80,
+		height:          24,
+		ready:           false,
+	}
+}
+
+func (m changesModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, getChangesCmd(m.expandUntracked, m.showIgnored))
+}
+
+func (m changesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		if !m.ready {
+			m.viewport = viewport.New(msg.Width, msg.Height-6) // Leave space for header and footer
+			m.viewport.YPosition = 0
+			m.ready = true
+		} else {
+			m.viewport.Width = msg.Width
+			m.viewport.Height = msg.Height - 6
+		}
+		m.viewport.SetContent(m.content())
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		}
+
+		if m.state == changesStateList {
+			switch msg.String() {
+			case "u":
+				// Toggle untracked directory expansion and reload
+				m.expandUntracked = !m.expandUntracked
+				m.state = changesStateLoading
+				return m, tea.Batch(m.spinner.Tick, getChangesCmd(m.expandUntracked, m.showIgnored))
+			case "i":
+				// Toggle the ignored files section and reload
+				m.showIgnored = !m.showIgnored
+				m.state = changesStateLoading
+				return m, tea.Batch(m.spinner.Tick, getChangesCmd(m.expandUntracked, m.showIgnored))
+			case "r":
+				m.state = changesStateLoading
+				return m, tea.Batch(m.spinner.Tick, getChangesCmd(m.expandUntracked, m.showIgnored))
+			case "?":
+				m.showHelp = !m.showHelp
+			default:
+				var cmd tea.Cmd
+				m.viewport, cmd = m.viewport.Update(msg)
+				return m, cmd
+			}
+		}
+
+	case spinner.TickMsg:
+		if m.state != changesStateLoading {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case getChangesMsg:
+		if msg.err != nil {
+			m.state = changesStateError
+			m.err = msg.err
+			return m, tea.Quit
+		}
+		m.status = msg.status
+		m.ignored = msg.ignored
+		m.viewport.SetContent(m.content())
+		m.viewport.GotoTop()
+		m.state = changesStateList
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// content builds the viewport content from the status and ignored sections
+func (m changesModel) content() string {
+	var content strings.Builder
+
+	if m.status == "" {
+		content.WriteString(infoStyle.Render("No changes - everything is clean!"))
+		content.WriteString("\n")
+	} else {
+		content.WriteString(m.status)
+	}
+
+	if m.showIgnored {
+		content.WriteString("\n")
+		content.WriteString(titleStyle.Render("Ignored"))
+		content.WriteString("\n")
+		if m.ignored == "" {
+			content.WriteString(infoStyle.Render("No ignored files"))
+			content.WriteString("\n")
+		} else {
+			content.WriteString(m.ignored)
+		}
+	}
+
+	return content.String()
+}
+
+func (m changesModel) View() string {
+	switch m.state {
+	case changesStateLoading:
+		return fmt.Sprintf("%s Loading changes...", m.spinner.View())
+
+	case changesStateList:
+		if !m.ready {
+			return "Loading..."
+		}
+
+		var s strings.Builder
+		titleStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(colorPrimary).
+			PaddingLeft(2)
+
+		title := "Changes"
+		if m.expandUntracked {
+			title += " (untracked directories expanded)"
+		}
+		s.WriteString(titleStyle.Render(title))
+		s.WriteString("\n\n")
+
+		viewportStyle := lipgloss.NewStyle().
+			PaddingLeft(2).
+			PaddingRight(2)
+		s.WriteString(viewportStyle.Render(m.viewport.View()))
+		s.WriteString("\n")
+
+		helpStyle := lipgloss.NewStyle().
+			Foreground(colorMuted).
+			PaddingLeft(2)
+		if m.showHelp {
+			s.WriteString(helpStyle.Render("↑/k: up  ↓/j: down  u: expand/collapse untracked  i: show/hide ignored  r: refresh  ?: help  q: quit"))
+		} else {
+			s.WriteString(helpStyle.Render("Press ? for help"))
+		}
+
+		return s.String()
+
+	case changesStateError:
+		return errorStyle.Render(fmt.Sprintf("✗ Error: %s", m.err))
+	}
+
+	return ""
+}
+
+func getChangesCmd(expandUntracked bool, showIgnored bool) tea.Cmd {
+	return func() tea.Msg {
+		status, err := GetColoredStatus(expandUntracked)
+		if err != nil {
+			return getChangesMsg{err: err}
+		}
+
+		ignored := ""
+		if showIgnored {
+			ignored, err = GetColoredIgnored(expandUntracked)
+			if err != nil {
+				return getChangesMsg{err: err}
+			}
+		}
+
+		return getChangesMsg{status: status, ignored: ignored}
+	}
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CheckFinding is a problem found in the lines a commit adds
+type CheckFinding struct {
+	Path   string
+	Line   int    // Line number in the new file
+	Text   string // The offending line
+	Rule   string // What was found, e.g. "conflict marker" or the pattern
+	Block  bool   // The commit must not go ahead
+	Secret bool   // Text is masked; --allow-secrets lets it through
+}
+
+func (f CheckFinding) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", f.Path, f.Line, f.Rule, strings.TrimSpace(f.Text))
+}
+
+// hunkHeaderRe reads the new file's start line from "@@ -1,4 +1,5 @@"
+var hunkHeaderRe = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// CheckDiff scans the lines added in a diff for conflict markers and the
+// configured patterns
+func CheckDiff(diff string, checks ChecksConfig) []CheckFinding {
+	var patterns []*regexp.Regexp
+	for _, pattern := range checks.Patterns {
+		// validate rejects patterns that don't compile
+		if re, err := regexp.Compile(pattern); err == nil {
+			patterns = append(patterns, re)
+		}
+	}
+
+	var findings []CheckFinding
+	path := ""
+	line := 0
+	envFile := false
+	for _, text := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			path = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+			envFile = checks.Secrets && isEnvFile(path)
+			continue
+		case strings.HasPrefix(text, "--- "), strings.HasPrefix(text, "diff --git "):
+			continue
+		case strings.HasPrefix(text, "@@"):
+			if m := hunkHeaderRe.FindStringSubmatch(text); m != nil {
+				line, _ = strconv.Atoi(m[1])
+			}
+			continue
+		case strings.HasPrefix(text, " "):
+			line++
+			continue
+		case !strings.HasPrefix(text, "+"):
+			continue
+		}
+
+		added := text[1:]
+		if checks.ConflictMarkers {
+			if marker, _ := conflictMarker(added); marker == markerOurs || marker == markerTheirs {
+				findings = append(findings, CheckFinding{Path: path, Line: line, Text: added, Rule: "conflict marker", Block: true})
+			}
+		}
+		if checks.Secrets {
+			if kind := secretKind(added); kind != "" {
+				masked := redactedText
+				if kind != "private key" {
+					masked, _ = RedactSecrets(added)
+				}
+				findings = append(findings, CheckFinding{Path: path, Line: line, Text: masked, Rule: kind, Block: true, Secret: true})
+			}
+			if envFile {
+				// One finding per file is enough; it shows the first variable
+				masked := redactedText
+				if name, _, ok := strings.Cut(added, "="); ok {
+					masked = name + "=" + redactedText
+				}
+				findings = append(findings, CheckFinding{Path: path, Line: line, Text: masked, Rule: "environment file", Block: true, Secret: true})
+				envFile = false
+			}
+		}
+		for _, re := range patterns {
+			if re.MatchString(added) {
+				findings = append(findings, CheckFinding{Path: path, Line: line, Text: added, Rule: re.String(), Block: checks.BlockPatterns})
+			}
+		}
+		line++
+	}
+	return findings
+}
+
+// blockingFindings returns the findings that stop the commit
+func blockingFindings(findings []CheckFinding) []CheckFinding {
+	var blocking []CheckFinding
+	for _, finding := range findings {
+		if finding.Block {
+			blocking = append(blocking, finding)
+		}
+	}
+	return blocking
+}
+
+// Kinds of WhitespaceIssue
+const (
+	whitespaceOnlyHunk  = "only changes trailing whitespace"
+	mixedLineEndings    = "mixed line endings"
+	missingFinalNewline = "no newline at end of file"
+)
+
+// WhitespaceIssue is a whitespace or line ending problem in a staged file
+// that FixWhitespace can repair
+type WhitespaceIssue struct {
+	Path   string
+	Kind   string
+	Line   int    // Start of the hunk, for whitespaceOnlyHunk
+	Detail string // e.g. "12 CRLF, 3 LF"
+	patch  string // The hunk with its file header, to revert it
+}
+
+func (i WhitespaceIssue) String() string {
+	s := i.Path
+	if i.Line > 0 {
+		s += fmt.Sprintf(":%d", i.Line)
+	}
+	s += ": " + i.Kind
+	if i.Detail != "" {
+		s += " (" + i.Detail + ")"
+	}
+	return s
+}
+
+// CheckWhitespace finds hunks that only change trailing whitespace, files
+// left without a final newline, and staged files mixing CRLF and LF
+func CheckWhitespace(diff string) []WhitespaceIssue {
+	var issues []WhitespaceIssue
+	for _, file := range splitDiffFiles(diff) {
+		header, hunks, _ := strings.Cut(file, "\n@@")
+		path := ""
+		for _, line := range strings.Split(header, "\n") {
+			if rest, ok := strings.CutPrefix(line, "+++ "); ok && rest != "/dev/null" {
+				path = strings.TrimPrefix(rest, "b/")
+			}
+		}
+		// Deleted and binary files have no new content to check
+		if path == "" || hunks == "" {
+			continue
+		}
+
+		missingNewline := false
+		for _, part := range strings.Split(hunks, "\n@@") {
+			hunk := "@@" + part
+			start := 0
+			if m := hunkHeaderRe.FindStringSubmatch(hunk); m != nil {
+				start, _ = strconv.Atoi(m[1])
+			}
+
+			var removed, added []string
+			previous := byte(' ')
+			for _, line := range strings.Split(hunk, "\n")[1:] {
+				if line == "" {
+					continue
+				}
+				switch line[0] {
+				case '-':
+					removed = append(removed, line[1:])
+				case '+':
+					added = append(added, line[1:])
+				case '\\':
+					if previous == '+' {
+						missingNewline = true
+					}
+				}
+				previous = line[0]
+			}
+			if whitespaceOnly(removed, added) {
+				patch := header + "\n" + strings.TrimSuffix(hunk, "\n") + "\n"
+				issues = append(issues, WhitespaceIssue{Path: path, Kind: whitespaceOnlyHunk, Line: start, patch: patch})
+			}
+		}
+
+		if missingNewline {
+			issues = append(issues, WhitespaceIssue{Path: path, Kind: missingFinalNewline})
+		}
+		if content, err := GetStagedContent(path); err == nil {
+			crlf := strings.Count(content, "\r\n")
+			if lf := strings.Count(content, "\n") - crlf; crlf > 0 && lf > 0 {
+				issues = append(issues, WhitespaceIssue{Path: path, Kind: mixedLineEndings, Detail: fmt.Sprintf("%d CRLF, %d LF", crlf, lf)})
+			}
+		}
+	}
+	return issues
+}
+
+// splitDiffFiles splits a diff into one part per file, each starting with
+// its "diff --git" line
+func splitDiffFiles(diff string) []string {
+	var files []string
+	for _, part := range strings.Split("\n"+diff, "\ndiff --git ") {
+		if strings.TrimSpace(part) != "" {
+			files = append(files, "diff --git "+part)
+		}
+	}
+	return files
+}
+
+// whitespaceOnly reports whether the added lines equal the removed ones apart
+// from trailing whitespace
+func whitespaceOnly(removed, added []string) bool {
+	if len(removed) == 0 || len(removed) != len(added) {
+		return false
+	}
+	changed := false
+	for i := range removed {
+		if strings.TrimRight(removed[i], " \t\r") != strings.TrimRight(added[i], " \t\r") {
+			return false
+		}
+		changed = changed || removed[i] != added[i]
+	}
+	return changed
+}
+
+// LargeFile is a staged file over checks.max_file_mb
+type LargeFile struct {
+	Path     string
+	OrigPath string // Set when the file was renamed
+	Size     int64
+}
+
+func (f LargeFile) String() string {
+	return fmt.Sprintf("%s: %s", f.Path, formatBytes(f.Size))
+}
+
+// CheckLargeFiles returns the staged files bigger than maxMB megabytes, which
+// bloat the history of every clone once committed
+func CheckLargeFiles(stats []FileDiffStat, maxMB int) []LargeFile {
+	if maxMB <= 0 {
+		return nil
+	}
+	limit := int64(maxMB) * 1000 * 1000
+	var large []LargeFile
+	for _, stat := range stats {
+		// Deleted files aren't in the index
+		size, err := GetStagedSize(stat.Path)
+		if err != nil || size <= limit {
+			continue
+		}
+		large = append(large, LargeFile{Path: stat.Path, OrigPath: stat.OrigPath, Size: size})
+	}
+	return large
+}
+
+// SkipLargeFiles unstages the files so the commit goes ahead without them
+func SkipLargeFiles(files []LargeFile) error {
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path)
+		if file.OrigPath != "" {
+			paths = append(paths, file.OrigPath)
+		}
+	}
+	return UnstagePaths(paths)
+}
+
+// LFSInstructions are the commands that move the files to Git LFS: files are
+// tracked by extension, so later ones of the same kind follow
+func LFSInstructions(files []LargeFile) []string {
+	var patterns []string
+	for _, file := range files {
+		pattern := file.Path
+		if ext := filepath.Ext(file.Path); ext != "" {
+			pattern = "*" + ext
+		}
+		if !slices.Contains(patterns, pattern) {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	lines := []string{"git lfs install"}
+	for _, pattern := range patterns {
+		lines = append(lines, fmt.Sprintf("git lfs track %q", pattern))
+	}
+	add := []string{".gitattributes"}
+	for _, file := range files {
+		add = append(add, file.Path)
+	}
+	return append(lines, "git add "+strings.Join(add, " "))
+}
+
+// FixWhitespace repairs the issues in the working tree and stages the result:
+// whitespace-only hunks are reverted, line endings follow the file's majority,
+// and a final newline is added
+func FixWhitespace(issues []WhitespaceIssue) error {
+	root, err := GetRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	// Revert later hunks first so earlier ones still apply where the patch says
+	var paths, normalize []string
+	for i := len(issues) - 1; i >= 0; i-- {
+		issue := issues[i]
+		if !slices.Contains(paths, issue.Path) {
+			paths = append(paths, issue.Path)
+		}
+		if issue.Kind != whitespaceOnlyHunk {
+			normalize = append(normalize, issue.Path)
+			continue
+		}
+		if err := RevertPatch(root, issue.patch); err != nil {
+			return fmt.Errorf("%s: %w", issue.Path, err)
+		}
+	}
+
+	for _, path := range paths {
+		full := filepath.Join(root, path)
+		if slices.Contains(normalize, path) {
+			info, err := os.Stat(full)
+			if err != nil {
+				return err
+			}
+			content, err := os.ReadFile(full)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(full, []byte(normalizeLineEndings(string(content))), info.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+		if err := StageFile(full); err != nil {
+			return fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// normalizeLineEndings converts a text to its most common line ending and
+// makes sure it ends with one
+func normalizeLineEndings(content string) string {
+	if content == "" {
+		return content
+	}
+	crlf := strings.Count(content, "\r\n")
+	lf := strings.Count(content, "\n") - crlf
+	ending := "\n"
+	if crlf > lf {
+		ending = "\r\n"
+	}
+
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return strings.ReplaceAll(content, "\n", ending)
+}
+
+// FindDuplicateSubject returns the recent subject that a message's subject
+// repeats, or "" when it is new. Case, spacing, a gitmoji and a trailing
+// period are ignored, and a few typos still count as the same subject.
+func FindDuplicateSubject(message string, recent []string) string {
+	subject := normalizeSubject(strings.SplitN(message, "\n", 2)[0])
+	if subject == "" {
+		return ""
+	}
+	for _, previous := range recent {
+		other := normalizeSubject(previous)
+		limit := max(len(subject), len(other)) / 10
+		if editDistance(subject, other) <= limit {
+			return previous
+		}
+	}
+	return ""
+}
+
+// normalizeSubject lowercases a subject and drops what doesn't change its meaning
+func normalizeSubject(subject string) string {
+	subject = gitmojiPrefixRe.ReplaceAllString(strings.TrimSpace(subject), "")
+	subject = strings.Join(strings.Fields(strings.ToLower(subject)), " ")
+	return strings.TrimRight(subject, ".")
+}
+
+// editDistance is the Levenshtein distance between two strings, in runes
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// recentSubjects returns the subjects to compare a new message with. When
+// amending, HEAD is the commit being replaced, so it is left out.
+func recentSubjects(amend bool) []string {
+	n := config.Checks.Duplicates
+	if n == 0 {
+		return nil
+	}
+	if amend {
+		n++
+	}
+	subjects, err := GetRecentSubjects(n)
+	if err != nil {
+		return nil
+	}
+	if amend && len(subjects) > 0 {
+		subjects = subjects[1:]
+	}
+	return subjects
+}
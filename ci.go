@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// stepSummaryEnvVar names the file GitHub Actions shows on a job's summary page
+const stepSummaryEnvVar = "GITHUB_STEP_SUMMARY"
+
+// WriteStepSummary appends markdown to the GitHub Actions step summary. Outside
+// GitHub Actions it does nothing, so the same commands can be tried locally.
+func WriteStepSummary(markdown string) error {
+	path := os.Getenv(stepSummaryEnvVar)
+	if path == "" {
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write the step summary: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(markdown + "\n"); err != nil {
+		return fmt.Errorf("failed to write the step summary: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteStepSummary(t *testing.T) {
+	t.Setenv(stepSummaryEnvVar, "")
+	if err := WriteStepSummary("## Ignored"); err != nil {
+		t.Errorf("Expected no error outside GitHub Actions, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "summary.md")
+	os.WriteFile(path, []byte("## Earlier step\n"), 0644)
+	t.Setenv(stepSummaryEnvVar, path)
+	if err := WriteStepSummary("## One"); err != nil {
+		t.Fatalf("WriteStepSummary failed: %v", err)
+	}
+	WriteStepSummary("## Two")
+	content, _ := os.ReadFile(path)
+	if string(content) != "## Earlier step\n## One\n## Two\n" {
+		t.Errorf("Expected the summaries to be appended, got %q", content)
+	}
+}
+
+func TestStepSummaryCommands(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	path := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv(stepSummaryEnvVar, path)
+
+	exec.Command("git", "tag", "-a", "v1.0.0", "-m", "v1.0.0").Run()
+	exec.Command("git", "commit", "--allow-empty", "-m", "fix: one").Run()
+
+	if _, err := runStatus(true); err != nil {
+		t.Fatalf("runStatus failed: %v", err)
+	}
+	if _, err := runTagsDiffPlain(formatJSON, true); err != nil {
+		t.Fatalf("runTagsDiffPlain failed: %v", err)
+	}
+	commits, _ := GetCommitHistory(5, false, "", "")
+	WriteStepSummary(stackMarkdown(commits))
+
+	content, _ := os.ReadFile(path)
+	summary := string(content)
+	for _, want := range []string{"## Repository status\n\n- On branch ", "- 1 commit since v1.0.0\n", "## Changes since v1.0.0", "| fix: one |", "## Recent commits", "| Initial commit | Test User |"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Expected %q in the step summary, got:\n%s", want, summary)
+		}
+	}
+}
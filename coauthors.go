@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// coAuthorTrailer is the trailer GitHub and GitLab read to credit pair partners
+const coAuthorTrailer = "Co-authored-by"
+
+var coAuthorRe = regexp.MustCompile(`^([^<>]+?)\s*<([^<>\s]+@[^<>\s]+)>$`)
+
+// ParseCoAuthor checks a "Name <email>" co-author and returns it with the
+// spacing normalized
+func ParseCoAuthor(s string) (string, error) {
+	match := coAuthorRe.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return "", fmt.Errorf("invalid co-author '%s' (use \"Name <email>\")", s)
+	}
+	return fmt.Sprintf("%s <%s>", strings.TrimSpace(match[1]), match[2]), nil
+}
+
+// coAuthorEmail is the lowercased email of a co-author, to spot duplicates
+func coAuthorEmail(coAuthor string) string {
+	if match := coAuthorRe.FindStringSubmatch(coAuthor); match != nil {
+		return strings.ToLower(match[2])
+	}
+	return strings.ToLower(coAuthor)
+}
+
+// AddCoAuthors appends a Co-authored-by trailer for each co-author the
+// message doesn't credit yet, matching them by email
+func AddCoAuthors(message string, coAuthors []string) string {
+	credited := map[string]bool{}
+	for _, line := range strings.Split(message, "\n") {
+		if value, ok := strings.CutPrefix(line, coAuthorTrailer+": "); ok {
+			credited[coAuthorEmail(value)] = true
+		}
+	}
+
+	var trailers []string
+	for _, coAuthor := range coAuthors {
+		if email := coAuthorEmail(coAuthor); !credited[email] {
+			credited[email] = true
+			trailers = append(trailers, coAuthorTrailer+": "+coAuthor)
+		}
+	}
+	return AddTrailers(message, trailers)
+}
+
+// coAuthorChoices lists the save.co_authors followed by any other co-authors
+// given with --co-author, and which of them are picked
+func coAuthorChoices(configured, picked []string) ([]string, []bool) {
+	var choices []string
+	var selected []bool
+	seen := map[string]int{}
+	for _, coAuthor := range configured {
+		if normalized, err := ParseCoAuthor(coAuthor); err == nil {
+			coAuthor = normalized
+		}
+		seen[coAuthorEmail(coAuthor)] = len(choices)
+		choices = append(choices, coAuthor)
+		selected = append(selected, false)
+	}
+	for _, coAuthor := range picked {
+		if i, ok := seen[coAuthorEmail(coAuthor)]; ok {
+			selected[i] = true
+			continue
+		}
+		seen[coAuthorEmail(coAuthor)] = len(choices)
+		choices = append(choices, coAuthor)
+		selected = append(selected, true)
+	}
+	return choices, selected
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseCoAuthor(t *testing.T) {
+	if got, err := ParseCoAuthor("  Jane Doe<jane@example.com> "); err != nil || got != "Jane Doe <jane@example.com>" {
+		t.Errorf("Expected a normalized co-author, got %q (%v)", got, err)
+	}
+	for _, bad := range []string{"Jane Doe", "<jane@example.com>", "Jane <jane>"} {
+		if _, err := ParseCoAuthor(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestAddCoAuthors(t *testing.T) {
+	jane := "Jane Doe <jane@example.com>"
+	bob := "Bob <bob@example.com>"
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"subject only", "fix: typo", "fix: typo\n\nCo-authored-by: " + jane + "\nCo-authored-by: " + bob},
+		{"after body", "fix: typo\n\n- one", "fix: typo\n\n- one\n\nCo-authored-by: " + jane + "\nCo-authored-by: " + bob},
+		{"joins trailers", "fix: typo\n\nSigned-off-by: Me <me@example.com>", "fix: typo\n\nSigned-off-by: Me <me@example.com>\nCo-authored-by: " + jane + "\nCo-authored-by: " + bob},
+		{"already credited", "fix: typo\n\nCo-authored-by: Jane <JANE@example.com>", "fix: typo\n\nCo-authored-by: Jane <JANE@example.com>\nCo-authored-by: " + bob},
+	}
+	for _, tt := range tests {
+		if got := AddCoAuthors(tt.message, []string{jane, bob}); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	choices, picked := coAuthorChoices([]string{bob, jane}, []string{"Jane <jane@example.com>", "Ann <ann@example.com>"})
+	if !slices.Equal(choices, []string{bob, jane, "Ann <ann@example.com>"}) || !slices.Equal(picked, []bool{false, true, true}) {
+		t.Errorf("Unexpected choices %v %v", choices, picked)
+	}
+}
+
+func TestRunSavePlainCoAuthors(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	os.WriteFile("pair.txt", []byte("pair\n"), 0644)
+	req := saveRequest{Message: "feat: pair", CoAuthors: []string{"Jane Doe <jane@example.com>"}}
+	if err := runSavePlain(req); err != nil {
+		t.Fatalf("runSavePlain failed: %v", err)
+	}
+	output, _ := exec.Command("git", "log", "-1", "--format=%(trailers:key=Co-authored-by,valueonly)").Output()
+	if got := strings.TrimSpace(string(output)); got != "Jane Doe <jane@example.com>" {
+		t.Errorf("Expected the co-author trailer, got %q", got)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// codeownersPaths are where GitHub looks for CODEOWNERS, in order
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeownersRule assigns owners to the paths matching a pattern
+type CodeownersRule struct {
+	Pattern string
+	Owners  []string // Empty when the pattern removes ownership
+	re      *regexp.Regexp
+}
+
+// OwnerShare is an owner and how many of the changed files they own
+type OwnerShare struct {
+	Owner string
+	Files int
+}
+
+// LoadCodeowners reads the repository's CODEOWNERS file. It returns no rules
+// when there is none.
+func LoadCodeowners() ([]CodeownersRule, error) {
+	root, err := GetRepoRoot()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range codeownersPaths {
+		content, err := os.ReadFile(filepath.Join(root, path))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return ParseCodeowners(string(content)), nil
+	}
+	return nil, nil
+}
+
+// ParseCodeowners parses CODEOWNERS lines of a pattern followed by owners.
+// Comments, blank lines and invalid patterns are skipped.
+func ParseCodeowners(content string) []CodeownersRule {
+	var rules []CodeownersRule
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		re, err := codeownersRegexp(fields[0])
+		if err != nil {
+			continue
+		}
+		rules = append(rules, CodeownersRule{Pattern: fields[0], Owners: fields[1:], re: re})
+	}
+	return rules
+}
+
+// codeownersRegexp converts a CODEOWNERS pattern to a regular expression. As
+// in gitignore, patterns without a slash match at any depth, a leading slash
+// anchors to the root, and a match on a directory covers everything below it,
+// except that "docs/*" only matches files directly in docs.
+func codeownersRegexp(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	switch {
+	case dirOnly:
+		b.WriteString("/.*$")
+	case strings.HasSuffix(pattern, "/*") && !strings.HasSuffix(pattern, "**"):
+		b.WriteString("$")
+	default:
+		b.WriteString("(?:/.*)?$")
+	}
+	return regexp.Compile(b.String())
+}
+
+// Owners returns the owners of a path: those of the last matching rule
+func Owners(rules []CodeownersRule, path string) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].re.MatchString(path) {
+			return rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// ReviewOwners counts the changed files per owner, most files first
+func ReviewOwners(rules []CodeownersRule, stats []FileDiffStat) []OwnerShare {
+	counts := map[string]int{}
+	for _, file := range stats {
+		for _, owner := range Owners(rules, file.Path) {
+			counts[owner]++
+		}
+	}
+
+	shares := make([]OwnerShare, 0, len(counts))
+	for owner, files := range counts {
+		shares = append(shares, OwnerShare{Owner: owner, Files: files})
+	}
+	slices.SortFunc(shares, func(a, b OwnerShare) int {
+		if a.Files != b.Files {
+			return b.Files - a.Files
+		}
+		return strings.Compare(a.Owner, b.Owner)
+	})
+	return shares
+}
+
+// stagedOwners loads CODEOWNERS and returns who owns the staged files. A
+// missing or unreadable file just means no hints.
+func stagedOwners(stats []FileDiffStat) []OwnerShare {
+	rules, err := LoadCodeowners()
+	if err != nil || len(rules) == 0 {
+		return nil
+	}
+	return ReviewOwners(rules, stats)
+}
+
+// ownersSummary formats the owners, e.g. "@acme/auth (3 files), @bob (1 file)"
+func ownersSummary(shares []OwnerShare) string {
+	parts := make([]string, len(shares))
+	for i, share := range shares {
+		if share.Files == 1 {
+			parts[i] = share.Owner + " (1 file)"
+		} else {
+			parts[i] = fmt.Sprintf("%s (%d files)", share.Owner, share.Files)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCodeownersMatching(t *testing.T) {
+	rules := ParseCodeowners(`# Default owners
+*                 @acme/core
+*.js              @acme/web   # any depth
+/build/           @acme/infra
+docs/*            @acme/docs
+apps/             @alice
+**/migrations     @acme/db
+/src/auth/vendor  # no owners
+`)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"main.go", "@acme/core"},
+		{"web/app/index.js", "@acme/web"},
+		{"build/ci/run.sh", "@acme/infra"},
+		{"tools/build/run.sh", "@acme/core"}, // /build/ is anchored
+		{"docs/intro.md", "@acme/docs"},
+		{"docs/guide/setup.md", "@acme/core"}, // docs/* is one level only
+		{"services/apps/api/main.go", "@alice"},
+		{"db/migrations/001.sql", "@acme/db"},
+		{"src/auth/vendor/lib.go", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if owners := Owners(rules, tt.path); len(owners) > 0 {
+			got = owners[0]
+		}
+		if got != tt.want {
+			t.Errorf("Owners(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestReviewOwners(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	stats := []FileDiffStat{{Path: "auth/login.go"}, {Path: "auth/session.go"}, {Path: "README.md"}}
+	if owners := stagedOwners(stats); owners != nil {
+		t.Errorf("Expected no owners without CODEOWNERS, got %v", owners)
+	}
+
+	os.MkdirAll(".github", 0755)
+	os.WriteFile(".github/CODEOWNERS", []byte("* @bob\n/auth/ @acme/auth @bob\n"), 0644)
+	owners := stagedOwners(stats)
+	if got, want := ownersSummary(owners), "@bob (3 files), @acme/auth (2 files)"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
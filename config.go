@@ -0,0 +1,839 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Color palette used by all views. Values come from the [colors] config section.
+var (
+	colorPrimary   = lipgloss.Color("#7D56F4") // Titles, cursors, links
+	colorSuccess   = lipgloss.Color("#04B575") // Success messages, additions
+	colorDanger    = lipgloss.Color("#FF5555") // Deletions, destructive actions
+	colorError     = lipgloss.Color("#FF0000") // Error messages
+	colorModified  = lipgloss.Color("#FF8800") // Modified files
+	colorWarning   = lipgloss.Color("#FFAA00") // Commit hashes, warnings
+	colorMuted     = lipgloss.Color("#888888") // Secondary text, help
+	colorText      = lipgloss.Color("#FFFFFF") // Primary text
+	colorHighlight = lipgloss.Color("#FFFF00") // Prompts
+)
+
+// repoConfigFile is the per-repository override, placed in the repository root
+const repoConfigFile = ".snap.toml"
+
+// repoRestrictedKeys can only be set in the global config. They pick where
+// diffs, API keys and tokens are sent, which a cloned repository must not decide.
+var repoRestrictedKeys = []string{
+	"ai.provider", "ai.fallback", "ai.redact",
+	"ollama.url",
+	"openai.url", "openai.api_key",
+	"anthropic.url", "anthropic.api_key",
+	"tickets.provider", "tickets.url",
+	"announce.webhook",
+	"forges.token_hosts",
+}
+
+// modelEnvVar overrides the model of the selected provider from the config files
+const modelEnvVar = "SNAP_MODEL"
+
+// hostEnvVar overrides ollama.url, using the same variable as the ollama CLI
+const hostEnvVar = "OLLAMA_HOST"
+
+// defaultOllamaPort is added to Ollama hosts given without a port
+const defaultOllamaPort = "11434"
+
+// Config holds user settings from ~/.config/snap/config.toml and .snap.toml
+type Config struct {
+	// Mode is "standard", or "beginner" to hide history rewrites and detached
+	// checkouts, and to confirm branch deletes by typing the name
+	Mode string `toml:"mode"`
+
+	AI         AIConfig         `toml:"ai"`
+	Ollama     OllamaConfig     `toml:"ollama"`
+	OpenAI     OpenAIConfig     `toml:"openai"`
+	Anthropic  AnthropicConfig  `toml:"anthropic"`
+	Save       SaveConfig       `toml:"save"`
+	Convention ConventionConfig `toml:"convention"`
+	Sync       SyncConfig       `toml:"sync"`
+	Tickets    TicketsConfig    `toml:"tickets"`
+	Announce   AnnounceConfig   `toml:"announce"`
+	Forges     ForgesConfig     `toml:"forges"`
+	Checks     ChecksConfig     `toml:"checks"`
+	Changes    ChangesConfig    `toml:"changes"`
+	Stack      StackConfig      `toml:"stack"`
+	Tags       TagsConfig       `toml:"tags"`
+	Changelog  ChangelogConfig  `toml:"changelog"`
+	Colors     ColorConfig      `toml:"colors"`
+
+	// Scopes maps path prefixes to commit scopes, e.g. "services/billing" = "billing"
+	Scopes map[string]string `toml:"scopes"`
+
+	// Profiles are commit identities for directory trees, e.g. [profiles.work]
+	Profiles map[string]ProfileConfig `toml:"profiles"`
+
+	// Prices are USD per million input and output tokens of hosted models,
+	// e.g. "gpt-4o-mini" = [0.15, 0.60], for the costs in snap ai usage
+	Prices map[string][]float64 `toml:"prices"`
+
+	files        []string // Config files that were loaded, in order
+	tagsSet      bool     // A config file has [tags], so snap.tagPrefix and snap.tagSemver in git config are ignored
+	changelogSet bool     // A config file has [changelog], so snap.changelogExclude in git config is ignored
+}
+
+// AIConfig selects the backend for AI commit messages
+type AIConfig struct {
+	Provider   string `toml:"provider"`    // "ollama", "openai", or "anthropic"
+	Style      string `toml:"style"`       // "conventional" or "gitmoji"
+	InferScope bool   `toml:"infer_scope"` // Add a scope derived from the changed paths
+	Examples   int    `toml:"examples"`    // Recent commit subjects shown to the model as style examples
+	Workers    int    `toml:"workers"`     // Chunks of a large diff summarized at once
+	Redact     bool   `toml:"redact"`      // Mask secrets in diffs before they are sent
+	Cache      bool   `toml:"cache"`       // Reuse summaries and messages for an unchanged diff
+	Usage      bool   `toml:"usage"`       // Log tokens of hosted providers for snap ai usage
+
+	// Fallback lists providers tried in order when ai.provider fails or times
+	// out, e.g. ["anthropic", "heuristic"]
+	Fallback []string `toml:"fallback"`
+
+	// RedactRules are regular expressions, by name, whose matches are masked
+	// in every diff sent to the AI, whether or not ai.redact is on
+	RedactRules map[string]string `toml:"redact_rules"`
+}
+
+// maxExamples caps ai.examples to keep prompts small enough for local models
+const maxExamples = 50
+
+// maxWorkers caps ai.workers; more parallel requests only queue up in Ollama
+const maxWorkers = 16
+
+// Supported values for mode
+const (
+	modeStandard = "standard"
+	modeBeginner = "beginner"
+)
+
+// Supported values for ai.provider
+const (
+	providerOllama    = "ollama"
+	providerOpenAI    = "openai"
+	providerAnthropic = "anthropic"
+)
+
+// providers lists the valid values for ai.provider
+var providers = []string{providerOllama, providerOpenAI, providerAnthropic}
+
+// Supported values for ai.style
+const (
+	styleConventional = "conventional" // feat: add login flow
+	styleGitmoji      = "gitmoji"      // ✨ feat: add login flow
+)
+
+// styles lists the valid values for ai.style
+var styles = []string{styleConventional, styleGitmoji}
+
+// OllamaConfig configures the Ollama provider
+type OllamaConfig struct {
+	Model     string `toml:"model"`
+	URL       string `toml:"url"`
+	Timeout   string `toml:"timeout"`    // Longest wait for an answer (or the next streamed token), e.g. "2m"
+	Retries   int    `toml:"retries"`    // Further attempts after a timeout or server error
+	KeepAlive string `toml:"keep_alive"` // How long the model stays loaded, e.g. "10m", or -1 for always
+}
+
+// maxOllamaRetries caps ollama.retries; backoff doubles with every attempt
+const maxOllamaRetries = 5
+
+// timeout returns ollama.timeout as a duration; validate rejects bad values
+func (c OllamaConfig) timeout() time.Duration {
+	d, _ := time.ParseDuration(c.Timeout)
+	return d
+}
+
+// OpenAIConfig configures the provider for OpenAI-compatible APIs
+type OpenAIConfig struct {
+	Model  string `toml:"model"`
+	URL    string `toml:"url"` // Base URL up to /v1, e.g. https://api.groq.com/openai/v1
+	APIKey string `toml:"api_key"`
+}
+
+// defaultOpenAIURL is the official OpenAI endpoint
+const defaultOpenAIURL = "https://api.openai.com/v1"
+
+// openAIKeyEnvVar is used when openai.api_key isn't set, so keys can stay out of config files
+const openAIKeyEnvVar = "OPENAI_API_KEY"
+
+// apiKey returns the configured API key, falling back to $OPENAI_API_KEY
+func (c OpenAIConfig) apiKey() string {
+	if c.APIKey != "" {
+		return c.APIKey
+	}
+	return os.Getenv(openAIKeyEnvVar)
+}
+
+// AnthropicConfig configures the Claude provider
+type AnthropicConfig struct {
+	Model  string `toml:"model"`
+	URL    string `toml:"url"`
+	APIKey string `toml:"api_key"`
+}
+
+// defaultAnthropicURL is the official Anthropic API endpoint
+const defaultAnthropicURL = "https://api.anthropic.com"
+
+// anthropicKeyEnvVar is used when anthropic.api_key isn't set
+const anthropicKeyEnvVar = "ANTHROPIC_API_KEY"
+
+// apiKey returns the configured API key, falling back to $ANTHROPIC_API_KEY
+func (c AnthropicConfig) apiKey() string {
+	if c.APIKey != "" {
+		return c.APIKey
+	}
+	return os.Getenv(anthropicKeyEnvVar)
+}
+
+// SaveConfig holds defaults for snap save
+type SaveConfig struct {
+	Seed       int      `toml:"seed"`
+	Candidates int      `toml:"candidates"`  // AI messages to choose from, 1 to maxCandidates
+	Body       bool     `toml:"body"`        // Also generate a bulleted commit body
+	Select     bool     `toml:"select"`      // Pick the files to commit before staging
+	StagedOnly bool     `toml:"staged_only"` // Commit the index as it is, without staging anything
+	Exclude    []string `toml:"exclude"`     // Glob patterns never staged, e.g. "*.lock" or "dist/**"
+	Push       bool     `toml:"push"`        // Sync (pull, then push) after committing
+	CoAuthors  []string `toml:"co_authors"`  // Frequent pair partners, "Name <email>", to pick from
+	Signoff    bool     `toml:"signoff"`     // Add Signed-off-by, for projects that enforce the DCO
+	Editor     bool     `toml:"editor"`      // e opens $EDITOR instead of the one-line input
+	Protected  []string `toml:"protected"`   // Branches (globs) where save offers a new branch first; empty turns it off
+	Junk       []string `toml:"junk"`        // Untracked paths save offers to .gitignore, e.g. "node_modules/"; empty turns it off
+	// Trailers added to every commit, e.g. {"Ticket" = "{ticket}"}; {ticket} is
+	// the ticket ID in the branch name, and the trailer is skipped without one
+	Trailers map[string]string `toml:"trailers"`
+}
+
+// maxCandidates caps the parallel requests snap save makes for one commit
+const maxCandidates = 5
+
+// ConventionConfig is the commit convention AI messages are asked for and
+// checked against
+type ConventionConfig struct {
+	Types        []string `toml:"types"`         // Allowed commit types
+	MaxSubject   int      `toml:"max_subject"`   // Longest subject line, in characters
+	RequireScope bool     `toml:"require_scope"` // Subjects must look like type(scope): ...
+}
+
+// SyncConfig sets the remotes snap sync uses. With upstream set (fork
+// workflows), sync pulls from upstream and pushes to origin.
+type SyncConfig struct {
+	Upstream string `toml:"upstream"` // Remote to pull from, e.g. "upstream"; empty uses the branch's tracking remote
+	Origin   string `toml:"origin"`   // Remote to push to when upstream is set
+}
+
+// TicketsConfig sets where ticket IDs in commit messages (e.g. ENG-123) are
+// looked up for release notes
+type TicketsConfig struct {
+	Provider string   `toml:"provider"` // "jira" or "linear"; empty turns the lookup off
+	URL      string   `toml:"url"`      // Jira site, e.g. https://corp.atlassian.net
+	Keys     []string `toml:"keys"`     // Project keys to look up, e.g. ["ENG"]; empty looks up every ID
+}
+
+// Supported values for tickets.provider
+const (
+	ticketsJira   = "jira"
+	ticketsLinear = "linear"
+)
+
+// AnnounceConfig sets the chat webhook new releases are announced to after
+// snap tags create
+type AnnounceConfig struct {
+	Webhook string `toml:"webhook"` // Incoming webhook URL; empty turns announcements off
+	Format  string `toml:"format"`  // "slack", "discord" or "teams"; empty guesses from the URL
+}
+
+// Supported values for announce.format
+var announceFormats = []string{"slack", "discord", "teams"}
+
+// ForgesConfig sets which hosts besides github.com and gitlab.com may get
+// $GITHUB_TOKEN or $GITLAB_TOKEN, e.g. a GitHub Enterprise server
+type ForgesConfig struct {
+	TokenHosts []string `toml:"token_hosts"` // Host names, e.g. ["github.corp.com"]
+}
+
+// ProfileConfig is the identity expected for repositories below Paths
+type ProfileConfig struct {
+	Name  string   `toml:"name"`  // Expected user.name; empty accepts any
+	Email string   `toml:"email"` // Expected user.email
+	Paths []string `toml:"paths"` // Directory trees, e.g. "~/work"
+}
+
+// ChecksConfig sets what snap save looks for in the lines a commit adds
+type ChecksConfig struct {
+	ConflictMarkers bool     `toml:"conflict_markers"` // Block committing <<<<<<< and >>>>>>> lines
+	Secrets         bool     `toml:"secrets"`          // Block committing API keys, tokens, private keys and .env files
+	Patterns        []string `toml:"patterns"`         // Regular expressions to warn about, e.g. "FIXME"
+	BlockPatterns   bool     `toml:"block_patterns"`   // Block instead of warning when a pattern matches
+	Whitespace      bool     `toml:"whitespace"`       // Point out whitespace-only hunks, mixed line endings and missing final newlines
+	Duplicates      int      `toml:"duplicates"`       // Warn when the subject repeats one of the last N commit subjects; 0 turns it off
+	MaxFileMB       int      `toml:"max_file_mb"`      // Warn about staged files larger than this many MB and suggest Git LFS; 0 turns it off
+}
+
+// ChangesConfig holds defaults for snap changes
+type ChangesConfig struct {
+	Expand  bool `toml:"expand"`
+	Ignored bool `toml:"ignored"`
+}
+
+// StackConfig holds defaults for snap stack
+type StackConfig struct {
+	Limit int  `toml:"limit"`
+	All   bool `toml:"all"`
+}
+
+// TagsConfig is the naming policy release tags must follow
+type TagsConfig struct {
+	Prefixes []string `toml:"prefixes"` // Allowed prefixes, e.g. ["api/v", "web/v"] for monorepos
+	Semver   bool     `toml:"semver"`   // Require a semantic version after the prefix
+}
+
+// ChangelogConfig sets what tag messages and release notes list
+type ChangelogConfig struct {
+	Exclude []string `toml:"exclude"` // Commit types left out, e.g. ["chore", "ci"]; empty lists all
+}
+
+// ColorConfig overrides the color palette with hex colors like "#7D56F4"
+type ColorConfig struct {
+	Primary   string `toml:"primary"`
+	Success   string `toml:"success"`
+	Danger    string `toml:"danger"`
+	Error     string `toml:"error"`
+	Modified  string `toml:"modified"`
+	Warning   string `toml:"warning"`
+	Muted     string `toml:"muted"`
+	Text      string `toml:"text"`
+	Highlight string `toml:"highlight"`
+}
+
+// config is the active configuration, loaded at startup
+var config = defaultConfig()
+
+// defaultConfig returns the built-in settings
+func defaultConfig() Config {
+	return Config{
+		Mode: modeStandard,
+		AI: AIConfig{
+			Provider:   providerOllama,
+			Style:      styleConventional,
+			InferScope: true,
+			Examples:   10,
+			Workers:    4,
+			Redact:     true,
+			Cache:      true,
+			Usage:      true,
+		},
+		Ollama: OllamaConfig{
+			Model:     "llama3.2:3b",
+			URL:       "http://localhost:11434",
+			Timeout:   "2m",
+			Retries:   2,
+			KeepAlive: "10m",
+		},
+		OpenAI: OpenAIConfig{
+			Model: "gpt-4o-mini",
+			URL:   defaultOpenAIURL,
+		},
+		Anthropic: AnthropicConfig{
+			Model: "claude-haiku-4-5",
+			URL:   defaultAnthropicURL,
+		},
+		Save: SaveConfig{
+			Seed:       42,
+			Candidates: 3,
+			Protected:  []string{"main", "master"},
+			Junk:       slices.Clone(defaultJunk), // Cloned, since decoding a config file writes into it
+		},
+		Convention: ConventionConfig{
+			Types:      []string{"feat", "fix", "docs", "style", "refactor", "test", "chore"},
+			MaxSubject: 72,
+		},
+		Sync: SyncConfig{
+			Origin: "origin",
+		},
+		Checks: ChecksConfig{
+			ConflictMarkers: true,
+			Secrets:         true,
+			Whitespace:      true,
+			Duplicates:      20,
+			MaxFileMB:       10,
+		},
+		Stack: StackConfig{
+			Limit: 50,
+		},
+		Tags: TagsConfig{
+			Prefixes: slices.Clone(defaultTagPolicy.Prefixes),
+			Semver:   defaultTagPolicy.RequireSemver,
+		},
+		Changelog: ChangelogConfig{
+			Exclude: slices.Clone(defaultChangelogExclude),
+		},
+	}
+}
+
+// globalConfigPath returns the user config file, honoring $XDG_CONFIG_HOME
+func globalConfigPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "snap", "config.toml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "snap", "config.toml"), nil
+}
+
+// repoConfigPath returns the per-repository config file, or "" outside a repository
+func repoConfigPath() string {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(strings.TrimSpace(string(output)), repoConfigFile)
+}
+
+// LoadConfig reads the global config and then the per-repository override on top
+// of the defaults, followed by environment overrides. Missing files are skipped;
+// invalid files, and repository files setting repoRestrictedKeys, are an error.
+func LoadConfig() (Config, error) {
+	cfg := defaultConfig()
+
+	paths := []string{}
+	if path, err := globalConfigPath(); err == nil {
+		paths = append(paths, path)
+	}
+	repoPath := repoConfigPath()
+	if repoPath != "" {
+		paths = append(paths, repoPath)
+	}
+
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		// Decoding into the same struct only overrides keys present in the file
+		meta, err := toml.DecodeFile(path, &cfg)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return Config{}, fmt.Errorf("%s: unknown setting '%s'", path, undecoded[0])
+		}
+		if path == repoPath {
+			for _, key := range meta.Keys() {
+				if slices.Contains(repoRestrictedKeys, key.String()) {
+					return Config{}, fmt.Errorf("%s: %s can only be set in the global config", path, key)
+				}
+			}
+		}
+		cfg.files = append(cfg.files, path)
+		if meta.IsDefined("tags") {
+			cfg.tagsSet = true
+		}
+		if meta.IsDefined("changelog") {
+			cfg.changelogSet = true
+		}
+	}
+
+	if model := os.Getenv(modelEnvVar); model != "" {
+		cfg.SetModel(model)
+	}
+	cfg.OpenAI.URL = strings.TrimSuffix(cfg.OpenAI.URL, "/")
+	cfg.Anthropic.URL = strings.TrimSuffix(cfg.Anthropic.URL, "/")
+	urlSource := "ollama.url"
+	if host := os.Getenv(hostEnvVar); host != "" {
+		cfg.Ollama.URL = host
+		urlSource = hostEnvVar
+	}
+	ollamaURL, err := NormalizeOllamaHost(cfg.Ollama.URL)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", urlSource, err)
+	}
+	cfg.Ollama.URL = ollamaURL
+
+	if err := cfg.validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// NormalizeOllamaHost turns an Ollama host as accepted by OLLAMA_HOST ("host",
+// "host:port", or a full URL) into a base URL. Like the ollama CLI, plain http
+// hosts without a port use 11434.
+func NormalizeOllamaHost(host string) (string, error) {
+	host = strings.TrimSpace(host)
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+
+	u, err := url.Parse(host)
+	if err != nil {
+		return "", fmt.Errorf("invalid ollama host '%s': %w", host, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("ollama host must use http or https (got '%s')", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("ollama host is missing a hostname")
+	}
+	if u.Port() == "" && u.Scheme == "http" {
+		u.Host = net.JoinHostPort(u.Hostname(), defaultOllamaPort)
+	}
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+var hexColorRe = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// commitTypeRe matches types that ParseConventionalCommit accepts
+var commitTypeRe = regexp.MustCompile(`^[a-z]+$`)
+
+// scopeRe matches scopes that fit in a conventional commit subject
+var scopeRe = regexp.MustCompile(`^[\w./-]+$`)
+
+// validate checks values that would otherwise fail later in confusing ways
+func (c Config) validate() error {
+	if c.Mode != modeStandard && c.Mode != modeBeginner {
+		return fmt.Errorf("mode must be %s or %s (got '%s')", modeStandard, modeBeginner, c.Mode)
+	}
+	if !slices.Contains(providers, c.AI.Provider) {
+		return fmt.Errorf("ai.provider must be one of %s (got '%s')", strings.Join(providers, ", "), c.AI.Provider)
+	}
+	for i, name := range c.AI.Fallback {
+		switch {
+		case name == providerHeuristic && i != len(c.AI.Fallback)-1:
+			return fmt.Errorf("ai.fallback can only end with %s, it never fails", providerHeuristic)
+		case name != providerHeuristic && !slices.Contains(providers, name):
+			return fmt.Errorf("ai.fallback entries must be one of %s, %s (got '%s')", strings.Join(providers, ", "), providerHeuristic, name)
+		case name == c.AI.Provider || slices.Contains(c.AI.Fallback[:i], name):
+			return fmt.Errorf("ai.fallback lists %s twice (ai.provider counts)", name)
+		}
+	}
+	if _, err := c.AI.redactRules(); err != nil {
+		return err
+	}
+	if !slices.Contains(styles, c.AI.Style) {
+		return fmt.Errorf("ai.style must be one of %s (got '%s')", strings.Join(styles, ", "), c.AI.Style)
+	}
+	if c.AI.Examples < 0 || c.AI.Examples > maxExamples {
+		return fmt.Errorf("ai.examples must be between 0 and %d (got %d)", maxExamples, c.AI.Examples)
+	}
+	if c.AI.Workers < 1 || c.AI.Workers > maxWorkers {
+		return fmt.Errorf("ai.workers must be between 1 and %d (got %d)", maxWorkers, c.AI.Workers)
+	}
+	if c.Ollama.Model == "" {
+		return fmt.Errorf("ollama.model cannot be empty")
+	}
+	if d, err := time.ParseDuration(c.Ollama.Timeout); err != nil || d <= 0 {
+		return fmt.Errorf("ollama.timeout must be a duration like \"2m\" (got '%s')", c.Ollama.Timeout)
+	}
+	if c.Ollama.Retries < 0 || c.Ollama.Retries > maxOllamaRetries {
+		return fmt.Errorf("ollama.retries must be between 0 and %d (got %d)", maxOllamaRetries, c.Ollama.Retries)
+	}
+	if c.Ollama.KeepAlive != "" {
+		_, durationErr := time.ParseDuration(c.Ollama.KeepAlive)
+		_, secondsErr := strconv.Atoi(c.Ollama.KeepAlive)
+		if durationErr != nil && secondsErr != nil {
+			return fmt.Errorf("ollama.keep_alive must be a duration like \"10m\" or seconds like \"-1\" (got '%s')", c.Ollama.KeepAlive)
+		}
+	}
+	if c.OpenAI.Model == "" {
+		return fmt.Errorf("openai.model cannot be empty")
+	}
+	if !strings.HasPrefix(c.OpenAI.URL, "http://") && !strings.HasPrefix(c.OpenAI.URL, "https://") {
+		return fmt.Errorf("openai.url must start with http:// or https:// (got '%s')", c.OpenAI.URL)
+	}
+	if c.Anthropic.Model == "" {
+		return fmt.Errorf("anthropic.model cannot be empty")
+	}
+	if !strings.HasPrefix(c.Anthropic.URL, "http://") && !strings.HasPrefix(c.Anthropic.URL, "https://") {
+		return fmt.Errorf("anthropic.url must start with http:// or https:// (got '%s')", c.Anthropic.URL)
+	}
+	if c.Save.Candidates < 1 || c.Save.Candidates > maxCandidates {
+		return fmt.Errorf("save.candidates must be between 1 and %d (got %d)", maxCandidates, c.Save.Candidates)
+	}
+	for _, pattern := range c.Save.Exclude {
+		if strings.TrimSpace(pattern) == "" || strings.HasPrefix(pattern, ":") {
+			return fmt.Errorf("save.exclude has an invalid pattern '%s' (use globs like \"*.lock\" or \"dist/**\")", pattern)
+		}
+	}
+	for _, pattern := range c.Save.Protected {
+		if _, err := path.Match(pattern, ""); err != nil || strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("save.protected has an invalid pattern '%s' (use branch names or globs like \"release/*\")", pattern)
+		}
+	}
+	for _, pattern := range c.Save.Junk {
+		name := strings.TrimSuffix(pattern, "/")
+		if _, err := path.Match(name, ""); err != nil || strings.TrimSpace(name) == "" || strings.Contains(name, "/") {
+			return fmt.Errorf("save.junk has an invalid pattern '%s' (use names or globs like \"node_modules/\" or \"*.log\")", pattern)
+		}
+	}
+	for _, coAuthor := range c.Save.CoAuthors {
+		if _, err := ParseCoAuthor(coAuthor); err != nil {
+			return fmt.Errorf("save.co_authors has an %v", err)
+		}
+	}
+	for key, value := range c.Save.Trailers {
+		if !trailerKeyRe.MatchString(key) {
+			return fmt.Errorf("save.trailers has an invalid key '%s' (use letters, digits and '-', e.g. Reviewed-by)", key)
+		}
+		if strings.TrimSpace(value) == "" || strings.Contains(value, "\n") {
+			return fmt.Errorf("save.trailers.%s must be a single non-empty line", key)
+		}
+	}
+	if c.Save.Select && c.Save.StagedOnly {
+		return fmt.Errorf("save.select and save.staged_only can't both be set")
+	}
+	if len(c.Convention.Types) == 0 {
+		return fmt.Errorf("convention.types cannot be empty")
+	}
+	for _, t := range c.Convention.Types {
+		if !commitTypeRe.MatchString(t) {
+			return fmt.Errorf("convention.types must be lowercase words like \"feat\" (got '%s')", t)
+		}
+	}
+	for _, t := range c.Changelog.Exclude {
+		if !commitTypeRe.MatchString(t) {
+			return fmt.Errorf("changelog.exclude must be lowercase words like \"chore\" (got '%s')", t)
+		}
+	}
+	if c.Convention.MaxSubject <= 0 {
+		return fmt.Errorf("convention.max_subject must be positive (got %d)", c.Convention.MaxSubject)
+	}
+	for prefix, scope := range c.Scopes {
+		if !scopeRe.MatchString(scope) {
+			return fmt.Errorf("scopes.\"%s\" must be a word like \"api\" (got '%s')", prefix, scope)
+		}
+	}
+	for model, price := range c.Prices {
+		if len(price) != 2 || price[0] < 0 || price[1] < 0 {
+			return fmt.Errorf("prices.\"%s\" must be [input, output] USD per million tokens (got %v)", model, price)
+		}
+	}
+	for name, profile := range c.Profiles {
+		if profile.Email == "" {
+			return fmt.Errorf("profiles.%s.email cannot be empty", name)
+		}
+		if len(profile.Paths) == 0 {
+			return fmt.Errorf("profiles.%s.paths cannot be empty", name)
+		}
+	}
+	if c.Sync.Upstream != "" && c.Sync.Upstream == c.Sync.Origin {
+		return fmt.Errorf("sync.upstream and sync.origin must be different remotes (both are '%s')", c.Sync.Origin)
+	}
+	if c.Sync.Upstream != "" && c.Sync.Origin == "" {
+		return fmt.Errorf("sync.origin cannot be empty when sync.upstream is set")
+	}
+	if p := c.Tickets.Provider; p != "" && p != ticketsJira && p != ticketsLinear {
+		return fmt.Errorf("tickets.provider must be %s or %s (got '%s')", ticketsJira, ticketsLinear, p)
+	}
+	if c.Tickets.Provider == ticketsJira && c.Tickets.URL == "" {
+		return fmt.Errorf("tickets.url must be set to the Jira site, e.g. \"https://corp.atlassian.net\"")
+	}
+	if f := c.Announce.Format; f != "" && !slices.Contains(announceFormats, f) {
+		return fmt.Errorf("announce.format must be one of %s (got '%s')", strings.Join(announceFormats, ", "), f)
+	}
+	if w := c.Announce.Webhook; w != "" && !strings.HasPrefix(w, "http://") && !strings.HasPrefix(w, "https://") {
+		return fmt.Errorf("announce.webhook must start with http:// or https://")
+	}
+	for _, host := range c.Forges.TokenHosts {
+		if host == "" || strings.ContainsAny(host, "/: ") {
+			return fmt.Errorf("forges.token_hosts must list host names like github.corp.com (got '%s')", host)
+		}
+	}
+	if c.Checks.Duplicates < 0 {
+		return fmt.Errorf("checks.duplicates cannot be negative (got %d)", c.Checks.Duplicates)
+	}
+	if c.Checks.MaxFileMB < 0 {
+		return fmt.Errorf("checks.max_file_mb cannot be negative (got %d)", c.Checks.MaxFileMB)
+	}
+	for _, pattern := range c.Checks.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("checks.patterns has an invalid regular expression '%s': %v", pattern, err)
+		}
+	}
+	if c.Stack.Limit <= 0 {
+		return fmt.Errorf("stack.limit must be positive (got %d)", c.Stack.Limit)
+	}
+	for name, value := range c.Colors.values() {
+		if value != "" && !hexColorRe.MatchString(value) {
+			return fmt.Errorf("colors.%s must be a hex color like \"#7D56F4\" (got '%s')", name, value)
+		}
+	}
+	return nil
+}
+
+// SetModel sets the model of the selected provider
+func (c *Config) SetModel(model string) {
+	switch c.AI.Provider {
+	case providerOpenAI:
+		c.OpenAI.Model = model
+	case providerAnthropic:
+		c.Anthropic.Model = model
+	default:
+		c.Ollama.Model = model
+	}
+}
+
+// Model returns the model of the selected provider
+func (c Config) Model() string {
+	switch c.AI.Provider {
+	case providerOpenAI:
+		return c.OpenAI.Model
+	case providerAnthropic:
+		return c.Anthropic.Model
+	default:
+		return c.Ollama.Model
+	}
+}
+
+// values maps config keys to the configured colors
+func (c ColorConfig) values() map[string]string {
+	return map[string]string{
+		"primary":   c.Primary,
+		"success":   c.Success,
+		"danger":    c.Danger,
+		"error":     c.Error,
+		"modified":  c.Modified,
+		"warning":   c.Warning,
+		"muted":     c.Muted,
+		"text":      c.Text,
+		"highlight": c.Highlight,
+	}
+}
+
+// beginnerMode reports whether mode = "beginner" keeps advanced and
+// destructive actions out of reach
+func beginnerMode() bool {
+	return config.Mode == modeBeginner
+}
+
+// applyConfig makes cfg the active configuration and applies its colors
+func applyConfig(cfg Config) {
+	config = cfg
+
+	colors := map[*lipgloss.Color]string{
+		&colorPrimary:   cfg.Colors.Primary,
+		&colorSuccess:   cfg.Colors.Success,
+		&colorDanger:    cfg.Colors.Danger,
+		&colorError:     cfg.Colors.Error,
+		&colorModified:  cfg.Colors.Modified,
+		&colorWarning:   cfg.Colors.Warning,
+		&colorMuted:     cfg.Colors.Muted,
+		&colorText:      cfg.Colors.Text,
+		&colorHighlight: cfg.Colors.Highlight,
+	}
+	for color, value := range colors {
+		if value != "" {
+			*color = lipgloss.Color(value)
+		}
+	}
+	initStyles()
+}
+
+// SetConfigValue sets key in [section] of a config file to a string value,
+// keeping the rest of the file (comments included) as it is. The file and its
+// directory are created when missing.
+func SetConfigValue(path, section, key, value string) error {
+	original, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	setting := fmt.Sprintf("%s = %q", key, value)
+	lines := strings.Split(strings.TrimSuffix(string(original), "\n"), "\n")
+	if len(original) == 0 {
+		lines = nil
+	}
+	keyRe := regexp.MustCompile(`^\s*` + regexp.QuoteMeta(key) + `\s*=`)
+
+	current := ""
+	header := -1 // Line of the [section] header
+	done := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			current = strings.Trim(strings.SplitN(trimmed, "#", 2)[0], "[] \t")
+			if current == section {
+				header = i
+			}
+			continue
+		}
+		if current == section && keyRe.MatchString(line) {
+			lines[i] = setting
+			done = true
+			break
+		}
+	}
+	switch {
+	case done:
+	case header >= 0:
+		lines = slices.Insert(lines, header+1, setting)
+	default:
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+section+"]", setting)
+	}
+	content := strings.Join(lines, "\n") + "\n"
+
+	// Never leave a config behind that snap can't read
+	var check Config
+	if _, err := toml.Decode(content, &check); err != nil {
+		return fmt.Errorf("%s: can't set %s.%s: %w", path, section, key, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// printConfig shows the loaded config files and the effective settings
+func printConfig(cfg Config) error {
+	if path, err := globalConfigPath(); err == nil {
+		fmt.Printf("# Global config: %s\n", path)
+	}
+	if path := repoConfigPath(); path != "" {
+		fmt.Printf("# Repository config: %s\n", path)
+	}
+	if len(cfg.files) == 0 {
+		fmt.Println("# No config files found, using defaults")
+	} else {
+		fmt.Printf("# Loaded: %s\n", strings.Join(cfg.files, ", "))
+	}
+	if os.Getenv(modelEnvVar) != "" {
+		fmt.Printf("# %s overrides %s.model\n", modelEnvVar, cfg.AI.Provider)
+	}
+	if os.Getenv(hostEnvVar) != "" {
+		fmt.Printf("# %s overrides ollama.url\n", hostEnvVar)
+	}
+	fmt.Println()
+
+	// Never print secrets
+	if cfg.OpenAI.APIKey != "" {
+		cfg.OpenAI.APIKey = "********"
+	}
+	if cfg.Anthropic.APIKey != "" {
+		cfg.Anthropic.APIKey = "********"
+	}
+	return toml.NewEncoder(os.Stdout).Encode(cfg)
+}
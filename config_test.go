@@ -0,0 +1,368 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestLoadConfigDefaults(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Ollama.Model != "llama3.2:3b" {
+		t.Errorf("Expected default model 'llama3.2:3b', got '%s'", cfg.Ollama.Model)
+	}
+	if cfg.Save.Seed != 42 {
+		t.Errorf("Expected default seed 42, got %d", cfg.Save.Seed)
+	}
+	if cfg.Stack.Limit != 50 {
+		t.Errorf("Expected default stack limit 50, got %d", cfg.Stack.Limit)
+	}
+	if len(cfg.files) != 0 {
+		t.Errorf("Expected no config files, got %v", cfg.files)
+	}
+}
+
+func TestLoadConfigRepoOverridesGlobal(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	if err := os.MkdirAll(filepath.Join(configHome, "snap"), 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+
+	global := `[ollama]
+model = "qwen2.5-coder:7b"
+
+[save]
+seed = 7
+
+[colors]
+primary = "#00FF00"
+`
+	if err := os.WriteFile(filepath.Join(configHome, "snap", "config.toml"), []byte(global), 0644); err != nil {
+		t.Fatalf("Failed to write global config: %v", err)
+	}
+
+	repo := `[save]
+seed = 123
+
+[changes]
+expand = true
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, repoConfigFile), []byte(repo), 0644); err != nil {
+		t.Fatalf("Failed to write repo config: %v", err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Ollama.Model != "qwen2.5-coder:7b" {
+		t.Errorf("Expected model from global config, got '%s'", cfg.Ollama.Model)
+	}
+	if cfg.Save.Seed != 123 {
+		t.Errorf("Expected repo config to override seed, got %d", cfg.Save.Seed)
+	}
+	if !cfg.Changes.Expand {
+		t.Error("Expected changes.expand from repo config")
+	}
+	if cfg.Stack.Limit != 50 {
+		t.Errorf("Expected unset stack limit to keep default 50, got %d", cfg.Stack.Limit)
+	}
+	if len(cfg.files) != 2 {
+		t.Errorf("Expected 2 loaded config files, got %v", cfg.files)
+	}
+
+	original := colorPrimary
+	defer applyConfig(defaultConfig())
+	defer func() { colorPrimary = original }()
+	applyConfig(cfg)
+	if colorPrimary != lipgloss.Color("#00FF00") {
+		t.Errorf("Expected primary color to be applied, got %v", colorPrimary)
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "Unknown key", content: "[save]\nsed = 1\n", wantErr: "unknown setting 'save.sed'"},
+		{name: "Bad color", content: "[colors]\nprimary = \"purple\"\n", wantErr: "colors.primary"},
+		{name: "Bad URL", content: "[ollama]\nurl = \"ftp://localhost\"\n", wantErr: "ollama.url"},
+		{name: "Unknown mode", content: "mode = \"expert\"\n", wantErr: "mode"},
+		{name: "Unknown style", content: "[ai]\nstyle = \"emoji\"\n", wantErr: "ai.style"},
+		{name: "Unknown provider", content: "[ai]\nprovider = \"gemini\"\n", wantErr: "ai.provider"},
+		{name: "Heuristic before a provider", content: "[ai]\nfallback = [\"heuristic\", \"openai\"]\n", wantErr: "ai.fallback"},
+		{name: "Fallback to the primary", content: "[ai]\nfallback = [\"ollama\"]\n", wantErr: "ai.fallback"},
+		{name: "Too many candidates", content: "[save]\ncandidates = 9\n", wantErr: "save.candidates"},
+		{name: "Negative duplicates", content: "[checks]\nduplicates = -1\n", wantErr: "checks.duplicates"},
+		{name: "Negative max file size", content: "[checks]\nmax_file_mb = -1\n", wantErr: "checks.max_file_mb"},
+		{name: "Junk pattern with a path", content: "[save]\njunk = [\"web/node_modules/\"]\n", wantErr: "save.junk"},
+		{name: "Empty exclude pattern", content: "[save]\nexclude = [\"\"]\n", wantErr: "save.exclude"},
+		{name: "Invalid protected pattern", content: "[save]\nprotected = [\"release/[\"]\n", wantErr: "save.protected"},
+		{name: "Trailer key with a space", content: "[save.trailers]\n\"Reviewed by\" = \"Jane\"\n", wantErr: "save.trailers"},
+		{name: "Co-author without email", content: "[save]\nco_authors = [\"Jane Doe\"]\n", wantErr: "save.co_authors"},
+		{name: "Select and staged only", content: "[save]\nselect = true\nstaged_only = true\n", wantErr: "save.staged_only"},
+		{name: "Price without output", content: "[prices]\n\"gpt-4o\" = [2.5]\n", wantErr: "prices.\"gpt-4o\""},
+		{name: "Bad redact rule", content: "[ai.redact_rules]\n\"ticket\" = \"ENG-(\"\n", wantErr: "ai.redact_rules.\"ticket\""},
+		{name: "Bad scope", content: "[scopes]\n\"web\" = \"front end\"\n", wantErr: "scopes.\"web\""},
+		{name: "No types", content: "[convention]\ntypes = []\n", wantErr: "convention.types"},
+		{name: "Bad type", content: "[convention]\ntypes = [\"Feat!\"]\n", wantErr: "convention.types"},
+		{name: "Bad changelog type", content: "[changelog]\nexclude = [\"Chore\"]\n", wantErr: "changelog.exclude"},
+		{name: "Zero subject length", content: "[convention]\nmax_subject = 0\n", wantErr: "convention.max_subject"},
+		{name: "No workers", content: "[ai]\nworkers = 0\n", wantErr: "ai.workers"},
+		{name: "Too many examples", content: "[ai]\nexamples = 100\n", wantErr: "ai.examples"},
+		{name: "Profile without email", content: "[profiles.work]\npaths = [\"~/work\"]\n", wantErr: "profiles.work.email"},
+		{name: "Profile without paths", content: "[profiles.work]\nemail = \"me@corp.com\"\n", wantErr: "profiles.work.paths"},
+		{name: "Bad ollama timeout", content: "[ollama]\ntimeout = \"soon\"\n", wantErr: "ollama.timeout"},
+		{name: "Too many retries", content: "[ollama]\nretries = 10\n", wantErr: "ollama.retries"},
+		{name: "Bad keep_alive", content: "[ollama]\nkeep_alive = \"forever\"\n", wantErr: "ollama.keep_alive"},
+		{name: "Same sync remotes", content: "[sync]\nupstream = \"origin\"\n", wantErr: "sync.upstream"},
+		{name: "Bad check pattern", content: "[checks]\npatterns = [\"TODO(\"]\n", wantErr: "checks.patterns"},
+		{name: "Zero limit", content: "[stack]\nlimit = 0\n", wantErr: "stack.limit"},
+		{name: "Unknown ticket provider", content: "[tickets]\nprovider = \"trello\"\n", wantErr: "tickets.provider"},
+		{name: "Jira without URL", content: "[tickets]\nprovider = \"jira\"\n", wantErr: "tickets.url"},
+		{name: "Unknown announce format", content: "[announce]\nformat = \"irc\"\n", wantErr: "announce.format"},
+		{name: "Bad webhook", content: "[announce]\nwebhook = \"hooks.slack.com/x\"\n", wantErr: "announce.webhook"},
+		{name: "Token host with a scheme", content: "[forges]\ntoken_hosts = [\"https://github.corp.com\"]\n", wantErr: "forges.token_hosts"},
+		{name: "Syntax error", content: "[save\n", wantErr: "config.toml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cleanup := setupTestRepo(t)
+			defer cleanup()
+			configHome := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", configHome)
+
+			// The global config, so settings a repository can't set are validated too
+			os.MkdirAll(filepath.Join(configHome, "snap"), 0755)
+			if err := os.WriteFile(filepath.Join(configHome, "snap", "config.toml"), []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			_, err := LoadConfig()
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing '%s', got '%v'", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigRepoRestricted(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		key     string
+	}{
+		{name: "OpenAI URL", content: "[openai]\nurl = \"https://evil.example/v1\"\n", key: "openai.url"},
+		{name: "Tickets URL", content: "[tickets]\nprovider = \"jira\"\nurl = \"https://evil.example\"\n", key: "tickets."},
+		{name: "Anthropic URL", content: "[anthropic]\nurl = \"https://evil.example\"\n", key: "anthropic.url"},
+		{name: "Ollama URL", content: "[ollama]\nurl = \"http://evil.example\"\n", key: "ollama.url"},
+		{name: "Provider", content: "[ai]\nprovider = \"openai\"\n", key: "ai.provider"},
+		{name: "Redaction off", content: "[ai]\nredact = false\n", key: "ai.redact"},
+		{name: "Token hosts", content: "[forges]\ntoken_hosts = [\"evil.example\"]\n", key: "forges.token_hosts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir, cleanup := setupTestRepo(t)
+			defer cleanup()
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+			if err := os.WriteFile(filepath.Join(tmpDir, repoConfigFile), []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write repo config: %v", err)
+			}
+
+			_, err := LoadConfig()
+			if err == nil || !strings.Contains(err.Error(), tt.key) || !strings.Contains(err.Error(), "global config") {
+				t.Errorf("Expected %s to be refused in %s, got %v", tt.key, repoConfigFile, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigModelEnv(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if err := os.WriteFile(filepath.Join(tmpDir, repoConfigFile), []byte("[ollama]\nmodel = \"llama3\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write repo config: %v", err)
+	}
+
+	t.Setenv(modelEnvVar, "mistral")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Ollama.Model != "mistral" {
+		t.Errorf("Expected %s to override the config file, got '%s'", modelEnvVar, cfg.Ollama.Model)
+	}
+}
+
+func TestNormalizeOllamaHost(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected string
+		wantErr  bool
+	}{
+		{host: "http://localhost:11434", expected: "http://localhost:11434"},
+		{host: "192.168.1.20", expected: "http://192.168.1.20:11434"},
+		{host: "ollama.lan:8080", expected: "http://ollama.lan:8080"},
+		{host: "http://ollama.lan/", expected: "http://ollama.lan:11434"},
+		{host: "https://ollama.example.com", expected: "https://ollama.example.com"},
+		{host: "https://proxy.example.com/ollama", expected: "https://proxy.example.com/ollama"},
+		{host: "[::1]:11434", expected: "http://[::1]:11434"},
+		{host: "ftp://ollama.lan", wantErr: true},
+		{host: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			result, err := NormalizeOllamaHost(tt.host)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeOllamaHost failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("NormalizeOllamaHost(%q) = %q, want %q", tt.host, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadConfigHostEnv(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	t.Setenv(hostEnvVar, "gpu-box.lan")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Ollama.URL != "http://gpu-box.lan:11434" {
+		t.Errorf("Expected %s to set the URL, got '%s'", hostEnvVar, cfg.Ollama.URL)
+	}
+
+	t.Setenv(hostEnvVar, "ftp://gpu-box.lan")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), hostEnvVar) {
+		t.Errorf("Expected error naming %s, got %v", hostEnvVar, err)
+	}
+}
+
+func TestLoadConfigOpenAIProvider(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	os.MkdirAll(filepath.Join(configHome, "snap"), 0755)
+
+	content := `[ai]
+provider = "openai"
+
+[openai]
+url = "https://api.groq.com/openai/v1/"
+`
+	if err := os.WriteFile(filepath.Join(configHome, "snap", "config.toml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	t.Setenv(modelEnvVar, "llama-3.1-8b-instant")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.OpenAI.URL != "https://api.groq.com/openai/v1" {
+		t.Errorf("Expected trailing slash to be trimmed, got '%s'", cfg.OpenAI.URL)
+	}
+	if cfg.OpenAI.Model != "llama-3.1-8b-instant" {
+		t.Errorf("Expected %s to set the OpenAI model, got '%s'", modelEnvVar, cfg.OpenAI.Model)
+	}
+	if cfg.Ollama.Model != "llama3.2:3b" {
+		t.Errorf("Expected the Ollama model to stay unchanged, got '%s'", cfg.Ollama.Model)
+	}
+}
+
+func TestCurrentProvider(t *testing.T) {
+	defer applyConfig(config)
+
+	cfg := defaultConfig()
+	cfg.AI.Provider = providerAnthropic
+	cfg.SetModel("claude-sonnet-4-5")
+	applyConfig(cfg)
+
+	p, ok := CurrentProvider().(anthropicProvider)
+	if !ok {
+		t.Fatalf("Expected the Claude provider, got %T", CurrentProvider())
+	}
+	if p.model != "claude-sonnet-4-5" || p.baseURL != defaultAnthropicURL {
+		t.Errorf("Unexpected provider settings %+v", p)
+	}
+
+	cfg.AI.Provider = providerOllama
+	applyConfig(cfg)
+	if _, ok := CurrentProvider().(ollamaProvider); !ok {
+		t.Errorf("Expected the Ollama provider, got %T", CurrentProvider())
+	}
+}
+
+func TestSetConfigValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snap", "config.toml")
+
+	// A missing file is created
+	if err := SetConfigValue(path, "ollama", "model", "mistral"); err != nil {
+		t.Fatalf("SetConfigValue failed: %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "[ollama]\nmodel = \"mistral\"\n" {
+		t.Errorf("Unexpected new file:\n%s", content)
+	}
+
+	original := "# My settings\n[ai]\nprovider = \"ollama\"\nmodel = \"not this one\"\n\n[ollama] # local\nurl = \"http://gpu-box:11434\"\nmodel = \"mistral\" # fast\n"
+	os.WriteFile(path, []byte(original), 0644)
+	if err := SetConfigValue(path, "ollama", "model", "qwen2.5:7b"); err != nil {
+		t.Fatalf("SetConfigValue failed: %v", err)
+	}
+	want := strings.Replace(original, "model = \"mistral\" # fast", "model = \"qwen2.5:7b\"", 1)
+	if content, _ := os.ReadFile(path); string(content) != want {
+		t.Errorf("Expected only the ollama model to change, got:\n%s", content)
+	}
+
+	// The key is added to an existing section, and missing sections are appended
+	os.WriteFile(path, []byte("[ollama]\nurl = \"http://gpu-box:11434\"\n"), 0644)
+	SetConfigValue(path, "ollama", "model", "mistral")
+	SetConfigValue(path, "openai", "model", "gpt-4o")
+	want = "[ollama]\nmodel = \"mistral\"\nurl = \"http://gpu-box:11434\"\n\n[openai]\nmodel = \"gpt-4o\"\n"
+	if content, _ := os.ReadFile(path); string(content) != want {
+		t.Errorf("Expected the model in [ollama] and a new [openai] section, got:\n%s", content)
+	}
+
+	// Values that would break the file are refused
+	os.WriteFile(path, []byte("[ollama]\nurl = \"http://gpu-box:11434\"\n"), 0644)
+	if err := SetConfigValue(path, "save", "seed", "x"); err == nil {
+		t.Error("Expected an error for a value of the wrong type")
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConflictHunk is one <<<<<<< ... >>>>>>> block of a conflicted file
+type ConflictHunk struct {
+	Ours        string
+	Base        string // Only present with merge.conflictStyle = diff3 or zdiff3
+	Theirs      string
+	OursLabel   string // Text after <<<<<<<, e.g. "HEAD"
+	TheirsLabel string // Text after >>>>>>>, e.g. "abc1234 (feat: add x)"
+	raw         string // The block including its markers
+}
+
+// ConflictFile is a conflicted file split into the text between conflicts and
+// the conflict hunks. parts always has one more entry than Hunks.
+type ConflictFile struct {
+	Path  string
+	Hunks []ConflictHunk
+	parts []string
+}
+
+// Conflict marker prefixes; each marker line is the prefix, optionally
+// followed by a space and a label
+const (
+	markerOurs   = "<<<<<<<"
+	markerBase   = "|||||||"
+	markerSplit  = "======="
+	markerTheirs = ">>>>>>>"
+)
+
+// ParseConflicts splits a file with conflict markers into its hunks
+func ParseConflicts(path, content string) (ConflictFile, error) {
+	file := ConflictFile{Path: path}
+
+	var text strings.Builder
+	var hunk *ConflictHunk
+	var raw strings.Builder
+	section := "" // Which part of the hunk the current line belongs to
+
+	for i, line := range strings.SplitAfter(content, "\n") {
+		marker, label := conflictMarker(line)
+
+		if hunk == nil {
+			if marker == markerOurs {
+				hunk = &ConflictHunk{OursLabel: label}
+				raw.Reset()
+				raw.WriteString(line)
+				section = markerOurs
+				continue
+			}
+			text.WriteString(line)
+			continue
+		}
+
+		raw.WriteString(line)
+		switch {
+		case marker == markerBase && section == markerOurs:
+			section = markerBase
+		case marker == markerSplit && (section == markerOurs || section == markerBase):
+			section = markerSplit
+		case marker == markerTheirs && section == markerSplit:
+			hunk.TheirsLabel = label
+			hunk.raw = raw.String()
+			file.Hunks = append(file.Hunks, *hunk)
+			file.parts = append(file.parts, text.String())
+			text.Reset()
+			hunk = nil
+		case marker != "":
+			return ConflictFile{}, fmt.Errorf("%s:%d: unexpected conflict marker %q", path, i+1, strings.TrimSpace(line))
+		case section == markerOurs:
+			hunk.Ours += line
+		case section == markerBase:
+			hunk.Base += line
+		default:
+			hunk.Theirs += line
+		}
+	}
+
+	if hunk != nil {
+		return ConflictFile{}, fmt.Errorf("%s: unterminated conflict starting with %q", path, strings.TrimSpace(strings.SplitN(raw.String(), "\n", 2)[0]))
+	}
+	file.parts = append(file.parts, text.String())
+	return file, nil
+}
+
+// conflictMarker returns the marker a line starts with and the label after it,
+// or "" for lines that aren't conflict markers
+func conflictMarker(line string) (string, string) {
+	line = strings.TrimRight(line, "\r\n")
+	for _, marker := range []string{markerOurs, markerBase, markerSplit, markerTheirs} {
+		rest, ok := strings.CutPrefix(line, marker)
+		if !ok {
+			continue
+		}
+		if rest == "" {
+			return marker, ""
+		}
+		// "=======" never has a label; longer runs of the character are content
+		if marker != markerSplit && rest[0] == ' ' {
+			return marker, strings.TrimSpace(rest)
+		}
+	}
+	return "", ""
+}
+
+// Render returns the file content with hunk i replaced by resolutions[i].
+// Hunks without a resolution keep their conflict markers.
+func (f ConflictFile) Render(resolutions map[int]string) string {
+	var b strings.Builder
+	for i, part := range f.parts {
+		b.WriteString(part)
+		if i == len(f.Hunks) {
+			break
+		}
+		if resolution, ok := resolutions[i]; ok {
+			b.WriteString(resolution)
+		} else {
+			b.WriteString(f.Hunks[i].raw)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseConflicts(t *testing.T) {
+	content := `package main
+
+<<<<<<< HEAD
+const limit = 10
+||||||| base
+const limit = 5
+=======
+const limit = 20
+>>>>>>> abc1234 (feat: raise limit)
+
+func main() {
+<<<<<<< HEAD
+	run()
+=======
+	start()
+>>>>>>> abc1234 (feat: raise limit)
+}
+`
+
+	file, err := ParseConflicts("main.go", content)
+	if err != nil {
+		t.Fatalf("ParseConflicts failed: %v", err)
+	}
+	if len(file.Hunks) != 2 {
+		t.Fatalf("Expected 2 hunks, got %d", len(file.Hunks))
+	}
+
+	first := file.Hunks[0]
+	if first.Ours != "const limit = 10\n" || first.Base != "const limit = 5\n" || first.Theirs != "const limit = 20\n" {
+		t.Errorf("Unexpected first hunk: %+v", first)
+	}
+	if first.OursLabel != "HEAD" || first.TheirsLabel != "abc1234 (feat: raise limit)" {
+		t.Errorf("Unexpected labels: %q, %q", first.OursLabel, first.TheirsLabel)
+	}
+	if second := file.Hunks[1]; second.Base != "" || second.Theirs != "\tstart()\n" {
+		t.Errorf("Unexpected second hunk: %+v", second)
+	}
+
+	// Without resolutions the file is unchanged
+	if rendered := file.Render(nil); rendered != content {
+		t.Errorf("Expected Render(nil) to return the original content, got:\n%s", rendered)
+	}
+
+	rendered := file.Render(map[int]string{0: "const limit = 20\n"})
+	if !strings.Contains(rendered, "package main\n\nconst limit = 20\n\nfunc main() {\n<<<<<<< HEAD\n") {
+		t.Errorf("Expected only the first hunk to be replaced, got:\n%s", rendered)
+	}
+}
+
+func TestParseConflictsErrors(t *testing.T) {
+	tests := map[string]string{
+		"Unterminated":      "<<<<<<< HEAD\na\n=======\nb\n",
+		"Nested":            "<<<<<<< HEAD\n<<<<<<< other\n",
+		"Theirs before ===": "<<<<<<< HEAD\na\n>>>>>>> other\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseConflicts("a.txt", content); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+
+	// Lines that only look like markers are content
+	file, err := ParseConflicts("a.md", "Title\n========\n<<<<<<<<<< not a marker\n")
+	if err != nil || len(file.Hunks) != 0 {
+		t.Errorf("Expected no hunks, got %d (%v)", len(file.Hunks), err)
+	}
+}
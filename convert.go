@@ -0,0 +1,481 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// defaultConvertCount is how many recent commits snap convert looks at
+const defaultConvertCount = 20
+
+// ConvertCommit is a commit whose message doesn't follow the convention, and
+// the rewrite proposed for it
+type ConvertCommit struct {
+	Hash      string
+	ShortHash string
+	Subject   string
+	Proposed  string
+	Err       error // Why there is no proposal
+	Apply     bool
+}
+
+// GetConvertCommits returns the commits among the last n on the current branch
+// whose subjects break the commit convention, newest first. The walk stops at
+// the first merge, since rewording replays the history above it.
+func GetConvertCommits(n int) ([]ConvertCommit, error) {
+	cmd := exec.Command("git", "log", "--first-parent", "-n", strconv.Itoa(n), "--format=%H%x00%h%x00%P%x00%s")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var commits []ConvertCommit
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		if len(strings.Fields(fields[2])) > 1 {
+			break
+		}
+		if CheckConvention(fields[3], config.Convention) != nil {
+			commits = append(commits, ConvertCommit{Hash: fields[0], ShortHash: fields[1], Subject: fields[3]})
+		}
+	}
+	return commits, nil
+}
+
+// RewordCommits replaces the messages of commits on the current branch with
+// an interactive rebase from the parent of oldest: every reworded pick is
+// followed by an exec that amends its message. The trees don't change, so
+// the rebase cannot conflict.
+func RewordCommits(oldest string, messages map[string]string) error {
+	rangeArgs := []string{"--root"}
+	revRange := "HEAD"
+	if exec.Command("git", "rev-parse", "--verify", "--quiet", oldest+"^").Run() == nil {
+		rangeArgs = []string{oldest + "^"}
+		revRange = oldest + "^..HEAD"
+	}
+	if output, _ := exec.Command("git", "rev-list", "--merges", revRange).Output(); strings.TrimSpace(string(output)) != "" {
+		return fmt.Errorf("can't reword across merge commits")
+	}
+	output, err := exec.Command("git", "rev-list", "--reverse", revRange).Output()
+	if err != nil {
+		return fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "snap-convert-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	var todo strings.Builder
+	reworded := 0
+	for _, hash := range strings.Fields(string(output)) {
+		todo.WriteString("pick " + hash + "\n")
+		message, ok := messages[hash]
+		if !ok {
+			continue
+		}
+		file := filepath.Join(dir, hash)
+		if err := os.WriteFile(file, []byte(message), 0644); err != nil {
+			return err
+		}
+		todo.WriteString(amendMessageExec(file) + "\n")
+		reworded++
+	}
+	if reworded != len(messages) {
+		return fmt.Errorf("some commits to reword are not on the current branch")
+	}
+	todoFile := filepath.Join(dir, "todo")
+	if err := os.WriteFile(todoFile, []byte(todo.String()), 0644); err != nil {
+		return err
+	}
+
+	// The sequence editor swaps git's todo list for ours
+	args := append([]string{"rebase", "--interactive", "--quiet", "--autostash"}, rangeArgs...)
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("GIT_SEQUENCE_EDITOR=cp '%s'", todoFile), "GIT_EDITOR=true")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		exec.Command("git", "rebase", "--abort").Run()
+		return fmt.Errorf("failed to reword commits: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+type convertState int
+
+const (
+	convertStateLoading convertState = iota
+	convertStateProposing
+	convertStateReviewing
+	convertStateEditing
+	convertStateApplying
+	convertStateDone
+	convertStateError
+)
+
+type convertModel struct {
+	state     convertState
+	spinner   spinner.Model
+	textInput textinput.Model
+	count     int  // Recent commits to look at
+	force     bool // Reword commits that are already pushed
+	commits   []ConvertCommit
+	proposing int  // The commit being proposed for
+	regen     bool // Proposing again for one commit, from the review
+	cursor    int
+	seed      int
+	notice    string // Why applying didn't start
+	backupRef string
+	reworded  int
+	err       error
+}
+
+type convertLoadMsg struct {
+	commits []ConvertCommit
+	err     error
+}
+
+type convertProposalMsg struct {
+	index    int
+	proposed string
+	err      error
+}
+
+type convertApplyMsg struct {
+	backupRef string
+	err       error
+}
+
+func initialConvertModel(count int, force bool) convertModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
+
+	ti := textinput.New()
+	ti.CharLimit = 200
+	ti.Width = 72
+
+	return convertModel{
+		state:     convertStateLoading,
+		spinner:   s,
+		textInput: ti,
+		count:     count,
+		force:     force,
+		seed:      config.Save.Seed,
+	}
+}
+
+func (m convertModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, loadConvertCmd(m.count))
+}
+
+func loadConvertCmd(count int) tea.Cmd {
+	return func() tea.Msg {
+		commits, err := GetConvertCommits(count)
+		if err == nil && len(commits) > 0 {
+			err = CurrentProvider().Check()
+		}
+		return convertLoadMsg{commits: commits, err: err}
+	}
+}
+
+// proposeCmd asks the AI for a conventional rewrite of one commit's subject
+func proposeCmd(index int, commit ConvertCommit, seed int) tea.Cmd {
+	return func() tea.Msg {
+		diff, err := GetCommitDiff(commit.Hash)
+		if err != nil {
+			return convertProposalMsg{index: index, err: err}
+		}
+		proposed, err := ConvertCommitMessage(commit.Subject, diff, seed)
+		if err == nil {
+			err = CheckConvention(proposed, config.Convention)
+		}
+		return convertProposalMsg{index: index, proposed: proposed, err: err}
+	}
+}
+
+// applyConvertCmd backs up HEAD and rewords the chosen commits, keeping
+// their bodies
+func applyConvertCmd(commits []ConvertCommit) tea.Cmd {
+	return func() tea.Msg {
+		var hashes []string
+		oldest := ""
+		for _, commit := range commits {
+			if commit.Apply {
+				hashes = append(hashes, commit.Hash)
+				oldest = commit.Hash
+			}
+		}
+		bodies, err := GetCommitBodies(hashes)
+		if err != nil {
+			return convertApplyMsg{err: err}
+		}
+		messages := map[string]string{}
+		for _, commit := range commits {
+			if !commit.Apply {
+				continue
+			}
+			messages[commit.Hash] = commit.Proposed
+			if body := bodies[commit.Hash]; body != "" {
+				messages[commit.Hash] += "\n\n" + body
+			}
+		}
+
+		backupRef, err := BackupHead(fmt.Sprintf("before snap convert of %d commits", len(hashes)))
+		if err != nil {
+			return convertApplyMsg{err: err}
+		}
+		if err := StartOperation("convert", backupRef, false); err != nil {
+			return convertApplyMsg{backupRef: backupRef, err: err}
+		}
+		defer EndOperation()
+		return convertApplyMsg{backupRef: backupRef, err: RewordCommits(oldest, messages)}
+	}
+}
+
+// selected counts the commits that will be reworded
+func (m convertModel) selected() int {
+	n := 0
+	for _, commit := range m.commits {
+		if commit.Apply {
+			n++
+		}
+	}
+	return n
+}
+
+// oldestSelected is the first commit the rebase rewrites
+func (m convertModel) oldestSelected() ConvertCommit {
+	var oldest ConvertCommit
+	for _, commit := range m.commits {
+		if commit.Apply {
+			oldest = commit
+		}
+	}
+	return oldest
+}
+
+func (m convertModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch m.state {
+		case convertStateReviewing:
+			m.notice = ""
+			switch msg.String() {
+			case "ctrl+c", "q", "esc":
+				m.state = convertStateDone
+				return m, tea.Quit
+			case "up", "k":
+				if m.cursor > 0 {
+					m.cursor--
+				}
+			case "down", "j":
+				if m.cursor < len(m.commits)-1 {
+					m.cursor++
+				}
+			case " ", "x":
+				if commit := &m.commits[m.cursor]; commit.Proposed != "" && commit.Err == nil {
+					commit.Apply = !commit.Apply
+				}
+			case "e":
+				value := m.commits[m.cursor].Proposed
+				if value == "" {
+					value = m.commits[m.cursor].Subject
+				}
+				m.textInput.SetValue(value)
+				m.textInput.CursorEnd()
+				m.state = convertStateEditing
+				return m, m.textInput.Focus()
+			case "g":
+				m.seed++
+				m.proposing = m.cursor
+				m.regen = true
+				m.state = convertStateProposing
+				return m, proposeCmd(m.cursor, m.commits[m.cursor], m.seed)
+			case "enter", "y":
+				if m.selected() == 0 {
+					m.notice = "Select at least one commit"
+					return m, nil
+				}
+				// Everything from the oldest reworded commit up is rewritten
+				if remote := GetPushedBranch(m.oldestSelected().Hash); remote != "" && !m.force {
+					m.notice = fmt.Sprintf("%s is already on %s - run snap convert --force to rewrite pushed history", m.oldestSelected().ShortHash, remote)
+					return m, nil
+				}
+				m.state = convertStateApplying
+				return m, applyConvertCmd(m.commits)
+			}
+			return m, nil
+
+		case convertStateEditing:
+			switch msg.String() {
+			case "ctrl+c":
+				m.state = convertStateDone
+				return m, tea.Quit
+			case "esc":
+				m.textInput.Blur()
+				m.state = convertStateReviewing
+				return m, nil
+			case "enter":
+				commit := &m.commits[m.cursor]
+				commit.Proposed = strings.TrimSpace(m.textInput.Value())
+				commit.Err = CheckConvention(commit.Proposed, config.Convention)
+				commit.Apply = commit.Err == nil
+				m.textInput.Blur()
+				m.state = convertStateReviewing
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.textInput, cmd = m.textInput.Update(msg)
+			return m, cmd
+
+		default:
+			if msg.String() == "ctrl+c" || msg.String() == "q" {
+				if m.state != convertStateApplying {
+					return m, tea.Quit
+				}
+			}
+		}
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case convertLoadMsg:
+		if msg.err != nil {
+			m.state = convertStateError
+			m.err = msg.err
+			return m, tea.Quit
+		}
+		m.commits = msg.commits
+		if len(m.commits) == 0 {
+			m.state = convertStateDone
+			return m, tea.Quit
+		}
+		m.state = convertStateProposing
+		return m, proposeCmd(0, m.commits[0], m.seed)
+
+	case convertProposalMsg:
+		commit := &m.commits[msg.index]
+		commit.Proposed = msg.proposed
+		commit.Err = msg.err
+		commit.Apply = msg.err == nil
+		// The first pass goes through every commit before the review
+		if next := msg.index + 1; !m.regen && next < len(m.commits) {
+			m.proposing = next
+			return m, proposeCmd(next, m.commits[next], m.seed)
+		}
+		m.regen = false
+		m.state = convertStateReviewing
+		return m, nil
+
+	case convertApplyMsg:
+		m.backupRef = msg.backupRef
+		if msg.err != nil {
+			m.state = convertStateError
+			m.err = msg.err
+			return m, tea.Quit
+		}
+		m.reworded = m.selected()
+		m.state = convertStateDone
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+func (m convertModel) View() string {
+	helpStyle := lipgloss.NewStyle().Foreground(colorMuted)
+
+	switch m.state {
+	case convertStateLoading:
+		return fmt.Sprintf("%s Looking for commits to convert...", m.spinner.View())
+
+	case convertStateProposing:
+		if m.regen {
+			return fmt.Sprintf("%s Asking %s for another message for %s...", m.spinner.View(), CurrentProvider().Name(), m.commits[m.proposing].ShortHash)
+		}
+		return fmt.Sprintf("%s Asking %s for conventional messages (%d/%d)...",
+			m.spinner.View(), CurrentProvider().Name(), m.proposing+1, len(m.commits))
+
+	case convertStateReviewing:
+		cursorStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+		oldStyle := lipgloss.NewStyle().Foreground(colorMuted).Strikethrough(true)
+
+		var s strings.Builder
+		s.WriteString(titleStyle.Render(fmt.Sprintf("Convert to conventional commits (%d of %d)", m.selected(), len(m.commits))))
+		s.WriteString("\n")
+		for i, commit := range m.commits {
+			cursor := "  "
+			if i == m.cursor {
+				cursor = cursorStyle.Render("→ ")
+			}
+			box := infoStyle.Render("[ ]")
+			if commit.Apply {
+				box = successStyle.Render("[x]")
+			}
+			s.WriteString(fmt.Sprintf("%s%s %s %s\n", cursor, box, infoStyle.Render(commit.ShortHash), oldStyle.Render(commit.Subject)))
+			switch {
+			case commit.Err != nil && commit.Proposed != "":
+				s.WriteString(fmt.Sprintf("             %s %s\n", commit.Proposed, errorStyle.Render("✗ "+commit.Err.Error())))
+			case commit.Err != nil:
+				s.WriteString("             " + errorStyle.Render("✗ "+commit.Err.Error()) + "\n")
+			default:
+				s.WriteString("             " + cursorStyle.Render(commit.Proposed) + "\n")
+			}
+		}
+		s.WriteString("\n")
+		if m.notice != "" {
+			s.WriteString(highlightStyle.Render(m.notice) + "\n")
+		}
+		s.WriteString(helpStyle.Render("space: toggle  e: edit  g: regenerate  enter: reword  q: quit"))
+		s.WriteString("\n")
+		return s.String()
+
+	case convertStateEditing:
+		return fmt.Sprintf("\n%s\n%s\n%s",
+			helpStyle.Render(fmt.Sprintf("Message for %s (was: %s)", m.commits[m.cursor].ShortHash, m.commits[m.cursor].Subject)),
+			m.textInput.View(),
+			helpStyle.Render("enter: use this message  esc: back"),
+		)
+
+	case convertStateApplying:
+		return fmt.Sprintf("%s Rewording %d commits...", m.spinner.View(), m.selected())
+
+	case convertStateDone:
+		if len(m.commits) == 0 {
+			return successStyle.Render("✓ Recent commits already follow the convention")
+		}
+		if m.reworded == 0 {
+			return infoStyle.Render("No commits reworded")
+		}
+		return fmt.Sprintf("%s\n%s",
+			successStyle.Render(fmt.Sprintf("✓ Reworded %d commits", m.reworded)),
+			helpStyle.Render(fmt.Sprintf("Undo with: git reset --hard %s", m.backupRef)),
+		)
+
+	case convertStateError:
+		if m.backupRef != "" {
+			return fmt.Sprintf("%s\n%s",
+				errorStyle.Render(fmt.Sprintf("✗ Error: %s", m.err)),
+				helpStyle.Render(fmt.Sprintf("HEAD before the attempt: %s", m.backupRef)),
+			)
+		}
+		return errorStyle.Render(fmt.Sprintf("✗ Error: %s", m.err))
+	}
+
+	return ""
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestRewordCommits(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	os.WriteFile("a.txt", []byte("a\n"), 0644)
+	exec.Command("git", "add", "a.txt").Run()
+	exec.Command("git", "commit", "-q", "-m", "feat: add a").Run()
+	os.WriteFile("b.txt", []byte("b\n"), 0644)
+	exec.Command("git", "add", "b.txt").Run()
+	exec.Command("git", "commit", "-q", "-m", "added b file", "-m", "Some details").Run()
+	os.WriteFile("dirty.txt", []byte("uncommitted\n"), 0644)
+
+	commits, err := GetConvertCommits(10)
+	if err != nil {
+		t.Fatalf("GetConvertCommits failed: %v", err)
+	}
+	if len(commits) != 2 || commits[0].Subject != "added b file" || commits[1].Subject != "Initial commit" {
+		t.Fatalf("Expected the two non-conventional commits, got %+v", commits)
+	}
+	tree, _ := exec.Command("git", "rev-parse", "HEAD^{tree}").Output()
+
+	// The root commit can be reworded too
+	messages := map[string]string{
+		commits[0].Hash: "feat: add b\n\nSome details",
+		commits[1].Hash: "chore: initial commit",
+	}
+	if err := RewordCommits(commits[1].Hash, messages); err != nil {
+		t.Fatalf("RewordCommits failed: %v", err)
+	}
+
+	output, _ := exec.Command("git", "log", "--format=%s|%b").Output()
+	if got, want := strings.TrimSpace(string(output)), "feat: add b|Some details\n\nfeat: add a|\nchore: initial commit|"; got != want {
+		t.Errorf("Expected reworded history %q, got %q", want, got)
+	}
+	if newTree, _ := exec.Command("git", "rev-parse", "HEAD^{tree}").Output(); string(newTree) != string(tree) {
+		t.Error("Expected the tree to stay the same")
+	}
+	if content, _ := os.ReadFile("dirty.txt"); string(content) != "uncommitted\n" {
+		t.Errorf("Expected uncommitted changes to survive, got %q", content)
+	}
+	if commits, _ := GetConvertCommits(10); len(commits) != 0 {
+		t.Errorf("Expected no commits left to convert, got %+v", commits)
+	}
+}
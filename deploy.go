@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// deployRefPrefix holds one ref per environment, pointing at the deployed commit
+const deployRefPrefix = "refs/deploys/"
+
+// deployNotesRef keeps the deployment history as notes on the deployed commits,
+// one "<env> <time> <who>" line per deployment
+const deployNotesRef = "refs/notes/deploys"
+
+// deployEnvRe limits environment names to what is safe in a ref name
+var deployEnvRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Deployment is the commit an environment runs
+type Deployment struct {
+	Env       string
+	Hash      string
+	ShortHash string
+	Subject   string
+	Time      time.Time // Zero when the note is missing, e.g. after fetching only the ref
+	By        string
+}
+
+// validateDeployEnv checks an environment name given on the command line
+func validateDeployEnv(env string) error {
+	if !deployEnvRe.MatchString(env) || strings.Contains(env, "..") || strings.HasSuffix(env, ".lock") {
+		return fmt.Errorf("invalid environment name '%s' (use letters, digits, '.', '-' and '_')", env)
+	}
+	return nil
+}
+
+// MarkDeployment records that env now runs the commit ref points to: the
+// environment's ref is moved there and a note with the time and the user is
+// added to the commit
+func MarkDeployment(env, ref string, now time.Time) (Deployment, error) {
+	if err := validateDeployEnv(env); err != nil {
+		return Deployment{}, err
+	}
+	hash, err := ResolveCommit(ref)
+	if err != nil {
+		return Deployment{}, err
+	}
+
+	if output, err := exec.Command("git", "update-ref", "-m", "snap deploy mark", deployRefPrefix+env, hash).CombinedOutput(); err != nil {
+		return Deployment{}, fmt.Errorf("failed to update %s%s: %s", deployRefPrefix, env, strings.TrimSpace(string(output)))
+	}
+
+	by, _ := exec.Command("git", "config", "user.name").Output()
+	line := fmt.Sprintf("%s %s %s", env, now.UTC().Format(time.RFC3339), strings.TrimSpace(string(by)))
+	cmd := exec.Command("git", "notes", "--ref="+deployNotesRef, "append", "-m", strings.TrimSpace(line), hash)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return Deployment{}, fmt.Errorf("failed to add deployment note: %s", strings.TrimSpace(string(output)))
+	}
+
+	return getDeployment(env, hash)
+}
+
+// GetDeployments returns the commit each environment runs, sorted by name
+func GetDeployments() ([]Deployment, error) {
+	output, err := exec.Command("git", "for-each-ref", "--sort=refname", "--format=%(refname)%00%(objectname)", deployRefPrefix).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var deployments []Deployment
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		ref, hash, ok := strings.Cut(line, "\x00")
+		if !ok {
+			continue
+		}
+		d, err := getDeployment(strings.TrimPrefix(ref, deployRefPrefix), hash)
+		if err != nil {
+			return nil, err
+		}
+		deployments = append(deployments, d)
+	}
+	return deployments, nil
+}
+
+// getDeployment reads the commit and the latest note line for env
+func getDeployment(env, hash string) (Deployment, error) {
+	output, err := exec.Command("git", "log", "-1", "--format=%h%x00%s", hash).Output()
+	if err != nil {
+		return Deployment{}, fmt.Errorf("failed to read commit %s: %w", hash, err)
+	}
+	shortHash, subject, _ := strings.Cut(strings.TrimSpace(string(output)), "\x00")
+	d := Deployment{Env: env, Hash: hash, ShortHash: shortHash, Subject: subject}
+
+	// Missing notes are fine; the ref alone says what runs where
+	notes, _ := exec.Command("git", "notes", "--ref="+deployNotesRef, "show", hash).Output()
+	for _, line := range strings.Split(string(notes), "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 3)
+		if len(fields) < 2 || fields[0] != env {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, fields[1]); err == nil && !t.Before(d.Time) {
+			d.Time = t
+			d.By = ""
+			if len(fields) == 3 {
+				d.By = fields[2]
+			}
+		}
+	}
+	return d, nil
+}
+
+// PushDeployment pushes an environment's ref and the deployment notes to
+// origin. Notes other people pushed in the meantime are merged first.
+func PushDeployment(env string) (string, error) {
+	if output, err := mergeRemoteDeployNotes(); err != nil {
+		return output, err
+	}
+	// A rollback moves the ref backwards, so it is always forced
+	cmd := exec.Command("git", "push", "origin", "+"+deployRefPrefix+env, deployNotesRef)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// FetchDeployments updates the environment refs from origin and merges its
+// deployment notes into the local ones
+func FetchDeployments() (string, error) {
+	cmd := exec.Command("git", "fetch", "-q", "origin", "+"+deployRefPrefix+"*:"+deployRefPrefix+"*")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return string(output), err
+	}
+	return mergeRemoteDeployNotes()
+}
+
+// mergeRemoteDeployNotes fetches origin's deployment notes and merges them
+// line by line into the local notes. A remote without notes is skipped.
+func mergeRemoteDeployNotes() (string, error) {
+	remoteNotes := deployNotesRef + "-origin"
+	if exec.Command("git", "fetch", "-q", "origin", "+"+deployNotesRef+":"+remoteNotes).Run() != nil {
+		return "", nil
+	}
+	merge := exec.Command("git", "notes", "--ref="+deployNotesRef, "merge", "-q", "-s", "cat_sort_uniq", remoteNotes)
+	if output, err := merge.CombinedOutput(); err != nil {
+		return string(output), fmt.Errorf("failed to merge deployment notes: %w", err)
+	}
+	return "", nil
+}
+
+// runDeployMark records a deployment and prints it
+func runDeployMark(env, ref string, push bool) error {
+	d, err := MarkDeployment(env, ref, time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Marked %s as deployed to %s: %s\n", d.ShortHash, d.Env, d.Subject)
+
+	if push {
+		if output, err := PushDeployment(env); err != nil {
+			return fmt.Errorf("failed to push deployment: %w\n%s", err, strings.TrimSpace(output))
+		}
+		fmt.Printf("✓ Pushed %s%s to origin\n", deployRefPrefix, env)
+	}
+	return nil
+}
+
+// runDeployStatus prints which commit each environment runs and how far it
+// is from HEAD
+func runDeployStatus(fetch bool) error {
+	if fetch {
+		if output, err := FetchDeployments(); err != nil {
+			return fmt.Errorf("failed to fetch deployments: %w\n%s", err, strings.TrimSpace(output))
+		}
+	}
+
+	deployments, err := GetDeployments()
+	if err != nil {
+		return fmt.Errorf("failed to load deployments: %w", err)
+	}
+	if len(deployments) == 0 {
+		fmt.Println("No deployments recorded - mark one with 'snap deploy mark <env>'")
+		return nil
+	}
+
+	width := 0
+	for _, d := range deployments {
+		width = max(width, len(d.Env))
+	}
+	for _, d := range deployments {
+		drift := "unknown"
+		if ahead, behind, err := GetAheadBehind(deployRefPrefix+d.Env, "HEAD"); err == nil {
+			drift = driftSummary(ahead, behind) + " HEAD"
+			if ahead == 0 && behind == 0 {
+				drift = "at HEAD"
+			}
+		}
+		fmt.Printf("%-*s  %s  %s (%s)\n", width, d.Env, d.ShortHash, d.Subject, drift)
+
+		if !d.Time.IsZero() {
+			when := "  deployed " + d.Time.Local().Format("2006-01-02 15:04")
+			if d.By != "" {
+				when += " by " + d.By
+			}
+			fmt.Printf("%-*s%s\n", width, "", when)
+		}
+	}
+	return nil
+}
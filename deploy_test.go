@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkDeployment(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	if _, err := MarkDeployment("prod/eu", "HEAD", time.Now()); err == nil {
+		t.Error("Expected an error for an environment name with a slash")
+	}
+	if _, err := MarkDeployment("production", "nope", time.Now()); err == nil || !strings.Contains(err.Error(), "unknown commit") {
+		t.Errorf("Expected an unknown commit error, got %v", err)
+	}
+
+	first := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	d, err := MarkDeployment("production", "HEAD", first)
+	if err != nil {
+		t.Fatalf("MarkDeployment failed: %v", err)
+	}
+	if d.Subject != "Initial commit" || !d.Time.Equal(first) || d.By != "Test User" {
+		t.Errorf("Unexpected deployment %+v", d)
+	}
+
+	exec.Command("git", "commit", "--allow-empty", "-q", "-m", "feat: two").Run()
+	MarkDeployment("staging", "HEAD", first.Add(time.Hour))
+
+	// A redeploy of the same commit updates the time
+	MarkDeployment("production", "HEAD~1", first.Add(2*time.Hour))
+
+	deployments, err := GetDeployments()
+	if err != nil {
+		t.Fatalf("GetDeployments failed: %v", err)
+	}
+	if len(deployments) != 2 || deployments[0].Env != "production" || deployments[1].Env != "staging" {
+		t.Fatalf("Expected production and staging, got %+v", deployments)
+	}
+	if !deployments[0].Time.Equal(first.Add(2 * time.Hour)) {
+		t.Errorf("Expected the latest production deploy time, got %v", deployments[0].Time)
+	}
+	if deployments[1].Subject != "feat: two" {
+		t.Errorf("Expected staging to run the new commit, got %q", deployments[1].Subject)
+	}
+	if _, behind, _ := GetAheadBehind(deployRefPrefix+"production", "HEAD"); behind != 1 {
+		t.Errorf("Expected production to be 1 behind HEAD, got %d", behind)
+	}
+}
+
+func TestPushDeployment(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	remoteDir := addBareRemote(t)
+	defer os.RemoveAll(remoteDir)
+	branch, _ := GetCurrentBranch()
+	exec.Command("git", "push", "-q", "origin", branch).Run()
+
+	// Someone else records a deployment first
+	otherDir := t.TempDir()
+	exec.Command("git", "clone", "-q", remoteDir, otherDir).Run()
+	other := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", otherDir, "-c", "user.name=Other", "-c", "user.email=other@example.com"}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	other("notes", "--ref="+deployNotesRef, "append", "-m", "staging 2026-10-01T09:00:00Z Other", "HEAD")
+	other("update-ref", deployRefPrefix+"staging", "HEAD")
+	other("push", "-q", "origin", deployRefPrefix+"staging", deployNotesRef)
+
+	if _, err := MarkDeployment("production", "HEAD", time.Now()); err != nil {
+		t.Fatalf("MarkDeployment failed: %v", err)
+	}
+	if output, err := PushDeployment("production"); err != nil {
+		t.Fatalf("PushDeployment failed: %v\n%s", err, output)
+	}
+
+	// Both notes survive the push
+	notes, _ := exec.Command("git", "--git-dir", remoteDir, "notes", "--ref="+deployNotesRef, "show", "HEAD").Output()
+	if !strings.Contains(string(notes), "staging ") || !strings.Contains(string(notes), "production ") {
+		t.Errorf("Expected both deployments in the remote notes, got %q", notes)
+	}
+
+	if output, err := FetchDeployments(); err != nil {
+		t.Fatalf("FetchDeployments failed: %v\n%s", err, output)
+	}
+	deployments, _ := GetDeployments()
+	if len(deployments) != 2 || deployments[1].By != "Other" {
+		t.Errorf("Expected the fetched staging deployment, got %+v", deployments)
+	}
+}
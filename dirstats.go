@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dirRecentDays is the window DirStats.Recent counts commits in
+const dirRecentDays = 30
+
+// Contributor is one author's share of the changes to a directory
+type Contributor struct {
+	Name      string
+	Commits   int
+	Additions int
+	Deletions int
+}
+
+// DirStats aggregates the history of a directory, e.g. one package of a
+// monorepo. Merge commits are left out, since their changes are counted in
+// the commits they merge.
+type DirStats struct {
+	Path         string
+	Commits      int
+	Additions    int
+	Deletions    int
+	Files        int // Distinct files changed, counting each name a renamed file had
+	Recent       int // Commits in the last dirRecentDays days
+	First        time.Time
+	Last         time.Time
+	Contributors []Contributor // Most commits first
+}
+
+// Churn is the number of lines added and removed
+func (s DirStats) Churn() int {
+	return s.Additions + s.Deletions
+}
+
+// GetDirStats collects the stats of every commit that changed something
+// below dir, on all branches with allBranches
+func GetDirStats(dir string, allBranches bool) (DirStats, error) {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return DirStats{}, fmt.Errorf("%s is not a directory", dir)
+	}
+	args := []string{"log", "--no-merges", "--numstat", "--format=%x1e%an%x1f%aI"}
+	if allBranches {
+		args = append(args, "--all")
+	}
+	output, err := exec.Command("git", append(args, "--", dir)...).Output()
+	if err != nil {
+		return DirStats{}, fmt.Errorf("failed to read the history of %s: %w", dir, err)
+	}
+	stats := parseDirLog(string(output), time.Now())
+	stats.Path = dir
+	return stats, nil
+}
+
+// parseDirLog parses git log --numstat output where each commit starts with
+// a \x1e-prefixed author and date line
+func parseDirLog(output string, now time.Time) DirStats {
+	var stats DirStats
+	files := map[string]bool{}
+	byName := map[string]*Contributor{}
+	recentSince := now.AddDate(0, 0, -dirRecentDays)
+
+	for _, record := range strings.Split(output, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		name, date, ok := strings.Cut(lines[0], "\x1f")
+		if !ok {
+			continue
+		}
+		when, _ := time.Parse(time.RFC3339, date)
+		contributor := byName[name]
+		if contributor == nil {
+			contributor = &Contributor{Name: name}
+			byName[name] = contributor
+		}
+		contributor.Commits++
+		stats.Commits++
+		if when.After(recentSince) {
+			stats.Recent++
+		}
+		if stats.Last.IsZero() || when.After(stats.Last) {
+			stats.Last = when
+		}
+		if stats.First.IsZero() || when.Before(stats.First) {
+			stats.First = when
+		}
+
+		for _, line := range lines[1:] {
+			fields := strings.SplitN(line, "\t", 3)
+			if len(fields) != 3 {
+				continue
+			}
+			// Binary files show "-" and count as changed without lines
+			added, _ := strconv.Atoi(fields[0])
+			deleted, _ := strconv.Atoi(fields[1])
+			contributor.Additions += added
+			contributor.Deletions += deleted
+			stats.Additions += added
+			stats.Deletions += deleted
+			files[fields[2]] = true
+		}
+	}
+
+	stats.Files = len(files)
+	for _, contributor := range byName {
+		stats.Contributors = append(stats.Contributors, *contributor)
+	}
+	slices.SortFunc(stats.Contributors, func(a, b Contributor) int {
+		if a.Commits != b.Commits {
+			return b.Commits - a.Commits
+		}
+		if a.Additions+a.Deletions != b.Additions+b.Deletions {
+			return (b.Additions + b.Deletions) - (a.Additions + a.Deletions)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return stats
+}
+
+// Summary is the one-line overview shown above the directory's history
+func (s DirStats) Summary() string {
+	if s.Commits == 0 {
+		return "No commits"
+	}
+	return fmt.Sprintf("%s by %s, %s, +%d -%d (churn %d), %d in the last %d days",
+		pluralize(s.Commits, "commit"),
+		pluralize(len(s.Contributors), "contributor"),
+		pluralize(s.Files, "file"),
+		s.Additions, s.Deletions, s.Churn(),
+		s.Recent, dirRecentDays)
+}
+
+// TopContributors lists up to n contributors as "name (commits, +a -d)"
+func (s DirStats) TopContributors(n int) []string {
+	var top []string
+	for _, c := range s.Contributors[:min(n, len(s.Contributors))] {
+		top = append(top, fmt.Sprintf("%s (%s, +%d -%d)", c.Name, pluralize(c.Commits, "commit"), c.Additions, c.Deletions))
+	}
+	return top
+}
+
+// pluralize writes a count with its noun, adding an s unless it is one
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// printDirStats prints a directory's activity above its plain history
+func printDirStats(s DirStats) {
+	fmt.Printf("%s: %s\n", s.Path, s.Summary())
+	if s.Commits > 0 {
+		fmt.Printf("Active from %s to %s\n", s.First.Format("2006-01-02"), s.Last.Format("2006-01-02"))
+	}
+	if top := s.TopContributors(5); len(top) > 0 {
+		fmt.Println("Top contributors:")
+		for _, line := range top {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+	fmt.Println()
+}
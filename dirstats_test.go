@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDirLog(t *testing.T) {
+	now := time.Date(2026, 10, 15, 18, 0, 0, 0, time.UTC)
+	output := "\x1eBob\x1f2026-10-10T09:00:00Z\n\n3\t1\tpkg/api/server.go\n-\t-\tpkg/api/logo.png\n" +
+		"\x1eAlice\x1f2026-08-01T09:00:00Z\n\n10\t0\tpkg/api/server.go\n5\t2\tpkg/api/routes.go\n" +
+		"\x1eBob\x1f2026-01-05T09:00:00Z\n\n20\t0\tpkg/api/server.go\n"
+
+	stats := parseDirLog(output, now)
+	if stats.Commits != 3 || stats.Additions != 38 || stats.Deletions != 3 || stats.Churn() != 41 {
+		t.Errorf("Expected 3 commits with +38 -3, got %+v", stats)
+	}
+	if stats.Files != 3 {
+		t.Errorf("Expected 3 files, got %d", stats.Files)
+	}
+	if stats.Recent != 1 {
+		t.Errorf("Expected 1 commit in the last %d days, got %d", dirRecentDays, stats.Recent)
+	}
+	if !stats.First.Equal(time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)) || !stats.Last.Equal(time.Date(2026, 10, 10, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected January to October, got %s to %s", stats.First, stats.Last)
+	}
+	if len(stats.Contributors) != 2 || stats.Contributors[0].Name != "Bob" || stats.Contributors[0].Commits != 2 || stats.Contributors[0].Additions != 23 {
+		t.Errorf("Expected Bob first with 2 commits and +23, got %+v", stats.Contributors)
+	}
+
+	want := "3 commits by 2 contributors, 3 files, +38 -3 (churn 41), 1 in the last 30 days"
+	if got := stats.Summary(); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+	if top := stats.TopContributors(1); len(top) != 1 || top[0] != "Bob (2 commits, +23 -1)" {
+		t.Errorf("Expected Bob on top, got %v", top)
+	}
+
+	if empty := parseDirLog("", now); empty.Commits != 0 || empty.Summary() != "No commits" {
+		t.Errorf("Expected no commits, got %+v", empty)
+	}
+}
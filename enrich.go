@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// placeholderDescription marks commits saved while the AI was unreachable
+const placeholderDescription = "save work in progress (message pending)"
+
+// PlaceholderMessage is the subject of a queued commit. It follows the
+// commit convention so hooks and checks accept it until snap enrich runs.
+func PlaceholderMessage() string {
+	commitType := config.Convention.Types[0]
+	if slices.Contains(config.Convention.Types, "chore") {
+		commitType = "chore"
+	}
+	if config.Convention.RequireScope {
+		return fmt.Sprintf("%s(wip): %s", commitType, placeholderDescription)
+	}
+	return fmt.Sprintf("%s: %s", commitType, placeholderDescription)
+}
+
+// enrichQueuePath returns the file listing commits waiting for an AI message.
+// It lives in the git directory, so every worktree and clone has its own.
+func enrichQueuePath() (string, error) {
+	return snapGitPath("enrich")
+}
+
+// LoadEnrichQueue returns the queued commit hashes, oldest first
+func LoadEnrichQueue() ([]string, error) {
+	path, err := enrichQueuePath()
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(content)), nil
+}
+
+// saveEnrichQueue replaces the queue; an empty queue removes the file
+func saveEnrichQueue(hashes []string) error {
+	path, err := enrichQueuePath()
+	if err != nil {
+		return err
+	}
+	if len(hashes) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(hashes, "\n")+"\n"), 0644)
+}
+
+// QueueForEnrich adds a commit to the queue for snap enrich
+func QueueForEnrich(ref string) error {
+	output, err := exec.Command("git", "rev-parse", "--verify", ref+"^{commit}").Output()
+	if err != nil {
+		return fmt.Errorf("unknown commit %s", ref)
+	}
+	hashes, err := LoadEnrichQueue()
+	if err != nil {
+		return err
+	}
+	if hash := strings.TrimSpace(string(output)); !slices.Contains(hashes, hash) {
+		hashes = append(hashes, hash)
+	}
+	return saveEnrichQueue(hashes)
+}
+
+// EnrichCommit is a queued commit that snap enrich can reword
+type EnrichCommit struct {
+	Hash  string
+	Depth int // Commits between it and HEAD; the oldest has the largest
+}
+
+// SplitEnrichQueue sorts the queue into commits that can still be reworded,
+// oldest first, and the ones that can't, with the reason
+func SplitEnrichQueue(hashes []string) (ready []EnrichCommit, skipped map[string]string) {
+	skipped = map[string]string{}
+	for _, hash := range hashes {
+		if exec.Command("git", "merge-base", "--is-ancestor", hash, "HEAD").Run() != nil {
+			skipped[hash] = "no longer on this branch (amended or rebased?)"
+			continue
+		}
+		if remote := GetPushedBranch(hash); remote != "" {
+			skipped[hash] = fmt.Sprintf("already pushed to %s - reword it by hand", remote)
+			continue
+		}
+		output, err := exec.Command("git", "rev-list", "--count", hash+"..HEAD").Output()
+		if err != nil {
+			skipped[hash] = "can't be read"
+			continue
+		}
+		depth, _ := strconv.Atoi(strings.TrimSpace(string(output)))
+		ready = append(ready, EnrichCommit{Hash: hash, Depth: depth})
+	}
+	slices.SortFunc(ready, func(a, b EnrichCommit) int { return b.Depth - a.Depth })
+	return ready, skipped
+}
+
+// runEnrich generates messages for the queued commits and rewords the ones
+// that aren't pushed yet, keeping their bodies, after backing up HEAD. Commits that can't be
+// reworded leave the queue; the rest stay queued if anything fails.
+func runEnrich(out io.Writer, seed int) error {
+	hashes, err := LoadEnrichQueue()
+	if err != nil {
+		return fmt.Errorf("failed to read the queue: %w", err)
+	}
+	if len(hashes) == 0 {
+		fmt.Fprintln(out, "No commits waiting for a message")
+		return nil
+	}
+
+	ready, skipped := SplitEnrichQueue(hashes)
+	for _, hash := range hashes {
+		if reason, ok := skipped[hash]; ok {
+			fmt.Fprintf(out, "⚠ Skipping %s: %s\n", shortHash(hash), reason)
+		}
+	}
+	if len(ready) == 0 {
+		return saveEnrichQueue(nil)
+	}
+	if err := saveEnrichQueue(enrichHashes(ready)); err != nil {
+		return err
+	}
+
+	if inProgress, _ := CheckRebaseInProgress(); inProgress {
+		return fmt.Errorf("a rebase is in progress - finish it first")
+	}
+	if err := CurrentProvider().Check(); err != nil {
+		return fmt.Errorf("%w - the commits stay queued", err)
+	}
+	bodies, err := GetCommitBodies(enrichHashes(ready))
+	if err != nil {
+		return err
+	}
+
+	messages := map[string]string{}
+	for _, commit := range ready {
+		fmt.Fprintf(out, "📸 Describing %s...\n", shortHash(commit.Hash))
+		diff, err := GetCommitDiff(commit.Hash)
+		if err != nil {
+			return err
+		}
+		subject := PlaceholderMessage()
+		if strings.TrimSpace(diff) != "" {
+			if subject, err = GenerateCommitMessage(diff, seed); err != nil {
+				return fmt.Errorf("failed to describe %s: %w - the commits stay queued", shortHash(commit.Hash), err)
+			}
+		}
+		messages[commit.Hash] = subject
+		if body := bodies[commit.Hash]; body != "" {
+			messages[commit.Hash] += "\n\n" + body
+		}
+	}
+
+	backupRef, err := BackupHead("before snap enrich")
+	if err != nil {
+		return err
+	}
+	if err := StartOperation("enrich", backupRef, false); err != nil {
+		return err
+	}
+	err = RewordCommits(ready[0].Hash, messages)
+	EndOperation()
+	if err != nil {
+		fmt.Fprintf(out, "HEAD before the attempt: %s\n", backupRef)
+		return err
+	}
+	for _, commit := range ready {
+		subject, _, _ := strings.Cut(messages[commit.Hash], "\n")
+		fmt.Fprintf(out, "✓ %s → %s\n", shortHash(commit.Hash), subject)
+	}
+	fmt.Fprintf(out, "Undo with: git reset --hard %s\n", backupRef)
+	return saveEnrichQueue(nil)
+}
+
+// enrichHashes lists the hashes of the commits
+func enrichHashes(commits []EnrichCommit) []string {
+	hashes := make([]string, len(commits))
+	for i, commit := range commits {
+		hashes[i] = commit.Hash
+	}
+	return hashes
+}
+
+// shortHash abbreviates a full commit hash for output
+func shortHash(hash string) string {
+	return hash[:min(len(hash), 7)]
+}
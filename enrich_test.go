@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestEnrichQueuedCommits(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	defer applyConfig(config)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	cfg := defaultConfig()
+	cfg.Ollama.URL = "http://127.0.0.1:1" // Nothing listens here
+	cfg.Save.Candidates = 1
+	applyConfig(cfg)
+
+	// A pushed placeholder commit can't be reworded any more
+	os.WriteFile("pushed.txt", []byte("pushed\n"), 0644)
+	if err := runSavePlain(saveRequest{Seed: 42, Queue: true}); err != nil {
+		t.Fatalf("runSavePlain with Queue failed: %v", err)
+	}
+	remoteDir := addBareRemote(t)
+	defer os.RemoveAll(remoteDir)
+	if output, err := exec.Command("git", "push", "-q", "-u", "origin", "HEAD").CombinedOutput(); err != nil {
+		t.Fatalf("Failed to push: %s", output)
+	}
+
+	os.WriteFile("notes.txt", []byte("hello\n"), 0644)
+	if err := runSavePlain(saveRequest{Seed: 42, Queue: true, Trailers: []string{"Signed-off-by: Test User <test@example.com>"}}); err != nil {
+		t.Fatalf("runSavePlain with Queue failed: %v", err)
+	}
+	if message, _ := GetHeadMessage(); !strings.HasPrefix(message, PlaceholderMessage()) {
+		t.Errorf("Expected the placeholder message, got %q", message)
+	}
+	if queue, err := LoadEnrichQueue(); err != nil || len(queue) != 2 {
+		t.Fatalf("Expected both commits queued, got %v, %v", queue, err)
+	}
+
+	// Still offline: nothing changes
+	if err := runEnrich(os.Stdout, 42); err == nil || !strings.Contains(err.Error(), "stay queued") {
+		t.Errorf("Expected the queue to wait for the provider, got %v", err)
+	}
+	if queue, _ := LoadEnrichQueue(); len(queue) != 1 {
+		t.Errorf("Expected only the unpushed commit left in the queue, got %v", queue)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			fmt.Fprintf(w, `{"models": [{"name": %q}]}`, cfg.Ollama.Model)
+			return
+		}
+		fmt.Fprint(w, `{"response": "docs: add notes", "done": true}`)
+	}))
+	defer server.Close()
+	cfg.Ollama.URL = server.URL
+	cfg.AI.InferScope = false
+	applyConfig(cfg)
+
+	before, _ := ResolveCommit("HEAD")
+	var out strings.Builder
+	if err := runEnrich(&out, 42); err != nil {
+		t.Fatalf("runEnrich failed: %v", err)
+	}
+	backups, _ := exec.Command("git", "for-each-ref", "--format=%(refname) %(objectname)", backupRefPrefix).Output()
+	backupRef, backupHash, _ := strings.Cut(strings.TrimSpace(string(backups)), " ")
+	if backupHash != before {
+		t.Errorf("Expected HEAD before the rewrite backed up, got %q", backups)
+	}
+	if !strings.Contains(out.String(), "git reset --hard "+backupRef) {
+		t.Errorf("Expected the undo hint for %s, got %q", backupRef, out.String())
+	}
+	message, _ := GetHeadMessage()
+	if message != "docs: add notes\n\nSigned-off-by: Test User <test@example.com>" {
+		t.Errorf("Expected the new subject with the trailer kept, got %q", message)
+	}
+	if queue, _ := LoadEnrichQueue(); len(queue) != 0 {
+		t.Errorf("Expected an empty queue, got %v", queue)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// providerHeuristic ends ai.fallback with a message guessed from the changed
+// files, so saving never stops at a provider outage
+const providerHeuristic = "heuristic"
+
+// heuristicSource names the heuristic where the TUI shows who wrote a message
+const heuristicSource = "file names (no AI)"
+
+// cacheSource names the AI cache where the TUI shows who wrote a message
+const cacheSource = "cache"
+
+// fallbackProvider tries ai.provider and then each provider in ai.fallback
+// until one answers
+type fallbackProvider struct {
+	providers []Provider
+	heuristic bool // Commit messages are guessed when every provider fails
+}
+
+// Name lists the chain, e.g. "Ollama → Claude"
+func (p fallbackProvider) Name() string {
+	names := make([]string, len(p.providers))
+	for i, provider := range p.providers {
+		names[i] = provider.Name()
+	}
+	return strings.Join(names, " → ")
+}
+
+// Check passes when any provider is ready, or the heuristic can step in.
+// Otherwise it reports the primary provider's problem, so a missing Ollama
+// model still offers the pull.
+func (p fallbackProvider) Check() error {
+	var first error
+	for _, provider := range p.providers {
+		err := provider.Check()
+		if err == nil {
+			return nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	if p.heuristic {
+		return nil
+	}
+	return first
+}
+
+func (p fallbackProvider) Generate(prompt string, seed int) (string, error) {
+	text, _, err := generateFrom(context.Background(), p, prompt, seed, nil)
+	return text, err
+}
+
+func (p fallbackProvider) GenerateStream(ctx context.Context, prompt string, seed int, onToken func(string)) (string, error) {
+	text, _, err := generateFrom(ctx, p, prompt, seed, onToken)
+	return text, err
+}
+
+// messageSource tells which provider produced a generated message
+type messageSource struct {
+	name   string // Provider name, heuristicSource or cacheSource
+	failed error  // Why the providers before it in the chain didn't answer
+}
+
+// generateFrom completes a prompt with the first provider of a chain that
+// answers, streaming to onToken when it is set and the provider can. Providers
+// after the first are skipped when their Check fails, so a stopped server
+// doesn't cost a full timeout.
+func generateFrom(ctx context.Context, provider Provider, prompt string, seed int, onToken func(string)) (string, messageSource, error) {
+	chain, ok := provider.(fallbackProvider)
+	if !ok {
+		text, err := generateOnce(ctx, provider, prompt, seed, onToken)
+		return text, messageSource{name: provider.Name()}, err
+	}
+
+	var failed []error
+	for i, p := range chain.providers {
+		if i > 0 {
+			if err := p.Check(); err != nil {
+				failed = append(failed, fmt.Errorf("%s: %w", p.Name(), err))
+				continue
+			}
+		}
+		text, err := generateOnce(ctx, p, prompt, seed, onToken)
+		if err == nil {
+			return text, messageSource{name: p.Name(), failed: errors.Join(failed...)}, nil
+		}
+		if ctx.Err() != nil {
+			return "", messageSource{}, err
+		}
+		failed = append(failed, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	return "", messageSource{}, errors.Join(failed...)
+}
+
+// generateOnce asks a single provider, streaming when it can and onToken is set
+func generateOnce(ctx context.Context, provider Provider, prompt string, seed int, onToken func(string)) (string, error) {
+	if p, ok := provider.(StreamingProvider); ok && onToken != nil {
+		return p.GenerateStream(ctx, prompt, seed, onToken)
+	}
+	return provider.Generate(prompt, seed)
+}
+
+// heuristicFallback reports whether ai.fallback ends with the heuristic
+func heuristicFallback() bool {
+	return slices.Contains(config.AI.Fallback, providerHeuristic)
+}
+
+// HeuristicCommitMessage guesses a message from the files a diff changes: the
+// type from what kind of files they are, the verb from whether they were
+// added, removed or renamed. It is the last resort when no provider answers.
+func HeuristicCommitMessage(diff string) string {
+	files := diffFiles(diff)
+	scope := commitScope(diff)
+	if scope == "" && config.Convention.RequireScope {
+		scope = InferScope(files, config.Scopes)
+	}
+
+	commitType := heuristicType(files)
+	if !slices.Contains(config.Convention.Types, commitType) {
+		commitType = config.Convention.Types[0]
+		if slices.Contains(config.Convention.Types, "chore") {
+			commitType = "chore"
+		}
+	}
+
+	object := fmt.Sprintf("%d files", len(files))
+	switch len(files) {
+	case 0:
+		object = "files"
+	case 1:
+		object = path.Base(files[0])
+	}
+	message := styleCommitMessage(fmt.Sprintf("%s: %s %s", commitType, heuristicVerb(diff), object), scope)
+	if len(files) == 1 && utf8.RuneCountInString(message) > config.Convention.MaxSubject {
+		message = styleCommitMessage(fmt.Sprintf("%s: %s 1 file", commitType, heuristicVerb(diff)), scope)
+	}
+	return message
+}
+
+// heuristicType picks docs, test or ci when every file is of that kind, and
+// chore otherwise
+func heuristicType(files []string) string {
+	kinds := map[string]func(string) bool{
+		"docs": func(p string) bool {
+			ext := strings.ToLower(path.Ext(p))
+			return ext == ".md" || ext == ".rst" || ext == ".adoc" || ext == ".txt" || strings.HasPrefix(p, "docs/")
+		},
+		"test": func(p string) bool {
+			base := path.Base(p)
+			return strings.Contains(base, "_test.") || strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+				slices.ContainsFunc(strings.Split(path.Dir(p), "/"), func(dir string) bool {
+					return dir == "test" || dir == "tests" || dir == "__tests__"
+				})
+		},
+		"ci": func(p string) bool {
+			return strings.HasPrefix(p, ".github/workflows/") || strings.HasPrefix(p, ".circleci/") || p == ".gitlab-ci.yml"
+		},
+	}
+	if len(files) > 0 {
+		for _, kind := range []string{"docs", "test", "ci"} {
+			if !slices.ContainsFunc(files, func(p string) bool { return !kinds[kind](p) }) {
+				return kind
+			}
+		}
+	}
+	return "chore"
+}
+
+// heuristicVerb says add, remove or rename when all files in a diff were, and
+// update otherwise
+func heuristicVerb(diff string) string {
+	var files, added, removed, renamed int
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files++
+		case strings.HasPrefix(line, "new file mode"):
+			added++
+		case strings.HasPrefix(line, "deleted file mode"):
+			removed++
+		case strings.HasPrefix(line, "rename from "):
+			renamed++
+		}
+	}
+	switch {
+	case files == 0:
+		return "update"
+	case added == files:
+		return "add"
+	case removed == files:
+		return "remove"
+	case renamed == files:
+		return "rename"
+	}
+	return "update"
+}
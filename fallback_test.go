@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGenerateFromFallsBack(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model crashed", http.StatusBadRequest)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices": [{"message": {"role": "assistant", "content": "fix: handle outage"}}]}`)
+	}))
+	defer up.Close()
+
+	chain := fallbackProvider{providers: []Provider{
+		ollamaProvider{baseURL: down.URL, model: "mistral"},
+		openAIProvider{baseURL: up.URL, model: "gpt"},
+	}}
+	text, source, err := generateFrom(context.Background(), chain, "prompt", 1, nil)
+	if err != nil {
+		t.Fatalf("generateFrom failed: %v", err)
+	}
+	if text != "fix: handle outage" || source.name != "OpenAI-compatible API" {
+		t.Errorf("Expected the second provider's answer, got %q from %q", text, source.name)
+	}
+	if source.failed == nil || !strings.Contains(source.failed.Error(), "Ollama: ") {
+		t.Errorf("Expected the Ollama failure to be reported, got %v", source.failed)
+	}
+
+	chain.providers = chain.providers[:1]
+	if _, _, err := generateFrom(context.Background(), chain, "prompt", 1, nil); err == nil {
+		t.Error("Expected an error when every provider fails")
+	}
+	if err := chain.Check(); err == nil {
+		t.Error("Expected Check to fail without a ready provider")
+	}
+	chain.heuristic = true
+	if err := chain.Check(); err != nil {
+		t.Errorf("Expected the heuristic to make Check pass, got %v", err)
+	}
+}
+
+func TestGenerateCommitMessageCandidatesHeuristic(t *testing.T) {
+	defer applyConfig(config)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	cfg := defaultConfig()
+	cfg.Ollama.URL = server.URL
+	cfg.AI.Cache = false
+	cfg.AI.Examples = 0
+	cfg.AI.Fallback = []string{providerHeuristic}
+	applyConfig(cfg)
+
+	diff := "diff --git a/docs/guide.md b/docs/guide.md\nnew file mode 100644\n+# Guide\n"
+	candidates, source, err := GenerateCommitMessageCandidates(context.Background(), diff, 1, 2, func(string) {}, nil)
+	if err != nil {
+		t.Fatalf("GenerateCommitMessageCandidates failed: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0] != "docs(docs): add guide.md" {
+		t.Errorf("Expected a guessed message, got %q", candidates)
+	}
+	if source.name != heuristicSource || source.failed == nil {
+		t.Errorf("Expected the heuristic with the provider's failure, got %+v", source)
+	}
+}
+
+func TestHeuristicCommitMessage(t *testing.T) {
+	defer applyConfig(config)
+	cfg := defaultConfig()
+	cfg.AI.InferScope = false
+	applyConfig(cfg)
+
+	tests := []struct {
+		name string
+		diff string
+		want string
+	}{
+		{
+			name: "Changed file",
+			diff: "diff --git a/main.go b/main.go\n+x\n",
+			want: "chore: update main.go",
+		},
+		{
+			name: "Removed tests",
+			diff: "diff --git a/a_test.go b/a_test.go\ndeleted file mode 100644\ndiff --git a/b_test.go b/b_test.go\ndeleted file mode 100644\n",
+			want: "test: remove 2 files",
+		},
+		{
+			name: "Workflow without a ci type",
+			diff: "diff --git a/.github/workflows/ci.yml b/.github/workflows/ci.yml\n+x\n",
+			want: "chore: update ci.yml",
+		},
+		{
+			name: "Mixed kinds",
+			diff: "diff --git a/README.md b/README.md\n+x\ndiff --git a/main.go b/main.go\n+x\n",
+			want: "chore: update 2 files",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HeuristicCommitMessage(tt.diff); got != tt.want {
+				t.Errorf("HeuristicCommitMessage() = %q, want %q", got, tt.want)
+			}
+			if err := CheckConvention(HeuristicCommitMessage(tt.diff), config.Convention); err != nil {
+				t.Errorf("Guessed message breaks the convention: %v", err)
+			}
+		})
+	}
+}
@@ -1,9 +1,18 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -31,18 +40,406 @@ func GetGitDiff() (string, error) {
 	return diff, nil
 }
 
-// StageAllChanges stages all changes in the repository
-func StageAllChanges() error {
-	cmd := exec.Command("git", "add", "-A")
-	return cmd.Run()
+// StageAllChanges stages all changes in the repository, except the paths
+// matching the exclude patterns
+func StageAllChanges(exclude ...string) error {
+	args := []string{"add", "-A"}
+	if len(exclude) > 0 {
+		args = append(append(args, "--", ":/"), ExcludePathspecs(exclude)...)
+	}
+	return exec.Command("git", args...).Run()
+}
+
+// ExcludePathspecs turns glob patterns like "*.lock" or "dist/**" into git
+// pathspecs that leave the matching paths out, relative to the repository root
+func ExcludePathspecs(patterns []string) []string {
+	specs := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		specs[i] = ":(top,exclude)" + pattern
+	}
+	return specs
 }
 
-// CommitChanges commits staged changes with the given message
-func CommitChanges(message string) error {
-	cmd := exec.Command("git", "commit", "-m", message)
+// FilterExcluded drops the entries whose path matches one of the exclude
+// patterns, using git's pathspec matching
+func FilterExcluded(entries []StatusEntry, patterns []string) ([]StatusEntry, error) {
+	if len(patterns) == 0 {
+		return entries, nil
+	}
+	args := []string{"ls-files", "-z", "--cached", "--others", "--exclude-standard", "--"}
+	for _, pattern := range patterns {
+		args = append(args, ":(top)"+pattern)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir, _ = GetRepoRoot()
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	excluded := map[string]bool{}
+	for _, path := range strings.Split(string(output), "\x00") {
+		excluded[path] = true
+	}
+
+	var kept []StatusEntry
+	for _, entry := range entries {
+		if !excluded[entry.Path] {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, nil
+}
+
+// StagePaths stages all changes below the given pathspecs, including
+// deletions and new files
+func StagePaths(paths []string) error {
+	cmd := exec.Command("git", append([]string{"add", "-A", "--"}, paths...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// IsKnownPath reports whether path exists in the working tree or is tracked
+// by git (a deleted file), so it can be told apart from a commit message
+func IsKnownPath(path string) bool {
+	if _, err := os.Stat(path); err == nil {
+		return true
+	}
+	return exec.Command("git", "ls-files", "--error-unmatch", "--", path).Run() == nil
+}
+
+// GetPathsDiff returns the staged changes below the given pathspecs
+func GetPathsDiff(paths []string) (string, error) {
+	output, err := exec.Command("git", append([]string{"diff", "--cached", "--"}, paths...)...).Output()
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// GetPathsFileStats returns line stats for the staged files below the given
+// pathspecs
+func GetPathsFileStats(paths []string) ([]FileDiffStat, error) {
+	output, err := exec.Command("git", append([]string{"diff", "--cached", "--numstat", "-z", "-M", "--"}, paths...)...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseNumstatZ(string(output)), nil
+}
+
+// StageSelected stages everything, then unstages the entries that weren't
+// selected, so only the selected files are committed. Staging first keeps
+// deletions and renames intact, which git add can't name by path.
+func StageSelected(unselected []StatusEntry, exclude []string) error {
+	if err := StageAllChanges(exclude...); err != nil {
+		return err
+	}
+	if len(unselected) == 0 {
+		return nil
+	}
+
+	var paths []string
+	for _, entry := range unselected {
+		paths = append(paths, entry.Path)
+		if entry.OrigPath != "" {
+			paths = append(paths, entry.OrigPath)
+		}
+	}
+	return UnstagePaths(paths)
+}
+
+// UnstagePaths takes files out of the index and leaves the working tree alone
+func UnstagePaths(paths []string) error {
+	args := append([]string{"reset", "-q", "--"}, paths...)
+	if exec.Command("git", "rev-parse", "--verify", "-q", "HEAD").Run() != nil {
+		// Nothing to reset to before the first commit
+		args = append([]string{"rm", "--cached", "-r", "-q", "--ignore-unmatch", "--"}, paths...)
+	}
+	if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to unstage files: %w\n%s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// StageFile stages a single file, e.g. to mark a conflict as resolved
+func StageFile(path string) error {
+	cmd := exec.Command("git", "add", "--", path)
 	return cmd.Run()
 }
 
+// GetRepoRoot returns the top-level directory of the repository
+func GetRepoRoot() (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetStagedContent returns a file as it is in the index; path is relative to
+// the repository root
+func GetStagedContent(path string) (string, error) {
+	output, err := exec.Command("git", "show", ":"+path).Output()
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// GetStagedSize returns the size in bytes of a file as it is in the index;
+// path is relative to the repository root
+func GetStagedSize(path string) (int64, error) {
+	output, err := exec.Command("git", "cat-file", "-s", ":"+path).Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+}
+
+// RevertPatch undoes a patch with root-relative paths in the working tree
+func RevertPatch(root, patch string) error {
+	cmd := exec.Command("git", "apply", "-R")
+	cmd.Dir = root
+	cmd.Stdin = strings.NewReader(patch)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git apply -R: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// GetConflictedFiles returns the paths with unresolved merge conflicts
+func GetConflictedFiles() ([]string, error) {
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// commitHookNames are the hooks git commit runs that can stop it
+var commitHookNames = []string{"pre-commit", "prepare-commit-msg", "commit-msg"}
+
+// CommitError is a failed git commit with what git and the hooks printed
+type CommitError struct {
+	Hooks  []string // Hooks that ran, which likely stopped the commit
+	Output string
+}
+
+func (e *CommitError) Error() string {
+	summary := "git commit failed"
+	switch len(e.Hooks) {
+	case 0:
+	case 1:
+		summary = fmt.Sprintf("the %s hook failed", e.Hooks[0])
+	default:
+		summary = fmt.Sprintf("a commit hook failed (%s)", strings.Join(e.Hooks, ", "))
+	}
+	if e.Output == "" {
+		return summary
+	}
+	return summary + ":\n" + e.Output
+}
+
+// CommitHooks returns the commit hooks that are installed and would run,
+// honoring core.hooksPath. noVerify skips pre-commit and commit-msg.
+func CommitHooks(noVerify bool) []string {
+	output, err := exec.Command("git", "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return nil
+	}
+	dir := strings.TrimSpace(string(output))
+
+	var hooks []string
+	for _, hook := range commitHookNames {
+		if noVerify && hook != "prepare-commit-msg" {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(dir, hook)); err == nil && info.Mode()&0111 != 0 {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
+}
+
+// runCommit runs git commit with the message on stdin. git's own summary is
+// left out, so the output is what the hooks printed; on failure it comes
+// with a CommitError.
+func runCommit(message string, noVerify bool, args ...string) (string, error) {
+	commitArgs := []string{"commit", "--quiet"}
+	if noVerify {
+		commitArgs = append(commitArgs, "--no-verify")
+	}
+	cmd := exec.Command("git", append(commitArgs, args...)...)
+	cmd.Stdin = strings.NewReader(message)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", &CommitError{Hooks: CommitHooks(noVerify), Output: strings.TrimSpace(string(output))}
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// CommitChanges commits staged changes with the given message and returns
+// the hooks' output. noVerify skips the pre-commit and commit-msg hooks.
+func CommitChanges(message string, noVerify bool) (string, error) {
+	return runCommit(message, noVerify, "-F", "-")
+}
+
+// CommitEmpty records a commit without changes, e.g. to trigger CI
+func CommitEmpty(message string, noVerify bool) (string, error) {
+	return runCommit(message, noVerify, "--allow-empty", "-F", "-")
+}
+
+// CommitPaths commits only the changes below the given pathspecs (git commit
+// --only); anything else that is staged stays staged for a later commit
+func CommitPaths(message string, paths []string, noVerify bool) (string, error) {
+	return runCommit(message, noVerify, append([]string{"-F", "-", "--only", "--"}, paths...)...)
+}
+
+// AmendCommit folds the staged changes into HEAD. An empty message keeps
+// HEAD's message.
+func AmendCommit(message string, noVerify bool) (string, error) {
+	if message == "" {
+		return runCommit(message, noVerify, "--amend", "--allow-empty", "--no-edit")
+	}
+	return runCommit(message, noVerify, "--amend", "--allow-empty", "-F", "-")
+}
+
+// GetCommitTemplate reads the commit.template file without its comment
+// lines, the way git commit would show it. It returns "" when no template
+// is configured.
+func GetCommitTemplate() (string, error) {
+	output, err := exec.Command("git", "config", "--path", "commit.template").Output()
+	path := strings.TrimSpace(string(output))
+	if err != nil || path == "" {
+		return "", nil
+	}
+	// Relative paths are relative to the repository root, where git commit runs
+	if !filepath.IsAbs(path) {
+		if root, err := GetRepoRoot(); err == nil {
+			path = filepath.Join(root, path)
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read commit.template: %w", err)
+	}
+	return stripCommentLines(string(content)), nil
+}
+
+// stripCommentLines drops the lines starting with core.commentChar, the way
+// git commit cleans up a message
+func stripCommentLines(content string) string {
+	commentChar := "#"
+	if output, err := exec.Command("git", "config", "core.commentChar").Output(); err == nil {
+		if c := strings.TrimSpace(string(output)); c != "" && c != "auto" {
+			commentChar = c
+		}
+	}
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(line, commentChar) {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// CheckMergeInProgress reports whether a merge is waiting to be committed
+// (MERGE_HEAD exists), e.g. after resolving its conflicts
+func CheckMergeInProgress() bool {
+	return exec.Command("git", "rev-parse", "-q", "--verify", "MERGE_HEAD").Run() == nil
+}
+
+// GetMergeMessage reads the message git prepared for the merge commit
+// (MERGE_MSG) without its comment lines
+func GetMergeMessage() (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--git-path", "MERGE_MSG").Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository")
+	}
+	content, err := os.ReadFile(strings.TrimSpace(string(output)))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the merge message: %w", err)
+	}
+	return stripCommentLines(string(content)), nil
+}
+
+// ApplyCommitTemplate puts the template's text below the message's subject
+// and body, unless the message already contains it (e.g. a kept message
+// when amending)
+func ApplyCommitTemplate(message, template string) string {
+	message = strings.TrimRight(message, "\n")
+	if template == "" || strings.Contains(message, template) {
+		return message
+	}
+	return message + "\n\n" + template
+}
+
+// amendBase returns what an amended HEAD is compared with: its first parent,
+// or the empty tree for the first commit
+func amendBase() string {
+	if exec.Command("git", "rev-parse", "--verify", "-q", "HEAD^").Run() != nil {
+		return emptyTreeHash
+	}
+	return "HEAD^"
+}
+
+// GetAmendDiff returns the diff HEAD would have after amending it with the
+// staged changes
+func GetAmendDiff() (string, error) {
+	output, err := exec.Command("git", "diff", "--cached", amendBase()).Output()
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// GetAmendFileStats returns the per-file stats of GetAmendDiff
+func GetAmendFileStats() ([]FileDiffStat, error) {
+	output, err := exec.Command("git", "diff", "--cached", "--numstat", "-z", "-M", amendBase()).Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseNumstatZ(string(output)), nil
+}
+
+// GetHeadMessage returns the full message of HEAD
+func GetHeadMessage() (string, error) {
+	output, err := exec.Command("git", "log", "-1", "--format=%B").Output()
+	if err != nil {
+		return "", fmt.Errorf("nothing to amend - there are no commits yet")
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetPushedBranch returns a remote-tracking branch that already contains ref,
+// or "" when ref hasn't been pushed (as of the last fetch)
+func GetPushedBranch(ref string) string {
+	output, err := exec.Command("git", "for-each-ref", "--contains", ref, "--format=%(refname)", "refs/remotes").Output()
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		// origin/HEAD is a symbolic ref, not a branch anyone pushed to
+		if line = strings.TrimSpace(line); line != "" && !strings.HasSuffix(line, "/HEAD") {
+			return strings.TrimPrefix(line, "refs/remotes/")
+		}
+	}
+	return ""
+}
+
 // GetStatus returns the git status showing modified, added, and untracked files
 func GetStatus() (string, error) {
 	cmd := exec.Command("git", "status", "--short")
@@ -53,35 +450,206 @@ func GetStatus() (string, error) {
 	return string(output), nil
 }
 
-// GetColoredStatus returns a colored, human-readable git status
-func GetColoredStatus() (string, error) {
-	cmd := exec.Command("git", "status", "--short")
+// StatusEntry represents a single file entry from git status
+type StatusEntry struct {
+	Code      string // Two-character XY status code ("??" for untracked)
+	Path      string
+	OrigPath  string // Original path for renames and copies
+	Submodule string // Submodule state from porcelain v2 (e.g. "SC.."), empty for regular files
+	FileCount int    // Number of files inside a collapsed untracked directory
+}
+
+// GetStatusEntries returns the parsed git status using the porcelain v2 format.
+// When expandUntracked is true, untracked directories are listed file by file
+// instead of being collapsed to "dir/".
+func GetStatusEntries(expandUntracked bool) ([]StatusEntry, error) {
+	untracked := "--untracked-files=normal"
+	if expandUntracked {
+		untracked = "--untracked-files=all"
+	}
+	cmd := exec.Command("git", "status", "--porcelain=v2", "-z", untracked)
 	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return parsePorcelainV2(string(output)), nil
+}
+
+// parsePorcelainV2 parses NUL-separated git status --porcelain=v2 -z output
+func parsePorcelainV2(output string) []StatusEntry {
+	fields := strings.Split(output, "\x00")
+	entries := make([]StatusEntry, 0, len(fields))
+
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if len(field) < 2 {
+			continue
+		}
+
+		switch field[0] {
+		case '1':
+			// 1 XY sub mH mI mW hH hI path
+			parts := strings.SplitN(field, " ", 9)
+			if len(parts) < 9 {
+				continue
+			}
+			entries = append(entries, StatusEntry{
+				Code:      porcelainV2Code(parts[1]),
+				Path:      parts[8],
+				Submodule: porcelainV2Submodule(parts[2]),
+			})
+		case '2':
+			// 2 XY sub mH mI mW hH hI Xscore path, followed by origPath as the next field
+			parts := strings.SplitN(field, " ", 10)
+			if len(parts) < 10 {
+				continue
+			}
+			entry := StatusEntry{
+				Code:      porcelainV2Code(parts[1]),
+				Path:      parts[9],
+				Submodule: porcelainV2Submodule(parts[2]),
+			}
+			if i+1 < len(fields) {
+				entry.OrigPath = fields[i+1]
+				i++ // Skip the original path
+			}
+			entries = append(entries, entry)
+		case 'u':
+			// u XY sub m1 m2 m3 mW h1 h2 h3 path
+			parts := strings.SplitN(field, " ", 11)
+			if len(parts) < 11 {
+				continue
+			}
+			entries = append(entries, StatusEntry{
+				Code:      porcelainV2Code(parts[1]),
+				Path:      parts[10],
+				Submodule: porcelainV2Submodule(parts[2]),
+			})
+		case '?':
+			entries = append(entries, StatusEntry{Code: "??", Path: field[2:]})
+		case '!':
+			entries = append(entries, StatusEntry{Code: "!!", Path: field[2:]})
+		}
+	}
+
+	return entries
+}
+
+// porcelainV2Code converts a porcelain v2 XY code to the short format ('.' becomes ' ')
+func porcelainV2Code(xy string) string {
+	return strings.ReplaceAll(xy, ".", " ")
+}
+
+// porcelainV2Submodule returns the submodule state, or empty string for regular files
+func porcelainV2Submodule(sub string) string {
+	if strings.HasPrefix(sub, "S") {
+		return sub
+	}
+	return ""
+}
+
+// IsUnmerged reports whether the entry is in a merge conflict state
+func (e StatusEntry) IsUnmerged() bool {
+	switch e.Code {
+	case "DD", "AU", "UD", "UA", "DU", "AA", "UU":
+		return true
+	}
+	return false
+}
+
+// DisplayPath returns the path for display, with an arrow for renames and copies
+func (e StatusEntry) DisplayPath() string {
+	if e.OrigPath != "" {
+		return e.OrigPath + " → " + e.Path
+	}
+	return e.Path
+}
+
+// submoduleDetail describes the submodule state in words
+func (e StatusEntry) submoduleDetail() string {
+	if len(e.Submodule) < 4 {
+		return ""
+	}
+
+	var details []string
+	if e.Submodule[1] == 'C' {
+		details = append(details, "new commits")
+	}
+	if e.Submodule[2] == 'M' {
+		details = append(details, "modified content")
+	}
+	if e.Submodule[3] == 'U' {
+		details = append(details, "untracked content")
+	}
+	return strings.Join(details, ", ")
+}
+
+// GetColoredStatus returns a colored, human-readable git status.
+// Collapsed untracked directories are annotated with the number of files they contain.
+func GetColoredStatus(expandUntracked bool) (string, error) {
+	entries, err := GetStatusEntries(expandUntracked)
 	if err != nil {
 		return "", err
 	}
 
-	if len(output) == 0 {
-		return "", nil
+	if !expandUntracked {
+		entries, err = countUntrackedDirFiles(entries)
+		if err != nil {
+			return "", err
+		}
 	}
 
-	lines := strings.Split(string(output), "\n")
-	var result strings.Builder
+	return renderStatusEntries(entries), nil
+}
 
-	greenStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575"))
-	redStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555"))
-	orangeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF8800"))
+// countUntrackedDirFiles fills in FileCount for collapsed untracked directories
+func countUntrackedDirFiles(entries []StatusEntry) ([]StatusEntry, error) {
+	hasDirs := false
+	for _, entry := range entries {
+		if entry.Code == "??" && strings.HasSuffix(entry.Path, "/") {
+			hasDirs = true
+			break
+		}
+	}
+	if !hasDirs {
+		return entries, nil
+	}
 
-	for _, line := range lines {
-		// Skip empty lines
-		if len(line) < 4 {
+	expanded, err := GetStatusEntries(true)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, entry := range entries {
+		if entry.Code != "??" || !strings.HasSuffix(entry.Path, "/") {
 			continue
 		}
+		for _, file := range expanded {
+			if file.Code == "??" && strings.HasPrefix(file.Path, entry.Path) {
+				entries[i].FileCount++
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// renderStatusEntries renders status entries as colored, human-readable lines
+func renderStatusEntries(entries []StatusEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	var result strings.Builder
 
-		// Git status format: XY filename (with leading space)
-		// Position 0: space, Position 1: staged status, Position 2: unstaged status
-		statusCode := line[0:2]
-		filename := line[3:]
+	greenStyle := lipgloss.NewStyle().Foreground(colorSuccess)
+	redStyle := lipgloss.NewStyle().Foreground(colorDanger)
+	orangeStyle := lipgloss.NewStyle().Foreground(colorModified)
+	dimStyle := lipgloss.NewStyle().Foreground(colorMuted)
+
+	for _, entry := range entries {
+		// Position 0: staged status, Position 1: unstaged status
+		statusCode := entry.Code
 
 		var color lipgloss.Style
 		var status string
@@ -91,10 +659,30 @@ func GetColoredStatus() (string, error) {
 		case statusCode == "??":
 			color = redStyle
 			status = "  untracked"
+		// Ignored by .gitignore
+		case statusCode == "!!":
+			color = dimStyle
+			status = "  ignored"
+		// Merge conflict
+		case entry.IsUnmerged():
+			color = redStyle
+			status = "  conflict"
+		// Submodule with changes
+		case entry.Submodule != "":
+			color = orangeStyle
+			status = "  submodule"
 		// Added (staged)
 		case statusCode[0] == 'A':
 			color = greenStyle
 			status = "  added"
+		// Renamed
+		case statusCode[0] == 'R':
+			color = greenStyle
+			status = "  renamed"
+		// Copied
+		case statusCode[0] == 'C':
+			color = greenStyle
+			status = "  copied"
 		// Modified (unstaged)
 		case statusCode[1] == 'M':
 			color = orangeStyle
@@ -111,14 +699,10 @@ func GetColoredStatus() (string, error) {
 		case statusCode[0] == 'D':
 			color = redStyle
 			status = "  deleted"
-		// Renamed
-		case statusCode[0] == 'R':
-			color = greenStyle
-			status = "  renamed"
-		// Copied
-		case statusCode[0] == 'C':
-			color = greenStyle
-			status = "  copied"
+		// Type changed (e.g. file replaced by symlink)
+		case statusCode[0] == 'T' || statusCode[1] == 'T':
+			color = orangeStyle
+			status = "  typechange"
 		default:
 			color = orangeStyle
 			status = "  changed"
@@ -126,7 +710,119 @@ func GetColoredStatus() (string, error) {
 
 		result.WriteString(color.Render(fmt.Sprintf("%-12s", status)))
 		result.WriteString(" ")
-		result.WriteString(color.Render(filename))
+		result.WriteString(color.Render(entry.DisplayPath()))
+		if entry.FileCount > 0 {
+			noun := "files"
+			if entry.FileCount == 1 {
+				noun = "file"
+			}
+			result.WriteString(" ")
+			result.WriteString(dimStyle.Render(fmt.Sprintf("(%d %s)", entry.FileCount, noun)))
+		}
+		if detail := entry.submoduleDetail(); detail != "" {
+			result.WriteString(" ")
+			result.WriteString(dimStyle.Render("(" + detail + ")"))
+		}
+		result.WriteString("\n")
+	}
+
+	return result.String()
+}
+
+// IgnoredFile represents a file excluded from commits by an ignore rule
+type IgnoredFile struct {
+	Path    string
+	Source  string // File that contains the matching rule (e.g. ".gitignore")
+	Line    string // Line number of the rule within Source
+	Pattern string
+}
+
+// GetIgnoredFiles returns files excluded by .gitignore rules along with the matching rule.
+// When expandUntracked is true, ignored directories are listed file by file.
+func GetIgnoredFiles(expandUntracked bool) ([]IgnoredFile, error) {
+	// matching lists a directory only when a rule matches the directory itself;
+	// one ignored through its files (*.log for logs/a.log) is listed file by
+	// file, since check-ignore has no rule for the directory
+	args := []string{"--ignored=matching", "--untracked-files=normal"}
+	if expandUntracked {
+		args = []string{"--ignored", "--untracked-files=all"}
+	}
+	cmd := exec.Command("git", append([]string{"status", "--porcelain=v2", "-z"}, args...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range parsePorcelainV2(string(output)) {
+		if entry.Code == "!!" {
+			paths = append(paths, entry.Path)
+		}
+	}
+
+	if len(paths) == 0 {
+		return []IgnoredFile{}, nil
+	}
+
+	// Ask git which rule matched each path; --non-matching keeps paths without
+	// one in the output, and exit status 1 only means none matched
+	cmd = exec.Command("git", "check-ignore", "-v", "-z", "--non-matching", "--stdin")
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00") + "\x00")
+	output, err = cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return nil, fmt.Errorf("failed to check ignore rules: %w", err)
+	}
+
+	return parseCheckIgnore(string(output)), nil
+}
+
+// parseCheckIgnore parses git check-ignore -v -z output (source, line, pattern,
+// path); with --non-matching, paths without a rule have empty fields
+func parseCheckIgnore(output string) []IgnoredFile {
+	fields := strings.Split(output, "\x00")
+	files := make([]IgnoredFile, 0, len(fields)/4)
+
+	for i := 0; i+3 < len(fields); i += 4 {
+		if fields[i+3] == "" {
+			continue
+		}
+		files = append(files, IgnoredFile{
+			Source:  fields[i],
+			Line:    fields[i+1],
+			Pattern: fields[i+2],
+			Path:    fields[i+3],
+		})
+	}
+
+	return files
+}
+
+// GetColoredIgnored returns a colored list of ignored files with their matching rules
+func GetColoredIgnored(expandUntracked bool) (string, error) {
+	files, err := GetIgnoredFiles(expandUntracked)
+	if err != nil {
+		return "", err
+	}
+
+	if len(files) == 0 {
+		return "", nil
+	}
+
+	var result strings.Builder
+	ignoredStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	ruleStyle := lipgloss.NewStyle().Foreground(colorPrimary)
+
+	for _, file := range files {
+		result.WriteString(ignoredStyle.Render(fmt.Sprintf("%-12s", "  ignored")))
+		result.WriteString(" ")
+		result.WriteString(file.Path)
+		result.WriteString(" ")
+		if file.Source == "" {
+			result.WriteString(ruleStyle.Render("(no matching rule)"))
+		} else {
+			result.WriteString(ruleStyle.Render(fmt.Sprintf("(%s:%s: %s)", file.Source, file.Line, file.Pattern)))
+		}
 		result.WriteString("\n")
 	}
 
@@ -153,6 +849,36 @@ func GetCurrentBranch() (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// GetDefaultBranch returns the repository's main line of development: the
+// branch origin/HEAD points to, then init.defaultBranch, then main or master.
+// A default branch that only exists on origin is returned as "origin/<name>".
+func GetDefaultBranch() (string, error) {
+	cmd := exec.Command("git", "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD")
+	if output, err := cmd.Output(); err == nil {
+		remote := strings.TrimSpace(string(output))
+		if name := strings.TrimPrefix(remote, "origin/"); localBranchExists(name) {
+			return name, nil
+		}
+		return remote, nil
+	}
+
+	candidates := []string{"main", "master"}
+	if output, err := exec.Command("git", "config", "init.defaultBranch").Output(); err == nil {
+		candidates = append([]string{strings.TrimSpace(string(output))}, candidates...)
+	}
+	for _, name := range candidates {
+		if localBranchExists(name) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("could not determine the default branch (set origin/HEAD with 'git remote set-head origin --auto')")
+}
+
+// localBranchExists reports whether refs/heads/<name> exists
+func localBranchExists(name string) bool {
+	return exec.Command("git", "rev-parse", "--verify", "--quiet", "refs/heads/"+name).Run() == nil
+}
+
 // HasUpstreamBranch checks if the current branch has an upstream branch
 func HasUpstreamBranch() (bool, error) {
 	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "@{upstream}")
@@ -181,6 +907,149 @@ func PushWithUpstream(branch string) (string, error) {
 	return string(output), err
 }
 
+// PullRebase pulls and replays local commits on top of the remote branch
+func PullRebase() (string, error) {
+	cmd := exec.Command("git", "pull", "--rebase")
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// PushWithLease pushes the current branch with --force-with-lease, which only
+// overwrites the remote branch if it hasn't moved since the last fetch. The
+// upstream is set when the branch has none yet.
+func PushWithLease(branch string, hasUpstream bool) (string, error) {
+	args := []string{"push", "--force-with-lease"}
+	if !hasUpstream {
+		args = append(args, "-u", "origin", branch)
+	}
+	cmd := exec.Command("git", args...)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// IsPushRejected reports whether push output says the remote has commits the
+// local branch doesn't, i.e. the remote moved and a pull is needed
+func IsPushRejected(output string) bool {
+	for _, reason := range []string{"non-fast-forward", "fetch first", "stale info"} {
+		if strings.Contains(output, reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoteExists reports whether a remote with the given name is configured
+func RemoteExists(name string) bool {
+	return exec.Command("git", "remote", "get-url", name).Run() == nil
+}
+
+// FetchRemote fetches all branches of a remote
+func FetchRemote(remote string) (string, error) {
+	cmd := exec.Command("git", "fetch", remote)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// GetRemoteDefaultBranch returns the name of a remote's default branch, from
+// refs/remotes/<remote>/HEAD or by asking the remote, falling back to the
+// local default branch
+func GetRemoteDefaultBranch(remote string) (string, error) {
+	cmd := exec.Command("git", "symbolic-ref", "--quiet", "--short", "refs/remotes/"+remote+"/HEAD")
+	if output, err := cmd.Output(); err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(string(output)), remote+"/"), nil
+	}
+
+	// "ref: refs/heads/main\tHEAD"
+	cmd = exec.Command("git", "ls-remote", "--symref", remote, "HEAD")
+	if output, err := cmd.Output(); err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			if ref, ok := strings.CutPrefix(line, "ref: refs/heads/"); ok {
+				if name, _, ok := strings.Cut(ref, "\t"); ok {
+					return name, nil
+				}
+			}
+		}
+	}
+
+	branch, err := GetDefaultBranch()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(branch, "origin/"), nil
+}
+
+// ForkPullBranch returns the branch of the upstream remote that branch pulls
+// from in a fork workflow: the branch of the same name if upstream has one,
+// otherwise upstream's default branch. Upstream must have been fetched.
+func ForkPullBranch(upstream, branch string) (string, error) {
+	if exec.Command("git", "rev-parse", "--verify", "--quiet", "refs/remotes/"+upstream+"/"+branch).Run() == nil {
+		return branch, nil
+	}
+	return GetRemoteDefaultBranch(upstream)
+}
+
+// PullFrom pulls a branch of a remote into the current branch, rebasing local
+// commits on top of it with rebase
+func PullFrom(remote, branch string, rebase bool) (string, error) {
+	args := []string{"pull"}
+	if rebase {
+		args = append(args, "--rebase")
+	}
+	cmd := exec.Command("git", append(args, remote, branch)...)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// PushTo pushes branch to remote, setting it as the upstream when setUpstream
+// is true. With lease, the push uses --force-with-lease.
+func PushTo(remote, branch string, setUpstream, lease bool) (string, error) {
+	args := []string{"push"}
+	if lease {
+		args = append(args, "--force-with-lease")
+	}
+	if setUpstream {
+		args = append(args, "-u")
+	}
+	cmd := exec.Command("git", append(args, remote, branch)...)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// UpdateFork fast-forwards the default branch of the fork (origin) and the
+// local branch of the same name to upstream's default branch. Nothing is
+// forced: diverged branches are an error. Returns the branch name.
+func UpdateFork(upstream, origin string) (string, error) {
+	if output, err := FetchRemote(upstream); err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %s", upstream, strings.TrimSpace(output))
+	}
+	branch, err := GetRemoteDefaultBranch(upstream)
+	if err != nil {
+		return "", err
+	}
+	source := upstream + "/" + branch
+
+	// Local branch: merge when checked out, otherwise move the ref
+	current, _ := GetCurrentBranch()
+	var cmd *exec.Cmd
+	switch {
+	case current == branch:
+		cmd = exec.Command("git", "merge", "--ff-only", source)
+	case localBranchExists(branch):
+		cmd = exec.Command("git", "fetch", ".", source+":"+branch)
+	}
+	if cmd != nil {
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("local %s can't be fast-forwarded to %s: %s", branch, source, strings.TrimSpace(string(output)))
+		}
+	}
+
+	cmd = exec.Command("git", "push", origin, source+":refs/heads/"+branch)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to fast-forward %s/%s: %s", origin, branch, strings.TrimSpace(string(output)))
+	}
+	return branch, nil
+}
+
 // CheckForUncommittedChanges checks if there are uncommitted changes
 func CheckForUncommittedChanges() (bool, error) {
 	status, err := GetStatus()
@@ -266,12 +1135,72 @@ func GetCommitHistory(limit int, allBranches bool, author string, filePath strin
 	return commits, nil
 }
 
+// CommitStats holds the line and file counts of a single commit
+type CommitStats struct {
+	Additions    int
+	Deletions    int
+	FilesChanged int
+}
+
+// GetCommitStats returns line and file counts for the given commits, keyed by full
+// hash, using a single git log --numstat call. Merge commits have no stats.
+func GetCommitStats(hashes []string) (map[string]CommitStats, error) {
+	if len(hashes) == 0 {
+		return map[string]CommitStats{}, nil
+	}
+
+	// Hashes go through stdin so long histories don't hit the argument limit
+	cmd := exec.Command("git", "log", "--no-walk=unsorted", "--stdin", "--numstat", "--format=%x1e%H")
+	cmd.Stdin = strings.NewReader(strings.Join(hashes, "\n") + "\n")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseNumstatLog(string(output)), nil
+}
+
+// parseNumstatLog parses git log --numstat output where each commit starts with
+// a \x1e-prefixed hash line. Binary files count as changed files without lines.
+func parseNumstatLog(output string) map[string]CommitStats {
+	stats := map[string]CommitStats{}
+
+	for _, record := range strings.Split(output, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		hash := strings.TrimSpace(lines[0])
+		if hash == "" {
+			continue
+		}
+
+		var s CommitStats
+		for _, line := range lines[1:] {
+			fields := strings.SplitN(line, "\t", 3)
+			if len(fields) < 3 {
+				continue
+			}
+			s.FilesChanged++
+			if added, err := strconv.Atoi(fields[0]); err == nil {
+				s.Additions += added
+			}
+			if deleted, err := strconv.Atoi(fields[1]); err == nil {
+				s.Deletions += deleted
+			}
+		}
+		stats[hash] = s
+	}
+
+	return stats
+}
+
 // BranchInfo represents a git branch with metadata
 type BranchInfo struct {
 	Name       string
 	Current    bool
 	LastCommit string
-	Upstream   string
+	Upstream   string // Upstream with its ahead/behind counts, e.g. "origin/x: ahead 1"
+	Base       string // Default branch the branch is compared to, empty for the default branch itself
+	Ahead      int    // Commits not on Base
+	Behind     int    // Commits on Base missing from the branch
 }
 
 // GetBranches returns a list of all branches with metadata
@@ -338,9 +1267,90 @@ func GetBranches() ([]BranchInfo, error) {
 		branches = append(branches, branch)
 	}
 
+	// Compare against the default branch, if there is one
+	if base, err := GetDefaultBranch(); err == nil {
+		for i := range branches {
+			if branches[i].Name == base {
+				continue
+			}
+			if ahead, behind, err := GetAheadBehind(branches[i].Name, base); err == nil {
+				branches[i].Base, branches[i].Ahead, branches[i].Behind = base, ahead, behind
+			}
+		}
+	}
+
 	return branches, nil
 }
 
+// GetRecentSubjects returns the subjects of the last n non-merge commits on HEAD,
+// newest first
+func GetRecentSubjects(n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	cmd := exec.Command("git", "log", "--no-merges", "-n", strconv.Itoa(n), "--format=%s")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var subjects []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			subjects = append(subjects, line)
+		}
+	}
+	return subjects, nil
+}
+
+// GetAheadBehind counts the commits on ref that aren't on base (ahead) and the
+// commits on base that aren't on ref (behind). Only local refs are compared;
+// nothing is fetched.
+func GetAheadBehind(ref, base string) (ahead, behind int, err error) {
+	cmd := exec.Command("git", "rev-list", "--left-right", "--count", ref+"..."+base)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscanf(string(output), "%d %d", &ahead, &behind); err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", output)
+	}
+	return ahead, behind, nil
+}
+
+// GetUpstreamBranch returns the upstream of the current branch, or "" if it has none
+func GetUpstreamBranch() string {
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "@{upstream}")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// GetGitConfig returns a config value, or "" if it isn't set. With local, only
+// the repository's own .git/config is read.
+func GetGitConfig(key string, local bool) string {
+	args := []string{"config"}
+	if local {
+		args = append(args, "--local")
+	}
+	output, err := exec.Command("git", append(args, "--get", key)...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// SetLocalGitConfig sets a config value for the current repository only
+func SetLocalGitConfig(key, value string) error {
+	output, err := exec.Command("git", "config", "--local", key, value).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git config %s: %s", key, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 // CreateBranch creates a new branch
 func CreateBranch(branchName string) error {
 	cmd := exec.Command("git", "branch", branchName)
@@ -367,6 +1377,79 @@ func CreateAndSwitchBranch(branchName string) error {
 	return nil
 }
 
+// IsProtectedBranch reports whether a branch matches one of the save.protected
+// patterns, where commits should go through a pull request instead
+func IsProtectedBranch(branch string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// BranchNameFor turns a commit subject into a branch name, e.g.
+// "feat(api): add rate limits" becomes feat/add-rate-limits
+func BranchNameFor(subject string) string {
+	prefix, description := "wip", subject
+	if c, ok := ParseConventionalCommit(subject, ""); ok {
+		prefix, description = strings.ToLower(c.Type), c.Description
+	}
+
+	var slug strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(description) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			slug.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	name := slug.String()
+	// Keep it short, cutting at a word
+	if len(name) > 40 {
+		name = name[:40]
+		if i := strings.LastIndex(name, "-"); i > 0 {
+			name = name[:i]
+		}
+	}
+	if name == "" {
+		name = "changes"
+	}
+	return prefix + "/" + name
+}
+
+// SuggestBranchName is BranchNameFor with a number added when the name is
+// already taken
+func SuggestBranchName(subject string) string {
+	name := BranchNameFor(subject)
+	taken := func(branch string) bool {
+		return exec.Command("git", "rev-parse", "--verify", "--quiet", "refs/heads/"+branch).Run() == nil
+	}
+	if !taken(name) {
+		return name
+	}
+	for i := 2; ; i++ {
+		if candidate := fmt.Sprintf("%s-%d", name, i); !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// CreateAndSwitchBranchAt creates a new branch at the given commit and switches to it
+func CreateAndSwitchBranchAt(branchName, startPoint string) error {
+	cmd := exec.Command("git", "checkout", "-b", branchName, startPoint)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, string(output))
+	}
+	return nil
+}
+
 // DeleteBranch deletes a branch (safe, won't delete if unmerged)
 func DeleteBranch(branchName string) error {
 	cmd := exec.Command("git", "branch", "-d", branchName)
@@ -394,32 +1477,270 @@ func CheckoutCommit(commitHash string) error {
 	if err != nil {
 		return fmt.Errorf("%w: %s", err, string(output))
 	}
-	return nil
+	return nil
+}
+
+// GetCommitDiff returns the changes a commit introduced, compared to its
+// first parent for merges
+func GetCommitDiff(hash string) (string, error) {
+	cmd := exec.Command("git", "show", "--format=", "--no-color", "--diff-merges=first-parent", hash)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// RewordCommit replaces the message of a commit on the current branch. HEAD is
+// amended without touching the index; an older commit is recreated with the
+// same tree, parents, and author, and the commits after it are rebased onto it.
+func RewordCommit(hash, message string) error {
+	head, err := ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+
+	if hash == head {
+		cmd := exec.Command("git", "commit", "--amend", "--only", "--allow-empty", "-F", "-")
+		cmd.Stdin = strings.NewReader(message)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to amend commit: %s", strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+
+	if err := exec.Command("git", "merge-base", "--is-ancestor", hash, "HEAD").Run(); err != nil {
+		return fmt.Errorf("commit %s is not on the current branch", hash[:min(7, len(hash))])
+	}
+
+	cmd := exec.Command("git", "show", "-s", "--date=raw", "--format=%T%n%P%n%an%n%ae%n%ad", hash)
+	output, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+	fields := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+	if len(fields) < 5 {
+		return fmt.Errorf("unexpected commit info for %s", hash)
+	}
+
+	args := []string{"commit-tree", fields[0]}
+	for _, parent := range strings.Fields(fields[1]) {
+		args = append(args, "-p", parent)
+	}
+	args = append(args, "-F", "-")
+
+	cmd = exec.Command("git", args...)
+	cmd.Stdin = strings.NewReader(message)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+fields[2],
+		"GIT_AUTHOR_EMAIL="+fields[3],
+		"GIT_AUTHOR_DATE="+fields[4],
+	)
+	output, err = cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to create reworded commit: %w", err)
+	}
+	reworded := strings.TrimSpace(string(output))
+
+	// The trees match, so replaying the later commits cannot conflict
+	cmd = exec.Command("git", "rebase", "--quiet", "--rebase-merges", "--autostash", "--onto", reworded, hash)
+	output, err = cmd.CombinedOutput()
+	if err != nil {
+		exec.Command("git", "rebase", "--abort").Run()
+		return fmt.Errorf("failed to rebase onto reworded commit: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// IsDetachedHead checks if HEAD is in detached state
+func IsDetachedHead() (bool, error) {
+	cmd := exec.Command("git", "symbolic-ref", "-q", "HEAD")
+	err := cmd.Run()
+	// If error, we're in detached HEAD state
+	return err != nil, nil
+}
+
+// GetCommitDetails returns detailed information about a commit
+func GetCommitDetails(commitHash string) (string, error) {
+	cmd := exec.Command("git", "show", "--stat", "--pretty=fuller", commitHash)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// GetCommitStat returns the git diff --stat of a commit: one line per file
+// and the "N files changed" summary
+func GetCommitStat(ref string) (string, error) {
+	cmd := exec.Command("git", "show", "--stat", "--format=", "--no-color", ref)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(string(output), "\n"), nil
+}
+
+// backupRefPrefix namespaces the refs snap writes before rewriting history
+const backupRefPrefix = "refs/snap/backup/"
+
+// BackupHead records the current HEAD under refs/snap/backup/<timestamp>
+// before a history rewrite, so `git reset --hard <ref>` undoes it. The reason
+// ends up in the ref's reflog. It returns the new ref.
+func BackupHead(reason string) (string, error) {
+	ref := backupRefPrefix + time.Now().Format("20060102-150405")
+	for i := 1; i <= 10; i++ {
+		// The empty old value makes update-ref fail instead of overwriting a backup
+		cmd := exec.Command("git", "update-ref", "--create-reflog", "-m", "snap: "+reason, ref, "HEAD", "")
+		if err := cmd.Run(); err == nil {
+			return ref, nil
+		}
+		if exec.Command("git", "rev-parse", "--verify", "--quiet", ref).Run() != nil {
+			return "", fmt.Errorf("failed to back up HEAD to %s", ref)
+		}
+		ref = fmt.Sprintf("%s%s-%d", backupRefPrefix, time.Now().Format("20060102-150405"), i)
+	}
+	return "", fmt.Errorf("failed to back up HEAD: too many backups this second")
+}
+
+// ReplayCommits rebases current branch onto the specified branch. onProgress,
+// when set, is called as git reports each commit being applied; the progress
+// lines are left out of the returned output. With updateRefs, branches
+// pointing at replayed commits are moved along (stacked branches). The
+// commits in messages get the new message as soon as they are applied.
+func ReplayCommits(ontoBranch string, updateRefs bool, messages map[string]string, onProgress func(current, total int)) (string, error) {
+	args := []string{"rebase"}
+	if updateRefs {
+		args = append(args, "--update-refs")
+	}
+	var env []string
+	var rewordDir string
+	if len(messages) > 0 {
+		// The messages outlive a conflict, for the execs after git rebase --continue
+		output, err := exec.Command("git", "rev-parse", "--git-path", "snap/reword").Output()
+		if err != nil {
+			return "", fmt.Errorf("not a git repository")
+		}
+		if rewordDir, err = filepath.Abs(strings.TrimSpace(string(output))); err != nil {
+			return "", err
+		}
+		os.RemoveAll(rewordDir)
+		if err := os.MkdirAll(rewordDir, 0755); err != nil {
+			return "", err
+		}
+		editor, err := writeRewordEditor(rewordDir, messages)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, "--interactive")
+		env = append(os.Environ(), "GIT_SEQUENCE_EDITOR="+editor, "GIT_EDITOR=true")
+	}
+	cmd := exec.Command("git", append(args, ontoBranch)...)
+	cmd.Env = env
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	var output strings.Builder
+	scanner := bufio.NewScanner(stdout)
+	scanner.Split(scanProgressLines)
+	for scanner.Scan() {
+		line := strings.ReplaceAll(scanner.Text(), "\x1b[K", "")
+		if line == "" {
+			continue
+		}
+		if current, total, ok := parseRebaseProgress(line); ok {
+			if onProgress != nil {
+				onProgress(current, total)
+			}
+			continue
+		}
+		output.WriteString(line + "\n")
+	}
+
+	err = cmd.Wait()
+	if err == nil && rewordDir != "" {
+		os.RemoveAll(rewordDir)
+	}
+	return output.String(), err
+}
+
+// writeRewordEditor writes the messages to dir and returns a sequence editor
+// that keeps git's todo list (update-ref lines included) and adds an exec
+// that amends the message after each pick of a listed commit. The todo list
+// abbreviates hashes, so picks are matched by prefix.
+func writeRewordEditor(dir string, messages map[string]string) (string, error) {
+	var program strings.Builder
+	program.WriteString("{ print }\n")
+	program.WriteString("$1 == \"pick\" || $1 == \"p\" {\n")
+	for hash, message := range messages {
+		file := filepath.Join(dir, hash)
+		if err := os.WriteFile(file, []byte(message), 0644); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&program, "\tif (length($2) >= 4 && index(\"%s\", $2) == 1) print \"%s\"\n", hash, amendMessageExec(file))
+	}
+	program.WriteString("}\n")
+	programFile := filepath.Join(dir, "reword.awk")
+	if err := os.WriteFile(programFile, []byte(program.String()), 0644); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("f() { awk -f '%s' \"$1\" > '%s' && cp '%s' \"$1\"; }; f",
+		programFile, filepath.Join(dir, "todo"), filepath.Join(dir, "todo")), nil
+}
+
+// amendMessageExec is the todo line that replaces the message of the commit
+// just picked with the file's, leaving its changes alone
+func amendMessageExec(file string) string {
+	return fmt.Sprintf("exec git commit --amend --only --allow-empty --no-verify --quiet -F '%s'", file)
+}
+
+// scanProgressLines splits output on newlines and on the carriage returns git
+// uses to redraw progress in place
+func scanProgressLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
 }
 
-// IsDetachedHead checks if HEAD is in detached state
-func IsDetachedHead() (bool, error) {
-	cmd := exec.Command("git", "symbolic-ref", "-q", "HEAD")
-	err := cmd.Run()
-	// If error, we're in detached HEAD state
-	return err != nil, nil
+// parseRebaseProgress reads git's "Rebasing (3/7)" progress line
+func parseRebaseProgress(line string) (current, total int, ok bool) {
+	n, _ := fmt.Sscanf(line, "Rebasing (%d/%d)", &current, &total)
+	return current, total, n == 2 && total > 0
 }
 
-// GetCommitDetails returns detailed information about a commit
-func GetCommitDetails(commitHash string) (string, error) {
-	cmd := exec.Command("git", "show", "--stat", "--pretty=fuller", commitHash)
+// GetStackedBranches returns the local branches other than the current one that
+// point at one of the given commits, which a replay with --update-refs moves along
+func GetStackedBranches(hashes []string) ([]string, error) {
+	currentBranch, err := GetCurrentBranch()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("git", "for-each-ref", "--format=%(objectname) %(refname:short)", "refs/heads")
 	output, err := cmd.Output()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return string(output), nil
-}
 
-// ReplayCommits rebases current branch onto the specified branch
-func ReplayCommits(ontoBranch string) (string, error) {
-	cmd := exec.Command("git", "rebase", ontoBranch)
-	output, err := cmd.CombinedOutput()
-	return string(output), err
+	var branches []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		hash, branch, ok := strings.Cut(line, " ")
+		if ok && branch != currentBranch && slices.Contains(hashes, hash) {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
 }
 
 // ReplayCommitsInteractive starts an interactive rebase
@@ -435,9 +1756,11 @@ func AbortRebase() error {
 	return cmd.Run()
 }
 
-// ContinueRebase continues a rebase after resolving conflicts
+// ContinueRebase continues a rebase after resolving conflicts, keeping the
+// message of the commit it stopped at instead of opening an editor
 func ContinueRebase() (string, error) {
 	cmd := exec.Command("git", "rebase", "--continue")
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
 	output, err := cmd.CombinedOutput()
 	return string(output), err
 }
@@ -451,22 +1774,25 @@ func SkipRebaseCommit() (string, error) {
 
 // CheckRebaseInProgress checks if a rebase is currently in progress
 func CheckRebaseInProgress() (bool, error) {
-	// Check for rebase-merge or rebase-apply directory
-	cmd := exec.Command("git", "rev-parse", "--git-path", "rebase-merge")
-	output, err := cmd.Output()
-	if err != nil {
-		return false, nil
-	}
+	// Check for rebase-merge (interactive and merge backend) or rebase-apply (git am backend)
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		cmd := exec.Command("git", "rev-parse", "--git-path", dir)
+		output, err := cmd.Output()
+		if err != nil {
+			return false, nil
+		}
 
-	rebasePath := strings.TrimSpace(string(output))
-	if rebasePath == "" {
-		return false, nil
-	}
+		rebasePath := strings.TrimSpace(string(output))
+		if rebasePath == "" {
+			continue
+		}
 
-	// Check if the directory exists
-	cmd = exec.Command("test", "-d", rebasePath)
-	err = cmd.Run()
-	return err == nil, nil
+		// Check if the directory exists
+		if info, err := os.Stat(rebasePath); err == nil && info.IsDir() {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 // GetRebaseCommits gets the list of commits that would be replayed
@@ -521,6 +1847,17 @@ func GetMergeBase(branch1, branch2 string) (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// ResolveCommit returns the full hash of the commit a hash, tag, branch, or
+// expression like HEAD~3 points to
+func ResolveCommit(ref string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("unknown commit '%s'", ref)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // TagInfo represents a git tag with metadata
 type TagInfo struct {
 	Name         string
@@ -535,6 +1872,8 @@ type CommitWithStats struct {
 	ShortHash    string
 	Message      string
 	Author       string
+	AuthorEmail  string
+	Date         time.Time // Author date
 	RelativeTime string
 	Additions    int
 	Deletions    int
@@ -590,6 +1929,76 @@ func GetMostRecentTag() (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// GetMostRecentTagWithPrefix returns the most recent tag reachable from ref whose
+// name starts with prefix (e.g. "api/v" in a monorepo). Prerelease tags (containing
+// a "-" after the prefix) are skipped unless includePrerelease is true.
+func GetMostRecentTagWithPrefix(ref, prefix string, includePrerelease bool) (string, error) {
+	args := []string{"describe", "--tags", "--abbrev=0", "--match", prefix + "*"}
+	if !includePrerelease {
+		args = append(args, "--exclude", prefix+"*-*")
+	}
+	args = append(args, ref)
+
+	cmd := exec.Command("git", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetReleaseCommits returns the commits in ref since the most recent tag with the
+// given prefix, along with that tag's name. The tag name is empty when there is
+// no previous release.
+func GetReleaseCommits(ref, prefix string, includePrerelease bool) ([]CommitWithStats, string, error) {
+	prevTag, err := GetMostRecentTagWithPrefix(ref, prefix, includePrerelease)
+	if err != nil {
+		// No previous release - the new tag covers the whole history
+		prevTag = ""
+	}
+
+	commits, err := GetCommitsBetweenTags(prevTag, ref)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return commits, prevTag, nil
+}
+
+// GetUnreleasedCommits returns the commits on HEAD since the most recent tag, along
+// with that tag's name. The tag name is empty when the repository has no tags yet.
+func GetUnreleasedCommits() ([]CommitWithStats, string, error) {
+	prevTag, err := GetMostRecentTag()
+	if err != nil {
+		// No tags yet - every commit is unreleased
+		prevTag = ""
+	}
+
+	commits, err := GetCommitsSinceTag(prevTag)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return commits, prevTag, nil
+}
+
+// ListTagNames returns the names of all tags starting with prefix
+func ListTagNames(prefix string) ([]string, error) {
+	cmd := exec.Command("git", "tag", "--list", prefix+"*")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			names = append(names, line)
+		}
+	}
+	return names, nil
+}
+
 // GetCommitsSinceTag returns commits between a tag and HEAD with stats
 func GetCommitsSinceTag(tagName string) ([]CommitWithStats, error) {
 	var ref string
@@ -600,8 +2009,8 @@ func GetCommitsSinceTag(tagName string) ([]CommitWithStats, error) {
 		ref = tagName + "..HEAD"
 	}
 
-	// Get commit info
-	args := []string{"log", "--no-merges", "--pretty=format:%H|%h|%s|%an|%ar"}
+	// Get commit info; the subject goes last since it may contain |
+	args := []string{"log", "--no-merges", "--pretty=format:%H|%h|%an|%ae|%aI|%ar|%s"}
 	if ref != "" {
 		args = append(args, ref)
 	}
@@ -623,17 +2032,20 @@ func GetCommitsSinceTag(tagName string) ([]CommitWithStats, error) {
 		if line == "" {
 			continue
 		}
-		parts := strings.SplitN(line, "|", 5)
-		if len(parts) < 5 {
+		parts := strings.SplitN(line, "|", 7)
+		if len(parts) < 7 {
 			continue
 		}
 
+		date, _ := time.Parse(time.RFC3339, parts[4])
 		commit := CommitWithStats{
 			Hash:         parts[0],
 			ShortHash:    parts[1],
-			Message:      parts[2],
-			Author:       parts[3],
-			RelativeTime: parts[4],
+			Author:       parts[2],
+			AuthorEmail:  parts[3],
+			Date:         date,
+			RelativeTime: parts[5],
+			Message:      parts[6],
 		}
 
 		// Get stats for this commit
@@ -693,7 +2105,7 @@ func GetTagDiffStats(tagName string) (additions, deletions, filesChanged int, er
 	var ref string
 	if tagName == "" {
 		// Compare with empty tree
-		ref = "4b825dc642cb6eb9a060e54bf8d69288fbee4904..HEAD"
+		ref = emptyTreeHash + "..HEAD"
 	} else {
 		ref = tagName + "..HEAD"
 	}
@@ -745,6 +2157,17 @@ func CreateAnnotatedTag(tagName, message string) error {
 	return nil
 }
 
+// CreateAnnotatedTagAt creates an annotated tag pointing at the given commit-ish
+func CreateAnnotatedTagAt(tagName, message, ref string) error {
+	// Keep markdown headings - the default cleanup strips lines starting with '#'
+	cmd := exec.Command("git", "tag", "-a", "--cleanup=whitespace", tagName, "-m", message, ref)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, string(output))
+	}
+	return nil
+}
+
 // PushTag pushes a tag to the remote repository
 func PushTag(tagName string) (string, error) {
 	cmd := exec.Command("git", "push", "origin", tagName)
@@ -758,6 +2181,79 @@ func DeleteTag(tagName string) error {
 	return cmd.Run()
 }
 
+// ForcePushTag pushes a tag to origin, replacing the remote tag if it differs
+func ForcePushTag(tagName string) (string, error) {
+	cmd := exec.Command("git", "push", "--force", "origin", "refs/tags/"+tagName)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// IsTagPushed checks whether origin has a tag with the given name
+func IsTagPushed(tagName string) (bool, error) {
+	cmd := exec.Command("git", "ls-remote", "--tags", "origin", "refs/tags/"+tagName)
+	output, err := cmd.Output()
+	if err != nil {
+		return false, err
+	}
+	return len(strings.TrimSpace(string(output))) > 0, nil
+}
+
+// GetTagMessage returns the annotation message of a tag, without the
+// signature block of a signed tag
+func GetTagMessage(tagName string) (string, error) {
+	cmd := exec.Command("git", "for-each-ref", "--format=%(objecttype)%00%(contents:subject)%00%(contents:body)", "refs/tags/"+tagName)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get tag message: %w", err)
+	}
+	if len(output) == 0 {
+		return "", fmt.Errorf("tag '%s' not found", tagName)
+	}
+
+	fields := strings.SplitN(string(output), "\x00", 3)
+	if fields[0] != "tag" || len(fields) != 3 {
+		return "", fmt.Errorf("'%s' is a lightweight tag without a message", tagName)
+	}
+	message := fields[1]
+	if body := strings.TrimRight(fields[2], "\n"); body != "" {
+		message += "\n\n" + body
+	}
+	return message, nil
+}
+
+// IsTagSigned reports whether an annotated tag carries a GPG or SSH signature
+func IsTagSigned(tagName string) bool {
+	output, err := exec.Command("git", "for-each-ref", "--format=%(contents:signature)", "refs/tags/"+tagName).Output()
+	return err == nil && strings.TrimSpace(string(output)) != ""
+}
+
+// ReplaceTagMessage re-creates an annotated tag with a new message, keeping it
+// on the same commit. A signed tag is signed again with the user's key; if
+// that fails, the tag is left as it was.
+func ReplaceTagMessage(tagName, message string) error {
+	signed := IsTagSigned(tagName)
+	mode := "-a"
+	if signed {
+		mode = "-s"
+	}
+	previous, err := exec.Command("git", "rev-parse", "refs/tags/"+tagName).Output()
+	if err != nil {
+		return fmt.Errorf("tag '%s' not found", tagName)
+	}
+	cmd := exec.Command("git", "tag", mode, "-f", "--cleanup=whitespace", tagName, "-m", message, tagName+"^{commit}")
+	output, err := cmd.CombinedOutput()
+	// Some git versions write an unsigned tag when SSH signing fails, so check
+	// the result and put the old tag back
+	if signed && (err != nil || !IsTagSigned(tagName)) {
+		exec.Command("git", "update-ref", "refs/tags/"+tagName, strings.TrimSpace(string(previous))).Run()
+		return fmt.Errorf("%s is signed and couldn't be signed again, so it was left unchanged: %s", tagName, strings.TrimSpace(string(output)))
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 // GetRemoteURL returns the URL of the origin remote
 func GetRemoteURL() (string, error) {
 	cmd := exec.Command("git", "remote", "get-url", "origin")
@@ -768,28 +2264,93 @@ func GetRemoteURL() (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
-// GetTagURL constructs a web URL for a tag based on the remote provider
-func GetTagURL(tagName string) (string, error) {
+// Forge is a web frontend for a git remote
+type Forge struct {
+	Kind    string // "github", "gitlab", or "bitbucket"
+	BaseURL string // e.g. https://github.com/owner/repo
+}
+
+// GetForge returns the forge of the origin remote
+func GetForge() (Forge, error) {
 	remoteURL, err := GetRemoteURL()
 	if err != nil {
-		return "", err
+		return Forge{}, err
 	}
 
 	baseURL := remoteToHTTPS(remoteURL)
 	if baseURL == "" {
-		return "", fmt.Errorf("could not parse remote URL: %s", remoteURL)
+		return Forge{}, fmt.Errorf("could not parse remote URL: %s", remoteURL)
 	}
 
-	// Determine provider from the host
+	return newForge(baseURL), nil
+}
+
+// newForge determines the provider from the host of a base URL
+func newForge(baseURL string) Forge {
 	switch {
 	case strings.Contains(baseURL, "gitlab.com") || strings.Contains(baseURL, "gitlab."):
-		return baseURL + "/-/tags/" + tagName, nil
+		return Forge{Kind: "gitlab", BaseURL: baseURL}
 	case strings.Contains(baseURL, "bitbucket.org") || strings.Contains(baseURL, "bitbucket."):
-		return baseURL + "/src/" + tagName, nil
+		return Forge{Kind: "bitbucket", BaseURL: baseURL}
 	default:
 		// GitHub and other GitHub-compatible hosts (Gitea, Forgejo, etc.)
-		return baseURL + "/releases/tag/" + tagName, nil
+		return Forge{Kind: "github", BaseURL: baseURL}
+	}
+}
+
+// TagURL returns the web URL of a tag or release
+func (f Forge) TagURL(tagName string) string {
+	switch f.Kind {
+	case "gitlab":
+		return f.BaseURL + "/-/tags/" + tagName
+	case "bitbucket":
+		return f.BaseURL + "/src/" + tagName
+	default:
+		return f.BaseURL + "/releases/tag/" + tagName
+	}
+}
+
+// CompareURL returns the web URL of the changes between two refs
+func (f Forge) CompareURL(from, to string) string {
+	switch f.Kind {
+	case "gitlab":
+		return f.BaseURL + "/-/compare/" + from + "..." + to
+	case "bitbucket":
+		return f.BaseURL + "/branches/compare/" + to + "%0D" + from
+	default:
+		return f.BaseURL + "/compare/" + from + "..." + to
+	}
+}
+
+// CommitURL returns the web URL of a commit
+func (f Forge) CommitURL(hash string) string {
+	switch f.Kind {
+	case "gitlab":
+		return f.BaseURL + "/-/commit/" + hash
+	case "bitbucket":
+		return f.BaseURL + "/commits/" + hash
+	default:
+		return f.BaseURL + "/commit/" + hash
+	}
+}
+
+// IssueURL returns the web URL of an issue (GitHub redirects to pull requests)
+func (f Forge) IssueURL(number string) string {
+	switch f.Kind {
+	case "gitlab":
+		return f.BaseURL + "/-/issues/" + number
+	default:
+		return f.BaseURL + "/issues/" + number
+	}
+}
+
+// GetTagURL constructs a web URL for a tag based on the remote provider
+func GetTagURL(tagName string) (string, error) {
+	forge, err := GetForge()
+	if err != nil {
+		return "", err
 	}
+	return forge.TagURL(tagName), nil
 }
 
 // remoteToHTTPS converts a git remote URL (SSH or HTTPS) to a base HTTPS URL
@@ -838,21 +2399,23 @@ type TagDetailInfo struct {
 
 // GetTagDetail returns detailed metadata for a specific tag
 func GetTagDetail(tagName string) (TagDetailInfo, error) {
+	// NUL-separated, since messages can contain any other character;
+	// contents:body leaves out the signature of a signed tag
 	cmd := exec.Command("git", "for-each-ref",
-		"--format=%(refname:short)|%(objectname)|%(objectname:short)|%(taggername)|%(taggeremail)|%(subject)|%(body)|%(creatordate:relative)|%(creatordate:iso)",
+		"--format=%(refname:short)%00%(objectname)%00%(objectname:short)%00%(taggername)%00%(taggeremail)%00%(subject)%00%(contents:body)%00%(creatordate:relative)%00%(creatordate:iso)",
 		fmt.Sprintf("refs/tags/%s", tagName))
 	output, err := cmd.Output()
 	if err != nil {
 		return TagDetailInfo{}, fmt.Errorf("failed to get tag detail: %w", err)
 	}
 
-	line := strings.TrimSpace(string(output))
+	line := strings.TrimSuffix(string(output), "\n")
 	if line == "" {
 		return TagDetailInfo{}, fmt.Errorf("tag '%s' not found", tagName)
 	}
 
-	parts := strings.SplitN(line, "|", 9)
-	if len(parts) < 9 {
+	parts := strings.Split(line, "\x00")
+	if len(parts) != 9 {
 		return TagDetailInfo{}, fmt.Errorf("unexpected tag format for '%s'", tagName)
 	}
 
@@ -869,6 +2432,152 @@ func GetTagDetail(tagName string) (TagDetailInfo, error) {
 	}, nil
 }
 
+// SignatureStatus is the verification result of a signed tag
+type SignatureStatus int
+
+const (
+	SignatureNone      SignatureStatus = iota // Tag is not signed
+	SignatureGood                             // Valid signature from a trusted key
+	SignatureUntrusted                        // Valid signature, but the key isn't trusted/allowed
+	SignatureExpired                          // Signed with an expired or revoked key
+	SignatureBad                              // Signature doesn't match the tag contents
+	SignatureUnknown                          // Signature can't be checked (missing key or setup)
+)
+
+// TagSignature describes the signature of a tag as reported by git verify-tag
+type TagSignature struct {
+	Status SignatureStatus
+	Signer string // User ID (GPG) or principal (SSH)
+	Key    string // Key ID (GPG) or key fingerprint (SSH)
+	Detail string // Extra information when verification didn't succeed
+}
+
+// GetTagSignature verifies the signature of a tag with git verify-tag
+func GetTagSignature(tagName string) (TagSignature, error) {
+	cmd := exec.Command("git", "for-each-ref", "--format=%(contents:signature)", "refs/tags/"+tagName)
+	output, err := cmd.Output()
+	if err != nil {
+		return TagSignature{}, fmt.Errorf("failed to read tag signature: %w", err)
+	}
+	if strings.TrimSpace(string(output)) == "" {
+		return TagSignature{Status: SignatureNone}, nil
+	}
+
+	// verify-tag exits non-zero for anything but a good, trusted signature,
+	// the details are in the output either way
+	cmd = exec.Command("git", "verify-tag", "--raw", tagName)
+	output, _ = cmd.CombinedOutput()
+	return parseTagVerification(string(output)), nil
+}
+
+// parseTagVerification parses the output of git verify-tag --raw for GPG
+// ([GNUPG:] status lines) and SSH signatures
+func parseTagVerification(output string) TagSignature {
+	sig := TagSignature{Status: SignatureUnknown}
+	sshGood := false
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		if rest, ok := strings.CutPrefix(line, "[GNUPG:] "); ok {
+			fields := strings.SplitN(rest, " ", 3)
+			keyword := fields[0]
+			switch keyword {
+			case "GOODSIG", "EXPSIG", "EXPKEYSIG", "REVKEYSIG", "BADSIG", "ERRSIG", "NO_PUBKEY":
+				// <keyword> <key id> [<user id>]
+				if len(fields) > 1 {
+					sig.Key = fields[1]
+				}
+				if len(fields) > 2 && keyword != "ERRSIG" {
+					sig.Signer = fields[2]
+				}
+			}
+			switch keyword {
+			case "GOODSIG":
+				// TRUST_* lines may downgrade this below
+				if sig.Status == SignatureUnknown {
+					sig.Status = SignatureGood
+				}
+			case "EXPSIG", "EXPKEYSIG", "REVKEYSIG":
+				sig.Status = SignatureExpired
+				sig.Detail = map[string]string{
+					"EXPSIG":    "signature expired",
+					"EXPKEYSIG": "key expired",
+					"REVKEYSIG": "key revoked",
+				}[keyword]
+			case "BADSIG":
+				sig.Status = SignatureBad
+			case "ERRSIG":
+				sig.Detail = "signature could not be checked"
+			case "NO_PUBKEY":
+				sig.Detail = "public key not found"
+			case "TRUST_UNDEFINED", "TRUST_NEVER":
+				if sig.Status == SignatureGood {
+					sig.Status = SignatureUntrusted
+					sig.Detail = "key is not trusted"
+				}
+			}
+			continue
+		}
+
+		// SSH: Good "git" signature for alice@example.com with ED25519 key SHA256:...
+		if strings.HasPrefix(line, "Good \"git\" signature") {
+			sshGood = true
+			if _, after, ok := strings.Cut(line, " for "); ok {
+				sig.Signer, _, _ = strings.Cut(after, " with ")
+			}
+			if idx := strings.LastIndex(line, " key "); idx >= 0 {
+				sig.Key = line[idx+len(" key "):]
+			}
+			sig.Status = SignatureGood
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "No principal matched"):
+			sig.Status = SignatureUntrusted
+			sig.Detail = "key is not in the allowed signers file"
+		case strings.Contains(line, "allowedSignersFile needs to be configured"):
+			sig.Detail = "configure gpg.ssh.allowedSignersFile to verify SSH signatures"
+		case strings.HasPrefix(line, "Could not verify signature") && !sshGood:
+			sig.Status = SignatureBad
+		}
+	}
+
+	return sig
+}
+
+// Summary describes the verification result in one line
+func (s TagSignature) Summary() string {
+	who := ""
+	if s.Signer != "" {
+		who = " from " + s.Signer
+	}
+	key := ""
+	if s.Key != "" {
+		key = " (key " + s.Key + ")"
+	}
+	detail := ""
+	if s.Detail != "" {
+		detail = ": " + s.Detail
+	}
+
+	switch s.Status {
+	case SignatureNone:
+		return "Not signed"
+	case SignatureGood:
+		return "Good signature" + who + key
+	case SignatureUntrusted:
+		return "Good signature" + who + key + ", but untrusted" + detail
+	case SignatureExpired:
+		return "Signature" + who + key + detail
+	case SignatureBad:
+		return "BAD signature" + who + key
+	default:
+		return "Signature could not be verified" + key + detail
+	}
+}
+
 // GetPreviousTag returns the tag before the given tag, or empty string if none
 func GetPreviousTag(tagName string) (string, error) {
 	cmd := exec.Command("git", "describe", "--tags", "--abbrev=0", tagName+"^")
@@ -935,12 +2644,56 @@ func GetCommitsBetweenTags(fromTag, toTag string) ([]CommitWithStats, error) {
 	return commits, nil
 }
 
+// ListRangeHashes returns the full hashes of all commits in from..to, including
+// merge commits. An empty from lists every commit reachable from to.
+func ListRangeHashes(from, to string) ([]string, error) {
+	ref := to
+	if from != "" {
+		ref = from + ".." + to
+	}
+
+	cmd := exec.Command("git", "rev-list", ref)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// GetMergedHashes returns the commits a merge commit brought in, including the
+// merge itself. For a regular commit only its own hash is returned.
+func GetMergedHashes(hash string) ([]string, error) {
+	cmd := exec.Command("git", "show", "-s", "--format=%P", hash)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	if len(strings.Fields(string(output))) < 2 {
+		return []string{hash}, nil
+	}
+	return ListRangeHashes(hash+"^1", hash)
+}
+
+// GetCommitTime returns the committer date of a ref
+func GetCommitTime(ref string) (time.Time, error) {
+	cmd := exec.Command("git", "log", "-1", "--format=%ct", ref)
+	output, err := cmd.Output()
+	if err != nil {
+		return time.Time{}, err
+	}
+	seconds, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected commit time for %s: %w", ref, err)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
 // GetTagRangeDiffStats returns total stats between two tags
 func GetTagRangeDiffStats(fromTag, toTag string) (additions, deletions, filesChanged int, err error) {
 	var ref string
 	if fromTag == "" {
 		// Compare with empty tree
-		ref = "4b825dc642cb6eb9a060e54bf8d69288fbee4904.." + toTag
+		ref = emptyTreeHash + ".." + toTag
 	} else {
 		ref = fromTag + ".." + toTag
 	}
@@ -981,3 +2734,103 @@ func GetTagRangeDiffStats(fromTag, toTag string) (additions, deletions, filesCha
 
 	return additions, deletions, filesChanged, nil
 }
+
+// emptyTreeHash is git's well-known empty tree, used to diff against "nothing"
+// when a range has no starting tag
+const emptyTreeHash = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// FileDiffStat represents a file changed between two refs
+type FileDiffStat struct {
+	Path      string
+	OrigPath  string // Previous path for renames
+	Additions int
+	Deletions int
+	Binary    bool
+}
+
+// DisplayPath returns the path, showing "old → new" for renames
+func (f FileDiffStat) DisplayPath() string {
+	if f.OrigPath != "" {
+		return f.OrigPath + " → " + f.Path
+	}
+	return f.Path
+}
+
+// GetStagedFileStats returns the staged files with line stats
+func GetStagedFileStats() ([]FileDiffStat, error) {
+	cmd := exec.Command("git", "diff", "--cached", "--numstat", "-z", "-M")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseNumstatZ(string(output)), nil
+}
+
+// GetChangedFilesBetween returns the files changed between two refs with line stats.
+// An empty from compares against the empty tree.
+func GetChangedFilesBetween(from, to string) ([]FileDiffStat, error) {
+	if from == "" {
+		from = emptyTreeHash
+	}
+
+	cmd := exec.Command("git", "diff", "--numstat", "-z", "-M", from, to)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseNumstatZ(string(output)), nil
+}
+
+// parseNumstatZ parses git diff --numstat -z output. Renamed files use an
+// empty path field followed by the old and new paths as separate records.
+func parseNumstatZ(output string) []FileDiffStat {
+	files := []FileDiffStat{}
+	records := strings.Split(output, "\x00")
+
+	for i := 0; i < len(records); i++ {
+		fields := strings.SplitN(records[i], "\t", 3)
+		if len(fields) < 3 {
+			continue
+		}
+
+		file := FileDiffStat{Path: fields[2]}
+		if fields[0] == "-" && fields[1] == "-" {
+			file.Binary = true
+		} else {
+			fmt.Sscanf(fields[0], "%d", &file.Additions)
+			fmt.Sscanf(fields[1], "%d", &file.Deletions)
+		}
+
+		if file.Path == "" && i+2 < len(records) {
+			file.OrigPath = records[i+1]
+			file.Path = records[i+2]
+			i += 2
+		}
+
+		files = append(files, file)
+	}
+
+	return files
+}
+
+// GetFileDiffBetween returns the full diff of a single file between two refs.
+// An empty from compares against the empty tree.
+func GetFileDiffBetween(from, to string, file FileDiffStat) (string, error) {
+	if from == "" {
+		from = emptyTreeHash
+	}
+
+	args := []string{"diff", "-M", from, to, "--"}
+	if file.OrigPath != "" {
+		args = append(args, file.OrigPath)
+	}
+	args = append(args, file.Path)
+
+	cmd := exec.Command("git", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
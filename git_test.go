@@ -1,10 +1,13 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -136,6 +139,31 @@ func TestCreateAndSwitchBranch(t *testing.T) {
 	}
 }
 
+func TestCreateAndSwitchBranchAt(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	first, _ := exec.Command("git", "rev-parse", "HEAD").Output()
+	exec.Command("git", "commit", "--allow-empty", "-m", "Second commit").Run()
+
+	if err := CreateAndSwitchBranchAt("hotfix", strings.TrimSpace(string(first))); err != nil {
+		t.Fatalf("CreateAndSwitchBranchAt failed: %v", err)
+	}
+
+	currentBranch, _ := GetCurrentBranch()
+	if currentBranch != "hotfix" {
+		t.Errorf("Expected current branch to be 'hotfix', got '%s'", currentBranch)
+	}
+	head, _ := exec.Command("git", "rev-parse", "HEAD").Output()
+	if string(head) != string(first) {
+		t.Errorf("Expected the branch to start at %s, got %s", first, head)
+	}
+
+	if err := CreateAndSwitchBranchAt("hotfix", "HEAD"); err == nil {
+		t.Error("Expected an error for an existing branch")
+	}
+}
+
 func TestSwitchBranch(t *testing.T) {
 	_, cleanup := setupTestRepo(t)
 	defer cleanup()
@@ -236,6 +264,126 @@ func TestGetCurrentBranch(t *testing.T) {
 	}
 }
 
+func TestGetDefaultBranch(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	initial, err := GetCurrentBranch()
+	if err != nil {
+		t.Fatalf("GetCurrentBranch failed: %v", err)
+	}
+	exec.Command("git", "config", "init.defaultBranch", "").Run()
+	if branch, err := GetDefaultBranch(); err != nil || branch != initial {
+		t.Errorf("Expected %q, got %q (%v)", initial, branch, err)
+	}
+
+	// init.defaultBranch wins over main/master once that branch exists
+	exec.Command("git", "config", "init.defaultBranch", "trunk").Run()
+	if branch, _ := GetDefaultBranch(); branch != initial {
+		t.Errorf("Expected missing trunk to be skipped, got %q", branch)
+	}
+	exec.Command("git", "branch", "trunk").Run()
+	if branch, _ := GetDefaultBranch(); branch != "trunk" {
+		t.Errorf("Expected trunk from init.defaultBranch, got %q", branch)
+	}
+
+	// origin/HEAD wins over everything
+	exec.Command("git", "update-ref", "refs/remotes/origin/develop", "HEAD").Run()
+	exec.Command("git", "symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/develop").Run()
+	if branch, _ := GetDefaultBranch(); branch != "origin/develop" {
+		t.Errorf("Expected origin/develop without a local branch, got %q", branch)
+	}
+	exec.Command("git", "branch", "develop").Run()
+	if branch, _ := GetDefaultBranch(); branch != "develop" {
+		t.Errorf("Expected develop, got %q", branch)
+	}
+}
+
+func TestGetAheadBehind(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	base, err := GetCurrentBranch()
+	if err != nil {
+		t.Fatalf("GetCurrentBranch failed: %v", err)
+	}
+	CreateAndSwitchBranch("feature")
+	exec.Command("git", "commit", "--allow-empty", "-m", "Feature 1").Run()
+	exec.Command("git", "commit", "--allow-empty", "-m", "Feature 2").Run()
+	exec.Command("git", "checkout", "-q", base).Run()
+	exec.Command("git", "commit", "--allow-empty", "-m", "Main 1").Run()
+
+	ahead, behind, err := GetAheadBehind("feature", base)
+	if err != nil {
+		t.Fatalf("GetAheadBehind failed: %v", err)
+	}
+	if ahead != 2 || behind != 1 {
+		t.Errorf("Expected 2 ahead, 1 behind, got %d ahead, %d behind", ahead, behind)
+	}
+
+	branches, err := GetBranches()
+	if err != nil {
+		t.Fatalf("GetBranches failed: %v", err)
+	}
+	for _, branch := range branches {
+		switch branch.Name {
+		case "feature":
+			if branch.Base != base || branch.Ahead != 2 || branch.Behind != 1 {
+				t.Errorf("Expected feature to be 2 ahead, 1 behind %s, got %+v", base, branch)
+			}
+		case base:
+			if branch.Base != "" {
+				t.Errorf("Expected the default branch not to be compared with itself, got %+v", branch)
+			}
+		}
+	}
+
+	if _, _, err := GetAheadBehind("missing", base); err == nil {
+		t.Error("Expected error for a missing branch")
+	}
+}
+
+func TestGetConflictedFiles(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	base, err := GetCurrentBranch()
+	if err != nil {
+		t.Fatalf("GetCurrentBranch failed: %v", err)
+	}
+	os.WriteFile("shared.txt", []byte("base\n"), 0644)
+	exec.Command("git", "add", ".").Run()
+	exec.Command("git", "commit", "-m", "Add shared").Run()
+
+	CreateAndSwitchBranch("other")
+	os.WriteFile("shared.txt", []byte("other\n"), 0644)
+	exec.Command("git", "commit", "-am", "Change on other").Run()
+	exec.Command("git", "checkout", "-q", base).Run()
+	os.WriteFile("shared.txt", []byte("mine\n"), 0644)
+	exec.Command("git", "commit", "-am", "Change on base").Run()
+
+	if files, err := GetConflictedFiles(); err != nil || len(files) != 0 {
+		t.Fatalf("Expected no conflicts before merging, got %v (%v)", files, err)
+	}
+
+	exec.Command("git", "merge", "other").Run()
+	files, err := GetConflictedFiles()
+	if err != nil {
+		t.Fatalf("GetConflictedFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0] != "shared.txt" {
+		t.Fatalf("Expected shared.txt to conflict, got %v", files)
+	}
+
+	os.WriteFile("shared.txt", []byte("resolved\n"), 0644)
+	if err := StageFile("shared.txt"); err != nil {
+		t.Fatalf("StageFile failed: %v", err)
+	}
+	if files, _ := GetConflictedFiles(); len(files) != 0 {
+		t.Errorf("Expected staging to resolve the conflict, got %v", files)
+	}
+}
+
 func TestGetRebaseCommits(t *testing.T) {
 	_, cleanup := setupTestRepo(t)
 	defer cleanup()
@@ -256,14 +404,14 @@ func TestGetRebaseCommits(t *testing.T) {
 		exec.Command("git", "commit", "-m", fmt.Sprintf("Feature commit %d", i)).Run()
 	}
 
-	// Get the commits that would be replayed onto main/master
-	commits, err := GetRebaseCommits("main")
+	// Get the commits that would be replayed onto the default branch
+	defaultBranch, err := GetDefaultBranch()
 	if err != nil {
-		// Try master if main doesn't exist
-		commits, err = GetRebaseCommits("master")
-		if err != nil {
-			t.Fatalf("GetRebaseCommits failed: %v", err)
-		}
+		t.Fatalf("GetDefaultBranch failed: %v", err)
+	}
+	commits, err := GetRebaseCommits(defaultBranch)
+	if err != nil {
+		t.Fatalf("GetRebaseCommits failed: %v", err)
 	}
 
 	if len(commits) != 3 {
@@ -315,7 +463,10 @@ func TestReplayCommits(t *testing.T) {
 	}
 
 	// Replay feature commits onto main
-	output, err := ReplayCommits(mainBranch)
+	var progress []string
+	output, err := ReplayCommits(mainBranch, false, nil, func(current, total int) {
+		progress = append(progress, fmt.Sprintf("%d/%d", current, total))
+	})
 	if err != nil {
 		t.Fatalf("ReplayCommits failed: %v\nOutput: %s", err, output)
 	}
@@ -340,6 +491,92 @@ func TestReplayCommits(t *testing.T) {
 	if len(commits) < 3 {
 		t.Errorf("Expected at least 3 commits after replay, got %d", len(commits))
 	}
+
+	if strings.Join(progress, " ") != "1/2 2/2" {
+		t.Errorf("Expected progress for both commits, got %v", progress)
+	}
+	if strings.Contains(output, "Rebasing (") {
+		t.Errorf("Expected progress lines to be left out of the output, got %q", output)
+	}
+}
+
+func TestReplayCommitsUpdateRefs(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mainBranch, err := GetCurrentBranch()
+	if err != nil {
+		t.Fatalf("GetCurrentBranch failed: %v", err)
+	}
+
+	os.WriteFile("main.txt", []byte("main content"), 0644)
+	exec.Command("git", "add", ".").Run()
+	exec.Command("git", "commit", "-m", "Main commit").Run()
+
+	// Stack part-2 on top of part-1, both branched from the initial commit
+	exec.Command("git", "checkout", "-q", "HEAD~1").Run()
+	if err := CreateAndSwitchBranch("part-1"); err != nil {
+		t.Fatalf("CreateAndSwitchBranch failed: %v", err)
+	}
+	os.WriteFile("part1.txt", []byte("part 1"), 0644)
+	exec.Command("git", "add", ".").Run()
+	exec.Command("git", "commit", "-m", "Part 1").Run()
+
+	if err := CreateAndSwitchBranch("part-2"); err != nil {
+		t.Fatalf("CreateAndSwitchBranch failed: %v", err)
+	}
+	os.WriteFile("part2.txt", []byte("part 2"), 0644)
+	exec.Command("git", "add", ".").Run()
+	exec.Command("git", "commit", "-m", "Part 2").Run()
+
+	commits, err := GetRebaseCommits(mainBranch)
+	if err != nil {
+		t.Fatalf("GetRebaseCommits failed: %v", err)
+	}
+	var hashes []string
+	for _, commit := range commits {
+		hashes = append(hashes, commit.Hash)
+	}
+	stacked, err := GetStackedBranches(hashes)
+	if err != nil {
+		t.Fatalf("GetStackedBranches failed: %v", err)
+	}
+	if len(stacked) != 1 || stacked[0] != "part-1" {
+		t.Fatalf("Expected part-1 to be stacked, got %v", stacked)
+	}
+
+	if output, err := ReplayCommits(mainBranch, true, nil, nil); err != nil {
+		t.Fatalf("ReplayCommits failed: %v\nOutput: %s", err, output)
+	}
+
+	// part-1 should have moved onto main along with part-2
+	if err := exec.Command("git", "merge-base", "--is-ancestor", mainBranch, "part-1").Run(); err != nil {
+		t.Errorf("Expected part-1 to be replayed onto %s", mainBranch)
+	}
+	if err := exec.Command("git", "merge-base", "--is-ancestor", "part-1", "part-2").Run(); err != nil {
+		t.Errorf("Expected part-1 to stay below part-2")
+	}
+}
+
+func TestParseRebaseProgress(t *testing.T) {
+	tests := []struct {
+		line    string
+		current int
+		total   int
+		ok      bool
+	}{
+		{line: "Rebasing (3/7)", current: 3, total: 7, ok: true},
+		{line: "Rebasing (1/1)", current: 1, total: 1, ok: true},
+		{line: "Successfully rebased and updated refs/heads/feature.", ok: false},
+		{line: "CONFLICT (content): Merge conflict in a.txt", ok: false},
+	}
+
+	for _, tt := range tests {
+		current, total, ok := parseRebaseProgress(tt.line)
+		if ok != tt.ok || current != tt.current || total != tt.total {
+			t.Errorf("parseRebaseProgress(%q) = %d, %d, %v, want %d, %d, %v", tt.line, current, total, ok, tt.current, tt.total, tt.ok)
+		}
+	}
 }
 
 func TestCheckRebaseInProgress(t *testing.T) {
@@ -634,6 +871,27 @@ func TestGetTagDetail(t *testing.T) {
 	}
 }
 
+func TestGetTagDetailSigned(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	signTagsWithSSH(t, tmpDir)
+	if output, err := exec.Command("git", "tag", "-s", "v1.0.0", "-m", "Release | one\n\n- a | b").CombinedOutput(); err != nil {
+		t.Skipf("Can't sign tags here: %s", output)
+	}
+
+	detail, err := GetTagDetail("v1.0.0")
+	if err != nil {
+		t.Fatalf("GetTagDetail failed: %v", err)
+	}
+	if detail.Subject != "Release | one" || detail.Body != "- a | b" {
+		t.Errorf("Expected subject and body without the signature, got %q and %q", detail.Subject, detail.Body)
+	}
+	if detail.Date == "" || detail.RelativeTime == "" {
+		t.Errorf("Expected the dates after the body, got %+v", detail)
+	}
+}
+
 func TestGetTagDetailNotFound(t *testing.T) {
 	_, cleanup := setupTestRepo(t)
 	defer cleanup()
@@ -828,3 +1086,1031 @@ func TestGetTagURL(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePorcelainV2(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []StatusEntry
+	}{
+		{
+			name:     "Modified file",
+			input:    "1 .M N... 100644 100644 100644 abc abc main.go\x00",
+			expected: []StatusEntry{{Code: " M", Path: "main.go"}},
+		},
+		{
+			name:     "Path with spaces",
+			input:    "1 A. N... 000000 100644 100644 000 abc my file.txt\x00",
+			expected: []StatusEntry{{Code: "A ", Path: "my file.txt"}},
+		},
+		{
+			name:     "Renamed file",
+			input:    "2 R. N... 100644 100644 100644 abc abc R100 new name.go\x00old name.go\x00",
+			expected: []StatusEntry{{Code: "R ", Path: "new name.go", OrigPath: "old name.go"}},
+		},
+		{
+			name:     "Untracked file",
+			input:    "? notes.txt\x00",
+			expected: []StatusEntry{{Code: "??", Path: "notes.txt"}},
+		},
+		{
+			name:     "Submodule with new commits",
+			input:    "1 .M SC.. 160000 160000 160000 abc abc vendor/lib\x00",
+			expected: []StatusEntry{{Code: " M", Path: "vendor/lib", Submodule: "SC.."}},
+		},
+		{
+			name:     "Unmerged file",
+			input:    "u UU N... 100644 100644 100644 100644 a b c conflict.go\x00",
+			expected: []StatusEntry{{Code: "UU", Path: "conflict.go"}},
+		},
+		{
+			name:     "Empty output",
+			input:    "",
+			expected: []StatusEntry{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parsePorcelainV2(tt.input)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("parsePorcelainV2(%q) returned %d entries, want %d", tt.input, len(result), len(tt.expected))
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("entry %d = %+v, want %+v", i, result[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetStatusEntriesRenameAndSpaces(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	// Rename the tracked file and add a file with spaces in its name
+	exec.Command("git", "mv", "test.txt", "renamed file.txt").Run()
+	if err := os.WriteFile("with space.txt", []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	entries, err := GetStatusEntries(false)
+	if err != nil {
+		t.Fatalf("GetStatusEntries failed: %v", err)
+	}
+
+	foundRename := false
+	foundSpaces := false
+	for _, entry := range entries {
+		if entry.Code[0] == 'R' && entry.OrigPath == "test.txt" && entry.Path == "renamed file.txt" {
+			foundRename = true
+		}
+		if entry.Code == "??" && entry.Path == "with space.txt" {
+			foundSpaces = true
+		}
+	}
+
+	if !foundRename {
+		t.Errorf("Expected rename entry test.txt -> renamed file.txt, got %+v", entries)
+	}
+	if !foundSpaces {
+		t.Errorf("Expected untracked entry 'with space.txt', got %+v", entries)
+	}
+
+	status, err := GetColoredStatus(false)
+	if err != nil {
+		t.Fatalf("GetColoredStatus failed: %v", err)
+	}
+	if !strings.Contains(status, "test.txt → renamed file.txt") {
+		t.Errorf("Expected rename arrow in colored status, got %q", status)
+	}
+}
+
+func TestGetStatusEntriesExpandUntracked(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	// Create an untracked directory with several files
+	if err := os.MkdirAll(filepath.Join("newdir", "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	for _, name := range []string{"newdir/a.txt", "newdir/b.txt", "newdir/sub/c.txt"} {
+		if err := os.WriteFile(name, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	collapsed, err := GetStatusEntries(false)
+	if err != nil {
+		t.Fatalf("GetStatusEntries(false) failed: %v", err)
+	}
+	if len(collapsed) != 1 || collapsed[0].Path != "newdir/" {
+		t.Errorf("Expected single collapsed entry 'newdir/', got %+v", collapsed)
+	}
+
+	expanded, err := GetStatusEntries(true)
+	if err != nil {
+		t.Fatalf("GetStatusEntries(true) failed: %v", err)
+	}
+	if len(expanded) != 3 {
+		t.Errorf("Expected 3 expanded entries, got %+v", expanded)
+	}
+
+	counted, err := countUntrackedDirFiles(collapsed)
+	if err != nil {
+		t.Fatalf("countUntrackedDirFiles failed: %v", err)
+	}
+	if counted[0].FileCount != 3 {
+		t.Errorf("Expected file count 3 for 'newdir/', got %d", counted[0].FileCount)
+	}
+}
+
+func TestGetIgnoredFiles(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	if err := os.WriteFile(".gitignore", []byte("build/\n*.log\n"), 0644); err != nil {
+		t.Fatalf("Failed to write .gitignore: %v", err)
+	}
+	if err := os.MkdirAll("build", 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	for _, name := range []string{"build/out.bin", "debug.log"} {
+		if err := os.WriteFile(name, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	files, err := GetIgnoredFiles(false)
+	if err != nil {
+		t.Fatalf("GetIgnoredFiles failed: %v", err)
+	}
+
+	rules := make(map[string]IgnoredFile)
+	for _, file := range files {
+		rules[file.Path] = file
+	}
+
+	if rule, ok := rules["debug.log"]; !ok || rule.Pattern != "*.log" || rule.Source != ".gitignore" || rule.Line != "2" {
+		t.Errorf("Expected debug.log ignored by .gitignore:2 *.log, got %+v", rules["debug.log"])
+	}
+	if rule, ok := rules["build/"]; !ok || rule.Pattern != "build/" {
+		t.Errorf("Expected build/ ignored by build/ rule, got %+v", rules["build/"])
+	}
+
+	expanded, err := GetIgnoredFiles(true)
+	if err != nil {
+		t.Fatalf("GetIgnoredFiles(true) failed: %v", err)
+	}
+	foundExpanded := false
+	for _, file := range expanded {
+		if file.Path == "build/out.bin" {
+			foundExpanded = true
+		}
+	}
+	if !foundExpanded {
+		t.Errorf("Expected build/out.bin in expanded ignored files, got %+v", expanded)
+	}
+}
+
+func TestGetIgnoredFilesRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		gitignore string
+		files     []string
+		expand    bool
+		want      map[string]string // Path to the pattern that ignores it
+	}{
+		{
+			name:      "Directory ignored through its files",
+			gitignore: "*.log\n",
+			files:     []string{"logs/a.log", "logs/b.log"},
+			want:      map[string]string{"logs/a.log": "*.log", "logs/b.log": "*.log"},
+		},
+		{
+			name:      "Mixed with other paths",
+			gitignore: "build/\n*.log\n",
+			files:     []string{"build/out.bin", "logs/a.log", "debug.log"},
+			want:      map[string]string{"build/": "build/", "logs/a.log": "*.log", "debug.log": "*.log"},
+		},
+		{
+			name:      "Expanded",
+			gitignore: "build/\n*.log\n",
+			files:     []string{"build/out.bin", "logs/a.log"},
+			expand:    true,
+			want:      map[string]string{"build/out.bin": "build/", "logs/a.log": "*.log"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cleanup := setupTestRepo(t)
+			defer cleanup()
+
+			os.WriteFile(".gitignore", []byte(tt.gitignore), 0644)
+			for _, name := range tt.files {
+				os.MkdirAll(filepath.Dir(name), 0755)
+				os.WriteFile(name, []byte("content"), 0644)
+			}
+
+			files, err := GetIgnoredFiles(tt.expand)
+			if err != nil {
+				t.Fatalf("GetIgnoredFiles failed: %v", err)
+			}
+			got := map[string]string{}
+			for _, file := range files {
+				got[file.Path] = file.Pattern
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetUnreleasedCommits(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	// No tags yet - the initial commit is unreleased
+	commits, prevTag, err := GetUnreleasedCommits()
+	if err != nil {
+		t.Fatalf("GetUnreleasedCommits failed: %v", err)
+	}
+	if prevTag != "" || len(commits) != 1 {
+		t.Errorf("Expected 1 commit and no tag, got %d commits since %q", len(commits), prevTag)
+	}
+
+	exec.Command("git", "tag", "-a", "v1.0.0", "-m", "v1.0.0").Run()
+	commits, prevTag, err = GetUnreleasedCommits()
+	if err != nil {
+		t.Fatalf("GetUnreleasedCommits failed: %v", err)
+	}
+	if prevTag != "v1.0.0" || len(commits) != 0 {
+		t.Errorf("Expected 0 commits since v1.0.0, got %d since %q", len(commits), prevTag)
+	}
+
+	exec.Command("git", "commit", "--allow-empty", "-m", "feat: after release").Run()
+	commits, _, err = GetUnreleasedCommits()
+	if err != nil {
+		t.Fatalf("GetUnreleasedCommits failed: %v", err)
+	}
+	if len(commits) != 1 || commits[0].Message != "feat: after release" {
+		t.Errorf("Expected the new commit to be unreleased, got %+v", commits)
+	}
+}
+
+func TestGetTagMessage(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	exec.Command("git", "tag", "-a", "v1.0.0", "-m", "Release 1.0.0\n\n- first feature").Run()
+	exec.Command("git", "tag", "light").Run()
+
+	message, err := GetTagMessage("v1.0.0")
+	if err != nil {
+		t.Fatalf("GetTagMessage failed: %v", err)
+	}
+	if message != "Release 1.0.0\n\n- first feature" {
+		t.Errorf("Unexpected message %q", message)
+	}
+
+	if _, err := GetTagMessage("light"); err == nil {
+		t.Error("Expected error for lightweight tag")
+	}
+	if _, err := GetTagMessage("v9.9.9"); err == nil {
+		t.Error("Expected error for missing tag")
+	}
+}
+
+func TestReplaceTagMessage(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	exec.Command("git", "tag", "-a", "v1.0.0", "-m", "Relase 1.0.0").Run()
+	before, _ := exec.Command("git", "rev-parse", "v1.0.0^{commit}").Output()
+
+	// Move HEAD so the tag must stay on its original commit
+	exec.Command("git", "commit", "--allow-empty", "-m", "later").Run()
+
+	if err := ReplaceTagMessage("v1.0.0", "Release 1.0.0\n\n## Notes"); err != nil {
+		t.Fatalf("ReplaceTagMessage failed: %v", err)
+	}
+
+	message, err := GetTagMessage("v1.0.0")
+	if err != nil {
+		t.Fatalf("GetTagMessage failed: %v", err)
+	}
+	if message != "Release 1.0.0\n\n## Notes" {
+		t.Errorf("Expected updated message with heading kept, got %q", message)
+	}
+
+	after, _ := exec.Command("git", "rev-parse", "v1.0.0^{commit}").Output()
+	if string(before) != string(after) {
+		t.Errorf("Tag moved from %s to %s", before, after)
+	}
+}
+
+// signTagsWithSSH sets up the test repository to sign with a new SSH key
+func signTagsWithSSH(t *testing.T, repoDir string) {
+	key := filepath.Join(repoDir, ".git", "signing-key")
+	if err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).Run(); err != nil {
+		t.Skip("ssh-keygen is needed to sign tags")
+	}
+	exec.Command("git", "config", "gpg.format", "ssh").Run()
+	exec.Command("git", "config", "user.signingkey", key).Run()
+}
+
+func TestReplaceSignedTagMessage(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	signTagsWithSSH(t, tmpDir)
+	if output, err := exec.Command("git", "tag", "-s", "v1.0.0", "-m", "Relase 1.0.0\n\n- first | feature").CombinedOutput(); err != nil {
+		t.Skipf("Can't sign tags here: %s", output)
+	}
+
+	message, err := GetTagMessage("v1.0.0")
+	if err != nil {
+		t.Fatalf("GetTagMessage failed: %v", err)
+	}
+	if message != "Relase 1.0.0\n\n- first | feature" {
+		t.Errorf("Expected the message without the signature, got %q", message)
+	}
+	if !IsTagSigned("v1.0.0") {
+		t.Fatal("Expected the tag to be signed")
+	}
+
+	if err := ReplaceTagMessage("v1.0.0", "Release 1.0.0\n\n- first | feature"); err != nil {
+		t.Fatalf("ReplaceTagMessage failed: %v", err)
+	}
+	if !IsTagSigned("v1.0.0") {
+		t.Error("Expected the rewritten tag to be signed again")
+	}
+	if message, _ := GetTagMessage("v1.0.0"); message != "Release 1.0.0\n\n- first | feature" {
+		t.Errorf("Expected the new message without signature text, got %q", message)
+	}
+
+	// When signing fails the tag stays as it was
+	before, _ := exec.Command("git", "rev-parse", "refs/tags/v1.0.0").Output()
+	exec.Command("git", "config", "gpg.ssh.program", "false").Run()
+	if err := ReplaceTagMessage("v1.0.0", "other"); err == nil {
+		t.Error("Expected an error when the tag can't be signed again")
+	}
+	after, _ := exec.Command("git", "rev-parse", "refs/tags/v1.0.0").Output()
+	if string(before) != string(after) {
+		t.Error("Expected the signed tag to be left unchanged")
+	}
+}
+
+func TestIsTagPushedAndForcePush(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	remoteDir := addBareRemote(t)
+	defer os.RemoveAll(remoteDir)
+
+	exec.Command("git", "tag", "-a", "v1.0.0", "-m", "typo").Run()
+	if pushed, err := IsTagPushed("v1.0.0"); err != nil || pushed {
+		t.Fatalf("Expected tag not pushed yet, got %v (err %v)", pushed, err)
+	}
+
+	if _, err := PushTag("v1.0.0"); err != nil {
+		t.Fatalf("PushTag failed: %v", err)
+	}
+	if pushed, err := IsTagPushed("v1.0.0"); err != nil || !pushed {
+		t.Fatalf("Expected tag pushed, got %v (err %v)", pushed, err)
+	}
+
+	// A rewritten tag is rejected by a normal push but accepted by a force-push
+	if err := ReplaceTagMessage("v1.0.0", "fixed"); err != nil {
+		t.Fatalf("ReplaceTagMessage failed: %v", err)
+	}
+	if _, err := PushTag("v1.0.0"); err == nil {
+		t.Error("Expected plain push of rewritten tag to fail")
+	}
+	if output, err := ForcePushTag("v1.0.0"); err != nil {
+		t.Fatalf("ForcePushTag failed: %v\n%s", err, output)
+	}
+
+	local, _ := exec.Command("git", "rev-parse", "refs/tags/v1.0.0").Output()
+	remote, _ := exec.Command("git", "ls-remote", "--tags", "origin", "refs/tags/v1.0.0").Output()
+	if !strings.HasPrefix(string(remote), strings.TrimSpace(string(local))) {
+		t.Errorf("Expected remote tag %q to match local %q", remote, local)
+	}
+}
+
+func TestParseTagVerification(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected TagSignature
+	}{
+		{
+			name: "GPG good signature",
+			output: "[GNUPG:] NEWSIG\n[GNUPG:] KEY_CONSIDERED ABCDEF 0\n" +
+				"[GNUPG:] GOODSIG 1234ABCD Alice <alice@example.com>\n" +
+				"[GNUPG:] VALIDSIG ABCDEF 2024-01-01\n[GNUPG:] TRUST_ULTIMATE 0 pgp\n",
+			expected: TagSignature{Status: SignatureGood, Signer: "Alice <alice@example.com>", Key: "1234ABCD"},
+		},
+		{
+			name:   "GPG untrusted key",
+			output: "[GNUPG:] GOODSIG 1234ABCD Alice <alice@example.com>\n[GNUPG:] TRUST_UNDEFINED 0 pgp\n",
+			expected: TagSignature{Status: SignatureUntrusted, Signer: "Alice <alice@example.com>", Key: "1234ABCD",
+				Detail: "key is not trusted"},
+		},
+		{
+			name:     "GPG bad signature",
+			output:   "[GNUPG:] BADSIG 1234ABCD Mallory <m@example.com>\n",
+			expected: TagSignature{Status: SignatureBad, Signer: "Mallory <m@example.com>", Key: "1234ABCD"},
+		},
+		{
+			name:     "GPG missing key",
+			output:   "[GNUPG:] ERRSIG 1234ABCD 1 10 00 1700000000 9 -\n[GNUPG:] NO_PUBKEY 1234ABCD\n",
+			expected: TagSignature{Status: SignatureUnknown, Key: "1234ABCD", Detail: "public key not found"},
+		},
+		{
+			name:     "GPG expired key",
+			output:   "[GNUPG:] EXPKEYSIG 1234ABCD Alice <alice@example.com>\n",
+			expected: TagSignature{Status: SignatureExpired, Signer: "Alice <alice@example.com>", Key: "1234ABCD", Detail: "key expired"},
+		},
+		{
+			name:     "SSH good signature",
+			output:   "Good \"git\" signature for alice@example.com with ED25519 key SHA256:abc123\n",
+			expected: TagSignature{Status: SignatureGood, Signer: "alice@example.com", Key: "SHA256:abc123"},
+		},
+		{
+			name:   "SSH signer not allowed",
+			output: "Good \"git\" signature with ED25519 key SHA256:abc123\nNo principal matched.\n",
+			expected: TagSignature{Status: SignatureUntrusted, Key: "SHA256:abc123",
+				Detail: "key is not in the allowed signers file"},
+		},
+		{
+			name:   "SSH without allowed signers file",
+			output: "error: gpg.ssh.allowedSignersFile needs to be configured and exist for ssh signature verification\n",
+			expected: TagSignature{Status: SignatureUnknown,
+				Detail: "configure gpg.ssh.allowedSignersFile to verify SSH signatures"},
+		},
+		{
+			name:     "SSH bad signature",
+			output:   "Signature verification failed: incorrect signature\nCould not verify signature.\n",
+			expected: TagSignature{Status: SignatureBad},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseTagVerification(tt.output)
+			if result != tt.expected {
+				t.Errorf("parseTagVerification() = %+v, want %+v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetTagSignature(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	exec.Command("git", "tag", "-a", "unsigned", "-m", "not signed").Run()
+	sig, err := GetTagSignature("unsigned")
+	if err != nil {
+		t.Fatalf("GetTagSignature failed: %v", err)
+	}
+	if sig.Status != SignatureNone {
+		t.Errorf("Expected unsigned tag, got %+v", sig)
+	}
+
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not available")
+	}
+
+	keyPath := filepath.Join(tmpDir, "signing-key")
+	if err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", keyPath).Run(); err != nil {
+		t.Skipf("ssh-keygen failed: %v", err)
+	}
+	publicKey, err := os.ReadFile(keyPath + ".pub")
+	if err != nil {
+		t.Fatalf("Failed to read public key: %v", err)
+	}
+	allowedSigners := filepath.Join(tmpDir, "allowed_signers")
+	os.WriteFile(allowedSigners, []byte("test@example.com "+string(publicKey)), 0644)
+
+	exec.Command("git", "config", "gpg.format", "ssh").Run()
+	exec.Command("git", "config", "user.signingkey", keyPath).Run()
+	exec.Command("git", "config", "gpg.ssh.allowedSignersFile", allowedSigners).Run()
+	if output, err := exec.Command("git", "tag", "-s", "signed", "-m", "signed").CombinedOutput(); err != nil {
+		t.Skipf("SSH tag signing not supported: %v\n%s", err, output)
+	}
+
+	sig, err = GetTagSignature("signed")
+	if err != nil {
+		t.Fatalf("GetTagSignature failed: %v", err)
+	}
+	if sig.Status != SignatureGood || sig.Signer != "test@example.com" {
+		t.Errorf("Expected good signature from test@example.com, got %+v", sig)
+	}
+}
+
+func TestParseNumstatZ(t *testing.T) {
+	output := "3\t1\tmain.go\x00-\t-\tlogo.png\x000\t0\t\x00old name.txt\x00new name.txt\x00"
+	expected := []FileDiffStat{
+		{Path: "main.go", Additions: 3, Deletions: 1},
+		{Path: "logo.png", Binary: true},
+		{Path: "new name.txt", OrigPath: "old name.txt"},
+	}
+
+	result := parseNumstatZ(output)
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %d files, got %d: %+v", len(expected), len(result), result)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Errorf("File %d = %+v, want %+v", i, result[i], expected[i])
+		}
+	}
+}
+
+func TestGetFileDiffBetween(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	exec.Command("git", "tag", "v1.0.0").Run()
+	os.WriteFile(filepath.Join(tmpDir, "test.txt"), []byte("test\nmore\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "new.txt"), []byte("new\n"), 0644)
+	exec.Command("git", "add", ".").Run()
+	exec.Command("git", "commit", "-m", "Change files").Run()
+
+	files, err := GetChangedFilesBetween("v1.0.0", "HEAD")
+	if err != nil {
+		t.Fatalf("GetChangedFilesBetween failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 changed files, got %+v", files)
+	}
+
+	var changed FileDiffStat
+	for _, f := range files {
+		if f.Path == "test.txt" {
+			changed = f
+		}
+	}
+	if changed.Additions != 2 || changed.Deletions != 1 {
+		t.Errorf("Expected test.txt +2 -1, got %+v", changed)
+	}
+
+	diff, err := GetFileDiffBetween("v1.0.0", "HEAD", changed)
+	if err != nil {
+		t.Fatalf("GetFileDiffBetween failed: %v", err)
+	}
+	if !strings.Contains(diff, "+more") || strings.Contains(diff, "new.txt") {
+		t.Errorf("Expected diff of test.txt only, got:\n%s", diff)
+	}
+
+	// Without a starting tag the whole history is compared against the empty tree
+	files, err = GetChangedFilesBetween("", "HEAD")
+	if err != nil {
+		t.Fatalf("GetChangedFilesBetween from empty tree failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("Expected 2 files from empty tree, got %+v", files)
+	}
+}
+
+func TestParseNumstatLog(t *testing.T) {
+	output := "\x1eaaa\n\n3\t1\tmain.go\n-\t-\tlogo.png\n\x1ebbb\n\x1eccc\n\n10\t0\tREADME.md\n"
+
+	stats := parseNumstatLog(output)
+	if len(stats) != 3 {
+		t.Fatalf("Expected 3 commits, got %d: %v", len(stats), stats)
+	}
+	if stats["aaa"] != (CommitStats{Additions: 3, Deletions: 1, FilesChanged: 2}) {
+		t.Errorf("Unexpected stats for aaa: %+v", stats["aaa"])
+	}
+	if stats["bbb"] != (CommitStats{}) {
+		t.Errorf("Expected empty stats for commit without changes, got %+v", stats["bbb"])
+	}
+	if stats["ccc"] != (CommitStats{Additions: 10, FilesChanged: 1}) {
+		t.Errorf("Unexpected stats for ccc: %+v", stats["ccc"])
+	}
+}
+
+func TestGetCommitStats(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("one\ntwo\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "test.txt"), []byte("changed\n"), 0644)
+	exec.Command("git", "add", ".").Run()
+	exec.Command("git", "commit", "-m", "Second commit").Run()
+
+	commits, err := GetCommitHistory(0, false, "", "")
+	if err != nil || len(commits) != 2 {
+		t.Fatalf("Expected 2 commits, got %v (err %v)", commits, err)
+	}
+
+	stats, err := GetCommitStats([]string{commits[0].Hash, commits[1].Hash})
+	if err != nil {
+		t.Fatalf("GetCommitStats failed: %v", err)
+	}
+	if stats[commits[0].Hash] != (CommitStats{Additions: 3, Deletions: 1, FilesChanged: 2}) {
+		t.Errorf("Unexpected stats for second commit: %+v", stats[commits[0].Hash])
+	}
+	if stats[commits[1].Hash] != (CommitStats{Additions: 1, FilesChanged: 1}) {
+		t.Errorf("Unexpected stats for initial commit: %+v", stats[commits[1].Hash])
+	}
+}
+
+func TestBackupHead(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	head, _ := exec.Command("git", "rev-parse", "HEAD").Output()
+	first, err := BackupHead("before test")
+	if err != nil {
+		t.Fatalf("BackupHead failed: %v", err)
+	}
+	second, err := BackupHead("before test")
+	if err != nil {
+		t.Fatalf("Second BackupHead failed: %v", err)
+	}
+
+	if !strings.HasPrefix(first, backupRefPrefix) || first == second {
+		t.Errorf("Expected two distinct backup refs, got %s and %s", first, second)
+	}
+	for _, ref := range []string{first, second} {
+		hash, _ := exec.Command("git", "rev-parse", ref).Output()
+		if string(hash) != string(head) {
+			t.Errorf("Expected %s to point at HEAD, got %s", ref, hash)
+		}
+	}
+	if reflog, _ := exec.Command("git", "reflog", "show", first).Output(); !strings.Contains(string(reflog), "snap: before test") {
+		t.Errorf("Expected the reason in the reflog, got %q", reflog)
+	}
+}
+
+func TestResolveCommit(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	exec.Command("git", "tag", "v1.0.0").Run()
+	exec.Command("git", "commit", "--allow-empty", "-m", "Second commit").Run()
+
+	first, _ := exec.Command("git", "rev-parse", "HEAD~1").Output()
+	expected := strings.TrimSpace(string(first))
+
+	for _, ref := range []string{"HEAD~1", "v1.0.0", expected[:7]} {
+		hash, err := ResolveCommit(ref)
+		if err != nil {
+			t.Errorf("ResolveCommit(%q) failed: %v", ref, err)
+			continue
+		}
+		if hash != expected {
+			t.Errorf("ResolveCommit(%q) = %s, want %s", ref, hash, expected)
+		}
+	}
+
+	if _, err := ResolveCommit("does-not-exist"); err == nil {
+		t.Error("Expected error for unknown ref")
+	}
+}
+
+func TestRewordCommit(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("a\n"), 0644)
+	exec.Command("git", "add", "a.txt").Run()
+	exec.Command("git", "commit", "-m", "add a").Run()
+	exec.Command("git", "commit", "--allow-empty", "-m", "add b").Run()
+
+	// Staged changes stay out of an amended HEAD
+	os.WriteFile(filepath.Join(tmpDir, "c.txt"), []byte("c\n"), 0644)
+	exec.Command("git", "add", "c.txt").Run()
+
+	head, _ := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err := RewordCommit(strings.TrimSpace(string(head)), "feat: add b"); err != nil {
+		t.Fatalf("RewordCommit(HEAD) failed: %v", err)
+	}
+	if files, _ := exec.Command("git", "show", "--name-only", "--format=", "HEAD").Output(); len(files) != 0 {
+		t.Errorf("Expected staged files to stay out of the amended commit, got %q", files)
+	}
+
+	older, _ := exec.Command("git", "rev-parse", "HEAD~1").Output()
+	if err := RewordCommit(strings.TrimSpace(string(older)), "feat: add a\n\nWith details."); err != nil {
+		t.Fatalf("RewordCommit(HEAD~1) failed: %v", err)
+	}
+
+	log, _ := exec.Command("git", "log", "--format=%s", "-3").Output()
+	if strings.TrimSpace(string(log)) != "feat: add b\nfeat: add a\nInitial commit" {
+		t.Errorf("Unexpected history after reword:\n%s", log)
+	}
+	if body, _ := exec.Command("git", "log", "-1", "--format=%b", "HEAD~1").Output(); strings.TrimSpace(string(body)) != "With details." {
+		t.Errorf("Expected the body to be kept, got %q", body)
+	}
+	if author, _ := exec.Command("git", "log", "-1", "--format=%an", "HEAD~1").Output(); strings.TrimSpace(string(author)) != "Test User" {
+		t.Errorf("Expected the author to be kept, got %q", author)
+	}
+	if staged, _ := exec.Command("git", "diff", "--cached", "--name-only").Output(); strings.TrimSpace(string(staged)) != "c.txt" {
+		t.Errorf("Expected c.txt to stay staged, got %q", staged)
+	}
+
+	diff, err := GetCommitDiff("HEAD~1")
+	if err != nil {
+		t.Fatalf("GetCommitDiff failed: %v", err)
+	}
+	if !strings.Contains(diff, "+a") {
+		t.Errorf("Expected the diff of a.txt, got %q", diff)
+	}
+}
+
+func TestStageSelected(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	exec.Command("git", "mv", "test.txt", "moved.txt").Run()
+	os.WriteFile("a.txt", []byte("a\n"), 0644)
+	os.WriteFile("b.txt", []byte("b\n"), 0644)
+	exec.Command("git", "add", "b.txt").Run() // Staged earlier, but not selected
+
+	entries, err := GetStatusEntries(true)
+	if err != nil {
+		t.Fatalf("GetStatusEntries failed: %v", err)
+	}
+	var unselected []StatusEntry
+	for _, entry := range entries {
+		if entry.Path == "b.txt" {
+			unselected = append(unselected, entry)
+		}
+	}
+	if err := StageSelected(unselected, nil); err != nil {
+		t.Fatalf("StageSelected failed: %v", err)
+	}
+
+	output, _ := exec.Command("git", "diff", "--cached", "--name-status", "-M").Output()
+	staged := string(output)
+	if !strings.Contains(staged, "test.txt\tmoved.txt") || !strings.Contains(staged, "A\ta.txt") {
+		t.Errorf("Expected the rename and a.txt to be staged, got %q", staged)
+	}
+	if strings.Contains(staged, "b.txt") {
+		t.Errorf("Expected b.txt to be unstaged, got %q", staged)
+	}
+	if _, err := os.Stat("b.txt"); err != nil {
+		t.Errorf("Expected b.txt to stay in the working tree: %v", err)
+	}
+}
+
+func TestAmendCommit(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	os.WriteFile("test.txt", []byte("amended\n"), 0644)
+	exec.Command("git", "add", "-A").Run()
+	diff, err := GetAmendDiff()
+	if err != nil {
+		t.Fatalf("GetAmendDiff failed: %v", err)
+	}
+	// The root commit is compared with the empty tree
+	if !strings.Contains(diff, "new file mode") || !strings.Contains(diff, "+amended") {
+		t.Errorf("Expected the combined diff of the root commit, got %q", diff)
+	}
+
+	previous, err := GetHeadMessage()
+	if err != nil {
+		t.Fatalf("GetHeadMessage failed: %v", err)
+	}
+	if _, err := AmendCommit("", false); err != nil {
+		t.Fatalf("AmendCommit failed: %v", err)
+	}
+	if message, _ := GetHeadMessage(); message != previous {
+		t.Errorf("Expected the message %q to be kept, got %q", previous, message)
+	}
+	if _, err := AmendCommit("feat: reworded\n\nWith a body.", false); err != nil {
+		t.Fatalf("AmendCommit with a message failed: %v", err)
+	}
+	if message, _ := GetHeadMessage(); message != "feat: reworded\n\nWith a body." {
+		t.Errorf("Expected the new message, got %q", message)
+	}
+	if output, _ := exec.Command("git", "rev-list", "--count", "HEAD").Output(); strings.TrimSpace(string(output)) != "1" {
+		t.Errorf("Expected a single commit after amending, got %s", output)
+	}
+}
+
+func TestCommitChangesHooks(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	hook := "#!/bin/sh\necho \"lint: 1 problem in test.txt\" >&2\nexit 1\n"
+	os.WriteFile(".git/hooks/pre-commit", []byte(hook), 0755)
+	os.WriteFile("test.txt", []byte("changed\n"), 0644)
+	exec.Command("git", "add", "-A").Run()
+
+	_, err := CommitChanges("fix: change", false)
+	var commitErr *CommitError
+	if !errors.As(err, &commitErr) {
+		t.Fatalf("Expected a CommitError, got %v", err)
+	}
+	if !slices.Equal(commitErr.Hooks, []string{"pre-commit"}) || commitErr.Output != "lint: 1 problem in test.txt" {
+		t.Errorf("Expected the pre-commit hook and its output, got %+v", commitErr)
+	}
+	if !strings.HasPrefix(err.Error(), "the pre-commit hook failed:\n") {
+		t.Errorf("Unexpected error message %q", err)
+	}
+
+	// --no-verify skips the hook; a passing hook's output is returned
+	os.WriteFile(".git/hooks/commit-msg", []byte("#!/bin/sh\necho checked message\n"), 0755)
+	if _, err := CommitChanges("fix: change", true); err != nil {
+		t.Fatalf("CommitChanges with noVerify failed: %v", err)
+	}
+	os.Remove(".git/hooks/pre-commit")
+	os.WriteFile("test.txt", []byte("changed again\n"), 0644)
+	exec.Command("git", "add", "-A").Run()
+	if output, err := CommitChanges("fix: again", false); err != nil || output != "checked message" {
+		t.Errorf("Expected the commit-msg hook output, got %q (%v)", output, err)
+	}
+}
+
+func TestCommitTemplate(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	if template, err := GetCommitTemplate(); err != nil || template != "" {
+		t.Errorf("Expected no template, got %q (%v)", template, err)
+	}
+
+	os.WriteFile(".gitmessage", []byte("# <type>: <subject>\n\nWhy:   \n\n# Explain the change\nRefs: \n"), 0644)
+	exec.Command("git", "config", "commit.template", ".gitmessage").Run()
+	os.MkdirAll("sub", 0755)
+	os.Chdir("sub")
+	template, err := GetCommitTemplate()
+	if err != nil {
+		t.Fatalf("GetCommitTemplate failed: %v", err)
+	}
+	if template != "Why:\n\nRefs:" {
+		t.Errorf("Expected the template without comments, got %q", template)
+	}
+
+	if got, want := ApplyCommitTemplate("fix: typo\n\n- one", template), "fix: typo\n\n- one\n\nWhy:\n\nRefs:"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := ApplyCommitTemplate("fix: typo\n\nWhy:\n\nRefs:", template); got != "fix: typo\n\nWhy:\n\nRefs:" {
+		t.Errorf("Expected the template only once, got %q", got)
+	}
+}
+
+func TestGetPushedBranch(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	remoteDir := addBareRemote(t)
+	defer os.RemoveAll(remoteDir)
+
+	if remote := GetPushedBranch("HEAD"); remote != "" {
+		t.Errorf("Expected nothing pushed yet, got %q", remote)
+	}
+	branch, _ := GetCurrentBranch()
+	if err := exec.Command("git", "push", "-q", "origin", branch).Run(); err != nil {
+		t.Fatalf("Failed to push: %v", err)
+	}
+	if remote := GetPushedBranch("HEAD"); remote != "origin/"+branch {
+		t.Errorf("Expected origin/%s, got %q", branch, remote)
+	}
+
+	os.WriteFile("local.txt", []byte("local\n"), 0644)
+	exec.Command("git", "add", "-A").Run()
+	exec.Command("git", "commit", "-q", "-m", "local").Run()
+	if remote := GetPushedBranch("HEAD"); remote != "" {
+		t.Errorf("Expected the new commit to be unpushed, got %q", remote)
+	}
+}
+
+func TestIsKnownPath(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	os.Remove("test.txt")
+	if !IsKnownPath("test.txt") {
+		t.Error("Expected a deleted tracked file to be a known path")
+	}
+	os.WriteFile("new.txt", []byte("new\n"), 0644)
+	if !IsKnownPath("new.txt") {
+		t.Error("Expected an untracked file to be a known path")
+	}
+	if IsKnownPath("fix the login bug") {
+		t.Error("Expected a commit message not to be a known path")
+	}
+}
+
+func TestStageAllChangesExclude(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	os.MkdirAll("dist/js", 0755)
+	os.MkdirAll("web", 0755)
+	os.WriteFile("dist/js/app.js", []byte("app\n"), 0644)
+	os.WriteFile("web/package.lock", []byte("lock\n"), 0644)
+	os.WriteFile("main.go", []byte("package main\n"), 0644)
+	exclude := []string{"*.lock", "dist/**"}
+
+	entries, err := GetStatusEntries(true)
+	if err != nil {
+		t.Fatalf("GetStatusEntries failed: %v", err)
+	}
+	kept, err := FilterExcluded(entries, exclude)
+	if err != nil {
+		t.Fatalf("FilterExcluded failed: %v", err)
+	}
+	if len(kept) != 1 || kept[0].Path != "main.go" {
+		t.Errorf("Expected only main.go to be offered, got %+v", kept)
+	}
+
+	if err := StageAllChanges(exclude...); err != nil {
+		t.Fatalf("StageAllChanges failed: %v", err)
+	}
+	output, _ := exec.Command("git", "diff", "--cached", "--name-only").Output()
+	if staged := strings.Fields(string(output)); len(staged) != 1 || staged[0] != "main.go" {
+		t.Errorf("Expected only main.go to be staged, got %v", staged)
+	}
+}
+
+func TestReplayCommitsReword(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	mainBranch, err := GetCurrentBranch()
+	if err != nil {
+		t.Fatalf("GetCurrentBranch failed: %v", err)
+	}
+	os.WriteFile("main.txt", []byte("main content"), 0644)
+	exec.Command("git", "add", ".").Run()
+	exec.Command("git", "commit", "-m", "Main commit").Run()
+
+	exec.Command("git", "checkout", "-q", "HEAD~1").Run()
+	if err := CreateAndSwitchBranch("part-1"); err != nil {
+		t.Fatalf("CreateAndSwitchBranch failed: %v", err)
+	}
+	os.WriteFile("part1.txt", []byte("part 1"), 0644)
+	exec.Command("git", "add", ".").Run()
+	exec.Command("git", "commit", "-m", "wip").Run()
+	if err := CreateAndSwitchBranch("part-2"); err != nil {
+		t.Fatalf("CreateAndSwitchBranch failed: %v", err)
+	}
+	os.WriteFile("part2.txt", []byte("part 2"), 0644)
+	exec.Command("git", "add", ".").Run()
+	exec.Command("git", "commit", "-m", "Part 2").Run()
+
+	commits, err := GetRebaseCommits(mainBranch)
+	if err != nil || len(commits) != 2 {
+		t.Fatalf("Expected two commits to replay, got %v, %v", commits, err)
+	}
+	messages := map[string]string{commits[1].Hash: "feat: add part 1\n\nKeeps its body"}
+	if output, err := ReplayCommits(mainBranch, true, messages, nil); err != nil {
+		t.Fatalf("ReplayCommits failed: %v\nOutput: %s", err, output)
+	}
+
+	output, _ := exec.Command("git", "log", "--format=%B%x00", mainBranch+"..HEAD").Output()
+	got := strings.Split(strings.TrimSpace(strings.TrimSuffix(string(output), "\x00\n")), "\x00")
+	if len(got) != 2 || strings.TrimSpace(got[0]) != "Part 2" || strings.TrimSpace(got[1]) != "feat: add part 1\n\nKeeps its body" {
+		t.Errorf("Expected only the first commit reworded, got %q", got)
+	}
+	// The stacked branch still moves along
+	if err := exec.Command("git", "merge-base", "--is-ancestor", mainBranch, "part-1").Run(); err != nil {
+		t.Errorf("Expected part-1 to be replayed onto %s", mainBranch)
+	}
+	if subject, _ := exec.Command("git", "log", "-1", "--format=%s", "part-1").Output(); strings.TrimSpace(string(subject)) != "feat: add part 1" {
+		t.Errorf("Expected part-1 to point at the reworded commit, got %q", subject)
+	}
+	if _, err := os.Stat(filepath.Join(".git", "snap", "reword")); !os.IsNotExist(err) {
+		t.Errorf("Expected the messages to be cleaned up, got %v", err)
+	}
+}
+
+func TestSuggestBranchName(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	tests := map[string]string{
+		"feat(api): add rate limits": "feat/add-rate-limits",
+		"Fix: Handle `nil` users":    "fix/handle-nil-users",
+		"update readme":              "wip/update-readme",
+		"docs: explain the very long and winding configuration story": "docs/explain-the-very-long-and-winding",
+		"chore: ✨": "chore/changes",
+	}
+	for subject, want := range tests {
+		if got := BranchNameFor(subject); got != want {
+			t.Errorf("BranchNameFor(%q) = %q, want %q", subject, got, want)
+		}
+	}
+
+	exec.Command("git", "branch", "feat/add-rate-limits").Run()
+	if got := SuggestBranchName("feat: add rate limits"); got != "feat/add-rate-limits-2" {
+		t.Errorf("Expected a number for a taken name, got %q", got)
+	}
+
+	patterns := []string{"main", "release/*"}
+	for branch, want := range map[string]bool{"main": true, "release/1.2": true, "feature/main": false, "master": false} {
+		if got := IsProtectedBranch(branch, patterns); got != want {
+			t.Errorf("IsProtectedBranch(%q) = %v, want %v", branch, got, want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MatchProfile returns the profile whose paths contain dir. When several
+// match, the most specific path wins.
+func MatchProfile(dir string, profiles map[string]ProfileConfig) (string, ProfileConfig, bool) {
+	var bestName, bestPath string
+	for name, profile := range profiles {
+		for _, path := range profile.Paths {
+			path = expandHome(path)
+			if dir != path && !strings.HasPrefix(dir, path+string(filepath.Separator)) {
+				continue
+			}
+			// Ties go to the first name alphabetically so the result is stable
+			if len(path) > len(bestPath) || (len(path) == len(bestPath) && name < bestName) {
+				bestName, bestPath = name, path
+			}
+		}
+	}
+	if bestPath == "" {
+		return "", ProfileConfig{}, false
+	}
+	return bestName, profiles[bestName], true
+}
+
+// expandHome resolves a leading ~ and symlinks, so profile paths compare
+// equal to what git reports as the repository root
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~"); ok && (rest == "" || rest[0] == '/') {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = home + rest
+		}
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return filepath.Clean(path)
+}
+
+// CheckIdentity compares the commit identity with the profile for the current
+// repository. A repository still using the global identity is switched to the
+// profile's; one with its own differing identity is only warned about, as
+// that was set on purpose. It returns a line to show, or "" if all is well.
+func CheckIdentity() (string, error) {
+	if len(config.Profiles) == 0 {
+		return "", nil
+	}
+	output, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", nil
+	}
+	name, profile, ok := MatchProfile(strings.TrimSpace(string(output)), config.Profiles)
+	if !ok {
+		return "", nil
+	}
+
+	wanted := map[string]string{"user.email": profile.Email}
+	if profile.Name != "" {
+		wanted["user.name"] = profile.Name
+	}
+
+	var mismatched []string
+	for _, key := range []string{"user.name", "user.email"} {
+		if value, ok := wanted[key]; ok && GetGitConfig(key, false) != value {
+			mismatched = append(mismatched, key)
+		}
+	}
+	if len(mismatched) == 0 {
+		return "", nil
+	}
+
+	var local []string
+	for _, key := range mismatched {
+		if GetGitConfig(key, true) != "" {
+			local = append(local, key)
+			continue
+		}
+		if err := SetLocalGitConfig(key, wanted[key]); err != nil {
+			return "", err
+		}
+	}
+	if len(local) > 0 {
+		var fixes []string
+		for _, key := range local {
+			fixes = append(fixes, fmt.Sprintf("git config %s %q", key, wanted[key]))
+		}
+		return fmt.Sprintf("⚠ This repository's %s doesn't match profile '%s' (%s) - fix with: %s",
+			strings.Join(local, " and "), name, identityString(profile), strings.Join(fixes, " && ")), nil
+	}
+	return fmt.Sprintf("✓ Using profile '%s' for this repository: %s", name, identityString(profile)), nil
+}
+
+// identityString formats a profile like a commit author
+func identityString(profile ProfileConfig) string {
+	if profile.Name == "" {
+		return "<" + profile.Email + ">"
+	}
+	return fmt.Sprintf("%s <%s>", profile.Name, profile.Email)
+}
+
+// SigningKey is a secret GPG key as listed by gpg --with-colons
+type SigningKey struct {
+	ID       string
+	Validity string    // "e" expired, "r" revoked, see gpg's DETAILS
+	Expires  time.Time // Zero if the key doesn't expire
+	CanSign  bool      // The key or one of its subkeys can currently sign
+	Emails   []string
+}
+
+// parseGPGSecretKeys reads 'gpg --list-secret-keys --with-colons' output
+func parseGPGSecretKeys(output string) []SigningKey {
+	var keys []SigningKey
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 12 {
+			continue
+		}
+		switch fields[0] {
+		case "sec":
+			key := SigningKey{
+				ID:       fields[4],
+				Validity: fields[1],
+				CanSign:  strings.Contains(fields[11], "S"),
+			}
+			if seconds, err := strconv.ParseInt(fields[6], 10, 64); err == nil {
+				key.Expires = time.Unix(seconds, 0)
+			}
+			keys = append(keys, key)
+		case "uid":
+			if len(keys) == 0 {
+				continue
+			}
+			if start, end := strings.LastIndex(fields[9], "<"), strings.LastIndex(fields[9], ">"); start >= 0 && end > start {
+				last := &keys[len(keys)-1]
+				last.Emails = append(last.Emails, fields[9][start+1:end])
+			}
+		}
+	}
+	return keys
+}
+
+// CheckSigningKey verifies that commits can be signed when commit.gpgsign is
+// on, so a missing or expired key is reported with a fix instead of as a gpg
+// failure halfway through the commit
+func CheckSigningKey() error {
+	switch strings.ToLower(GetGitConfig("commit.gpgsign", false)) {
+	case "true", "yes", "on", "1":
+	default:
+		return nil
+	}
+
+	key := GetGitConfig("user.signingkey", false)
+	switch format := GetGitConfig("gpg.format", false); format {
+	case "", "openpgp":
+		return checkGPGKey(key, GetGitConfig("user.email", false))
+	case "ssh":
+		return checkSSHKey(key)
+	default:
+		// x509 keys live in a certificate store we can't inspect portably
+		return nil
+	}
+}
+
+// checkGPGKey looks up the key git will sign with: user.signingkey, or the
+// committer email when it isn't set
+func checkGPGKey(key, email string) error {
+	program := GetGitConfig("gpg.program", false)
+	if program == "" {
+		program = "gpg"
+	}
+	query := key
+	if query == "" {
+		query = email
+	}
+
+	output, err := exec.Command(program, "--list-secret-keys", "--with-colons", query).Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("commit.gpgsign is on, but %s isn't installed - install it or turn signing off with 'git config commit.gpgsign false'", program)
+	}
+	keys := parseGPGSecretKeys(string(output))
+	if err != nil || len(keys) == 0 {
+		return fmt.Errorf("no secret GPG key found for '%s' - list your keys with 'gpg --list-secret-keys' and pick one with 'git config user.signingkey <key id>'", query)
+	}
+
+	signing := keys[0]
+	switch {
+	case signing.Validity == "r":
+		return fmt.Errorf("GPG key %s is revoked - pick another key with 'git config user.signingkey <key id>'", signing.ID)
+	case signing.Validity == "e" || (!signing.Expires.IsZero() && signing.Expires.Before(time.Now())):
+		return fmt.Errorf("GPG key %s expired on %s - extend it with 'gpg --quick-set-expire %s 1y' or pick another key", signing.ID, signing.Expires.Format("2006-01-02"), signing.ID)
+	case !signing.CanSign:
+		return fmt.Errorf("GPG key %s has no usable signing key - add one with 'gpg --quick-add-key %s default sign'", signing.ID, signing.ID)
+	}
+
+	if email != "" && !slices.ContainsFunc(signing.Emails, func(e string) bool { return strings.EqualFold(e, email) }) {
+		return fmt.Errorf("user.email %s isn't an identity of GPG key %s (%s) - add it with 'gpg --quick-add-uid %s \"Name <%s>\"' or change user.email",
+			email, signing.ID, strings.Join(signing.Emails, ", "), signing.ID, email)
+	}
+	return nil
+}
+
+// checkSSHKey makes sure the SSH signing key file exists. Literal keys
+// ("key::..." or "ssh-ed25519 ...") are used as they are.
+func checkSSHKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("commits are signed with SSH, but user.signingkey isn't set - set it with 'git config user.signingkey ~/.ssh/id_ed25519.pub'")
+	}
+	if strings.HasPrefix(key, "key::") || strings.HasPrefix(key, "ssh-") {
+		return nil
+	}
+	if _, err := os.Stat(expandHome(key)); err != nil {
+		return fmt.Errorf("SSH signing key %s doesn't exist - point user.signingkey at your public key with 'git config user.signingkey ~/.ssh/id_ed25519.pub'", key)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMatchProfile(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	profiles := map[string]ProfileConfig{
+		"personal": {Email: "me@example.com", Paths: []string{"~"}},
+		"work":     {Email: "me@corp.example", Paths: []string{"~/work", "/srv/corp"}},
+	}
+
+	tests := map[string]string{
+		filepath.Join(home, "work", "api"): "work",
+		filepath.Join(home, "work"):        "work",
+		filepath.Join(home, "workshop"):    "personal",
+		"/srv/corp/billing":                "work",
+	}
+	for dir, want := range tests {
+		if name, _, ok := MatchProfile(dir, profiles); !ok || name != want {
+			t.Errorf("MatchProfile(%q) = %q, %v; want %q", dir, name, ok, want)
+		}
+	}
+
+	if _, _, ok := MatchProfile("/opt/other", profiles); ok {
+		t.Error("Expected no profile outside the configured paths")
+	}
+}
+
+func TestCheckIdentity(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	defer applyConfig(config)
+
+	root, err := filepath.EvalSymlinks(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := defaultConfig()
+	cfg.Profiles = map[string]ProfileConfig{
+		"work": {Name: "Work Me", Email: "me@corp.example", Paths: []string{filepath.Dir(root)}},
+	}
+	applyConfig(cfg)
+
+	// The test repository sets its own identity, so snap only warns
+	notice, err := CheckIdentity()
+	if err != nil {
+		t.Fatalf("CheckIdentity failed: %v", err)
+	}
+	if !strings.Contains(notice, "⚠") || !strings.Contains(notice, `git config user.email "me@corp.example"`) {
+		t.Errorf("Expected a warning with the fix, got %q", notice)
+	}
+	if email := GetGitConfig("user.email", false); email != "test@example.com" {
+		t.Errorf("Expected the repository's identity to stay, got %q", email)
+	}
+
+	// Without a repository identity, the profile's is set
+	exec.Command("git", "config", "--unset", "user.name").Run()
+	exec.Command("git", "config", "--unset", "user.email").Run()
+	notice, err = CheckIdentity()
+	if err != nil {
+		t.Fatalf("CheckIdentity failed: %v", err)
+	}
+	if !strings.Contains(notice, "profile 'work'") {
+		t.Errorf("Expected the profile to be applied, got %q", notice)
+	}
+	if email := GetGitConfig("user.email", true); email != "me@corp.example" {
+		t.Errorf("Expected user.email to be set, got %q", email)
+	}
+
+	if notice, _ := CheckIdentity(); notice != "" {
+		t.Errorf("Expected no notice once the identity matches, got %q", notice)
+	}
+}
+
+const testGPGKeys = `sec:u:255:22:AAAA1111BBBB2222:1700000000:::u:::scESC:::+:::23::0:
+fpr:::::::::0123456789ABCDEFAAAA1111BBBB2222:
+uid:u::::1700000000::HASH::Test User <test@example.com>::::::::::0:
+uid:u::::1700000000::HASH::Test User (work) <test@corp.example>::::::::::0:
+ssb:u:255:18:CCCC3333DDDD4444:1700000000::::::e:::+:::23:
+sec:e:255:22:EEEE5555FFFF6666:1500000000:1600000000::u:::sc:::+:::23::0:
+uid:e::::1500000000::HASH::Old Key <old@example.com>::::::::::0:
+`
+
+func TestParseGPGSecretKeys(t *testing.T) {
+	keys := parseGPGSecretKeys(testGPGKeys)
+	if len(keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %d", len(keys))
+	}
+	if keys[0].ID != "AAAA1111BBBB2222" || !keys[0].CanSign || !keys[0].Expires.IsZero() {
+		t.Errorf("Unexpected first key: %+v", keys[0])
+	}
+	if want := []string{"test@example.com", "test@corp.example"}; !slices.Equal(keys[0].Emails, want) {
+		t.Errorf("Expected emails %v, got %v", want, keys[0].Emails)
+	}
+	if keys[1].Validity != "e" || keys[1].CanSign || keys[1].Expires.Year() != 2020 {
+		t.Errorf("Unexpected second key: %+v", keys[1])
+	}
+}
+
+func TestCheckSigningKey(t *testing.T) {
+	tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	// Signing is off by default
+	if err := CheckSigningKey(); err != nil {
+		t.Errorf("Expected no check without commit.gpgsign, got %v", err)
+	}
+
+	// A fake gpg lists the key matching its last argument
+	keys := filepath.Join(tmpDir, "keys")
+	script := filepath.Join(tmpDir, "fake-gpg")
+	content := "#!/bin/sh\nfor last; do :; done\ncase \"$last\" in\n" +
+		"AAAA*|test@*) head -n 5 " + keys + " ;;\n" +
+		"EEEE*) tail -n 2 " + keys + " ;;\n" +
+		"*) exit 2 ;;\nesac\n"
+	if err := os.WriteFile(keys, []byte(testGPGKeys), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	exec.Command("git", "config", "commit.gpgsign", "true").Run()
+	exec.Command("git", "config", "gpg.program", script).Run()
+
+	tests := []struct {
+		key     string
+		email   string
+		wantErr string
+	}{
+		{key: "", email: "test@example.com"},
+		{key: "AAAA1111BBBB2222", email: "Test@Corp.example"},
+		{key: "AAAA1111BBBB2222", email: "someone@else.example", wantErr: "isn't an identity"},
+		{key: "EEEE5555FFFF6666", email: "old@example.com", wantErr: "expired on 2020-09-13"},
+		{key: "0000", email: "test@example.com", wantErr: "no secret GPG key"},
+	}
+	for _, tt := range tests {
+		exec.Command("git", "config", "user.signingkey", tt.key).Run()
+		exec.Command("git", "config", "user.email", tt.email).Run()
+		err := CheckSigningKey()
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("key %q: unexpected error: %v", tt.key, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("key %q: expected error containing %q, got %v", tt.key, tt.wantErr, err)
+		}
+	}
+
+	exec.Command("git", "config", "gpg.format", "ssh").Run()
+	exec.Command("git", "config", "user.signingkey", filepath.Join(tmpDir, "missing.pub")).Run()
+	if err := CheckSigningKey(); err == nil || !strings.Contains(err.Error(), "doesn't exist") {
+		t.Errorf("Expected a missing SSH key error, got %v", err)
+	}
+}
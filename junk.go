@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// defaultJunk is the default save.junk: dependencies, build output, caches
+// and OS clutter that hardly ever belong in a commit. Patterns ending in a
+// slash match directories.
+var defaultJunk = []string{
+	"node_modules/", "bower_components/", ".next/", ".nuxt/",
+	"__pycache__/", ".pytest_cache/", ".mypy_cache/", ".venv/", "venv/", ".tox/",
+	"target/", "build/", "dist/", ".gradle/", "coverage/", ".terraform/",
+	".idea/", ".cache/",
+	".DS_Store", "Thumbs.db", "desktop.ini",
+	"*.pyc", "*.class", "*.o", "*.log", "*.swp",
+}
+
+// JunkPath is an untracked file or directory that matches save.junk
+type JunkPath struct {
+	Path    string // Relative to the repository root; directories end in a slash
+	Pattern string // The save.junk pattern it matched
+}
+
+// FindJunk returns the untracked paths git add -A would stage that match one
+// of the patterns. A junk directory is listed once instead of file by file,
+// and directories that already have tracked files are left alone.
+func FindJunk(patterns, exclude []string) ([]JunkPath, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	root, err := GetRepoRoot()
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{"ls-files", "-z", "--others", "--exclude-standard", "--", ":/"}, ExcludePathspecs(exclude)...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	var junk []JunkPath
+	seen := map[string]bool{}
+	for _, file := range strings.Split(string(output), "\x00") {
+		if file == "" {
+			continue
+		}
+		found, pattern := matchJunk(file, patterns)
+		if found == "" || seen[found] {
+			continue
+		}
+		seen[found] = true
+		if strings.HasSuffix(found, "/") && hasTrackedFiles(root, found) {
+			continue
+		}
+		junk = append(junk, JunkPath{Path: found, Pattern: pattern})
+	}
+	return junk, nil
+}
+
+// matchJunk returns the outermost junk directory a file is in, or the file
+// itself when its name matches, along with the pattern
+func matchJunk(file string, patterns []string) (string, string) {
+	parts := strings.Split(file, "/")
+	for i, part := range parts {
+		last := i == len(parts)-1
+		for _, pattern := range patterns {
+			name, isDir := strings.CutSuffix(pattern, "/")
+			if isDir == last {
+				continue
+			}
+			// validate rejects patterns that don't compile
+			if ok, _ := path.Match(name, part); !ok {
+				continue
+			}
+			if isDir {
+				return strings.Join(parts[:i+1], "/") + "/", pattern
+			}
+			return file, pattern
+		}
+	}
+	return "", ""
+}
+
+// hasTrackedFiles reports whether a directory holds committed or staged
+// files, which makes it a real part of the project, e.g. a build/ with scripts
+func hasTrackedFiles(root, dir string) bool {
+	cmd := exec.Command("git", "ls-files", "--cached", "--", ":(top)"+dir)
+	cmd.Dir = root
+	output, err := cmd.Output()
+	return err != nil || len(output) > 0
+}
+
+// junkPatterns lists the patterns the paths matched, once each
+func junkPatterns(junk []JunkPath) []string {
+	var patterns []string
+	for _, j := range junk {
+		if !slices.Contains(patterns, j.Pattern) {
+			patterns = append(patterns, j.Pattern)
+		}
+	}
+	return patterns
+}
+
+// AddToGitignore appends the patterns that aren't in the repository's
+// .gitignore yet
+func AddToGitignore(patterns []string) error {
+	root, err := GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(root, ".gitignore")
+	content, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	existing := strings.Split(string(content), "\n")
+	var add strings.Builder
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		add.WriteString("\n")
+	}
+	added := 0
+	for _, pattern := range patterns {
+		if slices.Contains(existing, pattern) {
+			continue
+		}
+		add.WriteString(pattern + "\n")
+		added++
+	}
+	if added == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(add.String())
+	return err
+}
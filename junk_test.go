@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFindJunk(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	write := func(path string) {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// build/ holds committed scripts, so a new file there isn't junk
+	write("build/release.sh")
+	exec.Command("git", "add", "-A").Run()
+	exec.Command("git", "commit", "-q", "-m", "add release script").Run()
+
+	write("build/package.sh")
+	write("node_modules/left-pad/index.js")
+	write("node_modules/left-pad/package.json")
+	write("web/node_modules/react/index.js")
+	write(".DS_Store")
+	write("src/app.py")
+	write("src/__pycache__/app.cpython-312.pyc")
+	write("dist/bundle.js")
+
+	junk, err := FindJunk(defaultJunk, []string{"dist/**"})
+	if err != nil {
+		t.Fatalf("FindJunk failed: %v", err)
+	}
+	var got []string
+	for _, j := range junk {
+		got = append(got, j.Path)
+	}
+	want := []string{".DS_Store", "node_modules/", "src/__pycache__/", "web/node_modules/"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if patterns := junkPatterns(junk); !slices.Equal(patterns, []string{".DS_Store", "node_modules/", "__pycache__/"}) {
+		t.Errorf("Expected each pattern once, got %q", patterns)
+	}
+
+	os.WriteFile(".gitignore", []byte("*.log"), 0644)
+	if err := AddToGitignore([]string{"*.log", "node_modules/", ".DS_Store"}); err != nil {
+		t.Fatalf("AddToGitignore failed: %v", err)
+	}
+	if content, _ := os.ReadFile(".gitignore"); string(content) != "*.log\nnode_modules/\n.DS_Store\n" {
+		t.Errorf("Expected the new patterns below the old ones, got %q", content)
+	}
+	if junk, _ := FindJunk(defaultJunk, []string{"dist/**"}); len(junk) != 1 || junk[0].Path != "src/__pycache__/" {
+		t.Errorf("Expected ignored paths to stop being junk, got %+v", junk)
+	}
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -19,21 +21,52 @@ Usage: snap <command> [options]
 Commands:
     init              Initialize a new repository
     save [message]    Save changes with AI-generated or custom message
+    status            Show branch, changes, and unreleased commits
+    deploy            Record deployments and show what each environment runs
     changes           Show uncommitted changes
     sync              Smart push/pull with remote
     stack             Show commit history as a visual timeline
+    timeline <file>   Browse a file's history and restore an old version
     branch            Manage branches
     replay <branch>   Replay commits onto another branch (rebase)
+    resolve           Resolve merge conflicts with AI suggestions
+    convert           Reword recent commits as conventional commits with AI help
+    prepare-pr [base] Squash and reorder a branch for review with AI, then force-push
+    enrich            Write AI messages for commits saved while the AI was unreachable
+    resume            Finish or clean up an operation snap was interrupted in
+    repos [query]     Jump between recently used repositories
+    model [name]      List Ollama models and pick the one snap uses
+    ai usage          Show tokens and cost of hosted AI requests
     tags              Manage tags
+    config            Show the loaded config files and effective settings
+    git -- <args>     Run any git command, logged in snap's journal
 
     help, --help      Show this help message
     version           Show version information
 
 Run 'snap <command> --help' for more information on a command.
+
+Settings are read from ~/.config/snap/config.toml and .snap.toml in the repository root.
 `
+	if beginnerMode() {
+		// Leave out the commands beginner mode refuses
+		var lines []string
+		for _, line := range strings.Split(help, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) > 0 && slices.Contains(advancedCommands, fields[0]) {
+				continue
+			}
+			lines = append(lines, line)
+		}
+		help = strings.Join(lines, "\n") + "\nBeginner mode hides replay, convert, prepare-pr and git; set mode = \"standard\" to use them.\n"
+	}
 	fmt.Println(help)
 }
 
+// advancedCommands rewrite published history or skip snap's safety checks, so
+// beginner mode hides them
+var advancedCommands = []string{"replay", "convert", "prepare-pr", "git"}
+
 func printVersion() {
 	fmt.Printf("Snap version %s\n", version)
 }
@@ -47,38 +80,234 @@ func hasHelpFlag() bool {
 	return false
 }
 
+func printConfigHelp() {
+	fmt.Println(`Usage: snap config
+
+Show which config files were loaded and the effective settings.
+
+Settings are read from ~/.config/snap/config.toml (or $XDG_CONFIG_HOME/snap/config.toml),
+then from .snap.toml in the repository root, which overrides the global file.
+Provider, endpoint, API key, redaction, tickets, announce webhook and forge
+token host settings are refused in .snap.toml, so a cloned repository can't redirect your diffs or keys.
+$SNAP_MODEL overrides the provider's model and $OLLAMA_HOST overrides ollama.url.
+Command-line flags override everything.
+
+Example config:
+  mode = "standard"   # Or "beginner": hides replay, convert, prepare-pr, git and detached checkouts,
+                      # and branch deletes ask you to type the branch name
+
+  [ai]
+  provider = "ollama"   # Or "openai" (OpenAI, Groq, OpenRouter, vLLM, ...) or "anthropic"
+  style = "conventional"   # Or "gitmoji" for messages like "✨ feat: add login flow"
+  infer_scope = true       # Add a scope from the changed paths, e.g. "feat(tui): ..."
+  examples = 10            # Recent commit subjects shown to the model (style examples, not to repeat; 0 disables)
+
+  [ollama]
+  model = "llama3.2:3b"
+  url = "http://localhost:11434"   # Or a host like "gpu-box.lan" (port 11434)
+
+  [openai]
+  model = "gpt-4o-mini"
+  url = "https://api.openai.com/v1"   # e.g. https://api.groq.com/openai/v1
+  api_key = ""                        # Defaults to $OPENAI_API_KEY; keep keys out of .snap.toml
+
+  [anthropic]
+  model = "claude-haiku-4-5"
+  api_key = ""   # Defaults to $ANTHROPIC_API_KEY
+
+  [save]
+  seed = 42
+  candidates = 3   # AI messages to pick from (1 streams a single one)
+  body = false     # Also generate a bulleted commit body
+
+  [convention]   # What AI messages are asked for and checked against
+  types = ["feat", "fix", "docs", "style", "refactor", "test", "chore"]
+  max_subject = 72
+  require_scope = false
+
+  [sync]   # Fork workflows: pull from upstream, push to origin
+  upstream = ""     # e.g. "upstream"; empty pulls from the branch's tracking remote
+  origin = "origin"
+
+  [changes]
+  expand = false    # Default for --expand
+  ignored = false   # Default for --ignored
+
+  [stack]
+  limit = 50        # Commits loaded by the interactive viewer
+  all = false       # Default for --all
+
+  [changelog]   # Tag messages and release notes
+  exclude = ["chore"]   # Commit types left out; [] lists all
+
+  [forges]   # Global config only
+  token_hosts = []   # Hosts besides github.com and gitlab.com that get $GITHUB_TOKEN/$GITLAB_TOKEN
+
+  [tags]   # Naming policy for snap tags create, bump and promote
+  prefixes = ["v"]   # e.g. ["api/v", "web/v"] for monorepos
+  semver = true      # Require a semantic version after the prefix
+
+  [colors]
+  primary = "#7D56F4"   # Also: success, danger, error, modified, warning, muted, text, highlight
+
+  [scopes]   # Path prefixes to scopes, for monorepos
+  "services/billing" = "billing"
+  "web" = "frontend"
+
+Example:
+  snap config`)
+}
+
 func printInitHelp() {
-	fmt.Println(`Usage: snap init
+	fmt.Println(`Usage: snap init [OPTIONS]
 
 Initialize a new git repository in the current directory.
 
-Example:
-  snap init`)
+Options:
+  --template <url|path>  Start from a template repository: copy its files without
+                         its history, fill in {{placeholders}} and commit them
+  --set <key=value>      Value for a placeholder instead of asking (repeatable)
+
+Examples:
+  snap init
+  snap init --template https://github.com/acme/go-service
+  snap init --template ../templates/cli --set project_name=snapper`)
 }
 
 func printChangesHelp() {
-	fmt.Println(`Usage: snap changes
+	fmt.Println(`Usage: snap changes [OPTIONS]
 
 Show uncommitted changes (staged and unstaged files).
 
-Example:
-  snap changes`)
+Options:
+  --expand            List every file inside untracked directories
+  --ignored           Also list files excluded by .gitignore, with the matching rule
+  --interactive, -i   Browse changes in an interactive view (u: expansion, i: ignored)
+
+Examples:
+  snap changes                 Show changes
+  snap changes --expand        Show exactly which untracked files save would commit
+  snap changes --ignored       See why a file isn't being committed
+  snap changes -i              Interactive changes viewer`)
+}
+
+func printStatusHelp() {
+	fmt.Println(`Usage: snap status [OPTIONS]
+
+Show the current branch, how far it is ahead of or behind its upstream and
+the default branch (as of the last fetch), the number of uncommitted changes,
+and how many commits were made since the last tag.
+
+Options:
+  --fail-if-any    Exit with status 1 if there are commits since the last tag
+  --step-summary   Also write the status to the GitHub Actions step summary
+                   ($GITHUB_STEP_SUMMARY; ignored outside GitHub Actions)
+
+Examples:
+  snap status                  Show repository status
+  snap status --fail-if-any    Fail a CI job when a release is overdue`)
+}
+
+func printDeployHelp() {
+	fmt.Println(`Usage: snap deploy <command> [OPTIONS]
+
+Record which commit runs in each environment. Deployments are kept as refs
+(refs/deploys/<env>) with the time and who deployed in git notes
+(refs/notes/deploys), so they can be shared through the remote.
+
+Commands:
+  mark <env> [commit]   Record that env now runs commit (default: HEAD)
+  status                Show the commit each environment runs and how far it is from HEAD
+
+Options:
+  --push     (mark) Push the deployment ref and notes to origin
+  --fetch    (status) Fetch deployments from origin first
+
+Examples:
+  snap deploy mark production         Record a deploy of HEAD
+  snap deploy mark staging v1.3.0-rc.1 --push
+  snap deploy status --fetch          See what runs where`)
 }
 
 func printSaveHelp() {
-	fmt.Println(`Usage: snap save [MESSAGE] [OPTIONS]
+	fmt.Println(`Usage: snap save [MESSAGE] [PATH...] [OPTIONS]
 
 Save changes with an AI-generated or custom commit message.
 
+With paths (files or directories), only the changes below them are staged and
+committed, and the message is generated from just that diff; anything else you
+staged stays staged. A single argument is the message; give a single path
+after --, which is also needed for paths that no longer exist.
+
+On a save.protected branch (main and master by default), press s before
+committing to put the commit on a new branch named after its message.
+
+While a replay (rebase) is stopped, snap save doesn't commit: it offers to stage
+your fixes and continue the rebase instead.
+
+While a merge is in progress, snap save concludes it: everything is committed
+as the merge commit with the message git prepared, without asking the AI.
+Resolve any conflicts first.
+
 Options:
-  --seed <number>     Set the seed for reproducible AI messages (default: 42)
+  --seed <number>     Set the seed for reproducible AI messages (default: save.seed, 42)
+  --candidates <n>    Number of AI messages to pick from, 1-5 (default: save.candidates, 3)
+  --body              Also generate a body with bullet points (default: save.body);
+                      press b before committing to show/hide or generate it
+  --select            Pick the files to commit from a checklist (default: save.select)
+  --exclude <glob>    Don't stage paths matching the glob, e.g. '*.lock' or 'dist/**'
+                      (repeatable; added to save.exclude)
+  --staged-only       Commit exactly what's staged, without git add -A (default: save.staged_only)
+  --amend             Fold the changes into the last commit, keeping its message
+  --regenerate        With --amend, generate a new message from the combined diff;
+                      during a merge, generate one from the merge's changes
+  --force             With --amend, amend even when the last commit is already pushed
+  --signoff, -s       Add a Signed-off-by trailer with your identity (default: save.signoff)
+  --co-author <who>   Credit a pair partner, "Name <email>", with a Co-authored-by
+                      trailer (repeatable); press a to pick from save.co_authors
+  --push              Sync after committing: pull, then push, setting the upstream
+                      if the branch has none (default: save.push)
+  --model <name>      AI model for messages (default: $SNAP_MODEL, then the provider's model setting)
+  --host <host>       Ollama server, e.g. gpu-box.lan or http://10.0.0.5:11434
+                      (default: $OLLAMA_HOST, ollama.url, localhost:11434)
   --message, -m       Custom commit message (alternative to positional argument)
+  --no-verify         Skip the pre-commit and commit-msg hooks
+  --allow-empty       Commit even when nothing changed, e.g. to trigger CI (needs a
+                      message, typed in or given with -m)
+  --continue          While a replay (rebase) is stopped at a conflict, stage the
+                      fixes and run git rebase --continue instead of committing
+                      (snap save asks first without it, --plain refuses)
+  --queue             With --plain, commit with a placeholder message when the AI is
+                      unreachable and queue it for snap enrich (Ctrl+O does the same
+                      interactively)
+  --skip-checks       Commit even if the [checks] find conflict markers or blocked patterns
+  --allow-secrets     Commit even if the staged lines contain API keys, private keys or .env files
+  --plain             Non-interactive mode for scripts and CI: commits the first valid AI
+                      message (or the custom one) and prints the hash, subject and diff stat
+  --print-hash        Like --plain, but prints only the new commit's full hash to stdout
 
 Examples:
   snap save                    Save with AI-generated message
   snap save "fix: bug"         Save with custom message
   snap save -m "fix: bug"      Save with custom message (flag style)
-  snap save --seed 123         Use a custom seed for AI generation`)
+  snap save --seed 123         Use a custom seed for AI generation
+  snap save --candidates 1     Stream a single suggestion instead of choosing
+  snap save --body             Add a bulleted body to the generated message
+  snap save --select           Commit only some of the changed files
+  snap save src/auth cmd/server  Commit only the changes in these directories
+  snap save -- docs            Commit only the changes in docs
+  snap save --exclude '*.lock' Save everything except lockfiles
+  snap save --staged-only      Commit only what you staged with git add -p
+  snap save --push "fix: typo" Commit and push in one go
+  snap save --allow-empty -m "ci: rerun the build"  Trigger CI without changes
+  snap save --co-author "Jane Doe <jane@example.com>"  Credit your pair partner
+  snap save --amend            Add forgotten changes to the last commit
+  snap save --amend --regenerate  Amend and let the AI rewrite the message
+  snap save --regenerate       Conclude a merge with an AI message instead of git's
+  snap save --plain -m "fix"   Commit from CI without prompts
+  snap save --print-hash -m "x" Print only the hash, e.g. sha=$(snap save --print-hash)
+  snap save --model mistral    Use a different Ollama model
+  snap save --host gpu-box.lan Use Ollama running on another machine`)
 }
 
 func printSyncHelp() {
@@ -86,12 +315,213 @@ func printSyncHelp() {
 
 Smart push/pull - sync with remote repository.
 
+For fork workflows, set upstream in the [sync] config section: sync then pulls
+from upstream (the branch of the same name, or its default branch) and pushes
+to origin.
+
+Options:
+  --from          Only pull changes from remote (skip push)
+  --until-clean   Rebase onto the remote and push with --force-with-lease,
+                  pulling again (up to 5 attempts) when the remote moves
+                  before the push lands
+  --update-fork   Fast-forward the fork's default branch, on origin and
+                  locally, to upstream's default branch
+
+Examples:
+  snap sync                 Push and pull changes automatically
+  snap sync --from          Only pull changes from remote
+  snap sync --until-clean   Keep syncing on a busy branch until the push lands
+  snap sync --update-fork   Bring a fork's main branch up to date with upstream`)
+}
+
+func printModelHelp() {
+	fmt.Println(`Usage: snap model [NAME] [OPTIONS]
+
+Show the models on the Ollama server and pick the one snap uses for commit
+messages. The choice is saved as ollama.model in ~/.config/snap/config.toml.
+
+Options:
+  --repo     Save the choice to .snap.toml for this repository only
+  --plain    List the models without the picker (* marks the active one)
+
+Examples:
+  snap model                  Pick a model from the list
+  snap model mistral          Use mistral from now on
+  snap model qwen2.5 --repo   Use qwen2.5 in this repository`)
+}
+
+func printEnrichHelp() {
+	fmt.Println(`Usage: snap enrich [OPTIONS]
+
+Replace the placeholder messages of commits saved while the AI provider was
+unreachable (Ctrl+O when writing the message, or snap save --plain --queue).
+Only unpushed commits on the current branch are reworded; their bodies and
+trailers are kept. The queue lives in .git/snap/enrich.
+
+Options:
+  --seed <number>   Seed for the generated messages (default: save.seed, 42)
+
+Examples:
+  snap enrich       Describe the queued commits now that the AI is back`)
+}
+
+func printGitHelp() {
+	fmt.Println(`Usage: snap git -- <git arguments>
+
+Run any git command in the current repository, for what snap doesn't wrap
+yet. Git's output, pager, editor and prompts work as usual. Every command is
+logged with its repository, branch, exit code and duration in
+~/.local/state/snap/journal (or $XDG_STATE_HOME/snap/journal).
+
+When git fails with an error, snap says so and suggests the snap command that
+deals with it, e.g. snap sync for a rejected push.
+
+Examples:
+  snap git -- stash push -m "wip"
+  snap git -- cherry-pick 1a2b3c4
+  snap git -- log --graph --oneline`)
+}
+
+func printAIHelp() {
+	fmt.Println(`Usage: snap ai <command> [OPTIONS]
+
+Commands:
+  usage      Show daily and weekly totals of requests to hosted AI providers
+
+Every OpenAI-compatible or Claude request is logged with its token counts in
+~/.local/state/snap/usage (turn off with ai.usage = false). Costs use the
+built-in prices of the default models or the ones under [prices] in the config.
+Ollama runs locally and isn't counted.
+
+Options:
+  --days N    (usage) Days to show (default: 7)
+  --weeks N   (usage) Weeks to show (default: 4)
+
+Examples:
+  snap ai usage              This week and the last few
+  snap ai usage --days 30    A month, day by day`)
+}
+
+func printReposHelp() {
+	fmt.Println(`Usage: snap repos [QUERY] [OPTIONS]
+
+Jump between recently used repositories. Snap remembers the last 20
+repositories it ran in (in ~/.local/state/snap/repos).
+
+The list is interactive: enter prints the selected path, s opens a shell
+there. A query prints the path of the repository at that position, or the
+most recent one whose name contains it.
+
+Options:
+  --plain    Print the list, most recent first
+
+Examples:
+  snap repos                  Pick a repository from the list
+  cd "$(snap repos)"          Pick one and change into it
+  cd "$(snap repos api)"      Change into the most recent repository named *api*
+
+Shell function for quick jumps:
+  sr() { cd "$(snap repos "$@")"; }`)
+}
+
+func printResumeHelp() {
+	fmt.Println(`Usage: snap resume [OPTIONS]
+
+Finish a history rewrite that snap was interrupted in, e.g. because it crashed
+or its terminal closed during snap replay, convert, enrich or prepare-pr. The
+operation is recorded in .git/snap/operation while it runs, and the next snap
+command offers to resume or clean it up; this command does the same without
+asking, for scripts.
+
+Resuming continues the rebase the operation left behind and makes the
+force-push prepare-pr still owed. Aborting gives up the rebase, which puts the
+branch back where it was; a rewrite that already finished is kept, and its
+refs/snap/backup/ ref undoes it.
+
+Options:
+  --abort   Clean up instead of resuming
+
+Examples:
+  snap resume
+  snap resume --abort`)
+}
+
+func printPreparePRHelp() {
+	fmt.Println(`Usage: snap prepare-pr [base] [OPTIONS]
+
+Reshape a feature branch into a reviewer-friendly sequence before opening a
+pull request. The AI groups the commits since the branch left base (default:
+the default branch), squashing fixups into the commit they belong to and
+ordering the groups, and writes a new message for each group from its
+combined diff. Nothing changes until you accept the preview; then HEAD is
+backed up under refs/snap/backup/, the branch is rebuilt with an interactive
+rebase and force-pushed with --force-with-lease.
+
+A new order that doesn't apply cleanly leaves the branch as it was. Branches
+with merge commits have to be replayed first.
+
 Options:
-  --from    Only pull changes from remote (skip push)
+  --seed <number>   Seed for the AI's grouping and messages (default: save.seed, 42)
+  --no-push         Only squash, don't push
+
+Keys:
+  enter       Squash (and push)
+  g           Ask for another grouping
+  q           Quit without changing anything
 
 Examples:
-  snap sync          Push and pull changes automatically
-  snap sync --from   Only pull changes from remote`)
+  snap prepare-pr
+  snap prepare-pr develop --no-push`)
+}
+
+func printConvertHelp() {
+	fmt.Println(`Usage: snap convert [OPTIONS]
+
+Reword recent commits whose messages don't follow the commit convention
+([convention] in the config), for repositories adopting conventional commits.
+The AI proposes a new subject for each from its old message and diff; you pick
+which to apply, and they are rewritten with an interactive rebase. Bodies are
+kept, and HEAD is backed up under refs/snap/backup/ first.
+
+Merge commits stop the search, since rewording replays everything above the
+oldest reworded commit.
+
+Options:
+  --count <n>   Recent commits to look at (default: 20)
+  --force       Also reword commits that are already pushed
+
+Keys:
+  space       Toggle a commit
+  e           Edit the proposed message
+  g           Ask for another proposal
+  enter       Reword the selected commits
+  q           Quit without changing anything
+
+Examples:
+  snap convert
+  snap convert --count 100`)
+}
+
+func printResolveHelp() {
+	fmt.Println(`Usage: snap resolve
+
+Resolve merge conflicts with AI suggestions after 'snap sync' or 'snap replay'
+stopped on conflicts. Each conflict (ours, theirs, and the base with
+merge.conflictStyle = diff3) is sent to the configured AI provider, and the
+proposed resolution is shown for you to accept, edit, or reject.
+
+Files with every conflict resolved are staged. Rejected conflicts keep their
+markers, so you can finish them by hand.
+
+Keys:
+  a, enter    Accept the suggestion
+  e           Edit the suggestion (ctrl+s to use it)
+  r           Reject it and keep the conflict markers
+  g           Ask for another suggestion
+  q           Quit; the current file is left unchanged
+
+Example:
+  snap resolve`)
 }
 
 func printStackHelp() {
@@ -102,20 +532,60 @@ Show commit history as a visual timeline.
 Options:
   --all       Include all branches
   --mine      Show only your commits
+  --dir DIR   Show the commits that changed anything below DIR, with its
+              commit count, churn (lines added and removed), files changed
+              and top contributors, e.g. for one package of a monorepo
   --plain     Non-interactive mode (for piping/scripts)
+  --step-summary  Like --plain, and write a commit table to the GitHub Actions
+                  step summary ($GITHUB_STEP_SUMMARY)
+
+Interactive keys:
+  /           Filter by message, hash, or author
+  t / T       Show only one conventional type (feat, fix, ...), with counts per type
+  0           Show all types again
+  s           Show added/deleted lines and changed files per commit
+  :           Go to a commit by hash, tag, or HEAD~n (loads more history if needed)
+  B           Create a branch at the commit and switch to it (no detached HEAD)
+  w           Reword the commit (amends HEAD, rebases for older commits);
+              Ctrl+G in the prompt generates a new message with AI
+  b           Mark the commit as compare base; b on another commit shows the files
+              and diffs between the two (Esc clears the base)
 
 Examples:
   snap stack               Interactive commit history viewer
   snap stack --all         Include all branches
   snap stack --mine        Show only your commits
   snap stack --plain       Non-interactive mode
-  snap stack README.md     Show history for a specific file`)
+  snap stack README.md     Show history for a specific file
+  snap stack --dir services/billing --plain
+                           Activity of one package, for scripts`)
+}
+
+func printTimelineHelp() {
+	fmt.Println(`Usage: snap timeline <file> [OPTIONS]
+
+Show every commit that changed a file, following renames: when, by whom and
+how many lines were added and removed. Preview the file as it was after any
+of them, and restore that version into your working tree.
+
+Options:
+  --plain     Print the timeline without the interactive view
+
+Interactive keys:
+  Enter       Preview the file at the selected commit (n/N: older/newer)
+  r           Restore the file to the selected version (asks first); the
+              change is left for snap save to commit
+
+Examples:
+  snap timeline README.md
+  snap timeline src/auth/login.go --plain`)
 }
 
 func printBranchHelp() {
 	fmt.Println(`Usage: snap branch [SUBCOMMAND] [OPTIONS]
 
-Manage branches - list, create, switch, or delete.
+Manage branches - list, create, switch, or delete. The list shows how far
+each branch is ahead of or behind its upstream and the default branch.
 
 Subcommands:
   new, create       Create and switch to a new branch
@@ -126,49 +596,195 @@ Examples:
   snap branch                  List all branches (interactive)
   snap branch new feature      Create and switch to 'feature' branch
   snap branch switch main      Switch to 'main' branch
-  snap branch delete feature   Delete 'feature' branch`)
+  snap branch delete feature   Delete 'feature' branch
+
+With mode = "beginner", deleting asks you to type the branch name, and switch
+only accepts local branches, so a tag or commit can't detach HEAD.`)
 }
 
 func printReplayHelp() {
-	fmt.Println(`Usage: snap replay <branch> [OPTIONS]
+	fmt.Println(`Usage: snap replay [branch] [OPTIONS]
 
-Replay commits onto another branch (rebase).
+Replay commits onto another branch (rebase). Without a branch, replays onto
+the default branch (origin/HEAD, then init.defaultBranch, then main/master).
+
+The previous HEAD is saved under refs/snap/backup/<timestamp> first, so
+'git reset --hard <ref>' undoes the replay. List backups with
+'git for-each-ref refs/snap/backup'.
 
 Options:
-  --interactive, -i   Interactive replay (not yet implemented)
+  --interactive, -i   Pick commits to reword with AI before replaying: r marks the
+                      commit under the cursor, which gets a new message from its own
+                      diff as it is replayed (keeping its body)
+  --update-refs       Move branches pointing at replayed commits along
+                      (stacked branches; requires git 2.38+)
 
 Examples:
-  snap replay main       Replay current branch commits onto main
-  snap replay main -i    Interactive replay`)
+  snap replay                     Replay onto the default branch
+  snap replay main                Replay current branch commits onto main
+  snap replay main --update-refs  Replay a stack of branches onto main
+  snap replay main -i             Clean up the branch's messages with AI before a PR`)
 }
 
 func printTagsHelp() {
-	fmt.Println(`Usage: snap tags [SUBCOMMAND]
+	fmt.Println(`Usage: snap tags [SUBCOMMAND] [OPTIONS]
 
-Manage tags - list, inspect, diff, or create.
+Manage tags - list, inspect, diff, create, or edit.
 
 Subcommands:
-  inspect <tag>       Inspect a tag (commits, stats, metadata)
-  diff                Show commits since last tag
-  create <version>    Create and push a new annotated tag
+  inspect <tag>         Inspect a tag (commits, stats, signature; o opens the release page)
+  diff                  Show commits and changed files since last tag (tab: files)
+                        (--plain for text output, --fail-if-any to exit 1 if any,
+                        --format json|markdown for release dashboards and announcements,
+                        --step-summary to add the markdown report to the GitHub Actions
+                        step summary)
+  create <version>      Create and push a new annotated tag
+  bump [level]          Create the next version tag (level: major, minor, patch)
+  promote <pre-tag>     Re-tag a prerelease commit as the final version
+  edit <tag>            Edit a tag's message (ctrl+e opens $EDITOR); signed tags are
+                        signed again with your key, or left unchanged if that fails
+  notes [tag]           Print markdown release notes for a tag (default: unreleased commits)
+                        with commit and issue links (--no-links for plain text)
+                        (--prs to list merged pull request titles instead of commits)
+                        (ticket IDs like ENG-123 get titles and links with [tickets])
+
+Options (create, bump, promote):
+  --plain             Non-interactive mode with textual output (for CI)
+  -y, --yes           Skip the confirmation prompt
+  --pre <id>          Bump to a prerelease, e.g. --pre rc gives v1.3.0-rc.1, rc.2, ...
+  --prefix <prefix>   Tag prefix to bump when several are configured (e.g. api/v)
+
+Tag naming:
+  Tags must match the naming policy (default: v + semantic version).
+  Bare versions get the prefix added, e.g. 'snap tags create 1.2.0' creates v1.2.0.
+  Set the policy in [tags] of the config (see snap config --help):
+    prefixes = ["api/v", "web/v"]   Monorepo prefixes
+    semver = false                  Allow non-semver names after the prefix
+
+Tag messages:
+  Commits are grouped by conventional type (Breaking Changes, Features, Bug Fixes, ...).
+  Set changelog.exclude = ["chore", "ci"] in the config to leave types out (default: chore).
+
+Announcements:
+  With announce.webhook (or $SNAP_ANNOUNCE_WEBHOOK), create, bump and promote preview a
+  Slack/Discord/Teams message with the highlights and compare link after pushing, and
+  post it once confirmed (-y posts without asking).
 
 Examples:
   snap tags                     List all tags interactively
   snap tags inspect v1.0.0      Inspect a specific tag
   snap tags diff                Show commits since last tag
-  snap tags create v1.0.0       Create and push a new tag`)
+  snap tags diff --fail-if-any  Fail in CI when commits are untagged
+  snap tags diff --format json  Commits with hashes, authors and stats as JSON
+  snap tags create v1.0.0       Create and push a new tag
+  snap tags create api/v1.2.3   Create a tag for a monorepo component
+  snap tags create v1.0.0 --plain -y   Create and push a tag from CI
+  snap tags bump minor --pre rc       Start a release candidate (v1.3.0-rc.1)
+  snap tags bump --pre rc             Next release candidate (v1.3.0-rc.2)
+  snap tags promote v1.3.0-rc.2       Release the candidate as v1.3.0
+  snap tags edit v1.3.0               Fix a typo in the release notes
+  snap tags notes v1.3.0 | pbcopy     Copy release notes for a GitHub Release
+  snap tags notes v1.3.0 --prs        Release notes from merged pull requests
+
+Pull requests are read from the GitHub or GitLab API of the origin remote.
+Set GITHUB_TOKEN or GITLAB_TOKEN for private repositories. Tokens are only sent
+over HTTPS to github.com and gitlab.com; list other hosts, e.g. GitHub Enterprise,
+in forges.token_hosts of ~/.config/snap/config.toml.`)
 }
 
-func main() {
-	seed := 42
+// tagCreateFlags holds the options shared by tags create, bump, and promote
+type tagCreateFlags struct {
+	args      []string
+	plainMode bool
+	assumeYes bool
+	pre       string
+	prefix    string
+}
+
+// parseTagCreateFlags parses tag creation options, exiting on unknown flags
+func parseTagCreateFlags(args []string) tagCreateFlags {
+	var opts tagCreateFlags
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--plain":
+			opts.plainMode = true
+		case arg == "-y" || arg == "--yes":
+			opts.assumeYes = true
+		case (arg == "--pre" || arg == "--prefix") && i+1 < len(args):
+			if arg == "--pre" {
+				opts.pre = args[i+1]
+			} else {
+				opts.prefix = args[i+1]
+			}
+			i++ // Skip the value
+		case !strings.HasPrefix(arg, "-"):
+			opts.args = append(opts.args, arg)
+		default:
+			fmt.Printf("Error: unknown option '%s'\n", arg)
+			fmt.Println("\nRun 'snap tags --help' for usage information")
+			os.Exit(1)
+		}
+	}
+	return opts
+}
+
+// exitTagPolicyError reports a tag naming policy violation and exits
+func exitTagPolicyError(err error) {
+	fmt.Printf("Error: %v\n", err)
+	fmt.Println("\nConfigure the naming policy in [tags] of ~/.config/snap/config.toml or .snap.toml:")
+	fmt.Println("  prefixes = [\"api/v\", \"web/v\"]   (monorepo prefixes)")
+	fmt.Println("  semver = false                  (allow non-semver names)")
+	os.Exit(1)
+}
+
+// runTagCreate runs the tag creation flow in plain or interactive mode and exits
+func runTagCreate(req tagCreateRequest, opts tagCreateFlags) {
+	if opts.plainMode {
+		if err := runTagsCreatePlain(req, opts.assumeYes); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if _, err := runTUI(initialTagsCreateModel(req), "snap tags create --plain", tea.WithAltScreen()); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
 
+func main() {
 	// Parse arguments
 	if len(os.Args) == 1 {
+		if cfg, err := LoadConfig(); err == nil {
+			applyConfig(cfg)
+		}
 		printHelp()
 		os.Exit(0)
 	}
 
+	cfg, err := LoadConfig()
+	if err != nil {
+		fmt.Printf("Error: invalid config: %v\n", err)
+		os.Exit(1)
+	}
+	applyConfig(cfg)
+	seed := config.Save.Seed
+	recordCurrentRepo()
+
 	command := os.Args[1]
+	if beginnerMode() && slices.Contains(advancedCommands, command) {
+		fmt.Printf("Error: snap %s is an advanced command, which beginner mode hides\n", command)
+		fmt.Println("\nSet mode = \"standard\" in ~/.config/snap/config.toml or .snap.toml to use it")
+		os.Exit(1)
+	}
+	if !slices.Contains([]string{"help", "--help", "-h", "version", "--version", "-v", "resume"}, command) && !hasHelpFlag() {
+		checkInterruptedOperation(stdinIsTerminal() && !slices.ContainsFunc(os.Args[2:], func(arg string) bool {
+			return arg == "--plain" || arg == "--print-hash" || arg == "--step-summary"
+		}))
+	}
 
 	// Handle commands
 	switch command {
@@ -180,73 +796,661 @@ func main() {
 		printVersion()
 		os.Exit(0)
 
-	case "init":
+	case "config":
+		if hasHelpFlag() {
+			printConfigHelp()
+			os.Exit(0)
+		}
+		if err := printConfig(config); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+
+	case "init":
+		if hasHelpFlag() {
+			printInitHelp()
+			os.Exit(0)
+		}
+		template := ""
+		values := map[string]string{}
+		for i := 2; i < len(os.Args); i++ {
+			switch {
+			case os.Args[i] == "--template" && i+1 < len(os.Args):
+				template = os.Args[i+1]
+				i++ // Skip the template
+			case os.Args[i] == "--set" && i+1 < len(os.Args):
+				key, value, ok := strings.Cut(os.Args[i+1], "=")
+				if !ok || !templateTokenRe.MatchString("{{"+key+"}}") {
+					fmt.Printf("Error: --set expects key=value with a lowercase key, got '%s'\n", os.Args[i+1])
+					os.Exit(1)
+				}
+				values[key] = value
+				i++ // Skip the value
+			default:
+				fmt.Printf("Error: unknown option '%s'\n", os.Args[i])
+				fmt.Println("\nRun 'snap init --help' for usage information")
+				os.Exit(1)
+			}
+		}
+		if len(values) > 0 && template == "" {
+			fmt.Println("Error: --set only applies with --template")
+			os.Exit(1)
+		}
+
+		// Check if already a git repository
+		if IsGitRepository() {
+			fmt.Println("Error: already a git repository")
+			fmt.Println("Use 'snap changes' to see what's changed")
+			os.Exit(1)
+		}
+
+		if template != "" {
+			if err := runInitTemplate(template, values); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+
+		// Initialize repository
+		fmt.Println("📸 Initializing new repository...")
+		output, err := InitRepository()
+		if err != nil {
+			fmt.Printf("Error: failed to initialize repository: %v\n", err)
+			fmt.Println(output)
+			os.Exit(1)
+		}
+
+		fmt.Println("✓ Repository initialized!")
+		fmt.Println("\nNext steps:")
+		fmt.Println("  1. Create some files")
+		fmt.Println("  2. Run 'snap changes' to see what's new")
+		fmt.Println("  3. Run 'snap save \"Initial commit\"' to save your work")
+		os.Exit(0)
+
+	case "status":
+		if hasHelpFlag() {
+			printStatusHelp()
+			os.Exit(0)
+		}
+		failIfAny := false
+		stepSummary := false
+		for _, arg := range os.Args[2:] {
+			switch arg {
+			case "--fail-if-any":
+				failIfAny = true
+			case "--step-summary":
+				stepSummary = true
+			default:
+				fmt.Printf("Error: unknown option '%s'\n", arg)
+				fmt.Println("\nRun 'snap status --help' for usage information")
+				os.Exit(1)
+			}
+		}
+
+		count, err := runStatus(stepSummary)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if failIfAny && count > 0 {
+			os.Exit(1)
+		}
+		os.Exit(0)
+
+	case "deploy":
+		if hasHelpFlag() || len(os.Args) < 3 {
+			printDeployHelp()
+			os.Exit(0)
+		}
+		var args []string
+		push, fetch := false, false
+		for _, arg := range os.Args[3:] {
+			switch {
+			case arg == "--push" && os.Args[2] == "mark":
+				push = true
+			case arg == "--fetch" && os.Args[2] == "status":
+				fetch = true
+			case !strings.HasPrefix(arg, "-"):
+				args = append(args, arg)
+			default:
+				fmt.Printf("Error: unknown option '%s'\n", arg)
+				fmt.Println("\nRun 'snap deploy --help' for usage information")
+				os.Exit(1)
+			}
+		}
+
+		var err error
+		switch os.Args[2] {
+		case "mark":
+			if len(args) == 0 || len(args) > 2 {
+				fmt.Println("Error: environment required")
+				fmt.Println("Usage: snap deploy mark <env> [commit] [--push]")
+				os.Exit(1)
+			}
+			ref := "HEAD"
+			if len(args) == 2 {
+				ref = args[1]
+			}
+			err = runDeployMark(args[0], ref, push)
+		case "status":
+			if len(args) > 0 {
+				fmt.Printf("Error: unexpected argument '%s'\n", args[0])
+				os.Exit(1)
+			}
+			err = runDeployStatus(fetch)
+		default:
+			fmt.Printf("Error: unknown deploy command '%s'\n", os.Args[2])
+			fmt.Println("\nRun 'snap deploy --help' for usage information")
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+
+	case "changes":
+		if hasHelpFlag() {
+			printChangesHelp()
+			os.Exit(0)
+		}
+		expandUntracked := config.Changes.Expand
+		showIgnored := config.Changes.Ignored
+		interactive := false
+		for i := 2; i < len(os.Args); i++ {
+			switch os.Args[i] {
+			case "--expand":
+				expandUntracked = true
+			case "--ignored":
+				showIgnored = true
+			case "--interactive", "-i":
+				interactive = true
+			default:
+				fmt.Printf("Error: unknown option '%s'\n", os.Args[i])
+				fmt.Println("\nRun 'snap changes --help' for usage information")
+				os.Exit(1)
+			}
+		}
+
+		if interactive {
+			if _, err := runTUI(initialChangesModel(expandUntracked, showIgnored), "snap changes", tea.WithAltScreen()); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+
+		status, err := GetColoredStatus(expandUntracked)
+		if err != nil {
+			fmt.Printf("Error: failed to get status: %v\n", err)
+			os.Exit(1)
+		}
+
+		if status == "" {
+			fmt.Println("No changes - everything is clean!")
+		} else {
+			fmt.Println("Changes:")
+			fmt.Print(status)
+		}
+
+		if showIgnored {
+			ignored, err := GetColoredIgnored(expandUntracked)
+			if err != nil {
+				fmt.Printf("Error: failed to get ignored files: %v\n", err)
+				os.Exit(1)
+			}
+
+			if ignored == "" {
+				fmt.Println("\nNo ignored files")
+			} else {
+				fmt.Println("\nIgnored:")
+				fmt.Print(ignored)
+			}
+		}
+		os.Exit(0)
+
+	case "sync":
+		if hasHelpFlag() {
+			printSyncHelp()
+			os.Exit(0)
+		}
+		pullOnly := false
+		untilClean := false
+		updateFork := false
+		for i := 2; i < len(os.Args); i++ {
+			switch os.Args[i] {
+			case "--from":
+				pullOnly = true
+			case "--until-clean":
+				untilClean = true
+			case "--update-fork":
+				updateFork = true
+			}
+		}
+
+		if updateFork {
+			if err := runSyncUpdateFork(); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+
+		// Run the TUI
+		if _, err := runTUI(initialSyncModel(pullOnly, untilClean), ""); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+
+	case "model":
+		if hasHelpFlag() {
+			printModelHelp()
+			os.Exit(0)
+		}
+		plainMode := false
+		repoMode := false
+		name := ""
+		for _, arg := range os.Args[2:] {
+			switch {
+			case arg == "--plain":
+				plainMode = true
+			case arg == "--repo":
+				repoMode = true
+			case !strings.HasPrefix(arg, "-") && name == "":
+				name = arg
+			default:
+				fmt.Printf("Error: unknown option '%s'\n", arg)
+				fmt.Println("\nRun 'snap model --help' for usage information")
+				os.Exit(1)
+			}
+		}
+
+		if config.AI.Provider != providerOllama {
+			fmt.Printf("Error: snap model picks Ollama models, but ai.provider is '%s' - set %s.model in the config instead\n", config.AI.Provider, config.AI.Provider)
+			os.Exit(1)
+		}
+
+		configPath, err := globalConfigPath()
+		if repoMode {
+			configPath = repoConfigPath()
+			if configPath == "" {
+				err = fmt.Errorf("--repo only works inside a repository")
+			}
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if plainMode || name != "" {
+			models, err := ollamaProvider{baseURL: config.Ollama.URL}.ListModels()
+			if plainMode {
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+				for _, model := range models {
+					active := " "
+					if HasModel([]OllamaModel{model}, config.Ollama.Model) {
+						active = "*"
+					}
+					fmt.Printf("%s %s  %s\n", active, model.Name, modelDetails(model))
+				}
+				os.Exit(0)
+			}
+
+			// Setting a model that isn't pulled yet is fine; snap save offers to pull it
+			if err == nil && !HasModel(models, name) {
+				fmt.Printf("Note: %s isn't on the Ollama server yet - snap save will offer to pull it\n", name)
+			}
+			if err := SetConfigValue(configPath, "ollama", "model", name); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(modelSavedMessage(name, configPath))
+			os.Exit(0)
+		}
+
+		finalModel, err := runTUI(initialModelPickerModel(configPath), "snap model --plain")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if mm, ok := finalModel.(modelPickerModel); ok && mm.state == modelPickerStateError {
+			os.Exit(1)
+		}
+
+	case "repos":
+		if hasHelpFlag() {
+			printReposHelp()
+			os.Exit(0)
+		}
+		plainMode := false
+		query := ""
+		for _, arg := range os.Args[2:] {
+			switch {
+			case arg == "--plain":
+				plainMode = true
+			case !strings.HasPrefix(arg, "-"):
+				query = arg
+			default:
+				fmt.Printf("Error: unknown option '%s'\n", arg)
+				fmt.Println("\nRun 'snap repos --help' for usage information")
+				os.Exit(1)
+			}
+		}
+
+		repos, err := LoadRecentRepos()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(repos) == 0 {
+			fmt.Fprintln(os.Stderr, "No recent repositories yet - run snap inside a repository first")
+			os.Exit(1)
+		}
+
+		if query != "" {
+			repo, err := MatchRecentRepo(repos, query)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(repo)
+			os.Exit(0)
+		}
+		if plainMode {
+			for _, repo := range repos {
+				fmt.Println(repo)
+			}
+			os.Exit(0)
+		}
+
+		// The list goes to stderr so 'cd "$(snap repos)"' captures only the path
+		finalModel, err := runTUI(initialReposModel(repos), "snap repos --plain", tea.WithOutput(os.Stderr))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		rm, ok := finalModel.(reposModel)
+		if !ok || rm.selected == "" {
+			os.Exit(1)
+		}
+		if rm.subshell {
+			if err := openSubshell(rm.selected); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+		fmt.Println(rm.selected)
+		os.Exit(0)
+
+	case "resolve":
+		if hasHelpFlag() {
+			printResolveHelp()
+			os.Exit(0)
+		}
+
+		if _, err := runTUI(initialResolveModel(), "", tea.WithAltScreen()); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+
+	case "enrich":
+		if hasHelpFlag() {
+			printEnrichHelp()
+			os.Exit(0)
+		}
+		for i := 2; i < len(os.Args); i++ {
+			switch {
+			case os.Args[i] == "--seed" && i+1 < len(os.Args):
+				n, err := strconv.Atoi(os.Args[i+1])
+				if err != nil {
+					fmt.Printf("Error: invalid seed value '%s'\n", os.Args[i+1])
+					os.Exit(1)
+				}
+				seed = n
+				i++ // Skip the seed value
+			default:
+				fmt.Printf("Error: unknown option '%s'\n", os.Args[i])
+				fmt.Println("\nRun 'snap enrich --help' for usage information")
+				os.Exit(1)
+			}
+		}
+		if err := runEnrich(os.Stdout, seed); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+
+	case "ai":
+		if hasHelpFlag() || len(os.Args) < 3 {
+			printAIHelp()
+			os.Exit(0)
+		}
+		if os.Args[2] != "usage" {
+			fmt.Printf("Error: unknown ai command '%s'\n", os.Args[2])
+			fmt.Println("\nRun 'snap ai --help' for usage information")
+			os.Exit(1)
+		}
+		days, weeks := 7, 4
+		for i := 3; i < len(os.Args); i++ {
+			switch {
+			case (os.Args[i] == "--days" || os.Args[i] == "--weeks") && i+1 < len(os.Args):
+				n, err := strconv.Atoi(os.Args[i+1])
+				if err != nil || n < 1 {
+					fmt.Printf("Error: %s must be a positive number\n", os.Args[i])
+					os.Exit(1)
+				}
+				if os.Args[i] == "--days" {
+					days = n
+				} else {
+					weeks = n
+				}
+				i++ // Skip the number
+			default:
+				fmt.Printf("Error: unknown option '%s'\n", os.Args[i])
+				fmt.Println("\nRun 'snap ai --help' for usage information")
+				os.Exit(1)
+			}
+		}
+		if err := runAIUsage(os.Stdout, days, weeks); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+
+	case "git":
+		args := os.Args[2:]
+		if len(args) > 0 && (args[0] == "--help" || args[0] == "-h") {
+			printGitHelp()
+			os.Exit(0)
+		}
+		if len(args) > 0 && args[0] == "--" {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			printGitHelp()
+			os.Exit(0)
+		}
+		exit, output, err := RunGit(args, os.Stdin, os.Stdout, os.Stderr)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		// A quiet non-zero exit is an answer, e.g. git diff --quiet
+		if exit != 0 && strings.TrimSpace(output) != "" {
+			fmt.Fprintln(os.Stderr, formatGitFailure(args, exit, output))
+		}
+		os.Exit(exit)
+
+	case "resume":
+		if hasHelpFlag() {
+			printResumeHelp()
+			os.Exit(0)
+		}
+		abort := false
+		for _, arg := range os.Args[2:] {
+			switch arg {
+			case "--abort":
+				abort = true
+			default:
+				fmt.Printf("Error: unknown option '%s'\n", arg)
+				fmt.Println("\nRun 'snap resume --help' for usage information")
+				os.Exit(1)
+			}
+		}
+		op, err := LoadOperation()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if op == nil {
+			fmt.Println("Nothing to resume - no snap operation was interrupted")
+			os.Exit(0)
+		}
+		if op.Running() {
+			fmt.Printf("Error: %s is still running (pid %d)\n", op, op.PID)
+			os.Exit(1)
+		}
+		if abort {
+			err = AbortOperation(*op, os.Stdout)
+		} else {
+			err = ResumeOperation(*op, os.Stdout)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+
+	case "prepare-pr":
 		if hasHelpFlag() {
-			printInitHelp()
+			printPreparePRHelp()
 			os.Exit(0)
 		}
-		// Check if already a git repository
-		if IsGitRepository() {
-			fmt.Println("Error: already a git repository")
-			fmt.Println("Use 'snap changes' to see what's changed")
+		base := ""
+		noPush := false
+		for i := 2; i < len(os.Args); i++ {
+			switch {
+			case os.Args[i] == "--seed" && i+1 < len(os.Args):
+				n, err := strconv.Atoi(os.Args[i+1])
+				if err != nil {
+					fmt.Printf("Error: invalid seed value '%s'\n", os.Args[i+1])
+					os.Exit(1)
+				}
+				seed = n
+				i++ // Skip the seed value
+			case os.Args[i] == "--no-push":
+				noPush = true
+			case !strings.HasPrefix(os.Args[i], "-") && base == "":
+				base = os.Args[i]
+			default:
+				fmt.Printf("Error: unknown option '%s'\n", os.Args[i])
+				fmt.Println("\nRun 'snap prepare-pr --help' for usage information")
+				os.Exit(1)
+			}
+		}
+		if base == "" {
+			branch, err := GetDefaultBranch()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			base = branch
+		}
+		if inProgress, _ := CheckRebaseInProgress(); inProgress {
+			fmt.Println("Error: a rebase is in progress - finish it first")
 			os.Exit(1)
 		}
 
-		// Initialize repository
-		fmt.Println("📸 Initializing new repository...")
-		output, err := InitRepository()
-		if err != nil {
-			fmt.Printf("Error: failed to initialize repository: %v\n", err)
-			fmt.Println(output)
+		if _, err := runTUI(initialPrepareModel(base, noPush, seed), ""); err != nil {
+			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
-
-		fmt.Println("✓ Repository initialized!")
-		fmt.Println("\nNext steps:")
-		fmt.Println("  1. Create some files")
-		fmt.Println("  2. Run 'snap changes' to see what's new")
-		fmt.Println("  3. Run 'snap save \"Initial commit\"' to save your work")
 		os.Exit(0)
 
-	case "changes":
+	case "convert":
 		if hasHelpFlag() {
-			printChangesHelp()
+			printConvertHelp()
 			os.Exit(0)
 		}
-		status, err := GetColoredStatus()
-		if err != nil {
-			fmt.Printf("Error: failed to get status: %v\n", err)
+		count := defaultConvertCount
+		force := false
+		for i := 2; i < len(os.Args); i++ {
+			switch {
+			case os.Args[i] == "--count" && i+1 < len(os.Args):
+				n, err := strconv.Atoi(os.Args[i+1])
+				if err != nil || n < 1 {
+					fmt.Println("Error: --count must be a positive number")
+					os.Exit(1)
+				}
+				count = n
+				i++ // Skip the count
+			case os.Args[i] == "--force":
+				force = true
+			default:
+				fmt.Printf("Error: unknown option '%s'\n", os.Args[i])
+				fmt.Println("\nRun 'snap convert --help' for usage information")
+				os.Exit(1)
+			}
+		}
+		if inProgress, _ := CheckRebaseInProgress(); inProgress {
+			fmt.Println("Error: a rebase is in progress - finish it first")
 			os.Exit(1)
 		}
 
-		if status == "" {
-			fmt.Println("No changes - everything is clean!")
-		} else {
-			fmt.Println("Changes:")
-			fmt.Print(status)
+		if _, err := runTUI(initialConvertModel(count, force), ""); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
 		}
 		os.Exit(0)
 
-	case "sync":
+	case "timeline":
 		if hasHelpFlag() {
-			printSyncHelp()
+			printTimelineHelp()
 			os.Exit(0)
 		}
-		// Check for --from flag (pull only)
-		pullOnly := false
-		for i := 2; i < len(os.Args); i++ {
-			if os.Args[i] == "--from" {
-				pullOnly = true
-				break
+		file := ""
+		plainMode := false
+		for _, arg := range os.Args[2:] {
+			switch {
+			case arg == "--plain":
+				plainMode = true
+			case !strings.HasPrefix(arg, "-") && file == "":
+				file = arg
+			default:
+				fmt.Printf("Error: unknown option '%s'\n", arg)
+				fmt.Println("\nRun 'snap timeline --help' for usage information")
+				os.Exit(1)
+			}
+		}
+		if file == "" {
+			fmt.Println("Error: file required")
+			fmt.Println("Usage: snap timeline <file>")
+			os.Exit(1)
+		}
+		if plainMode {
+			if err := printTimelinePlain(file); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
 			}
+			os.Exit(0)
 		}
 
-		// Run the TUI
-		p := tea.NewProgram(initialSyncModel(pullOnly))
-		if _, err := p.Run(); err != nil {
+		finalModel, err := runTUI(initialTimelineModel(file), "snap timeline --plain", tea.WithAltScreen())
+		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
+		if tm, ok := finalModel.(timelineModel); ok && tm.state == timelineStateError {
+			os.Exit(1)
+		}
 		os.Exit(0)
 
 	case "stack":
@@ -255,20 +1459,27 @@ func main() {
 			os.Exit(0)
 		}
 		// Parse flags
-		allBranches := false
+		allBranches := config.Stack.All
 		mineOnly := false
 		filePath := ""
-		limit := 50 // Default to 50 commits for interactive mode
+		dirMode := false
+		limit := config.Stack.Limit
 		plainMode := false
+		stepSummary := false
 
 		for i := 2; i < len(os.Args); i++ {
 			arg := os.Args[i]
-			if arg == "--all" {
+			if arg == "--dir" && i+1 < len(os.Args) {
+				filePath = os.Args[i+1]
+				dirMode = true
+				i++ // Skip the directory
+			} else if arg == "--all" {
 				allBranches = true
 			} else if arg == "--mine" {
 				mineOnly = true
-			} else if arg == "--plain" {
+			} else if arg == "--plain" || arg == "--step-summary" {
 				plainMode = true
+				stepSummary = stepSummary || arg == "--step-summary"
 				limit = 20 // Smaller limit for plain mode
 			} else if !strings.HasPrefix(arg, "-") {
 				// Assume it's a file path
@@ -276,8 +1487,24 @@ func main() {
 			}
 		}
 
+		if dirMode {
+			if info, err := os.Stat(filePath); err != nil || !info.IsDir() {
+				fmt.Printf("Error: %s is not a directory\n", filePath)
+				os.Exit(1)
+			}
+		}
+
 		// Check if we should use plain mode (non-interactive)
 		if plainMode {
+			if dirMode && !stepSummary {
+				stats, err := GetDirStats(filePath, allBranches)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+				printDirStats(stats)
+			}
+
 			// Get git user name for --mine filter
 			author := ""
 			if mineOnly {
@@ -293,6 +1520,13 @@ func main() {
 				os.Exit(1)
 			}
 
+			if stepSummary {
+				if err := WriteStepSummary(stackMarkdown(commits)); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+
 			if len(commits) == 0 {
 				fmt.Println("No commits yet")
 				os.Exit(0)
@@ -309,17 +1543,7 @@ func main() {
 			os.Exit(0)
 		}
 
-		// Run the interactive TUI with panic recovery
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Fprintf(os.Stderr, "Fatal error in interactive mode: %v\n", r)
-				fmt.Fprintf(os.Stderr, "Tip: Use 'snap stack --plain' for non-interactive mode\n\n")
-				os.Exit(1)
-			}
-		}()
-
-		p := tea.NewProgram(initialStackModel(limit, allBranches, mineOnly, filePath), tea.WithAltScreen())
-		if _, err := p.Run(); err != nil {
+		if _, err := runTUI(initialStackModel(limit, allBranches, mineOnly, filePath, dirMode), "snap stack --plain", tea.WithAltScreen()); err != nil {
 			// If TUI fails, fall back to plain mode
 			fmt.Fprintf(os.Stderr, "Interactive mode failed: %v\n", err)
 			fmt.Fprintf(os.Stderr, "Tip: Use 'snap stack --plain' for non-interactive mode\n\n")
@@ -371,8 +1595,7 @@ func main() {
 		}
 
 		// Run the TUI
-		p := tea.NewProgram(initialBranchModel(mode, branchName), tea.WithAltScreen())
-		if _, err := p.Run(); err != nil {
+		if _, err := runTUI(initialBranchModel(mode, branchName), "", tea.WithAltScreen()); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -384,35 +1607,34 @@ func main() {
 			os.Exit(0)
 		}
 		// Parse arguments
-		if len(os.Args) < 3 {
-			fmt.Println("Error: target branch required")
-			fmt.Println("Usage: snap replay <branch> [--interactive|-i]")
-			fmt.Println("\nExample:")
-			fmt.Println("  snap replay main       # Replay current branch onto main")
-			fmt.Println("  snap replay main -i    # Interactive replay")
-			os.Exit(1)
-		}
-
-		ontoBranch := os.Args[2]
+		ontoBranch := ""
 		interactive := false
+		updateRefs := false
 
-		// Check for interactive flag
-		for i := 3; i < len(os.Args); i++ {
-			if os.Args[i] == "--interactive" || os.Args[i] == "-i" {
+		for i := 2; i < len(os.Args); i++ {
+			switch os.Args[i] {
+			case "--interactive", "-i":
 				interactive = true
-				break
+			case "--update-refs":
+				updateRefs = true
+			default:
+				ontoBranch = os.Args[i]
 			}
 		}
 
-		if interactive {
-			fmt.Println("Error: interactive replay not yet implemented")
-			fmt.Println("Use 'snap replay <branch>' for non-interactive replay")
-			os.Exit(1)
+		// Default to the repository's main branch
+		if ontoBranch == "" {
+			branch, err := GetDefaultBranch()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				fmt.Println("Usage: snap replay [branch] [--interactive|-i] [--update-refs]")
+				os.Exit(1)
+			}
+			ontoBranch = branch
 		}
 
 		// Run the TUI
-		p := tea.NewProgram(initialReplayModel(ontoBranch, interactive), tea.WithAltScreen())
-		if _, err := p.Run(); err != nil {
+		if _, err := runTUI(initialReplayModel(ontoBranch, interactive, updateRefs), "", tea.WithAltScreen()); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -437,8 +1659,7 @@ func main() {
 					os.Exit(1)
 				}
 				tagName := os.Args[3]
-				p := tea.NewProgram(initialTagsInspectModel(tagName), tea.WithAltScreen())
-				if _, err := p.Run(); err != nil {
+				if _, err := runTUI(initialTagsInspectModel(tagName), "", tea.WithAltScreen()); err != nil {
 					fmt.Printf("Error: %v\n", err)
 					os.Exit(1)
 				}
@@ -446,8 +1667,50 @@ func main() {
 
 			case "diff":
 				// Show diff since last tag
-				p := tea.NewProgram(initialTagsDiffModel(), tea.WithAltScreen())
-				if _, err := p.Run(); err != nil {
+				plainMode := false
+				failIfAny := false
+				format := formatText
+				stepSummary := false
+				args := os.Args[3:]
+				for i := 0; i < len(args); i++ {
+					switch arg := args[i]; arg {
+					case "--plain":
+						plainMode = true
+					case "--fail-if-any":
+						// A CI gate never wants a TUI
+						plainMode = true
+						failIfAny = true
+					case "--step-summary":
+						plainMode = true
+						stepSummary = true
+					case "--format":
+						if i+1 >= len(args) || !slices.Contains(reportFormats, args[i+1]) {
+							fmt.Printf("Error: --format must be one of %s\n", strings.Join(reportFormats, ", "))
+							os.Exit(1)
+						}
+						format = args[i+1]
+						plainMode = true
+						i++ // Skip the format
+					default:
+						fmt.Printf("Error: unknown option '%s'\n", arg)
+						fmt.Println("\nRun 'snap tags --help' for usage information")
+						os.Exit(1)
+					}
+				}
+
+				if plainMode {
+					count, err := runTagsDiffPlain(format, stepSummary)
+					if err != nil {
+						fmt.Printf("Error: %v\n", err)
+						os.Exit(1)
+					}
+					if failIfAny && count > 0 {
+						os.Exit(1)
+					}
+					os.Exit(0)
+				}
+
+				if _, err := runTUI(initialTagsDiffModel(), "snap tags diff --plain", tea.WithAltScreen()); err != nil {
 					fmt.Printf("Error: %v\n", err)
 					os.Exit(1)
 				}
@@ -455,32 +1718,164 @@ func main() {
 
 			case "create":
 				// Create a new tag
-				if len(os.Args) < 4 {
+				opts := parseTagCreateFlags(os.Args[3:])
+				if len(opts.args) == 0 {
 					fmt.Println("Error: tag name required")
-					fmt.Println("Usage: snap tags create <version>")
+					fmt.Println("Usage: snap tags create <version> [--plain] [-y]")
 					fmt.Println("\nExample:")
 					fmt.Println("  snap tags create v1.0.0")
 					os.Exit(1)
 				}
+				requestedTag := opts.args[0]
+				policy := LoadTagPolicy()
+				tagName, err := policy.Resolve(requestedTag)
+				if err != nil {
+					exitTagPolicyError(err)
+				}
+
+				runTagCreate(tagCreateRequest{
+					Name:         tagName,
+					RequestedTag: requestedTag,
+					Prefix:       policy.PrefixOf(tagName),
+				}, opts)
+
+			case "bump":
+				// Create the next version tag
+				opts := parseTagCreateFlags(os.Args[3:])
+				level := ""
+				if len(opts.args) > 0 {
+					level = opts.args[0]
+				}
+
+				policy := LoadTagPolicy()
+				prefix := opts.prefix
+				if prefix == "" {
+					if len(policy.Prefixes) > 1 {
+						fmt.Printf("Error: multiple tag prefixes configured, choose one with --prefix (%s)\n",
+							strings.Join(policy.Prefixes, ", "))
+						os.Exit(1)
+					}
+					if len(policy.Prefixes) == 1 {
+						prefix = policy.Prefixes[0]
+					}
+				}
+
+				tagNames, err := ListTagNames(prefix)
+				if err != nil {
+					fmt.Printf("Error: failed to list tags: %v\n", err)
+					os.Exit(1)
+				}
+				next, err := NextVersion(TagVersions(tagNames, prefix), level, opts.pre)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+
+				tagName, err := policy.Resolve(prefix + next.String())
+				if err != nil {
+					exitTagPolicyError(err)
+				}
+
+				runTagCreate(tagCreateRequest{
+					Name:   tagName,
+					Prefix: policy.PrefixOf(tagName),
+				}, opts)
+
+			case "promote":
+				// Re-tag a prerelease commit as the final version
+				opts := parseTagCreateFlags(os.Args[3:])
+				if len(opts.args) == 0 {
+					fmt.Println("Error: prerelease tag required")
+					fmt.Println("Usage: snap tags promote <prerelease-tag> [--plain] [-y]")
+					fmt.Println("\nExample:")
+					fmt.Println("  snap tags promote v1.3.0-rc.2")
+					os.Exit(1)
+				}
+				preTag := opts.args[0]
+				if _, err := GetTagDetail(preTag); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+
+				policy := LoadTagPolicy()
+				prefix := policy.PrefixOf(preTag)
+				version, ok := ParseSemVer(strings.TrimPrefix(preTag, prefix))
+				if !ok || version.Prerelease == "" {
+					fmt.Printf("Error: '%s' is not a prerelease tag (expected e.g. %s1.3.0-rc.1)\n", preTag, prefix)
+					os.Exit(1)
+				}
+
+				tagName, err := policy.Resolve(prefix + version.Core().String())
+				if err != nil {
+					exitTagPolicyError(err)
+				}
+				if _, err := GetTagDetail(tagName); err == nil {
+					fmt.Printf("Error: tag '%s' already exists\n", tagName)
+					os.Exit(1)
+				}
+
+				runTagCreate(tagCreateRequest{
+					Name:        tagName,
+					Prefix:      prefix,
+					Target:      preTag + "^{commit}",
+					PromoteFrom: preTag,
+				}, opts)
+
+			case "notes":
+				// Print release notes as markdown
+				tagName := ""
+				links := true
+				prs := false
+				for _, arg := range os.Args[3:] {
+					switch {
+					case arg == "--no-links":
+						links = false
+					case arg == "--prs":
+						prs = true
+					case !strings.HasPrefix(arg, "-") && tagName == "":
+						tagName = arg
+					default:
+						fmt.Printf("Error: unknown option '%s'\n", arg)
+						fmt.Println("\nRun 'snap tags --help' for usage information")
+						os.Exit(1)
+					}
+				}
+				if err := runTagsNotes(tagName, links, prs); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+				os.Exit(0)
+
+			case "edit":
+				// Edit the message of an existing annotated tag
+				if len(os.Args) < 4 {
+					fmt.Println("Error: tag name required")
+					fmt.Println("Usage: snap tags edit <tag>")
+					fmt.Println("\nExample:")
+					fmt.Println("  snap tags edit v1.0.0")
+					os.Exit(1)
+				}
 				tagName := os.Args[3]
-				p := tea.NewProgram(initialTagsCreateModel(tagName), tea.WithAltScreen())
-				if _, err := p.Run(); err != nil {
+				finalModel, err := runTUI(initialTagsEditModel(tagName), "", tea.WithAltScreen())
+				if err != nil {
 					fmt.Printf("Error: %v\n", err)
 					os.Exit(1)
 				}
+				if em, ok := finalModel.(tagsEditModel); ok && em.state == tagsEditStateError {
+					os.Exit(1)
+				}
 				os.Exit(0)
 
 			default:
 				fmt.Printf("Error: unknown subcommand '%s'\n", subcommand)
-				fmt.Println("\nValid subcommands: inspect, diff, create")
+				fmt.Println("\nValid subcommands: inspect, diff, create, bump, promote, edit, notes")
 				fmt.Println("Or run 'snap tags' to list all tags")
 				os.Exit(1)
 			}
 		}
 
 		// No subcommand - run the tags list TUI
-		p := tea.NewProgram(initialTagsModel(), tea.WithAltScreen())
-		finalModel, err := p.Run()
+		finalModel, err := runTUI(initialTagsModel(), "", tea.WithAltScreen())
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
@@ -488,8 +1883,7 @@ func main() {
 
 		// If a tag was selected via Enter, launch the inspect view
 		if tm, ok := finalModel.(tagsModel); ok && tm.selectedTag != "" {
-			ip := tea.NewProgram(initialTagsInspectModel(tm.selectedTag), tea.WithAltScreen())
-			if _, err := ip.Run(); err != nil {
+			if _, err := runTUI(initialTagsInspectModel(tm.selectedTag), "", tea.WithAltScreen()); err != nil {
 				fmt.Printf("Error: %v\n", err)
 				os.Exit(1)
 			}
@@ -502,6 +1896,20 @@ func main() {
 			os.Exit(0)
 		}
 		var customMessage string
+		var paths []string
+		var positional []string // The message and paths, told apart after parsing
+		var coAuthors []string
+		noVerify := false
+		allowEmpty := false
+		queue := false
+		continueRebase := false
+		plainMode := false
+		printHash := false
+		selectFiles := false
+		stagedOnly := false
+		amend := false
+		regenerate := false
+		force := false
 
 		// Parse save options
 		for i := 2; i < len(os.Args); i++ {
@@ -513,6 +1921,89 @@ func main() {
 					os.Exit(1)
 				}
 				i++ // Skip the seed value
+			} else if os.Args[i] == "--candidates" && i+1 < len(os.Args) {
+				n, err := strconv.Atoi(os.Args[i+1])
+				if err != nil || n < 1 || n > maxCandidates {
+					fmt.Printf("Error: --candidates must be a number from 1 to %d\n", maxCandidates)
+					os.Exit(1)
+				}
+				config.Save.Candidates = n
+				i++ // Skip the count
+			} else if os.Args[i] == "--body" {
+				config.Save.Body = true
+			} else if os.Args[i] == "--select" {
+				selectFiles = true
+				config.Save.Select = true
+			} else if os.Args[i] == "--exclude" {
+				if i+1 >= len(os.Args) || os.Args[i+1] == "" {
+					fmt.Println("Error: --exclude requires a pattern")
+					os.Exit(1)
+				}
+				config.Save.Exclude = append(config.Save.Exclude, os.Args[i+1])
+				i++ // Skip the pattern
+			} else if os.Args[i] == "--staged-only" {
+				stagedOnly = true
+				config.Save.StagedOnly = true
+			} else if os.Args[i] == "--amend" {
+				amend = true
+			} else if os.Args[i] == "--regenerate" {
+				regenerate = true
+			} else if os.Args[i] == "--force" {
+				force = true
+			} else if os.Args[i] == "--signoff" || os.Args[i] == "-s" {
+				config.Save.Signoff = true
+			} else if os.Args[i] == "--co-author" {
+				if i+1 >= len(os.Args) {
+					fmt.Println("Error: --co-author requires \"Name <email>\"")
+					os.Exit(1)
+				}
+				coAuthor, err := ParseCoAuthor(os.Args[i+1])
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+				coAuthors = append(coAuthors, coAuthor)
+				i++ // Skip the co-author
+			} else if os.Args[i] == "--push" {
+				config.Save.Push = true
+			} else if os.Args[i] == "--plain" {
+				plainMode = true
+			} else if os.Args[i] == "--print-hash" {
+				// Scripts capture stdout, so there is no TUI either
+				plainMode = true
+				printHash = true
+			} else if os.Args[i] == "--no-verify" {
+				noVerify = true
+			} else if os.Args[i] == "--allow-empty" {
+				allowEmpty = true
+			} else if os.Args[i] == "--queue" {
+				queue = true
+			} else if os.Args[i] == "--continue" {
+				continueRebase = true
+			} else if os.Args[i] == "--skip-checks" {
+				config.Checks = ChecksConfig{}
+			} else if os.Args[i] == "--allow-secrets" {
+				config.Checks.Secrets = false
+			} else if os.Args[i] == "--model" {
+				if i+1 < len(os.Args) && os.Args[i+1] != "" {
+					config.SetModel(os.Args[i+1])
+					i++ // Skip the model name
+				} else {
+					fmt.Printf("Error: --model requires a value\n")
+					os.Exit(1)
+				}
+			} else if os.Args[i] == "--host" {
+				if i+1 >= len(os.Args) {
+					fmt.Printf("Error: --host requires a value\n")
+					os.Exit(1)
+				}
+				ollamaURL, err := NormalizeOllamaHost(os.Args[i+1])
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+				config.Ollama.URL = ollamaURL
+				i++ // Skip the host
 			} else if os.Args[i] == "--message" || os.Args[i] == "-m" {
 				if i+1 < len(os.Args) {
 					customMessage = os.Args[i+1]
@@ -521,9 +2012,12 @@ func main() {
 					fmt.Printf("Error: --message requires a value\n")
 					os.Exit(1)
 				}
-			} else if len(os.Args[i]) > 0 && os.Args[i][0] != '-' && customMessage == "" {
-				// Positional argument (custom message)
-				customMessage = os.Args[i]
+			} else if os.Args[i] == "--" {
+				// Everything after -- is a path, even if it doesn't exist
+				paths = append(paths, os.Args[i+1:]...)
+				break
+			} else if len(os.Args[i]) > 0 && os.Args[i][0] != '-' {
+				positional = append(positional, os.Args[i])
 			} else {
 				fmt.Printf("Error: unknown option '%s'\n", os.Args[i])
 				fmt.Println("\nRun 'snap help' for usage information")
@@ -531,8 +2025,197 @@ func main() {
 			}
 		}
 
-		p := tea.NewProgram(initialModelWithMessage(seed, customMessage))
-		if _, err := p.Run(); err != nil {
+		// A lone argument is the message, so snap save "docs" doesn't become a
+		// path-limited save because a docs directory exists. Next to -m, --
+		// or other arguments, the ones naming files or directories are paths.
+		if len(positional) == 1 && customMessage == "" && len(paths) == 0 {
+			if IsKnownPath(positional[0]) {
+				fmt.Printf("Error: ambiguous argument '%s': both a path and a message\n", positional[0])
+				fmt.Printf("\nUse 'snap save -- %s' to save only that path, or 'snap save -m %q' for the message\n", positional[0], positional[0])
+				os.Exit(1)
+			}
+			customMessage = positional[0]
+		} else {
+			for _, arg := range positional {
+				switch {
+				case IsKnownPath(arg):
+					paths = append(paths, arg)
+				case customMessage == "":
+					customMessage = arg
+				default:
+					fmt.Printf("Error: '%s' is not a file or directory\n", arg)
+					fmt.Println("\nUse -- before paths that no longer exist")
+					os.Exit(1)
+				}
+			}
+		}
+
+		// save.select from a config file just doesn't apply to --plain
+		if plainMode && selectFiles {
+			fmt.Println("Error: --select needs the interactive save, not --plain")
+			os.Exit(1)
+		}
+		if selectFiles && stagedOnly {
+			fmt.Println("Error: --select and --staged-only can't be combined")
+			os.Exit(1)
+		}
+		// A flag wins over the other mode set in the config
+		if selectFiles {
+			config.Save.StagedOnly = false
+		} else if stagedOnly {
+			config.Save.Select = false
+		}
+
+		if len(paths) > 0 && (selectFiles || amend || config.Save.StagedOnly || allowEmpty) {
+			fmt.Println("Error: paths can't be combined with --select, --staged-only, --amend or --allow-empty")
+			os.Exit(1)
+		}
+		if len(paths) > 0 {
+			// Paths choose what to commit, so save.select doesn't apply
+			config.Save.Select = false
+			paths = append(paths, ExcludePathspecs(config.Save.Exclude)...)
+		}
+		// A merge commits the whole index with git's message for it
+		merging := CheckMergeInProgress()
+		if merging {
+			if len(paths) > 0 || amend || allowEmpty || queue {
+				fmt.Println("Error: a merge is in progress - conclude it with a plain snap save first (paths, --amend, --allow-empty and --queue don't apply)")
+				os.Exit(1)
+			}
+			if conflicts, _ := GetConflictedFiles(); len(conflicts) > 0 {
+				fmt.Printf("Error: the merge still has conflicts in %s - resolve them (snap resolve), then save again\n", strings.Join(conflicts, ", "))
+				os.Exit(1)
+			}
+			config.Save.Select = false
+		}
+		// Committing in the middle of a replay would leave a stray commit between
+		// the replayed ones; the fixes belong to the commit it stopped at
+		rebasing, _ := CheckRebaseInProgress()
+		if continueRebase && !rebasing {
+			fmt.Println("Error: --continue only applies while a replay (rebase) is in progress")
+			os.Exit(1)
+		}
+		if rebasing {
+			if len(paths) > 0 || amend || allowEmpty || queue || customMessage != "" {
+				fmt.Println("Error: a replay (rebase) is in progress - a message, paths, --amend, --allow-empty and --queue don't apply")
+				os.Exit(1)
+			}
+			if !continueRebase && (plainMode || !confirmPlain("A replay (rebase) stopped for you to fix the commit it is at. Stage your changes and continue it?")) {
+				fmt.Println("Error: a replay (rebase) is in progress - stage your fixes and continue with snap save --continue (or git rebase --continue), or undo it with git rebase --abort")
+				os.Exit(1)
+			}
+			if err := continueRebasePlain(os.Stdout); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+		if regenerate && !amend && !merging {
+			fmt.Println("Error: --regenerate only applies with --amend or during a merge")
+			os.Exit(1)
+		}
+		if regenerate && customMessage != "" {
+			fmt.Println("Error: --regenerate and a custom message can't be combined")
+			os.Exit(1)
+		}
+
+		// With --print-hash, stdout is reserved for the commit hash
+		status := io.Writer(os.Stdout)
+		if printHash {
+			status = os.Stderr
+		}
+		notice, err := CheckIdentity()
+		if err != nil {
+			fmt.Fprintf(status, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if notice != "" {
+			fmt.Fprintln(status, notice)
+		}
+		if err := CheckSigningKey(); err != nil {
+			fmt.Fprintf(status, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		branch, _ := GetCurrentBranch()
+		trailers, err := SaveTrailers(config.Save, config.Tickets, branch)
+		if err != nil {
+			fmt.Fprintf(status, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		// git itself only reads the template for its editor, so a broken one
+		// shouldn't stop the save
+		template, err := GetCommitTemplate()
+		if err != nil {
+			fmt.Fprintf(status, "⚠ %v\n", err)
+		}
+
+		var previous string
+		if amend {
+			if previous, err = GetHeadMessage(); err != nil {
+				fmt.Fprintf(status, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			// Amending a pushed commit means the next push has to be forced
+			if remote := GetPushedBranch("HEAD"); remote != "" && !force {
+				fmt.Fprintf(status, "Error: HEAD is already on %s; amending rewrites pushed history - pass --force to amend anyway, then push with --force-with-lease\n", remote)
+				os.Exit(1)
+			} else if remote != "" && config.Save.Push {
+				// Pulling would merge the old commit right back in
+				fmt.Fprintf(status, "Error: HEAD is already on %s, so the amended commit can't be synced - save without --push, then push with --force-with-lease\n", remote)
+				os.Exit(1)
+			}
+		}
+
+		// Amending and merges don't add a commit of their own to move elsewhere
+		protected := !amend && !merging && IsProtectedBranch(branch, config.Save.Protected)
+
+		if plainMode {
+			if protected {
+				fmt.Fprintf(status, "⚠ Committing on %s, a protected branch (save.protected)\n", branch)
+			}
+			req := saveRequest{
+				Seed:       seed,
+				Message:    customMessage,
+				PrintHash:  printHash,
+				Amend:      amend,
+				Regenerate: regenerate,
+				Paths:      paths,
+				CoAuthors:  coAuthors,
+				Trailers:   trailers,
+				NoVerify:   noVerify,
+				AllowEmpty: allowEmpty,
+				Queue:      queue,
+				Merge:      merging,
+				Template:   template,
+			}
+			if err := runSavePlain(req); err != nil {
+				fmt.Fprintf(status, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+
+		m := initialModelWithMessage(seed, customMessage)
+		if amend {
+			m = initialAmendModel(seed, customMessage, previous, regenerate)
+		} else if merging {
+			mergeMsg, err := GetMergeMessage()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			m = initialMergeModel(seed, customMessage, mergeMsg, regenerate)
+		}
+		m.paths = paths
+		m.coAuthors, m.coAuthorOn = coAuthorChoices(config.Save.CoAuthors, coAuthors)
+		m.trailers = trailers
+		m.noVerify = noVerify
+		m.allowEmpty = allowEmpty
+		m.template = template
+		if protected {
+			m.protected = branch
+		}
+		if _, err := runTUI(m, "snap save --plain"); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -16,12 +20,18 @@ type state int
 
 const (
 	stateChecking state = iota
+	stateConfirmingPull
+	statePulling
+	stateSelectingFiles
+	stateConfirmingJunk
 	stateStaging
 	stateGettingDiff
 	stateGenerating
 	stateConfirming
+	stateSelectingCoAuthors
 	stateEditing
 	stateCommitting
+	stateSyncing // Pulling and pushing after the commit, with save.push
 	stateDone
 	stateError
 )
@@ -29,96 +39,225 @@ const (
 type model struct {
 	state         state
 	spinner       spinner.Model
-	textInput     textinput.Model
+	textarea      textarea.Model // Subject and body while editing
 	err           error
 	diff          string
 	commitMessage string
 	originalMsg   string
 	cursor        int
 	seed          int
-	ollamaRunning bool
+	providerReady bool
 	stagedChanges bool
 	generatedMsg  bool
 	userConfirmed bool
 	useCustomMsg  bool
+	amend         bool      // Fold the changes into HEAD instead of a new commit
+	keptMsg       bool      // Amending with HEAD's message as it is
+	paths         []string  // Only these pathspecs are staged and committed
+	allowEmpty    bool      // Commit even when nothing changed (--allow-empty)
+	empty         bool      // Nothing changed, so the commit is an empty one
+	queued        bool      // Committed with a placeholder for snap enrich
+	merging       bool      // Concluding a merge (MERGE_HEAD exists)
+	protected     string    // The save.protected branch the commit would land on
+	newBranch     string    // Created and switched to before committing
+	switchErr     error     // Why the new branch couldn't be created
+	sync          syncModel // Runs after the commit with save.push
+	partialMsg    string    // Streamed so far while generating
+	summarized    summarizeProgressMsg
+	updates       chan tea.Msg       // Streamed tokens, then the generated message
+	cancelGen     context.CancelFunc // Stops the running generation
+	candidates    []string           // Generated messages to pick from
+	candidateIdx  int
+	source        messageSource // Which provider generated the candidates
+	body          string        // Generated commit body, wrapped
+	includeBody   bool
+	bodyLoading   bool
+	bodyErr       error
+	providerErr   error          // Why AI generation is unavailable; the message is written by hand
+	stats         []FileDiffStat // Staged files, summarized above the message
+	owners        []OwnerShare   // CODEOWNERS of the staged files, who will review
+	recent        []string       // Recent commit subjects, to warn about a repeated message
+	redactions    []Redaction    // Secrets masked in the diff sent to the AI
+	findings      []CheckFinding // Warnings from the [checks] scan of the staged changes
+	whitespace    []WhitespaceIssue
+	fixingSpace   bool
+	spaceFixed    int // Whitespace issues fixed with f
+	spaceErr      error
+	large         []LargeFile // Staged files over checks.max_file_mb
+	skippingLarge bool
+	largeSkipped  int // Large files unstaged with u
+	largeErr      error
+	showLFS       bool // Show how to track the large files with Git LFS
+	showDiff      bool // Scrolling through the staged diff with d
+	diffView      viewport.Model
+	width         int
+	height        int
+	junk          []JunkPath    // Untracked save.junk paths git add -A would stage
+	files         []StatusEntry // Changed files offered with save.select
+	fileSelected  []bool
+	fileCursor    int
+	coAuthors     []string // save.co_authors and --co-author, credited with trailers
+	coAuthorOn    []bool
+	coAuthorIdx   int
+	trailers      []string // save.trailers and Signed-off-by, added to the message
+	noVerify      bool     // Skip the pre-commit and commit-msg hooks
+	template      string   // commit.template text, added below the message
+	editErr       error    // Why the message from $EDITOR wasn't used
+	hookOutput    string
+	missingModel  error // The Ollama model that isn't pulled yet
+	genFailed     bool  // Editing by hand because generation failed, not the provider check
+	pull          pullModelMsg
+	cancelPull    context.CancelFunc
 }
 
-type checkOllamaMsg struct {
-	running bool
+type checkProviderMsg struct {
+	err error
+}
+
+// pullModelMsg reports the progress of an Ollama model pull
+type pullModelMsg struct {
+	status    string
+	completed int64
+	total     int64
+}
+
+type pullDoneMsg struct {
+	err error
+}
+
+type fixWhitespaceMsg struct {
+	diff  string // The staged changes after the fix
+	stats []FileDiffStat
+	fixed int
+	err   error
+}
+
+type skipLargeMsg struct {
+	diff    string // The staged changes without the large files
+	stats   []FileDiffStat
+	skipped int
+	err     error
+}
+
+type junkMsg struct {
+	junk []JunkPath
 }
 
 type stageChangesMsg struct {
 	err error
 }
 
+// changedFilesMsg lists the files to pick from with save.select
+type changedFilesMsg struct {
+	entries []StatusEntry
+	err     error
+}
+
 type getDiffMsg struct {
-	diff string
-	err  error
+	diff   string
+	stats  []FileDiffStat // Staged files, for the summary line
+	owners []OwnerShare
+	recent []string // Subjects the new message shouldn't repeat
+	err    error
 }
 
 type generateMsgMsg struct {
-	message string
-	err     error
+	messages []string // Candidates, best first
+	source   messageSource
+	body     string // Generated when save.body is set
+	bodyErr  error
+	err      error
+}
+
+type generateBodyMsg struct {
+	body string
+	err  error
+}
+
+// generateTokenMsg carries the raw completion streamed so far
+type generateTokenMsg struct {
+	text string
+}
+
+// summarizeProgressMsg reports how many chunks of a large diff are summarized
+type summarizeProgressMsg struct {
+	done  int
+	total int
 }
 
 type commitMsg struct {
-	err error
+	output string // What the commit hooks printed
+	err    error
 }
 
 var (
+	titleStyle     lipgloss.Style
+	successStyle   lipgloss.Style
+	errorStyle     lipgloss.Style
+	infoStyle      lipgloss.Style
+	highlightStyle lipgloss.Style
+	boxStyle       lipgloss.Style
+)
+
+func init() {
+	initStyles()
+}
+
+// initStyles builds the shared styles from the color palette. It runs again
+// after the config is loaded so configured colors take effect.
+func initStyles() {
 	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#7D56F4")).
-			MarginBottom(1)
+		Bold(true).
+		Foreground(colorPrimary).
+		MarginBottom(1)
 
 	successStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#04B575"))
+		Foreground(colorSuccess)
 
 	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FF0000"))
+		Foreground(colorError)
 
 	infoStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#888888"))
+		Foreground(colorMuted)
 
 	highlightStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFFF00")).
-			Bold(true)
+		Foreground(colorHighlight).
+		Bold(true)
 
 	boxStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#7D56F4")).
-			Padding(1, 2)
-)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 2)
+}
+
+// newMessageArea is the editor for a subject line plus an optional body
+func newMessageArea() textarea.Model {
+	ta := textarea.New()
+	ta.Placeholder = "Subject line, then a blank line and the body..."
+	ta.ShowLineNumbers = false
+	ta.MaxHeight = 1000
+	ta.SetWidth(76)
+	ta.SetHeight(6)
+	return ta
+}
 
 func initialModel(seed int) model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
-
-	ti := textinput.New()
-	ti.Placeholder = "Enter commit message..."
-	ti.Focus()
-	ti.CharLimit = 200
-	ti.Width = 60
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
 
 	return model{
-		state:     stateChecking,
-		seed:      seed,
-		spinner:   s,
-		textInput: ti,
+		state:    stateChecking,
+		seed:     seed,
+		spinner:  s,
+		textarea: newMessageArea(),
 	}
 }
 
 func initialModelWithMessage(seed int, customMessage string) model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
-
-	ti := textinput.New()
-	ti.Placeholder = "Enter commit message..."
-	ti.Focus()
-	ti.CharLimit = 200
-	ti.Width = 60
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
 
 	if customMessage != "" {
 		// Skip AI generation, go straight to staging
@@ -128,7 +267,7 @@ func initialModelWithMessage(seed int, customMessage string) model {
 			commitMessage: customMessage,
 			useCustomMsg:  true,
 			spinner:       s,
-			textInput:     ti,
+			textarea:      newMessageArea(),
 		}
 	}
 
@@ -136,41 +275,291 @@ func initialModelWithMessage(seed int, customMessage string) model {
 	return initialModel(seed)
 }
 
+// initialAmendModel saves into the last commit. Without a custom message, the
+// previous message is kept unless regenerate asks the AI for a new one from
+// the combined diff.
+func initialAmendModel(seed int, customMessage, previous string, regenerate bool) model {
+	var m model
+	switch {
+	case customMessage != "":
+		m = initialModelWithMessage(seed, customMessage)
+	case regenerate:
+		m = initialModel(seed)
+	default:
+		m = initialModelWithMessage(seed, previous)
+		m.keptMsg = true
+		subject, body, _ := strings.Cut(previous, "\n")
+		m.commitMessage = subject
+		m.body = strings.TrimSpace(body)
+		m.includeBody = m.body != ""
+	}
+	m.amend = true
+	return m
+}
+
+// initialMergeModel concludes a merge. Without a custom message, the message
+// git prepared is kept unless regenerate asks the AI for one from the merge's
+// changes.
+func initialMergeModel(seed int, customMessage, mergeMsg string, regenerate bool) model {
+	var m model
+	switch {
+	case customMessage != "":
+		m = initialModelWithMessage(seed, customMessage)
+	case regenerate || mergeMsg == "":
+		m = initialModel(seed)
+	default:
+		m = initialModelWithMessage(seed, mergeMsg)
+		m.keptMsg = true
+		subject, body, _ := strings.Cut(mergeMsg, "\n")
+		m.commitMessage = subject
+		m.body = strings.TrimSpace(body)
+		m.includeBody = m.body != ""
+	}
+	m.merging = true
+	return m
+}
+
 func (m model) Init() tea.Cmd {
 	if m.useCustomMsg {
-		return tea.Batch(m.spinner.Tick, stageChanges)
+		return tea.Batch(m.spinner.Tick, m.startStaging())
 	}
-	return tea.Batch(m.spinner.Tick, checkOllama)
+	// The provider check and staging run side by side
+	return tea.Batch(m.spinner.Tick, checkProvider, m.startStaging())
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// After the commit, the sync handles everything until it quits
+	if m.state == stateSyncing {
+		next, cmd := m.sync.Update(msg)
+		m.sync = next.(syncModel)
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		// Handle text input in edit mode
+		// Handle the message editor in edit mode
 		if m.state == stateEditing {
 			switch msg.String() {
-			case "ctrl+c", "q":
+			case "ctrl+c", "esc":
+				m.textarea.Blur()
+				m.editErr = nil
+				if m.providerErr != nil {
+					// Nothing to go back to without a generated message
+					m.state = stateDone
+					m.err = fmt.Errorf("commit cancelled")
+					return m, tea.Quit
+				}
 				// Cancel editing, go back to confirming with original message
 				m.commitMessage = m.originalMsg
 				m.state = stateConfirming
 				return m, nil
-			case "enter":
-				// Accept edited message
-				m.commitMessage = m.textInput.Value()
-				if strings.TrimSpace(m.commitMessage) == "" {
-					m.state = stateError
-					m.err = fmt.Errorf("commit message cannot be empty")
-					return m, tea.Quit
+			case "ctrl+e":
+				// Continue in $EDITOR for a longer message
+				m.textarea.Blur()
+				m.editErr = nil
+				m = m.withEditedMessage(m.textarea.Value())
+				m.state = stateConfirming
+				return m, openEditorCmd(m.editableMessage())
+			case "ctrl+o":
+				// Without the AI, commit now and let snap enrich describe it later
+				if m.providerErr == nil || !m.canQueue() {
+					var cmd tea.Cmd
+					m.textarea, cmd = m.textarea.Update(msg)
+					return m, cmd
+				}
+				m.textarea.Blur()
+				m.editErr = nil
+				m.commitMessage = PlaceholderMessage()
+				m.body = ""
+				m.queued = true
+				m.state = stateCommitting
+				return m, m.commitCmd()
+			case "ctrl+s":
+				// Accept the edited message; only the subject has a format
+				subject, _, _ := strings.Cut(strings.TrimSpace(m.textarea.Value()), "\n")
+				if strings.TrimSpace(subject) == "" {
+					m.editErr = fmt.Errorf("the subject line cannot be empty")
+					return m, nil
+				}
+				// git writes merge subjects its own way
+				if err := CheckConvention(subject, config.Convention); err != nil && !m.merging {
+					m.editErr = fmt.Errorf("subject: %v", err)
+					return m, nil
 				}
+				m.textarea.Blur()
+				m.editErr = nil
+				m = m.withEditedMessage(m.textarea.Value())
 				m.state = stateCommitting
-				return m, commitChanges(m.commitMessage)
+				return m, m.commitCmd()
 			default:
 				var cmd tea.Cmd
-				m.textInput, cmd = m.textInput.Update(msg)
+				m.textarea, cmd = m.textarea.Update(msg)
 				return m, cmd
 			}
 		}
 
+		// Pick the files to commit
+		if m.state == stateSelectingFiles {
+			switch msg.String() {
+			case "up", "k":
+				if m.fileCursor > 0 {
+					m.fileCursor--
+				}
+			case "down", "j":
+				if m.fileCursor < len(m.files)-1 {
+					m.fileCursor++
+				}
+			case " ", "x":
+				m.fileSelected[m.fileCursor] = !m.fileSelected[m.fileCursor]
+			case "a":
+				// Select all, or none when everything is selected already
+				all := !slices.Contains(m.fileSelected, false)
+				for i := range m.fileSelected {
+					m.fileSelected[i] = !all
+				}
+			case "enter":
+				if !slices.Contains(m.fileSelected, true) {
+					return m, nil
+				}
+				var unselected []StatusEntry
+				for i, entry := range m.files {
+					if !m.fileSelected[i] {
+						unselected = append(unselected, entry)
+					}
+				}
+				m.state = stateStaging
+				return m, stageSelected(unselected)
+			case "ctrl+c", "q", "esc":
+				m.state = stateDone
+				m.err = fmt.Errorf("commit cancelled")
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		// Pick the co-authors to credit, then go back to confirming
+		if m.state == stateSelectingCoAuthors {
+			switch msg.String() {
+			case "up", "k":
+				if m.coAuthorIdx > 0 {
+					m.coAuthorIdx--
+				}
+			case "down", "j":
+				if m.coAuthorIdx < len(m.coAuthors)-1 {
+					m.coAuthorIdx++
+				}
+			case " ", "x":
+				m.coAuthorOn[m.coAuthorIdx] = !m.coAuthorOn[m.coAuthorIdx]
+			case "enter", "esc", "a":
+				m.state = stateConfirming
+			case "ctrl+c":
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		// Offer to pull a missing Ollama model
+		if m.state == stateConfirmingPull {
+			switch msg.String() {
+			case "y", "Y", "enter":
+				ctx, cancel := context.WithCancel(context.Background())
+				m.cancelPull = cancel
+				m.updates = make(chan tea.Msg)
+				m.state = statePulling
+				return m, pullModel(ctx, m.updates)
+			case "n", "N", "q", "esc":
+				m.providerErr = m.missingModel
+				return m.writeManually()
+			case "ctrl+c":
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+		// Offer to ignore junk instead of staging it
+		if m.state == stateConfirmingJunk {
+			switch msg.String() {
+			case "i", "I", "enter":
+				m.state = stateStaging
+				return m, ignoreJunk(m.junk)
+			case "s", "S":
+				m.state = stateStaging
+				return m, skipJunk(m.junk)
+			case "a", "A":
+				m.state = stateStaging
+				return m, stageChanges
+			case "ctrl+c", "q", "esc":
+				m.state = stateDone
+				m.err = fmt.Errorf("commit cancelled")
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+		if m.state == statePulling {
+			switch msg.String() {
+			case "ctrl+c", "q", "esc":
+				m.cancelPull()
+			}
+			return m, nil
+		}
+
+		// Cancel a running generation
+		if m.state == stateGenerating {
+			switch msg.String() {
+			case "ctrl+c", "q", "esc":
+				if m.cancelGen != nil {
+					m.cancelGen()
+				}
+				m.state = stateDone
+				m.err = fmt.Errorf("generation cancelled")
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		// Scroll through the staged diff
+		if m.state == stateConfirming && m.showDiff {
+			switch msg.String() {
+			case "ctrl+c":
+				m.state = stateDone
+				m.err = fmt.Errorf("commit cancelled")
+				return m, tea.Quit
+			case "d", "q", "esc":
+				m.showDiff = false
+			case "g":
+				m.diffView.GotoTop()
+			case "G":
+				m.diffView.GotoBottom()
+			default:
+				var cmd tea.Cmd
+				m.diffView, cmd = m.diffView.Update(msg)
+				return m, cmd
+			}
+			return m, nil
+		}
+
+		// Pick between generated candidates
+		if m.state == stateConfirming && len(m.candidates) > 1 {
+			switch msg.String() {
+			case "up", "k":
+				m.candidateIdx = (m.candidateIdx + len(m.candidates) - 1) % len(m.candidates)
+				m.commitMessage = m.candidates[m.candidateIdx]
+				return m, nil
+			case "down", "j", "tab":
+				m.candidateIdx = (m.candidateIdx + 1) % len(m.candidates)
+				m.commitMessage = m.candidates[m.candidateIdx]
+				return m, nil
+			case "enter":
+				m.state = stateCommitting
+				return m, m.commitCmd()
+			case "1", "2", "3", "4", "5":
+				if i := int(msg.String()[0] - '1'); i < len(m.candidates) {
+					m.candidateIdx = i
+					m.commitMessage = m.candidates[i]
+				}
+				return m, nil
+			}
+		}
+
 		// Handle confirmation state
 		switch msg.String() {
 		case "ctrl+c", "q":
@@ -185,7 +574,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "y", "Y":
 			if m.state == stateConfirming {
 				m.state = stateCommitting
-				return m, commitChanges(m.commitMessage)
+				return m, m.commitCmd()
 			}
 
 		case "n", "N":
@@ -195,31 +584,182 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tea.Quit
 			}
 
-		case "e", "E":
+		case "f", "F":
+			if m.state == stateConfirming && m.canFixWhitespace() && !m.fixingSpace {
+				m.fixingSpace = true
+				m.spaceErr = nil
+				return m, fixWhitespace(m.whitespace, m.amend, m.paths)
+			}
+
+		case "u", "U":
+			if m.state == stateConfirming && m.canSkipLarge() && !m.skippingLarge {
+				m.skippingLarge = true
+				m.largeErr = nil
+				return m, skipLarge(m.large)
+			}
+
+		case "l", "L":
+			if m.state == stateConfirming && len(m.large) > 0 {
+				m.showLFS = !m.showLFS
+				return m, nil
+			}
+
+		case "b", "B":
+			// Toggle the body, generating it on first use
+			if m.state == stateConfirming && m.generatedMsg && !m.bodyLoading {
+				if m.body != "" {
+					m.includeBody = !m.includeBody
+					return m, nil
+				}
+				m.bodyLoading = true
+				m.bodyErr = nil
+				return m, generateBody(m.diff, m.seed)
+			}
+
+		case "d", "D":
+			if m.state == stateConfirming && m.diff != "" {
+				m.diffView = m.newDiffView()
+				m.diffView.SetContent(renderColoredDiff(m.diff))
+				m.showDiff = true
+				return m, nil
+			}
+
+		case "a", "A":
+			if m.state == stateConfirming && len(m.coAuthors) > 0 {
+				m.state = stateSelectingCoAuthors
+				return m, nil
+			}
+
+		case "s", "S":
+			// Move the commit off the protected branch
+			if m.state == stateConfirming && m.protected != "" {
+				m.newBranch = SuggestBranchName(m.commitMessage)
+				m.switchErr = nil
+				return m, saveBranchCmd(m.newBranch)
+			}
+
+		case "E":
 			if m.state == stateConfirming {
-				// Enter edit mode
+				return m, openEditorCmd(m.editableMessage())
+			}
+
+		case "e":
+			if m.state == stateConfirming && config.Save.Editor {
+				return m, openEditorCmd(m.editableMessage())
+			}
+			if m.state == stateConfirming {
+				// Enter edit mode with the subject and the shown body
 				m.originalMsg = m.commitMessage
-				m.textInput.SetValue(m.commitMessage)
-				m.textInput.Focus()
+				value := m.commitMessage
+				if m.body != "" && m.includeBody {
+					value += "\n\n" + m.body
+				}
+				m.textarea.SetValue(value)
+				m.editErr = nil
 				m.state = stateEditing
-				return m, textinput.Blink
+				return m, m.textarea.Focus()
 			}
 		}
 
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		if m.showDiff {
+			m.diffView.Width = msg.Width - 4
+			m.diffView.Height = msg.Height - 4
+		}
+		return m, nil
+
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 
-	case checkOllamaMsg:
-		if !msg.running {
+	case editorFinishedMsg:
+		m.editErr = msg.err
+		message := strings.TrimSpace(msg.message)
+		if msg.err != nil {
+			return m, nil
+		}
+		if message == "" {
+			m.editErr = fmt.Errorf("the message was empty - kept the previous one")
+			return m, nil
+		}
+		// The edited text is the whole message, template included
+		m = m.withEditedMessage(message)
+		m.template = ""
+		m.candidates = nil
+		m.keptMsg = false
+		m.useCustomMsg = true
+		return m, nil
+
+	case saveBranchMsg:
+		if msg.err != nil {
+			m.switchErr = msg.err
+			m.newBranch = ""
+			return m, nil
+		}
+		m.protected = ""
+		m.state = stateCommitting
+		return m, m.commitCmd()
+
+	case checkProviderMsg:
+		if m.generatedMsg {
+			// The provider already answered
+			return m, nil
+		}
+		var missing ModelMissingError
+		switch {
+		case errors.As(msg.err, &missing):
+			m.missingModel = msg.err
+		case msg.err != nil:
+			// Still save, with a hand-written message
+			m.providerErr = msg.err
+		default:
+			m.providerReady = true
+			return m, nil
+		}
+		// Generation failed before the check could name the missing model,
+		// so offer the pull unless a message is already being typed
+		if m.state == stateEditing && m.genFailed && m.missingModel != nil && m.textarea.Value() == "" {
+			m.genFailed = false
+			m.providerErr = nil
+			m.textarea.Blur()
+			return m.generate()
+		}
+		// Staging carries on and generate picks this up, unless the diff was
+		// in first and a generation is already running
+		if m.state != stateGenerating {
+			return m, nil
+		}
+		m.cancelGen()
+		m.cancelGen = nil
+		m.partialMsg = ""
+		m.summarized = summarizeProgressMsg{}
+		return m.generate()
+
+	case junkMsg:
+		m.junk = msg.junk
+		m.state = stateConfirmingJunk
+		return m, nil
+
+	case changedFilesMsg:
+		if msg.err != nil {
 			m.state = stateError
-			m.err = fmt.Errorf("Ollama is not running. Please start Ollama first")
+			m.err = msg.err
 			return m, tea.Quit
 		}
-		m.ollamaRunning = true
-		m.state = stateStaging
-		return m, stageChanges
+		if len(msg.entries) < 2 {
+			// Nothing to choose between
+			return m, stageChanges
+		}
+		m.files = msg.entries
+		m.fileSelected = make([]bool, len(msg.entries))
+		for i := range m.fileSelected {
+			m.fileSelected[i] = true
+		}
+		m.state = stateSelectingFiles
+		return m, nil
 
 	case stageChangesMsg:
 		if msg.err != nil {
@@ -229,7 +769,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.stagedChanges = true
 		m.state = stateGettingDiff
-		return m, getDiff
+		return m, getDiff(m.amend, m.paths)
 
 	case getDiffMsg:
 		if msg.err != nil {
@@ -237,48 +777,189 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.err = msg.err
 			return m, tea.Quit
 		}
+		if strings.TrimSpace(msg.diff) == "" && m.allowEmpty {
+			m.empty = true
+			if m.useCustomMsg {
+				m.state = stateConfirming
+				return m, nil
+			}
+			m.providerErr = fmt.Errorf("an empty commit has no changes to describe")
+			return m.writeManually()
+		}
+		if strings.TrimSpace(msg.diff) == "" && m.merging {
+			// A merge that keeps HEAD's files still records its commit
+			if m.useCustomMsg {
+				m.state = stateConfirming
+				return m, nil
+			}
+			m.providerErr = fmt.Errorf("the merge changes nothing to describe")
+			return m.writeManually()
+		}
 		if strings.TrimSpace(msg.diff) == "" {
 			m.state = stateError
 			m.err = fmt.Errorf("no changes to commit")
+			if len(m.paths) > 0 {
+				m.err = fmt.Errorf("no changes in %s", pathsLabel(m.paths))
+			} else if config.Save.StagedOnly {
+				m.err = fmt.Errorf("nothing staged - stage changes with git add, or save without --staged-only")
+			}
 			return m, tea.Quit
 		}
 		m.diff = msg.diff
+		m.stats = msg.stats
+		m.owners = msg.owners
+		m.recent = msg.recent
+		m.redactions = diffRedactions(msg.diff)
+
+		m.findings = CheckDiff(msg.diff, config.Checks)
+		if blocking := blockingFindings(m.findings); len(blocking) > 0 {
+			m.state = stateError
+			m.err = checksError(blocking)
+			return m, tea.Quit
+		}
+		if config.Checks.Whitespace {
+			m.whitespace = CheckWhitespace(msg.diff)
+		}
+		m.large = CheckLargeFiles(msg.stats, config.Checks.MaxFileMB)
 
 		// If using custom message, skip AI generation
 		if m.useCustomMsg {
 			m.state = stateConfirming
 			return m, nil
 		}
+		return m.generate()
+
+	case pullModelMsg:
+		m.pull = msg
+		return m, waitForGenerate(m.updates)
+
+	case pullDoneMsg:
+		m.cancelPull()
+		if msg.err != nil {
+			m.providerErr = msg.err
+			if errors.Is(msg.err, context.Canceled) {
+				m.providerErr = fmt.Errorf("pull cancelled")
+			}
+		} else {
+			m.providerReady = true
+		}
+		return m.generate()
 
-		m.state = stateGenerating
-		return m, generateMessage(msg.diff, m.seed)
+	case generateTokenMsg:
+		if m.state != stateGenerating {
+			// Left over from a generation the provider check cancelled
+			return m, nil
+		}
+		m.partialMsg = msg.text
+		return m, waitForGenerate(m.updates)
+
+	case summarizeProgressMsg:
+		if m.state != stateGenerating {
+			return m, nil
+		}
+		m.summarized = msg
+		return m, waitForGenerate(m.updates)
 
 	case generateMsgMsg:
+		if m.state != stateGenerating {
+			return m, nil
+		}
+		if m.cancelGen != nil {
+			m.cancelGen()
+			m.cancelGen = nil
+		}
 		if msg.err != nil {
+			var missing ModelMissingError
+			if errors.As(msg.err, &missing) && !m.providerReady {
+				// Generation found the missing model before the provider check did
+				m.missingModel = msg.err
+				m.state = stateConfirmingPull
+				return m, nil
+			}
+			m.providerErr = msg.err
+			m.genFailed = true
+			return m.writeManually()
+		}
+
+		// Keep the candidates that follow the configured commit convention
+		m.candidates = nil
+		var invalid error
+		for _, message := range msg.messages {
+			cleanMsg := strings.TrimSpace(message)
+			if cleanMsg == "" {
+				invalid = fmt.Errorf("AI generated an empty commit message. Try again or use custom message")
+				continue
+			}
+			if err := CheckConvention(cleanMsg, config.Convention); err != nil {
+				invalid = fmt.Errorf("Invalid commit message %q: %v", cleanMsg, err)
+				continue
+			}
+			m.candidates = append(m.candidates, cleanMsg)
+		}
+		if len(m.candidates) == 0 {
 			m.state = stateError
-			m.err = msg.err
+			m.err = invalid
+			if m.err == nil {
+				m.err = fmt.Errorf("AI generated an empty commit message. Try again or use custom message")
+			}
 			return m, tea.Quit
 		}
 
-		// Validate message is not empty
-		cleanMsg := strings.TrimSpace(msg.message)
-		if cleanMsg == "" {
-			m.state = stateError
-			m.err = fmt.Errorf("AI generated an empty commit message. Try again or use custom message")
+		m.candidateIdx = 0
+		m.commitMessage = m.candidates[0]
+		m.generatedMsg = true
+		m.source = msg.source
+		m.body = msg.body
+		m.includeBody = msg.body != ""
+		m.bodyErr = msg.bodyErr
+		m.state = stateConfirming
+		return m, nil
+
+	case fixWhitespaceMsg:
+		m.fixingSpace = false
+		if msg.err != nil {
+			m.spaceErr = msg.err
+			return m, nil
+		}
+		if strings.TrimSpace(msg.diff) == "" {
+			m.state = stateDone
+			m.err = fmt.Errorf("nothing left to commit after fixing whitespace")
 			return m, tea.Quit
 		}
+		m.diff = msg.diff
+		m.stats = msg.stats
+		m.redactions = diffRedactions(msg.diff)
+		m.spaceFixed += msg.fixed
+		m.whitespace = CheckWhitespace(msg.diff)
+		return m, nil
 
-		// Validate message follows conventional commit format
-		parts := strings.Split(cleanMsg, ":")
-		if len(parts) < 2 || parts[0] == "" {
-			m.state = stateError
-			m.err = fmt.Errorf("Invalid commit message format: %q. Expected: type: description", cleanMsg)
+	case skipLargeMsg:
+		m.skippingLarge = false
+		if msg.err != nil {
+			m.largeErr = msg.err
+			return m, nil
+		}
+		if strings.TrimSpace(msg.diff) == "" {
+			m.state = stateDone
+			m.err = fmt.Errorf("nothing left to commit without the large files")
 			return m, tea.Quit
 		}
+		m.diff = msg.diff
+		m.stats = msg.stats
+		m.redactions = diffRedactions(msg.diff)
+		m.largeSkipped += msg.skipped
+		m.large = CheckLargeFiles(msg.stats, config.Checks.MaxFileMB)
+		m.showLFS = m.showLFS && len(m.large) > 0
+		if config.Checks.Whitespace {
+			m.whitespace = CheckWhitespace(msg.diff)
+		}
+		return m, nil
 
-		m.commitMessage = cleanMsg
-		m.generatedMsg = true
-		m.state = stateConfirming
+	case generateBodyMsg:
+		m.bodyLoading = false
+		m.body = msg.body
+		m.includeBody = msg.body != ""
+		m.bodyErr = msg.err
 		return m, nil
 
 	case commitMsg:
@@ -287,103 +968,911 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.err = msg.err
 			return m, tea.Quit
 		}
+		m.hookOutput = msg.output
+		if config.Save.Push {
+			m.sync = initialSyncModel(false, false)
+			m.sync.afterSave = true
+			m.state = stateSyncing
+			return m, m.sync.Init()
+		}
 		m.state = stateDone
 		return m, tea.Quit
 	}
 
-	return m, nil
+	return m, nil
+}
+
+func (m model) View() string {
+	switch m.state {
+	case stateChecking:
+		if m.useCustomMsg {
+			return fmt.Sprintf("%s Staging changes...", m.spinner.View())
+		}
+		return fmt.Sprintf("%s Checking %s and staging changes...", m.spinner.View(), CurrentProvider().Name())
+
+	case stateConfirmingPull:
+		return fmt.Sprintf("%s\n%s",
+			errorStyle.Render(fmt.Sprintf("✗ Model '%s' isn't on the Ollama server", config.Ollama.Model)),
+			highlightStyle.Render("Pull it now? (y)es, (n)o - write the message yourself:"),
+		)
+
+	case statePulling:
+		return m.renderPull()
+
+	case stateSelectingFiles:
+		return m.renderFileSelection()
+
+	case stateConfirmingJunk:
+		return m.renderJunk()
+
+	case stateStaging:
+		return fmt.Sprintf("%s Staging changes...", m.spinner.View())
+
+	case stateGettingDiff:
+		return fmt.Sprintf("%s Getting changes...", m.spinner.View())
+
+	case stateGenerating:
+		helpStyle := lipgloss.NewStyle().Foreground(colorMuted)
+		partial := strings.TrimSpace(m.partialMsg)
+		label := "Generating commit message..."
+		if n := config.Save.Candidates; n > 1 {
+			label = fmt.Sprintf("Generating %d commit messages...", n)
+		}
+		if p := m.summarized; p.total > 0 && p.done < p.total {
+			label = fmt.Sprintf("Summarizing %d/%d files...", p.done, p.total)
+		}
+		if partial == "" {
+			return fmt.Sprintf("%s %s %s", m.spinner.View(), label, helpStyle.Render("(Esc to cancel)"))
+		}
+		return fmt.Sprintf("%s %s %s\n\n%s",
+			m.spinner.View(),
+			label,
+			helpStyle.Render("(Esc to cancel)"),
+			lipgloss.NewStyle().Foreground(colorPrimary).Render(partial),
+		)
+
+	case stateConfirming:
+		if m.showDiff {
+			return m.renderDiffView()
+		}
+		// Compact inline confirmation
+		msgStyle := lipgloss.NewStyle().
+			Foreground(colorPrimary).
+			Bold(true)
+		debugStyle := lipgloss.NewStyle().
+			Foreground(colorMuted).
+			Italic(true)
+		helpStyle := lipgloss.NewStyle().
+			Foreground(colorMuted)
+
+		// Show message type for debugging
+		msgType := "Generated"
+		if m.keptMsg && m.merging {
+			msgType = "Merge"
+		} else if m.keptMsg {
+			msgType = "Previous"
+		} else if m.useCustomMsg {
+			msgType = "Custom"
+		}
+		label := fmt.Sprintf("[%s message]", msgType)
+		if msgType == "Generated" && m.source.name != "" {
+			label = fmt.Sprintf("[Generated message · %s]", m.source.name)
+		}
+
+		var s strings.Builder
+		s.WriteString("\n")
+		if len(m.stats) > 0 {
+			s.WriteString(helpStyle.Render(stagedSummary(m.stats)) + "\n\n")
+		}
+		if m.empty {
+			s.WriteString(helpStyle.Render("Empty commit - nothing changed (--allow-empty)") + "\n\n")
+		}
+		if m.merging {
+			s.WriteString(helpStyle.Render("Concluding the merge in progress") + "\n\n")
+		}
+		if m.protected != "" {
+			warningStyle := lipgloss.NewStyle().Foreground(colorWarning)
+			s.WriteString(warningStyle.Render(fmt.Sprintf("⚠ %s is a protected branch (save.protected) - press s to commit on a new branch instead", m.protected)) + "\n\n")
+		}
+		if m.switchErr != nil {
+			s.WriteString(errorStyle.Render(fmt.Sprintf("✗ Failed to create the branch: %v", m.switchErr)) + "\n\n")
+		}
+		if len(m.owners) > 0 {
+			s.WriteString(helpStyle.Render("👥 Review: "+ownersSummary(m.owners)) + "\n\n")
+		}
+		if len(m.redactions) > 0 && !m.useCustomMsg {
+			s.WriteString(helpStyle.Render("🔒 Redacted before sending to the AI: "+redactionReport(m.redactions)) + "\n\n")
+		}
+		s.WriteString(m.renderFindings())
+		if len(m.candidates) > 1 {
+			// Let the user pick between several generated messages
+			for i, candidate := range m.candidates {
+				if i == m.candidateIdx {
+					s.WriteString(msgStyle.Render(fmt.Sprintf("→ %d. %s", i+1, candidate)))
+				} else {
+					s.WriteString(helpStyle.Render(fmt.Sprintf("  %d. %s", i+1, candidate)))
+				}
+				s.WriteString("\n")
+			}
+			if m.source.name != "" {
+				s.WriteString(debugStyle.Render(fmt.Sprintf("[Generated messages · %s]", m.source.name)) + "\n")
+			}
+		} else {
+			s.WriteString(msgStyle.Render(m.commitMessage) + " " + debugStyle.Render(label))
+			s.WriteString("\n")
+		}
+		if m.source.failed != nil && !m.useCustomMsg {
+			warningStyle := lipgloss.NewStyle().Foreground(colorWarning)
+			s.WriteString(warningStyle.Render(fmt.Sprintf("⚠ Fell back to %s - %s", m.source.name, strings.ReplaceAll(m.source.failed.Error(), "\n", "; "))) + "\n")
+		}
+		if previous := FindDuplicateSubject(m.commitMessage, m.recent); previous != "" {
+			warningStyle := lipgloss.NewStyle().Foreground(colorWarning)
+			s.WriteString(warningStyle.Render(fmt.Sprintf("⚠ Same as a recent commit: %q - (e)dit to describe this change", previous)) + "\n")
+		}
+
+		// Show the generated body, or why there is none
+		switch {
+		case m.bodyLoading:
+			s.WriteString(fmt.Sprintf("\n%s Generating body...\n", m.spinner.View()))
+		case m.body != "" && m.includeBody:
+			s.WriteString("\n" + lipgloss.NewStyle().Foreground(colorText).Render(m.body) + "\n")
+		case m.body != "":
+			s.WriteString("\n" + debugStyle.Render("[body hidden]") + "\n")
+		case m.bodyErr != nil:
+			s.WriteString("\n" + errorStyle.Render(fmt.Sprintf("✗ Failed to generate body: %v", m.bodyErr)) + "\n")
+		}
+
+		if m.template != "" && !strings.Contains(m.commitMessage+"\n\n"+m.body, m.template) {
+			s.WriteString("\n" + lipgloss.NewStyle().Foreground(colorText).Render(m.template) + " " + debugStyle.Render("[commit.template]") + "\n")
+		}
+		if picked := m.pickedCoAuthors(); len(picked) > 0 {
+			s.WriteString("\n" + helpStyle.Render("🤝 Co-authored by "+strings.Join(picked, ", ")) + "\n")
+		}
+		if len(m.trailers) > 0 {
+			s.WriteString("\n" + helpStyle.Render(strings.Join(m.trailers, "\n")) + "\n")
+		}
+
+		s.WriteString(m.renderWhitespace())
+		s.WriteString(m.renderLargeFiles())
+		if m.editErr != nil {
+			s.WriteString("\n" + errorStyle.Render(fmt.Sprintf("✗ %v", m.editErr)) + "\n")
+		}
+
+		s.WriteString("\n")
+		options := "(y)es, (n)o, (e)dit, (E)ditor"
+		if config.Save.Editor {
+			options = "(y)es, (n)o, (e)dit in $EDITOR"
+		}
+		if m.diff != "" {
+			options += ", (d)iff"
+		}
+		if m.generatedMsg {
+			options += ", (b)ody"
+		}
+		if len(m.coAuthors) > 0 {
+			options += ", co-(a)uthors"
+		}
+		if m.canFixWhitespace() {
+			options += ", (f)ix whitespace"
+		}
+		if m.canSkipLarge() {
+			options += ", (u)nstage large files"
+		}
+		if len(m.large) > 0 {
+			options += ", (l)FS instructions"
+		}
+		if m.protected != "" {
+			options += ", (s)witch to " + BranchNameFor(m.commitMessage)
+		}
+		s.WriteString(highlightStyle.Render(options + ":"))
+		if len(m.candidates) > 1 {
+			s.WriteString(helpStyle.Render(" ↑/↓ or 1-" + fmt.Sprint(len(m.candidates)) + " to pick, Enter to commit"))
+		}
+		return s.String()
+
+	case stateSelectingCoAuthors:
+		return m.renderCoAuthorSelection()
+
+	case stateEditing:
+		helpStyle := lipgloss.NewStyle().Foreground(colorMuted)
+		var editErr string
+		if m.editErr != nil {
+			editErr = "\n" + errorStyle.Render(fmt.Sprintf("✗ %v", m.editErr))
+		}
+		if m.providerErr != nil {
+			warningStyle := lipgloss.NewStyle().Foreground(colorWarning)
+			help := "Write a commit message (Ctrl+S to commit, Ctrl+E for $EDITOR, Ctrl+C to cancel):"
+			if m.canQueue() {
+				help = "Write a commit message (Ctrl+S to commit, Ctrl+E for $EDITOR, Ctrl+C to cancel),\n" +
+					"or press Ctrl+O to commit with a placeholder that snap enrich rewrites later:"
+			}
+			return fmt.Sprintf("\n%s%s\n%s\n%s%s%s",
+				m.renderFindings(),
+				warningStyle.Render(fmt.Sprintf("⚠ No AI message: %s", m.providerErr)),
+				helpStyle.Render(help),
+				m.textarea.View(),
+				editErr,
+				m.renderTemplateHint(),
+			)
+		}
+		return fmt.Sprintf("\n%s\n%s%s",
+			helpStyle.Render("Edit commit message (Ctrl+S to commit, Ctrl+E for $EDITOR, Esc to go back):"),
+			m.textarea.View(),
+			editErr,
+		)
+
+	case stateCommitting:
+		if m.amend {
+			return fmt.Sprintf("%s Amending the last commit...", m.spinner.View())
+		}
+		if m.merging {
+			return fmt.Sprintf("%s Committing the merge...", m.spinner.View())
+		}
+		return fmt.Sprintf("%s Committing...", m.spinner.View())
+
+	case stateSyncing:
+		return m.renderCommitted() + "\n" + m.sync.View()
+
+	case stateDone:
+		if m.err != nil {
+			return errorStyle.Render(fmt.Sprintf("✗ %s", m.err))
+		}
+		return m.renderCommitted()
+
+	case stateError:
+		var commitErr *CommitError
+		if errors.As(m.err, &commitErr) {
+			return m.renderCommitError(commitErr)
+		}
+		return errorStyle.Render(fmt.Sprintf("✗ Error: %s", m.err))
+	}
+
+	return ""
+}
+
+// renderTemplateHint shows the commit.template text that will follow a
+// hand-written subject
+func (m model) renderTemplateHint() string {
+	if m.template == "" {
+		return ""
+	}
+	helpStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	return "\n\n" + helpStyle.Render("Added below from commit.template:") + "\n" + lipgloss.NewStyle().Foreground(colorText).Render(m.template)
+}
+
+// renderCommitted confirms the commit, after anything the hooks printed
+func (m model) renderCommitted() string {
+	done := "✓ Changes committed successfully!"
+	if m.amend {
+		done = "✓ Amended the last commit!"
+	} else if m.merging {
+		done = "✓ Merge committed successfully!"
+	}
+	if m.newBranch != "" {
+		done = fmt.Sprintf("✓ Created branch %s\n", m.newBranch) + done
+	}
+	if m.queued {
+		done += "\n" + lipgloss.NewStyle().Foreground(colorWarning).Render("⏳ Saved with a placeholder message - run snap enrich once the AI is back")
+	}
+	if m.hookOutput == "" {
+		return successStyle.Render(done)
+	}
+	return lipgloss.NewStyle().Foreground(colorMuted).Render(m.hookOutput) + "\n" + successStyle.Render(done)
+}
+
+// withoutQueue is the model committing the same thing without queueing it
+func (m model) withoutQueue() model {
+	m.queued = false
+	return m
+}
+
+// renderCommitError shows which hook stopped the commit and what it printed
+func (m model) renderCommitError(err *CommitError) string {
+	summary := *err
+	summary.Output = ""
+
+	var s strings.Builder
+	s.WriteString(errorStyle.Render(fmt.Sprintf("✗ Error: %s", summary.Error())))
+	s.WriteString("\n")
+	if err.Output != "" {
+		s.WriteString(boxStyle.BorderForeground(colorError).Padding(0, 1).Render(err.Output))
+		s.WriteString("\n")
+	}
+	if len(err.Hooks) > 0 && !m.noVerify {
+		s.WriteString(infoStyle.Render("Fix the problem and save again, or skip the hooks with snap save --no-verify"))
+		s.WriteString("\n")
+	}
+	return s.String()
+}
+
+// maxFindingsShown keeps long check results from pushing the prompt off screen
+const maxFindingsShown = 10
+
+// renderFindings lists the check warnings for the staged changes
+func (m model) renderFindings() string {
+	if len(m.findings) == 0 {
+		return ""
+	}
+	warningStyle := lipgloss.NewStyle().Foreground(colorWarning)
+	var s strings.Builder
+	for i, finding := range m.findings {
+		if i == maxFindingsShown {
+			s.WriteString(infoStyle.Render(fmt.Sprintf("  ... and %d more", len(m.findings)-i)) + "\n")
+			break
+		}
+		s.WriteString(warningStyle.Render("⚠ "+finding.String()) + "\n")
+	}
+	return s.String() + "\n"
+}
+
+// renderWhitespace lists the whitespace issues, or how the last fix went
+// canFixWhitespace tells whether f is offered. Fixing restages whole files,
+// which would pull in unstaged work with save.staged_only.
+func (m model) canFixWhitespace() bool {
+	return len(m.whitespace) > 0 && !config.Save.StagedOnly
+}
+
+func (m model) renderWhitespace() string {
+	warningStyle := lipgloss.NewStyle().Foreground(colorWarning)
+	var s strings.Builder
+	switch {
+	case m.fixingSpace:
+		s.WriteString(fmt.Sprintf("\n%s Fixing whitespace...\n", m.spinner.View()))
+	case m.spaceErr != nil:
+		s.WriteString("\n" + errorStyle.Render(fmt.Sprintf("✗ Failed to fix whitespace: %v", m.spaceErr)) + "\n")
+	case m.spaceFixed > 0 && len(m.whitespace) == 0:
+		s.WriteString("\n" + successStyle.Render(fmt.Sprintf("✓ Fixed %d whitespace issue(s) and restaged", m.spaceFixed)) + "\n")
+	}
+	if len(m.whitespace) > 0 && !m.fixingSpace {
+		s.WriteString("\n")
+		for i, issue := range m.whitespace {
+			if i == maxFindingsShown {
+				s.WriteString(infoStyle.Render(fmt.Sprintf("  ... and %d more", len(m.whitespace)-i)) + "\n")
+				break
+			}
+			s.WriteString(warningStyle.Render("⚠ "+issue.String()) + "\n")
+		}
+	}
+	return s.String()
+}
+
+// canSkipLarge tells whether u is offered. An amended commit keeps what HEAD
+// already has and --only commits read the working tree, so unstaging would
+// leave the files in the commit.
+func (m model) canSkipLarge() bool {
+	return len(m.large) > 0 && !m.amend && len(m.paths) == 0
+}
+
+// renderLargeFiles lists the staged files over checks.max_file_mb, and the
+// Git LFS commands for them after l
+func (m model) renderLargeFiles() string {
+	warningStyle := lipgloss.NewStyle().Foreground(colorWarning)
+	var s strings.Builder
+	switch {
+	case m.skippingLarge:
+		s.WriteString(fmt.Sprintf("\n%s Unstaging large files...\n", m.spinner.View()))
+	case m.largeErr != nil:
+		s.WriteString("\n" + errorStyle.Render(fmt.Sprintf("✗ Failed to unstage large files: %v", m.largeErr)) + "\n")
+	case m.largeSkipped > 0 && len(m.large) == 0:
+		s.WriteString("\n" + successStyle.Render(fmt.Sprintf("✓ Unstaged %d large file(s); add them to save.exclude to keep them out of later saves", m.largeSkipped)) + "\n")
+	}
+	if len(m.large) == 0 || m.skippingLarge {
+		return s.String()
+	}
+
+	s.WriteString("\n")
+	for _, file := range m.large {
+		s.WriteString(warningStyle.Render(fmt.Sprintf("⚠ %s, over the %d MB limit", file, config.Checks.MaxFileMB)) + "\n")
+	}
+	if m.showLFS {
+		s.WriteString("\n" + infoStyle.Render("To store them with Git LFS instead, cancel and run:") + "\n")
+		for _, line := range LFSInstructions(m.large) {
+			s.WriteString("  " + highlightStyle.Render(line) + "\n")
+		}
+		s.WriteString(infoStyle.Render("then snap save again.") + "\n")
+	}
+	return s.String()
+}
+
+// checksError explains findings that stop the commit
+func checksError(blocking []CheckFinding) error {
+	var lines []string
+	for i, finding := range blocking {
+		if i == maxFindingsShown {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", len(blocking)-i))
+			break
+		}
+		lines = append(lines, "  "+finding.String())
+	}
+	hint := "Fix them (conflicts: 'snap resolve'), or commit anyway with 'snap save --skip-checks'"
+	if slices.ContainsFunc(blocking, func(f CheckFinding) bool { return f.Secret }) {
+		hint += "\nTake secrets out of the change (and rotate any that were pushed), or commit them anyway with 'snap save --allow-secrets'"
+	}
+	return fmt.Errorf("the staged changes didn't pass the [checks]:\n%s\n%s", strings.Join(lines, "\n"), hint)
+}
+
+// pullBarWidth is the width of the model download bar in cells
+const pullBarWidth = 30
+
+// renderPull shows the pull status and, while a layer downloads, a bar for it
+func (m model) renderPull() string {
+	helpStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	status := m.pull.status
+	if status == "" {
+		status = "starting"
+	}
+
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf("%s Pulling %s: %s %s", m.spinner.View(), config.Ollama.Model, status, helpStyle.Render("(Esc to cancel)")))
+	if total := m.pull.total; total > 0 {
+		filled := int(min(m.pull.completed, total) * pullBarWidth / total)
+		s.WriteString("\n  ")
+		s.WriteString(lipgloss.NewStyle().Foreground(colorPrimary).Render(strings.Repeat("█", filled)))
+		s.WriteString(helpStyle.Render(strings.Repeat("░", pullBarWidth-filled)))
+		s.WriteString(infoStyle.Render(fmt.Sprintf(" %d%% of %s", m.pull.completed*100/total, formatBytes(total))))
+	}
+	return s.String()
+}
+
+// formatBytes renders a size like "1.9 GB"
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
+
+// writeManually asks for a hand-written message when the AI provider failed
+// canQueue reports whether the commit can take a placeholder for snap enrich,
+// which has nothing to describe in an empty commit and can't reword merges
+func (m model) canQueue() bool {
+	return !m.empty && !m.merging
+}
+
+func (m model) writeManually() (tea.Model, tea.Cmd) {
+	m.originalMsg = ""
+	m.textarea.SetValue("")
+	m.state = stateEditing
+	return m, m.textarea.Focus()
+}
+
+// generate starts the AI message for the staged diff. The provider check
+// runs alongside staging, so it may not have answered yet: then generation,
+// and the summaries of a large diff, start right away, and a failing check
+// cancels them.
+func (m model) generate() (tea.Model, tea.Cmd) {
+	if m.missingModel != nil && !m.providerReady && m.providerErr == nil {
+		m.state = stateConfirmingPull
+		return m, nil
+	}
+	if m.providerErr != nil {
+		return m.writeManually()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelGen = cancel
+	m.updates = make(chan tea.Msg)
+	m.state = stateGenerating
+	return m, generateMessage(ctx, m.diff, m.seed, config.Save.Candidates, config.Save.Body, m.updates)
+}
+
+func checkProvider() tea.Msg {
+	return checkProviderMsg{err: CurrentProvider().Check()}
+}
+
+// pullModel pulls the configured Ollama model in the background, sending
+// pullModelMsg progress on updates and a final pullDoneMsg
+func pullModel(ctx context.Context, updates chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		go func() {
+			provider := ollamaProvider{baseURL: config.Ollama.URL, model: config.Ollama.Model}
+			err := provider.Pull(ctx, func(status string, completed, total int64) {
+				select {
+				case updates <- pullModelMsg{status: status, completed: completed, total: total}:
+				case <-ctx.Done():
+				}
+			})
+			updates <- pullDoneMsg{err: err}
+		}()
+		return <-updates
+	}
+}
+
+func fixWhitespace(issues []WhitespaceIssue, amend bool, paths []string) tea.Cmd {
+	return func() tea.Msg {
+		if err := FixWhitespace(issues); err != nil {
+			return fixWhitespaceMsg{err: err}
+		}
+		diff, stats, err := stagedDiff(amend, paths)
+		return fixWhitespaceMsg{diff: diff, stats: stats, fixed: len(issues), err: err}
+	}
+}
+
+func skipLarge(files []LargeFile) tea.Cmd {
+	return func() tea.Msg {
+		if err := SkipLargeFiles(files); err != nil {
+			return skipLargeMsg{err: err}
+		}
+		diff, stats, err := stagedDiff(false, nil)
+		return skipLargeMsg{diff: diff, stats: stats, skipped: len(files), err: err}
+	}
+}
+
+func stageChanges() tea.Msg {
+	err := StageAllChanges(config.Save.Exclude...)
+	return stageChangesMsg{err: err}
+}
+
+// startStaging stages everything, or first lists the changed files to pick
+// from when save.select is set. With save.staged_only, the index is left as
+// it is; with paths, only they are staged.
+func (m model) startStaging() tea.Cmd {
+	if len(m.paths) > 0 {
+		return stagePaths(m.paths)
+	}
+	if config.Save.StagedOnly {
+		return func() tea.Msg { return stageChangesMsg{} }
+	}
+	if config.Save.Select {
+		return loadChangedFiles
+	}
+	return findJunk
+}
+
+// findJunk looks for save.junk paths before staging everything. Not being
+// able to list them doesn't stop the save.
+func findJunk() tea.Msg {
+	junk, err := FindJunk(config.Save.Junk, config.Save.Exclude)
+	if err != nil || len(junk) == 0 {
+		return stageChanges()
+	}
+	return junkMsg{junk: junk}
+}
+
+// ignoreJunk adds the junk's patterns to .gitignore, then stages the rest
+// along with the .gitignore change
+func ignoreJunk(junk []JunkPath) tea.Cmd {
+	return func() tea.Msg {
+		if err := AddToGitignore(junkPatterns(junk)); err != nil {
+			return stageChangesMsg{err: fmt.Errorf("failed to update .gitignore: %w", err)}
+		}
+		return stageChanges()
+	}
+}
+
+// skipJunk stages everything but the junk, this time only
+func skipJunk(junk []JunkPath) tea.Cmd {
+	return func() tea.Msg {
+		exclude := slices.Clone(config.Save.Exclude)
+		for _, j := range junk {
+			exclude = append(exclude, strings.TrimSuffix(j.Path, "/"))
+		}
+		return stageChangesMsg{err: StageAllChanges(exclude...)}
+	}
+}
+
+// renderJunk lists the junk about to be staged and asks what to do with it
+func (m model) renderJunk() string {
+	warningStyle := lipgloss.NewStyle().Foreground(colorWarning)
+	var s strings.Builder
+	s.WriteString(warningStyle.Render("⚠ These untracked paths look like dependencies, build output or OS clutter:") + "\n")
+	for i, j := range m.junk {
+		if i == maxFindingsShown {
+			s.WriteString(infoStyle.Render(fmt.Sprintf("  ... and %d more", len(m.junk)-i)) + "\n")
+			break
+		}
+		s.WriteString("  " + j.Path + "\n")
+	}
+	s.WriteString("\n" + infoStyle.Render("Adds to .gitignore: "+strings.Join(junkPatterns(m.junk), " ")) + "\n\n")
+	s.WriteString(highlightStyle.Render("(i)gnore them, (s)kip them this time, stage (a)nyway, (q)uit:"))
+	return s.String()
+}
+
+func loadChangedFiles() tea.Msg {
+	entries, err := GetStatusEntries(true)
+	if err == nil {
+		entries, err = FilterExcluded(entries, config.Save.Exclude)
+	}
+	return changedFilesMsg{entries: entries, err: err}
+}
+
+func stagePaths(paths []string) tea.Cmd {
+	return func() tea.Msg {
+		return stageChangesMsg{err: StagePaths(paths)}
+	}
+}
+
+func stageSelected(unselected []StatusEntry) tea.Cmd {
+	return func() tea.Msg {
+		return stageChangesMsg{err: StageSelected(unselected, config.Save.Exclude)}
+	}
+}
+
+// renderCoAuthorSelection shows the checkbox list of co-authors
+func (m model) renderCoAuthorSelection() string {
+	nameStyle := lipgloss.NewStyle().Foreground(colorText)
+	cursorStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Co-authors"))
+	s.WriteString("\n")
+	for i, coAuthor := range m.coAuthors {
+		cursor := "  "
+		style := nameStyle
+		if i == m.coAuthorIdx {
+			cursor = cursorStyle.Render("→ ")
+			style = cursorStyle
+		}
+		box := infoStyle.Render("[ ]")
+		if m.coAuthorOn[i] {
+			box = successStyle.Render("[x]")
+		}
+		s.WriteString(fmt.Sprintf("%s%s %s\n", cursor, box, style.Render(coAuthor)))
+	}
+	s.WriteString("\n")
+	s.WriteString(infoStyle.Render("space: toggle  enter: done"))
+	s.WriteString("\n")
+	return s.String()
 }
 
-func (m model) View() string {
-	switch m.state {
-	case stateChecking:
-		if m.useCustomMsg {
-			return fmt.Sprintf("%s Staging changes...", m.spinner.View())
+// renderFileSelection shows the checkbox list of changed files
+func (m model) renderFileSelection() string {
+	nameStyle := lipgloss.NewStyle().Foreground(colorText)
+	cursorStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	codeStyle := lipgloss.NewStyle().Foreground(colorModified)
+
+	count := 0
+	for _, selected := range m.fileSelected {
+		if selected {
+			count++
 		}
-		return fmt.Sprintf("%s Checking Ollama...", m.spinner.View())
+	}
 
-	case stateStaging:
-		return fmt.Sprintf("%s Staging changes...", m.spinner.View())
+	var s strings.Builder
+	s.WriteString(titleStyle.Render(fmt.Sprintf("Select files to save (%d of %d)", count, len(m.files))))
+	s.WriteString("\n")
+	for i, entry := range m.files {
+		cursor := "  "
+		style := nameStyle
+		if i == m.fileCursor {
+			cursor = cursorStyle.Render("→ ")
+			style = cursorStyle
+		}
+		box := infoStyle.Render("[ ]")
+		if m.fileSelected[i] {
+			box = successStyle.Render("[x]")
+		}
+		s.WriteString(fmt.Sprintf("%s%s %s %s\n", cursor, box, codeStyle.Render(entry.Code), style.Render(entry.DisplayPath())))
+	}
+	s.WriteString("\n")
+	if count == 0 {
+		s.WriteString(highlightStyle.Render("Select at least one file") + "\n")
+	}
+	s.WriteString(infoStyle.Render("space: toggle  a: all/none  enter: continue  esc: cancel"))
+	s.WriteString("\n")
+	return s.String()
+}
 
-	case stateGettingDiff:
-		return fmt.Sprintf("%s Getting changes...", m.spinner.View())
+// newDiffView sizes the staged diff to the terminal; the size isn't known
+// when the program isn't attached to one
+func (m model) newDiffView() viewport.Model {
+	width, height := m.width, m.height
+	if width == 0 || height == 0 {
+		width, height = 80, 24
+	}
+	return viewport.New(width-4, height-4)
+}
 
-	case stateGenerating:
-		return fmt.Sprintf("%s Generating commit message...", m.spinner.View())
+// renderDiffView shows the staged changes that pressing y commits
+func (m model) renderDiffView() string {
+	helpStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Staged changes"))
+	if len(m.stats) > 0 {
+		s.WriteString(helpStyle.Render("  " + stagedSummary(m.stats)))
+	}
+	s.WriteString("\n\n")
+	s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(m.diffView.View()))
+	s.WriteString("\n")
+	s.WriteString(helpStyle.Render(fmt.Sprintf("%3.f%%  ↑/↓: scroll  pgup/pgdn: page  g/G: top/bottom  esc: back to the message",
+		m.diffView.ScrollPercent()*100)))
+	return s.String()
+}
 
-	case stateConfirming:
-		// Compact inline confirmation
-		msgStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#7D56F4")).
-			Bold(true)
-		debugStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#888888")).
-			Italic(true)
-		helpStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#888888"))
+func getDiff(amend bool, paths []string) tea.Cmd {
+	return func() tea.Msg {
+		diff, stats, err := stagedDiff(amend, paths)
+		return getDiffMsg{diff: diff, stats: stats, owners: stagedOwners(stats), recent: recentSubjects(amend), err: err}
+	}
+}
 
-		// Show message type for debugging
-		msgType := "Generated"
-		if m.useCustomMsg {
-			msgType = "Custom"
+// stagedDiff returns the staged changes, or what the amended commit will
+// contain in total when amending, or only the changes below paths
+func stagedDiff(amend bool, paths []string) (string, []FileDiffStat, error) {
+	if len(paths) > 0 {
+		diff, err := GetPathsDiff(paths)
+		if err != nil {
+			return "", nil, err
 		}
+		stats, _ := GetPathsFileStats(paths)
+		return diff, stats, nil
+	}
+	if amend {
+		diff, err := GetAmendDiff()
+		if err != nil {
+			return "", nil, err
+		}
+		stats, _ := GetAmendFileStats()
+		return diff, stats, nil
+	}
+	diff, err := GetGitDiff()
+	if err != nil {
+		return "", nil, err
+	}
+	stats, _ := GetStagedFileStats()
+	return diff, stats, nil
+}
 
-		return fmt.Sprintf("\n%s %s\n\n%s %s",
-			msgStyle.Render(m.commitMessage),
-			debugStyle.Render(fmt.Sprintf("[%s message]", msgType)),
-			highlightStyle.Render("(y)es, (n)o, (e)dit:"),
-			helpStyle.Render(""),
-		)
+// generateMessage starts generating n candidates, and the body when withBody
+// is set, in the background. The first candidate's completion so far arrives
+// on updates as generateTokenMsg, followed by a final generateMsgMsg.
+func generateMessage(ctx context.Context, diff string, seed, n int, withBody bool, updates chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		go func() {
+			var body string
+			var bodyErr error
+			bodyDone := make(chan struct{})
+			go func() {
+				defer close(bodyDone)
+				if withBody {
+					body, bodyErr = GenerateCommitBody(diff, seed)
+				}
+			}()
+
+			var partial strings.Builder
+			messages, source, err := GenerateCommitMessageCandidates(ctx, diff, seed, n, func(token string) {
+				partial.WriteString(token)
+				select {
+				case updates <- generateTokenMsg{text: partial.String()}:
+				case <-ctx.Done():
+				}
+			}, func(done, total int) {
+				select {
+				case updates <- summarizeProgressMsg{done: done, total: total}:
+				case <-ctx.Done():
+				}
+			})
+			<-bodyDone
+			select {
+			case updates <- generateMsgMsg{messages: messages, source: source, body: body, bodyErr: bodyErr, err: err}:
+			case <-ctx.Done():
+			}
+		}()
+		return <-updates
+	}
+}
 
-	case stateEditing:
-		return fmt.Sprintf("\n%s\n%s",
-			lipgloss.NewStyle().Foreground(lipgloss.Color("#888888")).Render("Edit commit message (Enter to save, Ctrl+C to cancel):"),
-			m.textInput.View(),
-		)
+func generateBody(diff string, seed int) tea.Cmd {
+	return func() tea.Msg {
+		body, err := GenerateCommitBody(diff, seed)
+		return generateBodyMsg{body: body, err: err}
+	}
+}
 
-	case stateCommitting:
-		return fmt.Sprintf("%s Committing...", m.spinner.View())
+// fullMessage is the commit message with the body when it is included
+func (m model) fullMessage() string {
+	message := strings.TrimSuffix(m.editableMessage(), "\n")
+	return AddTrailers(AddCoAuthors(message, m.pickedCoAuthors()), m.trailers)
+}
 
-	case stateDone:
-		if m.err != nil {
-			return errorStyle.Render(fmt.Sprintf("✗ %s", m.err))
-		}
-		return successStyle.Render("✓ Changes committed successfully!")
+// withEditedMessage splits an edited message into the subject and the body
+func (m model) withEditedMessage(message string) model {
+	subject, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	m.commitMessage = strings.TrimSpace(subject)
+	m.body = strings.TrimSpace(body)
+	m.includeBody = m.body != ""
+	return m
+}
 
-	case stateError:
-		return errorStyle.Render(fmt.Sprintf("✗ Error: %s", m.err))
+// editableMessage is what $EDITOR opens: the message with its body and the
+// commit.template text. Co-authors and trailers are still added afterwards.
+func (m model) editableMessage() string {
+	message := m.commitMessage
+	if m.includeBody && m.body != "" {
+		message += "\n\n" + m.body
 	}
+	return ApplyCommitTemplate(message, m.template) + "\n"
+}
 
-	return ""
+// pickedCoAuthors are the co-authors checked in the list
+func (m model) pickedCoAuthors() []string {
+	var picked []string
+	for i, coAuthor := range m.coAuthors {
+		if m.coAuthorOn[i] {
+			picked = append(picked, coAuthor)
+		}
+	}
+	return picked
 }
 
-func checkOllama() tea.Msg {
-	running := CheckOllamaRunning()
-	return checkOllamaMsg{running: running}
+// waitForGenerate waits for the next update of a running generation
+func waitForGenerate(updates chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
 }
 
-func stageChanges() tea.Msg {
-	err := StageAllChanges()
-	return stageChangesMsg{err: err}
+func commitChanges(message string, noVerify bool) tea.Cmd {
+	return func() tea.Msg {
+		output, err := CommitChanges(message, noVerify)
+		return commitMsg{output: output, err: err}
+	}
 }
 
-func getDiff() tea.Msg {
-	diff, err := GetGitDiff()
-	return getDiffMsg{diff: diff, err: err}
+func commitEmpty(message string, noVerify bool) tea.Cmd {
+	return func() tea.Msg {
+		output, err := CommitEmpty(message, noVerify)
+		return commitMsg{output: output, err: err}
+	}
 }
 
-func generateMessage(diff string, seed int) tea.Cmd {
+func commitPaths(message string, paths []string, noVerify bool) tea.Cmd {
 	return func() tea.Msg {
-		message, err := GenerateCommitMessage(diff, seed)
-		return generateMsgMsg{message: message, err: err}
+		output, err := CommitPaths(message, paths, noVerify)
+		return commitMsg{output: output, err: err}
+	}
+}
+
+func amendChanges(message string, noVerify bool) tea.Cmd {
+	return func() tea.Msg {
+		output, err := AmendCommit(message, noVerify)
+		return commitMsg{output: output, err: err}
 	}
 }
 
-func commitChanges(message string) tea.Cmd {
+// saveBranchMsg reports the new branch the commit goes on
+type saveBranchMsg struct {
+	err error
+}
+
+// saveBranchCmd creates a branch and switches to it, keeping the staged
+// changes for the commit
+func saveBranchCmd(branch string) tea.Cmd {
 	return func() tea.Msg {
-		err := CommitChanges(message)
-		return commitMsg{err: err}
+		return saveBranchMsg{err: CreateAndSwitchBranch(branch)}
+	}
+}
+
+// commitCmd commits the chosen message, or amends the last commit with it
+func (m model) commitCmd() tea.Cmd {
+	if m.queued {
+		commit := m.withoutQueue().commitCmd()
+		return func() tea.Msg {
+			msg := commit().(commitMsg)
+			if msg.err == nil {
+				if err := QueueForEnrich("HEAD"); err != nil {
+					msg.err = fmt.Errorf("committed, but failed to queue the commit for snap enrich: %w", err)
+				}
+			}
+			return msg
+		}
+	}
+	if m.amend {
+		return amendChanges(m.fullMessage(), m.noVerify)
 	}
+	if len(m.paths) > 0 {
+		return commitPaths(m.fullMessage(), m.paths, m.noVerify)
+	}
+	if m.empty {
+		return commitEmpty(m.fullMessage(), m.noVerify)
+	}
+	return commitChanges(m.fullMessage(), m.noVerify)
 }
 
 // Branch TUI model
@@ -393,6 +1882,7 @@ const (
 	branchStateList branchState = iota
 	branchStateCreating
 	branchStateSwitching
+	branchStateConfirmingDelete // Beginner mode: typing the branch name
 	branchStateDeleting
 	branchStateDone
 	branchStateError
@@ -408,6 +1898,8 @@ type branchModel struct {
 	err        error
 	mode       string // "list", "new", "switch", "delete"
 	branchName string
+	fromList   bool   // Deleting from the list, so esc goes back to it
+	confirmErr string // Why the typed name didn't confirm the delete
 	showHelp   bool
 	width      int
 	height     int
@@ -434,7 +1926,7 @@ type deleteBranchMsg struct {
 func initialBranchModel(mode string, branchName string) branchModel {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
 
 	ti := textinput.New()
 	ti.Placeholder = "Enter branch name..."
@@ -475,6 +1967,36 @@ func (m branchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		// Confirm a delete by typing the branch name
+		if m.state == branchStateConfirmingDelete {
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "esc":
+				if m.fromList {
+					m.mode = "list"
+					m.state = branchStateList
+					m.textInput.Blur()
+					return m, nil
+				}
+				m.state = branchStateError
+				m.err = fmt.Errorf("delete cancelled")
+				return m, tea.Quit
+			case "enter":
+				if strings.TrimSpace(m.textInput.Value()) != m.branchName {
+					m.confirmErr = fmt.Sprintf("type %s exactly to delete it", m.branchName)
+					return m, nil
+				}
+				m.textInput.Blur()
+				m.state = branchStateDeleting
+				return m, deleteBranchCmd(m.branchName)
+			default:
+				var cmd tea.Cmd
+				m.textInput, cmd = m.textInput.Update(msg)
+				return m, cmd
+			}
+		}
+
 		// Handle text input in creating mode
 		if m.state == branchStateCreating && m.mode == "new" {
 			switch msg.String() {
@@ -522,6 +2044,7 @@ func (m branchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Create new branch
 				m.mode = "new"
 				m.state = branchStateCreating
+				m.textInput.Placeholder = "Enter branch name..."
 				m.textInput.Focus()
 				return m, textinput.Blink
 			case "d":
@@ -533,9 +2056,8 @@ func (m branchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.err = fmt.Errorf("cannot delete current branch")
 						return m, tea.Quit
 					}
-					m.branchName = selectedBranch.Name
-					m.state = branchStateDeleting
-					return m, deleteBranchCmd(selectedBranch.Name)
+					m.fromList = true
+					return m.startDelete(selectedBranch.Name)
 				}
 			case "?":
 				m.showHelp = !m.showHelp
@@ -568,12 +2090,16 @@ func (m branchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case "switch":
 			// Switch to specified branch
+			if beginnerMode() && !slices.ContainsFunc(m.branches, func(b BranchInfo) bool { return b.Name == m.branchName }) {
+				m.state = branchStateError
+				m.err = fmt.Errorf("%s is not a local branch - checking it out would detach HEAD, which beginner mode hides", m.branchName)
+				return m, tea.Quit
+			}
 			m.state = branchStateSwitching
 			return m, switchToBranch(m.branchName)
 		case "delete":
 			// Delete specified branch
-			m.state = branchStateDeleting
-			return m, deleteBranchCmd(m.branchName)
+			return m.startDelete(m.branchName)
 		default:
 			// List mode - just display
 			m.state = branchStateList
@@ -621,15 +2147,15 @@ func (m branchModel) View() string {
 		var content strings.Builder
 
 		currentStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#04B575")).
+			Foreground(colorSuccess).
 			Bold(true)
 		normalStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFFFFF"))
+			Foreground(colorText)
 		cursorStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#7D56F4")).
+			Foreground(colorPrimary).
 			Bold(true)
 		dimStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#888888"))
+			Foreground(colorMuted)
 
 		for i, branch := range m.branches {
 			cursor := "  "
@@ -657,6 +2183,10 @@ func (m branchModel) View() string {
 				content.WriteString(fmt.Sprintf(" %s", dimStyle.Render(fmt.Sprintf("[%s]", branch.Upstream))))
 			}
 
+			if branch.Base != "" && (branch.Ahead > 0 || branch.Behind > 0) {
+				content.WriteString(fmt.Sprintf(" %s", infoStyle.Render(fmt.Sprintf("(%s %s)", driftSummary(branch.Ahead, branch.Behind), branch.Base))))
+			}
+
 			if branch.LastCommit != "" {
 				content.WriteString(fmt.Sprintf(" %s", dimStyle.Render(branch.LastCommit)))
 			}
@@ -679,7 +2209,7 @@ func (m branchModel) View() string {
 		var s strings.Builder
 		titleStyle := lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#7D56F4")).
+			Foreground(colorPrimary).
 			PaddingLeft(2)
 
 		s.WriteString(titleStyle.Render("Branches"))
@@ -694,12 +2224,12 @@ func (m branchModel) View() string {
 
 		if m.showHelp {
 			helpStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#888888")).
+				Foreground(colorMuted).
 				PaddingLeft(2)
 			s.WriteString(helpStyle.Render("↑/k: up  ↓/j: down  Enter: switch  n: new branch  d: delete  ?: help  q: quit"))
 		} else {
 			helpStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#888888")).
+				Foreground(colorMuted).
 				PaddingLeft(2)
 			s.WriteString(helpStyle.Render("Press ? for help"))
 		}
@@ -709,7 +2239,7 @@ func (m branchModel) View() string {
 	case branchStateCreating:
 		if m.mode == "new" && m.branchName == "" {
 			return fmt.Sprintf("\n%s\n%s\n",
-				lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4")).Bold(true).Render("Create new branch"),
+				lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Render("Create new branch"),
 				m.textInput.View(),
 			)
 		}
@@ -718,6 +2248,22 @@ func (m branchModel) View() string {
 	case branchStateSwitching:
 		return fmt.Sprintf("%s Switching to branch '%s'...", m.spinner.View(), m.branchName)
 
+	case branchStateConfirmingDelete:
+		var s strings.Builder
+		s.WriteString("\n")
+		s.WriteString(lipgloss.NewStyle().Foreground(colorDanger).Bold(true).Render(fmt.Sprintf("Delete branch '%s'?", m.branchName)))
+		s.WriteString("\n")
+		s.WriteString(infoStyle.Render("Type the branch name to confirm (beginner mode):"))
+		s.WriteString("\n")
+		s.WriteString(m.textInput.View())
+		s.WriteString("\n")
+		if m.confirmErr != "" {
+			s.WriteString(errorStyle.Render("✗ "+m.confirmErr) + "\n")
+		}
+		s.WriteString(infoStyle.Render("Enter: delete  Esc: cancel"))
+		s.WriteString("\n")
+		return s.String()
+
 	case branchStateDeleting:
 		return fmt.Sprintf("%s Deleting branch '%s'...", m.spinner.View(), m.branchName)
 
@@ -740,6 +2286,42 @@ func (m branchModel) View() string {
 	return ""
 }
 
+// ExitSummary keeps the branch change visible after the alt screen closes
+func (m branchModel) ExitSummary() string {
+	switch m.state {
+	case branchStateDone:
+		switch m.mode {
+		case "new":
+			return fmt.Sprintf("✓ Created and switched to branch %s", m.branchName)
+		case "delete":
+			return fmt.Sprintf("✓ Deleted branch %s", m.branchName)
+		}
+		if m.branchName != "" {
+			return fmt.Sprintf("✓ Switched to branch %s", m.branchName)
+		}
+	case branchStateError:
+		return fmt.Sprintf("✗ Error: %v", m.err)
+	}
+	return ""
+}
+
+// startDelete deletes a branch, or in beginner mode first asks for its name
+// to be typed
+func (m branchModel) startDelete(name string) (tea.Model, tea.Cmd) {
+	m.mode = "delete"
+	m.branchName = name
+	if !beginnerMode() {
+		m.state = branchStateDeleting
+		return m, deleteBranchCmd(name)
+	}
+	m.state = branchStateConfirmingDelete
+	m.confirmErr = ""
+	m.textInput.SetValue("")
+	m.textInput.Placeholder = name
+	m.textInput.Focus()
+	return m, textinput.Blink
+}
+
 func getBranches() tea.Msg {
 	branches, err := GetBranches()
 	return getBranchesMsg{branches: branches, err: err}
@@ -773,6 +2355,7 @@ const (
 	replayStateChecking replayState = iota
 	replayStateShowingCommits
 	replayStateConfirming
+	replayStateRewording // Generating the messages of commits to reword with AI
 	replayStateReplaying
 	replayStateConflict
 	replayStateDone
@@ -787,18 +2370,43 @@ type replayModel struct {
 	currentBranch string
 	commits       []CommitInfo
 	interactive   bool
+	updateRefs    bool
+	stacked       []string // Branches moved along with --update-refs
 	output        string
+	backupRef     string
 	cursor        int
+	progress      replayProgressMsg // Last progress reported by git
+	updates       chan tea.Msg      // Progress, then the replay result
+	reword        []bool            // Commits to reword with AI, by index in commits
+	rewordQueue   []string          // Hashes of the commits to reword, oldest first
+	rewording     int               // The commit in rewordQueue being described
+	messages      map[string]string // New messages of the reworded commits
+	rewordErrs    map[string]error  // Why a commit kept its message
 }
 
 type getReplayCommitsMsg struct {
 	commits []CommitInfo
+	stacked []string
 	err     error
 }
 
 type replayCommitsMsg struct {
-	output string
-	err    error
+	output    string
+	backupRef string // HEAD before the replay
+	err       error
+}
+
+// replayProgressMsg reports that git is applying commit current of total
+type replayProgressMsg struct {
+	current int
+	total   int
+}
+
+// replayRewordMsg carries the AI message for a commit to reword
+type replayRewordMsg struct {
+	hash    string
+	message string
+	err     error
 }
 
 type checkRebaseMsg struct {
@@ -806,16 +2414,17 @@ type checkRebaseMsg struct {
 	err        error
 }
 
-func initialReplayModel(ontoBranch string, interactive bool) replayModel {
+func initialReplayModel(ontoBranch string, interactive, updateRefs bool) replayModel {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
 
 	return replayModel{
 		state:       replayStateChecking,
 		spinner:     s,
 		ontoBranch:  ontoBranch,
 		interactive: interactive,
+		updateRefs:  updateRefs,
 	}
 }
 
@@ -833,8 +2442,19 @@ func (m replayModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.err = fmt.Errorf("replay cancelled")
 				return m, tea.Quit
 			case "y", "Y", "enter":
-				m.state = replayStateReplaying
-				return m, replayCommits(m.ontoBranch)
+				return m.startReplay()
+			case "up", "k":
+				if m.interactive && m.cursor < len(m.commits)-1 {
+					m.cursor++
+				}
+			case "down", "j":
+				if m.interactive && m.cursor > 0 {
+					m.cursor--
+				}
+			case "r":
+				if m.interactive {
+					m.reword[m.cursor] = !m.reword[m.cursor]
+				}
 			}
 		} else if m.state == replayStateConfirming {
 			switch msg.String() {
@@ -843,8 +2463,7 @@ func (m replayModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.err = fmt.Errorf("replay cancelled")
 				return m, tea.Quit
 			case "y", "Y":
-				m.state = replayStateReplaying
-				return m, replayCommits(m.ontoBranch)
+				return m.startReplay()
 			}
 		}
 
@@ -880,7 +2499,7 @@ func (m replayModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 
-		return m, getReplayCommitsCmd(m.ontoBranch)
+		return m, getReplayCommitsCmd(m.ontoBranch, m.updateRefs)
 
 	case getReplayCommitsMsg:
 		if msg.err != nil {
@@ -889,6 +2508,7 @@ func (m replayModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 		m.commits = msg.commits
+		m.stacked = msg.stacked
 
 		if len(msg.commits) == 0 {
 			m.state = replayStateError
@@ -896,10 +2516,39 @@ func (m replayModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 
+		m.reword = make([]bool, len(msg.commits))
+		m.cursor = len(msg.commits) - 1 // The oldest commit is listed first
 		m.state = replayStateShowingCommits
 		return m, nil
 
+	case checkProviderMsg:
+		if msg.err != nil {
+			m.state = replayStateError
+			m.err = fmt.Errorf("can't reword with AI: %w", msg.err)
+			return m, tea.Quit
+		}
+		return m, rewordWithAI(m.rewordQueue[0], config.Save.Seed)
+
+	case replayRewordMsg:
+		if msg.err != nil {
+			m.rewordErrs[msg.hash] = msg.err
+		} else {
+			m.messages[msg.hash] = msg.message
+		}
+		m.rewording++
+		if m.rewording < len(m.rewordQueue) {
+			return m, rewordWithAI(m.rewordQueue[m.rewording], config.Save.Seed)
+		}
+		m.state = replayStateReplaying
+		m.updates = make(chan tea.Msg)
+		return m, replayCommits(m.ontoBranch, m.updateRefs, m.messages, m.updates)
+
+	case replayProgressMsg:
+		m.progress = msg
+		return m, waitForReplay(m.updates)
+
 	case replayCommitsMsg:
+		m.backupRef = msg.backupRef
 		if msg.err != nil {
 			// Check if it's a conflict
 			if strings.Contains(msg.output, "CONFLICT") || strings.Contains(msg.output, "conflict") {
@@ -920,6 +2569,27 @@ func (m replayModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// startReplay replays right away, or first asks the AI for the messages of
+// the commits marked to reword
+func (m replayModel) startReplay() (tea.Model, tea.Cmd) {
+	m.rewordQueue = nil
+	for i := len(m.commits) - 1; i >= 0; i-- {
+		if m.reword[i] {
+			m.rewordQueue = append(m.rewordQueue, m.commits[i].Hash)
+		}
+	}
+	m.messages = map[string]string{}
+	m.rewordErrs = map[string]error{}
+	if len(m.rewordQueue) > 0 {
+		m.rewording = 0
+		m.state = replayStateRewording
+		return m, checkProvider
+	}
+	m.state = replayStateReplaying
+	m.updates = make(chan tea.Msg)
+	return m, replayCommits(m.ontoBranch, m.updateRefs, nil, m.updates)
+}
+
 func (m replayModel) View() string {
 	switch m.state {
 	case replayStateChecking:
@@ -930,41 +2600,62 @@ func (m replayModel) View() string {
 
 		titleStyle := lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#7D56F4")).
+			Foreground(colorPrimary).
 			PaddingLeft(2)
 
 		s.WriteString(titleStyle.Render(fmt.Sprintf("Replay commits from '%s' onto '%s'", m.currentBranch, m.ontoBranch)))
 		s.WriteString("\n\n")
 
 		infoStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#888888")).
+			Foreground(colorMuted).
 			PaddingLeft(2)
 		s.WriteString(infoStyle.Render(fmt.Sprintf("The following %d commit(s) will be replayed:", len(m.commits))))
 		s.WriteString("\n\n")
 
-		commitStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4")).Bold(true)
-		hashStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
-		timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+		commitStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+		hashStyle := lipgloss.NewStyle().Foreground(colorMuted)
+		timeStyle := lipgloss.NewStyle().Foreground(colorMuted)
 		contentStyle := lipgloss.NewStyle().PaddingLeft(2)
 
 		// Show commits in reverse order (oldest first, as they'll be applied)
 		var content strings.Builder
 		for i := len(m.commits) - 1; i >= 0; i-- {
 			commit := m.commits[i]
+			message := commit.Message
+			if m.interactive && i == m.cursor {
+				message = highlightStyle.Render(message)
+			}
 			content.WriteString(fmt.Sprintf("%s %s %s\n",
 				commitStyle.Render("●"),
-				commit.Message,
+				message,
 				timeStyle.Render(fmt.Sprintf("(%s)", commit.RelativeTime)),
 			))
-			content.WriteString(fmt.Sprintf("  %s\n", hashStyle.Render(commit.ShortHash)))
+			action := ""
+			if m.interactive && m.reword[i] {
+				action = " " + lipgloss.NewStyle().Foreground(colorWarning).Render("✎ reword with AI")
+			}
+			content.WriteString(fmt.Sprintf("  %s%s\n", hashStyle.Render(commit.ShortHash), action))
 			if i > 0 {
 				content.WriteString(commitStyle.Render("│") + "\n")
 			}
 		}
 		s.WriteString(contentStyle.Render(content.String()))
 
+		if m.updateRefs {
+			s.WriteString("\n")
+			if len(m.stacked) > 0 {
+				s.WriteString(infoStyle.Render("Stacked branches moved along: ") + highlightStyle.Render(strings.Join(m.stacked, ", ")))
+			} else {
+				s.WriteString(infoStyle.Render("No other branches point at these commits"))
+			}
+			s.WriteString("\n")
+		}
+
 		s.WriteString("\n")
 		promptStyle := lipgloss.NewStyle().PaddingLeft(2)
+		if m.interactive {
+			s.WriteString(infoStyle.Render("↑/↓ to move, r to reword the commit with an AI message from its diff") + "\n")
+		}
 		s.WriteString(promptStyle.Render(highlightStyle.Render("Proceed with replay? (y/n): ")))
 
 		return s.String()
@@ -975,24 +2666,39 @@ func (m replayModel) View() string {
 			highlightStyle.Render("(y)es or (n)o: "),
 		)
 
+	case replayStateRewording:
+		hash := m.rewordQueue[min(m.rewording, len(m.rewordQueue)-1)]
+		return fmt.Sprintf("%s Rewording %s with AI (%d/%d)...",
+			m.spinner.View(), shortHash(hash), min(m.rewording+1, len(m.rewordQueue)), len(m.rewordQueue))
+
 	case replayStateReplaying:
-		return fmt.Sprintf("%s Replaying commits onto '%s'...", m.spinner.View(), m.ontoBranch)
+		status := fmt.Sprintf("%s Replaying commits onto '%s'...", m.spinner.View(), m.ontoBranch)
+		if m.progress.total == 0 {
+			return status
+		}
+		return status + "\n\n" + m.renderProgress()
 
 	case replayStateConflict:
 		var s strings.Builder
 		s.WriteString(errorStyle.Render("✗ Conflicts detected during replay") + "\n\n")
 
-		infoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+		infoStyle := lipgloss.NewStyle().Foreground(colorMuted)
 		s.WriteString(infoStyle.Render("Please resolve conflicts and then:") + "\n")
-		s.WriteString("  • Fix conflicts in your files\n")
+		s.WriteString("  • Fix conflicts in your files, or get AI suggestions: " + highlightStyle.Render("snap resolve") + "\n")
 		s.WriteString("  • Stage the resolved files: " + highlightStyle.Render("git add <files>") + "\n")
-		s.WriteString("  • Continue: " + highlightStyle.Render("git rebase --continue") + "\n")
+		s.WriteString("  • Continue: " + highlightStyle.Render("git rebase --continue") + ", or " + highlightStyle.Render("snap save") + " to stage and continue in one go\n")
 		s.WriteString("  • Or abort: " + highlightStyle.Render("git rebase --abort") + "\n\n")
 
+		if len(m.messages) > 0 {
+			s.WriteString(infoStyle.Render("The AI messages are applied as the replay continues") + "\n\n")
+		}
 		if m.output != "" {
 			s.WriteString(infoStyle.Render("Git output:") + "\n")
 			s.WriteString(m.output + "\n")
 		}
+		if m.backupRef != "" {
+			s.WriteString("\n" + restoreHint(m.backupRef) + "\n")
+		}
 
 		return s.String()
 
@@ -1000,12 +2706,20 @@ func (m replayModel) View() string {
 		if m.err != nil {
 			return errorStyle.Render(fmt.Sprintf("✗ %s", m.err))
 		}
-		return successStyle.Render(fmt.Sprintf("✓ Successfully replayed %d commit(s) onto '%s'", len(m.commits), m.ontoBranch))
+		done := successStyle.Render(fmt.Sprintf("✓ Successfully replayed %d commit(s) onto '%s'", len(m.commits), m.ontoBranch))
+		if len(m.stacked) > 0 {
+			done += "\n" + successStyle.Render(fmt.Sprintf("✓ Moved stacked branches: %s", strings.Join(m.stacked, ", ")))
+		}
+		done += m.renderReworded()
+		if m.backupRef != "" {
+			done += "\n" + restoreHint(m.backupRef)
+		}
+		return done
 
 	case replayStateError:
 		errMsg := errorStyle.Render(fmt.Sprintf("✗ Error: %s", m.err))
 		if m.output != "" {
-			infoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+			infoStyle := lipgloss.NewStyle().Foreground(colorMuted)
 			errMsg += "\n\n" + infoStyle.Render("Git output:") + "\n" + m.output
 		}
 		return errMsg
@@ -1014,25 +2728,152 @@ func (m replayModel) View() string {
 	return ""
 }
 
+// ExitSummary keeps the replay's outcome visible after the alt screen closes
+func (m replayModel) ExitSummary() string {
+	switch m.state {
+	case replayStateConflict:
+		return fmt.Sprintf("✗ Replay onto %s stopped at a conflict - resolve it, then snap save", m.ontoBranch)
+	case replayStateError:
+		return fmt.Sprintf("✗ Replay onto %s failed: %v", m.ontoBranch, m.err)
+	case replayStateDone:
+		if m.err != nil {
+			return ""
+		}
+		summary := fmt.Sprintf("✓ Replayed %d commit(s) onto %s", len(m.commits), m.ontoBranch)
+		if len(m.stacked) > 0 {
+			summary += fmt.Sprintf(", moving %s", strings.Join(m.stacked, ", "))
+		}
+		if m.backupRef != "" {
+			summary += " (backup: " + m.backupRef + ")"
+		}
+		return summary
+	}
+	return ""
+}
+
+// renderReworded lists the new subjects of the commits reworded with AI and
+// why the others kept their message
+func (m replayModel) renderReworded() string {
+	var s strings.Builder
+	for _, hash := range m.rewordQueue {
+		if err, failed := m.rewordErrs[hash]; failed {
+			s.WriteString("\n" + lipgloss.NewStyle().Foreground(colorWarning).Render(fmt.Sprintf("⚠ Kept the message of %s: %v", shortHash(hash), err)))
+			continue
+		}
+		subject, _, _ := strings.Cut(m.messages[hash], "\n")
+		s.WriteString("\n" + successStyle.Render(fmt.Sprintf("✎ %s → %s", shortHash(hash), subject)))
+	}
+	return s.String()
+}
+
+// replayBarWidth is the width of the replay progress bar in cells
+const replayBarWidth = 30
+
+// renderProgress shows a bar for the commits applied so far and the subject
+// of the commit being applied
+func (m replayModel) renderProgress() string {
+	current, total := m.progress.current, m.progress.total
+	filled := min(current, total) * replayBarWidth / total
+
+	var s strings.Builder
+	s.WriteString("  ")
+	s.WriteString(lipgloss.NewStyle().Foreground(colorPrimary).Render(strings.Repeat("█", filled)))
+	s.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render(strings.Repeat("░", replayBarWidth-filled)))
+	s.WriteString(infoStyle.Render(fmt.Sprintf(" %d/%d", current, total)))
+
+	// Commits are listed newest first; git skips those already upstream, so
+	// the subject is only known when every listed commit is replayed
+	if total == len(m.commits) && current >= 1 && current <= total {
+		commit := m.commits[total-current]
+		s.WriteString("\n  ")
+		s.WriteString(lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Render("●"))
+		s.WriteString(" " + commit.Message + " " + infoStyle.Render(commit.ShortHash))
+	}
+	return s.String()
+}
+
 func checkForRebase() tea.Msg {
 	inProgress, err := CheckRebaseInProgress()
 	return checkRebaseMsg{inProgress: inProgress, err: err}
 }
 
-func getReplayCommitsCmd(ontoBranch string) tea.Cmd {
+func getReplayCommitsCmd(ontoBranch string, updateRefs bool) tea.Cmd {
 	return func() tea.Msg {
 		commits, err := GetRebaseCommits(ontoBranch)
-		return getReplayCommitsMsg{commits: commits, err: err}
+		if err != nil || !updateRefs {
+			return getReplayCommitsMsg{commits: commits, err: err}
+		}
+
+		hashes := make([]string, len(commits))
+		for i, commit := range commits {
+			hashes[i] = commit.Hash
+		}
+		stacked, err := GetStackedBranches(hashes)
+		return getReplayCommitsMsg{commits: commits, stacked: stacked, err: err}
+	}
+}
+
+// replayCommits replays in the background. Progress arrives on updates as
+// replayProgressMsg, followed by a final replayCommitsMsg.
+func replayCommits(ontoBranch string, updateRefs bool, messages map[string]string, updates chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		go func() {
+			backupRef, err := BackupHead("before replay onto " + ontoBranch)
+			if err != nil {
+				updates <- replayCommitsMsg{err: err}
+				return
+			}
+			if err := StartOperation("replay", backupRef, false); err != nil {
+				updates <- replayCommitsMsg{err: err}
+				return
+			}
+			output, err := ReplayCommits(ontoBranch, updateRefs, messages, func(current, total int) {
+				updates <- replayProgressMsg{current: current, total: total}
+			})
+			// A conflict is shown in the TUI and left to snap save
+			EndOperation()
+			updates <- replayCommitsMsg{output: output, backupRef: backupRef, err: err}
+		}()
+		return <-updates
 	}
 }
 
-func replayCommits(ontoBranch string) tea.Cmd {
+// rewordWithAI generates a new message for a commit from its own diff. The
+// commit's body is kept below the new subject.
+func rewordWithAI(hash string, seed int) tea.Cmd {
 	return func() tea.Msg {
-		output, err := ReplayCommits(ontoBranch)
-		return replayCommitsMsg{output: output, err: err}
+		diff, err := GetCommitDiff(hash)
+		if err != nil {
+			return replayRewordMsg{hash: hash, err: err}
+		}
+		message, err := GenerateCommitMessage(diff, seed)
+		if err != nil {
+			return replayRewordMsg{hash: hash, err: err}
+		}
+		message = strings.TrimSpace(message)
+		if err := CheckConvention(message, config.Convention); err != nil {
+			return replayRewordMsg{hash: hash, err: fmt.Errorf("invalid message %q: %v", message, err)}
+		}
+		if bodies, err := GetCommitBodies([]string{hash}); err == nil && bodies[hash] != "" {
+			message += "\n\n" + bodies[hash]
+		}
+		return replayRewordMsg{hash: hash, message: message}
+	}
+}
+
+// waitForReplay waits for the next update of a running replay
+func waitForReplay(updates chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
 	}
 }
 
+// restoreHint tells how to undo a history rewrite with its backup ref
+func restoreHint(backupRef string) string {
+	return infoStyle.Render("Previous HEAD saved as "+backupRef+". To undo: ") +
+		highlightStyle.Render("git reset --hard "+backupRef)
+}
+
 // Stack (commit history) TUI model
 type stackState int
 
@@ -1042,6 +2883,8 @@ const (
 	stackStateFiltering
 	stackStateCheckingOut
 	stackStateShowingDetails
+	stackStateComparing
+	stackStateCompareDiff
 	stackStateDone
 	stackStateError
 )
@@ -1058,10 +2901,38 @@ type stackModel struct {
 	allBranches     bool
 	mineOnly        bool
 	filePath        string
+	dirMode         bool      // filePath is a directory whose stats are shown (--dir)
+	dirStats        *DirStats // Loaded in the background with dirMode
+	dirErr          error
 	author          string
 	limit           int
 	filterMode      bool
 	filterQuery     string
+	typeFilter      string // Conventional commit type to show, "" for all
+	gotoMode        bool
+	gotoInput       textinput.Model
+	gotoErr         error
+	highlightHash   string // Commit found by the last goto
+	branchMode      bool
+	branchInput     textinput.Model
+	branchErr       error
+	newBranch       string // Branch created at selectedCommit
+	rewordMode      bool
+	rewordInput     textinput.Model
+	rewordCommit    *CommitInfo
+	rewordBusy      string // "generating" or "saving" while a command runs
+	rewordErr       error
+	notice          string // Result of the last reword, or why beginner mode ignored a key
+	showStats       bool
+	stats           map[string]CommitStats // Loaded on first toggle, keyed by full hash
+	statsErr        error
+	compareBase     *CommitInfo // Commit marked with b, compared against the next one marked
+	compareTarget   *CommitInfo
+	compareFiles    []FileDiffStat
+	compareCursor   int
+	compareLoading  bool   // Files or a file diff are being loaded
+	compareNotice   string // Error while loading files or a file diff
+	diffView        viewport.Model
 	showHelp        bool
 	selectedCommit  *CommitInfo
 	width           int
@@ -1074,23 +2945,84 @@ type getCommitsMsg struct {
 	err     error
 }
 
-type checkoutCommitMsg struct {
-	err error
+type dirStatsMsg struct {
+	stats DirStats
+	err   error
+}
+
+type checkoutCommitMsg struct {
+	err error
+}
+
+type commitStatsMsg struct {
+	stats map[string]CommitStats
+	err   error
+}
+
+type compareFilesMsg struct {
+	files []FileDiffStat
+	err   error
+}
+
+type compareFileDiffMsg struct {
+	diff string
+	err  error
+}
+
+type rewordGeneratedMsg struct {
+	message string
+	err     error
+}
+
+type rewordCommitMsg struct {
+	backupRef string // HEAD before the reword
+	err       error
+}
+
+type gotoCommitMsg struct {
+	hash    string
+	commits []CommitInfo // Reloaded history when the commit was beyond the limit, else nil
+	limit   int
+	err     error
 }
 
-func initialStackModel(limit int, allBranches bool, mineOnly bool, filePath string) stackModel {
+func initialStackModel(limit int, allBranches bool, mineOnly bool, filePath string, dirMode bool) stackModel {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
 
 	ti := textinput.New()
 	ti.Placeholder = "Type to filter commits..."
 	ti.CharLimit = 100
 	ti.Width = 50
 	ti.Prompt = ""
-	ti.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
-	ti.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
-	ti.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	ti.PromptStyle = lipgloss.NewStyle().Foreground(colorPrimary)
+	ti.TextStyle = lipgloss.NewStyle().Foreground(colorText)
+	ti.Cursor.Style = lipgloss.NewStyle().Foreground(colorPrimary)
+
+	gi := textinput.New()
+	gi.Placeholder = "hash, tag, or HEAD~n"
+	gi.CharLimit = 100
+	gi.Width = 50
+	gi.Prompt = ""
+	gi.TextStyle = lipgloss.NewStyle().Foreground(colorText)
+	gi.Cursor.Style = lipgloss.NewStyle().Foreground(colorPrimary)
+
+	bi := textinput.New()
+	bi.Placeholder = "feature/my-branch"
+	bi.CharLimit = 100
+	bi.Width = 50
+	bi.Prompt = ""
+	bi.TextStyle = lipgloss.NewStyle().Foreground(colorText)
+	bi.Cursor.Style = lipgloss.NewStyle().Foreground(colorPrimary)
+
+	ri := textinput.New()
+	ri.Placeholder = "Enter commit message..."
+	ri.CharLimit = 200
+	ri.Width = 60
+	ri.Prompt = ""
+	ri.TextStyle = lipgloss.NewStyle().Foreground(colorText)
+	ri.Cursor.Style = lipgloss.NewStyle().Foreground(colorPrimary)
 
 	// Get author if mineOnly is true
 	author := ""
@@ -1106,9 +3038,13 @@ func initialStackModel(limit int, allBranches bool, mineOnly bool, filePath stri
 		state:           stackStateLoading,
 		spinner:         s,
 		textInput:       ti,
+		gotoInput:       gi,
+		branchInput:     bi,
+		rewordInput:     ri,
 		allBranches:     allBranches,
 		mineOnly:        mineOnly,
 		filePath:        filePath,
+		dirMode:         dirMode,
 		author:          author,
 		limit:           limit,
 		showHelp:        true,
@@ -1123,27 +3059,197 @@ func initialStackModel(limit int, allBranches bool, mineOnly bool, filePath stri
 }
 
 func (m stackModel) Init() tea.Cmd {
+	if m.dirMode {
+		return tea.Batch(m.spinner.Tick, getCommits(m.limit, m.allBranches, m.author, m.filePath), getDirStatsCmd(m.filePath, m.allBranches))
+	}
 	return tea.Batch(m.spinner.Tick, getCommits(m.limit, m.allBranches, m.author, m.filePath))
 }
 
+func getDirStatsCmd(dir string, allBranches bool) tea.Cmd {
+	return func() tea.Msg {
+		stats, err := GetDirStats(dir, allBranches)
+		return dirStatsMsg{stats: stats, err: err}
+	}
+}
+
+// headerLines is the space the list view leaves around the commits
+func (m stackModel) headerLines() int {
+	if m.dirMode {
+		return 13 // Header, filter, footer and the directory stats
+	}
+	return 10 // Header, filter, and footer
+}
+
 func (m stackModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 		if !m.ready {
-			m.viewport = viewport.New(msg.Width, msg.Height-10) // Leave space for header, filter, and footer
+			m.viewport = viewport.New(msg.Width, msg.Height-m.headerLines())
 			m.viewport.YPosition = 0
+			m.diffView = viewport.New(msg.Width-4, msg.Height-4)
 			m.ready = true
 		} else {
 			m.viewport.Width = msg.Width
-			m.viewport.Height = msg.Height - 10
+			m.viewport.Height = msg.Height - m.headerLines()
+			m.diffView.Width = msg.Width - 4
+			m.diffView.Height = msg.Height - 4
 		}
 		return m, nil
 
 	case tea.KeyMsg:
+		switch m.state {
+		case stackStateComparing:
+			switch msg.String() {
+			case "ctrl+c", "q":
+				return m, tea.Quit
+			case "esc", "backspace":
+				// Back to the list, keeping the base marked for another comparison
+				m.state = stackStateList
+				m.compareTarget = nil
+				m.compareFiles = nil
+				m.compareNotice = ""
+			case "up", "k":
+				if m.compareCursor > 0 {
+					m.compareCursor--
+				}
+			case "down", "j":
+				if m.compareCursor < len(m.compareFiles)-1 {
+					m.compareCursor++
+				}
+			case "g":
+				m.compareCursor = 0
+			case "G":
+				m.compareCursor = max(len(m.compareFiles)-1, 0)
+			case "enter", "d":
+				if len(m.compareFiles) > 0 && !m.compareLoading {
+					m.compareLoading = true
+					m.compareNotice = ""
+					return m, getCompareFileDiffCmd(m.compareBase.Hash, m.compareTarget.Hash, m.compareFiles[m.compareCursor])
+				}
+			case "?":
+				m.showHelp = !m.showHelp
+			}
+			return m, nil
+
+		case stackStateCompareDiff:
+			switch msg.String() {
+			case "ctrl+c", "q":
+				return m, tea.Quit
+			case "esc", "backspace", "h", "left":
+				m.state = stackStateComparing
+			case "n", "p":
+				// Jump to the next/previous file without going back to the list
+				next := m.compareCursor + 1
+				if msg.String() == "p" {
+					next = m.compareCursor - 1
+				}
+				if next >= 0 && next < len(m.compareFiles) && !m.compareLoading {
+					m.compareCursor = next
+					m.compareLoading = true
+					return m, getCompareFileDiffCmd(m.compareBase.Hash, m.compareTarget.Hash, m.compareFiles[m.compareCursor])
+				}
+			case "g":
+				m.diffView.GotoTop()
+			case "G":
+				m.diffView.GotoBottom()
+			default:
+				var cmd tea.Cmd
+				m.diffView, cmd = m.diffView.Update(msg)
+				return m, cmd
+			}
+			return m, nil
+		}
+
 		// Handle list navigation and filter mode toggle
 		if m.state == stackStateList {
+			// Handle new branch prompt input
+			if m.branchMode {
+				switch msg.String() {
+				case "esc", "ctrl+c":
+					m.branchMode = false
+					m.branchErr = nil
+					m.branchInput.Blur()
+					return m, nil
+				case "enter":
+					name := strings.TrimSpace(m.branchInput.Value())
+					if name == "" {
+						m.branchErr = fmt.Errorf("branch name cannot be empty")
+						return m, nil
+					}
+					m.branchErr = nil
+					m.newBranch = name
+					return m, createBranchAtCmd(name, m.selectedCommit.Hash)
+				default:
+					var cmd tea.Cmd
+					m.branchInput, cmd = m.branchInput.Update(msg)
+					return m, cmd
+				}
+			}
+
+			// Handle reword prompt input
+			if m.rewordMode {
+				if m.rewordBusy != "" {
+					if msg.String() == "ctrl+c" {
+						return m, tea.Quit
+					}
+					return m, nil
+				}
+				switch msg.String() {
+				case "esc", "ctrl+c":
+					m.rewordMode = false
+					m.rewordErr = nil
+					m.rewordInput.Blur()
+					return m, nil
+				case "ctrl+g":
+					// Let the AI write a new message from the commit's diff
+					m.rewordBusy = "generating"
+					m.rewordErr = nil
+					return m, generateRewordCmd(m.rewordCommit.Hash, config.Save.Seed)
+				case "enter":
+					message := strings.TrimSpace(m.rewordInput.Value())
+					if message == "" {
+						m.rewordErr = fmt.Errorf("commit message cannot be empty")
+						return m, nil
+					}
+					if message == m.rewordCommit.Message {
+						m.rewordMode = false
+						m.rewordInput.Blur()
+						return m, nil
+					}
+					m.rewordBusy = "saving"
+					m.rewordErr = nil
+					return m, rewordCommitCmd(m.rewordCommit.Hash, message)
+				default:
+					var cmd tea.Cmd
+					m.rewordInput, cmd = m.rewordInput.Update(msg)
+					return m, cmd
+				}
+			}
+
+			// Handle goto prompt input
+			if m.gotoMode {
+				switch msg.String() {
+				case "esc", "ctrl+c":
+					m.gotoMode = false
+					m.gotoInput.Blur()
+					return m, nil
+				case "enter":
+					m.gotoMode = false
+					m.gotoInput.Blur()
+					ref := strings.TrimSpace(m.gotoInput.Value())
+					if ref == "" {
+						return m, nil
+					}
+					return m, gotoCommitCmd(ref, m.commits, m.limit, m.allBranches, m.author, m.filePath)
+				default:
+					var cmd tea.Cmd
+					m.gotoInput, cmd = m.gotoInput.Update(msg)
+					return m, cmd
+				}
+			}
+
 			// Check for filter mode entry FIRST, before handling filter input
 			if !m.filterMode && msg.String() == "/" {
 				// Enter filter mode
@@ -1163,7 +3269,7 @@ func (m stackModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.filterQuery = ""
 					m.textInput.SetValue("")
 					m.textInput.Blur()
-					m.filteredCommits = m.commits
+					m.applyFilter()
 					m.cursor = 0
 					return m, nil
 				case "enter":
@@ -1198,14 +3304,114 @@ func (m stackModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "G":
 				// Go to bottom
 				m.cursor = len(m.getDisplayCommits()) - 1
+			case "esc":
+				// Drop the compare base
+				m.compareBase = nil
+			case "b":
+				// Mark the compare base, then compare it with the next commit marked
+				commits := m.getDisplayCommits()
+				if len(commits) == 0 || m.cursor >= len(commits) {
+					break
+				}
+				commit := commits[m.cursor]
+				switch {
+				case m.compareBase == nil:
+					m.compareBase = &commit
+				case m.compareBase.Hash == commit.Hash:
+					m.compareBase = nil
+				default:
+					m.compareTarget = &commit
+					m.compareFiles = nil
+					m.compareCursor = 0
+					m.compareNotice = ""
+					m.compareLoading = true
+					m.state = stackStateComparing
+					return m, getCompareFilesCmd(m.compareBase.Hash, commit.Hash)
+				}
 			case "c":
 				// Clear filter
 				m.filterQuery = ""
 				m.textInput.SetValue("")
+				m.typeFilter = ""
 				m.filteredCommits = m.commits
 				m.cursor = 0
+			case "t", "T":
+				// Cycle through the conventional types present in the history
+				types := append([]string{""}, m.commitTypes()...)
+				current := 0
+				for i, t := range types {
+					if t == m.typeFilter {
+						current = i
+					}
+				}
+				if msg.String() == "t" {
+					current = (current + 1) % len(types)
+				} else {
+					current = (current + len(types) - 1) % len(types)
+				}
+				m.typeFilter = types[current]
+				m.applyFilter()
+				m.cursor = 0
+			case "0":
+				// Show all types
+				m.typeFilter = ""
+				m.applyFilter()
+				m.cursor = 0
+			case "B":
+				// Create and switch to a branch at the selected commit
+				commits := m.getDisplayCommits()
+				if len(commits) == 0 || m.cursor >= len(commits) {
+					break
+				}
+				commit := commits[m.cursor]
+				m.selectedCommit = &commit
+				m.branchMode = true
+				m.branchErr = nil
+				m.branchInput.SetValue("")
+				m.branchInput.Focus()
+				return m, textinput.Blink
+			case "w":
+				// Reword the selected commit
+				commits := m.getDisplayCommits()
+				if len(commits) == 0 || m.cursor >= len(commits) {
+					break
+				}
+				if beginnerMode() {
+					m.notice = "⚠ Rewording rewrites history, which beginner mode hides"
+					break
+				}
+				commit := commits[m.cursor]
+				m.rewordCommit = &commit
+				m.rewordMode = true
+				m.rewordErr = nil
+				m.notice = ""
+				m.rewordInput.SetValue(commit.Message)
+				m.rewordInput.CursorEnd()
+				m.rewordInput.Focus()
+				return m, textinput.Blink
+			case ":":
+				// Go to a commit by hash or ref
+				m.gotoMode = true
+				m.gotoErr = nil
+				m.gotoInput.SetValue("")
+				m.gotoInput.Focus()
+				return m, textinput.Blink
+			case "s":
+				// Toggle the line and file counts, loading them for all commits at once
+				m.showStats = !m.showStats
+				if m.showStats && m.stats == nil && m.statsErr == nil {
+					hashes := make([]string, len(m.commits))
+					for i, commit := range m.commits {
+						hashes[i] = commit.Hash
+					}
+					return m, getCommitStatsCmd(hashes)
+				}
 			case "enter":
 				// Checkout selected commit
+				if beginnerMode() {
+					m.notice = "⚠ Checking out a commit detaches HEAD, which beginner mode hides - B starts a branch there instead"
+					break
+				}
 				commits := m.getDisplayCommits()
 				if len(commits) > 0 && m.cursor < len(commits) {
 					m.selectedCommit = &commits[m.cursor]
@@ -1231,6 +3437,14 @@ func (m stackModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 
+	case dirStatsMsg:
+		if msg.err != nil {
+			m.dirErr = msg.err
+			return m, nil
+		}
+		m.dirStats = &msg.stats
+		return m, nil
+
 	case getCommitsMsg:
 		if msg.err != nil {
 			m.state = stackStateError
@@ -1245,8 +3459,53 @@ func (m stackModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 		m.state = stackStateList
+		m.cursor = min(m.cursor, max(len(m.getDisplayCommits())-1, 0))
+		if m.showStats && m.stats == nil {
+			hashes := make([]string, len(m.commits))
+			for i, commit := range m.commits {
+				hashes[i] = commit.Hash
+			}
+			return m, getCommitStatsCmd(hashes)
+		}
+		return m, nil
+
+	case createBranchMsg:
+		if msg.err != nil {
+			m.branchErr = msg.err
+			m.newBranch = ""
+			return m, nil
+		}
+		m.branchMode = false
+		m.state = stackStateDone
+		return m, tea.Quit
+
+	case rewordGeneratedMsg:
+		m.rewordBusy = ""
+		if msg.err != nil {
+			m.rewordErr = msg.err
+			return m, nil
+		}
+		m.rewordInput.SetValue(msg.message)
+		m.rewordInput.CursorEnd()
 		return m, nil
 
+	case rewordCommitMsg:
+		m.rewordBusy = ""
+		if msg.err != nil {
+			m.rewordErr = msg.err
+			return m, nil
+		}
+
+		// Hashes from the reworded commit onward changed, reload the history
+		m.rewordMode = false
+		m.rewordInput.Blur()
+		m.notice = fmt.Sprintf("✓ Reworded %s (undo: git reset --hard %s)", m.rewordCommit.ShortHash, msg.backupRef)
+		m.stats = nil
+		m.compareBase = nil
+		m.highlightHash = ""
+		m.state = stackStateLoading
+		return m, getCommits(m.limit, m.allBranches, m.author, m.filePath)
+
 	case checkoutCommitMsg:
 		if msg.err != nil {
 			m.state = stackStateError
@@ -1255,26 +3514,80 @@ func (m stackModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.state = stackStateDone
 		return m, tea.Quit
+
+	case compareFilesMsg:
+		m.compareLoading = false
+		if msg.err != nil {
+			m.compareNotice = fmt.Sprintf("Failed to load changed files: %v", msg.err)
+			return m, nil
+		}
+		m.compareFiles = msg.files
+		return m, nil
+
+	case compareFileDiffMsg:
+		m.compareLoading = false
+		if msg.err != nil {
+			m.compareNotice = fmt.Sprintf("Failed to load diff: %v", msg.err)
+			return m, nil
+		}
+		m.diffView.SetContent(renderColoredDiff(msg.diff))
+		m.diffView.GotoTop()
+		m.state = stackStateCompareDiff
+		return m, nil
+
+	case commitStatsMsg:
+		m.stats = msg.stats
+		m.statsErr = msg.err
+		return m, nil
+
+	case gotoCommitMsg:
+		if msg.err != nil {
+			m.gotoErr = msg.err
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		if msg.commits != nil {
+			m.commits = msg.commits
+			m.limit = msg.limit
+			if m.stats != nil {
+				// Stats only cover the previous page of history
+				m.stats = nil
+				if m.showStats {
+					hashes := make([]string, len(m.commits))
+					for i, commit := range m.commits {
+						hashes[i] = commit.Hash
+					}
+					cmd = getCommitStatsCmd(hashes)
+				}
+			}
+		}
+
+		// Clear filters that would hide the commit
+		m.applyFilter()
+		if commitIndex(m.getDisplayCommits(), msg.hash) < 0 {
+			m.filterQuery = ""
+			m.textInput.SetValue("")
+			m.typeFilter = ""
+			m.applyFilter()
+		}
+		m.cursor = max(0, commitIndex(m.getDisplayCommits(), msg.hash))
+		m.highlightHash = msg.hash
+		return m, cmd
 	}
 
 	return m, nil
 }
 
 func (m *stackModel) applyFilter() {
-	if m.filterQuery == "" {
+	if m.filterQuery == "" && m.typeFilter == "" {
 		m.filteredCommits = m.commits
 		return
 	}
 
-	query := strings.ToLower(m.filterQuery)
 	filtered := make([]CommitInfo, 0)
-
 	for _, commit := range m.commits {
-		// Search in message, hash, and author
-		if strings.Contains(strings.ToLower(commit.Message), query) ||
-			strings.Contains(strings.ToLower(commit.Hash), query) ||
-			strings.Contains(strings.ToLower(commit.ShortHash), query) ||
-			strings.Contains(strings.ToLower(commit.Author), query) {
+		if m.matchesQuery(commit) && (m.typeFilter == "" || CommitType(commit.Message) == m.typeFilter) {
 			filtered = append(filtered, commit)
 		}
 	}
@@ -1282,8 +3595,140 @@ func (m *stackModel) applyFilter() {
 	m.filteredCommits = filtered
 }
 
+// matchesQuery reports whether the text filter matches a commit's message, hash, or author
+func (m stackModel) matchesQuery(commit CommitInfo) bool {
+	if m.filterQuery == "" {
+		return true
+	}
+	query := strings.ToLower(m.filterQuery)
+	return strings.Contains(strings.ToLower(commit.Message), query) ||
+		strings.Contains(strings.ToLower(commit.Hash), query) ||
+		strings.Contains(strings.ToLower(commit.ShortHash), query) ||
+		strings.Contains(strings.ToLower(commit.Author), query)
+}
+
+// commitTypes returns the conventional types present in the loaded commits, in
+// changelog order with "other" last. It is empty when no commit is conventional.
+func (m stackModel) commitTypes() []string {
+	present := map[string]bool{}
+	for _, commit := range m.commits {
+		present[CommitType(commit.Message)] = true
+	}
+	if len(present) == 0 || (len(present) == 1 && present[otherCommitType]) {
+		return nil
+	}
+
+	types := []string{}
+	for _, section := range changelogSections {
+		if present[section.Type] {
+			types = append(types, section.Type)
+		}
+	}
+	if present[otherCommitType] {
+		types = append(types, otherCommitType)
+	}
+	return types
+}
+
+// typeCounts counts the commits matching the text filter per conventional type
+func (m stackModel) typeCounts() (counts map[string]int, total int) {
+	counts = map[string]int{}
+	for _, commit := range m.commits {
+		if m.matchesQuery(commit) {
+			counts[CommitType(commit.Message)]++
+			total++
+		}
+	}
+	return counts, total
+}
+
+// renderTypeBar renders the type filter badges with counts, highlighting the active one
+// renderDirStats shows the activity of the directory given with --dir
+func (m stackModel) renderDirStats() string {
+	padStyle := lipgloss.NewStyle().PaddingLeft(2)
+	mutedStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	switch {
+	case m.dirErr != nil:
+		return padStyle.Render(errorStyle.Render(fmt.Sprintf("✗ Failed to load the directory stats: %v", m.dirErr))) + "\n\n"
+	case m.dirStats == nil:
+		return padStyle.Render(mutedStyle.Render(m.spinner.View()+" Adding up the directory's history...")) + "\n\n"
+	}
+	textStyle := lipgloss.NewStyle().Foreground(colorText)
+	var s strings.Builder
+	s.WriteString(padStyle.Render(textStyle.Render(m.dirStats.Summary())))
+	s.WriteString("\n")
+	if top := m.dirStats.TopContributors(3); len(top) > 0 {
+		s.WriteString(padStyle.Render(mutedStyle.Render("Top: " + strings.Join(top, ", "))))
+	}
+	s.WriteString("\n")
+	return s.String()
+}
+
+func (m stackModel) renderTypeBar() string {
+	types := m.commitTypes()
+	if len(types) == 0 {
+		return ""
+	}
+
+	activeStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	badgeStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	counts, total := m.typeCounts()
+
+	badges := make([]string, 0, len(types)+1)
+	for _, t := range append([]string{""}, types...) {
+		label, count := t, counts[t]
+		if t == "" {
+			label, count = "all", total
+		}
+		badge := fmt.Sprintf("%s %d", label, count)
+		if t == m.typeFilter {
+			badges = append(badges, activeStyle.Render("["+badge+"]"))
+		} else {
+			badges = append(badges, badgeStyle.Render(" "+badge+" "))
+		}
+	}
+	return strings.Join(badges, " ")
+}
+
+// statsBarWidth is the width of the bar for the largest commit in the stack
+const statsBarWidth = 10
+
+// renderCommitStats renders "+12 -3 · 2 files" and a bar scaled to maxLines,
+// so that large commits stand out in the list
+func (m stackModel) renderCommitStats(hash string, maxLines int) string {
+	dimStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	if m.statsErr != nil {
+		return dimStyle.Render("stats unavailable")
+	}
+	if m.stats == nil {
+		return dimStyle.Render("loading stats...")
+	}
+
+	st := m.stats[hash]
+	files := "files"
+	if st.FilesChanged == 1 {
+		files = "file"
+	}
+	text := fmt.Sprintf("%s %s %s",
+		lipgloss.NewStyle().Foreground(colorSuccess).Render(fmt.Sprintf("+%d", st.Additions)),
+		lipgloss.NewStyle().Foreground(colorDanger).Render(fmt.Sprintf("-%d", st.Deletions)),
+		dimStyle.Render(fmt.Sprintf("· %d %s", st.FilesChanged, files)),
+	)
+
+	lines := st.Additions + st.Deletions
+	if lines == 0 || maxLines == 0 {
+		return text
+	}
+	width := max(1, lines*statsBarWidth/maxLines)
+	barStyle := dimStyle
+	if width > statsBarWidth/2 {
+		barStyle = lipgloss.NewStyle().Foreground(colorWarning)
+	}
+	return text + " " + barStyle.Render(strings.Repeat("█", width))
+}
+
 func (m stackModel) getDisplayCommits() []CommitInfo {
-	if m.filterQuery != "" {
+	if m.filterQuery != "" || m.typeFilter != "" {
 		if m.filteredCommits == nil {
 			return []CommitInfo{}
 		}
@@ -1310,15 +3755,22 @@ func (m stackModel) View() string {
 		var content strings.Builder
 
 		if len(commits) == 0 {
-			content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#888888")).Render("No commits match filter"))
+			content.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render("No commits match filter"))
 			content.WriteString("\n")
 		} else {
-			commitStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4")).Bold(true)
-			timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
-			hashStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
-			authorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
-			cursorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4")).Bold(true)
-			pipeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+			commitStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+			timeStyle := lipgloss.NewStyle().Foreground(colorMuted)
+			hashStyle := lipgloss.NewStyle().Foreground(colorMuted)
+			authorStyle := lipgloss.NewStyle().Foreground(colorMuted)
+			cursorStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+			pipeStyle := lipgloss.NewStyle().Foreground(colorPrimary)
+
+			// Scale the stats bars to the largest commit on screen
+			maxLines := 0
+			for _, commit := range commits {
+				st := m.stats[commit.Hash]
+				maxLines = max(maxLines, st.Additions+st.Deletions)
+			}
 
 			for i, commit := range commits {
 				cursor := "  "
@@ -1327,11 +3779,17 @@ func (m stackModel) View() string {
 				}
 
 				// Show bullet, time and message
+				bullet := commitStyle.Render("●")
+				message := commit.Message
+				if commit.Hash == m.highlightHash {
+					bullet = highlightStyle.Render("◉")
+					message = highlightStyle.Render(message)
+				}
 				content.WriteString(fmt.Sprintf("%s%s %s %s\n",
 					cursor,
-					commitStyle.Render("●"),
+					bullet,
 					timeStyle.Render(commit.RelativeTime),
-					commit.Message,
+					message,
 				))
 
 				// Show hash and author
@@ -1344,6 +3802,12 @@ func (m stackModel) View() string {
 						authorStyle.Render(fmt.Sprintf("by %s", commit.Author)),
 					))
 				}
+				if m.compareBase != nil && commit.Hash == m.compareBase.Hash {
+					content.WriteString(" " + lipgloss.NewStyle().Foreground(colorWarning).Bold(true).Render("[base]"))
+				}
+				if m.showStats {
+					content.WriteString("  " + m.renderCommitStats(commit.Hash, maxLines))
+				}
 				content.WriteString("\n")
 
 				// Show pipe between commits (except for last one)
@@ -1353,51 +3817,134 @@ func (m stackModel) View() string {
 			}
 		}
 
-		m.viewport.SetContent(content.String())
-
-		// Auto-scroll to keep cursor visible (each commit takes 3 lines with pipe)
-		lineHeight := 3
-		cursorLine := m.cursor * lineHeight
-		if cursorLine < m.viewport.YOffset {
-			m.viewport.YOffset = cursorLine
-		} else if cursorLine >= m.viewport.YOffset+m.viewport.Height {
-			m.viewport.YOffset = cursorLine - m.viewport.Height + lineHeight
+		m.viewport.SetContent(content.String())
+
+		// Auto-scroll to keep cursor visible (each commit takes 3 lines with pipe)
+		lineHeight := 3
+		cursorLine := m.cursor * lineHeight
+		if cursorLine < m.viewport.YOffset {
+			m.viewport.YOffset = cursorLine
+		} else if cursorLine >= m.viewport.YOffset+m.viewport.Height {
+			m.viewport.YOffset = cursorLine - m.viewport.Height + lineHeight
+		}
+
+		// Build final view with header, filter bar, viewport, and footer
+		var s strings.Builder
+		titleStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(colorPrimary).
+			PaddingLeft(2)
+
+		title := "Commit History"
+		if m.allBranches {
+			title += " (all branches)"
+		}
+		if m.mineOnly {
+			title += " (mine)"
+		}
+		if m.filePath != "" {
+			title += fmt.Sprintf(" - %s", m.filePath)
+		}
+
+		s.WriteString(titleStyle.Render(title))
+		s.WriteString("\n\n")
+
+		// Show the directory's activity
+		if m.dirMode {
+			s.WriteString(m.renderDirStats())
+			s.WriteString("\n")
+		}
+
+		// Show type filter badges
+		if typeBar := m.renderTypeBar(); typeBar != "" {
+			s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(typeBar))
+			s.WriteString("\n\n")
+		}
+
+		// Show new branch prompt and its error
+		if m.branchMode {
+			branchLabelStyle := lipgloss.NewStyle().
+				Foreground(colorPrimary).
+				Bold(true).
+				PaddingLeft(2)
+			s.WriteString(branchLabelStyle.Render(fmt.Sprintf("New branch at %s: ", m.selectedCommit.ShortHash)))
+			s.WriteString(m.branchInput.View())
+			s.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render(" (Enter to create and switch, Esc to cancel)"))
+			s.WriteString("\n")
+			if m.branchErr != nil {
+				s.WriteString(lipgloss.NewStyle().Foreground(colorError).PaddingLeft(2).Render(fmt.Sprintf("✗ %v", strings.TrimSpace(m.branchErr.Error()))))
+				s.WriteString("\n")
+			}
+			s.WriteString("\n")
+		}
+
+		// Show reword prompt, its error, or the result
+		if m.rewordMode {
+			rewordLabelStyle := lipgloss.NewStyle().
+				Foreground(colorPrimary).
+				Bold(true).
+				PaddingLeft(2)
+			s.WriteString(rewordLabelStyle.Render(fmt.Sprintf("Reword %s: ", m.rewordCommit.ShortHash)))
+			switch m.rewordBusy {
+			case "generating":
+				s.WriteString(fmt.Sprintf("%s Generating commit message with %s...", m.spinner.View(), CurrentProvider().Name()))
+			case "saving":
+				s.WriteString(fmt.Sprintf("%s Rewording...", m.spinner.View()))
+			default:
+				s.WriteString(m.rewordInput.View())
+				s.WriteString("\n")
+				s.WriteString(lipgloss.NewStyle().Foreground(colorMuted).PaddingLeft(2).Render("Enter to save, Ctrl+G to generate with AI, Esc to cancel"))
+			}
+			s.WriteString("\n")
+			if m.rewordErr != nil {
+				s.WriteString(lipgloss.NewStyle().Foreground(colorError).PaddingLeft(2).Render(fmt.Sprintf("✗ %v", m.rewordErr)))
+				s.WriteString("\n")
+			}
+			s.WriteString("\n")
+		} else if m.notice != "" {
+			noticeStyle := successStyle
+			if strings.HasPrefix(m.notice, "⚠") {
+				noticeStyle = lipgloss.NewStyle().Foreground(colorWarning)
+			}
+			s.WriteString(noticeStyle.PaddingLeft(2).Render(m.notice))
+			s.WriteString("\n\n")
+		}
+
+		// Show goto prompt or its error
+		if m.gotoMode {
+			gotoLabelStyle := lipgloss.NewStyle().
+				Foreground(colorPrimary).
+				Bold(true).
+				PaddingLeft(2)
+			s.WriteString(gotoLabelStyle.Render("Go to: "))
+			s.WriteString(m.gotoInput.View())
+			s.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render(" (Enter to jump, Esc to cancel)"))
+			s.WriteString("\n\n")
+		} else if m.gotoErr != nil {
+			s.WriteString(lipgloss.NewStyle().Foreground(colorError).PaddingLeft(2).Render(fmt.Sprintf("✗ %v", m.gotoErr)))
+			s.WriteString("\n\n")
 		}
 
-		// Build final view with header, filter bar, viewport, and footer
-		var s strings.Builder
-		titleStyle := lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#7D56F4")).
-			PaddingLeft(2)
-
-		title := "Commit History"
-		if m.allBranches {
-			title += " (all branches)"
-		}
-		if m.mineOnly {
-			title += " (mine)"
-		}
-		if m.filePath != "" {
-			title += fmt.Sprintf(" - %s", m.filePath)
+		// Show the marked compare base
+		if m.compareBase != nil {
+			s.WriteString(lipgloss.NewStyle().Foreground(colorMuted).PaddingLeft(2).Render(
+				fmt.Sprintf("Base: %s - press b on another commit to compare (Esc to clear)", m.compareBase.ShortHash)))
+			s.WriteString("\n\n")
 		}
 
-		s.WriteString(titleStyle.Render(title))
-		s.WriteString("\n\n")
-
 		// Show filter bar
 		if m.filterMode {
 			filterLabelStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#7D56F4")).
+				Foreground(colorPrimary).
 				Bold(true).
 				PaddingLeft(2)
 			s.WriteString(filterLabelStyle.Render("🔍 Filter: "))
 			s.WriteString(m.textInput.View())
-			s.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#888888")).Render(" (Esc/Ctrl+C/q to cancel)"))
+			s.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render(" (Esc/Ctrl+C/q to cancel)"))
 			s.WriteString("\n\n")
 		} else if m.filterQuery != "" {
 			filterStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#888888")).
+				Foreground(colorMuted).
 				PaddingLeft(2)
 			s.WriteString(filterStyle.Render(fmt.Sprintf("🔍 Active filter: %s (press 'c' to clear)", m.filterQuery)))
 			s.WriteString("\n\n")
@@ -1413,24 +3960,57 @@ func (m stackModel) View() string {
 		// Show help
 		if m.showHelp {
 			helpStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#888888")).
+				Foreground(colorMuted).
 				PaddingLeft(2)
 			if m.filterMode {
 				s.WriteString(helpStyle.Render("Type to filter • Enter: apply • Esc/Ctrl+C/q: cancel"))
 			} else {
 				s.WriteString(helpStyle.Render("↑/k: up  ↓/j: down  g: top  G: bottom  /: filter  c: clear filter"))
 				s.WriteString("\n")
-				s.WriteString(helpStyle.Render("Enter: checkout  ?: toggle help  q: quit"))
+				s.WriteString(helpStyle.Render("t/T: next/previous type  0: all types  s: line stats  :: go to commit"))
+				s.WriteString("\n")
+				if beginnerMode() {
+					s.WriteString(helpStyle.Render("B: new branch here  b: mark base, then b again to compare"))
+					s.WriteString("\n")
+					s.WriteString(helpStyle.Render("?: toggle help  q: quit"))
+				} else {
+					s.WriteString(helpStyle.Render("w: reword  B: new branch here  b: mark base, then b again to compare"))
+					s.WriteString("\n")
+					s.WriteString(helpStyle.Render("Enter: checkout  ?: toggle help  q: quit"))
+				}
 			}
 		} else {
 			helpStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#888888")).
+				Foreground(colorMuted).
 				PaddingLeft(2)
 			s.WriteString(helpStyle.Render("Press ? for help"))
 		}
 
 		return s.String()
 
+	case stackStateComparing:
+		return m.renderCompareFiles()
+
+	case stackStateCompareDiff:
+		file := m.compareFiles[m.compareCursor]
+		helpStyle := lipgloss.NewStyle().Foreground(colorMuted).PaddingLeft(2)
+		titleStyle := lipgloss.NewStyle().Bold(true).Foreground(colorPrimary).PaddingLeft(2)
+
+		var s strings.Builder
+		s.WriteString(titleStyle.Render(file.DisplayPath()))
+		s.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render(fmt.Sprintf("  %s..%s  (%d/%d)",
+			m.compareBase.ShortHash, m.compareTarget.ShortHash, m.compareCursor+1, len(m.compareFiles))))
+		s.WriteString("\n\n")
+		s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(m.diffView.View()))
+		s.WriteString("\n")
+		if m.compareNotice != "" {
+			s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(errorStyle.Render(m.compareNotice)))
+			s.WriteString("\n")
+		}
+		s.WriteString(helpStyle.Render(fmt.Sprintf("%3.f%%  ↑/↓: scroll  pgup/pgdn: page  n/p: next/prev file  esc: back  q: quit",
+			m.diffView.ScrollPercent()*100)))
+		return s.String()
+
 	case stackStateCheckingOut:
 		if m.selectedCommit != nil {
 			return fmt.Sprintf("%s Checking out commit %s...", m.spinner.View(), m.selectedCommit.ShortHash)
@@ -1438,9 +4018,12 @@ func (m stackModel) View() string {
 		return fmt.Sprintf("%s Checking out commit...", m.spinner.View())
 
 	case stackStateDone:
+		if m.newBranch != "" {
+			return successStyle.Render(fmt.Sprintf("✓ Created and switched to branch '%s' at %s", m.newBranch, m.selectedCommit.ShortHash))
+		}
 		if m.selectedCommit != nil {
-			warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFAA00")).Bold(true)
-			infoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+			warningStyle := lipgloss.NewStyle().Foreground(colorWarning).Bold(true)
+			infoStyle := lipgloss.NewStyle().Foreground(colorMuted)
 
 			var s strings.Builder
 			s.WriteString(successStyle.Render(fmt.Sprintf("✓ Checked out commit %s", m.selectedCommit.ShortHash)) + "\n")
@@ -1459,6 +4042,87 @@ func (m stackModel) View() string {
 	return ""
 }
 
+// renderCompareFiles shows the files changed between the compare base and target
+func (m stackModel) renderCompareFiles() string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(colorPrimary).PaddingLeft(2)
+	mutedStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	hashStyle := lipgloss.NewStyle().Foreground(colorWarning)
+	textStyle := lipgloss.NewStyle().Foreground(colorText)
+	cursorStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	addStyle := lipgloss.NewStyle().Foreground(colorSuccess)
+	delStyle := lipgloss.NewStyle().Foreground(colorDanger)
+	padStyle := lipgloss.NewStyle().PaddingLeft(2)
+
+	var s strings.Builder
+	s.WriteString(titleStyle.Render(fmt.Sprintf("Compare %s..%s", m.compareBase.ShortHash, m.compareTarget.ShortHash)))
+	s.WriteString("\n\n")
+	s.WriteString(padStyle.Render(mutedStyle.Render("base    ") + hashStyle.Render(m.compareBase.ShortHash) + "  " + textStyle.Render(m.compareBase.Message)))
+	s.WriteString("\n")
+	s.WriteString(padStyle.Render(mutedStyle.Render("target  ") + hashStyle.Render(m.compareTarget.ShortHash) + "  " + textStyle.Render(m.compareTarget.Message)))
+	s.WriteString("\n\n")
+
+	var content strings.Builder
+	switch {
+	case m.compareLoading && m.compareFiles == nil:
+		content.WriteString(fmt.Sprintf("%s Loading changed files...\n", m.spinner.View()))
+	case m.compareFiles != nil && len(m.compareFiles) == 0:
+		content.WriteString(mutedStyle.Render("No files changed") + "\n")
+	}
+
+	additions, deletions := 0, 0
+	for i, file := range m.compareFiles {
+		additions += file.Additions
+		deletions += file.Deletions
+
+		cursor := "  "
+		if i == m.compareCursor {
+			cursor = cursorStyle.Render("→ ")
+		}
+
+		statsStr := mutedStyle.Render(fmt.Sprintf("%-12s", "binary"))
+		if !file.Binary {
+			statsStr = fmt.Sprintf("%s %s",
+				addStyle.Render(fmt.Sprintf("%6s", fmt.Sprintf("+%d", file.Additions))),
+				delStyle.Render(fmt.Sprintf("%-5s", fmt.Sprintf("-%d", file.Deletions))),
+			)
+		}
+		content.WriteString(fmt.Sprintf("%s%s  %s\n", cursor, statsStr, textStyle.Render(file.DisplayPath())))
+	}
+
+	if m.compareFiles != nil {
+		s.WriteString(padStyle.Render(mutedStyle.Render(fmt.Sprintf("%d files  ", len(m.compareFiles))) +
+			addStyle.Render(fmt.Sprintf("+%d", additions)) + "  " + delStyle.Render(fmt.Sprintf("-%d", deletions))))
+		s.WriteString("\n\n")
+	}
+
+	// Keep the cursor visible, leaving room for the header and footer
+	lines := strings.Split(strings.TrimSuffix(content.String(), "\n"), "\n")
+	if height := m.height - 10; height > 0 && len(lines) > height {
+		start := min(max(m.compareCursor-height+1, 0), len(lines)-height)
+		lines = lines[start : start+height]
+	}
+	s.WriteString(padStyle.Render(strings.Join(lines, "\n")))
+	s.WriteString("\n")
+
+	if m.compareNotice != "" {
+		s.WriteString(padStyle.Render(errorStyle.Render(m.compareNotice)))
+		s.WriteString("\n")
+	}
+
+	helpStyle := mutedStyle.PaddingLeft(2)
+	if m.showHelp {
+		loading := ""
+		if m.compareLoading && m.compareFiles != nil {
+			loading = m.spinner.View() + " "
+		}
+		s.WriteString(helpStyle.Render(loading + "↑/k: up  ↓/j: down  g: top  G: bottom  enter: view diff  esc: back  q: quit"))
+	} else {
+		s.WriteString(helpStyle.Render("Press ? for help"))
+	}
+
+	return s.String()
+}
+
 func getCommits(limit int, allBranches bool, author string, filePath string) tea.Cmd {
 	return func() tea.Msg {
 		commits, err := GetCommitHistory(limit, allBranches, author, filePath)
@@ -1466,6 +4130,110 @@ func getCommits(limit int, allBranches bool, author string, filePath string) tea
 	}
 }
 
+// commitIndex returns the position of the commit with the given full hash, or -1
+func commitIndex(commits []CommitInfo, hash string) int {
+	for i, commit := range commits {
+		if commit.Hash == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+// gotoCommitCmd resolves ref and finds it in the stack history, loading more
+// history (doubling the limit) until the commit shows up or the history ends
+func gotoCommitCmd(ref string, commits []CommitInfo, limit int, allBranches bool, author string, filePath string) tea.Cmd {
+	return func() tea.Msg {
+		hash, err := ResolveCommit(ref)
+		if err != nil {
+			return gotoCommitMsg{err: err}
+		}
+
+		reloaded := false
+		for commitIndex(commits, hash) < 0 {
+			if len(commits) < limit {
+				return gotoCommitMsg{err: fmt.Errorf("%s is not part of this history", ref)}
+			}
+			limit *= 2
+			commits, err = GetCommitHistory(limit, allBranches, author, filePath)
+			if err != nil {
+				return gotoCommitMsg{err: err}
+			}
+			reloaded = true
+		}
+
+		if !reloaded {
+			return gotoCommitMsg{hash: hash}
+		}
+		return gotoCommitMsg{hash: hash, commits: commits, limit: limit}
+	}
+}
+
+func createBranchAtCmd(name, commitHash string) tea.Cmd {
+	return func() tea.Msg {
+		return createBranchMsg{err: CreateAndSwitchBranchAt(name, commitHash)}
+	}
+}
+
+// generateRewordCmd asks the configured AI provider for a new message based on
+// the changes the commit introduced
+func generateRewordCmd(hash string, seed int) tea.Cmd {
+	return func() tea.Msg {
+		if err := CurrentProvider().Check(); err != nil {
+			return rewordGeneratedMsg{err: err}
+		}
+		diff, err := GetCommitDiff(hash)
+		if err != nil {
+			return rewordGeneratedMsg{err: err}
+		}
+		if strings.TrimSpace(diff) == "" {
+			return rewordGeneratedMsg{err: fmt.Errorf("commit has no changes to describe")}
+		}
+		message, err := GenerateCommitMessage(diff, seed)
+		return rewordGeneratedMsg{message: message, err: err}
+	}
+}
+
+// rewordCommitCmd replaces the subject of a commit, keeping its body
+func rewordCommitCmd(hash, subject string) tea.Cmd {
+	return func() tea.Msg {
+		bodies, err := GetCommitBodies([]string{hash})
+		if err != nil {
+			return rewordCommitMsg{err: err}
+		}
+		message := subject
+		if body := bodies[hash]; body != "" {
+			message += "\n\n" + body
+		}
+		backupRef, err := BackupHead("before reword of " + hash[:min(7, len(hash))])
+		if err != nil {
+			return rewordCommitMsg{err: err}
+		}
+		return rewordCommitMsg{backupRef: backupRef, err: RewordCommit(hash, message)}
+	}
+}
+
+func getCompareFilesCmd(base, target string) tea.Cmd {
+	return func() tea.Msg {
+		files, err := GetChangedFilesBetween(base, target)
+		return compareFilesMsg{files: files, err: err}
+	}
+}
+
+func getCompareFileDiffCmd(base, target string, file FileDiffStat) tea.Cmd {
+	return func() tea.Msg {
+		diff, err := GetFileDiffBetween(base, target, file)
+		return compareFileDiffMsg{diff: diff, err: err}
+	}
+}
+
+func getCommitStatsCmd(hashes []string) tea.Cmd {
+	return func() tea.Msg {
+		stats, err := GetCommitStats(hashes)
+		return commitStatsMsg{stats: stats, err: err}
+	}
+}
+
 func checkoutCommitCmd(commitHash string) tea.Cmd {
 	return func() tea.Msg {
 		err := CheckoutCommit(commitHash)
@@ -1516,16 +4284,16 @@ type getTagsMsg struct {
 func initialTagsModel() tagsModel {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
 
 	ti := textinput.New()
 	ti.Placeholder = "Type to filter tags..."
 	ti.CharLimit = 100
 	ti.Width = 50
 	ti.Prompt = ""
-	ti.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
-	ti.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
-	ti.Cursor.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	ti.PromptStyle = lipgloss.NewStyle().Foreground(colorPrimary)
+	ti.TextStyle = lipgloss.NewStyle().Foreground(colorText)
+	ti.Cursor.Style = lipgloss.NewStyle().Foreground(colorPrimary)
 
 	return tagsModel{
 		state:        tagsStateLoading,
@@ -1704,11 +4472,11 @@ func (m tagsModel) View() string {
 		if len(tags) == 0 {
 			content.WriteString("No tags match filter")
 		} else {
-			tagStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575")).Bold(true)
-			hashStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
-			timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
-			msgStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
-			cursorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4")).Bold(true)
+			tagStyle := lipgloss.NewStyle().Foreground(colorSuccess).Bold(true)
+			hashStyle := lipgloss.NewStyle().Foreground(colorMuted)
+			timeStyle := lipgloss.NewStyle().Foreground(colorMuted)
+			msgStyle := lipgloss.NewStyle().Foreground(colorText)
+			cursorStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
 
 			// Calculate max tag name width for alignment
 			maxTagWidth := 0
@@ -1777,7 +4545,7 @@ func (m tagsModel) View() string {
 		var s strings.Builder
 		titleStyle := lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#7D56F4")).
+			Foreground(colorPrimary).
 			PaddingLeft(2)
 
 		s.WriteString(titleStyle.Render("Tags"))
@@ -1786,16 +4554,16 @@ func (m tagsModel) View() string {
 		// Show filter bar
 		if m.filterMode {
 			filterLabelStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#7D56F4")).
+				Foreground(colorPrimary).
 				Bold(true).
 				PaddingLeft(2)
 			s.WriteString(filterLabelStyle.Render("Filter: "))
 			s.WriteString(m.textInput.View())
-			s.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#888888")).Render(" (Esc to cancel)"))
+			s.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render(" (Esc to cancel)"))
 			s.WriteString("\n\n")
 		} else if m.filterQuery != "" {
 			filterStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#888888")).
+				Foreground(colorMuted).
 				PaddingLeft(2)
 			s.WriteString(filterStyle.Render(fmt.Sprintf("Filter: %s (press 'c' to clear)", m.filterQuery)))
 			s.WriteString("\n\n")
@@ -1811,7 +4579,7 @@ func (m tagsModel) View() string {
 		// Show help
 		if m.showHelp {
 			helpStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#888888")).
+				Foreground(colorMuted).
 				PaddingLeft(2)
 			if m.filterMode {
 				s.WriteString(helpStyle.Render("Type to filter • Enter: apply • Esc: cancel"))
@@ -1820,7 +4588,7 @@ func (m tagsModel) View() string {
 			}
 		} else {
 			helpStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#888888")).
+				Foreground(colorMuted).
 				PaddingLeft(2)
 			s.WriteString(helpStyle.Render("Press ? for help"))
 		}
@@ -1845,6 +4613,8 @@ type tagsDiffState int
 const (
 	tagsDiffStateLoading tagsDiffState = iota
 	tagsDiffStateList
+	tagsDiffStateFiles
+	tagsDiffStateFileDiff
 	tagsDiffStateError
 )
 
@@ -1852,12 +4622,17 @@ type tagsDiffModel struct {
 	state       tagsDiffState
 	spinner     spinner.Model
 	viewport    viewport.Model
+	diffView    viewport.Model // Scrollable diff of the selected file
 	commits     []CommitWithStats
+	files       []FileDiffStat
 	previousTag string
 	err         error
+	notice      string // Error while loading files or a file diff
 	width       int
 	height      int
 	cursor      int
+	fileCursor  int
+	loading     bool // Files or a file diff are being loaded
 	showHelp    bool
 	ready       bool
 }
@@ -1868,10 +4643,20 @@ type getTagsDiffMsg struct {
 	err         error
 }
 
+type getTagFilesMsg struct {
+	files []FileDiffStat
+	err   error
+}
+
+type getTagFileDiffMsg struct {
+	diff string
+	err  error
+}
+
 func initialTagsDiffModel() tagsDiffModel {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
 
 	return tagsDiffModel{
 		state:    tagsDiffStateLoading,
@@ -1887,6 +4672,14 @@ func (m tagsDiffModel) Init() tea.Cmd {
 	return tea.Batch(m.spinner.Tick, getTagsDiffCmd)
 }
 
+// baseRef returns the ref the changes are compared against, empty when there is no previous tag
+func (m tagsDiffModel) baseRef() string {
+	if m.previousTag == "(no previous tag)" {
+		return ""
+	}
+	return m.previousTag
+}
+
 func (m tagsDiffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
@@ -1895,15 +4688,19 @@ func (m tagsDiffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if !m.ready {
 			m.viewport = viewport.New(msg.Width, msg.Height-8) // Leave space for header and footer
 			m.viewport.YPosition = 0
+			m.diffView = viewport.New(msg.Width-4, msg.Height-4)
 			m.ready = true
 		} else {
 			m.viewport.Width = msg.Width
 			m.viewport.Height = msg.Height - 8
+			m.diffView.Width = msg.Width - 4
+			m.diffView.Height = msg.Height - 4
 		}
 		return m, nil
 
 	case tea.KeyMsg:
-		if m.state == tagsDiffStateList {
+		switch m.state {
+		case tagsDiffStateList:
 			switch msg.String() {
 			case "ctrl+c", "q":
 				return m, tea.Quit
@@ -1919,12 +4716,82 @@ func (m tagsDiffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor = 0
 			case "G":
 				m.cursor = len(m.commits) - 1
+			case "tab", "f":
+				m.state = tagsDiffStateFiles
+				if m.files == nil && !m.loading {
+					m.loading = true
+					return m, tea.Batch(m.spinner.Tick, getTagFilesCmd(m.baseRef(), "HEAD"))
+				}
+			case "?":
+				m.showHelp = !m.showHelp
+			}
+
+		case tagsDiffStateFiles:
+			switch msg.String() {
+			case "ctrl+c", "q":
+				return m, tea.Quit
+			case "tab", "c", "esc":
+				m.state = tagsDiffStateList
+			case "up", "k":
+				if m.fileCursor > 0 {
+					m.fileCursor--
+				}
+			case "down", "j":
+				if m.fileCursor < len(m.files)-1 {
+					m.fileCursor++
+				}
+			case "g":
+				m.fileCursor = 0
+			case "G":
+				m.fileCursor = max(len(m.files)-1, 0)
+			case "enter", "d":
+				if len(m.files) > 0 && !m.loading {
+					m.loading = true
+					m.notice = ""
+					return m, tea.Batch(m.spinner.Tick, getTagFileDiffCmd(m.baseRef(), "HEAD", m.files[m.fileCursor]))
+				}
 			case "?":
 				m.showHelp = !m.showHelp
 			}
+
+		case tagsDiffStateFileDiff:
+			switch msg.String() {
+			case "ctrl+c", "q":
+				return m, tea.Quit
+			case "esc", "backspace", "h", "left":
+				m.state = tagsDiffStateFiles
+			case "n", "p":
+				// Jump to the next/previous file without going back to the list
+				next := m.fileCursor + 1
+				if msg.String() == "p" {
+					next = m.fileCursor - 1
+				}
+				if next >= 0 && next < len(m.files) && !m.loading {
+					m.fileCursor = next
+					m.loading = true
+					return m, tea.Batch(m.spinner.Tick, getTagFileDiffCmd(m.baseRef(), "HEAD", m.files[m.fileCursor]))
+				}
+			case "g":
+				m.diffView.GotoTop()
+			case "G":
+				m.diffView.GotoBottom()
+			default:
+				var cmd tea.Cmd
+				m.diffView, cmd = m.diffView.Update(msg)
+				return m, cmd
+			}
+
+		default:
+			switch msg.String() {
+			case "ctrl+c", "q":
+				return m, tea.Quit
+			}
 		}
 
 	case spinner.TickMsg:
+		if m.state != tagsDiffStateLoading && !m.loading {
+			return m, nil
+		}
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
@@ -1944,69 +4811,154 @@ func (m tagsDiffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.state = tagsDiffStateList
 		return m, nil
+
+	case getTagFilesMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Failed to load changed files: %v", msg.err)
+			m.state = tagsDiffStateList
+			return m, nil
+		}
+		m.files = msg.files
+		return m, nil
+
+	case getTagFileDiffMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Failed to load diff: %v", msg.err)
+			return m, nil
+		}
+		m.diffView.SetContent(renderColoredDiff(msg.diff))
+		m.diffView.GotoTop()
+		m.state = tagsDiffStateFileDiff
+		return m, nil
 	}
 
 	return m, nil
 }
 
+// renderColoredDiff colors a unified diff: additions green, deletions red, hunks purple
+func renderColoredDiff(diff string) string {
+	addStyle := lipgloss.NewStyle().Foreground(colorSuccess)
+	delStyle := lipgloss.NewStyle().Foreground(colorDanger)
+	hunkStyle := lipgloss.NewStyle().Foreground(colorPrimary)
+	metaStyle := lipgloss.NewStyle().Foreground(colorMuted)
+
+	if strings.TrimSpace(diff) == "" {
+		return metaStyle.Render("No textual changes")
+	}
+
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---"):
+			lines[i] = metaStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = addStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = delStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = hunkStyle.Render(line)
+		case strings.HasPrefix(line, "diff ") || strings.HasPrefix(line, "index ") ||
+			strings.HasPrefix(line, "similarity ") || strings.HasPrefix(line, "rename ") ||
+			strings.HasPrefix(line, "new file") || strings.HasPrefix(line, "deleted file") ||
+			strings.HasPrefix(line, "Binary files"):
+			lines[i] = metaStyle.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (m tagsDiffModel) View() string {
+	hashStyle := lipgloss.NewStyle().Foreground(colorWarning)
+	msgStyle := lipgloss.NewStyle().Foreground(colorText)
+	timeStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	cursorStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	addStyle := lipgloss.NewStyle().Foreground(colorSuccess)
+	delStyle := lipgloss.NewStyle().Foreground(colorDanger)
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorPrimary).
+		PaddingLeft(2)
+	helpStyle := lipgloss.NewStyle().
+		Foreground(colorMuted).
+		PaddingLeft(2)
+
 	switch m.state {
 	case tagsDiffStateLoading:
 		return fmt.Sprintf("%s Loading diff...", m.spinner.View())
 
-	case tagsDiffStateList:
+	case tagsDiffStateList, tagsDiffStateFiles:
 		if !m.ready {
 			return "Loading..."
 		}
 
-		// Build commits content for viewport
-		hashStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFAA00"))
-		msgStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
-		timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
-		cursorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4")).Bold(true)
-		addStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575"))
-		delStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555"))
-
 		var content strings.Builder
-		for i, commit := range m.commits {
-			cursor := "  "
-			if i == m.cursor {
-				cursor = cursorStyle.Render("→ ")
-			}
+		cursorLine := 0
+		if m.state == tagsDiffStateList {
+			cursorLine = m.cursor
+			for i, commit := range m.commits {
+				cursor := "  "
+				if i == m.cursor {
+					cursor = cursorStyle.Render("→ ")
+				}
 
-			// Format: cursor hash +add -del message (time)
-			statsStr := ""
-			if commit.Additions > 0 || commit.Deletions > 0 {
-				statsStr = fmt.Sprintf(" %s %s",
-					addStyle.Render(fmt.Sprintf("+%d", commit.Additions)),
-					delStyle.Render(fmt.Sprintf("-%d", commit.Deletions)),
-				)
-			}
+				// Format: cursor hash +add -del message (time)
+				statsStr := ""
+				if commit.Additions > 0 || commit.Deletions > 0 {
+					statsStr = fmt.Sprintf(" %s %s",
+						addStyle.Render(fmt.Sprintf("+%d", commit.Additions)),
+						delStyle.Render(fmt.Sprintf("-%d", commit.Deletions)),
+					)
+				}
+
+				// Truncate message if needed
+				msg := commit.Message
+				maxMsgLen := m.width - 40
+				if maxMsgLen < 20 {
+					maxMsgLen = 20
+				}
+				if len(msg) > maxMsgLen {
+					msg = msg[:maxMsgLen-3] + "..."
+				}
 
-			// Truncate message if needed
-			msg := commit.Message
-			maxMsgLen := m.width - 40
-			if maxMsgLen < 20 {
-				maxMsgLen = 20
+				content.WriteString(fmt.Sprintf("%s%s%s  %s  %s\n",
+					cursor,
+					hashStyle.Render(commit.ShortHash),
+					statsStr,
+					msgStyle.Render(msg),
+					timeStyle.Render(commit.RelativeTime),
+				))
 			}
-			if len(msg) > maxMsgLen {
-				msg = msg[:maxMsgLen-3] + "..."
+		} else {
+			cursorLine = m.fileCursor
+			switch {
+			case m.loading && m.files == nil:
+				content.WriteString(fmt.Sprintf("%s Loading changed files...\n", m.spinner.View()))
+			case len(m.files) == 0:
+				content.WriteString(timeStyle.Render("No files changed") + "\n")
 			}
+			for i, file := range m.files {
+				cursor := "  "
+				if i == m.fileCursor {
+					cursor = cursorStyle.Render("→ ")
+				}
 
-			content.WriteString(fmt.Sprintf("%s%s%s  %s  %s\n",
-				cursor,
-				hashStyle.Render(commit.ShortHash),
-				statsStr,
-				msgStyle.Render(msg),
-				timeStyle.Render(commit.RelativeTime),
-			))
+				statsStr := timeStyle.Render(fmt.Sprintf("%-12s", "binary"))
+				if !file.Binary {
+					statsStr = fmt.Sprintf("%s %s",
+						addStyle.Render(fmt.Sprintf("%6s", fmt.Sprintf("+%d", file.Additions))),
+						delStyle.Render(fmt.Sprintf("%-5s", fmt.Sprintf("-%d", file.Deletions))),
+					)
+				}
+
+				content.WriteString(fmt.Sprintf("%s%s  %s\n", cursor, statsStr, msgStyle.Render(file.DisplayPath())))
+			}
 		}
 
 		m.viewport.SetContent(content.String())
 
 		// Auto-scroll to keep cursor visible
-		lineHeight := 1
-		cursorLine := m.cursor * lineHeight
 		if cursorLine < m.viewport.YOffset {
 			m.viewport.YOffset = cursorLine
 		} else if cursorLine >= m.viewport.YOffset+m.viewport.Height {
@@ -2016,12 +4968,14 @@ func (m tagsDiffModel) View() string {
 		// Build final view
 		var s strings.Builder
 
-		titleStyle := lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#7D56F4")).
-			PaddingLeft(2)
-
 		s.WriteString(titleStyle.Render("Changes since " + m.previousTag))
+		tabStyle := lipgloss.NewStyle().Foreground(colorMuted)
+		activeTabStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Underline(true)
+		commitsTab, filesTab := activeTabStyle.Render("Commits"), tabStyle.Render("Files")
+		if m.state == tagsDiffStateFiles {
+			commitsTab, filesTab = tabStyle.Render("Commits"), activeTabStyle.Render("Files")
+		}
+		s.WriteString("   " + commitsTab + tabStyle.Render(" | ") + filesTab)
 		s.WriteString("\n\n")
 
 		// Summary
@@ -2033,10 +4987,14 @@ func (m tagsDiffModel) View() string {
 		}
 
 		summaryStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#888888")).
+			Foreground(colorMuted).
 			PaddingLeft(2)
 
-		s.WriteString(summaryStyle.Render(fmt.Sprintf("%d commits  ", len(m.commits))))
+		summary := fmt.Sprintf("%d commits  ", len(m.commits))
+		if m.files != nil {
+			summary = fmt.Sprintf("%d commits  %d files  ", len(m.commits), len(m.files))
+		}
+		s.WriteString(summaryStyle.Render(summary))
 		s.WriteString(addStyle.Render(fmt.Sprintf("+%d", totalAdditions)))
 		s.WriteString(summaryStyle.Render("  "))
 		s.WriteString(delStyle.Render(fmt.Sprintf("-%d", totalDeletions)))
@@ -2049,20 +5007,43 @@ func (m tagsDiffModel) View() string {
 		s.WriteString(viewportStyle.Render(m.viewport.View()))
 		s.WriteString("\n")
 
+		if m.notice != "" {
+			s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(errorStyle.Render(m.notice)))
+			s.WriteString("\n")
+		}
+
 		if m.showHelp {
-			helpStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#888888")).
-				PaddingLeft(2)
-			s.WriteString(helpStyle.Render("↑/k: up  ↓/j: down  g: top  G: bottom  ?: help  q: quit"))
+			if m.state == tagsDiffStateFiles {
+				loading := ""
+				if m.loading && m.files != nil {
+					loading = m.spinner.View() + " "
+				}
+				s.WriteString(helpStyle.Render(loading + "↑/k: up  ↓/j: down  g: top  G: bottom  enter: view diff  tab: commits  ?: help  q: quit"))
+			} else {
+				s.WriteString(helpStyle.Render("↑/k: up  ↓/j: down  g: top  G: bottom  tab: files  ?: help  q: quit"))
+			}
 		} else {
-			helpStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#888888")).
-				PaddingLeft(2)
 			s.WriteString(helpStyle.Render("Press ? for help"))
 		}
 
 		return s.String()
 
+	case tagsDiffStateFileDiff:
+		var s strings.Builder
+		file := m.files[m.fileCursor]
+		s.WriteString(titleStyle.Render(file.DisplayPath()))
+		s.WriteString(timeStyle.Render(fmt.Sprintf("  %s..HEAD  (%d/%d)", m.previousTag, m.fileCursor+1, len(m.files))))
+		s.WriteString("\n\n")
+		s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(m.diffView.View()))
+		s.WriteString("\n")
+		if m.notice != "" {
+			s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(errorStyle.Render(m.notice)))
+			s.WriteString("\n")
+		}
+		s.WriteString(helpStyle.Render(fmt.Sprintf("%3.f%%  ↑/↓: scroll  pgup/pgdn: page  n/p: next/prev file  esc: back  q: quit",
+			m.diffView.ScrollPercent()*100)))
+		return s.String()
+
 	case tagsDiffStateError:
 		return errorStyle.Render(fmt.Sprintf("✗ Error: %s", m.err))
 	}
@@ -2070,22 +5051,48 @@ func (m tagsDiffModel) View() string {
 	return ""
 }
 
-func getTagsDiffCmd() tea.Msg {
-	// Get the most recent tag
-	prevTag, err := GetMostRecentTag()
-	if err != nil {
-		prevTag = "(no previous tag)"
+func getTagFilesCmd(from, to string) tea.Cmd {
+	return func() tea.Msg {
+		files, err := GetChangedFilesBetween(from, to)
+		return getTagFilesMsg{files: files, err: err}
+	}
+}
+
+func getTagFileDiffCmd(from, to string, file FileDiffStat) tea.Cmd {
+	return func() tea.Msg {
+		diff, err := GetFileDiffBetween(from, to, file)
+		return getTagFileDiffMsg{diff: diff, err: err}
 	}
+}
 
-	// Get commits since that tag
-	commits, err := GetCommitsSinceTag(prevTag)
+func getTagsDiffCmd() tea.Msg {
+	commits, prevTag, err := GetUnreleasedCommits()
 	if err != nil {
 		return getTagsDiffMsg{err: err}
 	}
+	if prevTag == "" {
+		prevTag = "(no previous tag)"
+	}
 
 	return getTagsDiffMsg{commits: commits, previousTag: prevTag}
 }
 
+// getTagReleaseCmd loads the commits the requested tag will cover, compared against the
+// previous release with the same prefix (so monorepo components like "api/v" and
+// prerelease lines are compared against their own history)
+func getTagReleaseCmd(req tagCreateRequest) tea.Cmd {
+	return func() tea.Msg {
+		commits, prevTag, err := GetReleaseCommits(req.target(), req.Prefix, req.isPrerelease())
+		if err != nil {
+			return getTagsDiffMsg{err: err}
+		}
+		if prevTag == "" {
+			prevTag = "(no previous tag)"
+		}
+		return getTagsDiffMsg{commits: commits, previousTag: prevTag}
+	}
+}
+
 // Tags Create TUI model
 type tagsCreateState int
 
@@ -2095,6 +5102,8 @@ const (
 	tagsCreateStateConfirm
 	tagsCreateStateCreating
 	tagsCreateStatePushing
+	tagsCreateStateAnnounce // Previewing the release announcement
+	tagsCreateStateAnnouncing
 	tagsCreateStateDone
 	tagsCreateStateError
 )
@@ -2106,8 +5115,13 @@ type tagsCreateModel struct {
 	commits     []CommitWithStats
 	previousTag string
 	newTag      string
+	request     tagCreateRequest
 	tagURL      string
 	err         error
+	webhook     string // Where the release is announced, empty when off
+	announce    Announcement
+	announced   bool
+	announceErr error
 	width       int
 	height      int
 	cursor      int
@@ -2124,15 +5138,53 @@ type pushTagMsg struct {
 	err    error
 }
 
-func initialTagsCreateModel(tagName string) tagsCreateModel {
+type announceMsg struct {
+	err error
+}
+
+// tagCreateRequest describes a tag to create and the commit it should point at
+type tagCreateRequest struct {
+	Name         string // Final tag name after applying the tag policy
+	RequestedTag string // Name as typed by the user, for the "resolved from" hint
+	Prefix       string // Policy prefix the name matched, used to find the previous release
+	Target       string // Commit-ish to tag, HEAD when empty
+	PromoteFrom  string // Prerelease tag being promoted to a final release, if any
+}
+
+// target returns the commit-ish the tag will point at
+func (r tagCreateRequest) target() string {
+	if r.Target == "" {
+		return "HEAD"
+	}
+	return r.Target
+}
+
+// isPrerelease reports whether the tag is a prerelease (e.g. v1.3.0-rc.1)
+func (r tagCreateRequest) isPrerelease() bool {
+	v, ok := ParseSemVer(strings.TrimPrefix(r.Name, r.Prefix))
+	return ok && v.Prerelease != ""
+}
+
+// title describes the operation for previews
+func (r tagCreateRequest) title() string {
+	if r.PromoteFrom != "" {
+		return fmt.Sprintf("Promote %s → %s", r.PromoteFrom, r.Name)
+	}
+	return fmt.Sprintf("Create tag %s", r.Name)
+}
+
+// initialTagsCreateModel creates the tag creation TUI for a tag name that was
+// already resolved against the tag policy
+func initialTagsCreateModel(req tagCreateRequest) tagsCreateModel {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
 
 	return tagsCreateModel{
 		state:    tagsCreateStateLoading,
 		spinner:  s,
-		newTag:   tagName,
+		newTag:   req.Name,
+		request:  req,
 		showHelp: true,
 		width:    80,
 		height:   24,
@@ -2141,7 +5193,7 @@ func initialTagsCreateModel(tagName string) tagsCreateModel {
 }
 
 func (m tagsCreateModel) Init() tea.Cmd {
-	return tea.Batch(m.spinner.Tick, getTagsDiffCmd)
+	return tea.Batch(m.spinner.Tick, getTagReleaseCmd(m.request))
 }
 
 func (m tagsCreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -2174,11 +5226,21 @@ func (m tagsCreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			case "y", "Y", "enter":
 				m.state = tagsCreateStateCreating
-				return m, createTagCmd(m.newTag, m.generateTagMessage())
+				return m, createTagCmd(m.newTag, m.generateTagMessage(), m.request.target())
 			case "?":
 				m.showHelp = !m.showHelp
 			}
 		}
+		if m.state == tagsCreateStateAnnounce {
+			switch msg.String() {
+			case "y", "Y", "enter":
+				m.state = tagsCreateStateAnnouncing
+				return m, announceCmd(m.webhook, m.announce)
+			case "ctrl+c", "q", "n", "N", "esc":
+				m.state = tagsCreateStateDone
+				return m, tea.Quit
+			}
+		}
 
 	case spinner.TickMsg:
 		var cmd tea.Cmd
@@ -2217,6 +5279,21 @@ func (m tagsCreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if url, err := GetTagURL(m.newTag); err == nil {
 			m.tagURL = url
 		}
+		if m.webhook = announceWebhook(); m.webhook != "" {
+			previous := m.previousTag
+			if previous == "(no previous tag)" {
+				previous = ""
+			}
+			m.announce = BuildAnnouncement(m.newTag, previous, m.commits)
+			m.state = tagsCreateStateAnnounce
+			return m, nil
+		}
+		m.state = tagsCreateStateDone
+		return m, tea.Quit
+
+	case announceMsg:
+		m.announced = msg.err == nil
+		m.announceErr = msg.err
 		m.state = tagsCreateStateDone
 		return m, tea.Quit
 	}
@@ -2224,14 +5301,29 @@ func (m tagsCreateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m tagsCreateModel) generateTagMessage() string {
-	var sb strings.Builder
-
-	for _, commit := range m.commits {
-		sb.WriteString(fmt.Sprintf("- %s\n", commit.Message))
+// ExitSummary keeps the new tag visible after the alt screen closes
+func (m tagsCreateModel) ExitSummary() string {
+	switch m.state {
+	case tagsCreateStateDone:
+		summary := fmt.Sprintf("✓ Created and pushed tag %s", m.newTag)
+		if m.tagURL != "" {
+			summary += " " + m.tagURL
+		}
+		return summary
+	case tagsCreateStateError:
+		return fmt.Sprintf("✗ Error: %v", m.err)
 	}
+	return ""
+}
+
+func (m tagsCreateModel) generateTagMessage() string {
+	return generateTagMessage(m.commits)
+}
 
-	return sb.String()
+// generateTagMessage builds the annotated tag message from the commits in the release,
+// grouped by conventional commit type
+func generateTagMessage(commits []CommitWithStats) string {
+	return GenerateChangelog(commits, nil)
 }
 
 func (m tagsCreateModel) View() string {
@@ -2244,15 +5336,19 @@ func (m tagsCreateModel) View() string {
 
 		titleStyle := lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#7D56F4")).
+			Foreground(colorPrimary).
 			PaddingLeft(2)
 
-		s.WriteString(titleStyle.Render(fmt.Sprintf("Create tag %s", m.newTag)))
+		s.WriteString(titleStyle.Render(m.request.title()))
+		if m.request.RequestedTag != "" && m.request.RequestedTag != m.newTag {
+			s.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render(
+				fmt.Sprintf("  (resolved from '%s')", m.request.RequestedTag)))
+		}
 		s.WriteString("\n\n")
 
 		// Previous tag info
 		prevStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#888888")).
+			Foreground(colorMuted).
 			PaddingLeft(2)
 		if m.previousTag != "" && m.previousTag != "(no previous tag)" {
 			s.WriteString(prevStyle.Render(fmt.Sprintf("Previous tag: %s", m.previousTag)))
@@ -2270,10 +5366,10 @@ func (m tagsCreateModel) View() string {
 		}
 
 		summaryStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#888888")).
+			Foreground(colorMuted).
 			PaddingLeft(2)
-		addStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575"))
-		delStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555"))
+		addStyle := lipgloss.NewStyle().Foreground(colorSuccess)
+		delStyle := lipgloss.NewStyle().Foreground(colorDanger)
 
 		if len(m.commits) > 0 {
 			s.WriteString(summaryStyle.Render(fmt.Sprintf("%d commits  ", len(m.commits))))
@@ -2283,10 +5379,10 @@ func (m tagsCreateModel) View() string {
 			s.WriteString("\n\n")
 
 			// Commits
-			hashStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFAA00"))
-			msgStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
-			timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
-			cursorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4")).Bold(true)
+			hashStyle := lipgloss.NewStyle().Foreground(colorWarning)
+			msgStyle := lipgloss.NewStyle().Foreground(colorText)
+			timeStyle := lipgloss.NewStyle().Foreground(colorMuted)
+			cursorStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
 			contentStyle := lipgloss.NewStyle().PaddingLeft(2).PaddingRight(2)
 
 			var content strings.Builder
@@ -2339,19 +5435,37 @@ func (m tagsCreateModel) View() string {
 	case tagsCreateStatePushing:
 		return fmt.Sprintf("%s Pushing tag %s...", m.spinner.View(), m.newTag)
 
+	case tagsCreateStateAnnounce:
+		format := announceFormat(m.webhook)
+		boxed := boxStyle.Render(lipgloss.NewStyle().Foreground(colorText).Render(m.announce.Render(format)))
+		return fmt.Sprintf("%s\n\n%s\n%s\n\n%s",
+			successStyle.Render(fmt.Sprintf("✓ Created and pushed tag %s", m.newTag)),
+			infoStyle.Render(fmt.Sprintf("Announcement (%s):", format)),
+			boxed,
+			highlightStyle.Render("Post announcement? (y/n): "),
+		)
+
+	case tagsCreateStateAnnouncing:
+		return fmt.Sprintf("%s Posting the announcement...", m.spinner.View())
+
 	case tagsCreateStateDone:
 		var s strings.Builder
 		s.WriteString(successStyle.Render(fmt.Sprintf("✓ Created and pushed tag %s", m.newTag)))
 		if m.previousTag != "" && m.previousTag != "(no previous tag)" {
 			s.WriteString("\n")
-			infoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+			infoStyle := lipgloss.NewStyle().Foreground(colorMuted)
 			s.WriteString(infoStyle.Render(fmt.Sprintf("  %d commits since %s", len(m.commits), m.previousTag)))
 		}
 		if m.tagURL != "" {
 			s.WriteString("\n")
-			linkStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+			linkStyle := lipgloss.NewStyle().Foreground(colorPrimary)
 			s.WriteString(linkStyle.Render(fmt.Sprintf("  %s", m.tagURL)))
 		}
+		if m.announced {
+			s.WriteString("\n" + successStyle.Render("✓ Posted the announcement"))
+		} else if m.announceErr != nil {
+			s.WriteString("\n" + errorStyle.Render(fmt.Sprintf("✗ Failed to post the announcement: %v", m.announceErr)))
+		}
 		return s.String()
 
 	case tagsCreateStateError:
@@ -2361,13 +5475,19 @@ func (m tagsCreateModel) View() string {
 	return ""
 }
 
-func createTagCmd(tagName, message string) tea.Cmd {
+func createTagCmd(tagName, message, ref string) tea.Cmd {
 	return func() tea.Msg {
-		err := CreateAnnotatedTag(tagName, message)
+		err := CreateAnnotatedTagAt(tagName, message, ref)
 		return createTagMsg{err: err}
 	}
 }
 
+func announceCmd(webhook string, a Announcement) tea.Cmd {
+	return func() tea.Msg {
+		return announceMsg{err: PostAnnouncement(webhook, announceFormat(webhook), a)}
+	}
+}
+
 func pushTagCmd(tagName string) tea.Cmd {
 	return func() tea.Msg {
 		output, err := PushTag(tagName)
@@ -2385,22 +5505,30 @@ const (
 )
 
 type tagsInspectModel struct {
-	state          tagsInspectState
-	spinner        spinner.Model
-	viewport       viewport.Model
-	tagName        string
-	tagDetail      TagDetailInfo
-	commits        []CommitWithStats
-	previousTag    string
-	totalAdditions int
-	totalDeletions int
-	totalFiles     int
-	cursor         int
-	err            error
-	width          int
-	height         int
-	showHelp       bool
-	ready          bool
+	state           tagsInspectState
+	spinner         spinner.Model
+	textInput       textinput.Model
+	viewport        viewport.Model
+	tagName         string
+	tagDetail       TagDetailInfo
+	commits         []CommitWithStats
+	filteredCommits []CommitWithStats
+	previousTag     string
+	totalAdditions  int
+	totalDeletions  int
+	totalFiles      int
+	tagURL          string // Release page on the remote, empty if unknown
+	signature       TagSignature
+	notice          string // Result of the last action, e.g. opening the browser
+	cursor          int
+	offset          int // First commit shown in the list
+	filterMode      bool
+	filterQuery     string
+	err             error
+	width           int
+	height          int
+	showHelp        bool
+	ready           bool
 }
 
 type getTagInspectMsg struct {
@@ -2410,22 +5538,39 @@ type getTagInspectMsg struct {
 	additions   int
 	deletions   int
 	files       int
+	tagURL      string
+	signature   TagSignature
 	err         error
 }
 
+type openURLMsg struct {
+	url string
+	err error
+}
+
 func initialTagsInspectModel(tagName string) tagsInspectModel {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
+
+	ti := textinput.New()
+	ti.Placeholder = "Type to filter commits..."
+	ti.CharLimit = 100
+	ti.Width = 50
+	ti.Prompt = ""
+	ti.PromptStyle = lipgloss.NewStyle().Foreground(colorPrimary)
+	ti.TextStyle = lipgloss.NewStyle().Foreground(colorText)
+	ti.Cursor.Style = lipgloss.NewStyle().Foreground(colorPrimary)
 
 	return tagsInspectModel{
-		state:    tagsInspectStateLoading,
-		spinner:  s,
-		tagName:  tagName,
-		showHelp: true,
-		width:    80,
-		height:   24,
-		ready:    false,
+		state:     tagsInspectStateLoading,
+		spinner:   s,
+		textInput: ti,
+		tagName:   tagName,
+		showHelp:  true,
+		width:     80,
+		height:    24,
+		ready:     false,
 	}
 }
 
@@ -2438,38 +5583,92 @@ func (m tagsInspectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.textInput.Width = msg.Width - 20
 		if !m.ready {
-			m.viewport = viewport.New(msg.Width, msg.Height-12) // Leave space for header, body, summary, and footer
+			m.viewport = viewport.New(msg.Width, m.listHeight())
 			m.viewport.YPosition = 0
 			m.ready = true
 		} else {
 			m.viewport.Width = msg.Width
-			m.viewport.Height = msg.Height - 12
 		}
+		m.scrollToCursor()
 		return m, nil
 
 	case tea.KeyMsg:
 		if m.state == tagsInspectStateDisplay {
+			// Check for filter mode entry FIRST
+			if !m.filterMode && msg.String() == "/" {
+				m.filterMode = true
+				m.filterQuery = ""
+				m.textInput.SetValue("")
+				m.textInput.Focus()
+				return m, textinput.Blink
+			}
+
+			// Handle filter mode input
+			if m.filterMode {
+				switch msg.String() {
+				case "esc", "ctrl+c":
+					m.filterMode = false
+					m.filterQuery = ""
+					m.textInput.SetValue("")
+					m.textInput.Blur()
+					m.filteredCommits = m.commits
+					m.cursor = 0
+					m.offset = 0
+					return m, nil
+				case "enter":
+					m.filterMode = false
+					m.textInput.Blur()
+					return m, nil
+				default:
+					var cmd tea.Cmd
+					m.textInput, cmd = m.textInput.Update(msg)
+					m.filterQuery = m.textInput.Value()
+					m.applyFilter()
+					m.cursor = 0
+					m.offset = 0
+					return m, cmd
+				}
+			}
+
+			commits := m.getDisplayCommits()
 			switch msg.String() {
 			case "ctrl+c", "q":
 				return m, tea.Quit
+			case "o":
+				if m.tagURL == "" {
+					m.notice = "No release page - origin remote is missing or not recognized"
+					return m, nil
+				}
+				return m, openURLCmd(m.tagURL)
 			case "up", "k":
 				if m.cursor > 0 {
 					m.cursor--
 				}
 			case "down", "j":
-				if m.cursor < len(m.commits)-1 {
+				if m.cursor < len(commits)-1 {
 					m.cursor++
 				}
-			case "g":
+			case "pgup", "ctrl+b", "ctrl+u":
+				m.cursor = max(m.cursor-m.listHeight(), 0)
+			case "pgdown", "ctrl+f", "ctrl+d", " ":
+				m.cursor = max(min(m.cursor+m.listHeight(), len(commits)-1), 0)
+			case "g", "home":
 				m.cursor = 0
-			case "G":
-				if len(m.commits) > 0 {
-					m.cursor = len(m.commits) - 1
+			case "G", "end":
+				if len(commits) > 0 {
+					m.cursor = len(commits) - 1
 				}
+			case "c":
+				m.filterQuery = ""
+				m.textInput.SetValue("")
+				m.filteredCommits = m.commits
+				m.cursor = 0
 			case "?":
 				m.showHelp = !m.showHelp
 			}
+			m.scrollToCursor()
 		} else {
 			switch msg.String() {
 			case "ctrl+c", "q":
@@ -2490,17 +5689,172 @@ func (m tagsInspectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.tagDetail = msg.tagDetail
 		m.commits = msg.commits
+		m.filteredCommits = msg.commits
 		m.previousTag = msg.previousTag
 		m.totalAdditions = msg.additions
 		m.totalDeletions = msg.deletions
 		m.totalFiles = msg.files
+		m.tagURL = msg.tagURL
+		m.signature = msg.signature
 		m.state = tagsInspectStateDisplay
 		return m, nil
+
+	case openURLMsg:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Could not open browser: %v", msg.err)
+		} else {
+			m.notice = "Opened " + msg.url
+		}
+		return m, nil
 	}
 
 	return m, nil
 }
 
+func (m *tagsInspectModel) applyFilter() {
+	if m.filterQuery == "" {
+		m.filteredCommits = m.commits
+		return
+	}
+
+	query := strings.ToLower(m.filterQuery)
+	filtered := make([]CommitWithStats, 0)
+
+	for _, commit := range m.commits {
+		if strings.Contains(strings.ToLower(commit.Message), query) ||
+			strings.Contains(strings.ToLower(commit.Author), query) ||
+			strings.Contains(strings.ToLower(commit.ShortHash), query) {
+			filtered = append(filtered, commit)
+		}
+	}
+
+	m.filteredCommits = filtered
+}
+
+func (m tagsInspectModel) getDisplayCommits() []CommitWithStats {
+	if m.filterQuery != "" {
+		if m.filteredCommits == nil {
+			return []CommitWithStats{}
+		}
+		return m.filteredCommits
+	}
+	if m.commits == nil {
+		return []CommitWithStats{}
+	}
+	return m.commits
+}
+
+// listHeight returns how many commit lines fit below the header and above the footer
+func (m tagsInspectModel) listHeight() int {
+	height := m.height - lipgloss.Height(m.header()) - 2 // Footer and spacing
+	if m.filterMode || m.filterQuery != "" {
+		height -= 2
+	}
+	if m.notice != "" {
+		height--
+	}
+	return max(height, 3)
+}
+
+// scrollToCursor adjusts the list offset so the cursor stays visible
+func (m *tagsInspectModel) scrollToCursor() {
+	height := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+	if maxOffset := max(len(m.getDisplayCommits())-height, 0); m.offset > maxOffset {
+		m.offset = maxOffset
+	}
+}
+
+// header renders the tag metadata, message, and summary shown above the commit list
+func (m tagsInspectModel) header() string {
+	var s strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorPrimary).
+		PaddingLeft(2)
+	hashStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	dimStyle := lipgloss.NewStyle().
+		Foreground(colorMuted).
+		PaddingLeft(2)
+	addStyle := lipgloss.NewStyle().Foreground(colorSuccess)
+	delStyle := lipgloss.NewStyle().Foreground(colorDanger)
+
+	// Header: Tag name and hash
+	s.WriteString(fmt.Sprintf("%s  %s\n",
+		titleStyle.Render("Tag: "+m.tagDetail.Name),
+		hashStyle.Render("("+m.tagDetail.ShortHash+")"),
+	))
+
+	// Tagger info
+	if m.tagDetail.TaggerName != "" {
+		s.WriteString(fmt.Sprintf("%s  %s\n",
+			dimStyle.Render("By: "+m.tagDetail.TaggerName),
+			dimStyle.Render(m.tagDetail.RelativeTime),
+		))
+	} else {
+		s.WriteString(dimStyle.Render(m.tagDetail.RelativeTime))
+		s.WriteString("\n")
+	}
+
+	// Signature verification (lightweight tags have no tagger and can't be signed)
+	if m.tagDetail.TaggerName != "" {
+		sigStyle := dimStyle
+		icon := "○"
+		switch m.signature.Status {
+		case SignatureGood:
+			sigStyle = lipgloss.NewStyle().Foreground(colorSuccess).PaddingLeft(2)
+			icon = "✓"
+		case SignatureBad:
+			sigStyle = lipgloss.NewStyle().Foreground(colorDanger).PaddingLeft(2)
+			icon = "✗"
+		case SignatureUntrusted, SignatureExpired, SignatureUnknown:
+			sigStyle = lipgloss.NewStyle().Foreground(colorWarning).PaddingLeft(2)
+			icon = "⚠"
+		}
+		s.WriteString(sigStyle.Render(icon + " " + m.signature.Summary()))
+		s.WriteString("\n")
+	}
+
+	// Release page
+	if m.tagURL != "" {
+		linkStyle := lipgloss.NewStyle().Foreground(colorPrimary).PaddingLeft(2)
+		s.WriteString(linkStyle.Render(m.tagURL))
+		s.WriteString("\n")
+	}
+
+	// Tag body/message
+	if m.tagDetail.Body != "" {
+		s.WriteString("\n")
+		bodyStyle := lipgloss.NewStyle().
+			Foreground(colorText).
+			PaddingLeft(4)
+		// Indent body lines
+		for _, line := range strings.Split(m.tagDetail.Body, "\n") {
+			s.WriteString(bodyStyle.Render(line) + "\n")
+		}
+	}
+
+	s.WriteString("\n")
+
+	// Summary line
+	s.WriteString(dimStyle.Render(fmt.Sprintf("%d commits", len(m.commits))))
+	if m.totalAdditions > 0 || m.totalDeletions > 0 {
+		s.WriteString("  " + addStyle.Render(fmt.Sprintf("+%d", m.totalAdditions)))
+		s.WriteString("  " + delStyle.Render(fmt.Sprintf("-%d", m.totalDeletions)))
+	}
+	if m.totalFiles > 0 {
+		s.WriteString("  " + dimStyle.Render(fmt.Sprintf("%d files", m.totalFiles)))
+	}
+	s.WriteString("\n")
+
+	return s.String()
+}
+
 func (m tagsInspectModel) View() string {
 	switch m.state {
 	case tagsInspectStateLoading:
@@ -2509,74 +5863,45 @@ func (m tagsInspectModel) View() string {
 	case tagsInspectStateDisplay:
 		var s strings.Builder
 
-		titleStyle := lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#7D56F4")).
-			PaddingLeft(2)
-		hashStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
 		dimStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#888888")).
+			Foreground(colorMuted).
 			PaddingLeft(2)
-		addStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575"))
-		delStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555"))
-		commitHashStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFAA00"))
-		msgStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
-		timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
-		cursorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4")).Bold(true)
-
-		// Header: Tag name and hash
-		s.WriteString(fmt.Sprintf("%s  %s\n",
-			titleStyle.Render("Tag: "+m.tagDetail.Name),
-			hashStyle.Render("("+m.tagDetail.ShortHash+")"),
-		))
-
-		// Tagger info
-		if m.tagDetail.TaggerName != "" {
-			s.WriteString(fmt.Sprintf("%s  %s\n",
-				dimStyle.Render("By: "+m.tagDetail.TaggerName),
-				dimStyle.Render(m.tagDetail.RelativeTime),
-			))
-		} else {
-			s.WriteString(dimStyle.Render(m.tagDetail.RelativeTime))
-			s.WriteString("\n")
-		}
-
-		// Tag body/message
-		if m.tagDetail.Body != "" {
-			s.WriteString("\n")
-			bodyStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#FFFFFF")).
-				PaddingLeft(4)
-			// Indent body lines
-			for _, line := range strings.Split(m.tagDetail.Body, "\n") {
-				s.WriteString(bodyStyle.Render(line) + "\n")
-			}
-		}
-
+		addStyle := lipgloss.NewStyle().Foreground(colorSuccess)
+		delStyle := lipgloss.NewStyle().Foreground(colorDanger)
+		commitHashStyle := lipgloss.NewStyle().Foreground(colorWarning)
+		msgStyle := lipgloss.NewStyle().Foreground(colorText)
+		timeStyle := lipgloss.NewStyle().Foreground(colorMuted)
+		cursorStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+
+		s.WriteString(m.header())
 		s.WriteString("\n")
 
-		// Summary line
-		summaryParts := []string{
-			fmt.Sprintf("%d commits", len(m.commits)),
-		}
-		s.WriteString(dimStyle.Render(summaryParts[0]))
-		if m.totalAdditions > 0 || m.totalDeletions > 0 {
-			s.WriteString("  " + addStyle.Render(fmt.Sprintf("+%d", m.totalAdditions)))
-			s.WriteString("  " + delStyle.Render(fmt.Sprintf("-%d", m.totalDeletions)))
-		}
-		if m.totalFiles > 0 {
-			s.WriteString("  " + dimStyle.Render(fmt.Sprintf("%d files", m.totalFiles)))
+		// Show filter bar
+		if m.filterMode {
+			filterLabelStyle := lipgloss.NewStyle().
+				Foreground(colorPrimary).
+				Bold(true).
+				PaddingLeft(2)
+			s.WriteString(filterLabelStyle.Render("Filter: "))
+			s.WriteString(m.textInput.View())
+			s.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render(" (Esc to cancel)"))
+			s.WriteString("\n\n")
+		} else if m.filterQuery != "" {
+			s.WriteString(dimStyle.Render(fmt.Sprintf("Filter: %s (%d of %d commits, press 'c' to clear)",
+				m.filterQuery, len(m.getDisplayCommits()), len(m.commits))))
+			s.WriteString("\n\n")
 		}
-		s.WriteString("\n\n")
 
 		// Commits list
-		contentStyle := lipgloss.NewStyle().PaddingLeft(2).PaddingRight(2)
 		var content strings.Builder
+		commits := m.getDisplayCommits()
 
 		if len(m.commits) == 0 {
 			content.WriteString(dimStyle.Render("No commits in this tag range") + "\n")
+		} else if len(commits) == 0 {
+			content.WriteString(dimStyle.Render("No commits match filter") + "\n")
 		} else {
-			for i, commit := range m.commits {
+			for i, commit := range commits {
 				cursor := "  "
 				if i == m.cursor {
 					cursor = cursorStyle.Render("→ ")
@@ -2611,19 +5936,35 @@ func (m tagsInspectModel) View() string {
 			}
 		}
 
-		s.WriteString(contentStyle.Render(content.String()))
+		m.viewport.Height = m.listHeight()
+		m.viewport.SetContent(strings.TrimSuffix(content.String(), "\n"))
+		m.viewport.SetYOffset(m.offset)
+
+		viewportStyle := lipgloss.NewStyle().
+			PaddingLeft(2).
+			PaddingRight(2)
+		s.WriteString(viewportStyle.Render(m.viewport.View()))
 		s.WriteString("\n")
 
 		// Help
+		helpStyle := lipgloss.NewStyle().
+			Foreground(colorMuted).
+			PaddingLeft(2)
+		if m.notice != "" && !m.filterMode {
+			s.WriteString(helpStyle.Render(m.notice))
+			s.WriteString("\n")
+		}
 		if m.showHelp {
-			helpStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#888888")).
-				PaddingLeft(2)
-			s.WriteString(helpStyle.Render("↑/k: up  ↓/j: down  g/G: top/bottom  ?: help  q: quit"))
+			position := ""
+			if len(commits) > m.listHeight() {
+				position = fmt.Sprintf("%d/%d  ", m.cursor+1, len(commits))
+			}
+			if m.filterMode {
+				s.WriteString(helpStyle.Render("Type to filter • Enter: apply • Esc: cancel"))
+			} else {
+				s.WriteString(helpStyle.Render(position + "↑/k: up  ↓/j: down  pgup/pgdn: page  g/G: top/bottom  /: filter  c: clear  o: open in browser  ?: help  q: quit"))
+			}
 		} else {
-			helpStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#888888")).
-				PaddingLeft(2)
 			s.WriteString(helpStyle.Render("Press ? for help"))
 		}
 
@@ -2656,6 +5997,14 @@ func getTagInspectCmd(tagName string) tea.Cmd {
 		// Get aggregate stats
 		additions, deletions, files, _ := GetTagRangeDiffStats(prevTag, tagName)
 
+		// Release page is optional - repositories without a remote just don't show it
+		tagURL, _ := GetTagURL(tagName)
+
+		signature, err := GetTagSignature(tagName)
+		if err != nil {
+			return getTagInspectMsg{err: err}
+		}
+
 		return getTagInspectMsg{
 			tagDetail:   detail,
 			commits:     commits,
@@ -2663,6 +6012,14 @@ func getTagInspectCmd(tagName string) tea.Cmd {
 			additions:   additions,
 			deletions:   deletions,
 			files:       files,
+			tagURL:      tagURL,
+			signature:   signature,
 		}
 	}
 }
+
+func openURLCmd(url string) tea.Cmd {
+	return func() tea.Msg {
+		return openURLMsg{url: url, err: OpenBrowser(url)}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSaveProviderCheckOrder(t *testing.T) {
+	missing := ModelMissingError{Model: "llama3.2:3b"}
+	down := errors.New("connection refused")
+	diff := getDiffMsg{diff: "diff --git a/main.go b/main.go\n+++ b/main.go\n+func main() {}\n"}
+	generated := generateMsgMsg{messages: []string{"feat: add main"}}
+
+	tests := []struct {
+		name string
+		msgs []any
+		want state
+	}{
+		{name: "Check, then diff", msgs: []any{checkProviderMsg{}, diff, generated}, want: stateConfirming},
+		{name: "Diff, then check", msgs: []any{diff, checkProviderMsg{}, generated}, want: stateConfirming},
+		{name: "Missing model, then diff", msgs: []any{checkProviderMsg{err: missing}, diff}, want: stateConfirmingPull},
+		{name: "Diff, then missing model", msgs: []any{diff, checkProviderMsg{err: missing}}, want: stateConfirmingPull},
+		{name: "Cancelled generation after the check", msgs: []any{diff, checkProviderMsg{err: missing}, generateMsgMsg{err: errors.New("context canceled")}}, want: stateConfirmingPull},
+		{name: "Generation finds the missing model first", msgs: []any{diff, generateMsgMsg{err: missing}, checkProviderMsg{err: missing}}, want: stateConfirmingPull},
+		{name: "Generation fails, then the check names the model", msgs: []any{diff, generateMsgMsg{err: down}, checkProviderMsg{err: missing}}, want: stateConfirmingPull},
+		{name: "Provider down, then diff", msgs: []any{checkProviderMsg{err: down}, diff}, want: stateEditing},
+		{name: "Diff, then provider down", msgs: []any{diff, checkProviderMsg{err: down}}, want: stateEditing},
+		{name: "Generation fails, check passes", msgs: []any{diff, generateMsgMsg{err: down}, checkProviderMsg{}}, want: stateEditing},
+		{name: "Generation fails after the check", msgs: []any{checkProviderMsg{}, diff, generateMsgMsg{err: down}}, want: stateEditing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := initialModel(42)
+			m.state = stateGettingDiff
+			for _, msg := range tt.msgs {
+				next, _ := m.Update(msg)
+				m = next.(model)
+			}
+			if m.state != tt.want {
+				t.Errorf("Expected state %d, got %d (providerErr %v)", tt.want, m.state, m.providerErr)
+			}
+			if tt.want == stateConfirmingPull && m.missingModel == nil {
+				t.Error("Expected the missing model to be recorded for the pull prompt")
+			}
+		})
+	}
+}
+
+func TestSaveProviderFailure(t *testing.T) {
+	down := errors.New("connection refused")
+	diff := getDiffMsg{diff: "diff --git a/main.go b/main.go\n+++ b/main.go\n+func main() {}\n"}
+	esc := tea.KeyMsg{Type: tea.KeyEsc}
+
+	tests := []struct {
+		name      string
+		msgs      []tea.Msg
+		want      state
+		cancelled bool
+	}{
+		{name: "Provider check fails", msgs: []tea.Msg{checkProviderMsg{err: down}, diff}, want: stateEditing},
+		{name: "Generation fails", msgs: []tea.Msg{checkProviderMsg{}, diff, generateMsgMsg{err: down}}, want: stateEditing},
+		{name: "Esc after the check failed", msgs: []tea.Msg{checkProviderMsg{err: down}, diff, esc}, want: stateDone, cancelled: true},
+		{name: "Esc after generation failed", msgs: []tea.Msg{checkProviderMsg{}, diff, generateMsgMsg{err: down}, esc}, want: stateDone, cancelled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := initialModel(42)
+			m.state = stateGettingDiff
+			for _, msg := range tt.msgs {
+				next, _ := m.Update(msg)
+				m = next.(model)
+			}
+			if m.state != tt.want {
+				t.Fatalf("Expected state %d, got %d", tt.want, m.state)
+			}
+			if !errors.Is(m.providerErr, down) {
+				t.Errorf("Expected providerErr %v, got %v", down, m.providerErr)
+			}
+			if tt.cancelled && (m.err == nil || m.err.Error() != "commit cancelled") {
+				t.Errorf("Expected the commit cancelled, got %v", m.err)
+			}
+			if !tt.cancelled && (m.err != nil || m.commitMessage != "") {
+				t.Errorf("Expected an empty message to write by hand, got %q (%v)", m.commitMessage, m.err)
+			}
+		})
+	}
+}
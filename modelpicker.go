@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type modelPickerState int
+
+const (
+	modelPickerStateLoading modelPickerState = iota
+	modelPickerStateList
+	modelPickerStateDone
+	modelPickerStateError
+)
+
+// modelPickerModel lists the Ollama models and saves the chosen one as
+// ollama.model
+type modelPickerModel struct {
+	state      modelPickerState
+	spinner    spinner.Model
+	models     []OllamaModel
+	cursor     int
+	configPath string // File the choice is saved to
+	selected   string
+	err        error
+}
+
+type listModelsMsg struct {
+	models []OllamaModel
+	err    error
+}
+
+func initialModelPickerModel(configPath string) modelPickerModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
+
+	return modelPickerModel{
+		state:      modelPickerStateLoading,
+		spinner:    s,
+		configPath: configPath,
+	}
+}
+
+func (m modelPickerModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, listModels)
+}
+
+func (m modelPickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		}
+		if m.state != modelPickerStateList {
+			return m, nil
+		}
+		switch msg.String() {
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.models)-1 {
+				m.cursor++
+			}
+		case "enter":
+			m.selected = m.models[m.cursor].Name
+			if err := SetConfigValue(m.configPath, "ollama", "model", m.selected); err != nil {
+				m.state = modelPickerStateError
+				m.err = err
+				return m, tea.Quit
+			}
+			m.state = modelPickerStateDone
+			return m, tea.Quit
+		}
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case listModelsMsg:
+		if msg.err != nil {
+			m.state = modelPickerStateError
+			m.err = msg.err
+			return m, tea.Quit
+		}
+		if len(msg.models) == 0 {
+			m.state = modelPickerStateError
+			m.err = fmt.Errorf("no models on the Ollama server yet - pull one with 'ollama pull %s'", config.Ollama.Model)
+			return m, tea.Quit
+		}
+		m.models = msg.models
+		m.state = modelPickerStateList
+		// Start on the active model
+		for i, model := range m.models {
+			if HasModel([]OllamaModel{model}, config.Ollama.Model) {
+				m.cursor = i
+			}
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+func (m modelPickerModel) View() string {
+	switch m.state {
+	case modelPickerStateLoading:
+		return fmt.Sprintf("%s Loading models from %s...", m.spinner.View(), config.Ollama.URL)
+
+	case modelPickerStateList:
+		titleStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(colorPrimary).
+			PaddingLeft(2)
+		nameStyle := lipgloss.NewStyle().Foreground(colorText)
+		cursorStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+		dimStyle := lipgloss.NewStyle().Foreground(colorMuted)
+
+		width := 0
+		for _, model := range m.models {
+			width = max(width, len(model.Name))
+		}
+
+		var s strings.Builder
+		s.WriteString(titleStyle.Render(fmt.Sprintf("Ollama models at %s", config.Ollama.URL)))
+		s.WriteString("\n\n")
+		for i, model := range m.models {
+			cursor := "  "
+			style := nameStyle
+			if i == m.cursor {
+				cursor = cursorStyle.Render("→ ")
+				style = cursorStyle
+			}
+			s.WriteString(cursor)
+			s.WriteString(style.Render(fmt.Sprintf("%-*s", width, model.Name)))
+			s.WriteString(dimStyle.Render("  " + modelDetails(model)))
+			if HasModel([]OllamaModel{model}, config.Ollama.Model) {
+				s.WriteString(successStyle.Render("  (active)"))
+			}
+			s.WriteString("\n")
+		}
+		s.WriteString("\n")
+		s.WriteString(dimStyle.Render(fmt.Sprintf("  enter: use this model (saved to %s)  q: quit", displayPath(m.configPath))))
+		s.WriteString("\n")
+		return s.String()
+
+	case modelPickerStateDone:
+		return modelSavedMessage(m.selected, m.configPath) + "\n"
+
+	case modelPickerStateError:
+		return errorStyle.Render(fmt.Sprintf("✗ Error: %s", m.err)) + "\n"
+	}
+
+	return ""
+}
+
+// modelDetails summarizes a model as "2.0 GB  3.2B Q4_K_M  2025-01-14"
+func modelDetails(model OllamaModel) string {
+	parts := []string{fmt.Sprintf("%8s", formatBytes(model.Size))}
+	if details := strings.TrimSpace(model.Details.ParameterSize + " " + model.Details.QuantizationLevel); details != "" {
+		parts = append(parts, details)
+	}
+	if !model.ModifiedAt.IsZero() {
+		parts = append(parts, model.ModifiedAt.Format("2006-01-02"))
+	}
+	return strings.Join(parts, "  ")
+}
+
+// modelSavedMessage confirms a new ollama.model, noting a SNAP_MODEL that
+// still overrides it
+func modelSavedMessage(name, path string) string {
+	s := successStyle.Render(fmt.Sprintf("✓ Snap now uses %s (ollama.model in %s)", name, displayPath(path)))
+	if model := os.Getenv(modelEnvVar); model != "" {
+		warningStyle := lipgloss.NewStyle().Foreground(colorWarning)
+		s += "\n" + warningStyle.Render(fmt.Sprintf("⚠ %s=%s still overrides it in this shell", modelEnvVar, model))
+	}
+	return s
+}
+
+func listModels() tea.Msg {
+	models, err := ollamaProvider{baseURL: config.Ollama.URL}.ListModels()
+	return listModelsMsg{models: models, err: err}
+}
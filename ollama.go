@@ -1,22 +1,96 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-const ollamaURL = "http://localhost:11434"
+// Provider is an AI backend that completes prompts for commit messages
+type Provider interface {
+	// Name is shown in progress and error messages
+	Name() string
+	// Check reports why the provider can't be used, or nil when it is ready
+	Check() error
+	// Generate returns the completion for a prompt. The seed makes output
+	// reproducible where the backend supports it.
+	Generate(prompt string, seed int) (string, error)
+}
+
+// StreamingProvider is a Provider that reports the completion while it is
+// being generated
+type StreamingProvider interface {
+	Provider
+	// GenerateStream calls onToken with each piece of the completion as it
+	// arrives and returns the full text. Cancelling ctx stops the generation.
+	GenerateStream(ctx context.Context, prompt string, seed int, onToken func(string)) (string, error)
+}
+
+// CurrentProvider returns the provider selected by ai.provider in the config,
+// wrapped to fail over to the ones in ai.fallback
+func CurrentProvider() Provider {
+	if len(config.AI.Fallback) == 0 {
+		return newProvider(config.AI.Provider)
+	}
+	chain := fallbackProvider{providers: []Provider{newProvider(config.AI.Provider)}}
+	for _, name := range config.AI.Fallback {
+		if name == providerHeuristic {
+			chain.heuristic = true
+			continue
+		}
+		chain.providers = append(chain.providers, newProvider(name))
+	}
+	return chain
+}
+
+// newProvider configures the provider named by an ai.provider value
+func newProvider(name string) Provider {
+	switch name {
+	case providerOpenAI:
+		return openAIProvider{
+			baseURL: config.OpenAI.URL,
+			model:   config.OpenAI.Model,
+			apiKey:  config.OpenAI.apiKey(),
+		}
+	case providerAnthropic:
+		return anthropicProvider{
+			baseURL: config.Anthropic.URL,
+			model:   config.Anthropic.Model,
+			apiKey:  config.Anthropic.apiKey(),
+		}
+	default:
+		return ollamaProvider{
+			baseURL:   config.Ollama.URL,
+			model:     config.Ollama.Model,
+			timeout:   config.Ollama.timeout(),
+			retries:   config.Ollama.Retries,
+			keepAlive: config.Ollama.KeepAlive,
+		}
+	}
+}
+
+// generationTemperature keeps commit messages consistent between runs
+const generationTemperature = 0.3
 
 type OllamaRequest struct {
-	Model   string                 `json:"model"`
-	Prompt  string                 `json:"prompt"`
-	Stream  bool                   `json:"stream"`
-	Options map[string]interface{} `json:"options"`
+	Model     string                 `json:"model"`
+	Prompt    string                 `json:"prompt"`
+	Stream    bool                   `json:"stream"`
+	Options   map[string]interface{} `json:"options"`
+	KeepAlive interface{}            `json:"keep_alive,omitempty"` // "10m", or seconds as a number
 }
 
 type OllamaResponse struct {
@@ -24,24 +98,839 @@ type OllamaResponse struct {
 	CreatedAt string `json:"created_at"`
 	Response  string `json:"response"`
 	Done      bool   `json:"done"`
+	Error     string `json:"error,omitempty"` // Set on a failed streamed response
+}
+
+// ollamaProvider generates text with a local or remote Ollama server
+type ollamaProvider struct {
+	baseURL   string
+	model     string
+	timeout   time.Duration // Per request; while streaming, the longest wait for the next token. Zero waits forever.
+	retries   int           // Further attempts after a timeout, dropped connection or server error
+	keepAlive string        // How long Ollama keeps the model loaded after a request
+}
+
+// ollamaCheckTimeout bounds the request checking that Ollama is running
+const ollamaCheckTimeout = 5 * time.Second
+
+// ollamaRetryBackoff is the wait before the first retry; it doubles each time
+var ollamaRetryBackoff = time.Second
+
+// retryableError marks a failed request that may succeed when sent again
+type retryableError struct {
+	err error
+}
+
+func (e retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e retryableError) Unwrap() error {
+	return e.err
+}
+
+// errOllamaStalled is returned when a stream goes quiet for longer than the timeout
+var errOllamaStalled = fmt.Errorf("ollama stopped sending tokens")
+
+func (p ollamaProvider) Name() string {
+	return "Ollama"
+}
+
+// Check checks if Ollama is running and has the configured model
+func (p ollamaProvider) Check() error {
+	client := &http.Client{Timeout: ollamaCheckTimeout}
+	resp, err := client.Get(p.baseURL + "/api/tags")
+	if err != nil {
+		return fmt.Errorf("Ollama is not running at %s. Please start Ollama first", p.baseURL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Ollama is not running at %s. Please start Ollama first", p.baseURL)
+	}
+
+	var tags struct {
+		Models []OllamaModel `json:"models"`
+	}
+	// An unexpected listing shouldn't block generation; a missing model still
+	// shows up as a 404 then
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil
+	}
+	if !HasModel(tags.Models, p.model) {
+		return ModelMissingError{Model: p.model}
+	}
+	return nil
+}
+
+// OllamaModel is a model available on the Ollama server
+type OllamaModel struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+	Details    struct {
+		ParameterSize     string `json:"parameter_size"`
+		QuantizationLevel string `json:"quantization_level"`
+	} `json:"details"`
 }
 
-// CheckOllamaRunning checks if Ollama is running
-func CheckOllamaRunning() bool {
-	resp, err := http.Get(ollamaURL + "/api/tags")
+// ListModels returns the models pulled onto the Ollama server
+func (p ollamaProvider) ListModels() ([]OllamaModel, error) {
+	client := &http.Client{Timeout: ollamaCheckTimeout}
+	resp, err := client.Get(p.baseURL + "/api/tags")
 	if err != nil {
-		return false
+		return nil, fmt.Errorf("Ollama is not running at %s. Please start Ollama first", p.baseURL)
 	}
 	defer resp.Body.Close()
-	return resp.StatusCode == http.StatusOK
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama API error: %s", resp.Status)
+	}
+
+	var tags struct {
+		Models []OllamaModel `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("unexpected model list from Ollama: %w", err)
+	}
+	return tags.Models, nil
+}
+
+// HasModel reports whether name is in the list; "mistral" matches "mistral:latest"
+func HasModel(models []OllamaModel, name string) bool {
+	return slices.ContainsFunc(models, func(m OllamaModel) bool {
+		return m.Name == name || m.Name == name+":latest"
+	})
+}
+
+// ModelMissingError means the Ollama server doesn't have the model yet
+type ModelMissingError struct {
+	Model string
+}
+
+func (e ModelMissingError) Error() string {
+	return fmt.Sprintf("model '%s' not found (run 'ollama pull %s')", e.Model, e.Model)
+}
+
+// ollamaPullProgress is one line of a streamed /api/pull response
+type ollamaPullProgress struct {
+	Status    string `json:"status"`
+	Total     int64  `json:"total"`
+	Completed int64  `json:"completed"`
+	Error     string `json:"error"`
+}
+
+// Pull downloads the model onto the Ollama server, calling onProgress with
+// each status update ("pulling manifest", "downloading", ...) and the bytes
+// done of the current layer
+func (p ollamaProvider) Pull(ctx context.Context, onProgress func(status string, completed, total int64)) error {
+	jsonData, err := json.Marshal(map[string]interface{}{"model": p.model, "stream": true})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/pull", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ollama API error: %s", resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var progress ollamaPullProgress
+		if err := json.Unmarshal(line, &progress); err != nil {
+			return err
+		}
+		if progress.Error != "" {
+			return fmt.Errorf("pulling %s failed: %s", p.model, progress.Error)
+		}
+		if progress.Status == "success" {
+			return nil
+		}
+		onProgress(progress.Status, progress.Completed, progress.Total)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("pulling %s stopped before it finished", p.model)
+}
+
+func (p ollamaProvider) Generate(prompt string, seed int) (string, error) {
+	var message string
+	err := p.withRetries(context.Background(), func() error {
+		var err error
+		message, err = p.generateOnce(prompt, seed)
+		return err
+	})
+	return message, err
+}
+
+// generateOnce sends a single non-streamed generate request
+func (p ollamaProvider) generateOnce(prompt string, seed int) (string, error) {
+	jsonData, err := json.Marshal(p.request(prompt, seed, false))
+	if err != nil {
+		return "", err
+	}
+
+	client := &http.Client{Timeout: p.timeout}
+	resp, err := client.Post(p.baseURL+"/api/generate", "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", retryableError{err}
+	}
+	defer resp.Body.Close()
+
+	if err := ollamaStatusError(resp, p.model); err != nil {
+		return "", err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", retryableError{err}
+	}
+
+	var ollamaResp OllamaResponse
+	if err := json.Unmarshal(body, &ollamaResp); err != nil {
+		return "", err
+	}
+
+	message := strings.TrimSpace(ollamaResp.Response)
+	if message == "" {
+		return "", fmt.Errorf("ollama returned empty response")
+	}
+	return message, nil
+}
+
+// GenerateStream requests a streamed completion, which Ollama sends as one
+// JSON object per line
+func (p ollamaProvider) GenerateStream(ctx context.Context, prompt string, seed int, onToken func(string)) (string, error) {
+	var message string
+	streamed := false
+	err := p.withRetries(ctx, func() error {
+		var err error
+		message, err = p.generateStreamOnce(ctx, prompt, seed, func(token string) {
+			streamed = true
+			onToken(token)
+		})
+		// Tokens already shown can't be taken back, so only retry before the first
+		var retryable retryableError
+		if streamed && errors.As(err, &retryable) {
+			return retryable.err
+		}
+		return err
+	})
+	return message, err
+}
+
+// generateStreamOnce sends a single streamed generate request. The request is
+// abandoned when no token arrives within the timeout.
+func (p ollamaProvider) generateStreamOnce(ctx context.Context, prompt string, seed int, onToken func(string)) (string, error) {
+	jsonData, err := json.Marshal(p.request(prompt, seed, true))
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var stalled atomic.Bool
+	if p.timeout > 0 {
+		idle := time.AfterFunc(p.timeout, func() {
+			stalled.Store(true)
+			cancel()
+		})
+		defer idle.Stop()
+		onToken = func(next func(string)) func(string) {
+			return func(token string) {
+				idle.Reset(p.timeout)
+				next(token)
+			}
+		}(onToken)
+	}
+	// Reports a stall rather than the cancellation it caused
+	fail := func(err error) (string, error) {
+		if stalled.Load() {
+			return "", retryableError{errOllamaStalled}
+		}
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/generate", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fail(err)
+		}
+		return "", retryableError{err}
+	}
+	defer resp.Body.Close()
+
+	if err := ollamaStatusError(resp, p.model); err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var chunk OllamaResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return "", err
+		}
+		if chunk.Error != "" {
+			return "", fmt.Errorf("ollama API error: %s", chunk.Error)
+		}
+		if chunk.Response != "" {
+			text.WriteString(chunk.Response)
+			onToken(chunk.Response)
+		}
+		if chunk.Done {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fail(err)
+	}
+
+	message := strings.TrimSpace(text.String())
+	if message == "" {
+		return "", fmt.Errorf("ollama returned empty response")
+	}
+	return message, nil
+}
+
+// request builds a generate request with the sampling options and keep_alive
+func (p ollamaProvider) request(prompt string, seed int, stream bool) OllamaRequest {
+	req := OllamaRequest{
+		Model:  p.model,
+		Prompt: prompt,
+		Stream: stream,
+		Options: map[string]interface{}{
+			"temperature": generationTemperature,
+			"seed":        seed,
+		},
+	}
+	// Ollama reads plain numbers as seconds, e.g. -1 to keep the model loaded
+	if seconds, err := strconv.Atoi(p.keepAlive); err == nil {
+		req.KeepAlive = seconds
+	} else if p.keepAlive != "" {
+		req.KeepAlive = p.keepAlive
+	}
+	return req
+}
+
+// withRetries runs send until it succeeds, fails for good, or runs out of
+// retries, waiting longer before each new attempt
+func (p ollamaProvider) withRetries(ctx context.Context, send func() error) error {
+	backoff := ollamaRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := send()
+		var retryable retryableError
+		if err == nil || !errors.As(err, &retryable) {
+			return err
+		}
+		if attempt > p.retries || ctx.Err() != nil {
+			return p.retryError(err, attempt)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// retryError explains the last failure once no retries are left
+func (p ollamaProvider) retryError(err error, attempts int) error {
+	var netErr net.Error
+	if errors.Is(err, errOllamaStalled) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		err = fmt.Errorf("ollama didn't answer within %s - the model may be stuck or too slow (raise ollama.timeout)", p.timeout)
+	}
+	if attempts > 1 {
+		return fmt.Errorf("%w (gave up after %d attempts)", err, attempts)
+	}
+	return err
+}
+
+// ollamaStatusError explains a failed generate request. Ollama answers 404 when
+// the configured model hasn't been pulled.
+func ollamaStatusError(resp *http.Response, model string) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return ModelMissingError{Model: model}
+	default:
+		err := fmt.Errorf("ollama API error: %s", resp.Status)
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return retryableError{err}
+		}
+		return err
+	}
+}
+
+// openAIChatRequest is a request to an OpenAI-compatible chat completions API
+type openAIChatRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Temperature float64         `json:"temperature"`
+	Seed        int             `json:"seed"`
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// openAIErrorResponse is the error body returned by OpenAI-compatible APIs
+type openAIErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// openAIProvider generates text with any OpenAI-compatible chat completions API
+// (OpenAI, Groq, OpenRouter, vLLM, ...)
+type openAIProvider struct {
+	baseURL string
+	model   string
+	apiKey  string
+}
+
+func (p openAIProvider) Name() string {
+	return "OpenAI-compatible API"
+}
+
+// Check requires an API key for hosted APIs. Self-hosted servers such as vLLM
+// often run without one, so only the official endpoint is checked.
+func (p openAIProvider) Check() error {
+	if p.apiKey == "" && p.baseURL == defaultOpenAIURL {
+		return fmt.Errorf("no API key for %s (set openai.api_key or %s)", p.baseURL, openAIKeyEnvVar)
+	}
+	return nil
+}
+
+func (p openAIProvider) Generate(prompt string, seed int) (string, error) {
+	reqBody := openAIChatRequest{
+		Model:       p.model,
+		Messages:    []openAIMessage{{Role: "user", Content: prompt}},
+		Temperature: generationTemperature,
+		Seed:        seed,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", p.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp openAIErrorResponse
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error.Message != "" {
+			return "", fmt.Errorf("API error: %s: %s", resp.Status, errResp.Error.Message)
+		}
+		return "", fmt.Errorf("API error: %s", resp.Status)
+	}
+
+	var chatResp openAIChatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return "", err
+	}
+	RecordUsage(providerOpenAI, p.model, chatResp.Usage.PromptTokens, chatResp.Usage.CompletionTokens)
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("API returned no choices")
+	}
+
+	message := strings.TrimSpace(chatResp.Choices[0].Message.Content)
+	if message == "" {
+		return "", fmt.Errorf("API returned empty response")
+	}
+	return message, nil
+}
+
+// anthropicVersion is the Messages API version snap is written against
+const anthropicVersion = "2023-06-01"
+
+// anthropicMaxTokens caps the response length; commit messages and diff summaries are short
+const anthropicMaxTokens = 1024
+
+// anthropicRequest is a request to the Anthropic Messages API
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	Messages    []anthropicMessage `json:"messages"`
+	Temperature float64            `json:"temperature"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// anthropicErrorResponse is the error body returned by the Anthropic API
+type anthropicErrorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// anthropicProvider generates text with Anthropic's Claude models
+type anthropicProvider struct {
+	baseURL string
+	model   string
+	apiKey  string
+}
+
+func (p anthropicProvider) Name() string {
+	return "Claude"
+}
+
+func (p anthropicProvider) Check() error {
+	if p.apiKey == "" {
+		return fmt.Errorf("no API key for Claude (set anthropic.api_key or %s)", anthropicKeyEnvVar)
+	}
+	return nil
+}
+
+// Generate sends the prompt as a single user message. The Messages API has no
+// seed, so output is only as reproducible as the low temperature makes it.
+func (p anthropicProvider) Generate(prompt string, seed int) (string, error) {
+	reqBody := anthropicRequest{
+		Model:       p.model,
+		MaxTokens:   anthropicMaxTokens,
+		Messages:    []anthropicMessage{{Role: "user", Content: prompt}},
+		Temperature: generationTemperature,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", p.baseURL+"/v1/messages", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.apiKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp anthropicErrorResponse
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error.Message != "" {
+			return "", fmt.Errorf("Claude API error: %s: %s", resp.Status, errResp.Error.Message)
+		}
+		return "", fmt.Errorf("Claude API error: %s", resp.Status)
+	}
+
+	var claudeResp anthropicResponse
+	if err := json.Unmarshal(body, &claudeResp); err != nil {
+		return "", err
+	}
+	RecordUsage(providerAnthropic, p.model, claudeResp.Usage.InputTokens, claudeResp.Usage.OutputTokens)
+
+	var text strings.Builder
+	for _, block := range claudeResp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	message := strings.TrimSpace(text.String())
+	if message == "" {
+		return "", fmt.Errorf("Claude returned empty response")
+	}
+	return message, nil
 }
 
-// GenerateCommitMessage generates a commit message using Ollama
+// GenerateCommitMessage generates a commit message using the configured
+// provider, or guesses one when every provider fails and ai.fallback ends
+// with the heuristic
 func GenerateCommitMessage(diff string, seed int) (string, error) {
+	message, err := generateCommitMessage(diff, seed)
+	if err != nil && heuristicFallback() {
+		return HeuristicCommitMessage(diff), nil
+	}
+	return message, err
+}
+
+func generateCommitMessage(diff string, seed int) (string, error) {
+	prompt, err := commitMessagePrompt(diff, seed, nil)
+	if err != nil {
+		return "", err
+	}
+
+	message, err := CurrentProvider().Generate(prompt, seed)
+	if err != nil {
+		return "", err
+	}
+	message, err = cleanCommitMessage(message)
+	if err != nil {
+		return "", err
+	}
+	return styleCommitMessage(message, commitScope(diff)), nil
+}
+
+// ConvertCommitMessage rewrites an existing commit subject in the commit
+// convention, using the commit's diff for the type and scope
+func ConvertCommitMessage(subject, diff string, seed int) (string, error) {
+	input, err := diffPromptInput(diff, seed, nil)
+	if err != nil {
+		return "", err
+	}
+
+	convention := config.Convention
+	prompt := fmt.Sprintf(`You are a git commit message generator. Rewrite the commit message below as a SINGLE LINE conventional commit message. Keep its meaning; use the diff to pick the type and scope.
+
+CRITICAL REQUIREMENTS:
+- Output EXACTLY ONE LINE ONLY
+- Format: %s
+- Types: %s
+- Whole line under %d characters
+- NO explanations, NO markdown, NO extra text
+- NO prefixes like "commit message:" or "output:"
+
+Original message: %s
+
+Changes:
+%s
+
+OUTPUT ONLY ONE LINE:`, convention.format(), strings.Join(convention.Types, ", "), convention.MaxSubject, subject, input)
+
+	message, err := CurrentProvider().Generate(prompt, seed)
+	if err != nil {
+		return "", err
+	}
+	message, err = cleanCommitMessage(message)
+	if err != nil {
+		return "", err
+	}
+	return styleCommitMessage(message, commitScope(diff)), nil
+}
+
+// GenerateCommitMessageCandidates generates up to n distinct messages in
+// parallel, one per consecutive seed starting at seed. Only the first
+// candidate is streamed to onToken; onProgress reports summarized chunks of
+// large diffs. Candidates that fail are skipped; the error is returned only
+// when none succeed, unless ai.fallback ends with the heuristic. The source
+// tells which provider produced the first candidate.
+func GenerateCommitMessageCandidates(ctx context.Context, diff string, seed, n int, onToken func(string), onProgress func(done, total int)) ([]string, messageSource, error) {
+	candidates, source, err := generateCandidates(ctx, diff, seed, n, onToken, onProgress)
+	if err != nil && ctx.Err() == nil && heuristicFallback() {
+		return []string{HeuristicCommitMessage(diff)}, messageSource{name: heuristicSource, failed: err}, nil
+	}
+	return candidates, source, err
+}
+
+func generateCandidates(ctx context.Context, diff string, seed, n int, onToken func(string), onProgress func(done, total int)) ([]string, messageSource, error) {
+	prompt, err := commitMessagePrompt(diff, seed, onProgress)
+	if err != nil {
+		return nil, messageSource{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, messageSource{}, err
+	}
+
+	provider := CurrentProvider()
+	scope := commitScope(diff)
+	results := make([]string, n)
+	sources := make([]messageSource, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := aiCacheKey("message", prompt, strconv.Itoa(seed+i))
+			var message string
+			if loadCached(key, &message) {
+				if i == 0 {
+					onToken(message)
+				}
+				results[i] = styleCommitMessage(message, scope)
+				sources[i] = messageSource{name: cacheSource}
+				return
+			}
+
+			var stream func(string)
+			if i == 0 {
+				stream = onToken
+			}
+			message, source, err := generateFrom(ctx, provider, prompt, seed+i, stream)
+			if err == nil {
+				message, err = cleanCommitMessage(message)
+			}
+			if err == nil {
+				saveCached(key, message)
+			}
+			results[i], sources[i], errs[i] = styleCommitMessage(message, scope), source, err
+		}(i)
+	}
+
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, messageSource{}, err
+	}
+
+	// Keep seed order so the first candidate matches a single generation
+	var candidates []string
+	var source messageSource
+	for i, message := range results {
+		if errs[i] == nil && !slices.Contains(candidates, message) {
+			if candidates == nil {
+				source = sources[i]
+			}
+			candidates = append(candidates, message)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, messageSource{}, errs[0]
+	}
+	return candidates, source, nil
+}
+
+// commitMessagePrompt builds the prompt for a diff
+func commitMessagePrompt(diff string, seed int, onProgress func(done, total int)) (string, error) {
+	input, err := diffPromptInput(diff, seed, onProgress)
+	if err != nil {
+		return "", err
+	}
+
+	convention := config.Convention
+	prompt := fmt.Sprintf(`You are a git commit message generator. Generate a SINGLE LINE conventional commit message based on the git diff below.
+
+CRITICAL REQUIREMENTS:
+- Output EXACTLY ONE LINE ONLY
+- Format: %s
+- Types: %s
+- Whole line under %d characters
+- Describe WHAT changed, not HOW
+- NO explanations, NO markdown, NO extra text
+- NO line breaks, NO paragraphs
+- NO prefixes like "commit message:" or "output:"
+
+%s%sChanges:
+%s
+
+OUTPUT ONLY ONE LINE:`, convention.format(), strings.Join(convention.Types, ", "), convention.MaxSubject, branchContext(), historyExamples(), input)
+	return prompt, nil
+}
+
+// branchContext names the current branch for the prompt, since names like
+// "fix/login-timeout" or "feature/JIRA-123-login" hint at the type and scope.
+// Returns "" on a detached HEAD.
+func branchContext() string {
+	branch, err := GetCurrentBranch()
+	if err != nil || branch == "" {
+		return ""
+	}
+	return fmt.Sprintf("Current branch: %s (a hint for the type and scope)\n\n", branch)
+}
+
+// historyExamples lists the last ai.examples commit subjects of the repository
+// for the prompt, so generated messages match the project's tense, casing, and
+// scopes. Returns "" when there are none.
+func historyExamples() string {
+	subjects, err := GetRecentSubjects(config.AI.Examples)
+	if err != nil || len(subjects) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Recent commit messages in this repository. Match their tense, casing, and scopes, but keep the format above and do NOT repeat any of them:\n")
+	for _, subject := range subjects {
+		b.WriteString("- " + subject + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// diffPromptInput returns the diff to put in a prompt, summarizing large diffs
+// chunk by chunk first. ai.workers chunks are summarized at a time, and
+// onProgress, when set, is called as each one finishes.
+func diffPromptInput(diff string, seed int, onProgress func(done, total int)) (string, error) {
 	var input string
+	diff = diffForAI(diff)
 
+	key := aiCacheKey("summary", diff, strconv.Itoa(seed))
 	if len(diff) <= 2000 {
 		input = diff
+	} else if loadCached(key, &input) {
+		// Summarized on an earlier run of the same diff
 	} else {
 		// Chunk and summarize
 		chunks := splitDiffIntoChunks(diff)
@@ -49,92 +938,203 @@ func GenerateCommitMessage(diff string, seed int) (string, error) {
 			return "", fmt.Errorf("no diff chunks to process")
 		}
 
-		var summaries []string
+		// Summaries keep the chunk order so the combined text reads like the diff
+		results := make([]string, len(chunks))
+		jobs := make(chan int)
+		finished := 0
 		var mu sync.Mutex
 		var wg sync.WaitGroup
 
-		for _, chunk := range chunks {
+		for w := 0; w < min(config.AI.Workers, len(chunks)); w++ {
 			wg.Add(1)
-			go func(c string) {
+			go func() {
 				defer wg.Done()
-				summary, err := SummarizeDiffChunk(c, seed)
-				if err == nil {
+				for i := range jobs {
+					summary, err := SummarizeDiffChunk(chunks[i], seed)
 					mu.Lock()
-					summaries = append(summaries, summary)
+					if err == nil {
+						results[i] = summary
+					}
+					finished++
+					if onProgress != nil {
+						onProgress(finished, len(chunks))
+					}
 					mu.Unlock()
 				}
-			}(chunk)
+			}()
 		}
-
+		for i := range chunks {
+			jobs <- i
+		}
+		close(jobs)
 		wg.Wait()
 
+		var summaries []string
+		for _, summary := range results {
+			if summary != "" {
+				summaries = append(summaries, summary)
+			}
+		}
 		if len(summaries) == 0 {
 			return "", fmt.Errorf("failed to summarize any diff chunks")
 		}
 
 		input = strings.Join(summaries, "; ")
+		// Only complete summaries are cached, so a flaky chunk is retried next time
+		if len(summaries) == len(chunks) {
+			saveCached(key, input)
+		}
 	}
 
-	prompt := fmt.Sprintf(`You are a git commit message generator. Generate a SINGLE LINE conventional commit message based on the git diff below.
-
-CRITICAL REQUIREMENTS:
-- Output EXACTLY ONE LINE ONLY
-- Format: <type>: <description>
-- Types: feat, fix, docs, style, refactor, test, chore
-- Description under 72 characters
-- Describe WHAT changed, not HOW
-- NO explanations, NO markdown, NO extra text
-- NO line breaks, NO paragraphs
-- NO prefixes like "commit message:" or "output:"
+	return input, nil
+}
 
-Changes:
-%s
+// styleCommitMessage formats a conventional message in the configured ai.style,
+// adding scope when the model didn't pick one. The emoji is added here rather
+// than asked of the model, which keeps small models from inventing their own.
+func styleCommitMessage(message, scope string) string {
+	message = ApplyScope(message, scope)
+	if config.AI.Style == styleGitmoji {
+		return ApplyGitmoji(message)
+	}
+	return message
+}
 
-OUTPUT ONLY ONE LINE:`, input)
+// commitScope returns the scope for a diff's commit message, or "" when
+// ai.infer_scope is off
+func commitScope(diff string) string {
+	if !config.AI.InferScope {
+		return ""
+	}
+	return InferScope(diffFiles(diff), config.Scopes)
+}
 
-	reqBody := OllamaRequest{
-		Model:  "llama3.2:3b",
-		Prompt: prompt,
-		Stream: false,
-		Options: map[string]interface{}{
-			"temperature": 0.3,
-			"seed":        seed,
-		},
+// InferScope derives a conventional commit scope from changed paths. Paths
+// under a prefix in scopes get its scope, preferring the longest prefix; if
+// all paths map to the same scope it is used. Without a mapping, the deepest
+// directory shared by all paths names the scope, or the file name for a single
+// file in the repository root. Returns "" when the paths don't agree.
+func InferScope(paths []string, scopes map[string]string) string {
+	if len(paths) == 0 {
+		return ""
 	}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", err
+	if len(scopes) > 0 {
+		scope := ""
+		for _, p := range paths {
+			s := mappedScope(p, scopes)
+			if s == "" || (scope != "" && s != scope) {
+				scope = ""
+				break
+			}
+			scope = s
+		}
+		if scope != "" {
+			return scope
+		}
 	}
 
-	resp, err := http.Post(ollamaURL+"/api/generate", "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", err
+	common := strings.Split(path.Dir(paths[0]), "/")
+	for _, p := range paths[1:] {
+		dir := strings.Split(path.Dir(p), "/")
+		n := 0
+		for n < len(common) && n < len(dir) && common[n] == dir[n] {
+			n++
+		}
+		common = common[:n]
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ollama API error: %s", resp.Status)
+	if len(common) > 0 && common[0] != "." {
+		return common[len(common)-1]
+	}
+	if len(paths) == 1 {
+		name := path.Base(paths[0])
+		name = strings.TrimSuffix(name, path.Ext(name))
+		return strings.TrimSuffix(name, "_test")
 	}
+	return ""
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+// mappedScope returns the scope of the longest prefix in scopes containing p
+func mappedScope(p string, scopes map[string]string) string {
+	scope, longest := "", -1
+	for prefix, s := range scopes {
+		prefix = strings.Trim(prefix, "/")
+		if (p == prefix || strings.HasPrefix(p, prefix+"/")) && len(prefix) > longest {
+			scope, longest = s, len(prefix)
+		}
 	}
+	return scope
+}
 
-	var ollamaResp OllamaResponse
-	if err := json.Unmarshal(body, &ollamaResp); err != nil {
-		return "", err
+// diffFiles lists the paths changed in a unified diff, using the new path of
+// renamed files
+func diffFiles(diff string) []string {
+	var files []string
+	for _, line := range strings.Split(diff, "\n") {
+		header, ok := strings.CutPrefix(line, "diff --git ")
+		if !ok {
+			continue
+		}
+		if i := strings.LastIndex(header, " b/"); i >= 0 {
+			files = append(files, header[i+len(" b/"):])
+		}
 	}
+	return files
+}
 
-	// Clean up the response
-	message := strings.TrimSpace(ollamaResp.Response)
+// lockfiles are generated dependency pins; their diffs are long and tell the
+// model nothing the manifest change doesn't
+var lockfiles = []string{
+	"package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml", "bun.lockb",
+	"go.sum", "Cargo.lock", "Gemfile.lock", "composer.lock", "poetry.lock", "Pipfile.lock",
+	"uv.lock", "flake.lock", "mix.lock", "Podfile.lock", "pubspec.lock", "packages.lock.json",
+}
 
-	// Handle empty response
-	if message == "" {
-		return "", fmt.Errorf("ollama returned empty response")
+// IsLockfile reports whether a path is a dependency lockfile
+func IsLockfile(p string) bool {
+	return slices.Contains(lockfiles, path.Base(p))
+}
+
+// diffForAI prepares a diff for the AI provider: lockfile and binary changes
+// are left out, ai.redact_rules matches are masked and, with ai.redact, so are
+// secrets
+func diffForAI(diff string) string {
+	diff, _ = redactForAI(leaveOutFiles(diff))
+	return diff
+}
+
+// leaveOutFiles replaces the changes to lockfiles and binary files with a note,
+// keeping the file header so the model (and scope inference) still sees which
+// files changed
+func leaveOutFiles(diff string) string {
+	files := splitDiffFiles(diff)
+	for i, file := range files {
+		header, _, _ := strings.Cut(file, "\n")
+		if paths := diffFiles(file); len(paths) == 1 && IsLockfile(paths[0]) {
+			files[i] = header + "\n(lockfile changes left out)"
+		} else if isBinaryDiff(file) {
+			files[i] = header + "\n(binary file left out)"
+		}
+	}
+	return strings.Join(files, "\n")
+}
+
+// isBinaryDiff reports whether a file's section of a diff is a binary change
+func isBinaryDiff(file string) bool {
+	for _, line := range strings.Split(file, "\n") {
+		if strings.HasPrefix(line, "@@") {
+			return false
+		}
+		if strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch" {
+			return true
+		}
 	}
+	return false
+}
 
+// cleanCommitMessage reduces a model's reply to a single commit message line
+func cleanCommitMessage(message string) (string, error) {
 	// Take ONLY the first line - be very aggressive about this
 	lines := strings.Split(message, "\n")
 	firstLine := strings.TrimSpace(lines[0])
@@ -187,53 +1187,243 @@ OUTPUT ONLY ONE LINE:`, input)
 	return firstLine, nil
 }
 
-// SummarizeDiffChunk summarizes a chunk of git diff
-func SummarizeDiffChunk(chunk string, seed int) (string, error) {
-	prompt := fmt.Sprintf(`Summarize the changes in this git diff chunk in a few words, focusing on what was added, modified, or removed.
+// bodyWidth is the column commit bodies are wrapped at
+const bodyWidth = 72
 
-Git diff chunk:
-%s
+// GenerateCommitBody describes a diff as bullet points for the body of a
+// commit message, wrapped at bodyWidth
+func GenerateCommitBody(diff string, seed int) (string, error) {
+	input, err := diffPromptInput(diff, seed, nil)
+	if err != nil {
+		return "", err
+	}
 
-Summary:`, chunk)
+	prompt := fmt.Sprintf(`You are a git commit message generator. Describe the git diff below as 2 to 5 bullet points for the BODY of a commit message.
 
-	reqBody := OllamaRequest{
-		Model:  "llama3.2:3b",
-		Prompt: prompt,
-		Stream: false,
-		Options: map[string]interface{}{
-			"temperature": 0.3,
-			"seed":        seed,
-		},
-	}
+CRITICAL REQUIREMENTS:
+- One bullet per line, each starting with "- "
+- Each bullet under 72 characters
+- Describe WHAT changed and WHY, not HOW
+- NO subject line, NO headings, NO markdown besides the bullets
+- NO prefixes like "body:" or "output:"
 
-	jsonData, err := json.Marshal(reqBody)
+Changes:
+%s
+
+OUTPUT ONLY THE BULLETS:`, input)
+
+	reply, err := CurrentProvider().Generate(prompt, seed)
 	if err != nil {
 		return "", err
 	}
+	return cleanCommitBody(reply)
+}
+
+// cleanCommitBody keeps the bullet points of a model's reply, normalized to
+// "- " and wrapped at bodyWidth. A reply without bullets is wrapped as a
+// single paragraph.
+func cleanCommitBody(reply string) (string, error) {
+	var bullets, text []string
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "```") || strings.HasSuffix(line, ":") {
+			continue
+		}
+		if item, ok := bulletText(line); ok {
+			bullets = append(bullets, wrapText("- "+item, bodyWidth, "  "))
+		} else {
+			text = append(text, line)
+		}
+	}
+
+	if len(bullets) > 0 {
+		return strings.Join(bullets, "\n"), nil
+	}
+	if len(text) > 0 {
+		return wrapText(strings.Join(text, " "), bodyWidth, ""), nil
+	}
+	return "", fmt.Errorf("failed to extract commit body from AI response: %q", reply)
+}
+
+// bulletText returns the text of a list item such as "- x", "* x", "• x", or "1. x"
+func bulletText(line string) (string, bool) {
+	for _, marker := range []string{"- ", "* ", "• "} {
+		if strings.HasPrefix(line, marker) {
+			return strings.TrimSpace(line[len(marker):]), true
+		}
+	}
+	if i := strings.Index(line, ". "); i > 0 && i <= 2 {
+		if _, err := strconv.Atoi(line[:i]); err == nil {
+			return strings.TrimSpace(line[i+2:]), true
+		}
+	}
+	return "", false
+}
+
+// wrapText wraps text at width columns, starting continuation lines with indent
+func wrapText(text string, width int, indent string) string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		switch {
+		case line == "":
+			line = word
+		case len(line)+1+len(word) > width:
+			lines = append(lines, line)
+			line = indent + word
+		default:
+			line += " " + word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ResolveConflictHunk asks the model to merge both sides of a conflict hunk
+// and returns the proposed replacement for the hunk, without markers. Hunks
+// that ai.redact_rules match aren't sent, since the answer is written back.
+func ResolveConflictHunk(path string, hunk ConflictHunk, seed int) (string, error) {
+	if err := checkRedactRules(path + "\n" + hunk.Ours + hunk.Base + hunk.Theirs); err != nil {
+		return "", fmt.Errorf("resolve this hunk by hand - %w", err)
+	}
+	base := "(not available)\n"
+	if hunk.Base != "" {
+		base = hunk.Base
+	}
+
+	prompt := fmt.Sprintf(`You are resolving a git merge conflict in %s. Combine both sides into the code that should replace the conflict.
+
+CRITICAL REQUIREMENTS:
+- Keep the intent of BOTH sides where they don't contradict each other
+- Output ONLY the resolved lines, exactly as they should appear in the file
+- Keep the indentation of the surrounding code
+- NO conflict markers, NO explanations, NO markdown code fences
+
+OURS (%s):
+%s
+BASE (common ancestor):
+%s
+THEIRS (%s):
+%s
+OUTPUT ONLY THE RESOLVED LINES:`, path, hunk.OursLabel, hunk.Ours, base, hunk.TheirsLabel, hunk.Theirs)
 
-	resp, err := http.Post(ollamaURL+"/api/generate", "application/json", bytes.NewBuffer(jsonData))
+	reply, err := CurrentProvider().Generate(prompt, seed)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
+	return cleanConflictResolution(reply, hunk)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ollama API error: %s", resp.Status)
+// cleanConflictResolution strips markdown fences from a model's reply and
+// rejects replies that still contain conflict markers. The result ends in a
+// newline like the hunk it replaces.
+func cleanConflictResolution(reply string, hunk ConflictHunk) (string, error) {
+	lines := strings.Split(strings.Trim(reply, "\n"), "\n")
+	if len(lines) > 0 && strings.HasPrefix(strings.TrimSpace(lines[0]), "```") {
+		lines = lines[1:]
+	}
+	if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "```" {
+		lines = lines[:len(lines)-1]
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	for _, line := range lines {
+		if marker, _ := conflictMarker(line); marker != "" {
+			return "", fmt.Errorf("AI response still contains conflict markers")
+		}
+	}
+
+	resolution := strings.Join(lines, "\n")
+	if strings.TrimSpace(resolution) == "" {
+		// Both sides deleting the lines is a valid resolution
+		if strings.TrimSpace(hunk.Ours) == "" || strings.TrimSpace(hunk.Theirs) == "" {
+			return "", nil
+		}
+		return "", fmt.Errorf("AI returned an empty resolution")
+	}
+	return resolution + "\n", nil
+}
+
+// SuggestCommitGroups asks the model how to squash and order a branch's
+// commits for review. commits lists each commit's subject and changed files,
+// oldest first; the result holds their indexes, one group per new commit, in
+// the order the new commits should be applied.
+func SuggestCommitGroups(commits []PrepareCommit, seed int) ([][]int, error) {
+	var list strings.Builder
+	for i, commit := range commits {
+		fmt.Fprintf(&list, "%d. %s (files: %s)\n", i+1, commit.Subject, strings.Join(commit.Files, ", "))
+	}
+	if err := checkRedactRules(list.String()); err != nil {
+		return nil, err
+	}
+
+	prompt := fmt.Sprintf(`You are preparing a git branch for code review. Group its commits into a short, reviewer-friendly sequence: squash fixups, typo fixes and follow-ups into the commit they belong to, and order the groups so each builds on the previous one.
+
+CRITICAL REQUIREMENTS:
+- Output ONE LINE PER GROUP, in the order the new commits should be applied
+- Each line lists commit numbers separated by commas, e.g. 1,4
+- Use every number from 1 to %d exactly once
+- Keep commits that change the same files in their original order
+- NO explanations, NO markdown, NO extra text
+
+Commits, oldest first:
+%s
+OUTPUT ONLY THE GROUPS:`, len(commits), list.String())
+
+	reply, err := CurrentProvider().Generate(prompt, seed)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	return cleanCommitGroups(reply, len(commits))
+}
 
-	var ollamaResp OllamaResponse
-	if err := json.Unmarshal(body, &ollamaResp); err != nil {
-		return "", err
+// cleanCommitGroups parses the model's groups of 1-based commit numbers into
+// 0-based indexes, rejecting replies that skip or repeat a commit
+func cleanCommitGroups(reply string, n int) ([][]int, error) {
+	var groups [][]int
+	seen := make([]bool, n)
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.Trim(strings.TrimSpace(line), "`")
+		if line == "" {
+			continue
+		}
+		var group []int
+		for _, field := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' }) {
+			number, err := strconv.Atoi(strings.TrimPrefix(field, "#"))
+			if err != nil || number < 1 || number > n {
+				return nil, fmt.Errorf("AI returned an invalid group %q", line)
+			}
+			if seen[number-1] {
+				return nil, fmt.Errorf("AI put commit %d in more than one group", number)
+			}
+			seen[number-1] = true
+			group = append(group, number-1)
+		}
+		// Within a group, commits keep their order, so they apply cleanly
+		slices.Sort(group)
+		groups = append(groups, group)
+	}
+	for i, ok := range seen {
+		if !ok {
+			return nil, fmt.Errorf("AI left out commit %d", i+1)
+		}
 	}
+	return groups, nil
+}
 
-	message := strings.TrimSpace(ollamaResp.Response)
-	if message == "" {
-		return "", fmt.Errorf("ollama returned empty response")
+// SummarizeDiffChunk summarizes a chunk of git diff
+func SummarizeDiffChunk(chunk string, seed int) (string, error) {
+	prompt := fmt.Sprintf(`Summarize the changes in this git diff chunk in a few words, focusing on what was added, modified, or removed.
+
+Git diff chunk:
+%s
+
+Summary:`, chunk)
+
+	message, err := CurrentProvider().Generate(prompt, seed)
+	if err != nil {
+		return "", err
 	}
 
 	// Take first line
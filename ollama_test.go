@@ -1,8 +1,18 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"regexp"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestCleanCommitMessage(t *testing.T) {
@@ -76,56 +86,605 @@ func TestCleanCommitMessage(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// Simulate the cleanup logic from GenerateCommitMessage
-			message := strings.TrimSpace(tc.input)
-
-			if message == "" && tc.wantErr {
-				return // Expected error
-			}
-
-			firstLine := strings.Split(message, "\n")[0]
-			firstLine = strings.TrimSpace(firstLine)
-
-			// Remove common prefixes (case-insensitive)
-			prefixes := []string{
-				"commit message:",
-				"Commit message:",
-				"COMMIT MESSAGE:",
-				"message:",
-				"Message:",
-				"MESSAGE:",
-			}
-
-			for _, prefix := range prefixes {
-				if strings.HasPrefix(strings.ToLower(firstLine), strings.ToLower(prefix)) {
-					firstLine = strings.TrimSpace(firstLine[len(prefix):])
-					break
+			result, err := cleanCommitMessage(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for input %q, but got result %q", tc.input, result)
 				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("cleanCommitMessage(%q) failed: %v", tc.input, err)
 			}
 
-			// Remove markdown code blocks if present
-			firstLine = strings.Trim(firstLine, "`")
-
-			// Remove quotes if the entire message is quoted
-			if (strings.HasPrefix(firstLine, "\"") && strings.HasSuffix(firstLine, "\"")) ||
-				(strings.HasPrefix(firstLine, "'") && strings.HasSuffix(firstLine, "'")) {
-				firstLine = strings.Trim(firstLine, "\"'")
-				firstLine = strings.TrimSpace(firstLine)
+			if result != tc.expected {
+				t.Errorf("Input: %q\nExpected: %q\nGot: %q", tc.input, tc.expected, result)
 			}
+		})
+	}
+}
 
-			result := firstLine
+func TestCleanCommitBody(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "Bullets",
+			input:    "- add config loading\n- validate colors",
+			expected: "- add config loading\n- validate colors",
+		},
+		{
+			name:     "Other markers and heading",
+			input:    "Body:\n* add config loading\n2. validate colors\n• keep defaults",
+			expected: "- add config loading\n- validate colors\n- keep defaults",
+		},
+		{
+			name:  "Long bullet is wrapped",
+			input: "- read the global config first and let the repository config override every setting it defines",
+			expected: "- read the global config first and let the repository config override\n" +
+				"  every setting it defines",
+		},
+		{
+			name:     "Paragraph without bullets",
+			input:    "```\nAdds config loading.\nColors are validated.\n```",
+			expected: "Adds config loading. Colors are validated.",
+		},
+		{
+			name:    "Empty reply",
+			input:   "  \n```\n",
+			wantErr: true,
+		},
+	}
 
-			// Check if we should have an error
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := cleanCommitBody(tc.input)
 			if tc.wantErr {
-				if strings.TrimSpace(result) != "" {
+				if err == nil {
 					t.Errorf("Expected error for input %q, but got result %q", tc.input, result)
 				}
 				return
 			}
-
+			if err != nil {
+				t.Fatalf("cleanCommitBody(%q) failed: %v", tc.input, err)
+			}
 			if result != tc.expected {
 				t.Errorf("Input: %q\nExpected: %q\nGot: %q", tc.input, tc.expected, result)
 			}
 		})
 	}
 }
+
+func TestInferScope(t *testing.T) {
+	scopes := map[string]string{
+		"services/billing":     "billing",
+		"services/billing/api": "billing-api",
+		"web/":                 "web",
+	}
+
+	tests := []struct {
+		name   string
+		paths  []string
+		scopes map[string]string
+		want   string
+	}{
+		{name: "Shared directory", paths: []string{"internal/tui/list.go", "internal/tui/view.go"}, want: "tui"},
+		{name: "Common parent", paths: []string{"internal/tui/list.go", "internal/git/log.go"}, want: "internal"},
+		{name: "Single root file", paths: []string{"model_test.go"}, want: "model"},
+		{name: "Several root files", paths: []string{"main.go", "model.go"}, want: ""},
+		{name: "No paths", want: ""},
+		{name: "Mapped prefix", paths: []string{"web/src/app.ts", "web/package.json"}, scopes: scopes, want: "web"},
+		{name: "Longest prefix", paths: []string{"services/billing/api/handler.go"}, scopes: scopes, want: "billing-api"},
+		{name: "Mixed scopes", paths: []string{"web/app.ts", "services/billing/db.go"}, scopes: scopes, want: ""},
+		{name: "Unmapped falls back", paths: []string{"docs/a.md", "docs/b.md"}, scopes: scopes, want: "docs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InferScope(tt.paths, tt.scopes); got != tt.want {
+				t.Errorf("InferScope(%v) = %q, want %q", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffFiles(t *testing.T) {
+	diff := "diff --git a/model.go b/model.go\n--- a/model.go\n+++ b/model.go\n" +
+		"diff --git a/old/name.go b/new/name.go\nsimilarity index 90%\n"
+
+	files := diffFiles(diff)
+	if len(files) != 2 || files[0] != "model.go" || files[1] != "new/name.go" {
+		t.Errorf("Expected [model.go new/name.go], got %v", files)
+	}
+}
+
+func TestCleanConflictResolution(t *testing.T) {
+	hunk := ConflictHunk{Ours: "a\n", Theirs: "b\n"}
+
+	tests := []struct {
+		name    string
+		reply   string
+		want    string
+		wantErr bool
+	}{
+		{name: "Plain", reply: "merged\n", want: "merged\n"},
+		{name: "Fenced", reply: "```go\nfoo()\nbar()\n```\n", want: "foo()\nbar()\n"},
+		{name: "Keeps indentation", reply: "\tfoo()\n", want: "\tfoo()\n"},
+		{name: "Markers left", reply: "<<<<<<< HEAD\na\n=======\nb\n>>>>>>> x\n", wantErr: true},
+		{name: "Empty", reply: "\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cleanConflictResolution(tt.reply, hunk)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("cleanConflictResolution(%q) = %q, %v, want %q", tt.reply, got, err, tt.want)
+			}
+		})
+	}
+
+	// Deleting the lines is fine when one side deleted them
+	if got, err := cleanConflictResolution("", ConflictHunk{Ours: "a\n"}); err != nil || got != "" {
+		t.Errorf("Expected an empty resolution, got %q, %v", got, err)
+	}
+}
+
+func TestOllamaStatusError(t *testing.T) {
+	if err := ollamaStatusError(&http.Response{StatusCode: http.StatusOK}, "mistral"); err != nil {
+		t.Errorf("Expected no error for 200, got %v", err)
+	}
+
+	err := ollamaStatusError(&http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found"}, "mistral")
+	if err == nil || !strings.Contains(err.Error(), "ollama pull mistral") {
+		t.Errorf("Expected a pull hint for a missing model, got %v", err)
+	}
+
+	err = ollamaStatusError(&http.Response{StatusCode: http.StatusInternalServerError, Status: "500 Internal Server Error"}, "mistral")
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Expected the status in the error, got %v", err)
+	}
+}
+
+func TestOllamaProviderGenerate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if r.URL.Path != "/api/generate" || req.Model != "mistral" || req.Options["seed"] != float64(7) {
+			t.Errorf("Unexpected request to %s: %+v", r.URL.Path, req)
+		}
+		fmt.Fprint(w, `{"response": " feat: add provider \n"}`)
+	}))
+	defer server.Close()
+
+	p := ollamaProvider{baseURL: server.URL, model: "mistral"}
+	result, err := p.Generate("prompt", 7)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if result != "feat: add provider" {
+		t.Errorf("Expected trimmed response, got %q", result)
+	}
+}
+
+func TestOllamaProviderGenerateStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if !req.Stream {
+			t.Error("Expected a streamed request")
+		}
+		fmt.Fprintln(w, `{"response": "feat: "}`)
+		fmt.Fprintln(w, `{"response": "stream "}`)
+		fmt.Fprintln(w, `{"response": "tokens", "done": true}`)
+	}))
+	defer server.Close()
+
+	var tokens []string
+	p := ollamaProvider{baseURL: server.URL, model: "mistral"}
+	result, err := p.GenerateStream(context.Background(), "prompt", 7, func(token string) {
+		tokens = append(tokens, token)
+	})
+	if err != nil {
+		t.Fatalf("GenerateStream failed: %v", err)
+	}
+	if result != "feat: stream tokens" || len(tokens) != 3 {
+		t.Errorf("Unexpected result %q from tokens %q", result, tokens)
+	}
+}
+
+func TestOllamaProviderGenerateStreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"response": "feat"}`)
+		fmt.Fprintln(w, `{"error": "model runner stopped"}`)
+	}))
+	defer server.Close()
+
+	p := ollamaProvider{baseURL: server.URL, model: "mistral"}
+	_, err := p.GenerateStream(context.Background(), "prompt", 7, func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "model runner stopped") {
+		t.Errorf("Expected the streamed error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.GenerateStream(ctx, "prompt", 7, func(string) {}); err == nil {
+		t.Error("Expected an error for a cancelled context")
+	}
+}
+
+func TestGenerateCommitMessageCandidates(t *testing.T) {
+	replies := map[float64]string{42: "feat: add candidates", 43: "Commit message: feat: pick a message", 44: "feat: add candidates"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+			return
+		}
+		reply, _ := json.Marshal(OllamaResponse{Response: replies[req.Options["seed"].(float64)], Done: true})
+		w.Write(reply)
+	}))
+	defer server.Close()
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	defer applyConfig(config)
+	cfg := defaultConfig()
+	cfg.Ollama.URL = server.URL
+	applyConfig(cfg)
+
+	var streamed strings.Builder
+	candidates, _, err := GenerateCommitMessageCandidates(context.Background(), "+change", 42, 3, func(token string) {
+		streamed.WriteString(token)
+	}, nil)
+	if err != nil {
+		t.Fatalf("GenerateCommitMessageCandidates failed: %v", err)
+	}
+	if strings.Join(candidates, " | ") != "feat: add candidates | feat: pick a message" {
+		t.Errorf("Expected cleaned, deduplicated candidates in seed order, got %q", candidates)
+	}
+	if streamed.String() != "feat: add candidates" {
+		t.Errorf("Expected the first candidate to be streamed, got %q", streamed.String())
+	}
+}
+
+func TestCommitMessagePromptContext(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	exec.Command("git", "commit", "--allow-empty", "-m", "fix(api): handle empty pages").Run()
+	exec.Command("git", "commit", "--allow-empty", "-m", "feat(tui): add tag picker").Run()
+
+	defer applyConfig(config)
+	cfg := defaultConfig()
+	cfg.AI.Examples = 2
+	applyConfig(cfg)
+
+	prompt, err := commitMessagePrompt("+change", 42, nil)
+	if err != nil {
+		t.Fatalf("commitMessagePrompt failed: %v", err)
+	}
+	if !strings.Contains(prompt, "- feat(tui): add tag picker\n- fix(api): handle empty pages\n") {
+		t.Errorf("Expected the last 2 subjects as examples, got:\n%s", prompt)
+	}
+
+	exec.Command("git", "checkout", "-q", "-b", "feature/JIRA-123-login").Run()
+	prompt, _ = commitMessagePrompt("+change", 42, nil)
+	if !strings.Contains(prompt, "Current branch: feature/JIRA-123-login") {
+		t.Errorf("Expected the branch name in the prompt, got:\n%s", prompt)
+	}
+
+	cfg.AI.Examples = 0
+	applyConfig(cfg)
+	prompt, _ = commitMessagePrompt("+change", 42, nil)
+	if strings.Contains(prompt, "Recent commit messages") {
+		t.Errorf("Expected no examples with ai.examples = 0, got:\n%s", prompt)
+	}
+}
+
+func TestOpenAIProviderGenerate(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("Expected bearer token, got %q", r.Header.Get("Authorization"))
+		}
+		var req openAIChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Model != "gpt-4o-mini" || req.Seed != 42 || len(req.Messages) != 1 || req.Messages[0].Content != "prompt" {
+			t.Errorf("Unexpected request %+v", req)
+		}
+		fmt.Fprint(w, `{"choices": [{"message": {"role": "assistant", "content": "fix: handle timeouts"}}], "usage": {"prompt_tokens": 120, "completion_tokens": 8}}`)
+	}))
+	defer server.Close()
+
+	p := openAIProvider{baseURL: server.URL + "/v1", model: "gpt-4o-mini", apiKey: "sk-test"}
+	result, err := p.Generate("prompt", 42)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if result != "fix: handle timeouts" {
+		t.Errorf("Unexpected response %q", result)
+	}
+	records, err := LoadUsage()
+	if err != nil || len(records) != 1 || records[0].Model != "gpt-4o-mini" || records[0].InputTokens != 120 || records[0].OutputTokens != 8 {
+		t.Errorf("Expected the request's usage to be logged, got %+v (%v)", records, err)
+	}
+}
+
+func TestOpenAIProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error": {"message": "Incorrect API key provided"}}`)
+	}))
+	defer server.Close()
+
+	p := openAIProvider{baseURL: server.URL, model: "gpt-4o-mini", apiKey: "bad"}
+	_, err := p.Generate("prompt", 42)
+	if err == nil || !strings.Contains(err.Error(), "Incorrect API key") {
+		t.Errorf("Expected the API error message, got %v", err)
+	}
+}
+
+func TestOpenAIProviderCheck(t *testing.T) {
+	if err := (openAIProvider{baseURL: defaultOpenAIURL}).Check(); err == nil {
+		t.Error("Expected an error without API key for the official endpoint")
+	}
+	if err := (openAIProvider{baseURL: defaultOpenAIURL, apiKey: "sk-test"}).Check(); err != nil {
+		t.Errorf("Expected no error with API key, got %v", err)
+	}
+	if err := (openAIProvider{baseURL: "http://localhost:8000/v1"}).Check(); err != nil {
+		t.Errorf("Expected self-hosted servers to work without a key, got %v", err)
+	}
+}
+
+func TestAnthropicProviderGenerate(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "sk-ant-test" || r.Header.Get("anthropic-version") != anthropicVersion {
+			t.Errorf("Missing API headers: %v", r.Header)
+		}
+		var req anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Model != "claude-haiku-4-5" || req.MaxTokens == 0 || len(req.Messages) != 1 || req.Messages[0].Content != "prompt" {
+			t.Errorf("Unexpected request %+v", req)
+		}
+		fmt.Fprint(w, `{"content": [{"type": "text", "text": "docs: explain providers"}]}`)
+	}))
+	defer server.Close()
+
+	p := anthropicProvider{baseURL: server.URL, model: "claude-haiku-4-5", apiKey: "sk-ant-test"}
+	result, err := p.Generate("prompt", 42)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if result != "docs: explain providers" {
+		t.Errorf("Unexpected response %q", result)
+	}
+}
+
+func TestAnthropicProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"type": "error", "error": {"type": "not_found_error", "message": "model: claude-nope"}}`)
+	}))
+	defer server.Close()
+
+	p := anthropicProvider{baseURL: server.URL, model: "claude-nope", apiKey: "sk-ant-test"}
+	_, err := p.Generate("prompt", 42)
+	if err == nil || !strings.Contains(err.Error(), "model: claude-nope") {
+		t.Errorf("Expected the API error message, got %v", err)
+	}
+
+	if err := (anthropicProvider{baseURL: defaultAnthropicURL}).Check(); err == nil {
+		t.Error("Expected an error without API key")
+	}
+}
+
+func TestOllamaProviderRetries(t *testing.T) {
+	defer func(backoff time.Duration) { ollamaRetryBackoff = backoff }(ollamaRetryBackoff)
+	ollamaRetryBackoff = time.Millisecond
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if req.KeepAlive != float64(-1) {
+			t.Errorf("Expected keep_alive -1 as a number, got %#v", req.KeepAlive)
+		}
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"response": "feat: retry"}`)
+	}))
+	defer server.Close()
+
+	p := ollamaProvider{baseURL: server.URL, model: "mistral", retries: 2, keepAlive: "-1"}
+	if result, err := p.Generate("prompt", 7); err != nil || result != "feat: retry" {
+		t.Errorf("Expected success on the third attempt, got %q, %v", result, err)
+	}
+
+	requests.Store(0)
+	p.retries = 1
+	_, err := p.Generate("prompt", 7)
+	if err == nil || !strings.Contains(err.Error(), "gave up after 2 attempts") {
+		t.Errorf("Expected to give up after 2 attempts, got %v", err)
+	}
+}
+
+func TestOllamaProviderStreamStall(t *testing.T) {
+	defer func(backoff time.Duration) { ollamaRetryBackoff = backoff }(ollamaRetryBackoff)
+	ollamaRetryBackoff = time.Millisecond
+
+	release := make(chan struct{})
+	defer close(release)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		// A stuck model: headers, then nothing
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	p := ollamaProvider{baseURL: server.URL, model: "mistral", timeout: 50 * time.Millisecond, retries: 1}
+	_, err := p.GenerateStream(context.Background(), "prompt", 7, func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "didn't answer within 50ms") {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+}
+
+func TestOllamaProviderCheckModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"models": [{"name": "mistral:latest"}, {"name": "llama3.2:3b"}]}`)
+	}))
+	defer server.Close()
+
+	for _, model := range []string{"mistral", "llama3.2:3b"} {
+		if err := (ollamaProvider{baseURL: server.URL, model: model}).Check(); err != nil {
+			t.Errorf("Expected %s to be found, got %v", model, err)
+		}
+	}
+
+	var missing ModelMissingError
+	err := ollamaProvider{baseURL: server.URL, model: "qwen2.5"}.Check()
+	if !errors.As(err, &missing) || missing.Model != "qwen2.5" {
+		t.Errorf("Expected a missing model error, got %v", err)
+	}
+}
+
+func TestOllamaProviderPull(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/api/pull" || req["model"] != "mistral" {
+			t.Errorf("Unexpected request to %s: %v", r.URL.Path, req)
+		}
+		fmt.Fprintln(w, `{"status": "pulling manifest"}`)
+		fmt.Fprintln(w, `{"status": "downloading", "total": 200, "completed": 50}`)
+		fmt.Fprintln(w, `{"status": "downloading", "total": 200, "completed": 200}`)
+		if req["model"] == "mistral" {
+			fmt.Fprintln(w, `{"status": "success"}`)
+		}
+	}))
+	defer server.Close()
+
+	var updates []string
+	err := ollamaProvider{baseURL: server.URL, model: "mistral"}.Pull(context.Background(), func(status string, completed, total int64) {
+		updates = append(updates, fmt.Sprintf("%s %d/%d", status, completed, total))
+	})
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if want := "pulling manifest 0/0,downloading 50/200,downloading 200/200"; strings.Join(updates, ",") != want {
+		t.Errorf("Expected updates %q, got %q", want, strings.Join(updates, ","))
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{512: "512 B", 2_019_000_000: "2.0 GB", 45_300_000: "45.3 MB"}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestOllamaProviderListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"models": [{"name": "mistral:latest", "size": 4109865159, "modified_at": "2025-01-14T10:00:00.5+01:00",
+			"details": {"parameter_size": "7.2B", "quantization_level": "Q4_0"}}]}`)
+	}))
+	defer server.Close()
+
+	models, err := ollamaProvider{baseURL: server.URL}.ListModels()
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if len(models) != 1 || !HasModel(models, "mistral") || HasModel(models, "llama3.2") {
+		t.Fatalf("Unexpected models: %+v", models)
+	}
+	if got := modelDetails(models[0]); got != "  4.1 GB  7.2B Q4_0  2025-01-14" {
+		t.Errorf("Unexpected details %q", got)
+	}
+}
+
+func TestDiffForAI(t *testing.T) {
+	diff := "diff --git a/go.mod b/go.mod\n--- a/go.mod\n+++ b/go.mod\n@@ -1 +1 @@\n-go 1.23\n+go 1.24\n" +
+		"diff --git a/go.sum b/go.sum\n--- a/go.sum\n+++ b/go.sum\n@@ -1 +1 @@\n-a v1 h1:x\n+a v2 h1:y\n"
+	want := "diff --git a/go.mod b/go.mod\n--- a/go.mod\n+++ b/go.mod\n@@ -1 +1 @@\n-go 1.23\n+go 1.24\n" +
+		"diff --git a/go.sum b/go.sum\n(lockfile changes left out)"
+	if got := diffForAI(diff); got != want {
+		t.Errorf("Unexpected diff for the model:\n%s", got)
+	}
+	if files := diffFiles(diffForAI(diff)); len(files) != 2 {
+		t.Errorf("Expected both files to stay visible, got %v", files)
+	}
+}
+
+func TestDiffPromptInputWorkers(t *testing.T) {
+	var active, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+
+		var req OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		name := regexp.MustCompile(`a/(\w+)\.go`).FindStringSubmatch(req.Prompt)[1]
+		// Later files answer first, so ordering can't come from completion order
+		time.Sleep(time.Duration(20-len(name)) * time.Millisecond)
+		fmt.Fprintf(w, `{"response": "changed %s"}`, name)
+	}))
+	defer server.Close()
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	defer applyConfig(config)
+	cfg := defaultConfig()
+	cfg.Ollama.URL = server.URL
+	cfg.AI.Workers = 2
+	applyConfig(cfg)
+
+	var diff strings.Builder
+	names := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	for _, name := range names {
+		fmt.Fprintf(&diff, "diff --git a/%s.go b/%s.go\n+%s\n", name, name, strings.Repeat("x", 500))
+	}
+
+	var progress []string
+	input, err := diffPromptInput(diff.String(), 42, func(done, total int) {
+		progress = append(progress, fmt.Sprintf("%d/%d", done, total))
+	})
+	if err != nil {
+		t.Fatalf("diffPromptInput failed: %v", err)
+	}
+	if want := "changed a; changed bb; changed ccc; changed dddd; changed eeeee"; input != want {
+		t.Errorf("Expected summaries in diff order, got %q", input)
+	}
+	if strings.Join(progress, " ") != "1/5 2/5 3/5 4/5 5/5" {
+		t.Errorf("Unexpected progress %v", progress)
+	}
+	if peak.Load() > 2 {
+		t.Errorf("Expected at most 2 requests at once, got %d", peak.Load())
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// JournalEntry is one git command run through snap git
+type JournalEntry struct {
+	Time     time.Time `json:"time"`
+	Repo     string    `json:"repo"`
+	Branch   string    `json:"branch,omitempty"`
+	Args     []string  `json:"args"`
+	Exit     int       `json:"exit"`
+	Duration string    `json:"duration"`
+}
+
+// journalPath returns the journal of passed-through git commands, honoring
+// $XDG_STATE_HOME
+func journalPath() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "snap", "journal"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "snap", "journal"), nil
+}
+
+// RecordJournal appends an entry to the journal. Failures are ignored so the
+// log never gets in the way of the command.
+func RecordJournal(entry JournalEntry) {
+	path, err := journalPath()
+	if err != nil {
+		return
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(path), 0755) != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+}
+
+// RunGit runs git with the terminal attached, so pagers, editors and prompts
+// work, and records the command in the journal. It returns git's exit code
+// and what it wrote to stderr, for gitErrorHint.
+func RunGit(args []string, stdin io.Reader, stdout, stderr io.Writer) (int, string, error) {
+	var errOutput bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = io.MultiWriter(stderr, &errOutput)
+
+	entry := JournalEntry{Time: time.Now(), Args: args}
+	if root, err := GetRepoRoot(); err == nil {
+		entry.Repo = root
+		entry.Branch, _ = GetCurrentBranch()
+	} else if wd, err := os.Getwd(); err == nil {
+		entry.Repo = wd
+	}
+
+	err := cmd.Run()
+	entry.Duration = time.Since(entry.Time).Round(time.Millisecond).String()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		entry.Exit = exitErr.ExitCode()
+		err = nil
+	default:
+		entry.Exit = -1
+	}
+	RecordJournal(entry)
+	return entry.Exit, errOutput.String(), err
+}
+
+// gitErrorHints maps git error output to the snap command that deals with it
+var gitErrorHints = []struct {
+	match string
+	hint  string
+}{
+	{"not a git repository", "Run snap init to create a repository here"},
+	{"CONFLICT", "Run snap resolve to go through the conflicts"},
+	{"fetch first", "Run snap sync, which pulls before it pushes"},
+	{"non-fast-forward", "Run snap sync, which pulls before it pushes"},
+	{"Please commit your changes or stash them", "Run snap save to commit your changes first"},
+	{"has no upstream branch", "Run snap sync, which sets the upstream for a new branch"},
+	{"did not match any", "Check the name with snap branch or snap tags"},
+	{"is not a git command", "Run git help -a to list git's commands"},
+}
+
+// gitErrorHint suggests what to do about a failed git command, or returns ""
+func gitErrorHint(output string) string {
+	for _, h := range gitErrorHints {
+		if strings.Contains(output, h.match) {
+			return h.hint
+		}
+	}
+	return ""
+}
+
+// formatGitFailure is the error shown when a passed-through command fails
+func formatGitFailure(args []string, exit int, output string) string {
+	var s strings.Builder
+	s.WriteString(errorStyle.Render(fmt.Sprintf("✗ git %s failed (exit %d)", strings.Join(args, " "), exit)))
+	if hint := gitErrorHint(output); hint != "" {
+		s.WriteString("\n" + infoStyle.Render("Tip: "+hint))
+	}
+	return s.String()
+}
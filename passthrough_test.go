@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunGit(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	var out strings.Builder
+	exit, _, err := RunGit([]string{"rev-parse", "--abbrev-ref", "HEAD"}, nil, &out, io.Discard)
+	if err != nil || exit != 0 {
+		t.Fatalf("RunGit failed: exit %d, %v", exit, err)
+	}
+	branch, _ := GetCurrentBranch()
+	if strings.TrimSpace(out.String()) != branch {
+		t.Errorf("Expected git's output %q, got %q", branch, out.String())
+	}
+
+	exit, output, err := RunGit([]string{"checkout", "no-such-branch"}, nil, io.Discard, io.Discard)
+	if err != nil || exit == 0 {
+		t.Fatalf("Expected git to fail, got exit %d, %v", exit, err)
+	}
+	if gitErrorHint(output) == "" {
+		t.Errorf("Expected a hint for %q", output)
+	}
+
+	path, _ := journalPath()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Expected a journal: %v", err)
+	}
+	defer f.Close()
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Unreadable journal line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected both commands in the journal, got %+v", entries)
+	}
+	if entries[0].Exit != 0 || entries[0].Branch != branch || entries[0].Repo == "" {
+		t.Errorf("Expected a successful run on %s, got %+v", branch, entries[0])
+	}
+	if entries[1].Exit == 0 || entries[1].Args[1] != "no-such-branch" {
+		t.Errorf("Expected the failed checkout, got %+v", entries[1])
+	}
+}
+
+func TestGitErrorHint(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"fatal: not a git repository (or any of the parent directories): .git", "snap init"},
+		{" ! [rejected]        main -> main (fetch first)", "snap sync"},
+		{"CONFLICT (content): Merge conflict in main.go", "snap resolve"},
+		{"error: pathspec 'nope' did not match any file(s) known to git", "snap branch"},
+		{"fatal: bad revision 'HEAD~99'", ""},
+	}
+	for _, tt := range tests {
+		got := gitErrorHint(tt.output)
+		if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+			t.Errorf("gitErrorHint(%q) = %q, want a hint mentioning %q", tt.output, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,621 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// confirmPlain asks a yes/no question on stdin, returning false on EOF
+func confirmPlain(question string) bool {
+	fmt.Printf("%s (y/n): ", question)
+	reader := bufio.NewReader(os.Stdin)
+	answer, err := reader.ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Println()
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// printCommitsWithStats prints commits in the plain "hash +add -del message" format
+func printCommitsWithStats(commits []CommitWithStats) {
+	for _, commit := range commits {
+		stats := ""
+		if commit.Additions > 0 || commit.Deletions > 0 {
+			stats = fmt.Sprintf(" +%d -%d", commit.Additions, commit.Deletions)
+		}
+		fmt.Printf("  %s%s  %s (%s)\n", commit.ShortHash, stats, commit.Message, commit.RelativeTime)
+	}
+}
+
+// runTagsCreatePlain runs the tag preview/create/push flow without a TUI.
+// When assumeYes is false the user is asked to confirm on stdin.
+func runTagsCreatePlain(req tagCreateRequest, assumeYes bool) error {
+	tagName := req.Name
+	commits, prevTag, err := GetReleaseCommits(req.target(), req.Prefix, req.isPrerelease())
+	if err != nil {
+		return fmt.Errorf("failed to load commits: %w", err)
+	}
+
+	// Preview
+	if req.RequestedTag != "" && req.RequestedTag != tagName {
+		fmt.Printf("%s (resolved from '%s')\n", req.title(), req.RequestedTag)
+	} else {
+		fmt.Println(req.title())
+	}
+	if prevTag != "" {
+		fmt.Printf("Previous tag: %s\n", prevTag)
+	} else {
+		fmt.Println("No previous tag")
+	}
+
+	if len(commits) > 0 {
+		totalAdditions := 0
+		totalDeletions := 0
+		for _, c := range commits {
+			totalAdditions += c.Additions
+			totalDeletions += c.Deletions
+		}
+		fmt.Printf("%d commits  +%d  -%d\n\n", len(commits), totalAdditions, totalDeletions)
+		printCommitsWithStats(commits)
+	} else {
+		fmt.Println("No new commits (tag will be created at current HEAD)")
+	}
+	fmt.Println()
+
+	if !assumeYes && !confirmPlain("Create and push tag?") {
+		return fmt.Errorf("tag creation cancelled")
+	}
+
+	// Create
+	fmt.Printf("Creating tag %s...\n", tagName)
+	if err := CreateAnnotatedTagAt(tagName, generateTagMessage(commits), req.target()); err != nil {
+		return err
+	}
+
+	// Push
+	fmt.Printf("Pushing tag %s...\n", tagName)
+	if output, err := PushTag(tagName); err != nil {
+		// Tag was created but push failed - delete local tag and report error
+		DeleteTag(tagName)
+		return fmt.Errorf("failed to push tag: %w\n%s", err, strings.TrimSpace(output))
+	}
+
+	fmt.Printf("✓ Created and pushed tag %s\n", tagName)
+	if prevTag != "" {
+		fmt.Printf("  %d commits since %s\n", len(commits), prevTag)
+	}
+	if url, err := GetTagURL(tagName); err == nil {
+		fmt.Printf("  %s\n", url)
+	}
+
+	if webhook := announceWebhook(); webhook != "" {
+		announceReleasePlain(webhook, BuildAnnouncement(tagName, prevTag, commits), assumeYes)
+	}
+	return nil
+}
+
+// announceReleasePlain previews the release announcement and posts it once
+// confirmed. The tag is already pushed, so a failure is only a warning.
+func announceReleasePlain(webhook string, a Announcement, assumeYes bool) {
+	format := announceFormat(webhook)
+	fmt.Printf("\nAnnouncement (%s):\n", format)
+	for _, line := range strings.Split(a.Render(format), "\n") {
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Println()
+	if !assumeYes && !confirmPlain("Post announcement?") {
+		fmt.Println("Announcement skipped")
+		return
+	}
+	if err := PostAnnouncement(webhook, format, a); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to post the announcement: %v\n", err)
+		return
+	}
+	fmt.Println("✓ Posted the announcement")
+}
+
+// stagedStats returns the files a save commits
+func stagedStats(req saveRequest) []FileDiffStat {
+	var stats []FileDiffStat
+	switch {
+	case req.Amend:
+		stats, _ = GetAmendFileStats()
+	case len(req.Paths) > 0:
+		stats, _ = GetPathsFileStats(req.Paths)
+	default:
+		stats, _ = GetStagedFileStats()
+	}
+	return stats
+}
+
+// pathsLabel lists the paths of a path-limited save, without the pathspecs
+// that exclude patterns added
+func pathsLabel(paths []string) string {
+	var names []string
+	for _, path := range paths {
+		if !strings.HasPrefix(path, ":(") {
+			names = append(names, path)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// saveRequest holds the options of snap save
+type saveRequest struct {
+	Seed       int
+	Message    string   // Custom message; empty asks the AI (or keeps HEAD's message when amending)
+	PrintHash  bool     // Print only the new commit's hash to stdout
+	Amend      bool     // Fold the changes into HEAD
+	Regenerate bool     // Generate a new message for the amended commit or the merge
+	Paths      []string // Only stage and commit these pathspecs
+	CoAuthors  []string // Credited with Co-authored-by trailers
+	Trailers   []string // "Key: value" lines from save.trailers and save.signoff
+	NoVerify   bool     // Skip the pre-commit and commit-msg hooks
+	AllowEmpty bool     // Commit even when nothing changed; needs a Message then
+	Queue      bool     // Without the AI, commit with a placeholder for snap enrich
+	Merge      bool     // Conclude the merge in progress, with git's message unless Regenerate
+	Template   string   // commit.template text, added below the message
+}
+
+// runSavePlain stages and commits without a TUI, using the custom message or
+// the first AI message that follows the commit convention, then prints what
+// was committed the way git commit does. With PrintHash, stdout only gets the
+// new commit's full hash and everything else goes to stderr. With Amend, the
+// changes go into HEAD, which keeps its message unless Regenerate asks the AI
+// for one from the combined diff. With Paths, only the changes below them are
+// staged, described and committed. With Merge, the merge in progress is
+// committed with the message git prepared for it.
+func runSavePlain(req saveRequest) error {
+	out := io.Writer(os.Stdout)
+	if req.PrintHash {
+		out = os.Stderr
+	}
+
+	// With save.staged_only, the index is committed exactly as it is
+	var err error
+	switch {
+	case len(req.Paths) > 0:
+		err = StagePaths(req.Paths)
+	case !config.Save.StagedOnly:
+		if junk, _ := FindJunk(config.Save.Junk, config.Save.Exclude); len(junk) > 0 {
+			for _, j := range junk {
+				fmt.Fprintf(out, "⚠ Staging %s, which usually belongs in .gitignore\n", j.Path)
+			}
+		}
+		err = StageAllChanges(config.Save.Exclude...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+	getDiff := GetGitDiff
+	if req.Amend {
+		getDiff = GetAmendDiff
+	} else if len(req.Paths) > 0 {
+		getDiff = func() (string, error) { return GetPathsDiff(req.Paths) }
+	}
+	diff, err := getDiff()
+	if err != nil {
+		return err
+	}
+	empty := strings.TrimSpace(diff) == "" && !req.Amend
+	if req.Merge {
+		fmt.Fprintln(out, "🔀 Concluding the merge in progress")
+	}
+	if req.Merge && req.Message == "" && !req.Regenerate {
+		if req.Message, err = GetMergeMessage(); err != nil {
+			return err
+		}
+	}
+	if empty && req.Merge && req.Message == "" {
+		return fmt.Errorf("the merge changes nothing to describe - pass a message with -m")
+	}
+	if empty && req.AllowEmpty && req.Message == "" {
+		return fmt.Errorf("an empty commit has no changes to describe - pass a message with -m")
+	}
+	if empty && !req.AllowEmpty && !req.Merge {
+		if len(req.Paths) > 0 {
+			return fmt.Errorf("no changes in %s", pathsLabel(req.Paths))
+		}
+		if config.Save.StagedOnly {
+			return fmt.Errorf("nothing staged - stage changes with git add, or save without --staged-only")
+		}
+		return fmt.Errorf("no changes to commit")
+	}
+
+	findings := CheckDiff(diff, config.Checks)
+	if blocking := blockingFindings(findings); len(blocking) > 0 {
+		return checksError(blocking)
+	}
+	stats := stagedStats(req)
+	if owners := stagedOwners(stats); len(owners) > 0 {
+		fmt.Fprintf(out, "👥 Review: %s\n", ownersSummary(owners))
+	}
+	for _, finding := range findings {
+		fmt.Fprintf(out, "⚠ %s\n", finding)
+	}
+	if config.Checks.Whitespace {
+		for _, issue := range CheckWhitespace(diff) {
+			fmt.Fprintf(out, "⚠ %s\n", issue)
+		}
+	}
+	if large := CheckLargeFiles(stats, config.Checks.MaxFileMB); len(large) > 0 {
+		for _, file := range large {
+			fmt.Fprintf(out, "⚠ %s, over the %d MB limit\n", file, config.Checks.MaxFileMB)
+		}
+		fmt.Fprintf(out, "  Consider Git LFS: %s\n", strings.Join(LFSInstructions(large), " && "))
+	}
+
+	message := req.Message
+	queued := false
+	if message == "" && (!req.Amend || req.Regenerate) {
+		if redactions := diffRedactions(diff); len(redactions) > 0 {
+			fmt.Fprintf(out, "🔒 Redacted before sending to the AI: %s\n", redactionReport(redactions))
+		}
+		if message, err = generatePlainMessage(out, diff, req.Seed); err != nil && !req.Queue {
+			return fmt.Errorf("%w - pass a message with -m to commit without AI, or --queue to describe it later", err)
+		}
+		if err != nil {
+			fmt.Fprintf(out, "⚠ No AI message: %v\n", err)
+			message, queued = PlaceholderMessage(), true
+		}
+	}
+
+	if previous := FindDuplicateSubject(message, recentSubjects(req.Amend)); previous != "" {
+		fmt.Fprintf(out, "⚠ Same as a recent commit: %q\n", previous)
+	}
+	message = ApplyCommitTemplate(message, req.Template)
+	message = AddTrailers(AddCoAuthors(message, req.CoAuthors), req.Trailers)
+
+	var hookOutput string
+	switch {
+	case req.Amend:
+		hookOutput, err = AmendCommit(message, req.NoVerify)
+	case len(req.Paths) > 0:
+		hookOutput, err = CommitPaths(message, req.Paths, req.NoVerify)
+	case empty:
+		hookOutput, err = CommitEmpty(message, req.NoVerify)
+	default:
+		hookOutput, err = CommitChanges(message, req.NoVerify)
+	}
+	if err != nil {
+		if !req.NoVerify && len(CommitHooks(false)) > 0 {
+			return fmt.Errorf("%w\nFix the problem, or skip the hooks with --no-verify", err)
+		}
+		return err
+	}
+	if hookOutput != "" {
+		fmt.Fprintln(out, hookOutput)
+	}
+	if queued {
+		if err := QueueForEnrich("HEAD"); err != nil {
+			return fmt.Errorf("committed, but failed to queue the commit for snap enrich: %w", err)
+		}
+		fmt.Fprintln(out, "⏳ Saved with a placeholder message - run snap enrich once the AI is back")
+	}
+
+	commits, err := GetCommitHistory(1, false, "", "")
+	if err != nil || len(commits) == 0 {
+		if req.PrintHash {
+			return fmt.Errorf("committed, but failed to read the new commit's hash")
+		}
+		return syncAfterSavePlain(out)
+	}
+	branch, _ := GetCurrentBranch()
+	if branch == "" {
+		branch = "detached HEAD"
+	}
+	fmt.Fprintf(out, "[%s %s] %s\n", branch, commits[0].ShortHash, commits[0].Message)
+	if stat, err := GetCommitStat("HEAD"); err == nil && stat != "" {
+		fmt.Fprintln(out, stat)
+	}
+	if err := syncAfterSavePlain(out); err != nil {
+		return err
+	}
+	if req.PrintHash {
+		fmt.Println(commits[0].Hash)
+	}
+	return nil
+}
+
+// continueRebasePlain stages the fixes for the commit a replay stopped at and
+// continues the rebase, instead of committing them as a stray commit on top
+func continueRebasePlain(out io.Writer) error {
+	unresolved, err := unresolvedConflicts()
+	if err != nil {
+		return err
+	}
+	if len(unresolved) > 0 {
+		return fmt.Errorf("conflict markers left in %s - resolve them (snap resolve), then save again", strings.Join(unresolved, ", "))
+	}
+	if err := StageAllChanges(config.Save.Exclude...); err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+	output, err := ContinueRebase()
+	if output = strings.TrimSpace(output); output != "" {
+		fmt.Fprintln(out, output)
+	}
+	if err != nil {
+		return fmt.Errorf("git rebase --continue failed - fix the problem, or give up with git rebase --abort")
+	}
+	if rebasing, _ := CheckRebaseInProgress(); rebasing {
+		fmt.Fprintln(out, "⚠ The replay stopped again - resolve the next conflict, then save again")
+		return nil
+	}
+	fmt.Fprintln(out, "✓ Replay continued and finished")
+	return nil
+}
+
+// unresolvedConflicts lists the conflicted files that still contain conflict
+// markers; the others only wait to be staged
+func unresolvedConflicts() ([]string, error) {
+	paths, err := GetConflictedFiles()
+	if err != nil || len(paths) == 0 {
+		return nil, err
+	}
+	root, err := GetRepoRoot()
+	if err != nil {
+		return nil, err
+	}
+	var unresolved []string
+	for _, path := range paths {
+		content, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			// Deleted on one side; git add -A records the deletion
+			continue
+		}
+		if file, err := ParseConflicts(path, string(content)); err != nil || len(file.Hunks) > 0 {
+			unresolved = append(unresolved, path)
+		}
+	}
+	return unresolved, nil
+}
+
+// syncAfterSavePlain pulls and pushes with save.push, using the same steps
+// as snap sync
+func syncAfterSavePlain(out io.Writer) error {
+	if !config.Save.Push {
+		return nil
+	}
+	m := initialSyncModel(false, false)
+	m.afterSave = true
+	if m = runSyncSteps(m); m.err != nil {
+		return fmt.Errorf("committed, but failed to sync: %w", m.err)
+	}
+	fmt.Fprintln(out, m.View())
+	return nil
+}
+
+// generatePlainMessage asks the AI provider for a message (with a body when
+// save.body is set) and keeps the first candidate that follows the convention.
+// A fallback to another provider is reported on out.
+func generatePlainMessage(out io.Writer, diff string, seed int) (string, error) {
+	if err := CurrentProvider().Check(); err != nil {
+		return "", err
+	}
+	candidates, source, err := GenerateCommitMessageCandidates(context.Background(), diff, seed, config.Save.Candidates, func(string) {}, nil)
+	if err != nil {
+		return "", err
+	}
+	if source.failed != nil {
+		fmt.Fprintf(out, "⚠ Fell back to %s - %s\n", source.name, strings.ReplaceAll(source.failed.Error(), "\n", "; "))
+	}
+
+	var invalid error
+	for _, candidate := range candidates {
+		if invalid = CheckConvention(candidate, config.Convention); invalid != nil {
+			continue
+		}
+		if config.Save.Body {
+			if body, err := GenerateCommitBody(diff, seed); err == nil && body != "" {
+				return candidate + "\n\n" + body, nil
+			}
+		}
+		return candidate, nil
+	}
+	return "", fmt.Errorf("no generated message follows the commit convention (%v)", invalid)
+}
+
+// unreleasedSummary describes how many commits haven't been tagged yet
+func unreleasedSummary(count int, prevTag string) string {
+	noun := "commits"
+	if count == 1 {
+		noun = "commit"
+	}
+	if prevTag == "" {
+		return fmt.Sprintf("%d %s, no tags yet", count, noun)
+	}
+	return fmt.Sprintf("%d %s since %s", count, noun, prevTag)
+}
+
+// runSyncUpdateFork fast-forwards the fork's default branch (sync.origin) and
+// its local copy to upstream's default branch
+func runSyncUpdateFork() error {
+	upstream, origin := config.Sync.Upstream, config.Sync.Origin
+	if upstream == "" {
+		return fmt.Errorf("no upstream remote configured - set upstream in the [sync] section (e.g. upstream = \"upstream\")")
+	}
+	for _, remote := range []string{upstream, origin} {
+		if !RemoteExists(remote) {
+			return fmt.Errorf("remote '%s' doesn't exist - add it with 'git remote add %s <url>'", remote, remote)
+		}
+	}
+
+	branch, err := UpdateFork(upstream, origin)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Fast-forwarded %s/%s and local %s to %s/%s\n", origin, branch, branch, upstream, branch)
+	return nil
+}
+
+// driftSummary describes how far a branch has moved from another one
+func driftSummary(ahead, behind int) string {
+	switch {
+	case ahead == 0 && behind == 0:
+		return "up to date"
+	case behind == 0:
+		return fmt.Sprintf("%d ahead", ahead)
+	case ahead == 0:
+		return fmt.Sprintf("%d behind", behind)
+	}
+	return fmt.Sprintf("%d ahead, %d behind", ahead, behind)
+}
+
+// stagedSummary describes the scope of a commit, e.g.
+// "12 files (+240 -31), 2 binary, 1 lockfile excluded from AI"
+func stagedSummary(stats []FileDiffStat) string {
+	additions, deletions, binary, locks := 0, 0, 0, 0
+	for _, file := range stats {
+		additions += file.Additions
+		deletions += file.Deletions
+		if file.Binary {
+			binary++
+		} else if IsLockfile(file.Path) {
+			locks++
+		}
+	}
+
+	files := "files"
+	if len(stats) == 1 {
+		files = "file"
+	}
+	summary := fmt.Sprintf("%d %s (+%d -%d)", len(stats), files, additions, deletions)
+	var excluded []string
+	if binary > 0 {
+		excluded = append(excluded, fmt.Sprintf("%d binary", binary))
+	}
+	if locks == 1 {
+		excluded = append(excluded, "1 lockfile")
+	} else if locks > 1 {
+		excluded = append(excluded, fmt.Sprintf("%d lockfiles", locks))
+	}
+	if len(excluded) > 0 {
+		summary += ", " + strings.Join(excluded, ", ") + " excluded from AI"
+	}
+	return summary
+}
+
+// runTagsDiffPlain prints the commits since the last tag without a TUI, as
+// text, JSON or markdown, and returns how many there are, so callers can fail
+// CI when a release is due. With stepSummary, the markdown report also goes to
+// the GitHub Actions step summary.
+func runTagsDiffPlain(format string, stepSummary bool) (int, error) {
+	if stepSummary {
+		report, err := GetReleaseReport()
+		if err != nil {
+			return 0, err
+		}
+		if err := WriteStepSummary(report.Markdown()); err != nil {
+			return 0, err
+		}
+	}
+
+	if format == formatJSON || format == formatMarkdown {
+		report, err := GetReleaseReport()
+		if err != nil {
+			return 0, err
+		}
+		if format == formatMarkdown {
+			fmt.Print(report.Markdown())
+			return len(report.Commits), nil
+		}
+		output, err := report.JSON()
+		if err != nil {
+			return 0, err
+		}
+		fmt.Print(output)
+		return len(report.Commits), nil
+	}
+
+	commits, prevTag, err := GetUnreleasedCommits()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load commits: %w", err)
+	}
+
+	fmt.Println(unreleasedSummary(len(commits), prevTag))
+	if len(commits) > 0 {
+		fmt.Println()
+		printCommitsWithStats(commits)
+	}
+
+	return len(commits), nil
+}
+
+// runTagsNotes prints markdown release notes for a tag, or for the commits since
+// the last tag when tagName is empty. Commits and issues are linked to the forge
+// of the origin remote unless links is false. With prs, the notes list the titles
+// of pull requests merged in that range instead of their individual commits.
+// Ticket IDs are looked up with the [tickets] provider, if one is configured.
+func runTagsNotes(tagName string, links bool, prs bool) error {
+	var from, to string
+	if tagName != "" {
+		if _, err := GetTagDetail(tagName); err != nil {
+			return err
+		}
+		from, _ = GetPreviousTag(tagName)
+		to = tagName
+	} else {
+		// Without tags from stays empty and every commit is unreleased
+		from, _ = GetMostRecentTag()
+		to = "HEAD"
+	}
+
+	var linkForge *Forge
+	forge, forgeErr := GetForge()
+	if links && forgeErr == nil {
+		// Without a recognizable remote the notes are still useful, just unlinked
+		linkForge = &forge
+	}
+
+	if prs {
+		if forgeErr != nil {
+			return fmt.Errorf("pull requests need an origin remote: %w", forgeErr)
+		}
+		pulls, commits, err := GetReleasePullRequests(forge, from, to)
+		if err != nil {
+			return fmt.Errorf("failed to load pull requests: %w", err)
+		}
+		if len(pulls) == 0 && len(commits) == 0 {
+			return fmt.Errorf("no pull requests or commits to describe")
+		}
+		fmt.Print(withTickets(GeneratePullRequestChangelog(pulls, commits, linkForge), links))
+		return nil
+	}
+
+	commits, err := GetCommitsBetweenTags(from, to)
+	if err != nil {
+		return fmt.Errorf("failed to load commits: %w", err)
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("no commits to describe")
+	}
+
+	fmt.Print(withTickets(GenerateChangelog(commits, linkForge), links))
+	return nil
+}
+
+// withTickets adds the titles (and links) of the tickets mentioned in release
+// notes when [tickets] is configured. Lookup failures are reported on stderr
+// and leave the notes as they are.
+func withTickets(notes string, links bool) string {
+	if config.Tickets.Provider == "" {
+		return notes
+	}
+	ids := FindTicketIDs(notes, config.Tickets.Keys)
+	if len(ids) == 0 {
+		return notes
+	}
+	tickets, err := ResolveTickets(ids, config.Tickets)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to look up tickets: %v\n", err)
+		return notes
+	}
+	return addTickets(notes, tickets, links)
+}
@@ -0,0 +1,536 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// addBareRemote creates a bare repository and registers it as origin
+func addBareRemote(t *testing.T) string {
+	remoteDir, err := os.MkdirTemp("", "snap-remote-*")
+	if err != nil {
+		t.Fatalf("Failed to create remote dir: %v", err)
+	}
+	if err := exec.Command("git", "init", "--bare", remoteDir).Run(); err != nil {
+		os.RemoveAll(remoteDir)
+		t.Fatalf("Failed to init bare remote: %v", err)
+	}
+	if err := exec.Command("git", "remote", "add", "origin", remoteDir).Run(); err != nil {
+		os.RemoveAll(remoteDir)
+		t.Fatalf("Failed to add remote: %v", err)
+	}
+	return remoteDir
+}
+
+func TestRunTagsCreatePlain(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	remoteDir := addBareRemote(t)
+	defer os.RemoveAll(remoteDir)
+
+	if err := runTagsCreatePlain(tagCreateRequest{Name: "v1.0.0", RequestedTag: "1.0.0", Prefix: "v"}, true); err != nil {
+		t.Fatalf("runTagsCreatePlain failed: %v", err)
+	}
+
+	// Tag should exist locally and on the remote
+	if _, err := GetTagDetail("v1.0.0"); err != nil {
+		t.Errorf("Expected local tag v1.0.0: %v", err)
+	}
+	output, err := exec.Command("git", "ls-remote", "--tags", "origin").Output()
+	if err != nil {
+		t.Fatalf("git ls-remote failed: %v", err)
+	}
+	if !strings.Contains(string(output), "refs/tags/v1.0.0") {
+		t.Errorf("Expected v1.0.0 on remote, got %q", string(output))
+	}
+
+	// With a webhook, the release is announced
+	var posted string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		posted = string(body)
+	}))
+	defer server.Close()
+	t.Setenv(announceWebhookEnvVar, server.URL)
+	exec.Command("git", "commit", "--allow-empty", "-q", "-m", "feat: dark mode").Run()
+	if err := runTagsCreatePlain(tagCreateRequest{Name: "v1.1.0", Prefix: "v"}, true); err != nil {
+		t.Fatalf("runTagsCreatePlain with a webhook failed: %v", err)
+	}
+	if !strings.Contains(posted, "v1.1.0") || !strings.Contains(posted, "dark mode") {
+		t.Errorf("Expected the announcement to be posted, got %q", posted)
+	}
+}
+
+func TestPushWithLeaseRetry(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	remoteDir := addBareRemote(t)
+	defer os.RemoveAll(remoteDir)
+
+	branch, err := GetCurrentBranch()
+	if err != nil {
+		t.Fatalf("GetCurrentBranch failed: %v", err)
+	}
+	if output, err := PushWithLease(branch, false); err != nil {
+		t.Fatalf("PushWithLease failed: %v\n%s", err, output)
+	}
+
+	// Someone else pushes in between
+	otherDir, err := os.MkdirTemp("", "snap-other-*")
+	if err != nil {
+		t.Fatalf("Failed to create clone dir: %v", err)
+	}
+	defer os.RemoveAll(otherDir)
+	exec.Command("git", "clone", "-q", remoteDir, otherDir).Run()
+	other := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", otherDir, "-c", "user.name=Other", "-c", "user.email=other@example.com"}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	other("commit", "--allow-empty", "-m", "Their commit")
+	other("push", "-q")
+
+	exec.Command("git", "commit", "--allow-empty", "-m", "My commit").Run()
+	output, err := PushWithLease(branch, true)
+	if err == nil {
+		t.Fatal("Expected the push to be rejected")
+	}
+	if !IsPushRejected(output) {
+		t.Errorf("Expected a rejected push, got %q", output)
+	}
+
+	if output, err := PullRebase(); err != nil {
+		t.Fatalf("PullRebase failed: %v\n%s", err, output)
+	}
+	if output, err := PushWithLease(branch, true); err != nil {
+		t.Fatalf("Expected the retried push to land: %v\n%s", err, output)
+	}
+}
+
+func TestUpdateFork(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	originDir := addBareRemote(t)
+	defer os.RemoveAll(originDir)
+	upstreamDir, err := os.MkdirTemp("", "snap-upstream-*")
+	if err != nil {
+		t.Fatalf("Failed to create upstream dir: %v", err)
+	}
+	defer os.RemoveAll(upstreamDir)
+	exec.Command("git", "init", "-q", "--bare", upstreamDir).Run()
+	exec.Command("git", "remote", "add", "upstream", upstreamDir).Run()
+
+	branch, err := GetCurrentBranch()
+	if err != nil {
+		t.Fatalf("GetCurrentBranch failed: %v", err)
+	}
+	exec.Command("git", "push", "-q", "upstream", branch).Run()
+	exec.Command("git", "push", "-q", "origin", branch).Run()
+
+	// Upstream moves on while we work on a feature branch
+	exec.Command("git", "checkout", "-q", "-b", "feature").Run()
+	exec.Command("git", "commit", "--allow-empty", "-m", "Upstream work").Run()
+	exec.Command("git", "push", "-q", "upstream", "feature:"+branch).Run()
+	exec.Command("git", "reset", "-q", "--hard", "HEAD~1").Run()
+
+	if _, err := FetchRemote("upstream"); err != nil {
+		t.Fatalf("FetchRemote failed: %v", err)
+	}
+	if source, err := ForkPullBranch("upstream", "feature"); err != nil || source != branch {
+		t.Errorf("Expected feature to pull from upstream's %s, got %q (%v)", branch, source, err)
+	}
+	if source, _ := ForkPullBranch("upstream", branch); source != branch {
+		t.Errorf("Expected %s to pull from itself, got %q", branch, source)
+	}
+
+	if _, err := UpdateFork("upstream", "origin"); err != nil {
+		t.Fatalf("UpdateFork failed: %v", err)
+	}
+	want, _ := exec.Command("git", "rev-parse", "upstream/"+branch).Output()
+	for _, ref := range []string{branch, "refs/remotes/origin/" + branch} {
+		exec.Command("git", "fetch", "-q", "origin").Run()
+		got, _ := exec.Command("git", "rev-parse", ref).Output()
+		if string(got) != string(want) {
+			t.Errorf("Expected %s to be fast-forwarded to upstream, got %s", ref, got)
+		}
+	}
+	if current, _ := GetCurrentBranch(); current != "feature" {
+		t.Errorf("Expected to stay on feature, got %s", current)
+	}
+}
+
+func TestRunTagsCreatePlainPushFailure(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	// No remote configured - push fails and the local tag is rolled back
+	if err := runTagsCreatePlain(tagCreateRequest{Name: "v1.0.0", Prefix: "v"}, true); err == nil {
+		t.Fatal("Expected error when pushing without a remote")
+	}
+	if _, err := GetTagDetail("v1.0.0"); err == nil {
+		t.Error("Expected local tag to be deleted after failed push")
+	}
+}
+
+func TestUnreleasedSummary(t *testing.T) {
+	tests := []struct {
+		count    int
+		prevTag  string
+		expected string
+	}{
+		{count: 3, prevTag: "v1.2.0", expected: "3 commits since v1.2.0"},
+		{count: 1, prevTag: "v1.2.0", expected: "1 commit since v1.2.0"},
+		{count: 0, prevTag: "v1.2.0", expected: "0 commits since v1.2.0"},
+		{count: 5, prevTag: "", expected: "5 commits, no tags yet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if result := unreleasedSummary(tt.count, tt.prevTag); result != tt.expected {
+				t.Errorf("unreleasedSummary(%d, %q) = %q, want %q", tt.count, tt.prevTag, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDriftSummary(t *testing.T) {
+	tests := []struct {
+		ahead, behind int
+		expected      string
+	}{
+		{0, 0, "up to date"},
+		{2, 0, "2 ahead"},
+		{0, 3, "3 behind"},
+		{2, 3, "2 ahead, 3 behind"},
+	}
+
+	for _, tt := range tests {
+		if result := driftSummary(tt.ahead, tt.behind); result != tt.expected {
+			t.Errorf("driftSummary(%d, %d) = %q, want %q", tt.ahead, tt.behind, result, tt.expected)
+		}
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	exec.Command("git", "tag", "-a", "v1.0.0", "-m", "v1.0.0").Run()
+	exec.Command("git", "commit", "--allow-empty", "-m", "fix: one").Run()
+	exec.Command("git", "commit", "--allow-empty", "-m", "fix: two").Run()
+
+	count, err := runStatus(false)
+	if err != nil {
+		t.Fatalf("runStatus failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 unreleased commits, got %d", count)
+	}
+
+	count, err = runTagsDiffPlain(formatText, false)
+	if err != nil {
+		t.Fatalf("runTagsDiffPlain failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 commits since last tag, got %d", count)
+	}
+}
+
+func TestRunTagsNotes(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	if err := runTagsNotes("v9.9.9", true, false); err == nil {
+		t.Error("Expected error for missing tag")
+	}
+
+	exec.Command("git", "remote", "add", "origin", "git@github.com:owner/repo.git").Run()
+	exec.Command("git", "tag", "-a", "v1.0.0", "-m", "v1.0.0").Run()
+	exec.Command("git", "commit", "--allow-empty", "-m", "feat: add notes (#5)").Run()
+	exec.Command("git", "tag", "-a", "v1.1.0", "-m", "v1.1.0").Run()
+
+	if err := runTagsNotes("v1.1.0", true, false); err != nil {
+		t.Errorf("runTagsNotes failed: %v", err)
+	}
+	// No commits since v1.1.0
+	if err := runTagsNotes("", false, false); err == nil {
+		t.Error("Expected error when there are no unreleased commits")
+	}
+}
+
+func TestStagedSummary(t *testing.T) {
+	stats := []FileDiffStat{
+		{Path: "main.go", Additions: 200, Deletions: 30},
+		{Path: "web/package-lock.json", Additions: 40, Deletions: 1},
+		{Path: "logo.png", Binary: true},
+	}
+	if got, want := stagedSummary(stats), "3 files (+240 -31), 1 binary, 1 lockfile excluded from AI"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got, want := stagedSummary(stats[:1]), "1 file (+200 -30)"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestRunSavePlain(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	defer applyConfig(config)
+	cfg := defaultConfig()
+	cfg.Ollama.URL = "http://127.0.0.1:1" // Nothing listens here
+	applyConfig(cfg)
+
+	if err := runSavePlain(saveRequest{Seed: 42, Message: "fix: nothing"}); err == nil || !strings.Contains(err.Error(), "no changes") {
+		t.Errorf("Expected an error without changes, got %v", err)
+	}
+
+	os.WriteFile("notes.txt", []byte("hello\n"), 0644)
+	if err := runSavePlain(saveRequest{Seed: 42, Message: "docs: add notes"}); err != nil {
+		t.Fatalf("runSavePlain failed: %v", err)
+	}
+	if stat, err := GetCommitStat("HEAD"); err != nil || !strings.Contains(stat, "1 file changed, 1 insertion(+)") {
+		t.Errorf("Expected the commit's diff stat, got %q, %v", stat, err)
+	}
+
+	os.WriteFile("notes.txt", []byte("hello\nworld\n"), 0644)
+	if err := runSavePlain(saveRequest{Seed: 42, Message: "docs: extend notes", PrintHash: true}); err != nil {
+		t.Fatalf("runSavePlain with printHash failed: %v", err)
+	}
+
+	os.WriteFile("more.txt", []byte("more\n"), 0644)
+	if err := runSavePlain(saveRequest{Amend: true}); err != nil {
+		t.Fatalf("runSavePlain with Amend failed: %v", err)
+	}
+	if message, _ := GetHeadMessage(); message != "docs: extend notes" {
+		t.Errorf("Expected amending to keep the message, got %q", message)
+	}
+	if stat, _ := GetCommitStat("HEAD"); !strings.Contains(stat, "2 files changed") {
+		t.Errorf("Expected more.txt in the amended commit, got %q", stat)
+	}
+
+	os.WriteFile("notes.txt", []byte("<<<<<<< HEAD\nhello\n=======\nbye\n>>>>>>> other\n"), 0644)
+	if err := runSavePlain(saveRequest{Seed: 42, Message: "docs: merge notes"}); err == nil || !strings.Contains(err.Error(), "conflict marker") {
+		t.Errorf("Expected the conflict markers to block the commit, got %v", err)
+	}
+
+	os.WriteFile("notes.txt", []byte("hello again\n"), 0644)
+	if err := runSavePlain(saveRequest{Seed: 42}); err == nil || !strings.Contains(err.Error(), "-m") {
+		t.Errorf("Expected a hint to pass -m when the AI is unavailable, got %v", err)
+	}
+}
+
+func TestRunSavePlainAllowEmpty(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	defer applyConfig(config)
+	cfg := defaultConfig()
+	cfg.Ollama.URL = "http://127.0.0.1:1" // Nothing listens here
+	applyConfig(cfg)
+
+	if err := runSavePlain(saveRequest{Seed: 42, AllowEmpty: true}); err == nil || !strings.Contains(err.Error(), "-m") {
+		t.Errorf("Expected an empty commit to need a message, got %v", err)
+	}
+	if err := runSavePlain(saveRequest{Seed: 42, Message: "ci: rerun the build", AllowEmpty: true}); err != nil {
+		t.Fatalf("runSavePlain with AllowEmpty failed: %v", err)
+	}
+	commits, err := GetCommitHistory(2, false, "", "")
+	if err != nil || len(commits) != 2 || commits[0].Message != "ci: rerun the build" {
+		t.Fatalf("Expected the empty commit on top, got %+v, %v", commits, err)
+	}
+	if stat, _ := GetCommitStat("HEAD"); stat != "" {
+		t.Errorf("Expected no changes in the commit, got %q", stat)
+	}
+}
+
+func TestRunSavePlainStagedOnly(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	defer applyConfig(config)
+	cfg := defaultConfig()
+	cfg.Save.StagedOnly = true
+	applyConfig(cfg)
+
+	os.WriteFile("staged.txt", []byte("staged\n"), 0644)
+	os.WriteFile("unstaged.txt", []byte("unstaged\n"), 0644)
+	if err := runSavePlain(saveRequest{Message: "feat: staged"}); err == nil || !strings.Contains(err.Error(), "nothing staged") {
+		t.Errorf("Expected an error with an empty index, got %v", err)
+	}
+
+	exec.Command("git", "add", "staged.txt").Run()
+	if err := runSavePlain(saveRequest{Message: "feat: staged"}); err != nil {
+		t.Fatalf("runSavePlain failed: %v", err)
+	}
+	output, _ := exec.Command("git", "show", "--name-only", "--format=", "HEAD").Output()
+	if files := strings.Fields(string(output)); len(files) != 1 || files[0] != "staged.txt" {
+		t.Errorf("Expected only staged.txt in the commit, got %v", files)
+	}
+	if status, _ := exec.Command("git", "status", "--porcelain").Output(); !strings.Contains(string(status), "?? unstaged.txt") {
+		t.Errorf("Expected unstaged.txt to stay untracked, got %q", status)
+	}
+}
+
+func TestRunSavePlainPaths(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	os.WriteFile("other.txt", []byte("other\n"), 0644)
+	if err := runSavePlain(saveRequest{Message: "fix: nothing", Paths: []string{"test.txt"}}); err == nil || !strings.Contains(err.Error(), "no changes in test.txt") {
+		t.Errorf("Expected an error for a path without changes, got %v", err)
+	}
+
+	os.MkdirAll("src/auth", 0755)
+	os.WriteFile("src/auth/login.go", []byte("package auth\n"), 0644)
+	os.WriteFile("staged.txt", []byte("staged\n"), 0644)
+	exec.Command("git", "add", "staged.txt").Run()
+	os.Remove("test.txt")
+
+	if err := runSavePlain(saveRequest{Message: "feat(auth): add login", Paths: []string{"src/auth", "test.txt"}}); err != nil {
+		t.Fatalf("runSavePlain failed: %v", err)
+	}
+	output, _ := exec.Command("git", "show", "--name-status", "--format=", "HEAD").Output()
+	if committed := strings.Fields(string(output)); strings.Join(committed, " ") != "A src/auth/login.go D test.txt" {
+		t.Errorf("Expected only the given paths in the commit, got %v", committed)
+	}
+	status, _ := exec.Command("git", "status", "--porcelain").Output()
+	if !strings.Contains(string(status), "A  staged.txt") || !strings.Contains(string(status), "?? other.txt") {
+		t.Errorf("Expected the other changes to be left as they were, got %q", status)
+	}
+}
+
+func TestRunSavePlainPush(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	defer applyConfig(config)
+	cfg := defaultConfig()
+	cfg.Save.Push = true
+	applyConfig(cfg)
+
+	os.WriteFile("new.txt", []byte("new\n"), 0644)
+	if err := runSavePlain(saveRequest{Message: "feat: no remote"}); err == nil || !strings.Contains(err.Error(), "committed, but failed to sync") {
+		t.Errorf("Expected a sync error without a remote, got %v", err)
+	}
+
+	remoteDir := addBareRemote(t)
+	defer os.RemoveAll(remoteDir)
+	os.WriteFile("new.txt", []byte("newer\n"), 0644)
+	if err := runSavePlain(saveRequest{Message: "feat: pushed"}); err != nil {
+		t.Fatalf("runSavePlain failed: %v", err)
+	}
+
+	// The new branch is pushed with an upstream
+	branch, _ := GetCurrentBranch()
+	remote, _ := exec.Command("git", "--git-dir", remoteDir, "log", "-1", "--format=%s", branch).Output()
+	if got := strings.TrimSpace(string(remote)); got != "feat: pushed" {
+		t.Errorf("Expected the commit on the remote, got %q", got)
+	}
+	if hasUpstream, _ := HasUpstreamBranch(); !hasUpstream {
+		t.Error("Expected the branch to track the remote")
+	}
+}
+
+func TestRunSavePlainMerge(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	defer applyConfig(config)
+	cfg := defaultConfig()
+	cfg.Ollama.URL = "http://127.0.0.1:1" // Nothing listens here
+	applyConfig(cfg)
+
+	branch, _ := GetCurrentBranch()
+	exec.Command("git", "checkout", "-q", "-b", "feature").Run()
+	os.WriteFile("notes.txt", []byte("feature\n"), 0644)
+	exec.Command("git", "add", "notes.txt").Run()
+	exec.Command("git", "commit", "-q", "-m", "feat: add notes").Run()
+	exec.Command("git", "checkout", "-q", branch).Run()
+	os.WriteFile("notes.txt", []byte("main\n"), 0644)
+	exec.Command("git", "add", "notes.txt").Run()
+	exec.Command("git", "commit", "-q", "-m", "docs: start notes").Run()
+
+	if err := exec.Command("git", "merge", "-q", "feature").Run(); err == nil {
+		t.Fatal("Expected the merge to conflict")
+	}
+	if !CheckMergeInProgress() {
+		t.Fatal("Expected a merge in progress")
+	}
+	message, err := GetMergeMessage()
+	if err != nil || message != "Merge branch 'feature'" {
+		t.Fatalf("Expected git's merge message without the comments, got %q, %v", message, err)
+	}
+
+	os.WriteFile("notes.txt", []byte("main\nfeature\n"), 0644)
+	if err := runSavePlain(saveRequest{Seed: 42, Merge: true}); err != nil {
+		t.Fatalf("runSavePlain with Merge failed: %v", err)
+	}
+	if CheckMergeInProgress() {
+		t.Error("Expected the merge to be concluded")
+	}
+	if head, _ := GetHeadMessage(); head != "Merge branch 'feature'" {
+		t.Errorf("Expected git's merge message, got %q", head)
+	}
+	if output, _ := exec.Command("git", "rev-list", "--parents", "-n", "1", "HEAD").Output(); len(strings.Fields(string(output))) != 3 {
+		t.Errorf("Expected a merge commit with two parents, got %q", output)
+	}
+
+	// A merge that keeps HEAD's files still gets its commit
+	exec.Command("git", "checkout", "-q", "-b", "other", "HEAD~1").Run()
+	os.WriteFile("other.txt", []byte("other\n"), 0644)
+	exec.Command("git", "add", "other.txt").Run()
+	exec.Command("git", "commit", "-q", "-m", "feat: add other").Run()
+	exec.Command("git", "checkout", "-q", branch).Run()
+	if output, err := exec.Command("git", "merge", "-q", "-s", "ours", "--no-commit", "other").CombinedOutput(); err != nil {
+		t.Fatalf("Failed to start the merge: %s", output)
+	}
+	if err := runSavePlain(saveRequest{Seed: 42, Merge: true}); err != nil {
+		t.Fatalf("runSavePlain with an empty merge failed: %v", err)
+	}
+	if head, _ := GetHeadMessage(); head != "Merge branch 'other'" {
+		t.Errorf("Expected git's merge message, got %q", head)
+	}
+}
+
+func TestContinueRebasePlain(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	defer applyConfig(config)
+	applyConfig(defaultConfig())
+
+	mainBranch, _ := GetCurrentBranch()
+	os.WriteFile("notes.txt", []byte("main\n"), 0644)
+	exec.Command("git", "add", ".").Run()
+	exec.Command("git", "commit", "-q", "-m", "docs: start notes").Run()
+	exec.Command("git", "checkout", "-q", "-b", "feature", "HEAD~1").Run()
+	os.WriteFile("notes.txt", []byte("feature\n"), 0644)
+	exec.Command("git", "add", ".").Run()
+	exec.Command("git", "commit", "-q", "-m", "feat: add notes").Run()
+
+	if _, err := ReplayCommits(mainBranch, false, nil, nil); err == nil {
+		t.Fatal("Expected the replay to stop at a conflict")
+	}
+	if rebasing, _ := CheckRebaseInProgress(); !rebasing {
+		t.Fatal("Expected a rebase in progress")
+	}
+	if err := continueRebasePlain(io.Discard); err == nil || !strings.Contains(err.Error(), "notes.txt") {
+		t.Errorf("Expected the conflict markers to stop the save, got %v", err)
+	}
+
+	os.WriteFile("notes.txt", []byte("main\nfeature\n"), 0644)
+	if err := continueRebasePlain(io.Discard); err != nil {
+		t.Fatalf("continueRebasePlain failed: %v", err)
+	}
+	if rebasing, _ := CheckRebaseInProgress(); rebasing {
+		t.Error("Expected the rebase to be finished")
+	}
+	output, _ := exec.Command("git", "log", "--format=%s", mainBranch+"..HEAD").Output()
+	if subjects := strings.TrimSpace(string(output)); subjects != "feat: add notes" {
+		t.Errorf("Expected only the replayed commit on top, got %q", subjects)
+	}
+}
@@ -0,0 +1,456 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PrepareCommit is a commit of the branch that snap prepare-pr reshapes
+type PrepareCommit struct {
+	Hash      string
+	ShortHash string
+	Subject   string
+	Files     []string
+}
+
+// PrepareGroup is a commit of the prepared branch: the commits squashed into
+// it, oldest first, and its new message
+type PrepareGroup struct {
+	Commits []PrepareCommit
+	Message string
+	Err     error // Why there is no message
+}
+
+// GetPrepareCommits returns where the current branch left base and its
+// commits since, oldest first. Merges are refused, since squashing replays
+// the branch as a straight line.
+func GetPrepareCommits(base string) (string, []PrepareCommit, error) {
+	output, err := exec.Command("git", "merge-base", base, "HEAD").Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("the current branch has no common history with %s", base)
+	}
+	mergeBase := strings.TrimSpace(string(output))
+
+	output, err = exec.Command("git", "log", "--reverse", "--format=%H%x00%h%x00%P%x00%s", mergeBase+"..HEAD").Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	var commits []PrepareCommit
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		if len(strings.Fields(fields[2])) > 1 {
+			return "", nil, fmt.Errorf("the branch contains merge commits - replay it onto %s first", base)
+		}
+		files, err := exec.Command("git", "show", "--name-only", "--format=", fields[0]).Output()
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read %s: %w", fields[1], err)
+		}
+		commits = append(commits, PrepareCommit{
+			Hash:      fields[0],
+			ShortHash: fields[1],
+			Subject:   fields[3],
+			Files:     strings.Fields(string(files)),
+		})
+	}
+	return mergeBase, commits, nil
+}
+
+// SquashCommits rebuilds the branch above mergeBase as the groups: the first
+// commit of each is picked, the others are squashed into it with fixup, and
+// an exec sets the group's message. A new order that doesn't apply cleanly
+// leaves the branch as it was.
+func SquashCommits(mergeBase string, groups []PrepareGroup) error {
+	before, err := exec.Command("git", "rev-parse", "HEAD^{tree}").Output()
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "snap-prepare-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	var todo strings.Builder
+	for i, group := range groups {
+		for j, commit := range group.Commits {
+			action := "pick"
+			if j > 0 {
+				action = "fixup"
+			}
+			todo.WriteString(action + " " + commit.Hash + "\n")
+		}
+		file := filepath.Join(dir, fmt.Sprintf("group-%d", i+1))
+		if err := os.WriteFile(file, []byte(group.Message), 0644); err != nil {
+			return err
+		}
+		todo.WriteString(amendMessageExec(file) + "\n")
+	}
+	todoFile := filepath.Join(dir, "todo")
+	if err := os.WriteFile(todoFile, []byte(todo.String()), 0644); err != nil {
+		return err
+	}
+
+	// The sequence editor swaps git's todo list for ours
+	cmd := exec.Command("git", "rebase", "--interactive", "--quiet", "--autostash", mergeBase)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("GIT_SEQUENCE_EDITOR=cp '%s'", todoFile), "GIT_EDITOR=true")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		exec.Command("git", "rebase", "--abort").Run()
+		return fmt.Errorf("the new order doesn't apply cleanly, nothing changed: %s", strings.TrimSpace(string(output)))
+	}
+
+	// Reordering must not change what the branch ends up with
+	after, err := exec.Command("git", "rev-parse", "HEAD^{tree}").Output()
+	if err != nil || string(after) != string(before) {
+		return fmt.Errorf("the prepared branch doesn't end with the same files as before")
+	}
+	return nil
+}
+
+// groupDiff joins the diffs of a group's commits, which the group's message
+// describes
+func groupDiff(group PrepareGroup) (string, error) {
+	var diff strings.Builder
+	for _, commit := range group.Commits {
+		commitDiff, err := GetCommitDiff(commit.Hash)
+		if err != nil {
+			return "", err
+		}
+		diff.WriteString(commitDiff)
+	}
+	return diff.String(), nil
+}
+
+type prepareState int
+
+const (
+	prepareStateLoading prepareState = iota
+	prepareStateGrouping
+	prepareStateWriting
+	prepareStatePreview
+	prepareStateApplying
+	prepareStateDone
+	prepareStateError
+)
+
+type prepareModel struct {
+	state     prepareState
+	spinner   spinner.Model
+	base      string
+	noPush    bool
+	branch    string
+	mergeBase string
+	commits   []PrepareCommit
+	groups    []PrepareGroup
+	grouped   error // Why the AI's grouping wasn't used; every commit stays on its own
+	writing   int   // The group whose message is being written
+	seed      int
+	notice    string // Why applying didn't start
+	backupRef string
+	pushed    string // Output of the push
+	pushErr   error
+	err       error
+}
+
+type prepareLoadMsg struct {
+	branch    string
+	mergeBase string
+	commits   []PrepareCommit
+	err       error
+}
+
+type prepareGroupsMsg struct {
+	groups [][]int
+	err    error
+}
+
+type prepareMessageMsg struct {
+	index   int
+	message string
+	err     error
+}
+
+type prepareApplyMsg struct {
+	backupRef string
+	pushed    string
+	pushErr   error
+	err       error
+}
+
+func initialPrepareModel(base string, noPush bool, seed int) prepareModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
+
+	return prepareModel{
+		state:   prepareStateLoading,
+		spinner: s,
+		base:    base,
+		noPush:  noPush,
+		seed:    seed,
+	}
+}
+
+func (m prepareModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, loadPrepareCmd(m.base))
+}
+
+func loadPrepareCmd(base string) tea.Cmd {
+	return func() tea.Msg {
+		branch, err := GetCurrentBranch()
+		if err != nil {
+			return prepareLoadMsg{err: err}
+		}
+		if branch == "" || branch == base {
+			return prepareLoadMsg{err: fmt.Errorf("switch to the feature branch to prepare first")}
+		}
+		mergeBase, commits, err := GetPrepareCommits(base)
+		if err == nil && len(commits) > 0 {
+			err = CurrentProvider().Check()
+		}
+		return prepareLoadMsg{branch: branch, mergeBase: mergeBase, commits: commits, err: err}
+	}
+}
+
+// suggestGroupsCmd asks the AI how to squash and order the commits
+func suggestGroupsCmd(commits []PrepareCommit, seed int) tea.Cmd {
+	return func() tea.Msg {
+		groups, err := SuggestCommitGroups(commits, seed)
+		return prepareGroupsMsg{groups: groups, err: err}
+	}
+}
+
+// prepareMessageCmd asks the AI for a message describing a whole group
+func prepareMessageCmd(index int, group PrepareGroup, seed int) tea.Cmd {
+	return func() tea.Msg {
+		diff, err := groupDiff(group)
+		if err != nil {
+			return prepareMessageMsg{index: index, err: err}
+		}
+		message, err := GenerateCommitMessage(diff, seed)
+		if err == nil {
+			message = strings.TrimSpace(message)
+			err = CheckConvention(message, config.Convention)
+		}
+		return prepareMessageMsg{index: index, message: message, err: err}
+	}
+}
+
+// applyPrepareCmd backs up HEAD, squashes the branch into the groups and
+// force-pushes it with lease unless noPush is set
+func applyPrepareCmd(mergeBase, branch string, groups []PrepareGroup, noPush bool) tea.Cmd {
+	return func() tea.Msg {
+		backupRef, err := BackupHead("before snap prepare-pr")
+		if err != nil {
+			return prepareApplyMsg{err: err}
+		}
+		if err := StartOperation("prepare-pr", backupRef, !noPush); err != nil {
+			return prepareApplyMsg{backupRef: backupRef, err: err}
+		}
+		defer EndOperation()
+		if err := SquashCommits(mergeBase, groups); err != nil {
+			return prepareApplyMsg{backupRef: backupRef, err: err}
+		}
+		if noPush {
+			return prepareApplyMsg{backupRef: backupRef}
+		}
+		hasUpstream, _ := HasUpstreamBranch()
+		output, err := PushWithLease(branch, hasUpstream)
+		return prepareApplyMsg{backupRef: backupRef, pushed: strings.TrimSpace(output), pushErr: err}
+	}
+}
+
+// withGroups builds the groups from the AI's indexes, or keeps every commit
+// on its own when there are none
+func (m prepareModel) withGroups(indexes [][]int) prepareModel {
+	m.groups = nil
+	if indexes == nil {
+		for _, commit := range m.commits {
+			m.groups = append(m.groups, PrepareGroup{Commits: []PrepareCommit{commit}})
+		}
+		return m
+	}
+	for _, group := range indexes {
+		var commits []PrepareCommit
+		for _, i := range group {
+			commits = append(commits, m.commits[i])
+		}
+		m.groups = append(m.groups, PrepareGroup{Commits: commits})
+	}
+	return m
+}
+
+func (m prepareModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.state == prepareStatePreview {
+			m.notice = ""
+			switch msg.String() {
+			case "ctrl+c", "q", "esc", "n":
+				m.state = prepareStateDone
+				m.err = fmt.Errorf("prepare-pr cancelled")
+				return m, tea.Quit
+			case "g":
+				// Ask for another grouping and messages
+				m.seed++
+				m.state = prepareStateGrouping
+				return m, suggestGroupsCmd(m.commits, m.seed)
+			case "enter", "y":
+				for i, group := range m.groups {
+					if group.Err != nil {
+						m.notice = fmt.Sprintf("Commit %d has no valid message - press g to try again", i+1)
+						return m, nil
+					}
+				}
+				m.state = prepareStateApplying
+				return m, applyPrepareCmd(m.mergeBase, m.branch, m.groups, m.noPush)
+			}
+			return m, nil
+		}
+		if msg.String() == "ctrl+c" && m.state != prepareStateApplying {
+			return m, tea.Quit
+		}
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case prepareLoadMsg:
+		if msg.err != nil {
+			m.state = prepareStateError
+			m.err = msg.err
+			return m, tea.Quit
+		}
+		m.branch = msg.branch
+		m.mergeBase = msg.mergeBase
+		m.commits = msg.commits
+		if len(m.commits) == 0 {
+			m.state = prepareStateError
+			m.err = fmt.Errorf("no commits since %s to prepare", m.base)
+			return m, tea.Quit
+		}
+		m.state = prepareStateGrouping
+		return m, suggestGroupsCmd(m.commits, m.seed)
+
+	case prepareGroupsMsg:
+		m.grouped = msg.err
+		m = m.withGroups(msg.groups)
+		m.writing = 0
+		m.state = prepareStateWriting
+		return m, prepareMessageCmd(0, m.groups[0], m.seed)
+
+	case prepareMessageMsg:
+		m.groups[msg.index].Message = msg.message
+		m.groups[msg.index].Err = msg.err
+		if next := msg.index + 1; next < len(m.groups) {
+			m.writing = next
+			return m, prepareMessageCmd(next, m.groups[next], m.seed)
+		}
+		m.state = prepareStatePreview
+		return m, nil
+
+	case prepareApplyMsg:
+		m.backupRef = msg.backupRef
+		if msg.err != nil {
+			m.state = prepareStateError
+			m.err = msg.err
+			return m, tea.Quit
+		}
+		m.pushed = msg.pushed
+		m.pushErr = msg.pushErr
+		m.state = prepareStateDone
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+func (m prepareModel) View() string {
+	helpStyle := lipgloss.NewStyle().Foreground(colorMuted)
+
+	switch m.state {
+	case prepareStateLoading:
+		return fmt.Sprintf("%s Reading the branch...", m.spinner.View())
+
+	case prepareStateGrouping:
+		return fmt.Sprintf("%s Asking %s how to group %d commits...", m.spinner.View(), CurrentProvider().Name(), len(m.commits))
+
+	case prepareStateWriting:
+		return fmt.Sprintf("%s Writing messages (%d/%d)...", m.spinner.View(), m.writing+1, len(m.groups))
+
+	case prepareStatePreview:
+		subjectStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+
+		var s strings.Builder
+		s.WriteString(titleStyle.Render(fmt.Sprintf("Prepare '%s' for review: %d commits → %d", m.branch, len(m.commits), len(m.groups))))
+		s.WriteString("\n")
+		if m.grouped != nil {
+			s.WriteString(lipgloss.NewStyle().Foreground(colorWarning).Render(fmt.Sprintf("⚠ Keeping every commit on its own: %v", m.grouped)) + "\n\n")
+		}
+		for _, group := range m.groups {
+			if group.Err != nil {
+				s.WriteString(errorStyle.Render("✗ "+group.Err.Error()) + "\n")
+			} else {
+				s.WriteString(subjectStyle.Render("● "+group.Message) + "\n")
+			}
+			for _, commit := range group.Commits {
+				s.WriteString(fmt.Sprintf("    %s %s\n", infoStyle.Render(commit.ShortHash), helpStyle.Render(commit.Subject)))
+			}
+		}
+		s.WriteString("\n")
+		if m.notice != "" {
+			s.WriteString(highlightStyle.Render(m.notice) + "\n")
+		}
+		apply := "squash and force-push with lease"
+		if m.noPush {
+			apply = "squash"
+		}
+		s.WriteString(helpStyle.Render(fmt.Sprintf("enter: %s  g: regroup  q: quit", apply)))
+		s.WriteString("\n")
+		return s.String()
+
+	case prepareStateApplying:
+		return fmt.Sprintf("%s Squashing into %d commits...", m.spinner.View(), len(m.groups))
+
+	case prepareStateDone:
+		if m.err != nil {
+			return errorStyle.Render(fmt.Sprintf("✗ %s", m.err))
+		}
+		var s strings.Builder
+		s.WriteString(successStyle.Render(fmt.Sprintf("✓ Prepared '%s': %d commits → %d", m.branch, len(m.commits), len(m.groups))) + "\n")
+		switch {
+		case m.noPush:
+		case m.pushErr != nil:
+			s.WriteString(errorStyle.Render("✗ Push failed - push with snap sync, or git push --force-with-lease") + "\n")
+			if m.pushed != "" {
+				s.WriteString(helpStyle.Render(m.pushed) + "\n")
+			}
+		default:
+			s.WriteString(successStyle.Render("✓ Force-pushed with lease") + "\n")
+		}
+		s.WriteString(restoreHint(m.backupRef))
+		return s.String()
+
+	case prepareStateError:
+		if m.backupRef != "" {
+			return fmt.Sprintf("%s\n%s",
+				errorStyle.Render(fmt.Sprintf("✗ Error: %s", m.err)),
+				restoreHint(m.backupRef),
+			)
+		}
+		return errorStyle.Render(fmt.Sprintf("✗ Error: %s", m.err))
+	}
+
+	return ""
+}
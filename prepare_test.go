@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCleanCommitGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    [][]int
+		wantErr string
+	}{
+		{name: "groups in a new order", reply: "2\n3,1\n", want: [][]int{{1}, {0, 2}}},
+		{name: "spaces and fences", reply: "```\n1, 3\n2\n```", want: [][]int{{0, 2}, {1}}},
+		{name: "missing commit", reply: "1,2", wantErr: "left out commit 3"},
+		{name: "repeated commit", reply: "1,2\n2,3", wantErr: "more than one group"},
+		{name: "out of range", reply: "1,2,3,4", wantErr: "invalid group"},
+		{name: "prose", reply: "Group 1: 1,2", wantErr: "invalid group"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cleanCommitGroups(tt.reply, 3)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected an error with %q, got %v, %v", tt.wantErr, got, err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cleanCommitGroups(%q) = %v, %v; want %v", tt.reply, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSquashCommits(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	base, _ := GetCurrentBranch()
+	exec.Command("git", "checkout", "-q", "-b", "feature").Run()
+	commit := func(file, content, message string) {
+		os.WriteFile(file, []byte(content), 0644)
+		exec.Command("git", "add", ".").Run()
+		exec.Command("git", "commit", "-q", "-m", message).Run()
+	}
+	commit("api.go", "package api\n", "add api")
+	commit("docs.md", "# Docs\n", "add docs")
+	commit("api.go", "package api\n\nfunc Get() {}\n", "fix typo")
+
+	mergeBase, commits, err := GetPrepareCommits(base)
+	if err != nil || len(commits) != 3 {
+		t.Fatalf("Expected three commits to prepare, got %v, %v", commits, err)
+	}
+	if commits[0].Subject != "add api" || !reflect.DeepEqual(commits[2].Files, []string{"api.go"}) {
+		t.Errorf("Expected the commits oldest first with their files, got %+v", commits)
+	}
+
+	// Applying the fix before the file exists can't work
+	conflicting := []PrepareGroup{
+		{Commits: []PrepareCommit{commits[2]}, Message: "fix: update api"},
+		{Commits: []PrepareCommit{commits[0], commits[1]}, Message: "feat: add api"},
+	}
+	if err := SquashCommits(mergeBase, conflicting); err == nil {
+		t.Error("Expected the conflicting order to fail")
+	}
+	if rebasing, _ := CheckRebaseInProgress(); rebasing {
+		t.Fatal("Expected the failed rebase to be aborted")
+	}
+	if output, _ := exec.Command("git", "rev-list", "--count", mergeBase+"..HEAD").Output(); strings.TrimSpace(string(output)) != "3" {
+		t.Errorf("Expected the branch to be unchanged, got %s commits", output)
+	}
+
+	groups := []PrepareGroup{
+		{Commits: []PrepareCommit{commits[1]}, Message: "docs: add docs"},
+		{Commits: []PrepareCommit{commits[0], commits[2]}, Message: "feat: add api"},
+	}
+	if err := SquashCommits(mergeBase, groups); err != nil {
+		t.Fatalf("SquashCommits failed: %v", err)
+	}
+	output, _ := exec.Command("git", "log", "--reverse", "--format=%s", mergeBase+"..HEAD").Output()
+	if subjects := strings.Split(strings.TrimSpace(string(output)), "\n"); !reflect.DeepEqual(subjects, []string{"docs: add docs", "feat: add api"}) {
+		t.Errorf("Expected the squashed and reordered commits, got %q", subjects)
+	}
+	if content, _ := os.ReadFile("api.go"); string(content) != "package api\n\nfunc Get() {}\n" {
+		t.Errorf("Expected the files to stay the same, got %q", content)
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pullRequestPageSize is the number of pull requests requested per API page
+const pullRequestPageSize = 100
+
+// pullRequestMaxPages bounds how far back the pull request history is searched
+const pullRequestMaxPages = 10
+
+// PullRequest is a merged pull request (a merge request on GitLab)
+type PullRequest struct {
+	Label       string // "#12" on GitHub, "!12" on GitLab
+	Title       string
+	Body        string
+	URL         string
+	MergeCommit string // Merge, squash, or last rebased commit on the target branch
+	UpdatedAt   time.Time
+}
+
+// APIURL returns the REST API endpoint of the repository
+func (f Forge) APIURL() (string, error) {
+	u, err := url.Parse(f.BaseURL)
+	if err != nil {
+		return "", err
+	}
+	path := strings.Trim(u.Path, "/")
+
+	switch f.Kind {
+	case "github":
+		if u.Host == "github.com" {
+			return "https://api.github.com/repos/" + path, nil
+		}
+		// GitHub Enterprise
+		return fmt.Sprintf("%s://%s/api/v3/repos/%s", u.Scheme, u.Host, path), nil
+	case "gitlab":
+		return fmt.Sprintf("%s://%s/api/v4/projects/%s", u.Scheme, u.Host, url.PathEscape(path)), nil
+	default:
+		return "", fmt.Errorf("pull requests are not supported for %s remotes", f.Kind)
+	}
+}
+
+// forgeToken returns the API token for a forge from the environment, if any.
+// Tokens only go over HTTPS to github.com, gitlab.com and the hosts in
+// forges.token_hosts, never to whatever host a remote happens to name.
+func forgeToken(forge Forge) string {
+	u, err := url.Parse(forge.BaseURL)
+	if err != nil || u.Scheme != "https" {
+		return ""
+	}
+	host := u.Hostname()
+	allowed := func(public string) bool {
+		return strings.EqualFold(host, public) || slices.ContainsFunc(config.Forges.TokenHosts, func(h string) bool {
+			return strings.EqualFold(h, host)
+		})
+	}
+	switch forge.Kind {
+	case "gitlab":
+		if !allowed("gitlab.com") {
+			return ""
+		}
+		return os.Getenv("GITLAB_TOKEN")
+	case "github":
+		if !allowed("github.com") {
+			return ""
+		}
+		if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+			return token
+		}
+		return os.Getenv("GH_TOKEN")
+	default:
+		return ""
+	}
+}
+
+// GetMergedPullRequests returns the pull requests merged on the forge, newest first,
+// going back until since. A zero since searches the full (bounded) history.
+// GITHUB_TOKEN or GITLAB_TOKEN is used for private repositories and rate limits.
+func GetMergedPullRequests(forge Forge, since time.Time) ([]PullRequest, error) {
+	apiURL, err := forge.APIURL()
+	if err != nil {
+		return nil, err
+	}
+	return fetchMergedPullRequests(apiURL, forge.Kind, forgeToken(forge), since)
+}
+
+// githubPull is the subset of the GitHub pull request API response snap uses
+type githubPull struct {
+	Number         int        `json:"number"`
+	Title          string     `json:"title"`
+	Body           string     `json:"body"`
+	HTMLURL        string     `json:"html_url"`
+	MergeCommitSHA string     `json:"merge_commit_sha"`
+	MergedAt       *time.Time `json:"merged_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// gitlabMergeRequest is the subset of the GitLab merge request API response snap uses
+type gitlabMergeRequest struct {
+	IID             int       `json:"iid"`
+	Title           string    `json:"title"`
+	Description     string    `json:"description"`
+	WebURL          string    `json:"web_url"`
+	MergeCommitSHA  string    `json:"merge_commit_sha"`
+	SquashCommitSHA string    `json:"squash_commit_sha"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// fetchMergedPullRequests pages through the pull request list, most recently
+// updated first, until the pages are older than since
+func fetchMergedPullRequests(apiURL, kind, token string, since time.Time) ([]PullRequest, error) {
+	client := &http.Client{Timeout: 15 * time.Second}
+	prs := []PullRequest{}
+
+	for page := 1; page <= pullRequestMaxPages; page++ {
+		var pageURL string
+		if kind == "gitlab" {
+			pageURL = fmt.Sprintf("%s/merge_requests?state=merged&order_by=updated_at&sort=desc&per_page=%d&page=%d",
+				apiURL, pullRequestPageSize, page)
+			if !since.IsZero() {
+				pageURL += "&updated_after=" + url.QueryEscape(since.UTC().Format(time.RFC3339))
+			}
+		} else {
+			pageURL = fmt.Sprintf("%s/pulls?state=closed&sort=updated&direction=desc&per_page=%d&page=%d",
+				apiURL, pullRequestPageSize, page)
+		}
+
+		req, err := http.NewRequest("GET", pageURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			if kind == "gitlab" {
+				req.Header.Set("PRIVATE-TOKEN", token)
+			} else {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		if kind != "gitlab" {
+			req.Header.Set("Accept", "application/vnd.github+json")
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		pagePRs, count, err := decodePullRequests(resp, kind)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		prs = append(prs, pagePRs...)
+
+		if count < pullRequestPageSize {
+			break
+		}
+		if !since.IsZero() && len(pagePRs) > 0 && pagePRs[len(pagePRs)-1].UpdatedAt.Before(since) {
+			break
+		}
+	}
+
+	return prs, nil
+}
+
+// decodePullRequests decodes one page of the API response. count is the number
+// of items on the page, including pull requests that were closed without merging.
+func decodePullRequests(resp *http.Response, kind string) (prs []PullRequest, count int, err error) {
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound {
+			return nil, 0, fmt.Errorf("%s API error: %s (set %s for private repositories)", kind, resp.Status, tokenVariable(kind))
+		}
+		return nil, 0, fmt.Errorf("%s API error: %s", kind, resp.Status)
+	}
+
+	if kind == "gitlab" {
+		var mrs []gitlabMergeRequest
+		if err := json.NewDecoder(resp.Body).Decode(&mrs); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse response: %w", err)
+		}
+		for _, mr := range mrs {
+			// A merge commit wraps the squash commit, so prefer it when both exist
+			commit := mr.MergeCommitSHA
+			if commit == "" {
+				commit = mr.SquashCommitSHA
+			}
+			prs = append(prs, PullRequest{
+				Label:       fmt.Sprintf("!%d", mr.IID),
+				Title:       mr.Title,
+				Body:        mr.Description,
+				URL:         mr.WebURL,
+				MergeCommit: commit,
+				UpdatedAt:   mr.UpdatedAt,
+			})
+		}
+		return prs, len(mrs), nil
+	}
+
+	var pulls []githubPull
+	if err := json.NewDecoder(resp.Body).Decode(&pulls); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+	for _, pull := range pulls {
+		if pull.MergedAt == nil {
+			continue // Closed without merging
+		}
+		prs = append(prs, PullRequest{
+			Label:       fmt.Sprintf("#%d", pull.Number),
+			Title:       pull.Title,
+			Body:        pull.Body,
+			URL:         pull.HTMLURL,
+			MergeCommit: pull.MergeCommitSHA,
+			UpdatedAt:   pull.UpdatedAt,
+		})
+	}
+	return prs, len(pulls), nil
+}
+
+// tokenVariable names the environment variable holding the API token for a forge
+func tokenVariable(kind string) string {
+	if kind == "gitlab" {
+		return "GITLAB_TOKEN"
+	}
+	return "GITHUB_TOKEN"
+}
+
+// GetReleasePullRequests returns the pull requests merged in from..to and the
+// commits in that range that didn't come from any of them. An empty from covers
+// the whole history of to.
+func GetReleasePullRequests(forge Forge, from, to string) ([]PullRequest, []CommitWithStats, error) {
+	hashes, err := ListRangeHashes(from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var since time.Time
+	if from != "" {
+		since, err = GetCommitTime(from)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Allow for clock skew between the forge and the committer
+		since = since.Add(-24 * time.Hour)
+	}
+
+	all, err := GetMergedPullRequests(forge, since)
+	if err != nil {
+		return nil, nil, err
+	}
+	prs := releasePullRequests(all, hashes)
+
+	covered := map[string]bool{}
+	for _, pr := range prs {
+		merged, err := GetMergedHashes(pr.MergeCommit)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, hash := range merged {
+			covered[hash] = true
+		}
+	}
+
+	commits, err := GetCommitsBetweenTags(from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	direct := []CommitWithStats{}
+	for _, commit := range commits {
+		if !covered[commit.Hash] {
+			direct = append(direct, commit)
+		}
+	}
+
+	return prs, direct, nil
+}
+
+// releasePullRequests keeps the pull requests whose merge commit is one of hashes,
+// ordered like hashes (newest first, as git log lists commits)
+func releasePullRequests(prs []PullRequest, hashes []string) []PullRequest {
+	position := make(map[string]int, len(hashes))
+	for i, hash := range hashes {
+		position[hash] = i
+	}
+
+	result := []PullRequest{}
+	seen := map[string]bool{}
+	for _, pr := range prs {
+		if _, ok := position[pr.MergeCommit]; !ok || seen[pr.MergeCommit] {
+			continue
+		}
+		seen[pr.MergeCommit] = true
+		result = append(result, pr)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return position[result[i].MergeCommit] < position[result[j].MergeCommit]
+	})
+	return result
+}
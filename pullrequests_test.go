@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestForgeAPIURL(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		expected string
+		wantErr  bool
+	}{
+		{baseURL: "https://github.com/owner/repo", expected: "https://api.github.com/repos/owner/repo"},
+		{baseURL: "https://git.example.com/owner/repo", expected: "https://git.example.com/api/v3/repos/owner/repo"},
+		{baseURL: "https://gitlab.com/group/sub/repo", expected: "https://gitlab.com/api/v4/projects/group%2Fsub%2Frepo"},
+		{baseURL: "https://bitbucket.org/owner/repo", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.baseURL, func(t *testing.T) {
+			result, err := newForge(tt.baseURL).APIURL()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("APIURL failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("APIURL() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestForgeToken(t *testing.T) {
+	defer applyConfig(config)
+	t.Setenv("GITHUB_TOKEN", "ghp_secret")
+	t.Setenv("GITLAB_TOKEN", "glpat_secret")
+
+	cfg := defaultConfig()
+	cfg.Forges.TokenHosts = []string{"github.corp.com"}
+	applyConfig(cfg)
+
+	tests := []struct {
+		forge Forge
+		want  string
+	}{
+		{Forge{Kind: "github", BaseURL: "https://github.com/o/r"}, "ghp_secret"},
+		{Forge{Kind: "gitlab", BaseURL: "https://gitlab.com/g/r"}, "glpat_secret"},
+		{Forge{Kind: "github", BaseURL: "https://github.corp.com/o/r"}, "ghp_secret"},
+		{Forge{Kind: "github", BaseURL: "https://git.example.org/o/r"}, ""},
+		{Forge{Kind: "gitlab", BaseURL: "https://gitlab.example.org/g/r"}, ""},
+		{Forge{Kind: "github", BaseURL: "http://github.com/o/r"}, ""},
+		{Forge{Kind: "github", BaseURL: "http://github.corp.com/o/r"}, ""},
+		{Forge{Kind: "bitbucket", BaseURL: "https://bitbucket.org/o/r"}, ""},
+	}
+	for _, tt := range tests {
+		if got := forgeToken(tt.forge); got != tt.want {
+			t.Errorf("forgeToken(%s) = %q, want %q", tt.forge.BaseURL, got, tt.want)
+		}
+	}
+}
+
+func TestGetMergedPullRequestsUnknownHost(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "ghp_secret")
+	t.Setenv("GH_TOKEN", "ghp_secret")
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("Expected no token for an unknown host, got %q", auth)
+		}
+		fmt.Fprint(w, "[]")
+	}))
+	defer server.Close()
+
+	// A remote like http://127.0.0.1:8765/acme/app.git is taken for GitHub Enterprise
+	if _, err := GetMergedPullRequests(newForge(server.URL+"/acme/app"), time.Time{}); err != nil {
+		t.Fatalf("GetMergedPullRequests failed: %v", err)
+	}
+	if requests == 0 {
+		t.Error("Expected the pull request API to be queried")
+	}
+}
+
+func TestFetchMergedPullRequestsGitHub(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages = append(pages, r.URL.Query().Get("page"))
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Expected token header, got %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("page") != "1" {
+			fmt.Fprint(w, "[]")
+			return
+		}
+		fmt.Fprint(w, `[
+			{"number": 12, "title": "feat: add notes", "html_url": "https://github.com/o/r/pull/12",
+			 "merge_commit_sha": "aaa", "merged_at": "2026-01-02T00:00:00Z", "updated_at": "2026-01-02T00:00:00Z"},
+			{"number": 11, "title": "wip", "merge_commit_sha": "bbb", "merged_at": null,
+			 "updated_at": "2026-01-01T00:00:00Z"}
+		]`)
+	}))
+	defer server.Close()
+
+	prs, err := fetchMergedPullRequests(server.URL, "github", "secret", time.Time{})
+	if err != nil {
+		t.Fatalf("fetchMergedPullRequests failed: %v", err)
+	}
+	if len(pages) != 1 {
+		t.Errorf("Expected a single page request for a short page, got %v", pages)
+	}
+	if len(prs) != 1 {
+		t.Fatalf("Expected 1 merged pull request, got %d", len(prs))
+	}
+	if prs[0].Label != "#12" || prs[0].MergeCommit != "aaa" || prs[0].URL != "https://github.com/o/r/pull/12" {
+		t.Errorf("Unexpected pull request %+v", prs[0])
+	}
+}
+
+func TestFetchMergedPullRequestsGitLab(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			t.Errorf("Expected token header, got %q", r.Header.Get("PRIVATE-TOKEN"))
+		}
+		if !strings.Contains(r.URL.RawQuery, "updated_after=") {
+			t.Errorf("Expected updated_after in query, got %q", r.URL.RawQuery)
+		}
+		fmt.Fprint(w, `[
+			{"iid": 3, "title": "fix: squash", "web_url": "https://gitlab.com/g/r/-/merge_requests/3",
+			 "merge_commit_sha": null, "squash_commit_sha": "ccc", "updated_at": "2026-01-02T00:00:00Z"}
+		]`)
+	}))
+	defer server.Close()
+
+	prs, err := fetchMergedPullRequests(server.URL, "gitlab", "secret", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("fetchMergedPullRequests failed: %v", err)
+	}
+	if len(prs) != 1 || prs[0].Label != "!3" || prs[0].MergeCommit != "ccc" {
+		t.Errorf("Unexpected pull requests %+v", prs)
+	}
+}
+
+func TestFetchMergedPullRequestsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := fetchMergedPullRequests(server.URL, "github", "", time.Time{})
+	if err == nil || !strings.Contains(err.Error(), "GITHUB_TOKEN") {
+		t.Errorf("Expected error mentioning GITHUB_TOKEN, got %v", err)
+	}
+}
+
+func TestReleasePullRequests(t *testing.T) {
+	prs := []PullRequest{
+		{Label: "#3", MergeCommit: "c1"},
+		{Label: "#2", MergeCommit: "outside"},
+		{Label: "#1", MergeCommit: "c3"},
+		{Label: "#0"},
+	}
+
+	result := releasePullRequests(prs, []string{"c3", "c2", "c1"})
+	var labels []string
+	for _, pr := range result {
+		labels = append(labels, pr.Label)
+	}
+	if strings.Join(labels, " ") != "#1 #3" {
+		t.Errorf("Expected [#1 #3] in history order, got %v", labels)
+	}
+}
+
+func TestGetMergedHashes(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	exec.Command("git", "checkout", "-b", "feature").Run()
+	exec.Command("git", "commit", "--allow-empty", "-m", "feat: one").Run()
+	exec.Command("git", "commit", "--allow-empty", "-m", "feat: two").Run()
+	exec.Command("git", "checkout", "-").Run()
+	exec.Command("git", "merge", "--no-ff", "-m", "Merge pull request #1", "feature").Run()
+
+	merge, _ := exec.Command("git", "rev-parse", "HEAD").Output()
+	hashes, err := GetMergedHashes(strings.TrimSpace(string(merge)))
+	if err != nil {
+		t.Fatalf("GetMergedHashes failed: %v", err)
+	}
+	if len(hashes) != 3 {
+		t.Errorf("Expected the merge and its 2 commits, got %v", hashes)
+	}
+
+	parent, _ := exec.Command("git", "rev-parse", "HEAD~1").Output()
+	hashes, err = GetMergedHashes(strings.TrimSpace(string(parent)))
+	if err != nil {
+		t.Fatalf("GetMergedHashes failed: %v", err)
+	}
+	if len(hashes) != 1 {
+		t.Errorf("Expected only the commit itself, got %v", hashes)
+	}
+}
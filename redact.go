@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// redactedText replaces secrets in text sent to the AI provider
+const redactedText = "[REDACTED]"
+
+// ruleRedactedText replaces matches of ai.redact_rules
+const ruleRedactedText = "«redacted»"
+
+// secretPattern finds one kind of secret. Group 1, when present, is context
+// kept in front of the secret, e.g. the "password = " of an assignment.
+type secretPattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// secretPatterns are checked in order; earlier, more specific patterns win
+var secretPatterns = []secretPattern{
+	{"AWS access key", regexp.MustCompile(`()\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`()\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{"Slack token", regexp.MustCompile(`()\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"API key", regexp.MustCompile(`()\b(?:sk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}|AIza[0-9A-Za-z_-]{35})`)},
+	{"JWT", regexp.MustCompile(`()\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"secret value", regexp.MustCompile(`(?i)(\b[\w.-]*(?:password|passwd|secret|token|api_?key|access_?key)[\w.-]*["']?\s*[:=]\s*["']?)[^\s"',;\[\]()]{8,}`)},
+}
+
+// privateKeyBegin and privateKeyEnd delimit PEM private keys
+var (
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END [A-Z ]*PRIVATE KEY( BLOCK)?-----`)
+)
+
+// secretKind names the secret on a line, or returns "" when there is none.
+// Unlike RedactSecrets it skips the "secret value" heuristic, which also
+// matches code like token = getToken() and would block commits for nothing.
+func secretKind(line string) string {
+	if privateKeyBegin.MatchString(line) {
+		return "private key"
+	}
+	for _, pattern := range secretPatterns {
+		if pattern.kind != "secret value" && pattern.re.MatchString(line) {
+			return pattern.kind
+		}
+	}
+	return ""
+}
+
+// envTemplates are the .env.<suffix> files meant to be committed
+var envTemplates = []string{"example", "sample", "template", "dist"}
+
+// isEnvFile reports whether a path is a dotenv file with real values
+func isEnvFile(path string) bool {
+	base := filepath.Base(path)
+	if base == ".env" {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(base, ".env.")
+	return ok && !slices.Contains(envTemplates, suffix)
+}
+
+// Redaction counts the secrets of one kind that were masked
+type Redaction struct {
+	Kind  string
+	Count int
+}
+
+// RedactSecrets masks private key blocks and things that look like keys,
+// tokens and passwords. It works line by line, so diff prefixes survive.
+func RedactSecrets(text string) (string, []Redaction) {
+	counts := map[string]int{}
+	var kinds []string
+	found := func(kind string, n int) {
+		if counts[kind] == 0 {
+			kinds = append(kinds, kind)
+		}
+		counts[kind] += n
+	}
+
+	lines := strings.Split(text, "\n")
+	inKey := false
+	for i, line := range lines {
+		// Keep a diff line's +/-/space prefix in front of the masked key
+		prefix := ""
+		if line != "" && strings.ContainsRune("+- ", rune(line[0])) {
+			prefix = line[:1]
+		}
+		switch {
+		case privateKeyBegin.MatchString(line):
+			found("private key", 1)
+			inKey = !privateKeyEnd.MatchString(line)
+			lines[i] = prefix + redactedText
+			continue
+		case inKey:
+			inKey = !privateKeyEnd.MatchString(line)
+			lines[i] = prefix + redactedText
+			continue
+		}
+
+		for _, pattern := range secretPatterns {
+			if matches := pattern.re.FindAllStringIndex(line, -1); len(matches) > 0 {
+				found(pattern.kind, len(matches))
+				line = pattern.re.ReplaceAllString(line, "${1}"+redactedText)
+			}
+		}
+		lines[i] = line
+	}
+
+	var redactions []Redaction
+	for _, kind := range kinds {
+		redactions = append(redactions, Redaction{Kind: kind, Count: counts[kind]})
+	}
+	return strings.Join(lines, "\n"), redactions
+}
+
+// redactRules compiles ai.redact_rules, sorted by name
+func (c AIConfig) redactRules() ([]secretPattern, error) {
+	var rules []secretPattern
+	for name, expr := range c.RedactRules {
+		if expr == "" {
+			return nil, fmt.Errorf("ai.redact_rules.\"%s\" cannot be empty", name)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("ai.redact_rules.\"%s\" is not a valid regular expression: %v", name, err)
+		}
+		rules = append(rules, secretPattern{kind: name, re: re})
+	}
+	slices.SortFunc(rules, func(a, b secretPattern) int { return strings.Compare(a.kind, b.kind) })
+	return rules, nil
+}
+
+// ApplyRedactRules replaces what the rules match with «redacted», line by
+// line. A diff line's +/-/space prefix is never part of a match.
+func ApplyRedactRules(text string, rules []secretPattern) (string, []Redaction) {
+	if len(rules) == 0 {
+		return text, nil
+	}
+	counts := make([]int, len(rules))
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		prefix := ""
+		if line != "" && strings.ContainsRune("+- ", rune(line[0])) {
+			prefix, line = line[:1], line[1:]
+		}
+		for j, rule := range rules {
+			if matches := rule.re.FindAllStringIndex(line, -1); len(matches) > 0 {
+				counts[j] += len(matches)
+				line = rule.re.ReplaceAllLiteralString(line, ruleRedactedText)
+			}
+		}
+		lines[i] = prefix + line
+	}
+
+	var redactions []Redaction
+	for j, rule := range rules {
+		if counts[j] > 0 {
+			redactions = append(redactions, Redaction{Kind: rule.kind, Count: counts[j]})
+		}
+	}
+	return strings.Join(lines, "\n"), redactions
+}
+
+// redactForAI masks the ai.redact_rules matches and, with ai.redact, the
+// secrets in text bound for the AI provider
+func redactForAI(text string) (string, []Redaction) {
+	rules, _ := config.AI.redactRules() // Checked when the config was loaded
+	text, redactions := ApplyRedactRules(text, rules)
+	if config.AI.Redact {
+		var secrets []Redaction
+		text, secrets = RedactSecrets(text)
+		redactions = append(redactions, secrets...)
+	}
+	return text, redactions
+}
+
+// diffRedactions returns what diffForAI masks in a diff
+func diffRedactions(diff string) []Redaction {
+	_, redactions := redactForAI(leaveOutFiles(diff))
+	return redactions
+}
+
+// checkRedactRules refuses text that ai.redact_rules would mask but which
+// can't be sent masked, like a conflict hunk whose answer is written back
+func checkRedactRules(text string) error {
+	rules, _ := config.AI.redactRules()
+	if _, redactions := ApplyRedactRules(text, rules); len(redactions) > 0 {
+		return fmt.Errorf("it matches ai.redact_rules (%s), so it isn't sent to the AI", redactionReport(redactions))
+	}
+	return nil
+}
+
+// redactionReport describes what was masked, e.g. "AWS access key, 2 × secret value"
+func redactionReport(redactions []Redaction) string {
+	var parts []string
+	for _, r := range redactions {
+		if r.Count == 1 {
+			parts = append(parts, r.Kind)
+		} else {
+			parts = append(parts, fmt.Sprintf("%d × %s", r.Count, r.Kind))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
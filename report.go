@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Output formats for snap tags diff
+const (
+	formatText     = "text"
+	formatJSON     = "json"
+	formatMarkdown = "markdown"
+)
+
+// reportFormats lists the valid values for --format
+var reportFormats = []string{formatText, formatJSON, formatMarkdown}
+
+// ReleaseCommit is one commit of a release report
+type ReleaseCommit struct {
+	Hash         string    `json:"hash"`
+	ShortHash    string    `json:"short_hash"`
+	Subject      string    `json:"subject"`
+	Author       string    `json:"author"`
+	AuthorEmail  string    `json:"author_email"`
+	Date         time.Time `json:"date"`
+	Additions    int       `json:"additions"`
+	Deletions    int       `json:"deletions"`
+	FilesChanged int       `json:"files_changed"`
+}
+
+// ReleaseReport describes the commits since a tag, for release dashboards and
+// announcement generators
+type ReleaseReport struct {
+	PreviousTag  string          `json:"previous_tag"` // Empty when there are no tags yet
+	Head         string          `json:"head"`
+	Commits      []ReleaseCommit `json:"commits"`
+	Additions    int             `json:"additions"` // Over the whole range, not summed per commit
+	Deletions    int             `json:"deletions"`
+	FilesChanged int             `json:"files_changed"`
+}
+
+// GetReleaseReport collects the commits since the most recent tag with their
+// stats
+func GetReleaseReport() (ReleaseReport, error) {
+	commits, prevTag, err := GetUnreleasedCommits()
+	if err != nil {
+		return ReleaseReport{}, fmt.Errorf("failed to load commits: %w", err)
+	}
+	head, err := ResolveCommit("HEAD")
+	if err != nil {
+		return ReleaseReport{}, err
+	}
+
+	report := ReleaseReport{PreviousTag: prevTag, Head: head, Commits: []ReleaseCommit{}}
+	for _, c := range commits {
+		report.Commits = append(report.Commits, ReleaseCommit{
+			Hash:         c.Hash,
+			ShortHash:    c.ShortHash,
+			Subject:      c.Message,
+			Author:       c.Author,
+			AuthorEmail:  c.AuthorEmail,
+			Date:         c.Date,
+			Additions:    c.Additions,
+			Deletions:    c.Deletions,
+			FilesChanged: c.FilesChanged,
+		})
+	}
+	if len(commits) > 0 {
+		report.Additions, report.Deletions, report.FilesChanged, _ = GetTagDiffStats(prevTag)
+	}
+	return report, nil
+}
+
+// JSON renders the report as indented JSON
+func (r ReleaseReport) JSON() (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// Markdown renders the report as a heading, a summary line and a commit table
+func (r ReleaseReport) Markdown() string {
+	var b strings.Builder
+	if r.PreviousTag != "" {
+		fmt.Fprintf(&b, "## Changes since %s\n\n", r.PreviousTag)
+	} else {
+		b.WriteString("## Changes\n\n")
+	}
+
+	if len(r.Commits) == 0 {
+		b.WriteString("No commits since the last tag.\n")
+		return b.String()
+	}
+	commits, files := "commits", "files"
+	if len(r.Commits) == 1 {
+		commits = "commit"
+	}
+	if r.FilesChanged == 1 {
+		files = "file"
+	}
+	fmt.Fprintf(&b, "%d %s, %d %s changed (+%d -%d)\n\n", len(r.Commits), commits, r.FilesChanged, files, r.Additions, r.Deletions)
+
+	b.WriteString("| Commit | Subject | Author | Date | Changes |\n")
+	b.WriteString("|--------|---------|--------|------|---------|\n")
+	for _, c := range r.Commits {
+		date := ""
+		if !c.Date.IsZero() {
+			date = c.Date.Format("2006-01-02")
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | +%d -%d |\n",
+			c.ShortHash, markdownCell(c.Subject), markdownCell(c.Author), date, c.Additions, c.Deletions)
+	}
+	return b.String()
+}
+
+// stackMarkdown renders recent commits as a markdown table
+func stackMarkdown(commits []CommitInfo) string {
+	var b strings.Builder
+	b.WriteString("## Recent commits\n\n")
+	if len(commits) == 0 {
+		b.WriteString("No commits yet.\n")
+		return b.String()
+	}
+	b.WriteString("| Commit | Subject | Author | When |\n")
+	b.WriteString("|--------|---------|--------|------|\n")
+	for _, c := range commits {
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", c.ShortHash, markdownCell(c.Message), markdownCell(c.Author), c.RelativeTime)
+	}
+	return b.String()
+}
+
+// statusMarkdown renders the lines of snap status as a markdown list
+func statusMarkdown(lines []string) string {
+	var b strings.Builder
+	b.WriteString("## Repository status\n\n")
+	for _, line := range lines {
+		b.WriteString("- " + line + "\n")
+	}
+	return b.String()
+}
+
+// markdownCell escapes text for a markdown table cell
+func markdownCell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestGetReleaseReport(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	exec.Command("git", "tag", "-a", "v1.0.0", "-m", "v1.0.0").Run()
+	os.WriteFile("a.txt", []byte("one\ntwo\n"), 0644)
+	exec.Command("git", "add", "a.txt").Run()
+	exec.Command("git", "commit", "-m", "feat: pipes | in subjects").Run()
+
+	report, err := GetReleaseReport()
+	if err != nil {
+		t.Fatalf("GetReleaseReport failed: %v", err)
+	}
+	if report.PreviousTag != "v1.0.0" || len(report.Commits) != 1 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	commit := report.Commits[0]
+	if commit.Subject != "feat: pipes | in subjects" || commit.Author != "Test User" || commit.AuthorEmail != "test@example.com" {
+		t.Errorf("Unexpected commit: %+v", commit)
+	}
+	if commit.Date.IsZero() || commit.Additions != 2 || report.FilesChanged != 1 {
+		t.Errorf("Expected a date and stats, got %+v", report)
+	}
+
+	output, err := report.JSON()
+	if err != nil {
+		t.Fatalf("JSON failed: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, output)
+	}
+	if decoded["previous_tag"] != "v1.0.0" || decoded["head"] != report.Head {
+		t.Errorf("Unexpected JSON:\n%s", output)
+	}
+
+	markdown := report.Markdown()
+	if !strings.HasPrefix(markdown, "## Changes since v1.0.0\n\n1 commit, 1 file changed (+2 -0)\n") {
+		t.Errorf("Unexpected markdown summary:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "| `"+commit.ShortHash+"` | feat: pipes \\| in subjects | Test User | ") {
+		t.Errorf("Expected an escaped table row, got:\n%s", markdown)
+	}
+}
+
+func TestReleaseReportEmpty(t *testing.T) {
+	report := ReleaseReport{Commits: []ReleaseCommit{}}
+	if got := report.Markdown(); got != "## Changes\n\nNo commits since the last tag.\n" {
+		t.Errorf("Unexpected markdown %q", got)
+	}
+	if output, _ := report.JSON(); !strings.Contains(output, `"commits": []`) {
+		t.Errorf("Expected an empty commit list, got %s", output)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxRecentRepos caps the recently used repositories that are remembered
+const maxRecentRepos = 20
+
+// recentReposPath returns the file listing recently used repositories, honoring
+// $XDG_STATE_HOME
+func recentReposPath() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "snap", "repos"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "snap", "repos"), nil
+}
+
+// LoadRecentRepos returns the recently used repositories, most recent first.
+// Repositories that no longer exist are left out.
+func LoadRecentRepos() ([]string, error) {
+	path, err := recentReposPath()
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		if info, err := os.Stat(line); err == nil && info.IsDir() {
+			repos = append(repos, line)
+		}
+	}
+	return repos, nil
+}
+
+// RecordRecentRepo moves a repository root to the top of the recent list
+func RecordRecentRepo(root string) error {
+	repos, err := LoadRecentRepos()
+	if err != nil {
+		return err
+	}
+	repos = slices.DeleteFunc(repos, func(repo string) bool { return repo == root })
+	repos = append([]string{root}, repos...)
+	if len(repos) > maxRecentRepos {
+		repos = repos[:maxRecentRepos]
+	}
+
+	path, err := recentReposPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(repos, "\n")+"\n"), 0644)
+}
+
+// recordCurrentRepo remembers the repository snap runs in, if any. Failures
+// are ignored; the list is a convenience.
+func recordCurrentRepo() {
+	output, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return
+	}
+	RecordRecentRepo(strings.TrimSpace(string(output)))
+}
+
+// MatchRecentRepo picks the repository for a query: a 1-based position in the
+// list, or the most recent repository whose directory name contains the query
+func MatchRecentRepo(repos []string, query string) (string, error) {
+	var n int
+	if _, err := fmt.Sscanf(query, "%d", &n); err == nil && fmt.Sprint(n) == query {
+		if n < 1 || n > len(repos) {
+			return "", fmt.Errorf("no repository #%d (%d remembered)", n, len(repos))
+		}
+		return repos[n-1], nil
+	}
+
+	for _, repo := range repos {
+		if strings.Contains(strings.ToLower(filepath.Base(repo)), strings.ToLower(query)) {
+			return repo, nil
+		}
+	}
+	return "", fmt.Errorf("no recent repository matches '%s'", query)
+}
+
+// displayPath shortens paths in the home directory to ~/...
+func displayPath(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if rel, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return filepath.Join("~", rel)
+	}
+	return path
+}
+
+type reposModel struct {
+	repos    []string
+	cursor   int
+	selected string // Repository picked with enter or s
+	subshell bool   // Open a shell in the selected repository
+}
+
+func initialReposModel(repos []string) reposModel {
+	return reposModel{repos: repos}
+}
+
+func (m reposModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m reposModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.repos)-1 {
+				m.cursor++
+			}
+		case "enter":
+			m.selected = m.repos[m.cursor]
+			return m, tea.Quit
+		case "s":
+			m.selected = m.repos[m.cursor]
+			m.subshell = true
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m reposModel) View() string {
+	if m.selected != "" {
+		return ""
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorPrimary).
+		PaddingLeft(2)
+	nameStyle := lipgloss.NewStyle().Foreground(colorText)
+	cursorStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	dimStyle := lipgloss.NewStyle().Foreground(colorMuted)
+
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Recent repositories"))
+	s.WriteString("\n\n")
+	for i, repo := range m.repos {
+		cursor := "  "
+		style := nameStyle
+		if i == m.cursor {
+			cursor = cursorStyle.Render("→ ")
+			style = cursorStyle
+		}
+		s.WriteString(fmt.Sprintf("%s%2d. %s %s\n", cursor, i+1, style.Render(filepath.Base(repo)), dimStyle.Render(displayPath(repo))))
+	}
+	s.WriteString("\n")
+	s.WriteString(dimStyle.Render("  enter: print path  s: open a shell there  q: quit"))
+	s.WriteString("\n")
+	return s.String()
+}
+
+// openSubshell starts $SHELL in dir and waits for it to exit
+func openSubshell(dir string) error {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	fmt.Fprintf(os.Stderr, "Opening %s in %s (exit to return)\n", filepath.Base(shell), displayPath(dir))
+
+	cmd := exec.Command(shell)
+	cmd.Dir = dir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRecordRecentRepo(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	base := t.TempDir()
+	var dirs []string
+	for _, name := range []string{"api", "web", "gone"} {
+		dir := filepath.Join(base, name)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		dirs = append(dirs, dir)
+	}
+
+	for _, dir := range append(dirs, dirs[0]) {
+		if err := RecordRecentRepo(dir); err != nil {
+			t.Fatalf("RecordRecentRepo failed: %v", err)
+		}
+	}
+	os.Remove(dirs[2])
+
+	repos, err := LoadRecentRepos()
+	if err != nil {
+		t.Fatalf("LoadRecentRepos failed: %v", err)
+	}
+	// Most recent first, without duplicates or deleted repositories
+	if want := []string{dirs[0], dirs[1]}; !slices.Equal(repos, want) {
+		t.Errorf("Expected %v, got %v", want, repos)
+	}
+}
+
+func TestMatchRecentRepo(t *testing.T) {
+	repos := []string{"/src/snap", "/src/api-server", "/work/api"}
+
+	tests := map[string]string{
+		"2":    "/src/api-server",
+		"api":  "/src/api-server",
+		"SNAP": "/src/snap",
+	}
+	for query, want := range tests {
+		got, err := MatchRecentRepo(repos, query)
+		if err != nil || got != want {
+			t.Errorf("MatchRecentRepo(%q) = %q, %v; want %q", query, got, err, want)
+		}
+	}
+
+	for _, query := range []string{"4", "0", "missing"} {
+		if _, err := MatchRecentRepo(repos, query); err == nil {
+			t.Errorf("Expected an error for %q", query)
+		}
+	}
+}
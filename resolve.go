@@ -0,0 +1,446 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type resolveState int
+
+const (
+	resolveStateLoading resolveState = iota
+	resolveStateSuggesting
+	resolveStateReviewing
+	resolveStateEditing
+	resolveStateWriting
+	resolveStateDone
+	resolveStateError
+)
+
+// resolveSectionLines caps how many lines of each side of a hunk are shown
+const resolveSectionLines = 12
+
+type resolveModel struct {
+	state       resolveState
+	spinner     spinner.Model
+	textarea    textarea.Model
+	root        string // Repository root; conflict paths are relative to it
+	rebasing    bool   // Conflicts come from a rebase rather than a merge
+	files       []ConflictFile
+	fileIdx     int
+	hunkIdx     int
+	resolutions map[int]string // Accepted or edited hunks of the current file
+	suggestion  string
+	suggestErr  error
+	seed        int
+	resolved    int      // Hunks replaced by a resolution
+	rejected    int      // Hunks left with their conflict markers
+	staged      []string // Files without conflicts left, staged as resolved
+	err         error
+	width       int
+	height      int
+}
+
+type loadConflictsMsg struct {
+	root     string
+	files    []ConflictFile
+	rebasing bool
+	err      error
+}
+
+type suggestResolutionMsg struct {
+	resolution string
+	err        error
+}
+
+type writeConflictFileMsg struct {
+	staged bool
+	err    error
+}
+
+func initialResolveModel() resolveModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
+
+	ta := textarea.New()
+	ta.ShowLineNumbers = false
+	ta.MaxHeight = 1000
+	ta.SetWidth(76)
+	ta.SetHeight(14)
+
+	return resolveModel{
+		state:       resolveStateLoading,
+		spinner:     s,
+		textarea:    ta,
+		resolutions: map[int]string{},
+		seed:        config.Save.Seed,
+		width:       80,
+		height:      24,
+	}
+}
+
+func (m resolveModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, loadConflictsCmd)
+}
+
+func (m resolveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.textarea.SetWidth(msg.Width - 4)
+		m.textarea.SetHeight(max(msg.Height-10, 3))
+		return m, nil
+
+	case tea.KeyMsg:
+		switch m.state {
+		case resolveStateReviewing:
+			switch msg.String() {
+			case "ctrl+c", "q", "esc":
+				m.state = resolveStateDone
+				return m, tea.Quit
+			case "a", "enter":
+				if m.suggestErr != nil {
+					return m, nil
+				}
+				m.resolutions[m.hunkIdx] = m.suggestion
+				m.resolved++
+				return m.nextHunk()
+			case "e":
+				value := m.suggestion
+				if m.suggestErr != nil {
+					value = m.currentHunk().Ours
+				}
+				m.textarea.SetValue(strings.TrimSuffix(value, "\n"))
+				m.state = resolveStateEditing
+				return m, m.textarea.Focus()
+			case "r":
+				m.rejected++
+				return m.nextHunk()
+			case "g":
+				m.seed++
+				m.state = resolveStateSuggesting
+				return m, suggestResolutionCmd(m.currentFile().Path, m.currentHunk(), m.seed)
+			}
+
+		case resolveStateEditing:
+			switch msg.String() {
+			case "ctrl+c":
+				m.state = resolveStateDone
+				return m, tea.Quit
+			case "esc":
+				m.textarea.Blur()
+				m.state = resolveStateReviewing
+				return m, nil
+			case "ctrl+s":
+				resolution := m.textarea.Value()
+				if resolution != "" {
+					resolution += "\n"
+				}
+				m.textarea.Blur()
+				m.resolutions[m.hunkIdx] = resolution
+				m.resolved++
+				return m.nextHunk()
+			}
+
+			var cmd tea.Cmd
+			m.textarea, cmd = m.textarea.Update(msg)
+			return m, cmd
+
+		case resolveStateSuggesting:
+			if msg.String() == "ctrl+c" || msg.String() == "q" {
+				m.state = resolveStateDone
+				return m, tea.Quit
+			}
+
+		default:
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+		}
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case loadConflictsMsg:
+		if msg.err != nil {
+			m.state = resolveStateError
+			m.err = msg.err
+			return m, tea.Quit
+		}
+		m.root = msg.root
+		m.files = msg.files
+		m.rebasing = msg.rebasing
+		if len(m.files) == 0 {
+			m.state = resolveStateDone
+			return m, tea.Quit
+		}
+		m.state = resolveStateSuggesting
+		return m, suggestResolutionCmd(m.currentFile().Path, m.currentHunk(), m.seed)
+
+	case suggestResolutionMsg:
+		m.suggestion = msg.resolution
+		m.suggestErr = msg.err
+		m.state = resolveStateReviewing
+		return m, nil
+
+	case writeConflictFileMsg:
+		if msg.err != nil {
+			m.state = resolveStateError
+			m.err = msg.err
+			return m, tea.Quit
+		}
+		if msg.staged {
+			m.staged = append(m.staged, m.currentFile().Path)
+		}
+
+		m.fileIdx++
+		m.hunkIdx = 0
+		m.resolutions = map[int]string{}
+		if m.fileIdx == len(m.files) {
+			m.state = resolveStateDone
+			return m, tea.Quit
+		}
+		m.state = resolveStateSuggesting
+		return m, suggestResolutionCmd(m.currentFile().Path, m.currentHunk(), m.seed)
+	}
+
+	return m, nil
+}
+
+func (m resolveModel) currentFile() ConflictFile {
+	return m.files[m.fileIdx]
+}
+
+func (m resolveModel) currentHunk() ConflictHunk {
+	return m.files[m.fileIdx].Hunks[m.hunkIdx]
+}
+
+// nextHunk moves to the next hunk of the current file, or writes the file
+// once every hunk has been decided
+func (m resolveModel) nextHunk() (tea.Model, tea.Cmd) {
+	m.suggestion = ""
+	m.suggestErr = nil
+	m.hunkIdx++
+	if m.hunkIdx < len(m.currentFile().Hunks) {
+		m.state = resolveStateSuggesting
+		return m, suggestResolutionCmd(m.currentFile().Path, m.currentHunk(), m.seed)
+	}
+
+	m.state = resolveStateWriting
+	return m, writeConflictFileCmd(m.root, m.currentFile(), m.resolutions)
+}
+
+// ExitSummary keeps the result visible after the alt screen closes
+func (m resolveModel) ExitSummary() string {
+	switch m.state {
+	case resolveStateDone:
+		if len(m.files) == 0 {
+			return ""
+		}
+		summary := fmt.Sprintf("✓ Resolved %d conflict(s)", m.resolved)
+		if m.rejected > 0 {
+			summary += fmt.Sprintf(", %d left to resolve by hand", m.rejected)
+		}
+		return summary
+	case resolveStateError:
+		return fmt.Sprintf("✗ Error: %v", m.err)
+	}
+	return ""
+}
+
+func (m resolveModel) View() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorPrimary).
+		PaddingLeft(2)
+	helpStyle := lipgloss.NewStyle().
+		Foreground(colorMuted).
+		PaddingLeft(2)
+
+	switch m.state {
+	case resolveStateLoading:
+		return fmt.Sprintf("%s Looking for conflicts...", m.spinner.View())
+
+	case resolveStateSuggesting:
+		return fmt.Sprintf("%s Asking %s to resolve %s (conflict %d/%d)...",
+			m.spinner.View(), CurrentProvider().Name(), m.currentFile().Path, m.hunkIdx+1, len(m.currentFile().Hunks))
+
+	case resolveStateReviewing:
+		hunk := m.currentHunk()
+		var s strings.Builder
+		s.WriteString(titleStyle.Render(fmt.Sprintf("Resolve %s", m.currentFile().Path)))
+		s.WriteString(helpStyle.Render(fmt.Sprintf("conflict %d/%d, file %d/%d",
+			m.hunkIdx+1, len(m.currentFile().Hunks), m.fileIdx+1, len(m.files))))
+		s.WriteString("\n\n")
+
+		s.WriteString(renderConflictSection("Ours ("+hunk.OursLabel+")", hunk.Ours, colorDanger))
+		if hunk.Base != "" {
+			s.WriteString(renderConflictSection("Base", hunk.Base, colorMuted))
+		}
+		s.WriteString(renderConflictSection("Theirs ("+hunk.TheirsLabel+")", hunk.Theirs, colorSuccess))
+
+		if m.suggestErr != nil {
+			s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(errorStyle.Render("✗ No suggestion: " + m.suggestErr.Error())))
+			s.WriteString("\n\n")
+			s.WriteString(helpStyle.Render("e: edit  r: reject (keep markers)  g: try again  q: quit"))
+		} else {
+			s.WriteString(renderConflictSection("Suggested resolution", m.suggestion, colorPrimary))
+			s.WriteString(helpStyle.Render("a: accept  e: edit  r: reject (keep markers)  g: regenerate  q: quit"))
+		}
+		return s.String()
+
+	case resolveStateEditing:
+		var s strings.Builder
+		s.WriteString(titleStyle.Render(fmt.Sprintf("Edit resolution for %s", m.currentFile().Path)))
+		s.WriteString("\n\n")
+		s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(m.textarea.View()))
+		s.WriteString("\n\n")
+		s.WriteString(helpStyle.Render("ctrl+s: use this resolution  esc: back"))
+		return s.String()
+
+	case resolveStateWriting:
+		return fmt.Sprintf("%s Writing %s...", m.spinner.View(), m.currentFile().Path)
+
+	case resolveStateDone:
+		if len(m.files) == 0 {
+			return successStyle.Render("✓ No conflicts to resolve")
+		}
+
+		var s strings.Builder
+		s.WriteString(successStyle.Render(fmt.Sprintf("✓ Resolved %d conflict(s)", m.resolved)))
+		s.WriteString("\n")
+		if len(m.staged) > 0 {
+			s.WriteString(infoStyle.Render("  Staged: " + strings.Join(m.staged, ", ")))
+			s.WriteString("\n")
+		}
+		if m.rejected > 0 {
+			s.WriteString(errorStyle.Render(fmt.Sprintf("✗ %d conflict(s) left to resolve by hand", m.rejected)))
+			s.WriteString("\n")
+		}
+		if m.fileIdx < len(m.files) {
+			s.WriteString(infoStyle.Render(fmt.Sprintf("  Stopped at %s, which was left unchanged", m.currentFile().Path)))
+			s.WriteString("\n")
+		}
+		next := "snap save"
+		if m.rebasing {
+			next = "git rebase --continue"
+		}
+		s.WriteString(infoStyle.Render("  Review the result, then run ") + highlightStyle.Render(next))
+		return s.String()
+
+	case resolveStateError:
+		return errorStyle.Render(fmt.Sprintf("✗ Error: %s", m.err))
+	}
+
+	return ""
+}
+
+// renderConflictSection shows one side of a conflict under a colored heading,
+// capped at resolveSectionLines lines
+func renderConflictSection(title, content string, color lipgloss.Color) string {
+	headingStyle := lipgloss.NewStyle().Foreground(color).Bold(true).PaddingLeft(2)
+	codeStyle := lipgloss.NewStyle().Foreground(colorText).PaddingLeft(4)
+	mutedStyle := lipgloss.NewStyle().Foreground(colorMuted).PaddingLeft(4)
+
+	var s strings.Builder
+	s.WriteString(headingStyle.Render(title))
+	s.WriteString("\n")
+
+	if content == "" {
+		s.WriteString(mutedStyle.Render("(empty)"))
+		s.WriteString("\n\n")
+		return s.String()
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i, line := range lines {
+		if i == resolveSectionLines {
+			s.WriteString(mutedStyle.Render(fmt.Sprintf("… %d more lines", len(lines)-i)))
+			s.WriteString("\n")
+			break
+		}
+		s.WriteString(codeStyle.Render(line))
+		s.WriteString("\n")
+	}
+	s.WriteString("\n")
+	return s.String()
+}
+
+func loadConflictsCmd() tea.Msg {
+	output, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return loadConflictsMsg{err: fmt.Errorf("not a git repository")}
+	}
+	root := strings.TrimSpace(string(output))
+
+	paths, err := GetConflictedFiles()
+	if err != nil {
+		return loadConflictsMsg{err: err}
+	}
+	if len(paths) == 0 {
+		return loadConflictsMsg{root: root}
+	}
+	if err := CurrentProvider().Check(); err != nil {
+		return loadConflictsMsg{err: err}
+	}
+
+	var files []ConflictFile
+	for _, path := range paths {
+		content, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			// Deleted on one side; nothing to merge line by line
+			continue
+		}
+		file, err := ParseConflicts(path, string(content))
+		if err != nil {
+			return loadConflictsMsg{err: err}
+		}
+		if len(file.Hunks) > 0 {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return loadConflictsMsg{err: fmt.Errorf("%d conflicted file(s), but none with conflict markers (deleted or binary files must be resolved by hand)", len(paths))}
+	}
+
+	rebasing, _ := CheckRebaseInProgress()
+	return loadConflictsMsg{root: root, files: files, rebasing: rebasing}
+}
+
+func suggestResolutionCmd(path string, hunk ConflictHunk, seed int) tea.Cmd {
+	return func() tea.Msg {
+		resolution, err := ResolveConflictHunk(path, hunk, seed)
+		return suggestResolutionMsg{resolution: resolution, err: err}
+	}
+}
+
+// writeConflictFileCmd writes the decided hunks back to the file and stages it
+// when no conflicts are left in it
+func writeConflictFileCmd(root string, file ConflictFile, resolutions map[int]string) tea.Cmd {
+	return func() tea.Msg {
+		path := filepath.Join(root, file.Path)
+		info, err := os.Stat(path)
+		if err != nil {
+			return writeConflictFileMsg{err: err}
+		}
+		if err := os.WriteFile(path, []byte(file.Render(resolutions)), info.Mode().Perm()); err != nil {
+			return writeConflictFileMsg{err: err}
+		}
+		if len(resolutions) < len(file.Hunks) {
+			return writeConflictFileMsg{}
+		}
+		return writeConflictFileMsg{staged: true, err: StageFile(path)}
+	}
+}
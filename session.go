@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Operation is a history rewrite snap has started. It is written to
+// .git/snap/operation before the branch changes and removed when the rewrite
+// ends, so a file left behind means snap crashed or its terminal closed.
+type Operation struct {
+	Command string    `json:"command"` // e.g. "replay" or "prepare-pr"
+	Branch  string    `json:"branch"`
+	Head    string    `json:"head"`             // HEAD before the rewrite
+	Backup  string    `json:"backup,omitempty"` // The refs/snap/backup/ ref, if one was made
+	Push    bool      `json:"push,omitempty"`   // Force-push the branch once the rewrite is done
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+}
+
+func (op Operation) String() string {
+	return fmt.Sprintf("snap %s on %s (started %s)", op.Command, op.Branch, op.Started.Format("Jan 2 15:04"))
+}
+
+// Running reports whether the snap process that started the operation is
+// still alive, e.g. in another terminal
+func (op Operation) Running() bool {
+	if op.PID <= 0 || op.PID == os.Getpid() {
+		return false
+	}
+	process, err := os.FindProcess(op.PID)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// snapGitPath returns a path below the git directory's snap folder, so every
+// worktree has its own
+func snapGitPath(name string) (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--git-path", "snap/"+name).Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository")
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// StartOperation records a rewrite of the current branch before it begins
+func StartOperation(command, backup string, push bool) error {
+	path, err := snapGitPath("operation")
+	if err != nil {
+		return err
+	}
+	branch, err := GetCurrentBranch()
+	if err != nil {
+		return err
+	}
+	head, err := ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	op := Operation{Command: command, Branch: branch, Head: head, Backup: backup, Push: push, PID: os.Getpid(), Started: time.Now()}
+	content, err := json.MarshalIndent(op, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// EndOperation forgets the recorded operation once it finished or stopped
+// where the user sees it, e.g. at a conflict
+func EndOperation() {
+	if path, err := snapGitPath("operation"); err == nil {
+		os.Remove(path)
+	}
+}
+
+// LoadOperation returns the recorded operation, or nil when there is none
+func LoadOperation() (*Operation, error) {
+	path, err := snapGitPath("operation")
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var op Operation
+	if err := json.Unmarshal(content, &op); err != nil {
+		return nil, fmt.Errorf("unreadable %s: %w", path, err)
+	}
+	return &op, nil
+}
+
+// ResumeOperation finishes an interrupted operation: the rebase it left
+// behind is continued, and a pending force-push is made
+func ResumeOperation(op Operation, out io.Writer) error {
+	if rebasing, _ := CheckRebaseInProgress(); rebasing {
+		unresolved, err := unresolvedConflicts()
+		if err != nil {
+			return err
+		}
+		if len(unresolved) > 0 {
+			return fmt.Errorf("conflict markers left in %s - resolve them (snap resolve), then snap resume", strings.Join(unresolved, ", "))
+		}
+		conflicted, _ := GetConflictedFiles()
+		for _, path := range conflicted {
+			if err := StageFile(path); err != nil {
+				return fmt.Errorf("failed to stage %s: %w", path, err)
+			}
+		}
+		output, err := ContinueRebase()
+		if output = strings.TrimSpace(output); output != "" {
+			fmt.Fprintln(out, output)
+		}
+		if err != nil {
+			return fmt.Errorf("git rebase --continue failed - fix the problem and run snap resume, or snap resume --abort")
+		}
+		if rebasing, _ := CheckRebaseInProgress(); rebasing {
+			EndOperation()
+			fmt.Fprintln(out, "⚠ The rebase stopped at a conflict - resolve it, then snap save to continue")
+			return nil
+		}
+		fmt.Fprintln(out, "✓ Rebase finished")
+	}
+	removeRewordMessages()
+
+	if op.Push {
+		if branch, _ := GetCurrentBranch(); branch != op.Branch {
+			return fmt.Errorf("not pushing: %s is checked out instead of %s", branch, op.Branch)
+		}
+		hasUpstream, _ := HasUpstreamBranch()
+		output, err := PushWithLease(op.Branch, hasUpstream)
+		if err != nil {
+			return fmt.Errorf("push failed: %s", strings.TrimSpace(output))
+		}
+		fmt.Fprintf(out, "✓ Pushed %s with --force-with-lease\n", op.Branch)
+	}
+	EndOperation()
+	return nil
+}
+
+// AbortOperation cleans up after an interrupted operation: an unfinished
+// rebase is aborted, which puts the branch back where it was. A rewrite that
+// already finished is left alone; the backup ref undoes it.
+func AbortOperation(op Operation, out io.Writer) error {
+	if rebasing, _ := CheckRebaseInProgress(); rebasing {
+		if err := AbortRebase(); err != nil {
+			return fmt.Errorf("git rebase --abort failed: %w", err)
+		}
+		fmt.Fprintf(out, "✓ Aborted the unfinished rebase; %s is back at %s\n", op.Branch, shortHash(op.Head))
+	} else if head, _ := ResolveCommit("HEAD"); head != op.Head && op.Backup != "" {
+		fmt.Fprintf(out, "The rewrite had finished; undo it with git reset --hard %s\n", op.Backup)
+	}
+	removeRewordMessages()
+	EndOperation()
+	return nil
+}
+
+// removeRewordMessages deletes the messages snap replay -i left for a rebase
+func removeRewordMessages() {
+	if dir, err := snapGitPath("reword"); err == nil {
+		os.RemoveAll(dir)
+	}
+}
+
+// stdinIsTerminal reports whether there is someone to answer a question
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// checkInterruptedOperation offers to resume or clean up an operation a
+// previous snap left behind. Without a terminal to ask on, it only warns.
+func checkInterruptedOperation(interactive bool) {
+	op, err := LoadOperation()
+	if err != nil || op == nil || op.Running() {
+		return
+	}
+	if !interactive {
+		fmt.Fprintf(os.Stderr, "⚠ %s was interrupted - run snap resume to finish it, or snap resume --abort\n", op)
+		return
+	}
+
+	fmt.Printf("⚠ %s was interrupted.\n", op)
+	fmt.Print("(r)esume it, (a)bort and clean up, or (l)ater: ")
+	var answer string
+	fmt.Scanln(&answer)
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "r", "resume":
+		err = ResumeOperation(*op, os.Stdout)
+	case "a", "abort":
+		err = AbortOperation(*op, os.Stdout)
+	default:
+		return
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println()
+}
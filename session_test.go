@@ -0,0 +1,94 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// interruptRebase leaves a rebase stopped at HEAD's commit, as if snap had
+// been killed while replaying it
+func interruptRebase(t *testing.T) {
+	t.Helper()
+	cmd := exec.Command("git", "rebase", "-i", "HEAD~1")
+	cmd.Env = append(os.Environ(), "GIT_SEQUENCE_EDITOR=sed -i -e s/^pick/edit/")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to start the rebase: %s", output)
+	}
+	if rebasing, _ := CheckRebaseInProgress(); !rebasing {
+		t.Fatal("Expected the rebase to stop")
+	}
+}
+
+func TestResumeOperation(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	if op, err := LoadOperation(); err != nil || op != nil {
+		t.Fatalf("Expected no operation, got %v (%v)", op, err)
+	}
+
+	os.WriteFile("notes.txt", []byte("hello\n"), 0644)
+	exec.Command("git", "add", "-A").Run()
+	exec.Command("git", "commit", "-q", "-m", "add notes").Run()
+	head, _ := ResolveCommit("HEAD")
+
+	if err := StartOperation("replay", "", false); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	interruptRebase(t)
+
+	op, err := LoadOperation()
+	if err != nil || op == nil {
+		t.Fatalf("Expected the operation to be recorded, got %v (%v)", op, err)
+	}
+	if op.Command != "replay" || op.Head != head {
+		t.Errorf("Expected replay from %s, got %+v", head, op)
+	}
+	// This process started it, so it doesn't count as running elsewhere
+	if op.Running() {
+		t.Error("Expected the operation not to be running")
+	}
+
+	if err := ResumeOperation(*op, io.Discard); err != nil {
+		t.Fatalf("ResumeOperation failed: %v", err)
+	}
+	if rebasing, _ := CheckRebaseInProgress(); rebasing {
+		t.Error("Expected the rebase to finish")
+	}
+	if op, _ := LoadOperation(); op != nil {
+		t.Errorf("Expected the operation to be forgotten, got %+v", op)
+	}
+}
+
+func TestAbortOperation(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	os.WriteFile("notes.txt", []byte("hello\n"), 0644)
+	exec.Command("git", "add", "-A").Run()
+	exec.Command("git", "commit", "-q", "-m", "add notes").Run()
+	head, _ := ResolveCommit("HEAD")
+
+	if err := StartOperation("convert", "", false); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	interruptRebase(t)
+	// A half-done rewrite of the stopped commit
+	exec.Command("git", "commit", "-q", "--amend", "-m", "docs: add notes").Run()
+
+	op, _ := LoadOperation()
+	if err := AbortOperation(*op, io.Discard); err != nil {
+		t.Fatalf("AbortOperation failed: %v", err)
+	}
+	if rebasing, _ := CheckRebaseInProgress(); rebasing {
+		t.Error("Expected the rebase to be aborted")
+	}
+	if now, _ := ResolveCommit("HEAD"); now != head {
+		t.Errorf("Expected HEAD back at %s, got %s", head, now)
+	}
+	if op, _ := LoadOperation(); op != nil {
+		t.Errorf("Expected the operation to be forgotten, got %+v", op)
+	}
+}
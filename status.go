@@ -0,0 +1,64 @@
+package main
+
+import "fmt"
+
+// runStatus prints a short overview of the repository: the current branch and
+// how far it is from its upstream and the default branch, uncommitted changes,
+// and commits not covered by a tag yet. With stepSummary, the overview also
+// goes to the GitHub Actions step summary. It returns the number of unreleased
+// commits.
+func runStatus(stepSummary bool) (int, error) {
+	var lines []string
+	addLine := func(line string) {
+		fmt.Println(line)
+		lines = append(lines, line)
+	}
+
+	branch, err := GetCurrentBranch()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current branch: %w", err)
+	}
+	if branch == "" {
+		addLine("HEAD detached")
+	} else {
+		addLine("On branch " + branch)
+	}
+
+	// Compared with the last fetch; nothing is fetched here
+	if upstream := GetUpstreamBranch(); upstream != "" {
+		if ahead, behind, err := GetAheadBehind("HEAD", upstream); err == nil {
+			addLine(fmt.Sprintf("Upstream %s: %s", upstream, driftSummary(ahead, behind)))
+		}
+	}
+	if base, err := GetDefaultBranch(); err == nil && base != branch {
+		if ahead, behind, err := GetAheadBehind("HEAD", base); err == nil {
+			addLine(fmt.Sprintf("Default branch %s: %s", base, driftSummary(ahead, behind)))
+		}
+	}
+
+	entries, err := GetStatusEntries(false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get status: %w", err)
+	}
+	switch len(entries) {
+	case 0:
+		addLine("No changes - everything is clean!")
+	case 1:
+		addLine("1 uncommitted change")
+	default:
+		addLine(fmt.Sprintf("%d uncommitted changes", len(entries)))
+	}
+
+	commits, prevTag, err := GetUnreleasedCommits()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load commits: %w", err)
+	}
+	addLine(unreleasedSummary(len(commits), prevTag))
+
+	if stepSummary {
+		if err := WriteStepSummary(statusMarkdown(lines)); err != nil {
+			return 0, err
+		}
+	}
+	return len(commits), nil
+}
@@ -19,14 +19,22 @@ const (
 	syncStateError
 )
 
+// syncMaxAttempts is how often 'snap sync --until-clean' pulls and pushes
+// before giving up on a remote that keeps moving
+const syncMaxAttempts = 5
+
 type syncModel struct {
-	state      syncState
-	spinner    spinner.Model
-	err        error
-	pullOnly   bool
-	branch     string
-	pullOutput string
-	pushOutput string
+	state       syncState
+	spinner     spinner.Model
+	err         error
+	pullOnly    bool
+	untilClean  bool // Rebase and push with lease, retrying while the remote moves
+	afterSave   bool // Run by snap save --push, which may leave changes uncommitted on purpose
+	attempt     int
+	branch      string
+	hasUpstream bool
+	pullOutput  string
+	pushOutput  string
 }
 
 type syncCheckMsg struct {
@@ -47,15 +55,16 @@ type syncPushMsg struct {
 	err    error
 }
 
-func initialSyncModel(pullOnly bool) syncModel {
+func initialSyncModel(pullOnly, untilClean bool) syncModel {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
 
 	return syncModel{
-		state:    syncStateChecking,
-		spinner:  s,
-		pullOnly: pullOnly,
+		state:      syncStateChecking,
+		spinner:    s,
+		pullOnly:   pullOnly,
+		untilClean: untilClean,
 	}
 }
 
@@ -63,6 +72,25 @@ func (m syncModel) Init() tea.Cmd {
 	return tea.Batch(m.spinner.Tick, checkSync)
 }
 
+// finished reports whether the sync is done or failed
+func (m syncModel) finished() bool {
+	return m.state == syncStateDone || m.state == syncStateError
+}
+
+// runSyncSteps drives the sync without a TUI, feeding each step's result back
+// into Update until the sync is finished
+func runSyncSteps(m syncModel) syncModel {
+	var msg tea.Msg = checkSync()
+	for {
+		next, cmd := m.Update(msg)
+		m = next.(syncModel)
+		if m.finished() || cmd == nil {
+			return m
+		}
+		msg = cmd()
+	}
+}
+
 func (m syncModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -86,22 +114,33 @@ func (m syncModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.err = fmt.Errorf("no remote repository configured")
 			return m, tea.Quit
 		}
-		if msg.hasChanges {
+		if msg.hasChanges && !m.afterSave {
 			m.state = syncStateError
 			m.err = fmt.Errorf("you have uncommitted changes - run 'snap save' first")
 			return m, tea.Quit
 		}
 
 		m.branch = msg.branch
+		m.hasUpstream = msg.hasUpstream
+		m.attempt = 1
+
+		// A branch the remote doesn't have yet has nothing to pull
+		if !m.hasUpstream && config.Sync.Upstream == "" && !m.pullOnly {
+			m.state = syncStatePushing
+			return m, m.pushCmd()
+		}
 		m.state = syncStatePulling
-		return m, pullChanges
+		return m, m.pullCmd()
 
 	case syncPullMsg:
 		m.pullOutput = msg.output
 		if msg.err != nil {
 			if strings.Contains(msg.output, "CONFLICT") {
 				m.state = syncStateError
-				m.err = fmt.Errorf("merge conflict detected - resolve manually and run 'snap save'")
+				m.err = fmt.Errorf("merge conflict detected - resolve manually (or with 'snap resolve') and run 'snap save'")
+				if m.untilClean {
+					m.err = fmt.Errorf("conflict while rebasing onto the remote - resolve manually (or with 'snap resolve') and run 'git rebase --continue'")
+				}
 				return m, tea.Quit
 			}
 			m.state = syncStateError
@@ -115,11 +154,22 @@ func (m syncModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		m.state = syncStatePushing
-		return m, pushChanges(m.branch)
+		return m, m.pushCmd()
 
 	case syncPushMsg:
 		m.pushOutput = msg.output
 		if msg.err != nil {
+			// The remote moved between pull and push; pull again and retry
+			if m.untilClean && IsPushRejected(msg.output) {
+				if m.attempt < syncMaxAttempts {
+					m.attempt++
+					m.state = syncStatePulling
+					return m, m.pullCmd()
+				}
+				m.state = syncStateError
+				m.err = fmt.Errorf("the remote kept moving - gave up after %d attempts", m.attempt)
+				return m, tea.Quit
+			}
 			m.state = syncStateError
 			m.err = msg.err
 			return m, tea.Quit
@@ -133,18 +183,24 @@ func (m syncModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m syncModel) View() string {
-	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575"))
-	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000"))
+	successStyle := lipgloss.NewStyle().Foreground(colorSuccess)
+	errorStyle := lipgloss.NewStyle().Foreground(colorError)
 
 	switch m.state {
 	case syncStateChecking:
 		return fmt.Sprintf("%s Checking repository...", m.spinner.View())
 
 	case syncStatePulling:
-		return fmt.Sprintf("%s Pulling changes...", m.spinner.View())
+		if config.Sync.Upstream != "" {
+			return fmt.Sprintf("%s Pulling changes from %s...%s", m.spinner.View(), config.Sync.Upstream, m.attemptSuffix())
+		}
+		return fmt.Sprintf("%s Pulling changes...%s", m.spinner.View(), m.attemptSuffix())
 
 	case syncStatePushing:
-		return fmt.Sprintf("%s Pushing changes...", m.spinner.View())
+		if config.Sync.Upstream != "" {
+			return fmt.Sprintf("%s Pushing changes to %s...%s", m.spinner.View(), config.Sync.Origin, m.attemptSuffix())
+		}
+		return fmt.Sprintf("%s Pushing changes...%s", m.spinner.View(), m.attemptSuffix())
 
 	case syncStateDone:
 		if m.pullOnly {
@@ -158,6 +214,8 @@ func (m syncModel) View() string {
 		pullMsg := "pulled"
 		if strings.Contains(m.pullOutput, "Already up to date") {
 			pullMsg = "up to date"
+		} else if !m.hasUpstream && config.Sync.Upstream == "" {
+			pullMsg = "new branch"
 		}
 
 		pushMsg := "pushed"
@@ -165,6 +223,9 @@ func (m syncModel) View() string {
 			pushMsg = "up to date"
 		}
 
+		if m.attempt > 1 {
+			return successStyle.Render(fmt.Sprintf("✓ Sync complete (%s, %s) after %d attempts", pullMsg, pushMsg, m.attempt))
+		}
 		return successStyle.Render(fmt.Sprintf("✓ Sync complete (%s, %s)", pullMsg, pushMsg))
 
 	case syncStateError:
@@ -180,6 +241,15 @@ func checkSync() tea.Msg {
 		return syncCheckMsg{err: err}
 	}
 
+	// Fork workflows need both remotes
+	if upstream := config.Sync.Upstream; upstream != "" {
+		for _, remote := range []string{upstream, config.Sync.Origin} {
+			if !RemoteExists(remote) {
+				return syncCheckMsg{err: fmt.Errorf("remote '%s' from the [sync] config doesn't exist - add it with 'git remote add %s <url>'", remote, remote)}
+			}
+		}
+	}
+
 	hasChanges, err := CheckForUncommittedChanges()
 	if err != nil {
 		return syncCheckMsg{err: err}
@@ -203,6 +273,71 @@ func checkSync() tea.Msg {
 	}
 }
 
+// attemptSuffix shows the retry count once --until-clean had to retry
+func (m syncModel) attemptSuffix() string {
+	if m.attempt <= 1 {
+		return ""
+	}
+	return fmt.Sprintf(" (attempt %d/%d)", m.attempt, syncMaxAttempts)
+}
+
+// pullCmd pulls with rebase in --until-clean mode, so the retried push is a
+// fast-forward, and with a plain pull otherwise. Fork workflows pull from
+// sync.upstream.
+func (m syncModel) pullCmd() tea.Cmd {
+	if config.Sync.Upstream != "" {
+		return pullFromUpstream(config.Sync.Upstream, m.branch, m.untilClean)
+	}
+	if m.untilClean {
+		return pullRebaseChanges
+	}
+	return pullChanges
+}
+
+// pushCmd pushes the branch; fork workflows push to sync.origin
+func (m syncModel) pushCmd() tea.Cmd {
+	if config.Sync.Upstream != "" {
+		return pushToOrigin(config.Sync.Origin, m.branch, !m.hasUpstream, m.untilClean)
+	}
+	if m.untilClean {
+		return pushWithLease(m.branch, m.hasUpstream)
+	}
+	return pushChanges(m.branch)
+}
+
+func pullFromUpstream(upstream, branch string, rebase bool) tea.Cmd {
+	return func() tea.Msg {
+		if output, err := FetchRemote(upstream); err != nil {
+			return syncPullMsg{output: output, err: err}
+		}
+		source, err := ForkPullBranch(upstream, branch)
+		if err != nil {
+			return syncPullMsg{err: err}
+		}
+		output, err := PullFrom(upstream, source, rebase)
+		return syncPullMsg{output: output, err: err}
+	}
+}
+
+func pushToOrigin(origin, branch string, setUpstream, lease bool) tea.Cmd {
+	return func() tea.Msg {
+		output, err := PushTo(origin, branch, setUpstream, lease)
+		return syncPushMsg{output: output, err: err}
+	}
+}
+
+func pullRebaseChanges() tea.Msg {
+	output, err := PullRebase()
+	return syncPullMsg{output: output, err: err}
+}
+
+func pushWithLease(branch string, hasUpstream bool) tea.Cmd {
+	return func() tea.Msg {
+		output, err := PushWithLease(branch, hasUpstream)
+		return syncPushMsg{output: output, err: err}
+	}
+}
+
 func pullChanges() tea.Msg {
 	output, err := PullChanges()
 	return syncPullMsg{output: output, err: err}
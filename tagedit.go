@@ -0,0 +1,341 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type tagsEditState int
+
+const (
+	tagsEditStateLoading tagsEditState = iota
+	tagsEditStateEditing
+	tagsEditStateConfirm
+	tagsEditStateSaving
+	tagsEditStatePushing
+	tagsEditStateDone
+	tagsEditStateError
+)
+
+type tagsEditModel struct {
+	state    tagsEditState
+	spinner  spinner.Model
+	textarea textarea.Model
+	tagName  string
+	original string
+	pushed   bool // Tag exists on origin, so saving requires a force-push
+	signed   bool // Saving signs the tag again with the user's key
+	skipPush bool // User chose to only update the local tag
+	err      error
+	width    int
+	height   int
+}
+
+type getTagMessageMsg struct {
+	message string
+	pushed  bool
+	signed  bool
+	err     error
+}
+
+type saveTagMessageMsg struct {
+	err error
+}
+
+type forcePushTagMsg struct {
+	output string
+	err    error
+}
+
+type editorFinishedMsg struct {
+	message string
+	err     error
+}
+
+func initialTagsEditModel(tagName string) tagsEditModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
+
+	ta := textarea.New()
+	ta.ShowLineNumbers = false
+	ta.MaxHeight = 1000 // Release notes can be long
+	ta.SetWidth(76)
+	ta.SetHeight(14)
+
+	return tagsEditModel{
+		state:    tagsEditStateLoading,
+		spinner:  s,
+		textarea: ta,
+		tagName:  tagName,
+		width:    80,
+		height:   24,
+	}
+}
+
+func (m tagsEditModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, getTagMessageCmd(m.tagName))
+}
+
+func (m tagsEditModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.textarea.SetWidth(msg.Width - 4)
+		m.textarea.SetHeight(max(msg.Height-8, 3)) // Leave space for header and footer
+		return m, nil
+
+	case tea.KeyMsg:
+		switch m.state {
+		case tagsEditStateEditing:
+			switch msg.String() {
+			case "ctrl+c", "esc":
+				return m, tea.Quit
+			case "ctrl+s":
+				if strings.TrimSpace(m.textarea.Value()) == "" {
+					m.err = fmt.Errorf("tag message cannot be empty")
+					return m, nil
+				}
+				if m.textarea.Value() == m.original {
+					m.err = fmt.Errorf("message unchanged")
+					return m, nil
+				}
+				m.err = nil
+				m.state = tagsEditStateConfirm
+				return m, nil
+			case "ctrl+e":
+				return m, openEditorCmd(m.textarea.Value())
+			}
+
+			var cmd tea.Cmd
+			m.textarea, cmd = m.textarea.Update(msg)
+			return m, cmd
+
+		case tagsEditStateConfirm:
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "y", "Y", "enter":
+				m.state = tagsEditStateSaving
+				return m, saveTagMessageCmd(m.tagName, m.textarea.Value())
+			case "l", "L":
+				// Only rewrite the local tag, leave origin untouched
+				if m.pushed {
+					m.skipPush = true
+					m.state = tagsEditStateSaving
+					return m, saveTagMessageCmd(m.tagName, m.textarea.Value())
+				}
+			case "n", "N", "esc":
+				m.state = tagsEditStateEditing
+				return m, m.textarea.Focus()
+			}
+		}
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case getTagMessageMsg:
+		if msg.err != nil {
+			m.state = tagsEditStateError
+			m.err = msg.err
+			return m, tea.Quit
+		}
+		m.original = msg.message
+		m.pushed = msg.pushed
+		m.signed = msg.signed
+		m.textarea.SetValue(msg.message)
+		m.state = tagsEditStateEditing
+		return m, m.textarea.Focus()
+
+	case editorFinishedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.err = nil
+		m.textarea.SetValue(msg.message)
+		return m, nil
+
+	case saveTagMessageMsg:
+		if msg.err != nil {
+			m.state = tagsEditStateError
+			m.err = msg.err
+			return m, tea.Quit
+		}
+		if !m.pushed || m.skipPush {
+			m.state = tagsEditStateDone
+			return m, tea.Quit
+		}
+		m.state = tagsEditStatePushing
+		return m, forcePushTagCmd(m.tagName)
+
+	case forcePushTagMsg:
+		if msg.err != nil {
+			m.state = tagsEditStateError
+			m.err = fmt.Errorf("tag updated locally but force-push failed: %w\n%s", msg.err, strings.TrimSpace(msg.output))
+			return m, tea.Quit
+		}
+		m.state = tagsEditStateDone
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+// ExitSummary keeps the result visible after the alt screen closes
+func (m tagsEditModel) ExitSummary() string {
+	switch m.state {
+	case tagsEditStateDone:
+		if m.pushed && !m.skipPush {
+			return fmt.Sprintf("✓ Updated and force-pushed tag %s", m.tagName)
+		}
+		if m.skipPush {
+			return fmt.Sprintf("✓ Updated local tag %s - origin still has the old message", m.tagName)
+		}
+		return fmt.Sprintf("✓ Updated tag %s", m.tagName)
+	case tagsEditStateError:
+		return fmt.Sprintf("✗ Error: %v", m.err)
+	}
+	return ""
+}
+
+func (m tagsEditModel) View() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorPrimary).
+		PaddingLeft(2)
+	helpStyle := lipgloss.NewStyle().
+		Foreground(colorMuted).
+		PaddingLeft(2)
+
+	switch m.state {
+	case tagsEditStateLoading:
+		return fmt.Sprintf("%s Loading tag %s...", m.spinner.View(), m.tagName)
+
+	case tagsEditStateEditing:
+		var s strings.Builder
+		s.WriteString(titleStyle.Render(fmt.Sprintf("Edit tag %s", m.tagName)))
+		s.WriteString("\n\n")
+		s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(m.textarea.View()))
+		s.WriteString("\n\n")
+		if m.err != nil {
+			s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(errorStyle.Render(m.err.Error())))
+			s.WriteString("\n")
+		}
+		s.WriteString(helpStyle.Render("ctrl+s: save  ctrl+e: open in $EDITOR  esc: cancel"))
+		return s.String()
+
+	case tagsEditStateConfirm:
+		var s strings.Builder
+		s.WriteString(titleStyle.Render(fmt.Sprintf("Edit tag %s", m.tagName)))
+		s.WriteString("\n\n")
+
+		promptStyle := lipgloss.NewStyle().PaddingLeft(2)
+		if m.signed {
+			s.WriteString(lipgloss.NewStyle().Foreground(colorWarning).PaddingLeft(2).Render("⚠ This tag is signed. It will be signed again with your key, or left unchanged if signing fails."))
+			s.WriteString("\n")
+		}
+		if m.pushed {
+			warningStyle := lipgloss.NewStyle().Foreground(colorWarning).PaddingLeft(2)
+			s.WriteString(warningStyle.Render("⚠ This tag is already on origin and will be force-pushed."))
+			s.WriteString("\n")
+			s.WriteString(helpStyle.Render("Anyone who already fetched it keeps the old message until they run 'git fetch --tags --force'."))
+			s.WriteString("\n\n")
+			s.WriteString(promptStyle.Render(highlightStyle.Render("Rewrite and force-push tag? (y/n, l: local only): ")))
+		} else {
+			s.WriteString(promptStyle.Render(highlightStyle.Render("Rewrite tag message? (y/n): ")))
+		}
+		return s.String()
+
+	case tagsEditStateSaving:
+		return fmt.Sprintf("%s Rewriting tag %s...", m.spinner.View(), m.tagName)
+
+	case tagsEditStatePushing:
+		return fmt.Sprintf("%s Force-pushing tag %s...", m.spinner.View(), m.tagName)
+
+	case tagsEditStateDone:
+		if m.pushed && !m.skipPush {
+			return successStyle.Render(fmt.Sprintf("✓ Updated and force-pushed tag %s", m.tagName))
+		}
+		if m.skipPush {
+			return successStyle.Render(fmt.Sprintf("✓ Updated local tag %s", m.tagName)) + "\n" +
+				infoStyle.Render(fmt.Sprintf("  Origin still has the old message - push with 'git push --force origin %s'", m.tagName))
+		}
+		return successStyle.Render(fmt.Sprintf("✓ Updated tag %s", m.tagName))
+
+	case tagsEditStateError:
+		return errorStyle.Render(fmt.Sprintf("✗ Error: %s", m.err))
+	}
+
+	return ""
+}
+
+func getTagMessageCmd(tagName string) tea.Cmd {
+	return func() tea.Msg {
+		message, err := GetTagMessage(tagName)
+		if err != nil {
+			return getTagMessageMsg{err: err}
+		}
+		// Without a reachable remote the tag is treated as local only
+		pushed, _ := IsTagPushed(tagName)
+		return getTagMessageMsg{message: message, pushed: pushed, signed: IsTagSigned(tagName)}
+	}
+}
+
+func saveTagMessageCmd(tagName, message string) tea.Cmd {
+	return func() tea.Msg {
+		return saveTagMessageMsg{err: ReplaceTagMessage(tagName, message)}
+	}
+}
+
+func forcePushTagCmd(tagName string) tea.Cmd {
+	return func() tea.Msg {
+		output, err := ForcePushTag(tagName)
+		return forcePushTagMsg{output: output, err: err}
+	}
+}
+
+// openEditorCmd suspends the TUI and edits the message in $EDITOR (vi if unset)
+func openEditorCmd(message string) tea.Cmd {
+	file, err := os.CreateTemp("", "snap-tag-*.txt")
+	if err != nil {
+		return func() tea.Msg { return editorFinishedMsg{err: err} }
+	}
+	path := file.Name()
+	_, err = file.WriteString(message)
+	file.Close()
+	if err != nil {
+		os.Remove(path)
+		return func() tea.Msg { return editorFinishedMsg{err: err} }
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	// $EDITOR may contain arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return editorFinishedMsg{err: fmt.Errorf("editor failed: %w", err)}
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return editorFinishedMsg{err: err}
+		}
+		return editorFinishedMsg{message: strings.TrimRight(string(content), "\n")}
+	})
+}
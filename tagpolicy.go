@@ -0,0 +1,314 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// SemVer represents a parsed semantic version (MAJOR.MINOR.PATCH[-PRERELEASE])
+type SemVer struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// ParseSemVer parses a semantic version without prefix, e.g. "1.2.3" or "1.3.0-rc.1"
+func ParseSemVer(version string) (SemVer, bool) {
+	// Build metadata is allowed but ignored
+	if idx := strings.Index(version, "+"); idx >= 0 {
+		version = version[:idx]
+	}
+
+	var v SemVer
+	core := version
+	if idx := strings.Index(version, "-"); idx >= 0 {
+		core = version[:idx]
+		v.Prerelease = version[idx+1:]
+		if v.Prerelease == "" {
+			return SemVer{}, false
+		}
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return SemVer{}, false
+	}
+
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		if part == "" || (len(part) > 1 && part[0] == '0') {
+			return SemVer{}, false
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return SemVer{}, false
+		}
+		numbers[i] = n
+	}
+
+	v.Major, v.Minor, v.Patch = numbers[0], numbers[1], numbers[2]
+	return v, true
+}
+
+// String formats the version without prefix
+func (v SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Core returns the version without its prerelease part
+func (v SemVer) Core() SemVer {
+	return SemVer{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+}
+
+// CompareSemVer returns -1, 0 or 1 depending on whether a sorts before, equal to or after b.
+// Prereleases sort before the final version (1.3.0-rc.1 < 1.3.0-rc.2 < 1.3.0).
+func CompareSemVer(a, b SemVer) int {
+	for _, pair := range [][2]int{{a.Major, b.Major}, {a.Minor, b.Minor}, {a.Patch, b.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case a.Prerelease == b.Prerelease:
+		return 0
+	case a.Prerelease == "":
+		return 1
+	case b.Prerelease == "":
+		return -1
+	}
+
+	// Compare dot-separated identifiers: numeric ones numerically, others lexically
+	aParts := strings.Split(a.Prerelease, ".")
+	bParts := strings.Split(b.Prerelease, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum != bNum {
+				if aNum < bNum {
+					return -1
+				}
+				return 1
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if cmp := strings.Compare(aParts[i], bParts[i]); cmp != 0 {
+				return cmp
+			}
+		}
+	}
+
+	switch {
+	case len(aParts) < len(bParts):
+		return -1
+	case len(aParts) > len(bParts):
+		return 1
+	}
+	return 0
+}
+
+// NextVersion computes the version to release after the existing ones.
+// level is "major", "minor", "patch" or empty; pre is a prerelease identifier such as "rc".
+// With an empty level, an unreleased prerelease line is continued (1.3.0-rc.2 -> 1.3.0-rc.3,
+// or 1.3.0 without pre), otherwise the patch version is bumped. A prerelease that
+// would sort below an existing one of the same version (beta after rc) is an error.
+func NextVersion(existing []SemVer, level string, pre string) (SemVer, error) {
+	var latestFinal, latestAny SemVer
+	for _, v := range existing {
+		if v.Prerelease == "" && CompareSemVer(v, latestFinal) > 0 {
+			latestFinal = v
+		}
+		if CompareSemVer(v, latestAny) > 0 {
+			latestAny = v
+		}
+	}
+
+	var next SemVer
+	switch level {
+	case "major":
+		next = SemVer{Major: latestFinal.Major + 1}
+	case "minor":
+		next = SemVer{Major: latestFinal.Major, Minor: latestFinal.Minor + 1}
+	case "patch":
+		next = SemVer{Major: latestFinal.Major, Minor: latestFinal.Minor, Patch: latestFinal.Patch + 1}
+	case "":
+		if latestAny.Prerelease != "" {
+			next = latestAny.Core()
+		} else {
+			next = SemVer{Major: latestFinal.Major, Minor: latestFinal.Minor, Patch: latestFinal.Patch + 1}
+		}
+	default:
+		return SemVer{}, fmt.Errorf("unknown version level '%s' (expected major, minor, or patch)", level)
+	}
+
+	if pre == "" {
+		return next, nil
+	}
+	if strings.ContainsAny(pre, ". ") {
+		return SemVer{}, fmt.Errorf("invalid prerelease identifier '%s'", pre)
+	}
+
+	// Auto-increment the prerelease number for this version line
+	number := 0
+	for _, v := range existing {
+		if v.Core() != next || !strings.HasPrefix(v.Prerelease, pre+".") {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(v.Prerelease, pre+".")); err == nil && n > number {
+			number = n
+		}
+	}
+	next.Prerelease = fmt.Sprintf("%s.%d", pre, number+1)
+
+	// A prerelease train only moves forward, e.g. no beta after an rc
+	for _, v := range existing {
+		if v.Core() == next.Core() && v.Prerelease != "" && CompareSemVer(next, v) <= 0 {
+			return SemVer{}, fmt.Errorf("%s would sort below the existing %s - use a later identifier than '%s', or bump to the next version", next, v, pre)
+		}
+	}
+
+	return next, nil
+}
+
+// TagVersions parses the versions of all tags that start with prefix, skipping non-semver tags
+func TagVersions(tagNames []string, prefix string) []SemVer {
+	versions := make([]SemVer, 0, len(tagNames))
+	for _, name := range tagNames {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if v, ok := ParseSemVer(name[len(prefix):]); ok {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+// TagPolicy describes how release tags must be named
+type TagPolicy struct {
+	Prefixes      []string // Allowed prefixes, e.g. "v" or "api/v" for monorepos
+	RequireSemver bool     // Require a semantic version after the prefix
+}
+
+// defaultTagPolicy is used when the repository doesn't configure a policy
+var defaultTagPolicy = TagPolicy{
+	Prefixes:      []string{"v"},
+	RequireSemver: true,
+}
+
+// LoadTagPolicy returns the tag naming policy from [tags] in the config.
+// Without a [tags] section, the older git config keys still apply:
+// snap.tagPrefix (repeatable) and snap.tagSemver.
+func LoadTagPolicy() TagPolicy {
+	policy := TagPolicy{
+		Prefixes:      config.Tags.Prefixes,
+		RequireSemver: config.Tags.Semver,
+	}
+	if config.tagsSet {
+		return policy
+	}
+
+	cmd := exec.Command("git", "config", "--get-all", "snap.tagPrefix")
+	if output, err := cmd.Output(); err == nil {
+		policy.Prefixes = strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+	}
+
+	cmd = exec.Command("git", "config", "--type=bool", "--get", "snap.tagSemver")
+	if output, err := cmd.Output(); err == nil {
+		policy.RequireSemver = strings.TrimSpace(string(output)) == "true"
+	}
+
+	return policy
+}
+
+// Resolve returns the final tag name for the requested name or version,
+// adding the prefix to bare versions and rejecting names that violate the policy
+func (p TagPolicy) Resolve(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("tag name cannot be empty")
+	}
+
+	if len(p.Prefixes) == 0 {
+		if err := p.validateVersion(name, ""); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+
+	// Prefer the longest matching prefix (e.g. "api/v" over "")
+	matched := ""
+	found := false
+	for _, prefix := range p.Prefixes {
+		if strings.HasPrefix(name, prefix) && (!found || len(prefix) > len(matched)) {
+			matched = prefix
+			found = true
+		}
+	}
+
+	if found {
+		if err := p.validateVersion(name[len(matched):], matched); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+
+	// Bare version - add the prefix if it's unambiguous
+	if _, ok := ParseSemVer(name); ok {
+		if len(p.Prefixes) == 1 {
+			return p.Prefixes[0] + name, nil
+		}
+		return "", fmt.Errorf("multiple tag prefixes configured, specify one of: %s", p.describePrefixes())
+	}
+
+	return "", fmt.Errorf("tag '%s' does not match the naming policy (expected %s)", name, p.describePrefixes())
+}
+
+// validateVersion checks the part of the tag name after the prefix
+func (p TagPolicy) validateVersion(version, prefix string) error {
+	if version == "" {
+		return fmt.Errorf("tag name needs a version after the prefix '%s'", prefix)
+	}
+	if !p.RequireSemver {
+		return nil
+	}
+	if _, ok := ParseSemVer(version); !ok {
+		return fmt.Errorf("'%s' is not a semantic version (expected %sMAJOR.MINOR.PATCH)", version, prefix)
+	}
+	return nil
+}
+
+// describePrefixes returns the allowed prefixes as an example pattern list
+func (p TagPolicy) describePrefixes() string {
+	examples := make([]string, len(p.Prefixes))
+	for i, prefix := range p.Prefixes {
+		examples[i] = prefix + "MAJOR.MINOR.PATCH"
+	}
+	return strings.Join(examples, ", ")
+}
+
+// PrefixOf returns the configured prefix that the tag name starts with
+func (p TagPolicy) PrefixOf(name string) string {
+	matched := ""
+	for _, prefix := range p.Prefixes {
+		if strings.HasPrefix(name, prefix) && len(prefix) > len(matched) {
+			matched = prefix
+		}
+	}
+	return matched
+}
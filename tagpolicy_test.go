@@ -0,0 +1,236 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestParseSemVer(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected SemVer
+		ok       bool
+	}{
+		{input: "1.2.3", expected: SemVer{Major: 1, Minor: 2, Patch: 3}, ok: true},
+		{input: "0.10.0", expected: SemVer{Major: 0, Minor: 10, Patch: 0}, ok: true},
+		{input: "1.3.0-rc.1", expected: SemVer{Major: 1, Minor: 3, Patch: 0, Prerelease: "rc.1"}, ok: true},
+		{input: "1.0.0+build.5", expected: SemVer{Major: 1, Minor: 0, Patch: 0}, ok: true},
+		{input: "1.2", ok: false},
+		{input: "01.2.3", ok: false},
+		{input: "1.2.x", ok: false},
+		{input: "1.2.3-", ok: false},
+		{input: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, ok := ParseSemVer(tt.input)
+			if ok != tt.ok {
+				t.Fatalf("ParseSemVer(%q) ok = %v, want %v", tt.input, ok, tt.ok)
+			}
+			if ok && result != tt.expected {
+				t.Errorf("ParseSemVer(%q) = %+v, want %+v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTagPolicyResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   TagPolicy
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "Prefixed version",
+			policy:   defaultTagPolicy,
+			input:    "v1.2.3",
+			expected: "v1.2.3",
+		},
+		{
+			name:     "Bare version gets prefix",
+			policy:   defaultTagPolicy,
+			input:    "1.2.3",
+			expected: "v1.2.3",
+		},
+		{
+			name:     "Prerelease version",
+			policy:   defaultTagPolicy,
+			input:    "v1.3.0-rc.1",
+			expected: "v1.3.0-rc.1",
+		},
+		{
+			name:    "Non-semver rejected",
+			policy:  defaultTagPolicy,
+			input:   "release-2024",
+			wantErr: true,
+		},
+		{
+			name:    "Prefix without valid version",
+			policy:  defaultTagPolicy,
+			input:   "vnext",
+			wantErr: true,
+		},
+		{
+			name:     "Monorepo prefix",
+			policy:   TagPolicy{Prefixes: []string{"api/v", "web/v"}, RequireSemver: true},
+			input:    "api/v1.2.3",
+			expected: "api/v1.2.3",
+		},
+		{
+			name:    "Monorepo bare version is ambiguous",
+			policy:  TagPolicy{Prefixes: []string{"api/v", "web/v"}, RequireSemver: true},
+			input:   "1.2.3",
+			wantErr: true,
+		},
+		{
+			name:    "Wrong monorepo prefix",
+			policy:  TagPolicy{Prefixes: []string{"api/v"}, RequireSemver: true},
+			input:   "web/v1.2.3",
+			wantErr: true,
+		},
+		{
+			name:     "Free-form names allowed",
+			policy:   TagPolicy{Prefixes: []string{"release-"}, RequireSemver: false},
+			input:    "release-2024",
+			expected: "release-2024",
+		},
+		{
+			name:    "Empty name",
+			policy:  defaultTagPolicy,
+			input:   "  ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.policy.Resolve(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Resolve(%q) = %q, expected error", tt.input, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q) failed: %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Resolve(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadTagPolicy(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	policy := LoadTagPolicy()
+	if len(policy.Prefixes) != 1 || policy.Prefixes[0] != "v" || !policy.RequireSemver {
+		t.Errorf("Expected default policy, got %+v", policy)
+	}
+
+	exec.Command("git", "config", "--add", "snap.tagPrefix", "api/v").Run()
+	exec.Command("git", "config", "--add", "snap.tagPrefix", "web/v").Run()
+	exec.Command("git", "config", "snap.tagSemver", "false").Run()
+
+	policy = LoadTagPolicy()
+	if len(policy.Prefixes) != 2 || policy.Prefixes[0] != "api/v" || policy.Prefixes[1] != "web/v" {
+		t.Errorf("Expected monorepo prefixes, got %v", policy.Prefixes)
+	}
+	if policy.RequireSemver {
+		t.Error("Expected RequireSemver to be false")
+	}
+
+	// [tags] in the config replaces the git config keys
+	defer applyConfig(config)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	os.WriteFile(repoConfigFile, []byte("[tags]\nprefixes = [\"release-\"]\n"), 0644)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	applyConfig(cfg)
+	policy = LoadTagPolicy()
+	if len(policy.Prefixes) != 1 || policy.Prefixes[0] != "release-" || !policy.RequireSemver {
+		t.Errorf("Expected the [tags] policy, got %+v", policy)
+	}
+}
+
+func TestCompareSemVer(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "1.2.3", b: "1.2.3", expected: 0},
+		{a: "1.2.3", b: "1.3.0", expected: -1},
+		{a: "2.0.0", b: "1.9.9", expected: 1},
+		{a: "1.3.0-rc.1", b: "1.3.0", expected: -1},
+		{a: "1.3.0-rc.2", b: "1.3.0-rc.1", expected: 1},
+		{a: "1.3.0-rc.10", b: "1.3.0-rc.9", expected: 1},
+		{a: "1.3.0-beta.1", b: "1.3.0-rc.1", expected: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			a, _ := ParseSemVer(tt.a)
+			b, _ := ParseSemVer(tt.b)
+			if result := CompareSemVer(a, b); result != tt.expected {
+				t.Errorf("CompareSemVer(%s, %s) = %d, want %d", tt.a, tt.b, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		level    string
+		pre      string
+		expected string
+		wantErr  bool
+	}{
+		{name: "No tags", level: "", expected: "0.0.1"},
+		{name: "Patch bump", existing: []string{"1.2.5"}, level: "patch", expected: "1.2.6"},
+		{name: "Minor bump", existing: []string{"1.2.5", "1.1.0"}, level: "minor", expected: "1.3.0"},
+		{name: "Major bump", existing: []string{"1.2.5"}, level: "major", expected: "2.0.0"},
+		{name: "First release candidate", existing: []string{"1.2.5"}, level: "minor", pre: "rc", expected: "1.3.0-rc.1"},
+		{name: "Next release candidate", existing: []string{"1.2.5", "1.3.0-rc.1"}, level: "", pre: "rc", expected: "1.3.0-rc.2"},
+		{name: "Finish prerelease line", existing: []string{"1.2.5", "1.3.0-rc.2"}, level: "", expected: "1.3.0"},
+		{name: "Bump after final release", existing: []string{"1.3.0-rc.2", "1.3.0"}, level: "", expected: "1.3.1"},
+		{name: "Beta after a release candidate", existing: []string{"1.2.5", "1.3.0-rc.1"}, level: "", pre: "beta", wantErr: true},
+		{name: "Release candidate after a beta", existing: []string{"1.2.5", "1.3.0-beta.2"}, level: "", pre: "rc", expected: "1.3.0-rc.1"},
+		{name: "Beta for the next version after an rc", existing: []string{"1.2.5", "1.3.0-rc.1"}, level: "major", pre: "beta", expected: "2.0.0-beta.1"},
+		{name: "Unknown level", existing: []string{"1.2.5"}, level: "huge", wantErr: true},
+		{name: "Invalid prerelease identifier", existing: []string{"1.2.5"}, level: "patch", pre: "rc.1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var existing []SemVer
+			for _, s := range tt.existing {
+				v, _ := ParseSemVer(s)
+				existing = append(existing, v)
+			}
+
+			result, err := NextVersion(existing, tt.level, tt.pre)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("NextVersion() = %s, expected error", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NextVersion() failed: %v", err)
+			}
+			if result.String() != tt.expected {
+				t.Errorf("NextVersion() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
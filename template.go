@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// templateTokenRe matches placeholders like {{project_name}} in template file
+// names and contents
+var templateTokenRe = regexp.MustCompile(`\{\{([a-z][a-z0-9_]*)\}\}`)
+
+// FetchTemplate makes a template available as a directory. A local directory
+// that isn't a repository is used as it is; anything else is cloned without
+// history. cleanup removes the clone.
+func FetchTemplate(source string) (dir string, cleanup func(), err error) {
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		if _, err := os.Stat(filepath.Join(source, ".git")); os.IsNotExist(err) {
+			return source, func() {}, nil
+		}
+	}
+
+	dir, err = os.MkdirTemp("", "snap-template-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+	// file:// makes --depth work for local repositories too
+	url := source
+	if abs, err := filepath.Abs(source); err == nil {
+		if _, err := os.Stat(abs); err == nil {
+			url = "file://" + abs
+		}
+	}
+	if output, err := exec.Command("git", "clone", "-q", "--depth", "1", url, dir).CombinedOutput(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to clone template %s: %s", source, strings.TrimSpace(string(output)))
+	}
+	return dir, cleanup, nil
+}
+
+// walkTemplate calls fn for every file and symlink in the template, with the
+// path relative to dir; the template's .git directory is skipped
+func walkTemplate(dir string, fn func(rel string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), d)
+	})
+}
+
+// FindTemplateTokens lists the placeholders used in the template's file names
+// and text files, sorted
+func FindTemplateTokens(dir string) ([]string, error) {
+	seen := map[string]bool{}
+	collect := func(s string) {
+		for _, match := range templateTokenRe.FindAllStringSubmatch(s, -1) {
+			seen[match[1]] = true
+		}
+	}
+	err := walkTemplate(dir, func(rel string, d fs.DirEntry) error {
+		collect(rel)
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		content, err := os.ReadFile(filepath.Join(dir, rel))
+		if err != nil {
+			return err
+		}
+		if !isBinary(content) {
+			collect(string(content))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]string, 0, len(seen))
+	for token := range seen {
+		tokens = append(tokens, token)
+	}
+	slices.Sort(tokens)
+	return tokens, nil
+}
+
+// replaceTokens fills in the placeholders that have a value and leaves the
+// others as they are, so unrelated {{...}} syntax survives
+func replaceTokens(s string, values map[string]string) string {
+	return templateTokenRe.ReplaceAllStringFunc(s, func(token string) string {
+		if value := values[token[2:len(token)-2]]; value != "" {
+			return value
+		}
+		return token
+	})
+}
+
+// isBinary guesses like git does: a NUL byte in the first 8000 bytes
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0
+}
+
+// RenderTemplate copies the template into dest with the placeholders in file
+// names and text files replaced. Nothing is written if a file would be
+// overwritten. It returns the paths it created, relative to dest.
+func RenderTemplate(dir, dest string, values map[string]string) ([]string, error) {
+	targets := map[string]string{}
+	var paths []string
+	err := walkTemplate(dir, func(rel string, d fs.DirEntry) error {
+		target := replaceTokens(rel, values)
+		if _, err := os.Lstat(filepath.Join(dest, target)); err == nil {
+			return fmt.Errorf("%s already exists - run snap init --template in an empty directory", target)
+		}
+		targets[rel] = target
+		paths = append(paths, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]string, 0, len(paths))
+	for _, rel := range paths {
+		src := filepath.Join(dir, rel)
+		target := filepath.Join(dest, targets[rel])
+		info, err := os.Lstat(src)
+		if err != nil {
+			return created, err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return created, err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err := os.Readlink(src)
+			if err != nil {
+				return created, err
+			}
+			if err := os.Symlink(replaceTokens(link, values), target); err != nil {
+				return created, err
+			}
+		} else {
+			content, err := os.ReadFile(src)
+			if err != nil {
+				return created, err
+			}
+			if !isBinary(content) {
+				content = []byte(replaceTokens(string(content), values))
+			}
+			if err := os.WriteFile(target, content, info.Mode().Perm()); err != nil {
+				return created, err
+			}
+		}
+		created = append(created, targets[rel])
+	}
+	return created, nil
+}
+
+// templateDefaults suggests values for common placeholders
+func templateDefaults() map[string]string {
+	defaults := map[string]string{"year": fmt.Sprint(time.Now().Year())}
+	if cwd, err := os.Getwd(); err == nil {
+		defaults["project_name"] = filepath.Base(cwd)
+	}
+	if name, err := exec.Command("git", "config", "user.name").Output(); err == nil {
+		defaults["author"] = strings.TrimSpace(string(name))
+		defaults["author_name"] = defaults["author"]
+	}
+	if email, err := exec.Command("git", "config", "user.email").Output(); err == nil {
+		defaults["author_email"] = strings.TrimSpace(string(email))
+	}
+	return defaults
+}
+
+// runInitTemplate bootstraps a repository from a template: its files without
+// its history, the placeholders filled in (from values, or asked for on
+// stdin), and an initial commit
+func runInitTemplate(source string, values map[string]string) error {
+	fmt.Printf("📸 Fetching template %s...\n", source)
+	dir, cleanup, err := FetchTemplate(source)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	tokens, err := FindTemplateTokens(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+	defaults := templateDefaults()
+	reader := bufio.NewReader(os.Stdin)
+	for _, token := range tokens {
+		if _, ok := values[token]; ok {
+			continue
+		}
+		// An empty answer without a default keeps {{token}} as it is
+		if defaults[token] != "" {
+			fmt.Printf("%s [%s]: ", token, defaults[token])
+		} else {
+			fmt.Printf("%s: ", token)
+		}
+		answer, err := reader.ReadString('\n')
+		if err != nil && answer == "" {
+			fmt.Println()
+		}
+		values[token] = strings.TrimSpace(answer)
+		if values[token] == "" {
+			values[token] = defaults[token]
+		}
+	}
+
+	files, err := RenderTemplate(dir, ".", values)
+	if err != nil {
+		return err
+	}
+	if len(files) == 1 {
+		fmt.Println("✓ Copied 1 file")
+	} else {
+		fmt.Printf("✓ Copied %d files\n", len(files))
+	}
+
+	if output, err := InitRepository(); err != nil {
+		return fmt.Errorf("failed to initialize repository: %w\n%s", err, strings.TrimSpace(output))
+	}
+	notice, err := CheckIdentity()
+	if err != nil {
+		return err
+	}
+	if notice != "" {
+		fmt.Println(notice)
+	}
+	if err := StageAllChanges(); err != nil {
+		return fmt.Errorf("failed to stage template files: %w", err)
+	}
+	name := strings.TrimSuffix(filepath.Base(strings.TrimRight(source, "/")), ".git")
+	if _, err := CommitChanges(fmt.Sprintf("chore: initial commit from %s template", name), false); err != nil {
+		return err
+	}
+	fmt.Println("✓ Repository initialized with an initial commit!")
+	return nil
+}
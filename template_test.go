@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "cmd", "{{project_name}}"), 0755)
+	os.WriteFile(filepath.Join(dir, "cmd", "{{project_name}}", "main.go"), []byte("// {{project_name}} by {{author}}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "chart.yaml"), []byte("{{end}} {{ .Values.name }}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "logo.png"), []byte("\x89PNG\x00{{year}}"), 0644)
+
+	tokens, err := FindTemplateTokens(dir)
+	if err != nil {
+		t.Fatalf("FindTemplateTokens failed: %v", err)
+	}
+	if want := []string{"author", "end", "project_name"}; !slices.Equal(tokens, want) {
+		t.Errorf("Expected tokens %v, got %v", want, tokens)
+	}
+
+	dest := t.TempDir()
+	values := map[string]string{"project_name": "snapper", "author": "Jane", "end": ""}
+	files, err := RenderTemplate(dir, dest, values)
+	if err != nil {
+		t.Fatalf("RenderTemplate failed: %v", err)
+	}
+	if len(files) != 3 {
+		t.Errorf("Expected 3 files, got %v", files)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dest, "cmd", "snapper", "main.go")); string(content) != "// snapper by Jane\n" {
+		t.Errorf("Unexpected main.go %q", content)
+	}
+	// Placeholders without a value and other template syntax stay
+	if content, _ := os.ReadFile(filepath.Join(dest, "chart.yaml")); string(content) != "{{end}} {{ .Values.name }}\n" {
+		t.Errorf("Unexpected chart.yaml %q", content)
+	}
+
+	if _, err := RenderTemplate(dir, dest, values); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected an error for existing files, got %v", err)
+	}
+}
+
+func TestRunInitTemplate(t *testing.T) {
+	template := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", template, "-c", "user.name=T", "-c", "user.email=t@example.com"}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	git("init", "-q")
+	os.WriteFile(filepath.Join(template, "README.md"), []byte("# {{project_name}}\n"), 0644)
+	git("add", "-A")
+	git("commit", "-q", "-m", "template history")
+
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+	os.Chdir(t.TempDir())
+	t.Setenv("GIT_AUTHOR_NAME", "Test User")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test User")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	if err := runInitTemplate(template, map[string]string{"project_name": "snapper"}); err != nil {
+		t.Fatalf("runInitTemplate failed: %v", err)
+	}
+	if content, _ := os.ReadFile("README.md"); string(content) != "# snapper\n" {
+		t.Errorf("Unexpected README.md %q", content)
+	}
+	output, _ := exec.Command("git", "log", "--format=%s").Output()
+	if got := strings.TrimSpace(string(output)); !strings.HasPrefix(got, "chore: initial commit from") || strings.Contains(got, "\n") {
+		t.Errorf("Expected only the initial commit, got %q", got)
+	}
+}
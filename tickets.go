@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ticketIDRe matches Jira and Linear issue keys like ENG-123
+var ticketIDRe = regexp.MustCompile(`\b([A-Z][A-Z0-9]+)-(\d+)\b`)
+
+// linearAPIURL is Linear's GraphQL endpoint
+var linearAPIURL = "https://api.linear.app/graphql"
+
+// ticketLookupTimeout bounds each ticket API request
+const ticketLookupTimeout = 10 * time.Second
+
+// Ticket is an issue in Jira or Linear
+type Ticket struct {
+	ID    string
+	Title string
+	URL   string
+}
+
+// FindTicketIDs returns the ticket IDs in text in order of appearance, limited
+// to the project keys when any are given
+func FindTicketIDs(text string, keys []string) []string {
+	var ids []string
+	for _, match := range ticketIDRe.FindAllStringSubmatch(text, -1) {
+		if len(keys) > 0 && !slices.Contains(keys, match[1]) {
+			continue
+		}
+		if !slices.Contains(ids, match[0]) {
+			ids = append(ids, match[0])
+		}
+	}
+	return ids
+}
+
+// ResolveTickets looks up ticket IDs with the configured provider. IDs that
+// don't exist (or aren't tickets, like UTF-8) are left out; an error is only
+// returned when the provider can't be used at all.
+func ResolveTickets(ids []string, cfg TicketsConfig) (map[string]Ticket, error) {
+	tickets := map[string]Ticket{}
+	client := &http.Client{Timeout: ticketLookupTimeout}
+	for _, id := range ids {
+		var ticket Ticket
+		var found bool
+		var err error
+		if cfg.Provider == ticketsLinear {
+			ticket, found, err = fetchLinearTicket(client, id)
+		} else {
+			ticket, found, err = fetchJiraTicket(client, cfg.URL, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			tickets[id] = ticket
+		}
+	}
+	return tickets, nil
+}
+
+// fetchJiraTicket reads an issue's summary from the Jira REST API. Jira Cloud
+// uses JIRA_EMAIL and JIRA_API_TOKEN; without an email the token is sent as a
+// bearer token (personal access tokens on Jira Server/Data Center).
+func fetchJiraTicket(client *http.Client, site, id string) (Ticket, bool, error) {
+	site = strings.TrimRight(site, "/")
+	req, err := http.NewRequest("GET", site+"/rest/api/2/issue/"+id+"?fields=summary", nil)
+	if err != nil {
+		return Ticket{}, false, err
+	}
+	token := os.Getenv("JIRA_API_TOKEN")
+	if email := os.Getenv("JIRA_EMAIL"); email != "" {
+		req.SetBasicAuth(email, token)
+	} else if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Ticket{}, false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return Ticket{}, false, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return Ticket{}, false, fmt.Errorf("jira API error: %s (set JIRA_EMAIL and JIRA_API_TOKEN)", resp.Status)
+	default:
+		return Ticket{}, false, fmt.Errorf("jira API error: %s", resp.Status)
+	}
+
+	var issue struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Summary string `json:"summary"`
+		} `json:"fields"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return Ticket{}, false, fmt.Errorf("failed to decode jira response: %w", err)
+	}
+	return Ticket{ID: id, Title: issue.Fields.Summary, URL: site + "/browse/" + issue.Key}, true, nil
+}
+
+// fetchLinearTicket reads an issue by its identifier from Linear's GraphQL API
+// with LINEAR_API_KEY
+func fetchLinearTicket(client *http.Client, id string) (Ticket, bool, error) {
+	key := os.Getenv("LINEAR_API_KEY")
+	if key == "" {
+		return Ticket{}, false, fmt.Errorf("set LINEAR_API_KEY to look up Linear tickets")
+	}
+	body, _ := json.Marshal(map[string]any{
+		"query":     `query($id: String!) { issue(id: $id) { identifier title url } }`,
+		"variables": map[string]string{"id": id},
+	})
+	req, err := http.NewRequest("POST", linearAPIURL, bytes.NewReader(body))
+	if err != nil {
+		return Ticket{}, false, err
+	}
+	req.Header.Set("Authorization", key)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Ticket{}, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return Ticket{}, false, fmt.Errorf("linear API error: %s (check LINEAR_API_KEY)", resp.Status)
+	}
+
+	var result struct {
+		Data struct {
+			Issue *struct {
+				Identifier string `json:"identifier"`
+				Title      string `json:"title"`
+				URL        string `json:"url"`
+			} `json:"issue"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Ticket{}, false, fmt.Errorf("failed to decode linear response: %w", err)
+	}
+	// Unknown identifiers come back as a GraphQL error without an issue
+	if result.Data.Issue == nil {
+		return Ticket{}, false, nil
+	}
+	issue := result.Data.Issue
+	return Ticket{ID: id, Title: issue.Title, URL: issue.URL}, true, nil
+}
+
+// addTickets links the resolved ticket IDs in release notes and lists the
+// tickets with their titles at the end. Without links, only the list is added.
+func addTickets(notes string, tickets map[string]Ticket, links bool) string {
+	if len(tickets) == 0 {
+		return notes
+	}
+
+	var ids []string
+	if links {
+		notes = ticketIDRe.ReplaceAllStringFunc(notes, func(id string) string {
+			if ticket, ok := tickets[id]; ok {
+				return fmt.Sprintf("[%s](%s)", id, ticket.URL)
+			}
+			return id
+		})
+	}
+	for _, id := range ticketIDRe.FindAllString(notes, -1) {
+		if _, ok := tickets[id]; ok && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(notes, "\n"))
+	b.WriteString("\n\n### Tickets\n\n")
+	for _, id := range ids {
+		ticket := tickets[id]
+		if links {
+			fmt.Fprintf(&b, "- [%s](%s) %s\n", id, ticket.URL, ticket.Title)
+		} else {
+			fmt.Fprintf(&b, "- %s %s\n", id, ticket.Title)
+		}
+	}
+	return b.String()
+}
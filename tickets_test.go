@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFindTicketIDs(t *testing.T) {
+	text := "- login: fix ENG-12 and OPS-3 (#4)\n- use UTF-8 everywhere ENG-12"
+	if got := FindTicketIDs(text, nil); strings.Join(got, " ") != "ENG-12 OPS-3 UTF-8" {
+		t.Errorf("Unexpected IDs %v", got)
+	}
+	if got := FindTicketIDs(text, []string{"ENG"}); strings.Join(got, " ") != "ENG-12" {
+		t.Errorf("Expected only ENG tickets, got %v", got)
+	}
+}
+
+func TestResolveTicketsJira(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "me@corp.com" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/rest/api/2/issue/ENG-12" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"key": "ENG-12", "fields": {"summary": "Login fails on Safari"}}`)
+	}))
+	defer server.Close()
+
+	cfg := TicketsConfig{Provider: ticketsJira, URL: server.URL + "/"}
+	t.Setenv("JIRA_EMAIL", "me@corp.com")
+	t.Setenv("JIRA_API_TOKEN", "wrong")
+	if _, err := ResolveTickets([]string{"ENG-12"}, cfg); err == nil || !strings.Contains(err.Error(), "JIRA_API_TOKEN") {
+		t.Errorf("Expected an auth error naming the variables, got %v", err)
+	}
+
+	t.Setenv("JIRA_API_TOKEN", "secret")
+	tickets, err := ResolveTickets([]string{"ENG-12", "UTF-8"}, cfg)
+	if err != nil {
+		t.Fatalf("ResolveTickets failed: %v", err)
+	}
+	want := Ticket{ID: "ENG-12", Title: "Login fails on Safari", URL: server.URL + "/browse/ENG-12"}
+	if len(tickets) != 1 || tickets["ENG-12"] != want {
+		t.Errorf("Unexpected tickets %+v", tickets)
+	}
+}
+
+func TestResolveTicketsLinear(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "lin_api_key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Variables map[string]string `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Variables["id"] != "ENG-7" {
+			fmt.Fprint(w, `{"data": {"issue": null}, "errors": [{"message": "Entity not found"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"issue": {"identifier": "ENG-7", "title": "Dark mode", "url": "https://linear.app/corp/issue/ENG-7/dark-mode"}}}`)
+	}))
+	defer server.Close()
+	defer func(url string) { linearAPIURL = url }(linearAPIURL)
+	linearAPIURL = server.URL
+
+	cfg := TicketsConfig{Provider: ticketsLinear}
+	t.Setenv("LINEAR_API_KEY", "")
+	if _, err := ResolveTickets([]string{"ENG-7"}, cfg); err == nil {
+		t.Error("Expected an error without LINEAR_API_KEY")
+	}
+
+	t.Setenv("LINEAR_API_KEY", "lin_api_key")
+	tickets, err := ResolveTickets([]string{"ENG-7", "ENG-8"}, cfg)
+	if err != nil {
+		t.Fatalf("ResolveTickets failed: %v", err)
+	}
+	if len(tickets) != 1 || tickets["ENG-7"].Title != "Dark mode" {
+		t.Errorf("Unexpected tickets %+v", tickets)
+	}
+}
+
+func TestAddTickets(t *testing.T) {
+	tickets := map[string]Ticket{"ENG-12": {ID: "ENG-12", Title: "Login fails on Safari", URL: "https://jira/browse/ENG-12"}}
+	notes := "### Bug Fixes\n\n- login: fix ENG-12 in UTF-8 (abc1234)\n"
+
+	want := "### Bug Fixes\n\n- login: fix [ENG-12](https://jira/browse/ENG-12) in UTF-8 (abc1234)\n\n" +
+		"### Tickets\n\n- [ENG-12](https://jira/browse/ENG-12) Login fails on Safari\n"
+	if got := addTickets(notes, tickets, true); got != want {
+		t.Errorf("Unexpected notes:\n%s", got)
+	}
+
+	want = "### Bug Fixes\n\n- login: fix ENG-12 in UTF-8 (abc1234)\n\n### Tickets\n\n- ENG-12 Login fails on Safari\n"
+	if got := addTickets(notes, tickets, false); got != want {
+		t.Errorf("Unexpected notes without links:\n%s", got)
+	}
+	if got := addTickets(notes, nil, true); got != notes {
+		t.Errorf("Expected unchanged notes without tickets, got:\n%s", got)
+	}
+}
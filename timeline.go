@@ -0,0 +1,517 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// FileChange is one commit in the history of a single file
+type FileChange struct {
+	Hash         string
+	ShortHash    string
+	Author       string
+	Date         time.Time
+	RelativeTime string
+	Subject      string
+	Stat         FileDiffStat // The file's path in this commit, and its line counts
+	Deleted      bool         // The commit removed the file
+}
+
+// RepoRelativePath turns a path given on the command line into one relative
+// to the repository root, the form git show <rev>:<path> expects
+func RepoRelativePath(file string) (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--show-prefix").Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository")
+	}
+	rel := path.Clean(strings.TrimSpace(string(output)) + filepath.ToSlash(file))
+	if rel == "." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("%s is not a file in this repository", file)
+	}
+	return rel, nil
+}
+
+// GetFileTimeline returns the commits that changed a file, newest first,
+// following it across renames
+func GetFileTimeline(file string) ([]FileChange, error) {
+	rel, err := RepoRelativePath(file)
+	if err != nil {
+		return nil, err
+	}
+	output, err := exec.Command("git", "log", "--follow", "--numstat", "-z", "--diff-merges=first-parent",
+		"--format=%x1e%H%x1f%h%x1f%an%x1f%aI%x1f%ar%x1f%s", "--", ":(top)"+rel).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the history of %s: %w", file, err)
+	}
+	changes := parseFileTimeline(string(output))
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("%s has no commits", file)
+	}
+	for i := range changes {
+		changes[i].Deleted = !fileExistsAt(changes[i].Hash, changes[i].Stat.Path)
+	}
+	return changes, nil
+}
+
+// parseFileTimeline parses git log --numstat -z output where each commit
+// starts with \x1e and \x1f-separated fields, followed by the file's numstat
+func parseFileTimeline(output string) []FileChange {
+	var changes []FileChange
+	for _, record := range strings.Split(output, "\x1e") {
+		header, numstat, _ := strings.Cut(record, "\x00")
+		fields := strings.Split(header, "\x1f")
+		if len(fields) != 6 {
+			continue
+		}
+		change := FileChange{
+			Hash:         fields[0],
+			ShortHash:    fields[1],
+			Author:       fields[2],
+			RelativeTime: fields[4],
+			Subject:      fields[5],
+		}
+		change.Date, _ = time.Parse(time.RFC3339, fields[3])
+		if stats := parseNumstatZ(strings.TrimLeft(numstat, "\n")); len(stats) > 0 {
+			change.Stat = stats[0]
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// fileExistsAt reports whether a commit has the file; path is relative to
+// the repository root
+func fileExistsAt(hash, file string) bool {
+	return exec.Command("git", "cat-file", "-e", hash+":"+file).Run() == nil
+}
+
+// GetFileAtCommit returns a file as it was in a commit; path is relative to
+// the repository root
+func GetFileAtCommit(hash, file string) (string, error) {
+	output, err := exec.Command("git", "show", hash+":"+file).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s isn't in %s", file, shortHash(hash))
+	}
+	return string(output), nil
+}
+
+// RestoreFileVersion overwrites dest in the working tree with the file as it
+// was in a commit, where it may have had another name. The change is left
+// for snap save to commit; both paths are relative to the repository root.
+func RestoreFileVersion(hash, file, dest string) error {
+	content, err := GetFileAtCommit(hash, file)
+	if err != nil {
+		return err
+	}
+	root, err := GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(root, filepath.FromSlash(dest))
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(target); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, []byte(content), mode)
+}
+
+// changeCounts shows a change's lines as "+3 -1", or "binary"
+func changeCounts(stat FileDiffStat) string {
+	if stat.Binary {
+		return "binary"
+	}
+	return fmt.Sprintf("+%d -%d", stat.Additions, stat.Deletions)
+}
+
+// printTimelinePlain prints one line per change, for pipes and scripts
+func printTimelinePlain(file string) error {
+	changes, err := GetFileTimeline(file)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		line := fmt.Sprintf("%s  %s  %-10s  %s  %s", change.ShortHash, change.Date.Format("2006-01-02"), changeCounts(change.Stat), change.Author, change.Subject)
+		if change.Stat.OrigPath != "" {
+			line += fmt.Sprintf(" (renamed from %s)", change.Stat.OrigPath)
+		}
+		if change.Deleted {
+			line += " (deleted)"
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// Timeline TUI model
+type timelineState int
+
+const (
+	timelineStateLoading timelineState = iota
+	timelineStateList
+	timelineStatePreview
+	timelineStateConfirmRestore
+	timelineStateDone
+	timelineStateError
+)
+
+type timelineModel struct {
+	state    timelineState
+	file     string // As given on the command line
+	current  string // The file's path today, relative to the repository root
+	changes  []FileChange
+	cursor   int
+	spinner  spinner.Model
+	viewport viewport.Model // The file's content at the selected commit
+	back     timelineState  // Where esc goes from the restore prompt
+	notice   string
+	restored *FileChange
+	err      error
+	width    int
+	height   int
+	ready    bool
+}
+
+type getTimelineMsg struct {
+	current string
+	changes []FileChange
+	err     error
+}
+
+type fileAtCommitMsg struct {
+	hash    string
+	content string
+	err     error
+}
+
+type restoreFileMsg struct {
+	err error
+}
+
+func initialTimelineModel(file string) timelineModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
+
+	return timelineModel{
+		state:   timelineStateLoading,
+		file:    file,
+		spinner: s,
+		width:   80,
+		height:  24,
+	}
+}
+
+func (m timelineModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, getTimelineCmd(m.file))
+}
+
+func getTimelineCmd(file string) tea.Cmd {
+	return func() tea.Msg {
+		current, err := RepoRelativePath(file)
+		if err != nil {
+			return getTimelineMsg{err: err}
+		}
+		changes, err := GetFileTimeline(file)
+		return getTimelineMsg{current: current, changes: changes, err: err}
+	}
+}
+
+func fileAtCommitCmd(change FileChange) tea.Cmd {
+	return func() tea.Msg {
+		content, err := GetFileAtCommit(change.Hash, change.Stat.Path)
+		return fileAtCommitMsg{hash: change.Hash, content: content, err: err}
+	}
+}
+
+func restoreFileCmd(change FileChange, dest string) tea.Cmd {
+	return func() tea.Msg {
+		return restoreFileMsg{err: RestoreFileVersion(change.Hash, change.Stat.Path, dest)}
+	}
+}
+
+func (m timelineModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		if !m.ready {
+			m.viewport = viewport.New(msg.Width-4, msg.Height-5) // Leave space for header and footer
+			m.ready = true
+		} else {
+			m.viewport.Width = msg.Width - 4
+			m.viewport.Height = msg.Height - 5
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch m.state {
+		case timelineStateList:
+			switch msg.String() {
+			case "ctrl+c", "q", "esc":
+				return m, tea.Quit
+			case "up", "k":
+				if m.cursor > 0 {
+					m.cursor--
+				}
+			case "down", "j":
+				if m.cursor < len(m.changes)-1 {
+					m.cursor++
+				}
+			case "g":
+				m.cursor = 0
+			case "G":
+				m.cursor = len(m.changes) - 1
+			case "enter", "p":
+				return m.preview()
+			case "r":
+				return m.confirmRestore()
+			}
+
+		case timelineStatePreview:
+			switch msg.String() {
+			case "ctrl+c", "q":
+				return m, tea.Quit
+			case "esc":
+				m.state = timelineStateList
+				m.notice = ""
+			case "n":
+				// Older version
+				if m.cursor < len(m.changes)-1 {
+					m.cursor++
+					return m.preview()
+				}
+			case "N":
+				// Newer version
+				if m.cursor > 0 {
+					m.cursor--
+					return m.preview()
+				}
+			case "r":
+				return m.confirmRestore()
+			case "g":
+				m.viewport.GotoTop()
+			case "G":
+				m.viewport.GotoBottom()
+			default:
+				var cmd tea.Cmd
+				m.viewport, cmd = m.viewport.Update(msg)
+				return m, cmd
+			}
+
+		case timelineStateConfirmRestore:
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "y", "Y":
+				return m, restoreFileCmd(m.changes[m.cursor], m.current)
+			case "n", "N", "esc", "q":
+				m.state = m.back
+			}
+
+		default:
+			if msg.String() == "ctrl+c" || msg.String() == "q" {
+				return m, tea.Quit
+			}
+		}
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case getTimelineMsg:
+		if msg.err != nil {
+			m.state = timelineStateError
+			m.err = msg.err
+			return m, tea.Quit
+		}
+		m.current = msg.current
+		m.changes = msg.changes
+		m.state = timelineStateList
+		return m, nil
+
+	case fileAtCommitMsg:
+		if msg.hash != m.changes[m.cursor].Hash {
+			// Left over from a version paged past
+			return m, nil
+		}
+		content := msg.content
+		if msg.err != nil {
+			content = errorStyle.Render(fmt.Sprintf("✗ %v", msg.err))
+		}
+		m.viewport.SetContent(content)
+		m.viewport.GotoTop()
+		return m, nil
+
+	case restoreFileMsg:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Failed to restore: %v", msg.err)
+			m.state = m.back
+			return m, nil
+		}
+		change := m.changes[m.cursor]
+		m.restored = &change
+		m.state = timelineStateDone
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+// preview shows the file as it was at the selected commit
+func (m timelineModel) preview() (tea.Model, tea.Cmd) {
+	change := m.changes[m.cursor]
+	m.state = timelineStatePreview
+	m.notice = ""
+	if change.Deleted {
+		m.viewport.SetContent(infoStyle.Render("The file was deleted in this commit"))
+		return m, nil
+	}
+	if change.Stat.Binary {
+		m.viewport.SetContent(infoStyle.Render("Binary file - no preview"))
+		return m, nil
+	}
+	m.viewport.SetContent(infoStyle.Render("Loading..."))
+	return m, fileAtCommitCmd(change)
+}
+
+// confirmRestore asks before the working copy is overwritten
+func (m timelineModel) confirmRestore() (tea.Model, tea.Cmd) {
+	if m.changes[m.cursor].Deleted {
+		m.notice = "The file was deleted in this commit - pick an older version to restore"
+		return m, nil
+	}
+	m.back = m.state
+	m.notice = ""
+	m.state = timelineStateConfirmRestore
+	return m, nil
+}
+
+func (m timelineModel) View() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorPrimary).
+		PaddingLeft(2)
+	helpStyle := lipgloss.NewStyle().
+		Foreground(colorMuted).
+		PaddingLeft(2)
+
+	switch m.state {
+	case timelineStateLoading:
+		return fmt.Sprintf("%s Loading the history of %s...", m.spinner.View(), m.file)
+
+	case timelineStateList:
+		hashStyle := lipgloss.NewStyle().Foreground(colorWarning)
+		dimStyle := lipgloss.NewStyle().Foreground(colorMuted)
+		textStyle := lipgloss.NewStyle().Foreground(colorText)
+		cursorStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+		addStyle := lipgloss.NewStyle().Foreground(colorSuccess)
+		delStyle := lipgloss.NewStyle().Foreground(colorDanger)
+
+		var s strings.Builder
+		s.WriteString(titleStyle.Render(m.current))
+		s.WriteString(dimStyle.Render(fmt.Sprintf("  %d changes", len(m.changes))))
+		s.WriteString("\n\n")
+
+		// Keep the cursor on screen
+		rows := max(m.height-6, 1)
+		start := 0
+		if m.cursor >= rows {
+			start = m.cursor - rows + 1
+		}
+		end := min(start+rows, len(m.changes))
+		for i := start; i < end; i++ {
+			change := m.changes[i]
+			cursor := "  "
+			subject := textStyle.Render(change.Subject)
+			if i == m.cursor {
+				cursor = cursorStyle.Render("→ ")
+				subject = cursorStyle.Render(change.Subject)
+			}
+			counts := dimStyle.Render("binary")
+			if !change.Stat.Binary {
+				counts = addStyle.Render(fmt.Sprintf("+%d", change.Stat.Additions)) + " " + delStyle.Render(fmt.Sprintf("-%d", change.Stat.Deletions))
+			}
+			line := fmt.Sprintf("  %s%s  %s  %s  %s %s",
+				cursor,
+				hashStyle.Render(change.ShortHash),
+				dimStyle.Render(fmt.Sprintf("%-10s", change.Date.Format("2006-01-02"))),
+				counts,
+				subject,
+				dimStyle.Render("· "+change.Author+", "+change.RelativeTime),
+			)
+			if change.Stat.OrigPath != "" {
+				line += dimStyle.Render(fmt.Sprintf(" (renamed from %s)", change.Stat.OrigPath))
+			}
+			if change.Deleted {
+				line += delStyle.Render(" (deleted)")
+			}
+			s.WriteString(line + "\n")
+		}
+		s.WriteString("\n")
+		if m.notice != "" {
+			s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(errorStyle.Render(m.notice)) + "\n")
+		}
+		s.WriteString(helpStyle.Render("↑/↓: move  enter: preview  r: restore this version  q: quit"))
+		return s.String()
+
+	case timelineStatePreview:
+		change := m.changes[m.cursor]
+		var s strings.Builder
+		s.WriteString(titleStyle.Render(change.Stat.Path))
+		s.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render(fmt.Sprintf("  at %s · %s  (%d/%d)", change.ShortHash, change.Subject, m.cursor+1, len(m.changes))))
+		s.WriteString("\n\n")
+		s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(m.viewport.View()))
+		s.WriteString("\n")
+		if m.notice != "" {
+			s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(errorStyle.Render(m.notice)) + "\n")
+		}
+		s.WriteString(helpStyle.Render(fmt.Sprintf("%3.f%%  ↑/↓: scroll  n/N: older/newer version  r: restore  esc: back  q: quit",
+			m.viewport.ScrollPercent()*100)))
+		return s.String()
+
+	case timelineStateConfirmRestore:
+		change := m.changes[m.cursor]
+		var s strings.Builder
+		s.WriteString("\n")
+		s.WriteString(titleStyle.Render(fmt.Sprintf("Restore %s as of %s?", m.current, change.ShortHash)))
+		s.WriteString("\n\n")
+		s.WriteString(helpStyle.Render(change.Subject + " · " + change.Author + ", " + change.RelativeTime))
+		s.WriteString("\n")
+		s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Foreground(colorWarning).Render("Uncommitted changes to the file are overwritten; snap save commits the restored version."))
+		s.WriteString("\n\n")
+		s.WriteString(lipgloss.NewStyle().PaddingLeft(2).Render(highlightStyle.Render("(y)es, (n)o:")))
+		return s.String()
+
+	case timelineStateDone:
+		return ""
+
+	case timelineStateError:
+		return errorStyle.Render(fmt.Sprintf("✗ Error: %v", m.err))
+	}
+	return ""
+}
+
+// ExitSummary keeps the restore visible after the alt screen closes
+func (m timelineModel) ExitSummary() string {
+	switch m.state {
+	case timelineStateDone:
+		return fmt.Sprintf("✓ Restored %s as of %s - snap save to commit it", m.current, m.restored.ShortHash)
+	case timelineStateError:
+		return fmt.Sprintf("✗ Error: %v", m.err)
+	}
+	return ""
+}
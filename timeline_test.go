@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestGetFileTimeline(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	os.WriteFile("notes.txt", []byte("one\ntwo\nthree\nfour\n"), 0644)
+	exec.Command("git", "add", "-A").Run()
+	exec.Command("git", "commit", "-q", "-m", "add notes").Run()
+	exec.Command("git", "mv", "notes.txt", "todo.txt").Run()
+	os.WriteFile("todo.txt", []byte("one\ntwo\nthree\nfour\nfive\nsix\n"), 0644)
+	exec.Command("git", "commit", "-q", "-am", "rename notes").Run()
+	exec.Command("git", "rm", "-q", "todo.txt").Run()
+	exec.Command("git", "commit", "-q", "-m", "drop todo").Run()
+
+	changes, err := GetFileTimeline("todo.txt")
+	if err != nil {
+		t.Fatalf("GetFileTimeline failed: %v", err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("Expected 3 changes across the rename, got %+v", changes)
+	}
+	if changes[0].Subject != "drop todo" || !changes[0].Deleted || changes[0].Stat.Deletions != 6 {
+		t.Errorf("Expected the delete first, got %+v", changes[0])
+	}
+	if changes[1].Stat.OrigPath != "notes.txt" || changes[1].Stat.Path != "todo.txt" || changes[1].Stat.Additions != 2 {
+		t.Errorf("Expected the rename with +2, got %+v", changes[1].Stat)
+	}
+	if changes[2].Stat.Path != "notes.txt" || changes[2].Deleted || changes[2].Date.IsZero() {
+		t.Errorf("Expected the first version under its old name, got %+v", changes[2])
+	}
+
+	if _, err := GetFileTimeline("missing.txt"); err == nil {
+		t.Error("Expected an error for a file without history")
+	}
+}
+
+func TestRestoreFileVersion(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	os.WriteFile("notes.txt", []byte("first\n"), 0644)
+	exec.Command("git", "add", "-A").Run()
+	exec.Command("git", "commit", "-q", "-m", "add notes").Run()
+	first, _ := ResolveCommit("HEAD")
+	os.WriteFile("notes.txt", []byte("second\n"), 0644)
+	exec.Command("git", "commit", "-q", "-am", "update notes").Run()
+
+	if content, err := GetFileAtCommit(first, "notes.txt"); err != nil || content != "first\n" {
+		t.Fatalf("Expected the first version, got %q (%v)", content, err)
+	}
+	if err := RestoreFileVersion(first, "notes.txt", "notes.txt"); err != nil {
+		t.Fatalf("RestoreFileVersion failed: %v", err)
+	}
+	if content, _ := os.ReadFile("notes.txt"); string(content) != "first\n" {
+		t.Errorf("Expected the working copy restored, got %q", content)
+	}
+	// Restored, not committed
+	if head, _ := GetFileAtCommit("HEAD", "notes.txt"); head != "second\n" {
+		t.Errorf("Expected HEAD untouched, got %q", head)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	trailerRe    = regexp.MustCompile(`^[A-Za-z0-9-]+: `)
+	trailerKeyRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+)
+
+// ticketPlaceholder in a save.trailers value stands for the ticket ID in the
+// branch name
+const ticketPlaceholder = "{ticket}"
+
+// AddTrailers appends "Key: value" trailer lines the message doesn't have
+// yet. They join an existing trailer block, like Signed-off-by lines do;
+// otherwise they start a new paragraph.
+func AddTrailers(message string, trailers []string) string {
+	message = strings.TrimRight(message, "\n")
+	lines := strings.Split(message, "\n")
+	var missing []string
+	for _, trailer := range trailers {
+		if !slices.Contains(lines, trailer) && !slices.Contains(missing, trailer) {
+			missing = append(missing, trailer)
+		}
+	}
+	if len(missing) == 0 {
+		return message
+	}
+
+	separator := "\n\n"
+	if hasTrailerBlock(message) {
+		separator = "\n"
+	}
+	return message + separator + strings.Join(missing, "\n")
+}
+
+// hasTrailerBlock reports whether the message ends in a paragraph of
+// "Key: value" trailers. The subject never counts, since "fix: typo" looks
+// like one.
+func hasTrailerBlock(message string) bool {
+	i := strings.LastIndex(message, "\n\n")
+	if i < 0 {
+		return false
+	}
+	for _, line := range strings.Split(message[i+2:], "\n") {
+		if !trailerRe.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// SaveTrailers returns the trailers snap save adds to every commit: the
+// save.trailers sorted by key, then Signed-off-by with save.signoff. A
+// trailer using {ticket} is left out when the branch names no ticket.
+func SaveTrailers(cfg SaveConfig, tickets TicketsConfig, branch string) ([]string, error) {
+	keys := make([]string, 0, len(cfg.Trailers))
+	for key := range cfg.Trailers {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	ticket := ""
+	// Branches are often lowercase, e.g. eng-123-login
+	if ids := FindTicketIDs(strings.ToUpper(branch), tickets.Keys); len(ids) > 0 {
+		ticket = ids[0]
+	}
+
+	var trailers []string
+	for _, key := range keys {
+		value := cfg.Trailers[key]
+		if strings.Contains(value, ticketPlaceholder) {
+			if ticket == "" {
+				continue
+			}
+			value = strings.ReplaceAll(value, ticketPlaceholder, ticket)
+		}
+		trailers = append(trailers, key+": "+value)
+	}
+
+	if cfg.Signoff {
+		ident, err := committerIdent()
+		if err != nil {
+			return nil, err
+		}
+		trailers = append(trailers, "Signed-off-by: "+ident)
+	}
+	return trailers, nil
+}
+
+// committerIdent is "Name <email>" of whoever commits, as git commit
+// --signoff would write it
+func committerIdent() (string, error) {
+	output, err := exec.Command("git", "var", "GIT_COMMITTER_IDENT").Output()
+	if err != nil {
+		return "", fmt.Errorf("signing off needs user.name and user.email - set them with git config")
+	}
+	ident := strings.TrimSpace(string(output))
+	if i := strings.LastIndex(ident, ">"); i >= 0 {
+		ident = ident[:i+1]
+	}
+	return ident, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAddTrailers(t *testing.T) {
+	trailers := []string{"Ticket: ENG-1", "Signed-off-by: Test User <test@example.com>"}
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"subject only", "fix: typo", "fix: typo\n\nTicket: ENG-1\nSigned-off-by: Test User <test@example.com>"},
+		{"joins trailers", "fix: typo\n\nCo-authored-by: Bob <bob@example.com>", "fix: typo\n\nCo-authored-by: Bob <bob@example.com>\nTicket: ENG-1\nSigned-off-by: Test User <test@example.com>"},
+		{"already there", "fix: typo\n\nTicket: ENG-1\n", "fix: typo\n\nTicket: ENG-1\nSigned-off-by: Test User <test@example.com>"},
+	}
+	for _, tt := range tests {
+		if got := AddTrailers(tt.message, trailers); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSaveTrailers(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	cfg := SaveConfig{
+		Signoff:  true,
+		Trailers: map[string]string{"Ticket": "{ticket}", "Reviewed-by": "Jane Doe <jane@example.com>"},
+	}
+	trailers, err := SaveTrailers(cfg, TicketsConfig{}, "feature/eng-42-login")
+	if err != nil {
+		t.Fatalf("SaveTrailers failed: %v", err)
+	}
+	want := []string{"Reviewed-by: Jane Doe <jane@example.com>", "Ticket: ENG-42", "Signed-off-by: Test User <test@example.com>"}
+	if !slices.Equal(trailers, want) {
+		t.Errorf("Expected %v, got %v", want, trailers)
+	}
+
+	// Without a ticket in the branch name, the ticket trailer is left out
+	trailers, _ = SaveTrailers(cfg, TicketsConfig{}, "main")
+	if len(trailers) != 2 || trailers[1] != "Signed-off-by: Test User <test@example.com>" {
+		t.Errorf("Expected no ticket trailer, got %v", trailers)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tuiGuard holds the first panic caught in a TUI
+type tuiGuard struct {
+	program *tea.Program
+	value   any
+	stack   []byte
+}
+
+// caught records a panic; later ones are usually fallout from the first
+func (g *tuiGuard) caught(r any) {
+	if g.value == nil {
+		g.value = r
+		g.stack = debug.Stack()
+	}
+}
+
+// wrap makes a command quit the program instead of crashing it. The commands
+// of a batch are wrapped as they come out.
+func (g *tuiGuard) wrap(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() (msg tea.Msg) {
+		defer func() {
+			if r := recover(); r != nil {
+				g.caught(r)
+				msg = tea.QuitMsg{}
+			}
+		}()
+		msg = cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for i := range batch {
+				batch[i] = g.wrap(batch[i])
+			}
+		}
+		return msg
+	}
+}
+
+// safeModel catches panics in a model and its commands and quits, so Bubble
+// Tea shuts down normally and leaves the terminal usable
+type safeModel struct {
+	model tea.Model
+	guard *tuiGuard
+}
+
+func (m safeModel) Init() (cmd tea.Cmd) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.guard.caught(r)
+			cmd = tea.Quit
+		}
+	}()
+	return m.guard.wrap(m.model.Init())
+}
+
+func (m safeModel) Update(msg tea.Msg) (model tea.Model, cmd tea.Cmd) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.guard.caught(r)
+			model, cmd = m, tea.Quit
+		}
+	}()
+	next, cmd := m.model.Update(msg)
+	return safeModel{model: next, guard: m.guard}, m.guard.wrap(cmd)
+}
+
+func (m safeModel) View() (view string) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.guard.caught(r)
+			view = ""
+			// View can't return a command, and Quit must not block the renderer
+			go m.guard.program.Quit()
+		}
+	}()
+	return m.model.View()
+}
+
+// exitSummary is implemented by models whose outcome should stay in the
+// scrollback after an alt-screen program clears its last view
+type exitSummary interface {
+	// ExitSummary is one line, or empty when nothing happened
+	ExitSummary() string
+}
+
+// runProgram runs a model with panics caught. It returns the final model and
+// the guard, whose value is set if the model panicked.
+func runProgram(model tea.Model, opts ...tea.ProgramOption) (tea.Model, *tuiGuard, error) {
+	guard := &tuiGuard{}
+	guard.program = tea.NewProgram(safeModel{model: model, guard: guard}, opts...)
+	final, err := guard.program.Run()
+	if m, ok := final.(safeModel); ok {
+		final = m.model
+	}
+	return final, guard, err
+}
+
+// runTUI runs a command's TUI and prints its exit summary, if any. If it
+// panics, the terminal is restored, the stack trace goes to the crash log and
+// snap exits with plainHint, the non-interactive form of the command, if it
+// has one.
+func runTUI(model tea.Model, plainHint string, opts ...tea.ProgramOption) (tea.Model, error) {
+	final, guard, err := runProgram(model, opts...)
+	if guard.value == nil && !errors.Is(err, tea.ErrProgramPanic) {
+		if s, ok := final.(exitSummary); ok && err == nil {
+			if line := s.ExitSummary(); line != "" {
+				fmt.Println(line)
+			}
+		}
+		return final, err
+	}
+
+	if guard.value != nil {
+		fmt.Fprintf(os.Stderr, "Fatal error in interactive mode: %v\n", guard.value)
+		if path, err := writeCrashLog(guard.value, guard.stack); err == nil {
+			fmt.Fprintf(os.Stderr, "The stack trace is in %s - please include it when reporting the bug\n", path)
+		}
+	} else {
+		// Bubble Tea caught it first and printed the stack trace itself
+		fmt.Fprintf(os.Stderr, "Fatal error in interactive mode: %v\n", err)
+	}
+	if plainHint != "" {
+		fmt.Fprintf(os.Stderr, "Tip: Use '%s' for non-interactive mode\n", plainHint)
+	}
+	os.Exit(1)
+	return nil, nil
+}
+
+// crashLogPath returns the file the last crash is written to, honoring
+// $XDG_STATE_HOME
+func crashLogPath() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "snap", "crash.log"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "snap", "crash.log"), nil
+}
+
+// writeCrashLog replaces the crash log with a panic and its stack trace
+func writeCrashLog(value any, stack []byte) (string, error) {
+	path, err := crashLogPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	content := fmt.Sprintf("snap %s crashed at %s\ncommand: %s\npanic: %v\n\n%s",
+		version, time.Now().Format(time.RFC3339), strings.Join(os.Args, " "), value, stack)
+	return path, os.WriteFile(path, []byte(content), 0644)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type boomMsg struct{}
+
+// panicModel panics where its field says, once the program is running
+type panicModel struct {
+	in string
+}
+
+func (m panicModel) Init() tea.Cmd {
+	return func() tea.Msg {
+		if m.in == "cmd" {
+			panic("boom in cmd")
+		}
+		return boomMsg{}
+	}
+}
+
+func (m panicModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(boomMsg); ok {
+		if m.in == "update" {
+			panic("boom in update")
+		}
+		return m, tea.Batch(nil, func() tea.Msg { panic("boom in batch") })
+	}
+	return m, nil
+}
+
+func (m panicModel) View() string {
+	return "view"
+}
+
+func TestRunProgramRecoversPanics(t *testing.T) {
+	for _, in := range []string{"update", "cmd", "batch"} {
+		final, guard, err := runProgram(panicModel{in: in}, tea.WithInput(strings.NewReader("")), tea.WithOutput(io.Discard))
+		if err != nil {
+			t.Fatalf("%s: expected the program to quit normally, got %v", in, err)
+		}
+		if guard.value != "boom in "+in {
+			t.Errorf("%s: expected the panic to be caught, got %v", in, guard.value)
+		}
+		if !strings.Contains(string(guard.stack), "tui_test.go") {
+			t.Errorf("%s: expected the stack trace to reach the test model", in)
+		}
+		if _, ok := final.(panicModel); !ok {
+			t.Errorf("%s: expected the model to be unwrapped, got %T", in, final)
+		}
+	}
+}
+
+func TestWriteCrashLog(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	path, err := writeCrashLog("boom", []byte("goroutine 1 [running]:\n"))
+	if err != nil {
+		t.Fatalf("writeCrashLog failed: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "panic: boom\n\ngoroutine 1 [running]:") {
+		t.Errorf("Expected the panic and stack trace, got %q", content)
+	}
+}
+
+func TestExitSummary(t *testing.T) {
+	tests := []struct {
+		model exitSummary
+		want  string
+	}{
+		{replayModel{state: replayStateDone, ontoBranch: "main", commits: make([]CommitInfo, 2), stacked: []string{"part-2"}}, "✓ Replayed 2 commit(s) onto main, moving part-2"},
+		{replayModel{state: replayStateDone, err: fmt.Errorf("replay cancelled")}, ""},
+		{replayModel{state: replayStateConflict, ontoBranch: "main"}, "✗ Replay onto main stopped at a conflict - resolve it, then snap save"},
+		{branchModel{state: branchStateDone, mode: "list", branchName: "feature"}, "✓ Switched to branch feature"},
+		{branchModel{state: branchStateList}, ""},
+		{tagsCreateModel{state: tagsCreateStateDone, newTag: "v1.2.0"}, "✓ Created and pushed tag v1.2.0"},
+		{tagsEditModel{state: tagsEditStateDone, tagName: "v1.2.0", pushed: true}, "✓ Updated and force-pushed tag v1.2.0"},
+	}
+	for _, tt := range tests {
+		if got := tt.model.ExitSummary(); got != tt.want {
+			t.Errorf("%T.ExitSummary() = %q, want %q", tt.model, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UsageRecord is one request to a hosted AI provider
+type UsageRecord struct {
+	Time         time.Time `json:"time"`
+	Provider     string    `json:"provider"`
+	Model        string    `json:"model"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+}
+
+// defaultPrices are USD per million input and output tokens for the default
+// hosted models, as published when they were added; [prices] overrides them
+var defaultPrices = map[string][]float64{
+	"gpt-4o-mini":       {0.15, 0.60},
+	"gpt-4o":            {2.50, 10.00},
+	"claude-haiku-4-5":  {1.00, 5.00},
+	"claude-sonnet-4-5": {3.00, 15.00},
+}
+
+// usageMu keeps parallel candidate requests from interleaving their lines
+var usageMu sync.Mutex
+
+// usagePath returns the usage log, honoring $XDG_STATE_HOME
+func usagePath() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "snap", "usage"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "snap", "usage"), nil
+}
+
+// RecordUsage appends a request's token counts to the usage log when ai.usage
+// is on. Failures are ignored; the log is only for keeping an eye on spend.
+func RecordUsage(provider, model string, inputTokens, outputTokens int) {
+	if !config.AI.Usage {
+		return
+	}
+	path, err := usagePath()
+	if err != nil {
+		return
+	}
+	line, err := json.Marshal(UsageRecord{
+		Time:         time.Now(),
+		Provider:     provider,
+		Model:        model,
+		InputTokens:  inputTokens,
+		OutputTokens: outputTokens,
+	})
+	if err != nil {
+		return
+	}
+
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	if os.MkdirAll(filepath.Dir(path), 0755) != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+}
+
+// LoadUsage reads the usage log, skipping lines it can't parse
+func LoadUsage() ([]UsageRecord, error) {
+	path, err := usagePath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []UsageRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record UsageRecord
+		if json.Unmarshal(scanner.Bytes(), &record) == nil {
+			records = append(records, record)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// usageCost prices a record; ok is false when the model has no known price
+func usageCost(record UsageRecord, prices map[string][]float64) (cost float64, ok bool) {
+	price, ok := prices[record.Model]
+	if !ok {
+		price, ok = defaultPrices[record.Model]
+	}
+	if !ok {
+		return 0, false
+	}
+	return (float64(record.InputTokens)*price[0] + float64(record.OutputTokens)*price[1]) / 1e6, true
+}
+
+// UsageTotal sums the requests of a day or week
+type UsageTotal struct {
+	Start        time.Time
+	Requests     int
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+	Unpriced     []string // Models without a price, left out of Cost
+}
+
+// add counts a record into the total
+func (t *UsageTotal) add(record UsageRecord, prices map[string][]float64) {
+	t.Requests++
+	t.InputTokens += record.InputTokens
+	t.OutputTokens += record.OutputTokens
+	if cost, ok := usageCost(record, prices); ok {
+		t.Cost += cost
+	} else if !slices.Contains(t.Unpriced, record.Model) {
+		t.Unpriced = append(t.Unpriced, record.Model)
+	}
+}
+
+// SummarizeUsage totals the records of the last days days and weeks weeks
+// (weeks start on Monday) up to now, oldest first
+func SummarizeUsage(records []UsageRecord, now time.Time, days, weeks int, prices map[string][]float64) (daily, weekly []UsageTotal) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for i := days - 1; i >= 0; i-- {
+		daily = append(daily, UsageTotal{Start: today.AddDate(0, 0, -i)})
+	}
+	monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	for i := weeks - 1; i >= 0; i-- {
+		weekly = append(weekly, UsageTotal{Start: monday.AddDate(0, 0, -7*i)})
+	}
+
+	for _, record := range records {
+		t := record.Time.In(now.Location())
+		for i := range daily {
+			if !t.Before(daily[i].Start) && t.Before(daily[i].Start.AddDate(0, 0, 1)) {
+				daily[i].add(record, prices)
+			}
+		}
+		for i := range weekly {
+			if !t.Before(weekly[i].Start) && t.Before(weekly[i].Start.AddDate(0, 0, 7)) {
+				weekly[i].add(record, prices)
+			}
+		}
+	}
+	return daily, weekly
+}
+
+// formatCost shows dollars to the cent; + marks a total missing unpriced models
+func formatCost(total UsageTotal) string {
+	switch {
+	case total.Requests == 0:
+		return "-"
+	case total.Cost == 0 && len(total.Unpriced) > 0:
+		return "?"
+	}
+	cost := fmt.Sprintf("$%.2f", total.Cost)
+	if total.Cost > 0 && total.Cost < 0.01 {
+		cost = "<$0.01"
+	}
+	if len(total.Unpriced) > 0 {
+		cost += "+"
+	}
+	return cost
+}
+
+// runAIUsage prints the daily and weekly totals of hosted AI requests
+func runAIUsage(out io.Writer, days, weeks int) error {
+	records, err := LoadUsage()
+	if err != nil {
+		return fmt.Errorf("failed to read usage: %w", err)
+	}
+	if len(records) == 0 {
+		fmt.Fprintln(out, "No hosted AI requests recorded yet (Ollama runs locally and isn't counted)")
+		return nil
+	}
+
+	daily, weekly := SummarizeUsage(records, time.Now(), days, weeks, config.Prices)
+	var unpriced []string
+	table := func(title, layout string, totals []UsageTotal) {
+		fmt.Fprintf(out, "%s\n", title)
+		fmt.Fprintf(out, "  %-12s %8s %14s %14s %10s\n", "", "Requests", "Input tokens", "Output tokens", "Cost")
+		for _, total := range totals {
+			fmt.Fprintf(out, "  %-12s %8d %14d %14d %10s\n",
+				total.Start.Format(layout), total.Requests, total.InputTokens, total.OutputTokens, formatCost(total))
+			for _, model := range total.Unpriced {
+				if !slices.Contains(unpriced, model) {
+					unpriced = append(unpriced, model)
+				}
+			}
+		}
+	}
+	table("Daily", "Mon Jan 02", daily)
+	fmt.Fprintln(out)
+	table("Weekly (from Monday)", "2006-01-02", weekly)
+
+	if len(unpriced) > 0 {
+		slices.Sort(unpriced)
+		fmt.Fprintf(out, "\n⚠ No price for %s - add it under [prices] as [input, output] USD per million tokens\n", strings.Join(unpriced, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordUsage(t *testing.T) {
+	defer applyConfig(config)
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	cfg := defaultConfig()
+	applyConfig(cfg)
+	RecordUsage(providerAnthropic, "claude-haiku-4-5", 1000, 50)
+	RecordUsage(providerOpenAI, "gpt-4o-mini", 2000, 20)
+
+	cfg.AI.Usage = false
+	applyConfig(cfg)
+	RecordUsage(providerOpenAI, "gpt-4o-mini", 1, 1)
+
+	records, err := LoadUsage()
+	if err != nil {
+		t.Fatalf("LoadUsage failed: %v", err)
+	}
+	if len(records) != 2 || records[0].Provider != providerAnthropic || records[1].InputTokens != 2000 {
+		t.Errorf("Expected the two requests made with ai.usage on, got %+v", records)
+	}
+}
+
+func TestSummarizeUsage(t *testing.T) {
+	// Thursday
+	now := time.Date(2026, 10, 15, 18, 0, 0, 0, time.UTC)
+	records := []UsageRecord{
+		{Time: now.Add(-time.Hour), Model: "gpt-4o-mini", InputTokens: 1_000_000, OutputTokens: 1_000_000},
+		{Time: now.Add(-2 * time.Hour), Model: "local-llm", InputTokens: 10, OutputTokens: 5},
+		{Time: now.AddDate(0, 0, -1), Model: "gpt-4o", InputTokens: 100_000},
+		// Sunday of the week before
+		{Time: now.AddDate(0, 0, -4), Model: "custom", InputTokens: 1_000_000},
+		// Too old for either table
+		{Time: now.AddDate(0, 0, -30), Model: "gpt-4o", InputTokens: 1},
+	}
+	prices := map[string][]float64{"custom": {3, 0}}
+
+	daily, weekly := SummarizeUsage(records, now, 3, 2, prices)
+	if len(daily) != 3 || !daily[2].Start.Equal(time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected three days ending today, got %+v", daily)
+	}
+	if today := daily[2]; today.Requests != 2 || today.InputTokens != 1_000_010 || today.Cost != 0.75 || len(today.Unpriced) != 1 {
+		t.Errorf("Unexpected total for today: %+v", today)
+	}
+	if got := formatCost(daily[2]); got != "$0.75+" {
+		t.Errorf("Expected the cost marked as partial, got %q", got)
+	}
+	if got := formatCost(daily[1]); got != "$0.25" {
+		t.Errorf("Expected yesterday's gpt-4o cost, got %q", got)
+	}
+	if got := formatCost(daily[0]); got != "-" {
+		t.Errorf("Expected no cost without requests, got %q", got)
+	}
+
+	if len(weekly) != 2 || !weekly[1].Start.Equal(time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected weeks starting on Monday, got %+v", weekly)
+	}
+	if weekly[0].Requests != 1 || weekly[0].Cost != 3 || weekly[1].Requests != 3 {
+		t.Errorf("Unexpected weekly totals: %+v", weekly)
+	}
+}
+
+func TestRunAIUsage(t *testing.T) {
+	defer applyConfig(config)
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	applyConfig(defaultConfig())
+
+	var out strings.Builder
+	if err := runAIUsage(&out, 7, 4); err != nil || !strings.Contains(out.String(), "No hosted AI requests") {
+		t.Errorf("Expected a note without requests, got %q (%v)", out.String(), err)
+	}
+
+	RecordUsage(providerOpenAI, "my-model", 10, 2)
+	out.Reset()
+	if err := runAIUsage(&out, 7, 4); err != nil {
+		t.Fatalf("runAIUsage failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Weekly") || !strings.Contains(out.String(), "No price for my-model") {
+		t.Errorf("Expected the tables and a price hint, got:\n%s", out.String())
+	}
+}